@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	config "hunt-chat-api/configs"
 	"hunt-chat-api/pkg/handlers"
@@ -49,16 +50,77 @@ func setupApp() *gin.Engine {
 			log.Printf("FATAL: Failed to initialize VectorStoreService in Vercel function: %v", err)
 		}
 
+		siteRegistry, err := services.NewLocationRegistry(cfg.SitesFile, cfg.AMeDASStationsFile)
+		if err != nil {
+			log.Printf("⚠️ 店舗・拠点の読み込みに失敗したため、地域コードのみで動作します: %v", err)
+			siteRegistry, _ = services.NewLocationRegistry("", "")
+		}
+		owmConfig := config.GetOpenWeatherMapConfig()
+		weatherProviders, err := services.BuildWeatherProviders(services.WeatherProviderConfig{
+			Order:                 services.ParseWeatherProviderOrder(cfg.WeatherProviders),
+			JMABaseURL:            cfg.JMAForecastBaseURL,
+			OpenWeatherMapAPIKey:  owmConfig.APIKey,
+			OpenWeatherMapBaseURL: owmConfig.BaseURL,
+			CSVPath:               cfg.WeatherCSVPath,
+			FixtureDir:            cfg.WeatherFixtureDir,
+		})
+		if err != nil {
+			log.Printf("⚠️ 気象データの取得元の設定が不正なため、既定の取得元を使用します: %v", err)
+		}
+		gapFill, err := services.ParseWeatherGapFill(cfg.WeatherGapFill)
+		if err != nil {
+			log.Printf("⚠️ 気象データの補完方法の設定が不正なため、補完しません: %v", err)
+		}
+		weatherService := services.NewWeatherServiceWithConfig(services.WeatherServiceConfig{
+			Providers: weatherProviders,
+			RecordDir: cfg.WeatherRecordDir,
+			GapFill:   gapFill,
+			Sites:     siteRegistry,
+			Cache: services.NewWeatherCache(services.WeatherCacheConfig{
+				MaxEntries:    cfg.WeatherCacheMaxEntries,
+				ForecastTTL:   time.Duration(cfg.WeatherForecastCacheTTLMinutes) * time.Minute,
+				HistoricalTTL: time.Duration(cfg.WeatherHistoryCacheTTLHours) * time.Hour,
+				PersistPath:   cfg.WeatherCachePath,
+				FlushDelay:    time.Duration(cfg.WeatherCacheFlushSeconds) * time.Second,
+			}),
+			Archive: services.NewForecastArchive(services.ForecastArchiveConfig{
+				PersistPath: cfg.WeatherForecastArchivePath,
+				Retention:   time.Duration(cfg.WeatherForecastArchiveDays) * 24 * time.Hour,
+			}),
+		})
+		// サーバーレス環境では常駐のタイマーが動かないため、予報の検証ジョブは起動しない
+		// 検証は POST /api/v1/admin/weather-forecast-archive/verify を定期的に呼び出して実行する（Vercel Cron など）
+
 		// ハンドラーの初期化
-		weatherHandler := handlers.NewWeatherHandler()
-		demandForecastHandler := handlers.NewDemandForecastHandler(weatherHandler.GetWeatherService())
+		weatherHandler := handlers.NewWeatherHandler(weatherService)
+		siteHandler := handlers.NewSiteHandler(siteRegistry)
 		economicSymbolMapping := map[string]string{
 			"NIKKEI": "moc/nikkei_daily.csv",
 		}
 		economicService := services.NewEconomicService(".", economicSymbolMapping)
+		hybridSearchService := services.NewHybridSearchService(vectorStoreService, services.HybridSearchConfig{
+			VectorWeight:   cfg.RAGVectorWeight,
+			LexicalWeight:  cfg.RAGLexicalWeight,
+			FusionK:        cfg.RAGFusionK,
+			TokenBudget:    cfg.RAGContextTokenBudget,
+			RerankMode:     cfg.RAGRerankMode,
+			DedupThreshold: cfg.RAGDedupThreshold,
+		})
+		conversationMemoryService := services.NewConversationMemoryService(vectorStoreService, azureOpenAIService, services.ConversationMemoryConfig{
+			RecentTurns:     cfg.ChatMemoryRecentTurns,
+			SummaryInterval: cfg.ChatMemorySummaryInterval,
+		})
+		followUpScheduler := services.NewFollowUpScheduler(vectorStoreService, services.ParseFollowUpOffsets(cfg.FollowUpOffsets))
+		webhookService := services.NewWebhookService(vectorStoreService, services.WebhookConfig{
+			MaxAttempts:    cfg.WebhookMaxAttempts,
+			InitialBackoff: time.Duration(cfg.WebhookInitialBackoffSeconds * float64(time.Second)),
+			Timeout:        time.Duration(cfg.WebhookTimeoutSeconds) * time.Second,
+		})
+		demandForecastHandler := handlers.NewDemandForecastHandler(weatherHandler.GetWeatherService())
 		economicHandler := handlers.NewEconomicHandler(vectorStoreService)
-		aiHandler := handlers.NewAIHandler(azureOpenAIService, weatherHandler.GetWeatherService(), economicService, demandForecastHandler.GetDemandForecastService(), vectorStoreService)
+		aiHandler := handlers.NewAIHandler(azureOpenAIService, weatherHandler.GetWeatherService(), economicService, demandForecastHandler.GetDemandForecastService(), vectorStoreService, hybridSearchService, conversationMemoryService, followUpScheduler, webhookService)
 		adminHandler := handlers.NewAdminHandler(cfg)
+		webhookHandler := handlers.NewWebhookHandler(webhookService)
 		monitoringHandler := handlers.NewMonitoringHandler(monitoringService)
 
 		// ミドルウェアの登録
@@ -106,6 +168,17 @@ func setupApp() *gin.Engine {
 				admin.GET("/health-status", adminHandler.GetHealthStatus)
 				admin.POST("/maintenance/start", adminHandler.StartMaintenance)
 				admin.POST("/maintenance/stop", adminHandler.StopMaintenance)
+				admin.GET("/webhooks", webhookHandler.ListSubscriptions)
+				admin.POST("/webhooks", webhookHandler.CreateSubscription)
+				admin.PUT("/webhooks/:id", webhookHandler.UpdateSubscription)
+				admin.DELETE("/webhooks/:id", webhookHandler.DeleteSubscription)
+				admin.POST("/webhooks/:id/test", webhookHandler.TestSubscription)
+				admin.GET("/webhooks/dead-letters", webhookHandler.ListDeadLetters)
+				admin.POST("/webhooks/dead-letters/:id/replay", webhookHandler.ReplayDeadLetter)
+				admin.GET("/weather-cache", weatherHandler.GetCacheStats)
+				admin.DELETE("/weather-cache", weatherHandler.PurgeCache)
+				admin.GET("/weather-forecast-archive", weatherHandler.GetForecastArchiveStats)
+				admin.POST("/weather-forecast-archive/verify", weatherHandler.VerifyForecasts)
 			}
 
 			// モニタリングAPI
@@ -122,25 +195,50 @@ func setupApp() *gin.Engine {
 				weather.GET("/forecast/:regionCode", weatherHandler.GetForecastData)
 				weather.GET("/forecast", weatherHandler.GetForecastData)
 				weather.GET("/tokyo", weatherHandler.GetTokyoWeatherData)
+				weather.GET("/daily-forecast/:regionCode", weatherHandler.GetDailyForecasts)
 				weather.GET("/region/:regionCode", weatherHandler.GetWeatherByRegion)
 				weather.GET("/historical/:regionCode", weatherHandler.GetHistoricalWeatherData)
 				weather.GET("/historical", weatherHandler.GetHistoricalWeatherData)
 				weather.GET("/historical/:regionCode/date", weatherHandler.GetHistoricalWeatherDataByDate)
 				weather.GET("/historical/:regionCode/range", weatherHandler.GetHistoricalWeatherDataRange)
 				weather.GET("/historical-range", weatherHandler.GetAvailableHistoricalDataRange)
-				weather.GET("/suzuka/monthly", weatherHandler.GetSuzukaMonthlyWeatherSummary)
+				weather.GET("/suzuka/monthly", weatherHandler.GetSiteMonthlyWeatherSummary)
+				weather.GET("/sites/:siteId/monthly", weatherHandler.GetSiteMonthlyWeatherSummary)
 				weather.GET("/analysis/:regionCode", weatherHandler.GetWeatherDataAnalysis)
 				weather.GET("/analysis", weatherHandler.GetWeatherDataAnalysis)
+				weather.GET("/features/:regionCode", weatherHandler.GetWeatherFeatures)
+				weather.GET("/features", weatherHandler.GetWeatherFeatures)
+				weather.GET("/quality/:regionCode", weatherHandler.GetWeatherQuality)
+				weather.GET("/quality", weatherHandler.GetWeatherQuality)
+				weather.GET("/climatology/:regionCode", weatherHandler.GetClimatology)
+				weather.GET("/climatology", weatherHandler.GetClimatology)
+				weather.GET("/anomalies/:regionCode", weatherHandler.GetWeatherAnomalies)
+				weather.GET("/anomalies", weatherHandler.GetWeatherAnomalies)
+				weather.GET("/forecast-archive/:regionCode", weatherHandler.GetForecastArchive)
+				weather.GET("/forecast-archive", weatherHandler.GetForecastArchive)
+				weather.GET("/forecast-skill/:regionCode", weatherHandler.GetForecastSkill)
+				weather.GET("/forecast-skill", weatherHandler.GetForecastSkill)
 				weather.GET("/trends/:regionCode", weatherHandler.GetWeatherTrendAnalysis)
 				weather.GET("/trends", weatherHandler.GetWeatherTrendAnalysis)
 				weather.GET("/category/:regionCode", weatherHandler.GetWeatherDataByCategory)
 				weather.GET("/category", weatherHandler.GetWeatherDataByCategory)
 			}
 
+			sites := v1.Group("/sites")
+			{
+				sites.GET("", siteHandler.ListSites)
+				sites.POST("", siteHandler.CreateSite)
+				sites.GET("/nearest-station", siteHandler.GetNearestStation)
+				sites.GET("/:siteId", siteHandler.GetSite)
+				sites.PUT("/:siteId", siteHandler.UpdateSite)
+				sites.DELETE("/:siteId", siteHandler.DeleteSite)
+			}
+
 			// 需要予測API
 			demand := v1.Group("/demand")
 			{
 				demand.POST("/forecast", demandForecastHandler.PredictDemand)
+				demand.POST("/scenarios", demandForecastHandler.SimulateScenarios)
 				demand.GET("/forecast/suzuka", demandForecastHandler.GetDemandForecastForSuzuka)
 				demand.GET("/settings", demandForecastHandler.GetDemandForecastSettings)
 				demand.GET("/insights/:regionCode", demandForecastHandler.GetDemandInsights)
@@ -170,6 +268,7 @@ func setupApp() *gin.Engine {
 				ai.POST("/forecast-product", aiHandler.ForecastProductDemand)
 				ai.POST("/analyze-weekly", aiHandler.AnalyzeWeeklySales)
 				ai.POST("/detect-anomalies", aiHandler.DetectAnomaliesInSales)
+				ai.POST("/detect-regime-shifts", aiHandler.DetectRegimeShifts)
 				ai.POST("/anomaly-response", aiHandler.SaveAnomalyResponse)
 				ai.POST("/anomaly-response-with-followup", aiHandler.SaveAnomalyResponseWithFollowUp)
 				ai.GET("/anomaly-responses", aiHandler.GetAnomalyResponses)
@@ -181,6 +280,30 @@ func setupApp() *gin.Engine {
 				ai.DELETE("/analysis-report", aiHandler.DeleteAnalysisReport)
 				ai.DELETE("/analysis-reports", aiHandler.DeleteAllAnalysisReports)
 				ai.GET("/unanswered-anomalies", aiHandler.GetUnansweredAnomalies)
+				ai.GET("/chat-sessions", aiHandler.ListChatSessions)
+				ai.GET("/chat-sessions/:session_id", aiHandler.GetChatSessionTranscript)
+				ai.DELETE("/chat-sessions/:session_id", aiHandler.DeleteChatSession)
+				ai.POST("/anomaly-interview/start", aiHandler.StartAnomalyInterview)
+				ai.POST("/anomaly-interview/answer", aiHandler.AnswerAnomalyInterview)
+				ai.GET("/anomaly-interview/:session_id", aiHandler.GetAnomalyInterview)
+				ai.GET("/follow-ups/pending", aiHandler.GetPendingFollowUps)
+				ai.GET("/follow-ups", aiHandler.ListFollowUps)
+				ai.PUT("/follow-ups/:id/status", aiHandler.UpdateFollowUpStatus)
+				ai.POST("/follow-ups/schedule/:session_id", aiHandler.ScheduleSessionFollowUps)
+				ai.GET("/question-analytics", aiHandler.GetQuestionAnalytics)
+				ai.POST("/question-analytics/backfill", aiHandler.BackfillAnswerQuality)
+				ai.GET("/events", aiHandler.ListBusinessEvents)
+				ai.GET("/events/:id", aiHandler.GetBusinessEvent)
+				ai.POST("/events", aiHandler.CreateBusinessEvent)
+				ai.PUT("/events/:id", aiHandler.UpdateBusinessEvent)
+				ai.DELETE("/events/:id", aiHandler.DeleteBusinessEvent)
+				ai.POST("/events/ingest", aiHandler.IngestBusinessEvents)
+				ai.GET("/anomalies", aiHandler.ListAnomalies)
+				ai.GET("/anomalies/:id", aiHandler.GetAnomaly)
+				ai.PUT("/anomalies/:id/status", aiHandler.TransitionAnomaly)
+				ai.PUT("/anomalies/:id/assignee", aiHandler.AssignAnomaly)
+				ai.POST("/anomalies/:id/comments", aiHandler.AddAnomalyComment)
+				ai.POST("/anomalies/sync", aiHandler.SyncAnomalies)
 			}
 
 			// 経済/金融データAPI（CSV疑似yfinance）
@@ -1,8 +1,14 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	config "hunt-chat-api/configs"
 	"hunt-chat-api/pkg/handlers"
@@ -42,13 +48,74 @@ func main() {
 		"NIKKEI": "moc/nikkei_daily.csv",
 	}
 	economicService := services.NewEconomicService("", economicSymbolMapping)
+	hybridSearchService := services.NewHybridSearchService(vectorStoreService, services.HybridSearchConfig{
+		VectorWeight:   cfg.RAGVectorWeight,
+		LexicalWeight:  cfg.RAGLexicalWeight,
+		FusionK:        cfg.RAGFusionK,
+		TokenBudget:    cfg.RAGContextTokenBudget,
+		RerankMode:     cfg.RAGRerankMode,
+		DedupThreshold: cfg.RAGDedupThreshold,
+	})
+	conversationMemoryService := services.NewConversationMemoryService(vectorStoreService, azureOpenAIService, services.ConversationMemoryConfig{
+		RecentTurns:     cfg.ChatMemoryRecentTurns,
+		SummaryInterval: cfg.ChatMemorySummaryInterval,
+	})
+	followUpScheduler := services.NewFollowUpScheduler(vectorStoreService, services.ParseFollowUpOffsets(cfg.FollowUpOffsets))
+	webhookService := services.NewWebhookService(vectorStoreService, services.WebhookConfig{
+		MaxAttempts:    cfg.WebhookMaxAttempts,
+		InitialBackoff: time.Duration(cfg.WebhookInitialBackoffSeconds * float64(time.Second)),
+		Timeout:        time.Duration(cfg.WebhookTimeoutSeconds) * time.Second,
+	})
+
+	siteRegistry, err := services.NewLocationRegistry(cfg.SitesFile, cfg.AMeDASStationsFile)
+	if err != nil {
+		log.Printf("⚠️ 店舗・拠点の読み込みに失敗したため、地域コードのみで動作します: %v", err)
+		siteRegistry, _ = services.NewLocationRegistry("", "")
+	}
+	owmConfig := config.GetOpenWeatherMapConfig()
+	weatherProviders, err := services.BuildWeatherProviders(services.WeatherProviderConfig{
+		Order:                 services.ParseWeatherProviderOrder(cfg.WeatherProviders),
+		JMABaseURL:            cfg.JMAForecastBaseURL,
+		OpenWeatherMapAPIKey:  owmConfig.APIKey,
+		OpenWeatherMapBaseURL: owmConfig.BaseURL,
+		CSVPath:               cfg.WeatherCSVPath,
+		FixtureDir:            cfg.WeatherFixtureDir,
+	})
+	if err != nil {
+		log.Printf("⚠️ 気象データの取得元の設定が不正なため、既定の取得元を使用します: %v", err)
+	}
+	gapFill, err := services.ParseWeatherGapFill(cfg.WeatherGapFill)
+	if err != nil {
+		log.Printf("⚠️ 気象データの補完方法の設定が不正なため、補完しません: %v", err)
+	}
+	weatherService := services.NewWeatherServiceWithConfig(services.WeatherServiceConfig{
+		Providers: weatherProviders,
+		RecordDir: cfg.WeatherRecordDir,
+		GapFill:   gapFill,
+		Sites:     siteRegistry,
+		Cache: services.NewWeatherCache(services.WeatherCacheConfig{
+			MaxEntries:    cfg.WeatherCacheMaxEntries,
+			ForecastTTL:   time.Duration(cfg.WeatherForecastCacheTTLMinutes) * time.Minute,
+			HistoricalTTL: time.Duration(cfg.WeatherHistoryCacheTTLHours) * time.Hour,
+			PersistPath:   cfg.WeatherCachePath,
+			FlushDelay:    time.Duration(cfg.WeatherCacheFlushSeconds) * time.Second,
+		}),
+		Archive: services.NewForecastArchive(services.ForecastArchiveConfig{
+			PersistPath: cfg.WeatherForecastArchivePath,
+			Retention:   time.Duration(cfg.WeatherForecastArchiveDays) * 24 * time.Hour,
+		}),
+	})
+	// 対象日を過ぎた予報を定期的に観測値と比較する
+	weatherService.StartForecastVerification(time.Duration(cfg.WeatherForecastVerifyMinutes) * time.Minute)
 
 	// ハンドラーの初期化
-	weatherHandler := handlers.NewWeatherHandler()
+	weatherHandler := handlers.NewWeatherHandler(weatherService)
+	siteHandler := handlers.NewSiteHandler(siteRegistry)
 	demandForecastHandler := handlers.NewDemandForecastHandler(weatherHandler.GetWeatherService())
-	aiHandler := handlers.NewAIHandler(azureOpenAIService, weatherHandler.GetWeatherService(), economicService, demandForecastHandler.GetDemandForecastService(), vectorStoreService)
+	aiHandler := handlers.NewAIHandler(azureOpenAIService, weatherHandler.GetWeatherService(), economicService, demandForecastHandler.GetDemandForecastService(), vectorStoreService, hybridSearchService, conversationMemoryService, followUpScheduler, webhookService)
 	economicHandler := handlers.NewEconomicHandler(vectorStoreService)
 	adminHandler := handlers.NewAdminHandler(cfg)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
 	monitoringHandler := handlers.NewMonitoringHandler(monitoringService)
 
 	// ミドルウェアの登録
@@ -90,6 +157,17 @@ func main() {
 			admin.GET("/health-status", adminHandler.GetHealthStatus)
 			admin.POST("/maintenance/start", adminHandler.StartMaintenance)
 			admin.POST("/maintenance/stop", adminHandler.StopMaintenance)
+			admin.GET("/webhooks", webhookHandler.ListSubscriptions)                         // Webhook購読一覧
+			admin.POST("/webhooks", webhookHandler.CreateSubscription)                       // Webhook購読の作成
+			admin.PUT("/webhooks/:id", webhookHandler.UpdateSubscription)                    // Webhook購読の更新
+			admin.DELETE("/webhooks/:id", webhookHandler.DeleteSubscription)                 // Webhook購読の削除
+			admin.POST("/webhooks/:id/test", webhookHandler.TestSubscription)                // テスト通知の送信
+			admin.GET("/webhooks/dead-letters", webhookHandler.ListDeadLetters)              // 配信に失敗したWebhook一覧
+			admin.POST("/webhooks/dead-letters/:id/replay", webhookHandler.ReplayDeadLetter) // 配信に失敗したWebhookの再送
+			admin.GET("/weather-cache", weatherHandler.GetCacheStats)                        // 気象データキャッシュの利用状況
+			admin.DELETE("/weather-cache", weatherHandler.PurgeCache)                        // 気象データキャッシュの削除
+			admin.GET("/weather-forecast-archive", weatherHandler.GetForecastArchiveStats)   // 予報アーカイブの件数
+			admin.POST("/weather-forecast-archive/verify", weatherHandler.VerifyForecasts)   // 予報の検証ジョブを今すぐ実行
 		}
 
 		// モニタリングAPI
@@ -106,6 +184,7 @@ func main() {
 			weather.GET("/forecast/:regionCode", weatherHandler.GetForecastData)
 			weather.GET("/forecast", weatherHandler.GetForecastData) // デフォルト：東京
 			weather.GET("/tokyo", weatherHandler.GetTokyoWeatherData)
+			weather.GET("/daily-forecast/:regionCode", weatherHandler.GetDailyForecasts) // 日別・予報区別の予報
 			weather.GET("/region/:regionCode", weatherHandler.GetWeatherByRegion)
 
 			// 過去データAPI
@@ -115,20 +194,45 @@ func main() {
 			weather.GET("/historical/:regionCode/range", weatherHandler.GetHistoricalWeatherDataRange)
 			weather.GET("/historical-range", weatherHandler.GetAvailableHistoricalDataRange)
 
-			// 三重県鈴鹿市専用API
-			weather.GET("/suzuka/monthly", weatherHandler.GetSuzukaMonthlyWeatherSummary)
+			// 月次サマリー・分析API
+			weather.GET("/suzuka/monthly", weatherHandler.GetSiteMonthlyWeatherSummary)        // 既定の拠点（鈴鹿市）の月次サマリー
+			weather.GET("/sites/:siteId/monthly", weatherHandler.GetSiteMonthlyWeatherSummary) // 店舗・拠点別の月次サマリー
 			weather.GET("/analysis/:regionCode", weatherHandler.GetWeatherDataAnalysis)
-			weather.GET("/analysis", weatherHandler.GetWeatherDataAnalysis) // デフォルト：三重県
+			weather.GET("/analysis", weatherHandler.GetWeatherDataAnalysis)         // デフォルト：三重県
+			weather.GET("/features/:regionCode", weatherHandler.GetWeatherFeatures) // 派生特徴量（不快指数・冷暖房度日など）
+			weather.GET("/features", weatherHandler.GetWeatherFeatures)
+			weather.GET("/quality/:regionCode", weatherHandler.GetWeatherQuality) // 欠測・異常値の検証と補完内容
+			weather.GET("/quality", weatherHandler.GetWeatherQuality)
+			weather.GET("/climatology/:regionCode", weatherHandler.GetClimatology) // 日ごとの平年値（平均・パーセンタイル）
+			weather.GET("/climatology", weatherHandler.GetClimatology)
+			weather.GET("/anomalies/:regionCode", weatherHandler.GetWeatherAnomalies) // 平年と比べた天候の珍しさ
+			weather.GET("/anomalies", weatherHandler.GetWeatherAnomalies)
+			weather.GET("/forecast-archive/:regionCode", weatherHandler.GetForecastArchive) // 保存した予報と観測値との比較
+			weather.GET("/forecast-archive", weatherHandler.GetForecastArchive)
+			weather.GET("/forecast-skill/:regionCode", weatherHandler.GetForecastSkill) // リードタイム別の予報精度
+			weather.GET("/forecast-skill", weatherHandler.GetForecastSkill)
 			weather.GET("/trends/:regionCode", weatherHandler.GetWeatherTrendAnalysis)
 			weather.GET("/trends", weatherHandler.GetWeatherTrendAnalysis) // デフォルト：三重県
 			weather.GET("/category/:regionCode", weatherHandler.GetWeatherDataByCategory)
 			weather.GET("/category", weatherHandler.GetWeatherDataByCategory) // デフォルト：三重県
 		}
 
+		// 店舗・拠点API
+		sites := v1.Group("/sites")
+		{
+			sites.GET("", siteHandler.ListSites)
+			sites.POST("", siteHandler.CreateSite)
+			sites.GET("/nearest-station", siteHandler.GetNearestStation) // 座標から最寄りのアメダス観測所
+			sites.GET("/:siteId", siteHandler.GetSite)
+			sites.PUT("/:siteId", siteHandler.UpdateSite)
+			sites.DELETE("/:siteId", siteHandler.DeleteSite)
+		}
+
 		// 需要予測API
 		demand := v1.Group("/demand")
 		{
 			demand.POST("/forecast", demandForecastHandler.PredictDemand)
+			demand.POST("/scenarios", demandForecastHandler.SimulateScenarios) // 天候シナリオ別の需要予測の比較
 			demand.GET("/forecast/suzuka", demandForecastHandler.GetDemandForecastForSuzuka)
 			demand.GET("/settings", demandForecastHandler.GetDemandForecastSettings)
 			demand.GET("/insights/:regionCode", demandForecastHandler.GetDemandInsights)
@@ -151,6 +255,7 @@ func main() {
 			ai.POST("/analyze-file", aiHandler.AnalyzeFile)
 			ai.POST("/predict-sales", aiHandler.PredictSales)                                     // 売上予測API
 			ai.POST("/detect-anomalies", aiHandler.DetectAnomaliesInSales)                        // 異常検知API
+			ai.POST("/detect-regime-shifts", aiHandler.DetectRegimeShifts)                        // 水準変化（変化点）検出API
 			ai.POST("/forecast-product", aiHandler.ForecastProductDemand)                         // 製品別需要予測API
 			ai.POST("/analyze-weekly", aiHandler.AnalyzeWeeklySales)                              // 週次分析API
 			ai.POST("/anomaly-response", aiHandler.SaveAnomalyResponse)                           // 異常への回答保存API
@@ -164,6 +269,30 @@ func main() {
 			ai.DELETE("/anomaly-response/:id", aiHandler.DeleteAnomalyResponse)                   // 回答削除API
 			ai.DELETE("/anomaly-responses", aiHandler.DeleteAllAnomalyResponses)                  // 全回答削除API
 			ai.GET("/unanswered-anomalies", aiHandler.GetUnansweredAnomalies)                     // 未回答の異常を取得
+			ai.GET("/chat-sessions", aiHandler.ListChatSessions)                                  // チャットセッション一覧
+			ai.GET("/chat-sessions/:session_id", aiHandler.GetChatSessionTranscript)              // セッションのトランスクリプト
+			ai.DELETE("/chat-sessions/:session_id", aiHandler.DeleteChatSession)                  // セッション削除
+			ai.POST("/anomaly-interview/start", aiHandler.StartAnomalyInterview)                  // 仮説検証型の異常インタビュー開始
+			ai.POST("/anomaly-interview/answer", aiHandler.AnswerAnomalyInterview)                // 検証質問への回答
+			ai.GET("/anomaly-interview/:session_id", aiHandler.GetAnomalyInterview)               // インタビューの状態・結論取得
+			ai.GET("/follow-ups/pending", aiHandler.GetPendingFollowUps)                          // 予定日を迎えたフォローアップ質問
+			ai.GET("/follow-ups", aiHandler.ListFollowUps)                                        // フォローアップ質問一覧
+			ai.PUT("/follow-ups/:id/status", aiHandler.UpdateFollowUpStatus)                      // フォローアップ質問の状態更新
+			ai.POST("/follow-ups/schedule/:session_id", aiHandler.ScheduleSessionFollowUps)       // 完了済みセッションのフォローアップ計画
+			ai.GET("/question-analytics", aiHandler.GetQuestionAnalytics)                         // 質問テンプレート・仮説カテゴリ別の質問効果
+			ai.POST("/question-analytics/backfill", aiHandler.BackfillAnswerQuality)              // 過去の回答の品質分析
+			ai.GET("/events", aiHandler.ListBusinessEvents)                                       // イベント知識ベース一覧
+			ai.GET("/events/:id", aiHandler.GetBusinessEvent)                                     // イベント取得
+			ai.POST("/events", aiHandler.CreateBusinessEvent)                                     // イベント登録
+			ai.PUT("/events/:id", aiHandler.UpdateBusinessEvent)                                  // イベント更新
+			ai.DELETE("/events/:id", aiHandler.DeleteBusinessEvent)                               // イベント削除
+			ai.POST("/events/ingest", aiHandler.IngestBusinessEvents)                             // 過去の回答をイベント化
+			ai.GET("/anomalies", aiHandler.ListAnomalies)                                         // 登録済みの異常一覧
+			ai.GET("/anomalies/:id", aiHandler.GetAnomaly)                                        // 異常の取得
+			ai.PUT("/anomalies/:id/status", aiHandler.TransitionAnomaly)                          // 異常の状態遷移
+			ai.PUT("/anomalies/:id/assignee", aiHandler.AssignAnomaly)                            // 担当者の割り当て
+			ai.POST("/anomalies/:id/comments", aiHandler.AddAnomalyComment)                       // コメント追加
+			ai.POST("/anomalies/sync", aiHandler.SyncAnomalies)                                   // 既存レポートの異常を登録
 		}
 
 		// 経済/金融データAPI（CSV疑似yfinance）
@@ -185,8 +314,26 @@ func main() {
 	}
 
 	log.Println("Starting HUNT Chat-API server on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	srv := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// 終了シグナルを受けたら処理中のリクエストを待ってから、未保存の気象データキャッシュを書き出す
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	log.Println("🛑 サーバーを終了します")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ サーバーの終了処理に失敗: %v", err)
+	}
+	if err := weatherService.Cache().Flush(); err != nil {
+		log.Printf("⚠️ 気象データキャッシュの保存に失敗: %v", err)
 	}
 }
 
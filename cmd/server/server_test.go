@@ -52,9 +52,12 @@ func TestApplicationSetup(t *testing.T) {
 	var vectorStoreService *services.VectorStoreService // テスト中はnilを許容	assert.NotNil(t, vectorStoreService, "VectorStoreService should not be nil")
 
 	// ハンドラーの初期化テスト
-	weatherHandler := handlers.NewWeatherHandler()
+	weatherHandler := handlers.NewWeatherHandler(nil)
 	assert.NotNil(t, weatherHandler, "WeatherHandler should not be nil")
 
+	webhookService := services.NewWebhookService(vectorStoreService, services.WebhookConfig{MaxAttempts: cfg.WebhookMaxAttempts})
+	assert.False(t, webhookService.Available(), "WebhookService should be unavailable without a vector store")
+
 	demandForecastHandler := handlers.NewDemandForecastHandler(weatherHandler.GetWeatherService())
 	assert.NotNil(t, demandForecastHandler, "DemandForecastHandler should not be nil")
 
@@ -64,7 +67,26 @@ func TestApplicationSetup(t *testing.T) {
 	}
 	economicService := services.NewEconomicService(".", economicSymbolMapping)
 
-	aiHandler := handlers.NewAIHandler(azureOpenAIService, weatherHandler.GetWeatherService(), economicService, demandForecastHandler.GetDemandForecastService(), vectorStoreService)
+	hybridSearchService := services.NewHybridSearchService(vectorStoreService, services.HybridSearchConfig{
+		VectorWeight:   cfg.RAGVectorWeight,
+		LexicalWeight:  cfg.RAGLexicalWeight,
+		FusionK:        cfg.RAGFusionK,
+		TokenBudget:    cfg.RAGContextTokenBudget,
+		RerankMode:     cfg.RAGRerankMode,
+		DedupThreshold: cfg.RAGDedupThreshold,
+	})
+	assert.NotNil(t, hybridSearchService, "HybridSearchService should not be nil")
+
+	conversationMemoryService := services.NewConversationMemoryService(vectorStoreService, azureOpenAIService, services.ConversationMemoryConfig{
+		RecentTurns:     cfg.ChatMemoryRecentTurns,
+		SummaryInterval: cfg.ChatMemorySummaryInterval,
+	})
+	assert.NotNil(t, conversationMemoryService, "ConversationMemoryService should not be nil")
+
+	followUpScheduler := services.NewFollowUpScheduler(vectorStoreService, services.ParseFollowUpOffsets(cfg.FollowUpOffsets))
+	assert.NotEmpty(t, followUpScheduler.Offsets(), "FollowUpScheduler should have offsets")
+
+	aiHandler := handlers.NewAIHandler(azureOpenAIService, weatherHandler.GetWeatherService(), economicService, demandForecastHandler.GetDemandForecastService(), vectorStoreService, hybridSearchService, conversationMemoryService, followUpScheduler, webhookService)
 	assert.NotNil(t, aiHandler, "AIHandler should not be nil")
 }
 
@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strconv"
 )
 
 // Config holds the application configuration
@@ -20,6 +21,34 @@ type Config struct {
 	APIKey                             string
 	AdminUsername                      string
 	AdminPassword                      string
+	RAGVectorWeight                    float64 // ハイブリッド検索におけるベクトル検索の重み
+	RAGLexicalWeight                   float64 // ハイブリッド検索におけるBM25検索の重み
+	RAGFusionK                         int     // Reciprocal Rank Fusion の定数k
+	RAGContextTokenBudget              int     // RAGコンテキストのトークン予算
+	RAGRerankMode                      string  // リランキング方式（heuristic / llm）
+	RAGDedupThreshold                  float64 // 重複チャンク判定のJaccard係数閾値
+	ChatMemoryRecentTurns              int     // プロンプトに常に含める直近の発話数
+	ChatMemorySummaryInterval          int     // 未要約の古い発話がこの数に達したら要約を更新
+	FollowUpOffsets                    string  // フォローアップ質問の種類と異常発生日からの日数（例: short_term_effect=14,yearly_review=365）
+	WebhookMaxAttempts                 int     // Webhook配信の最大試行回数（初回を含む）
+	WebhookInitialBackoffSeconds       float64 // Webhook配信の初回リトライまでの秒数（以降は2倍ずつ増やす）
+	WebhookTimeoutSeconds              int     // Webhook配信1回あたりのタイムアウト秒数
+	WeatherProviders                   string  // 気象データの取得元の優先順（jma / openweathermap / csv / fixture / mock をカンマ区切り）
+	JMAForecastBaseURL                 string  // 気象庁予報APIのベースURL（空の場合は気象庁）
+	WeatherCSVPath                     string  // csv 取得元の読み込みファイル
+	WeatherFixtureDir                  string  // fixture 取得元の読み込みディレクトリ
+	WeatherRecordDir                   string  // 取得した気象データを fixture 形式で記録するディレクトリ（空の場合は記録しない）
+	WeatherCacheMaxEntries             int     // 気象データキャッシュに保持する日数（地域×日×種類）の上限
+	WeatherForecastCacheTTLMinutes     int     // 予報のキャッシュ有効期間（分）
+	WeatherHistoryCacheTTLHours        int     // 観測済みの過去データのキャッシュ有効期間（時間）
+	WeatherCachePath                   string  // 気象データキャッシュの保存先ファイル（空の場合はメモリのみ）
+	WeatherCacheFlushSeconds           int     // キャッシュの変更をファイルへまとめて書き出すまでの待ち時間（秒）
+	WeatherGapFill                     string  // 気象データの欠測・異常値の補完方法の優先順（linear / climatology / nearest_station をカンマ区切り、none で補完しない）
+	WeatherForecastArchivePath         string  // 取得した予報のアーカイブの保存先ファイル（空の場合はメモリのみ）
+	WeatherForecastArchiveDays         int     // 予報アーカイブの保存期間（対象日からの日数）
+	WeatherForecastVerifyMinutes       int     // 予報を観測値と比較する検証ジョブの実行間隔（分、0で定期実行しない）
+	SitesFile                          string  // 店舗・拠点の登録簿（JSON。CRUD APIでの変更も書き戻す）
+	AMeDASStationsFile                 string  // アメダス観測所一覧（気象庁の amedastable.json 形式）
 }
 
 // LoadConfig loads configuration from environment variables
@@ -39,6 +68,34 @@ func LoadConfig() *Config {
 		APIKey:                             getEnv("API_KEY", "default_secret_key"), // 開発用のデフォルトキー
 		AdminUsername:                      getEnv("ADMIN_USERNAME", "admin"),
 		AdminPassword:                      getEnv("ADMIN_PASSWORD", "admin_password"),
+		RAGVectorWeight:                    getEnvFloat("RAG_VECTOR_WEIGHT", 1.0),
+		RAGLexicalWeight:                   getEnvFloat("RAG_LEXICAL_WEIGHT", 1.0),
+		RAGFusionK:                         getEnvInt("RAG_FUSION_K", 60),
+		RAGContextTokenBudget:              getEnvInt("RAG_CONTEXT_TOKEN_BUDGET", 3000),
+		RAGRerankMode:                      getEnv("RAG_RERANK_MODE", "heuristic"),
+		RAGDedupThreshold:                  getEnvFloat("RAG_DEDUP_THRESHOLD", 0.85),
+		ChatMemoryRecentTurns:              getEnvInt("CHAT_MEMORY_RECENT_TURNS", 6),
+		ChatMemorySummaryInterval:          getEnvInt("CHAT_MEMORY_SUMMARY_INTERVAL", 10),
+		FollowUpOffsets:                    getEnv("FOLLOW_UP_OFFSETS", "short_term_effect=14,medium_term_effect=60,yearly_review=365"),
+		WebhookMaxAttempts:                 getEnvInt("WEBHOOK_MAX_ATTEMPTS", 5),
+		WebhookInitialBackoffSeconds:       getEnvFloat("WEBHOOK_INITIAL_BACKOFF_SECONDS", 2),
+		WebhookTimeoutSeconds:              getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+		WeatherProviders:                   getEnv("WEATHER_PROVIDERS", "jma,mock"),
+		JMAForecastBaseURL:                 getEnv("JMA_FORECAST_BASE_URL", ""),
+		WeatherCSVPath:                     getEnv("WEATHER_CSV_PATH", ""),
+		WeatherFixtureDir:                  getEnv("WEATHER_FIXTURE_DIR", ""),
+		WeatherRecordDir:                   getEnv("WEATHER_RECORD_DIR", ""),
+		WeatherCacheMaxEntries:             getEnvInt("WEATHER_CACHE_MAX_ENTRIES", 20000),
+		WeatherForecastCacheTTLMinutes:     getEnvInt("WEATHER_FORECAST_CACHE_TTL_MINUTES", 180),
+		WeatherHistoryCacheTTLHours:        getEnvInt("WEATHER_HISTORY_CACHE_TTL_HOURS", 168),
+		WeatherCachePath:                   getEnv("WEATHER_CACHE_PATH", ""),
+		WeatherCacheFlushSeconds:           getEnvInt("WEATHER_CACHE_FLUSH_SECONDS", 30),
+		WeatherGapFill:                     getEnv("WEATHER_GAP_FILL", "linear"),
+		WeatherForecastArchivePath:         getEnv("WEATHER_FORECAST_ARCHIVE_PATH", ""),
+		WeatherForecastArchiveDays:         getEnvInt("WEATHER_FORECAST_ARCHIVE_DAYS", 400),
+		WeatherForecastVerifyMinutes:       getEnvInt("WEATHER_FORECAST_VERIFY_INTERVAL_MINUTES", 360),
+		SitesFile:                          getEnv("SITES_FILE", "data/sites.json"),
+		AMeDASStationsFile:                 getEnv("AMEDAS_STATIONS_FILE", "data/amedas_stations.json"),
 	}
 }
 
@@ -49,3 +106,23 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvFloat gets a float environment variable with a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// getEnvInt gets an integer environment variable with a default value
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
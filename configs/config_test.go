@@ -77,4 +77,30 @@ func TestLoadConfigDefaults(t *testing.T) {
 	if cfg.Environment != "development" {
 		t.Errorf("Expected default Environment to be 'development', got '%s'", cfg.Environment)
 	}
+
+	if cfg.RAGFusionK != 60 {
+		t.Errorf("Expected default RAGFusionK to be 60, got %d", cfg.RAGFusionK)
+	}
+
+	if cfg.ChatMemoryRecentTurns != 6 {
+		t.Errorf("Expected default ChatMemoryRecentTurns to be 6, got %d", cfg.ChatMemoryRecentTurns)
+	}
+}
+
+func TestLoadConfigHybridSearchWeights(t *testing.T) {
+	os.Setenv("RAG_VECTOR_WEIGHT", "0.7")
+	os.Setenv("RAG_LEXICAL_WEIGHT", "invalid")
+	defer os.Unsetenv("RAG_VECTOR_WEIGHT")
+	defer os.Unsetenv("RAG_LEXICAL_WEIGHT")
+
+	cfg := LoadConfig()
+
+	if cfg.RAGVectorWeight != 0.7 {
+		t.Errorf("Expected RAGVectorWeight to be 0.7, got %f", cfg.RAGVectorWeight)
+	}
+
+	// 不正な値はデフォルトにフォールバック
+	if cfg.RAGLexicalWeight != 1.0 {
+		t.Errorf("Expected RAGLexicalWeight to fall back to 1.0, got %f", cfg.RAGLexicalWeight)
+	}
 }
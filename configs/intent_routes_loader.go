@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetrievalPlan は意図ごとの検索プラン（各ソースの候補件数）を定義
+type RetrievalPlan struct {
+	ChatHistory      int    `yaml:"chat_history" json:"chat_history"`
+	Documents        int    `yaml:"documents" json:"documents"`
+	Reports          int    `yaml:"reports" json:"reports"`
+	AnomalyResponses int    `yaml:"anomaly_responses" json:"anomaly_responses"`
+	WeatherDays      int    `yaml:"weather_days" json:"weather_days"`
+	WeatherRegion    string `yaml:"weather_region" json:"weather_region,omitempty"`
+}
+
+// IntentRoute は1つの意図とその検索プランを定義
+type IntentRoute struct {
+	Name        string        `yaml:"name"`
+	Label       string        `yaml:"label"`
+	Action      string        `yaml:"action"`
+	Tool        string        `yaml:"tool"` // 指定した場合は最初の応答でこのチャットツールを必ず呼び出させる（tool_choice）
+	Description string        `yaml:"description"`
+	Keywords    []string      `yaml:"keywords"`
+	Retrieval   RetrievalPlan `yaml:"retrieval"`
+}
+
+// IntentRoutesConfig はintent_routes.yamlの構造を定義
+type IntentRoutesConfig struct {
+	DefaultIntent string        `yaml:"default_intent"`
+	Intents       []IntentRoute `yaml:"intents"`
+}
+
+var cachedIntentRoutes *IntentRoutesConfig
+
+// LoadIntentRoutes はYAMLファイルから意図ルーティング設定を読み込む
+func LoadIntentRoutes() (*IntentRoutesConfig, error) {
+	if cachedIntentRoutes != nil {
+		return cachedIntentRoutes, nil
+	}
+
+	// 複数のパスを試行（ローカル開発、Vercel、Dockerなど異なる環境に対応）
+	possiblePaths := []string{
+		"configs/intent_routes.yaml",
+		"./configs/intent_routes.yaml",
+		"../configs/intent_routes.yaml",
+		"/var/task/configs/intent_routes.yaml", // Vercel/AWS Lambda
+		"/app/configs/intent_routes.yaml",      // Docker
+	}
+
+	var data []byte
+	var err error
+	var loadedPath string
+
+	for _, path := range possiblePaths {
+		data, err = os.ReadFile(path)
+		if err == nil {
+			loadedPath = path
+			break
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("意図ルーティング設定ファイルの読み込みに失敗（試行したパス: %v）: %w", possiblePaths, err)
+	}
+
+	config, err := ParseIntentRoutes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w（ファイル: %s）", err, loadedPath)
+	}
+
+	cachedIntentRoutes = config
+	fmt.Printf("✅ 意図ルーティング設定を読み込みました: %s\n", loadedPath)
+	return cachedIntentRoutes, nil
+}
+
+// ParseIntentRoutes はYAMLデータを意図ルーティング設定として解析・検証する
+func ParseIntentRoutes(data []byte) (*IntentRoutesConfig, error) {
+	var config IntentRoutesConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("YAMLのパースに失敗: %w", err)
+	}
+	if len(config.Intents) == 0 {
+		return nil, fmt.Errorf("意図が1つも定義されていません")
+	}
+	if config.DefaultIntent == "" {
+		config.DefaultIntent = config.Intents[0].Name
+	}
+	if config.Find(config.DefaultIntent) == nil {
+		return nil, fmt.Errorf("default_intent '%s' が intents に定義されていません", config.DefaultIntent)
+	}
+	return &config, nil
+}
+
+// Find は名前で意図を検索する（見つからない場合はnil）
+func (c *IntentRoutesConfig) Find(name string) *IntentRoute {
+	for i := range c.Intents {
+		if c.Intents[i].Name == name {
+			return &c.Intents[i]
+		}
+	}
+	return nil
+}
+
+// DefaultIntentRoutes は設定ファイルが読み込めない場合のフォールバック設定を返す
+func DefaultIntentRoutes() *IntentRoutesConfig {
+	return &IntentRoutesConfig{
+		DefaultIntent: "docs",
+		Intents: []IntentRoute{
+			{
+				Name:      "docs",
+				Label:     "ドキュメント回答",
+				Action:    "answer_from_docs",
+				Retrieval: RetrievalPlan{ChatHistory: 3, Documents: 8},
+			},
+			{
+				Name:      "reports",
+				Label:     "分析レポート照会",
+				Action:    "query_reports",
+				Keywords:  []string{"分析", "相関", "ファイル", "レポート"},
+				Retrieval: RetrievalPlan{ChatHistory: 3, Documents: 3, Reports: 4},
+			},
+		},
+	}
+}
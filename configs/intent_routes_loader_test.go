@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestLoadIntentRoutes(t *testing.T) {
+	routes, err := LoadIntentRoutes()
+	if err != nil {
+		t.Fatalf("LoadIntentRoutes() returned error: %v", err)
+	}
+
+	// 想定している意図がすべて定義されていることを確認
+	for _, name := range []string{"docs", "reports", "forecast", "weather", "anomaly", "econ"} {
+		if routes.Find(name) == nil {
+			t.Errorf("Intent '%s' not found in intent routes", name)
+		}
+	}
+
+	// 実行を伴うアクションの意図はツールを指定している
+	for _, name := range []string{"forecast", "econ"} {
+		if route := routes.Find(name); route != nil && route.Tool == "" {
+			t.Errorf("Intent '%s' should name the tool that runs its action", name)
+		}
+	}
+
+	if routes.Find(routes.DefaultIntent) == nil {
+		t.Errorf("Default intent '%s' is not defined", routes.DefaultIntent)
+	}
+}
+
+func TestParseIntentRoutesInvalidDefault(t *testing.T) {
+	data := []byte(`
+default_intent: "unknown"
+intents:
+  - name: "docs"
+    retrieval:
+      documents: 3
+`)
+	if _, err := ParseIntentRoutes(data); err == nil {
+		t.Error("Expected error for undefined default_intent, got nil")
+	}
+}
@@ -58,9 +58,39 @@ func NewOpenAIClient(endpoint, apiKey, apiVersion, chatDeploymentName, embedding
 // --- データ構造定義 ---
 
 // ChatMessage チャットメッセージ
+// ツール呼び出しを行うassistantメッセージはToolCallsを、ツール実行結果を返すtoolメッセージはToolCallIDを持つ
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+// Tool モデルに公開するツール（関数）定義
+type Tool struct {
+	Type     string       `json:"type"` // 常に "function"
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction ツールとして公開する関数の名前・説明・JSONスキーマ
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// ToolCall モデルが要求したツール呼び出し
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction ツール呼び出しの関数名と引数（JSON文字列）
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // ChatCompletionRequest チャット補完リクエスト
@@ -71,6 +101,8 @@ type ChatCompletionRequest struct {
 	TopP        float32       `json:"top_p,omitempty"`
 	Stop        []string      `json:"stop,omitempty"`
 	Stream      bool          `json:"stream,omitempty"`
+	Tools       []Tool        `json:"tools,omitempty"`
+	ToolChoice  interface{}   `json:"tool_choice,omitempty"`
 }
 
 // ChatCompletionResponse チャット補完レスポンス
@@ -82,8 +114,9 @@ type ChatCompletionResponse struct {
 	Choices []struct {
 		Index   int `json:"index"`
 		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
+			Role      string     `json:"role"`
+			Content   string     `json:"content"`
+			ToolCalls []ToolCall `json:"tool_calls"`
 		}
 		FinishReason string `json:"finish_reason"`
 	}
@@ -147,6 +180,41 @@ func (c *OpenAIClient) ChatCompletion(ctx context.Context, messages []ChatMessag
 	return &response, nil
 }
 
+// ChatCompletionWithTools ツール定義付きでチャット補完を実行（function calling）
+// toolChoiceは "auto"（モデルが判断）、"none"（ツールを呼ばせない）、またはツール名（そのツールを必ず呼ばせる）。空の場合は "auto" として扱う
+func (c *OpenAIClient) ChatCompletionWithTools(ctx context.Context, messages []ChatMessage, tools []Tool, toolChoice string, maxTokens int, temperature float32) (*ChatCompletionResponse, error) {
+	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(c.endpoint, "/"), c.chatDeploymentName, c.apiVersion)
+
+	request := ChatCompletionRequest{
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		TopP:        0.95,
+		Tools:       tools,
+	}
+	if len(tools) > 0 {
+		switch toolChoice {
+		case "":
+			request.ToolChoice = "auto"
+		case "auto", "none":
+			request.ToolChoice = toolChoice
+		default:
+			request.ToolChoice = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]string{"name": toolChoice},
+			}
+		}
+	}
+
+	var response ChatCompletionResponse
+	_, err := c.doRequest(ctx, url, request, &response)
+	if err != nil {
+		return nil, fmt.Errorf("Azure OpenAI API 呼び出しに失敗: %w", err)
+	}
+	return &response, nil
+}
+
 // CreateEmbedding テキストのベクトル表現を生成
 func (c *OpenAIClient) CreateEmbedding(ctx context.Context, text string) ([]float32, error) {
 	if c.embeddingDeploymentName == "" {
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math"
 	"net/http"
 	"sort"
 	"strconv"
@@ -27,17 +26,43 @@ type AIHandler struct {
 	demandForecastService *services.DemandForecastService
 	vectorStoreService    *services.VectorStoreService
 	statisticsService     *services.StatisticsService
+	hybridSearchService   *services.HybridSearchService
+	intentRouter          *services.IntentRouter
+	chatTools             *services.ChatToolRegistry
+	conversationMemory    *services.ConversationMemoryService
+	anomalyInterview      *services.AnomalyInterviewService
+	followUpScheduler     *services.FollowUpScheduler
+	answerQuality         *services.AnswerQualityService
+	eventKnowledge        *services.EventKnowledgeService
+	anomalyRegistry       *services.AnomalyRegistryService
+	webhookService        *services.WebhookService
 }
 
 // NewAIHandler 新しいAI統合ハンドラーを作成
-func NewAIHandler(azureOpenAIService *services.AzureOpenAIService, weatherService *services.WeatherService, economicService *services.EconomicService, demandForecastService *services.DemandForecastService, vectorStoreService *services.VectorStoreService) *AIHandler {
+func NewAIHandler(azureOpenAIService *services.AzureOpenAIService, weatherService *services.WeatherService, economicService *services.EconomicService, demandForecastService *services.DemandForecastService, vectorStoreService *services.VectorStoreService, hybridSearchService *services.HybridSearchService, conversationMemory *services.ConversationMemoryService, followUpScheduler *services.FollowUpScheduler, webhookService *services.WebhookService) *AIHandler {
+	statisticsService := services.NewStatisticsService(weatherService, economicService, azureOpenAIService)
+	answerQuality := services.NewAnswerQualityService(azureOpenAIService, vectorStoreService)
+	eventKnowledge := services.NewEventKnowledgeService(vectorStoreService)
+	if demandForecastService != nil {
+		demandForecastService.SetEventKnowledge(eventKnowledge)
+	}
 	return &AIHandler{
 		azureOpenAIService:    azureOpenAIService,
 		weatherService:        weatherService,
 		economicService:       economicService,
 		demandForecastService: demandForecastService,
 		vectorStoreService:    vectorStoreService,
-		statisticsService:     services.NewStatisticsService(weatherService, economicService, azureOpenAIService),
+		statisticsService:     statisticsService,
+		hybridSearchService:   hybridSearchService,
+		intentRouter:          services.NewIntentRouter(),
+		chatTools:             services.NewDefaultChatToolRegistry(weatherService, statisticsService, vectorStoreService),
+		conversationMemory:    conversationMemory,
+		anomalyInterview:      services.NewAnomalyInterviewService(azureOpenAIService, vectorStoreService, weatherService, answerQuality),
+		followUpScheduler:     followUpScheduler,
+		answerQuality:         answerQuality,
+		eventKnowledge:        eventKnowledge,
+		anomalyRegistry:       services.NewAnomalyRegistryService(vectorStoreService),
+		webhookService:        webhookService,
 	}
 }
 
@@ -109,6 +134,7 @@ type ChatInputRequest struct {
 // AnalyzeWeatherDataRequest 気象データ分析リクエスト
 type AnalyzeWeatherDataRequest struct {
 	RegionCode string `json:"region_code"`
+	SiteID     string `json:"site_id"` // 指定した場合は地域コードの代わりに店舗・拠点の気象データを使う
 	Days       int    `json:"days"`
 }
 
@@ -132,7 +158,12 @@ func (ah *AIHandler) AnalyzeWeatherData(c *gin.Context) {
 	if req.Days == 0 {
 		req.Days = 30
 	}
-	weatherSummary, err := ah.weatherService.GetSuzukaWeatherSummary(req.Days, "daily")
+	locationKey, err := ah.weatherService.ResolveLocationKey(req.SiteID, req.RegionCode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	weatherSummary, err := ah.weatherService.GetSiteWeatherSummary(locationKey, req.Days, "daily")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "気象データの取得に失敗しました"})
 		return
@@ -158,6 +189,7 @@ func (ah *AIHandler) AnalyzeWeatherData(c *gin.Context) {
 
 type GenerateDemandInsightsRequest struct {
 	RegionCode      string `json:"region_code"`
+	SiteID          string `json:"site_id"` // 指定した場合は地域コードの代わりに店舗・拠点の気象データを使う
 	Days            int    `json:"days"`
 	ProductCategory string `json:"product_category"`
 }
@@ -185,12 +217,17 @@ func (ah *AIHandler) GenerateDemandInsights(c *gin.Context) {
 	if req.ProductCategory == "" {
 		req.ProductCategory = "一般製造業"
 	}
-	weatherSummary, err := ah.weatherService.GetSuzukaWeatherSummary(req.Days, "daily")
+	locationKey, err := ah.weatherService.ResolveLocationKey(req.SiteID, req.RegionCode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	weatherSummary, err := ah.weatherService.GetSiteWeatherSummary(locationKey, req.Days, "daily")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "気象データの取得に失敗しました"})
 		return
 	}
-	historicalData, err := ah.weatherService.GetHistoricalWeatherDataByRange(req.RegionCode, req.Days)
+	historicalData, err := ah.weatherService.GetHistoricalWeatherDataByRange(locationKey, req.Days)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "過去データの取得に失敗しました"})
 		return
@@ -214,6 +251,7 @@ func (ah *AIHandler) GenerateDemandInsights(c *gin.Context) {
 
 type PredictDemandWithAIRequest struct {
 	RegionCode      string `json:"region_code"`
+	SiteID          string `json:"site_id"` // 指定した場合は地域コードの代わりに店舗・拠点の気象データを使う
 	Days            int    `json:"days"`
 	ProductCategory string `json:"product_category"`
 }
@@ -242,12 +280,17 @@ func (ah *AIHandler) PredictDemandWithAI(c *gin.Context) {
 	if req.ProductCategory == "" {
 		req.ProductCategory = "一般製造業"
 	}
-	weatherSummary, err := ah.weatherService.GetSuzukaWeatherSummary(req.Days, "daily")
+	locationKey, err := ah.weatherService.ResolveLocationKey(req.SiteID, req.RegionCode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	weatherSummary, err := ah.weatherService.GetSiteWeatherSummary(locationKey, req.Days, "daily")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "気象データの取得に失敗しました"})
 		return
 	}
-	historicalData, err := ah.weatherService.GetHistoricalWeatherDataByRange(req.RegionCode, req.Days)
+	historicalData, err := ah.weatherService.GetHistoricalWeatherDataByRange(locationKey, req.Days)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "過去データの取得に失敗しました"})
 		return
@@ -309,9 +352,9 @@ func (ah *AIHandler) GetAICapabilities(c *gin.Context) {
 }
 
 func (ah *AIHandler) GenerateAnomalyQuestion(c *gin.Context) {
-	regionCode := c.Query("region_code")
-	if regionCode == "" {
-		regionCode = "240000"
+	regionCode, ok := locationKey(c, ah.weatherService, c.Query("region_code"), "240000") // デフォルト：三重県
+	if !ok {
+		return
 	}
 	days := 30
 	if daysStr := c.Query("days"); daysStr != "" {
@@ -424,6 +467,46 @@ func (ah *AIHandler) DetectAnomaliesInSales(c *gin.Context) {
 	})
 }
 
+// DetectRegimeShifts 売上の持続的な水準変化（変化点）を検出
+func (ah *AIHandler) DetectRegimeShifts(c *gin.Context) {
+	var req models.RegimeShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "リクエストパラメータが不正です: " + err.Error(),
+		})
+		return
+	}
+
+	if len(req.Sales) != len(req.Dates) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "売上データと日付データの長さが一致しません",
+		})
+		return
+	}
+
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = "weekly"
+	}
+	if granularity != "daily" && granularity != "weekly" && granularity != "monthly" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "granularityは 'daily', 'weekly', 'monthly' のいずれかを指定してください",
+		})
+		return
+	}
+
+	shifts := ah.statisticsService.DetectRegimeShifts(req.Sales, req.Dates, req.ProductID, req.ProductName, granularity, req.MinSegmentLength)
+
+	c.JSON(http.StatusOK, models.RegimeShiftResponse{
+		Success:      true,
+		RegimeShifts: shifts,
+		Message:      fmt.Sprintf("%d 件の水準変化を検出しました", len(shifts)),
+	})
+}
+
 // ForecastProductDemand 製品別需要予測
 func (ah *AIHandler) ForecastProductDemand(c *gin.Context) {
 	var req models.ProductForecastRequest
@@ -442,18 +525,52 @@ func (ah *AIHandler) ForecastProductDemand(c *gin.Context) {
 	if req.RegionCode == "" {
 		req.RegionCode = "240000" // デフォルト: 三重県
 	}
+	for _, name := range req.WeatherFeatures {
+		if _, ok := services.WeatherFeatureDefinitionFor(name); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("不明な気象特徴量です: %s（GET /api/v1/weather/features で一覧を確認できます）", name),
+			})
+			return
+		}
+	}
+	locationKey, err := ah.weatherService.ResolveLocationKey(req.SiteID, req.RegionCode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		return
+	}
 
 	// サンプルデータを生成（実際の実装ではQdrantや外部DBから取得）
 	// TODO: アップロードされたファイルデータを使用
 	historicalData := ah.generateSampleHistoricalData(req.ProductID, 90)
 
+	// 最新の水準変化以降のデータだけで学習する
+	var regimeShift *models.RegimeShift
+	if req.LatestRegimeOnly {
+		historicalData, regimeShift = ah.statisticsService.TrimToLatestRegime(req.ProductID, req.ProductName, historicalData)
+	}
+
+	// 学習期間と予測期間に重なるイベントを回帰要因として取得
+	var events []models.EventRegressor
+	if req.UseEvents == nil || *req.UseEvents {
+		events = ah.forecastEventRegressors(c.Request.Context(), req.ProductID, historicalData)
+	}
+
+	// 学習期間と予測期間の気象特徴量を回帰要因として取得
+	var weatherRegressors *services.WeatherFeatureRegressors
+	if len(req.WeatherFeatures) > 0 {
+		weatherRegressors = ah.forecastWeatherRegressors(locationKey, req.WeatherFeatures, historicalData)
+	}
+
 	// 需要予測を実行
-	forecast, err := ah.statisticsService.ForecastProductDemand(
+	forecast, err := ah.statisticsService.ForecastProductDemandWithRegressors(
 		req.ProductID,
 		req.ProductName,
 		historicalData,
 		req.Period,
-		req.RegionCode,
+		locationKey,
+		events,
+		weatherRegressors,
 	)
 
 	if err != nil {
@@ -464,6 +581,31 @@ func (ah *AIHandler) ForecastProductDemand(c *gin.Context) {
 		return
 	}
 
+	forecast.TrainingStart = historicalData[0].Date
+	if regimeShift != nil {
+		forecast.RegimeShift = regimeShift
+		forecast.Factors = append(forecast.Factors, fmt.Sprintf("水準変化（%s、%+.1f%%）以降のデータのみで学習", regimeShift.StartDate, regimeShift.MagnitudePercent))
+	}
+
+	// 直近の期間でイベントあり・なしの予測精度を比較
+	if req.Backtest {
+		backtest, err := ah.statisticsService.BacktestEventFeatures(req.ProductID, req.ProductName, historicalData, req.Period, events)
+		if err != nil {
+			log.Printf("⚠️ イベント効果のバックテストに失敗: %v", err)
+		} else {
+			forecast.Backtest = backtest
+		}
+		// 気象特徴量を使う場合は、当時の予報で予測した場合との精度差（天気予報の誤差の影響）も求める
+		if len(req.WeatherFeatures) > 0 {
+			weatherBacktest, err := ah.statisticsService.BacktestWeatherForecasts(req.ProductID, req.ProductName, historicalData, req.Period, locationKey, events, req.WeatherFeatures)
+			if err != nil {
+				log.Printf("⚠️ 天気予報のバックテストに失敗: %v", err)
+			} else {
+				forecast.WeatherBacktest = weatherBacktest
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, models.ProductForecastResponse{
 		Success:  true,
 		Forecast: forecast,
@@ -471,6 +613,28 @@ func (ah *AIHandler) ForecastProductDemand(c *gin.Context) {
 	})
 }
 
+// forecastWeatherRegressors 学習期間の実績と予測期間の予報から気象特徴量を計算する（取得できない場合はnil）
+func (ah *AIHandler) forecastWeatherRegressors(locationKey string, names []string, historicalData []models.SalesDataPoint) *services.WeatherFeatureRegressors {
+	if len(historicalData) == 0 {
+		return nil
+	}
+	startDate, err := time.Parse("2006-01-02", historicalData[0].Date)
+	if err != nil {
+		return nil
+	}
+	endDate, err := time.Parse("2006-01-02", historicalData[len(historicalData)-1].Date)
+	if err != nil {
+		return nil
+	}
+
+	days, err := ah.weatherService.GetWeatherFeatures(locationKey, startDate, endDate, true)
+	if err != nil {
+		log.Printf("⚠️ 予測に使う気象特徴量の取得に失敗: %v", err)
+		return nil
+	}
+	return &services.WeatherFeatureRegressors{Names: names, Days: days}
+}
+
 // AnalyzeWeeklySales 週次売上分析
 func (ah *AIHandler) AnalyzeWeeklySales(c *gin.Context) {
 	var req models.WeeklyAnalysisRequest
@@ -585,6 +749,7 @@ func (ah *AIHandler) SaveAnomalyResponse(c *gin.Context) {
 		ImpactValue: req.ImpactValue,
 		Timestamp:   time.Now().Format(time.RFC3339),
 		UserID:      c.GetString("user_id"), // 認証から取得（未実装の場合は空）
+		Analysis:    ah.answerQuality.Score(req.Question, req.Answer),
 	}
 
 	// Qdrantに保存
@@ -614,6 +779,11 @@ func (ah *AIHandler) SaveAnomalyResponse(c *gin.Context) {
 			"impact_value": response.ImpactValue,
 			"timestamp":    response.Timestamp,
 		}
+		if analysisJSON, err := json.Marshal(response.Analysis); err == nil {
+			metadata["analysis_json"] = string(analysisJSON)
+			metadata["specificity_score"] = response.Analysis.SpecificityScore
+			metadata["actionable"] = response.Analysis.Actionable
+		}
 
 		// Qdrantに保存
 		err := ah.vectorStoreService.StoreDocument(
@@ -634,6 +804,8 @@ func (ah *AIHandler) SaveAnomalyResponse(c *gin.Context) {
 		}
 
 		log.Printf("✅ 異常回答を保存しました: %s (製品: %s, 日付: %s)", responseID, req.ProductID, req.AnomalyDate)
+		ah.learnEventFromResponse(response)
+		ah.markAnomalyByAnswer(req.AnomalyDate, req.ProductID, "explained", "回答が保存されました")
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -791,6 +963,7 @@ func (ah *AIHandler) GetAnomalyResponses(c *gin.Context) {
 }
 
 // GetLearningInsights AIが学習した洞察を取得
+// イベント知識ベースから、イベントの種類ごと（?product_id= 指定時はその製品）の効果量を推定して返す
 func (ah *AIHandler) GetLearningInsights(c *gin.Context) {
 	if ah.vectorStoreService == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
@@ -799,26 +972,11 @@ func (ah *AIHandler) GetLearningInsights(c *gin.Context) {
 		})
 		return
 	}
-	category := c.Query("category") // "campaign", "weather", "event", etc.
-
-	if ah.vectorStoreService == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"success": false,
-			"error":   "ベクトルストアサービスが利用できません",
-		})
-		return
-	}
-
-	// コレクション名を定義
-	collectionName := "anomaly_responses"
-
-	// 回答履歴を全件取得
-	scrollResults, err := ah.vectorStoreService.ScrollAllPoints(
-		context.Background(),
-		collectionName,
-		100,
-	)
+	category := c.Query("category") // "campaign", "weather", "holiday", etc.
+	productID := c.Query("product_id")
+	refresh := c.Query("refresh") == "true"
 
+	estimates, err := ah.eventKnowledge.Effects(c.Request.Context(), category, productID, refresh)
 	if err != nil {
 		log.Printf("学習データの取得に失敗: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -828,86 +986,33 @@ func (ah *AIHandler) GetLearningInsights(c *gin.Context) {
 		return
 	}
 
-	// タグごとに集計
-	tagStats := make(map[string]*struct {
-		count       int
-		totalImpact float64
-		examples    []string
-	})
-
-	for _, result := range scrollResults {
-		if result.Payload == nil {
-			continue
-		}
-
-		// typeフィールドでフィルタ
-		if typeVal := getStringFromPayload(result.Payload, "type"); typeVal != "anomaly_response" {
-			continue
-		}
-
-		tagsStr := getStringFromPayload(result.Payload, "tags")
-		impact := getFloatFromPayload(result.Payload, "impact_value")
-		date := getStringFromPayload(result.Payload, "anomaly_date")
-
-		if tagsStr == "" {
-			continue
-		}
-
-		tags := strings.Split(tagsStr, ",")
-		for _, tag := range tags {
-			tag = strings.TrimSpace(tag)
-			if tag == "" {
-				continue
-			}
-
-			// カテゴリフィルタ
-			if category != "" && tag != category {
-				continue
-			}
-
-			if tagStats[tag] == nil {
-				tagStats[tag] = &struct {
-					count       int
-					totalImpact float64
-					examples    []string
-				}{}
-			}
-
-			tagStats[tag].count++
-			tagStats[tag].totalImpact += impact
-			if len(tagStats[tag].examples) < 3 {
-				tagStats[tag].examples = append(tagStats[tag].examples, date)
-			}
-		}
-	}
-
 	// 洞察を生成
 	insights := make([]models.LearningInsight, 0)
-	insightID := 1
-
-	for tag, stats := range tagStats {
-		if stats.count < 2 {
+	for i := range estimates {
+		estimate := estimates[i]
+		if estimate.Count < 2 {
 			continue // 2件未満はスキップ
 		}
 
-		avgImpact := stats.totalImpact / float64(stats.count)
-		confidence := math.Min(float64(stats.count)/10.0, 1.0) // 10件で信頼度100%
-
-		pattern := ah.generatePatternDescription(tag, avgImpact, stats.count)
-
-		insight := models.LearningInsight{
-			InsightID:     fmt.Sprintf("insight_%d", insightID),
-			Category:      tag,
-			Pattern:       pattern,
-			Examples:      stats.examples,
-			AverageImpact: avgImpact,
-			Confidence:    confidence,
-			LearnedFrom:   stats.count,
-			LastUpdated:   time.Now().Format(time.RFC3339),
+		insightID := "insight_" + estimate.EventType
+		if estimate.ProductID != "" {
+			insightID += "_" + estimate.ProductID
 		}
+		pattern := ah.generatePatternDescription(services.EventTypeLabel(estimate.EventType), estimate.MeanUplift, estimate.Count)
+		pattern += fmt.Sprintf("（95%%信頼区間: %+.1f%%〜%+.1f%%、効果量 g=%.2f）", estimate.CILower, estimate.CIUpper, estimate.HedgesG)
 
-		insights = append(insights, insight)
-		insightID++
+		insights = append(insights, models.LearningInsight{
+			InsightID:     insightID,
+			Category:      estimate.EventType,
+			Pattern:       pattern,
+			Examples:      estimate.Examples,
+			AverageImpact: estimate.MeanUplift,
+			Confidence:    services.EffectConfidence(estimate),
+			LearnedFrom:   estimate.Count,
+			LastUpdated:   estimate.LastUpdated,
+			ProductID:     estimate.ProductID,
+			Effect:        &estimate,
+		})
 	}
 
 	// 信頼度順にソート
@@ -1120,43 +1225,12 @@ func (ah *AIHandler) GetUnansweredAnomalies(c *gin.Context) {
 		return
 	}
 
-	ctx := c.Request.Context()
-
-	// 1. 全ての分析レポートを取得
-	reports, err := ah.vectorStoreService.GetAllAnalysisReports(ctx)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "分析レポートの取得に失敗しました: " + err.Error()})
-		return
-	}
-
-	// 2. 全ての回答済み異常を取得
-	responses, err := ah.vectorStoreService.GetAllAnomalyResponses(ctx)
+	unansweredAnomalies, err := ah.findUnansweredAnomalies(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "回答済み異常の取得に失敗しました: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
 	}
 
-	// 3. 回答済みの異常をマップに格納 (キー: "日付-製品ID")
-	answeredAnomalies := make(map[string]struct{})
-	for _, res := range responses {
-		key := fmt.Sprintf("%s-%s", res.AnomalyDate, res.ProductID)
-		answeredAnomalies[key] = struct{}{}
-	}
-
-	// 4. 未回答の異常をフィルタリング
-	unansweredAnomalies := make([]models.AnomalyDetection, 0)
-	for _, report := range reports {
-		for _, anomaly := range report.Anomalies {
-			key := fmt.Sprintf("%s-%s", anomaly.Date, anomaly.ProductID)
-			if _, found := answeredAnomalies[key]; !found {
-				// ProductIDが空の異常は除外する
-				if anomaly.ProductID != "" {
-					unansweredAnomalies = append(unansweredAnomalies, anomaly)
-				}
-			}
-		}
-	}
-
 	log.Printf("未回答の異常を %d 件見つけました", len(unansweredAnomalies))
 
 	// デバッグ用に詳細ログを追加
@@ -1232,12 +1306,18 @@ func (ah *AIHandler) SaveAnomalyResponseWithFollowUp(c *gin.Context) {
 		}
 	}
 
-	// 今回の会話を追加
+	// 今回の会話を追加（最初の質問か深掘り質問かを記録し、回答の品質を分析）
+	questionTemplate := services.TemplateAnomalyQuestion
+	if len(session.Conversations) > 0 {
+		questionTemplate = services.TemplateFollowUpProbe
+	}
 	conversation := models.Conversation{
-		Question:   req.Question,
-		Answer:     req.Answer,
-		Timestamp:  time.Now().Format(time.RFC3339),
-		AnswerType: req.AnswerType,
+		Question:         req.Question,
+		Answer:           req.Answer,
+		Timestamp:        time.Now().Format(time.RFC3339),
+		AnswerType:       req.AnswerType,
+		QuestionTemplate: questionTemplate,
+		Analysis:         ah.answerQuality.Score(req.Question, req.Answer),
 	}
 	session.Conversations = append(session.Conversations, conversation)
 
@@ -1287,6 +1367,7 @@ func (ah *AIHandler) SaveAnomalyResponseWithFollowUp(c *gin.Context) {
 		}
 
 		log.Printf("🔍 深掘り質問を生成しました (%d/%d回目)", session.FollowUpCount, MAX_FOLLOW_UPS)
+		ah.markAnomalyByAnswer(session.AnomalyDate, session.ProductID, "investigating", "深掘り質問で調査中")
 
 		// 深掘り質問を返す
 		c.JSON(http.StatusOK, models.SaveAnomalyResponseResponse{
@@ -1324,6 +1405,9 @@ func (ah *AIHandler) SaveAnomalyResponseWithFollowUp(c *gin.Context) {
 		return
 	}
 
+	ah.learnEventFromSession(session)
+	ah.markAnomalyByAnswer(session.AnomalyDate, session.ProductID, "explained", "対話セッションで回答が完了しました")
+
 	log.Printf("✅ 対話セッション完了: %s (製品: %s, 会話数: %d, 深掘り回数: %d)",
 		session.SessionID,
 		session.ProductID,
@@ -1332,10 +1416,11 @@ func (ah *AIHandler) SaveAnomalyResponseWithFollowUp(c *gin.Context) {
 	)
 
 	c.JSON(http.StatusOK, models.SaveAnomalyResponseResponse{
-		Success:       true,
-		SessionID:     session.SessionID,
-		Message:       "回答を保存しました。ありがとうございます！",
-		NeedsFollowUp: false,
-		Evaluation:    evaluation,
+		Success:            true,
+		SessionID:          session.SessionID,
+		Message:            "回答を保存しました。ありがとうございます！",
+		NeedsFollowUp:      false,
+		Evaluation:         evaluation,
+		ScheduledFollowUps: ah.scheduleFollowUps(ctx, session),
 	})
 }
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListAnomalies 登録済みの異常を取得（?status=open,investigating / ?product_id= / ?granularity= / ?assignee= / ?from= / ?to=）
+func (ah *AIHandler) ListAnomalies(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	filter := models.AnomalyFilter{
+		Status:      c.Query("status"),
+		ProductID:   c.Query("product_id"),
+		Granularity: c.Query("granularity"),
+		Assignee:    c.Query("assignee"),
+		From:        c.Query("from"),
+		To:          c.Query("to"),
+	}
+	records, err := ah.anomalyRegistry.List(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("異常の取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	statusCounts := make(map[string]int)
+	for _, record := range records {
+		statusCounts[record.Status]++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"anomalies":     records,
+		"count":         len(records),
+		"status_counts": statusCounts,
+	})
+}
+
+// GetAnomaly 登録済みの異常を取得
+func (ah *AIHandler) GetAnomaly(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	record, err := ah.anomalyRegistry.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(anomalyErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "anomaly": record})
+}
+
+// TransitionAnomaly 異常の状態を遷移させる（open → acknowledged → investigating → explained / dismissed）
+func (ah *AIHandler) TransitionAnomaly(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.AnomalyTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	record, err := ah.anomalyRegistry.Transition(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(anomalyErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "anomaly": record})
+}
+
+// AssignAnomaly 異常に担当者を割り当てる
+func (ah *AIHandler) AssignAnomaly(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.AnomalyAssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	record, err := ah.anomalyRegistry.Assign(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(anomalyErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "anomaly": record})
+}
+
+// AddAnomalyComment 異常にコメントを追加
+func (ah *AIHandler) AddAnomalyComment(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.AnomalyCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	record, err := ah.anomalyRegistry.Comment(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(anomalyErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "anomaly": record})
+}
+
+// SyncAnomalies 保存済みの分析レポート・回答から異常を登録し直す（既存データの移行用）
+func (ah *AIHandler) SyncAnomalies(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	registered, err := ah.anomalyRegistry.Sync(c.Request.Context())
+	if err != nil {
+		log.Printf("異常の同期に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "registered": registered})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"registered": registered,
+		"message":    "分析レポートの異常をレジストリに同期しました",
+	})
+}
+
+// findUnansweredAnomalies 未解決（open / acknowledged / investigating）の異常を返す
+// レジストリに異常が登録されていない場合は、分析レポートと回答の突き合わせにフォールバックする
+func (ah *AIHandler) findUnansweredAnomalies(ctx context.Context) ([]models.AnomalyDetection, error) {
+	records, err := ah.anomalyRegistry.List(ctx, models.AnomalyFilter{})
+	if err != nil {
+		log.Printf("⚠️ 異常レジストリの取得に失敗したため、分析レポートから未回答の異常を探します: %v", err)
+	}
+	if len(records) == 0 {
+		return ah.vectorStoreService.FindUnansweredAnomalies(ctx)
+	}
+
+	anomalies := make([]models.AnomalyDetection, 0)
+	for _, record := range records {
+		if services.IsActiveAnomalyStatus(record.Status) {
+			anomalies = append(anomalies, record.Detection)
+		}
+	}
+	return anomalies, nil
+}
+
+// markAnomalyByAnswer 回答・セッションの進行に合わせて、登録済みの異常の状態をバックグラウンドで進める
+func (ah *AIHandler) markAnomalyByAnswer(date, productID, status, note string) {
+	if ah.anomalyRegistry == nil || ah.vectorStoreService == nil {
+		return
+	}
+	go func() {
+		if err := ah.anomalyRegistry.MarkByAnswer(context.Background(), date, productID, status, "system", note); err != nil {
+			log.Printf("⚠️ 異常の状態更新に失敗 (日付: %s, 製品: %s): %v", date, productID, err)
+		}
+	}()
+}
+
+// anomalyErrorStatus 異常の操作のエラーをHTTPステータスに変換
+func anomalyErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidAnomalyTransition) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, services.ErrAnomalyRecordNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrInvalidAnomalyRequest) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
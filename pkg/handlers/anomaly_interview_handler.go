@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartAnomalyInterview 異常の仮説を生成し、最も有力な仮説の検証質問から仮説検証型インタビューを開始
+func (ah *AIHandler) StartAnomalyInterview(c *gin.Context) {
+	if ah.azureOpenAIService == nil || ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "AIサービスまたはデータベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.AnomalyInterviewStartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	session, enhanced, err := ah.anomalyInterview.Start(c.Request.Context(), req)
+	if err != nil {
+		if errors.Is(err, services.ErrAnomalyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+			return
+		}
+		log.Printf("異常インタビューの開始に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	ah.markAnomalyByAnswer(session.AnomalyDate, session.ProductID, "investigating", "仮説検証インタビューを開始")
+
+	response := newAnomalyInterviewResponse(session)
+	response.PrimaryQuestion = enhanced.PrimaryQuestion
+	response.ContextSummary = enhanced.ContextSummary
+	c.JSON(http.StatusOK, response)
+}
+
+// AnswerAnomalyInterview 検証質問への回答で仮説の信頼度を更新し、次の質問または根本原因の結論を返す
+func (ah *AIHandler) AnswerAnomalyInterview(c *gin.Context) {
+	if ah.azureOpenAIService == nil || ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "AIサービスまたはデータベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.AnomalyInterviewAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	session, err := ah.anomalyInterview.Answer(c.Request.Context(), req)
+	if err != nil {
+		log.Printf("異常インタビューの回答処理に失敗: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	response := newAnomalyInterviewResponse(session)
+	if session.IsComplete {
+		response.ScheduledFollowUps = ah.scheduleFollowUps(c.Request.Context(), session)
+		ah.learnEventFromSession(session)
+		ah.markAnomalyByAnswer(session.AnomalyDate, session.ProductID, "explained", "仮説検証インタビューで根本原因を特定")
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAnomalyInterview インタビューの現在の状態（仮説の信頼度・次の質問・結論）を取得
+func (ah *AIHandler) GetAnomalyInterview(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	session, err := ah.vectorStoreService.GetAnomalyResponseSession(c.Request.Context(), sessionID)
+	if err != nil || session.Mode != "interview" {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "インタビューが見つかりません: " + sessionID})
+		return
+	}
+
+	c.JSON(http.StatusOK, newAnomalyInterviewResponse(session))
+}
+
+// newAnomalyInterviewResponse セッションからレスポンスを組み立てる（未完了なら回答待ちの検証質問を含める）
+func newAnomalyInterviewResponse(session *models.AnomalyResponseSession) models.AnomalyInterviewResponse {
+	response := models.AnomalyInterviewResponse{
+		Success:    true,
+		SessionID:  session.SessionID,
+		IsComplete: session.IsComplete,
+		Hypotheses: session.Hypotheses,
+		Conclusion: session.RootCause,
+	}
+	if !session.IsComplete {
+		for _, h := range session.Hypotheses {
+			if h.ID == session.CurrentHypothesis {
+				response.NextQuestion = h.VerificationQuestion
+				response.NextChoices = h.Choices
+				response.HypothesisID = h.ID
+				break
+			}
+		}
+	}
+	return response
+}
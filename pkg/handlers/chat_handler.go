@@ -10,12 +10,15 @@ import (
 	"time"
 
 	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/qdrant/go-client/qdrant"
 )
 
+// maxChatToolIterations チャットのエージェントループでツールを呼び出せる最大回数
+const maxChatToolIterations = 3
+
 // ChatInput RAGを使用したAIチャット
 func (ah *AIHandler) ChatInput(c *gin.Context) {
 	if ah.vectorStoreService == nil {
@@ -42,8 +45,16 @@ func (ah *AIHandler) ChatInput(c *gin.Context) {
 
 	ctx := c.Request.Context()
 
-	// メタデータを抽出（意図やキーワード）
-	intent, keywords, _ := ah.azureOpenAIService.ExtractMetadataFromMessage(req.ChatMessage)
+	// メタデータを抽出（意図やキーワード）し、意図に応じた検索プランを決定
+	classifiedIntent, keywords, err := ah.azureOpenAIService.ExtractMetadataFromMessage(req.ChatMessage, ah.intentRouter.Routes().Intents)
+	if err != nil {
+		log.Printf("⚠️ 意図分類に失敗したためキーワードでルーティングします: %v", err)
+	}
+	route := ah.intentRouter.Route(classifiedIntent, req.ChatMessage)
+	intent := route.Intent
+	plan := route.Plan
+	log.Printf("🧭 意図ルーティング: intent=%s action=%s tool=%s method=%s keywords=%v plan=%+v",
+		route.Intent, route.Action, route.Tool, route.Method, route.MatchedKeywords, plan)
 
 	// ユーザーメッセージをチャット履歴として保存
 	userEntry := models.ChatHistoryEntry{
@@ -71,117 +82,182 @@ func (ah *AIHandler) ChatInput(c *gin.Context) {
 		}
 	}()
 
-	// RAG: 類似した過去の会話を検索（チャット履歴から）
-	var ragContext strings.Builder
-	var relevantHistoryTexts []string
-	var contextSources []models.ContextSource // スコア情報付きのソースリスト
+	// 🧠 同じセッションの要約と、要約に含まれていない発話（時系列順）を読み込む
+	var recentTurns []services.ChatMessage
+	recentTurnIDs := make(map[string]bool)
+	var sessionSummary *models.ChatSessionSummary
+	if ah.conversationMemory != nil {
+		memory, err := ah.conversationMemory.Load(ctx, req.SessionID, userEntry.ID)
+		if err != nil {
+			log.Printf("セッション履歴の取得に失敗: %v", err)
+		} else {
+			for _, turn := range memory.RecentTurns {
+				recentTurns = append(recentTurns, services.ChatMessage{Role: turn.Role, Content: turn.Message})
+				recentTurnIDs[turn.ID] = true
+			}
+			sessionSummary = memory.Summary
+			log.Printf("🧠 セッション履歴: 要約していない%d件の発話（全%d件）、要約あり=%v", len(memory.RecentTurns), memory.TotalTurns, sessionSummary != nil)
+		}
+	}
+
+	// RAG: 広めに候補を集め、リランキング・重複除去・トークン予算詰め込みを行う
+	var candidates []services.ContextCandidate
+
+	if sessionSummary != nil {
+		// 直近の発話より古い会話の要約は常に含める
+		candidates = append(candidates, services.ContextCandidate{
+			Source: models.ContextSource{
+				Type:     "session_summary",
+				FileName: fmt.Sprintf("このセッションの要約（%d件の発話）", sessionSummary.SummarizedTurns),
+				Score:    1.0,
+				Date:     sessionSummary.UpdatedAt,
+			},
+			Text:   sessionSummary.Summary,
+			Pinned: true,
+		})
+	}
 
 	if req.Context != "" {
-		ragContext.WriteString(req.Context) // ファイル分析のコンテキストを維持
-		contextSources = append(contextSources, models.ContextSource{
-			Type:     "file_analysis",
-			FileName: "アップロードファイル",
-			Score:    1.0, // 明示的に提供されたコンテキストは最高スコア
+		// ファイル分析のコンテキストを維持（明示的に提供されたコンテキストは最高スコア）
+		candidates = append(candidates, services.ContextCandidate{
+			Source: models.ContextSource{
+				Type:     "file_analysis",
+				FileName: "アップロードファイル",
+				Score:    1.0,
+			},
+			Text:   req.Context,
+			Pinned: true,
 		})
 	}
 
 	// 🔍 過去のチャット履歴から関連する会話を検索
-	chatHistory, err := ah.vectorStoreService.SearchChatHistory(ctx, req.ChatMessage, "", req.UserID, 3)
-	if err != nil {
-		log.Printf("チャット履歴検索に失敗: %v", err)
-	} else if len(chatHistory) > 0 {
-		ragContext.WriteString("\n\n## 過去の関連する会話履歴:\n")
-		for i, entry := range chatHistory {
-			historyText := fmt.Sprintf("[%s] %s: %s", entry.Timestamp, entry.Role, entry.Message)
-			relevantHistoryTexts = append(relevantHistoryTexts, historyText)
-			ragContext.WriteString(fmt.Sprintf("%d. %s (関連度: %.2f)\n", i+1, historyText, entry.Metadata.RelevanceScore))
-			contextSources = append(contextSources, models.ContextSource{
-				Type:     "chat_history",
-				FileName: fmt.Sprintf("会話 %s", entry.Timestamp),
-				Score:    float32(entry.Metadata.RelevanceScore),
-				Date:     entry.Timestamp,
-			})
+	if plan.ChatHistory > 0 {
+		chatHistory, err := ah.vectorStoreService.SearchChatHistory(ctx, req.ChatMessage, "", req.UserID, uint64(plan.ChatHistory))
+		if err != nil {
+			log.Printf("チャット履歴検索に失敗: %v", err)
+		} else if len(chatHistory) > 0 {
+			for _, entry := range chatHistory {
+				if recentTurnIDs[entry.ID] {
+					// 直近の発話はメッセージとしてそのまま渡すため重複させない
+					continue
+				}
+				candidates = append(candidates, services.ContextCandidate{
+					Source: models.ContextSource{
+						Type:     "chat_history",
+						FileName: fmt.Sprintf("会話 %s", entry.Timestamp),
+						Score:    float32(entry.Metadata.RelevanceScore),
+						Date:     entry.Timestamp,
+					},
+					Text: fmt.Sprintf("[%s] %s: %s", entry.Timestamp, entry.Role, entry.Message),
+				})
+			}
+			log.Printf("📚 %d件の関連する過去の会話を候補に追加しました", len(chatHistory))
 		}
-		log.Printf("📚 %d件の関連する過去の会話を取得しました", len(chatHistory))
 	}
 
-	// 🔍 統一コレクション 'hunt_documents' から関連ドキュメントを検索
-	log.Println("🔍 統一コレクション 'hunt_documents' を検索します...")
-	searchResults, err := ah.vectorStoreService.Search(ctx, req.ChatMessage, 3) // 検索件数を3に増やす
-	if err != nil {
-		log.Printf("ベクトル検索に失敗: %v", err)
-	} else if len(searchResults) > 0 {
-		ragContext.WriteString("\n\n## 関連ドキュメント情報:\n")
-		log.Printf("📚 %d件の関連ドキュメントを 'hunt_documents' から取得しました", len(searchResults))
-		for _, point := range searchResults {
-			fileName := "不明なドキュメント"
-			if fn, ok := point.Payload["file_name"]; ok {
-				fileName = fn.GetStringValue()
+	// 🔍 統一コレクション 'hunt_documents' から関連ドキュメントをハイブリッド検索（ベクトル + BM25）
+	if plan.Documents > 0 {
+		log.Println("🔍 統一コレクション 'hunt_documents' をハイブリッド検索します...")
+		searchResults, err := ah.hybridSearchService.SearchDocuments(ctx, req.ChatMessage, plan.Documents)
+		if err != nil {
+			log.Printf("ドキュメント検索に失敗: %v", err)
+		} else if len(searchResults) > 0 {
+			log.Printf("📚 %d件の関連ドキュメントを 'hunt_documents' から取得しました", len(searchResults))
+			for _, result := range searchResults {
+				if result.Text == "" {
+					continue
+				}
+				fileName := result.FileName
+				if fileName == "" {
+					fileName = "不明なドキュメント"
+				}
+				candidates = append(candidates, services.ContextCandidate{
+					Source: newHybridContextSource("document", fileName, "", result),
+					Text:   fmt.Sprintf("[%s] %s", fileName, result.Text),
+				})
 			}
+		}
+	}
 
-			// コンテキストソースにも詳細を追加
-			contextSources = append(contextSources, models.ContextSource{
-				Type:     "document",
-				FileName: fileName,
-				Score:    point.Score,
-			})
-
-			if textPayload, ok := point.Payload["text"]; ok {
-				if text, ok := textPayload.GetKind().(*qdrant.Value_StringValue); ok {
-					ragContext.WriteString(fmt.Sprintf("- [%s] %s (類似度: %.2f)\n", fileName, text.StringValue, point.Score))
+	// 📊 分析レポートを検索
+	if plan.Reports > 0 {
+		analysisResults, err := ah.hybridSearchService.SearchReports(ctx, req.ChatMessage, plan.Reports)
+		if err != nil {
+			log.Printf("分析レポート検索に失敗: %v", err)
+		} else {
+			for _, result := range analysisResults {
+				report, ok := parseAnalysisReportPayload(result)
+				if !ok {
+					continue
 				}
+				candidates = append(candidates, services.ContextCandidate{
+					Source: newHybridContextSource("analysis_report", report.FileName, report.AnalysisDate, result),
+					Text:   formatReportForContext(report),
+				})
 			}
 		}
 	}
 
-	// 分析レポートを検索（質問が分析関連の場合）
-	if strings.Contains(strings.ToLower(req.ChatMessage), "分析") ||
-		strings.Contains(strings.ToLower(req.ChatMessage), "相関") ||
-		strings.Contains(strings.ToLower(req.ChatMessage), "ファイル") ||
-		strings.Contains(strings.ToLower(req.ChatMessage), "レポート") {
-
-		analysisResults, err := ah.vectorStoreService.SearchAnalysisReports(ctx, req.ChatMessage, 2)
+	// 🗣️ 過去の異常への回答（現場の知見）を検索
+	if plan.AnomalyResponses > 0 {
+		responses, err := ah.vectorStoreService.SearchWithFilter(ctx, "anomaly_responses", req.ChatMessage, uint64(plan.AnomalyResponses), nil)
 		if err != nil {
-			log.Printf("分析レポート検索に失敗: %v", err)
-		} else if len(analysisResults) > 0 {
-			ragContext.WriteString("\n\n## 関連する過去の分析レポート:\n")
-			for _, point := range analysisResults {
-				if textPayload, ok := point.Payload["text"]; ok {
-					if text, ok := textPayload.GetKind().(*qdrant.Value_StringValue); ok {
-						var report models.AnalysisReport
-						if json.Unmarshal([]byte(text.StringValue), &report) == nil {
-							ragContext.WriteString(fmt.Sprintf("\n### レポート: %s\n", report.FileName))
-							ragContext.WriteString(fmt.Sprintf("- 分析日: %s\n", report.AnalysisDate))
-							ragContext.WriteString(fmt.Sprintf("- データ点数: %d\n", report.DataPoints))
-							ragContext.WriteString(fmt.Sprintf("- サマリー:\n%s\n", report.Summary))
-							if len(report.Correlations) > 0 {
-								ragContext.WriteString("- 相関分析結果:\n")
-								for _, corr := range report.Correlations {
-									ragContext.WriteString(fmt.Sprintf("  * %s: %.3f (%s)\n",
-										corr.Factor, corr.CorrelationCoef, corr.Interpretation))
-								}
-							}
-							if report.Regression != nil {
-								ragContext.WriteString(fmt.Sprintf("- 回帰分析: %s\n", report.Regression.Description))
-							}
-							contextSources = append(contextSources, models.ContextSource{
-								Type:     "analysis_report",
-								FileName: report.FileName,
-								Score:    point.Score,
-								Date:     report.AnalysisDate,
-							})
-						}
-					}
+			log.Printf("異常回答の検索に失敗: %v", err)
+		} else {
+			for _, point := range responses {
+				text := getStringFromPayload(point.Payload, "text")
+				if text == "" {
+					continue
 				}
+				candidates = append(candidates, services.ContextCandidate{
+					Source: models.ContextSource{
+						Type:        "anomaly_response",
+						FileName:    fmt.Sprintf("異常回答 %s %s", getStringFromPayload(point.Payload, "product_id"), getStringFromPayload(point.Payload, "anomaly_date")),
+						Score:       point.Score,
+						Date:        getStringFromPayload(point.Payload, "anomaly_date"),
+						VectorScore: point.Score,
+					},
+					Text: text,
+				})
 			}
 		}
 	}
 
-	// 🤖 AIに応答を生成させる（過去の履歴を活用）
-	aiResponse, err := ah.azureOpenAIService.ProcessChatWithHistory(
+	// 🌤️ 直近の気象データを参照
+	if plan.WeatherDays > 0 && ah.weatherService != nil {
+		regionCode := plan.WeatherRegion
+		if regionCode == "" {
+			regionCode = "240000" // デフォルト：三重県
+		}
+		weatherData, err := ah.weatherService.GetHistoricalWeatherDataByRange(regionCode, plan.WeatherDays)
+		if err != nil {
+			log.Printf("気象データの取得に失敗: %v", err)
+		} else if len(weatherData) > 0 {
+			candidates = append(candidates, services.ContextCandidate{
+				Source: models.ContextSource{
+					Type:     "weather",
+					FileName: fmt.Sprintf("%s 直近%d日間の気象データ", weatherData[0].RegionName, plan.WeatherDays),
+					Score:    1.0,
+				},
+				Text: formatWeatherForContext(weatherData),
+			})
+		}
+	}
+
+	packed := ah.hybridSearchService.PackContext(req.ChatMessage, candidates)
+	ragContext, relevantHistoryTexts := buildRAGContext(packed)
+	contextSources := packed.Sources
+
+	// 🤖 AIに応答を生成させる（過去の履歴を活用し、必要に応じて予測・気象・統計ツールを呼び出す）
+	aiResponse, toolInvocations, err := ah.azureOpenAIService.ProcessChatWithTools(
+		c.Request.Context(),
 		req.ChatMessage,
-		ragContext.String(),
+		ragContext,
 		relevantHistoryTexts,
+		recentTurns,
+		ah.chatTools,
+		route.Tool,
+		maxChatToolIterations,
 	)
 	if err != nil {
 		log.Printf("AI処理エラー詳細: %v", err)
@@ -212,6 +288,11 @@ func (ah *AIHandler) ChatInput(c *gin.Context) {
 			log.Printf("AI応答の履歴保存に失敗: %v", err)
 		} else {
 			log.Printf("✅ AI応答を履歴に保存: SessionID=%s", req.SessionID)
+			if ah.conversationMemory != nil {
+				if _, err := ah.conversationMemory.MaybeSummarize(context.Background(), req.SessionID); err != nil {
+					log.Printf("セッション要約の更新に失敗: %v", err)
+				}
+			}
 		}
 	}()
 
@@ -223,7 +304,128 @@ func (ah *AIHandler) ChatInput(c *gin.Context) {
 			"session_id":         req.SessionID,
 			"relevant_history":   relevantHistoryTexts,
 			"context_sources":    contextSources,
-			"conversation_count": len(chatHistory),
+			"conversation_count": len(relevantHistoryTexts),
+			"context_tokens":     packed.UsedTokens,
+			"context_budget":     packed.Budget,
+			"route":              route,
+			"tool_invocations":   toolInvocations,
+			"session_memory": gin.H{
+				"recent_turns":     len(recentTurns),
+				"summary_included": sessionSummary != nil,
+			},
 		},
 	})
 }
+
+// newHybridContextSource ハイブリッド検索結果からソースごとのスコア付きContextSourceを作成
+func newHybridContextSource(sourceType, fileName, date string, result services.HybridSearchResult) models.ContextSource {
+	score := result.VectorScore
+	if result.VectorRank == 0 {
+		// BM25のみでヒットした場合は融合スコアを表示用スコアとする
+		score = float32(result.FusedScore)
+	}
+	return models.ContextSource{
+		Type:         sourceType,
+		FileName:     fileName,
+		Score:        score,
+		Date:         date,
+		VectorScore:  result.VectorScore,
+		VectorRank:   result.VectorRank,
+		LexicalScore: result.LexicalScore,
+		LexicalRank:  result.LexicalRank,
+		FusedScore:   result.FusedScore,
+	}
+}
+
+// parseAnalysisReportPayload 検索結果のペイロードから分析レポートを復元
+// hunt_documents は text に、hunt_chat_documents は full_report_json に完全なJSONを保持している
+func parseAnalysisReportPayload(result services.HybridSearchResult) (models.AnalysisReport, bool) {
+	var report models.AnalysisReport
+	candidates := []string{getStringFromPayload(result.Payload, "full_report_json"), result.Text}
+	for _, text := range candidates {
+		if text != "" && json.Unmarshal([]byte(text), &report) == nil {
+			return report, true
+		}
+	}
+	return report, false
+}
+
+// formatReportForContext 分析レポートをRAGコンテキスト用のテキストに整形
+func formatReportForContext(report models.AnalysisReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("### レポート: %s\n", report.FileName))
+	sb.WriteString(fmt.Sprintf("- 分析日: %s\n", report.AnalysisDate))
+	sb.WriteString(fmt.Sprintf("- データ点数: %d\n", report.DataPoints))
+	sb.WriteString(fmt.Sprintf("- サマリー:\n%s\n", report.Summary))
+	if len(report.Correlations) > 0 {
+		sb.WriteString("- 相関分析結果:\n")
+		for _, corr := range report.Correlations {
+			sb.WriteString(fmt.Sprintf("  * %s: %.3f (%s)\n",
+				corr.Factor, corr.CorrelationCoef, corr.Interpretation))
+		}
+	}
+	if report.Regression != nil {
+		sb.WriteString(fmt.Sprintf("- 回帰分析: %s\n", report.Regression.Description))
+	}
+	return sb.String()
+}
+
+// buildRAGContext 採用されたチャンクをソース種別ごとの見出し付きで連結する
+func buildRAGContext(packed services.PackedContext) (string, []string) {
+	sections := []struct {
+		sourceType string
+		heading    string
+	}{
+		{"file_analysis", ""},
+		{"session_summary", "## これまでの会話の要約:"},
+		{"chat_history", "## 過去の関連する会話履歴:"},
+		{"document", "## 関連ドキュメント情報:"},
+		{"analysis_report", "## 関連する過去の分析レポート:"},
+		{"anomaly_response", "## 過去の異常に対する現場の回答:"},
+		{"weather", "## 気象データ:"},
+	}
+
+	var ragContext strings.Builder
+	var relevantHistoryTexts []string
+	for _, section := range sections {
+		var items []services.ContextCandidate
+		for _, c := range packed.Included {
+			if c.Source.Type == section.sourceType {
+				items = append(items, c)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+		if section.heading != "" {
+			if ragContext.Len() > 0 {
+				ragContext.WriteString("\n\n")
+			}
+			ragContext.WriteString(section.heading + "\n")
+		}
+		for i, item := range items {
+			switch section.sourceType {
+			case "file_analysis":
+				ragContext.WriteString(item.Text)
+			case "chat_history":
+				relevantHistoryTexts = append(relevantHistoryTexts, item.Text)
+				ragContext.WriteString(fmt.Sprintf("%d. %s (関連度: %.2f)\n", i+1, item.Text, item.Source.RerankScore))
+			case "document", "anomaly_response":
+				ragContext.WriteString(fmt.Sprintf("- %s (関連度: %.2f)\n", item.Text, item.Source.RerankScore))
+			default:
+				ragContext.WriteString("\n" + item.Text)
+			}
+		}
+	}
+	return ragContext.String(), relevantHistoryTexts
+}
+
+// formatWeatherForContext 気象データをRAGコンテキスト用のテキストに整形
+func formatWeatherForContext(weatherData []services.HistoricalWeatherData) string {
+	var sb strings.Builder
+	for _, w := range weatherData {
+		sb.WriteString(fmt.Sprintf("- %s: %s 平均%.1f℃ (最高%.1f℃/最低%.1f℃) 降水量%.1fmm 湿度%.0f%%\n",
+			w.Date, w.Weather, w.Temperature, w.MaxTemp, w.MinTemp, w.Precipitation, w.Humidity))
+	}
+	return sb.String()
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListChatSessions チャットセッションの一覧を取得（?user_id= で絞り込み）
+func (ah *AIHandler) ListChatSessions(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	sessions, err := ah.vectorStoreService.ListChatSessions(c.Request.Context(), c.Query("user_id"))
+	if err != nil {
+		log.Printf("チャットセッション一覧の取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// GetChatSessionTranscript セッションの全発話を時系列順に取得（要約があれば併せて返す）
+func (ah *AIHandler) GetChatSessionTranscript(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	ctx := c.Request.Context()
+
+	transcript, err := ah.vectorStoreService.GetChatTranscript(ctx, sessionID)
+	if err != nil {
+		log.Printf("トランスクリプトの取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if len(transcript) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "セッションが見つかりません: " + sessionID})
+		return
+	}
+
+	summary, err := ah.vectorStoreService.GetChatSessionSummary(ctx, sessionID)
+	if err != nil {
+		log.Printf("⚠️ セッション要約の取得に失敗: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"session_id": sessionID,
+		"turns":      transcript,
+		"count":      len(transcript),
+		"summary":    summary,
+	})
+}
+
+// DeleteChatSession セッションの全発話と要約を削除
+func (ah *AIHandler) DeleteChatSession(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	sessionID := c.Param("session_id")
+	deleted, err := ah.vectorStoreService.DeleteChatSession(c.Request.Context(), sessionID)
+	if err != nil {
+		log.Printf("チャットセッションの削除に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "セッションが見つかりません: " + sessionID})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"message":       "セッションを削除しました",
+		"session_id":    sessionID,
+		"deleted_turns": deleted,
+	})
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -12,12 +13,14 @@ import (
 // DemandForecastHandler 需要予測ハンドラー
 type DemandForecastHandler struct {
 	demandForecastService *services.DemandForecastService
+	weatherService        *services.WeatherService
 }
 
 // NewDemandForecastHandler 新しい需要予測ハンドラーを作成
 func NewDemandForecastHandler(weatherService *services.WeatherService) *DemandForecastHandler {
 	return &DemandForecastHandler{
 		demandForecastService: services.NewDemandForecastService(weatherService),
+		weatherService:        weatherService,
 	}
 }
 
@@ -54,6 +57,12 @@ func (dfh *DemandForecastHandler) PredictDemand(c *gin.Context) {
 
 	// 需要予測を実行
 	forecast, err := dfh.demandForecastService.PredictDemand(request)
+	if errors.Is(err, services.ErrSiteNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "需要予測の実行に失敗しました: " + err.Error(),
@@ -67,8 +76,70 @@ func (dfh *DemandForecastHandler) PredictDemand(c *gin.Context) {
 	})
 }
 
+// SimulateScenarios 天候のシナリオ（気温・降水確率の変更、過去の似た時期の天候）ごとの需要予測を基準と比較
+func (dfh *DemandForecastHandler) SimulateScenarios(c *gin.Context) {
+	var request services.DemandScenarioRequest
+
+	// リクエストボディをバインド
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "リクエストの解析に失敗しました: " + err.Error(),
+		})
+		return
+	}
+
+	// デフォルト値の設定（通常の需要予測と同じ）
+	if request.RegionCode == "" {
+		request.RegionCode = "240000" // 三重県
+	}
+	if request.ProductCategory == "" {
+		request.ProductCategory = "飲料"
+	}
+	if request.ForecastDays == 0 {
+		request.ForecastDays = 7
+	}
+	if request.HistoricalDays == 0 {
+		request.HistoricalDays = 30
+	}
+	if request.ForecastDays < 0 || request.ForecastDays > 30 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "forecast_days は1〜30で指定してください",
+		})
+		return
+	}
+
+	simulation, err := dfh.demandForecastService.SimulateScenarios(request)
+	switch {
+	case errors.Is(err, services.ErrSiteNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		return
+	case errors.Is(err, services.ErrInvalidScenario):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "シナリオ別の需要予測に失敗しました: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    simulation,
+	})
+}
+
 // GetDemandForecastForSuzuka 三重県鈴鹿市の需要予測を取得（簡易版）
+// site_id を指定した場合はその店舗・拠点、省略時は既定の拠点（未登録の場合は三重県）を対象とする
 func (dfh *DemandForecastHandler) GetDemandForecastForSuzuka(c *gin.Context) {
+	siteID := c.Query("site_id")
+	if _, ok := dfh.weatherService.Sites().Get(services.DefaultSiteID); siteID == "" && ok {
+		siteID = services.DefaultSiteID
+	}
+
 	// クエリパラメータの取得
 	productCategory := c.Query("product_category")
 	if productCategory == "" {
@@ -130,6 +201,7 @@ func (dfh *DemandForecastHandler) GetDemandForecastForSuzuka(c *gin.Context) {
 	// リクエストを構築
 	request := services.DemandForecastRequest{
 		RegionCode:      "240000", // 三重県
+		SiteID:          siteID,
 		ProductCategory: productCategory,
 		ForecastDays:    forecastDays,
 		HistoricalDays:  historicalDays,
@@ -140,6 +212,12 @@ func (dfh *DemandForecastHandler) GetDemandForecastForSuzuka(c *gin.Context) {
 
 	// 需要予測を実行
 	forecast, err := dfh.demandForecastService.PredictDemand(request)
+	if errors.Is(err, services.ErrSiteNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "需要予測の実行に失敗しました: " + err.Error(),
@@ -200,9 +278,9 @@ func (dfh *DemandForecastHandler) GetDemandForecastSettings(c *gin.Context) {
 
 // GetDemandInsights 需要インサイトを取得
 func (dfh *DemandForecastHandler) GetDemandInsights(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "240000"
+	regionCode, ok := locationKey(c, dfh.weatherService, c.Param("regionCode"), "240000")
+	if !ok {
+		return
 	}
 
 	productCategory := c.Query("product_category")
@@ -272,9 +350,9 @@ func (dfh *DemandForecastHandler) GetDemandInsights(c *gin.Context) {
 
 // GetDemandAnalytics 需要分析データを取得
 func (dfh *DemandForecastHandler) GetDemandAnalytics(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "240000"
+	regionCode, ok := locationKey(c, dfh.weatherService, c.Param("regionCode"), "240000")
+	if !ok {
+		return
 	}
 
 	productCategory := c.Query("product_category")
@@ -325,9 +403,9 @@ func (dfh *DemandForecastHandler) GetDemandAnalytics(c *gin.Context) {
 
 // DetectAnomalies 異常検知を実行
 func (dfh *DemandForecastHandler) DetectAnomalies(c *gin.Context) {
-	regionCode := c.Query("region_code")
-	if regionCode == "" {
-		regionCode = "240000" // デフォルト：三重県
+	regionCode, ok := locationKey(c, dfh.weatherService, c.Query("region_code"), "240000") // デフォルト：三重県
+	if !ok {
+		return
 	}
 
 	days := 30
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListBusinessEvents イベント知識ベースの一覧を取得（?type= / ?product_id= / ?from= / ?to= で絞り込み）
+func (ah *AIHandler) ListBusinessEvents(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	filter := models.BusinessEventFilter{
+		EventType: c.Query("type"),
+		ProductID: c.Query("product_id"),
+		From:      c.Query("from"),
+		To:        c.Query("to"),
+	}
+	events, err := ah.vectorStoreService.ListBusinessEvents(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("イベントの取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"events":  events,
+		"count":   len(events),
+	})
+}
+
+// GetBusinessEvent イベントを取得
+func (ah *AIHandler) GetBusinessEvent(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	event, err := ah.vectorStoreService.GetBusinessEvent(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "event": event})
+}
+
+// CreateBusinessEvent イベントを手動で登録
+func (ah *AIHandler) CreateBusinessEvent(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.BusinessEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	event, err := ah.eventKnowledge.Create(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "event": event})
+}
+
+// UpdateBusinessEvent イベントを更新
+func (ah *AIHandler) UpdateBusinessEvent(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.BusinessEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	event, err := ah.eventKnowledge.Update(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "event": event})
+}
+
+// DeleteBusinessEvent イベントを削除
+func (ah *AIHandler) DeleteBusinessEvent(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	eventID := c.Param("id")
+	if err := ah.eventKnowledge.Delete(c.Request.Context(), eventID); err != nil {
+		c.JSON(eventErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "イベントを削除しました",
+		"event_id": eventID,
+	})
+}
+
+// IngestBusinessEvents 保存済みの異常回答・完了済みセッションをイベント知識ベースに取り込む
+func (ah *AIHandler) IngestBusinessEvents(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	ingested, err := ah.eventKnowledge.IngestAll(c.Request.Context())
+	if err != nil {
+		log.Printf("イベントの取り込みに失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error(), "ingested": ingested})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"ingested": ingested,
+		"message":  "回答をイベント知識ベースに取り込みました",
+	})
+}
+
+// learnEventFromResponse 保存した回答をイベント知識ベースにバックグラウンドで取り込む
+func (ah *AIHandler) learnEventFromResponse(response models.AnomalyResponse) {
+	if ah.eventKnowledge == nil {
+		return
+	}
+	go func() {
+		ctx := context.Background()
+		event, err := ah.eventKnowledge.IngestResponse(ctx, response, ah.lookupReportAnomaly(ctx, response.AnomalyDate, response.ProductID))
+		if err != nil {
+			log.Printf("⚠️ 回答のイベント化に失敗 (ID: %s): %v", response.ResponseID, err)
+		} else if event != nil {
+			log.Printf("📚 回答をイベントとして記録しました: %s (%s)", event.Title, event.EventType)
+		}
+	}()
+}
+
+// learnEventFromSession 完了したセッションをイベント知識ベースにバックグラウンドで取り込む
+func (ah *AIHandler) learnEventFromSession(session *models.AnomalyResponseSession) {
+	if ah.eventKnowledge == nil {
+		return
+	}
+	snapshot := *session
+	go func() {
+		ctx := context.Background()
+		event, err := ah.eventKnowledge.IngestSession(ctx, &snapshot, ah.lookupReportAnomaly(ctx, snapshot.AnomalyDate, snapshot.ProductID))
+		if err != nil {
+			log.Printf("⚠️ セッションのイベント化に失敗 (ID: %s): %v", snapshot.SessionID, err)
+		} else if event != nil {
+			log.Printf("📚 セッションをイベントとして記録しました: %s (%s)", event.Title, event.EventType)
+		}
+	}()
+}
+
+// forecastEventRegressors 学習期間から予測期間の終わりまでに重なるイベントを回帰要因として取得（取得できない場合はイベントなし）
+func (ah *AIHandler) forecastEventRegressors(ctx context.Context, productID string, historicalData []models.SalesDataPoint) []models.EventRegressor {
+	if ah.vectorStoreService == nil || len(historicalData) == 0 {
+		return nil
+	}
+	lastDate, err := time.Parse("2006-01-02", historicalData[len(historicalData)-1].Date)
+	if err != nil {
+		return nil
+	}
+	// 予測期間外のイベントは寄与しないため、最長の予測期間（30日）まで取得する
+	to := lastDate.AddDate(0, 0, 30).Format("2006-01-02")
+
+	events, err := ah.eventKnowledge.Regressors(ctx, productID, historicalData[0].Date, to)
+	if err != nil {
+		log.Printf("⚠️ 予測に使うイベントの取得に失敗: %v", err)
+		return nil
+	}
+	return events
+}
+
+// lookupReportAnomaly 分析レポートから異常を探す（見つからない場合はnil）
+func (ah *AIHandler) lookupReportAnomaly(ctx context.Context, date, productID string) *models.AnomalyDetection {
+	reports, err := ah.vectorStoreService.GetAllAnalysisReports(ctx)
+	if err != nil {
+		return nil
+	}
+	return services.FindReportAnomaly(reports, date, productID)
+}
+
+// eventErrorStatus イベント操作のエラーをHTTPステータスに変換
+func eventErrorStatus(err error) int {
+	if errors.Is(err, services.ErrBusinessEventNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrInvalidBusinessEvent) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
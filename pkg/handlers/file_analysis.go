@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/xuri/excelize/v2"
@@ -43,15 +44,20 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 	}
 
 	// 粒度のバリデーション
-	if granularity != "daily" && granularity != "weekly" && granularity != "monthly" {
+	if granularity != "hourly" && granularity != "daily" && granularity != "weekly" && granularity != "monthly" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   fmt.Sprintf("無効な粒度です: %s。'daily', 'weekly', 'monthly' のいずれかを指定してください。", granularity),
+			"error":   fmt.Sprintf("無効な粒度です: %s。'hourly', 'daily', 'weekly', 'monthly' のいずれかを指定してください。", granularity),
 		})
 		return
 	}
 
-	log.Printf("📊 [ファイル分析] データ粒度: %s", granularity)
+	// 製品横断の異常検知（カテゴリ全体の動きと比べて isolated / systemic を判定）
+	crossProduct := c.PostForm("cross_product") == "true"
+	// 気象調整モード（同日の気象条件による回帰を期待値にし、天候で説明できない残差だけを異常とする）
+	weatherAdjusted := c.PostForm("weather_adjusted") == "true"
+
+	log.Printf("📊 [ファイル分析] データ粒度: %s / 製品横断モード: %v / 気象調整モード: %v", granularity, crossProduct, weatherAdjusted)
 
 	// ⏱️ ステップ1: ファイル読み込み
 	step1Start := time.Now()
@@ -101,6 +107,11 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 
 	// 列インデックスを検出
 	dateColIdx := findIndex(header, "date", "日付")
+	// 時刻を含む日時列（POSの出力など）があればそちらを使い、なければ日付列と時刻列を組み合わせる
+	timeColIdx := findIndex(header, "time", "時刻")
+	if dateTimeColIdx := findIndex(header, "datetime", "日時", "timestamp", "タイムスタンプ"); dateTimeColIdx != -1 {
+		dateColIdx, timeColIdx = dateTimeColIdx, -1
+	}
 	// 製品ID列（必須）
 	productIDColIdx := findIndex(header, "製品ID", "製品id", "製品コード", "商品ID", "商品id", "商品コード", "product_code", "product_id", "product_ID")
 	// 製品名列（オプション・表示用）
@@ -110,6 +121,7 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 	// 🔍 デバッグ: 列インデックスをログ出力
 	log.Printf("🔍 [列検出] ヘッダー: %v", header)
 	log.Printf("🔍 [列検出] 日付列インデックス: %d", dateColIdx)
+	log.Printf("🔍 [列検出] 時刻列インデックス: %d", timeColIdx)
 	log.Printf("🔍 [列検出] 製品ID列インデックス: %d", productIDColIdx)
 	log.Printf("🔍 [列検出] 製品名列インデックス: %d", productNameColIdx)
 	log.Printf("🔍 [列検出] 販売数列インデックス: %d", salesColIdx)
@@ -148,7 +160,6 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 
 	for _, row := range dataRows {
 		if len(row) > dateColIdx && len(row) > productIDColIdx && len(row) > salesColIdx {
-			dateStr := row[dateColIdx]
 			productID := row[productIDColIdx]
 			productName := ""
 			if productNameColIdx != -1 && len(row) > productNameColIdx {
@@ -156,17 +167,15 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 			}
 			salesStr := row[salesColIdx]
 
-			var t time.Time
-			t, err = time.Parse("2006-01-02", dateStr)
-			if err != nil {
-				t, _ = time.Parse("2006/1/2", dateStr)
-			}
+			t, _ := parseSalesTimestamp(row, dateColIdx, timeColIdx)
 
 			sales, convErr := strconv.Atoi(salesStr)
 			if productID != "" && !t.IsZero() && convErr == nil {
 				// 粒度に応じた期間キーを生成
 				var periodKey string
 				switch granularity {
+				case "hourly":
+					periodKey = services.HourKey(t)
 				case "daily":
 					periodKey = t.Format("2006-01-02")
 				case "weekly":
@@ -195,6 +204,8 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 	// 粒度に応じたラベル
 	var periodLabel string
 	switch granularity {
+	case "hourly":
+		periodLabel = "時間帯別"
 	case "daily":
 		periodLabel = "日次"
 	case "weekly":
@@ -277,6 +288,7 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 	var salesData []models.WeatherSalesData
 	var parseErrors []string
 	successfulParse := 0
+	timedRows := 0 // 時刻まで解析できた行数（hourly 粒度で使う）
 
 	log.Printf("🔍 CSV解析開始: 総行数=%d, dateCol=%d, productIDCol=%d, productNameCol=%d, salesCol=%d",
 		len(dataRows), dateColIdx, productIDColIdx, productNameColIdx, salesColIdx)
@@ -305,14 +317,7 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 					rowIdx+1, dateStr, productID, productName, salesStr)
 			}
 
-			var t time.Time
-			t, _ = time.Parse("2006-01-02", dateStr)
-			if t.IsZero() {
-				t, _ = time.Parse("2006/1/2", dateStr)
-				if t.IsZero() {
-					t, _ = time.Parse("2006/01/02", dateStr)
-				}
-			}
+			t, hasTime := parseSalesTimestamp(row, dateColIdx, timeColIdx)
 
 			sales, convErr := strconv.ParseFloat(salesStr, 64)
 
@@ -334,12 +339,17 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 				continue
 			}
 
-			salesData = append(salesData, models.WeatherSalesData{
+			record := models.WeatherSalesData{
 				Date:        t.Format("2006-01-02"),
 				ProductID:   productID,
 				ProductName: productName,
 				Sales:       sales,
-			})
+			}
+			if granularity == "hourly" && hasTime {
+				record.DateTime = services.HourKey(t)
+				timedRows++
+			}
+			salesData = append(salesData, record)
 			successfulParse++
 
 			// 最初の成功例をログ
@@ -363,10 +373,25 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 		}
 	}
 
-	// デフォルトの地域コード（三重県）
-	regionCode := "240000"
-	if rc := c.Query("region_code"); rc != "" {
-		regionCode = rc
+	if granularity == "hourly" && len(salesData) > 0 && timedRows == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "hourly 粒度には時刻を含む日時列（datetime / 日時 / timestamp）、または日付列と時刻列（time / 時刻）が必要です。",
+		})
+		return
+	}
+
+	// 時間帯別の分析でも、相関分析・水準変化・製品横断の分析は製品・日ごとの合計で行う
+	dailySalesData := salesData
+	if granularity == "hourly" {
+		dailySalesData = services.DailyTotals(salesData)
+		log.Printf("🕐 時刻を解析できた行: %d件 / 日次の合計: %d件", timedRows, len(dailySalesData))
+	}
+
+	// site_id を指定した場合は店舗・拠点の気象データを使う（デフォルトの地域コードは三重県）
+	regionCode, ok := locationKey(c, ah.weatherService, c.Query("region_code"), "240000")
+	if !ok {
+		return
 	}
 
 	log.Printf("📂 ファイル分析開始: %s, 販売データ件数: %d, 地域コード: %s", fileName, len(salesData), regionCode)
@@ -401,7 +426,7 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 		// 統計レポート作成（AI分析なし）
 		report, err := ah.statisticsService.CreateAnalysisReport(
 			fileName,
-			salesData,
+			dailySalesData,
 			regionCode,
 			"", // AI分析結果は後で追加
 		)
@@ -457,13 +482,39 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 			step4Start := time.Now()
 
 			// === 異常検知の実行 ===
+			detectionData := salesData
+			if granularity == "hourly" {
+				if weatherAdjusted {
+					log.Printf("⚠️ 気象調整モードは hourly 粒度に対応していないため、同じ時刻の過去の平均で異常検知します")
+					weatherAdjusted = false
+				}
+				// 各時間帯にその時間帯の気象を結合する（異常の気象条件と、雨の時間帯の比較に使う）
+				joined, err := ah.statisticsService.JoinHourlyWeather(salesData, regionCode)
+				if err != nil {
+					log.Printf("⚠️ 1時間ごとの気象データの結合に失敗したため、気象なしで異常検知します: %v", err)
+				} else {
+					detectionData = joined
+				}
+			} else if weatherAdjusted {
+				// 気象調整モードでは販売データに同日の気象データを結合してから検知する
+				joined, err := ah.statisticsService.JoinWeather(salesData, regionCode)
+				if err != nil {
+					log.Printf("⚠️ 気象データの結合に失敗したため、移動平均で異常検知します: %v", err)
+					weatherAdjusted = false
+				} else {
+					detectionData = joined
+				}
+			}
+
 			// salesDataを製品IDでグループ化
 			productSalesData := make(map[string][]models.WeatherSalesData)
-			for _, sd := range salesData {
+			for _, sd := range detectionData {
 				productSalesData[sd.ProductID] = append(productSalesData[sd.ProductID], sd)
 			}
 
 			var allDetectedAnomalies []models.AnomalyDetection
+			var allRegimeShifts []models.RegimeShift
+			var weatherAdjustments []models.WeatherAdjustment
 			log.Printf("[デバッグ] 製品別データグループ数: %d", len(productSalesData))
 
 			// 各製品ごとに異常検知を実行（AI質問生成なし）
@@ -486,12 +537,64 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 
 				if len(salesFloats) > 0 {
 					// 粒度を指定して異常検知を実行
-					detectedAnomalies := ah.statisticsService.DetectAnomaliesWithGranularity(salesFloats, datesStrings, productID, productName, granularity)
+					var detectedAnomalies []models.AnomalyDetection
+					regimeGranularity := granularity
+					if granularity == "hourly" {
+						detectedAnomalies = ah.statisticsService.DetectHourlyAnomalies(pSalesData, productID, productName)
+						// 水準変化は日ごとの合計で判定する
+						salesFloats, datesStrings, regimeGranularity = nil, nil, "daily"
+						for _, sd := range services.DailyTotals(pSalesData) {
+							salesFloats = append(salesFloats, sd.Sales)
+							datesStrings = append(datesStrings, sd.Date)
+						}
+					} else if weatherAdjusted {
+						var adjustment models.WeatherAdjustment
+						detectedAnomalies, adjustment = ah.statisticsService.DetectWeatherAdjustedAnomalies(pSalesData, productID, productName, granularity)
+						weatherAdjustments = append(weatherAdjustments, adjustment)
+					} else {
+						detectedAnomalies = ah.statisticsService.DetectAnomaliesWithGranularity(salesFloats, datesStrings, productID, productName, granularity)
+					}
 					allDetectedAnomalies = append(allDetectedAnomalies, detectedAnomalies...)
+
+					// 移動平均では吸収されてしまう持続的な水準変化を別途記録
+					regimeShifts := ah.statisticsService.DetectRegimeShifts(salesFloats, datesStrings, productID, productName, regimeGranularity, 0)
+					allRegimeShifts = append(allRegimeShifts, regimeShifts...)
+				}
+			}
+
+			// 日次・時間帯の異常には、その日の天候が平年と比べてどれだけ珍しかったかを付ける（質問の前提に使う）
+			allDetectedAnomalies = ah.statisticsService.AttachWeatherAnomalies(allDetectedAnomalies, regionCode)
+
+			// 異常をレジストリに登録してIDを付与（再分析で検出された同じ異常は既存のものに統合される）
+			if len(allDetectedAnomalies) > 0 {
+				registered, fresh, err := ah.anomalyRegistry.Register(context.Background(), report.ReportID, allDetectedAnomalies)
+				if err != nil {
+					log.Printf("⚠️ 異常のレジストリ登録に失敗: %v", err)
+					// 登録できなかった異常は既存のものか判断できないため、通知の対象に含める
+					fresh = append(fresh, allDetectedAnomalies[len(registered):]...)
+				} else {
+					allDetectedAnomalies = registered
 				}
+				// 購読条件（既定では critical のみ）に一致する異常をWebhookで通知
+				// 既存の異常に統合されただけの検出は通知済みのため、新しい異常と深刻度が上がった異常に限る
+				ah.webhookService.NotifyAsync("file_analysis", services.NotificationsFromDetections(fresh))
 			}
 
 			analysisReport.Anomalies = allDetectedAnomalies
+			analysisReport.RegimeShifts = allRegimeShifts
+			sort.Slice(weatherAdjustments, func(i, j int) bool { return weatherAdjustments[i].ProductID < weatherAdjustments[j].ProductID })
+			analysisReport.WeatherAdjustments = weatherAdjustments
+			analysisReport.Granularity = granularity
+			if granularity == "hourly" {
+				analysisReport.HourOfDayProfiles = ah.statisticsService.BuildHourOfDayProfiles(detectionData)
+			}
+			if crossProduct {
+				crossGranularity := granularity
+				if granularity == "hourly" {
+					crossGranularity = "daily"
+				}
+				analysisReport.CrossProduct = ah.statisticsService.DetectCrossProductAnomalies(dailySalesData, crossGranularity)
+			}
 			stepTimes["4_anomaly_detection"] = time.Since(step4Start)
 			log.Printf("⏱️ [計測] ステップ4完了（異常検知）: %v", stepTimes["4_anomaly_detection"])
 			log.Printf("📈 %d件の異常を検知しました", len(allDetectedAnomalies))
@@ -523,8 +626,12 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 					questionsDuration := time.Since(questionsStart)
 					log.Printf("✅ [非同期AI質問] AI質問生成完了 (%d件, 所要時間: %v)", len(anomaliesCopy), questionsDuration)
 
-					// レポートを更新してDB保存（簡易実装: 既存のStoreDocumentを使用）
-					// TODO: 専用の更新メソッドを実装
+					// 登録済みの異常にAI質問を反映
+					// TODO: レポート本体の更新メソッドを実装
+					if _, _, err := ah.anomalyRegistry.Register(context.Background(), reportID, anomaliesCopy); err != nil {
+						log.Printf("⚠️ [非同期AI質問] 異常へのAI質問の保存に失敗: %v", err)
+						return
+					}
 					log.Printf("📊 [非同期AI質問] AI質問をDBに保存完了（ReportID: %s）", reportID)
 				}()
 			}
@@ -556,6 +663,18 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 			// === 目標② 分析結果をQdrantに保存 ===
 			ctx := context.Background()
 
+			// 時間帯別の売上は製品ごとの集計値として保存する（日次・週次の集計値と同じコレクション）
+			if granularity == "hourly" {
+				for productID, pSalesData := range productSalesData {
+					if productID == "" {
+						continue
+					}
+					if err := ah.vectorStoreService.StoreSalesAggregates(ctx, productID, "hourly", "sum", services.HourlyAggregatedPoints(pSalesData)); err != nil {
+						log.Printf("⚠️ 時間帯別の売上の保存に失敗（製品ID: %s）: %v", productID, err)
+					}
+				}
+			}
+
 			// 完全なレポートをJSONに変換
 			reportJSON, err := json.Marshal(analysisReport)
 			if err != nil {
@@ -594,6 +713,10 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 					log.Printf("⏱️ [計測] ステップ5完了（DB保存）: %v", stepTimes["5_db_save"])
 					log.Printf("分析レポート %s をQdrantに同期的に保存しました (ベクトルテキスト: %d文字, 完全JSON: %d文字)",
 						analysisReport.ReportID, len(vectorText), len(reportJSON))
+					// 新しいレポートをBM25インデックスに反映させる
+					if ah.hybridSearchService != nil {
+						ah.hybridSearchService.Invalidate()
+					}
 				}
 			}
 		}
@@ -610,6 +733,7 @@ func (ah *AIHandler) AnalyzeFile(c *gin.Context) {
 		"debug": gin.H{ // 🔍 デバッグ情報を追加
 			"header":                 header,
 			"date_col_index":         dateColIdx,
+			"time_col_index":         timeColIdx,
 			"product_id_col_index":   productIDColIdx,
 			"product_name_col_index": productNameColIdx,
 			"sales_col_index":        salesColIdx,
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPendingFollowUps 予定日を迎えた回答待ちのフォローアップ質問を取得
+// ?as_of=YYYY-MM-DD で基準日を指定、?mark_sent=true で取得した pending の質問を sent に更新
+func (ah *AIHandler) GetPendingFollowUps(c *gin.Context) {
+	if ah.vectorStoreService == nil || ah.followUpScheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	asOf := time.Now()
+	if s := c.Query("as_of"); s != "" {
+		parsed, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "as_ofはYYYY-MM-DD形式で指定してください"})
+			return
+		}
+		asOf = parsed
+	}
+
+	ctx := c.Request.Context()
+	followUps, err := ah.followUpScheduler.Pending(ctx, asOf)
+	if err != nil {
+		log.Printf("フォローアップ質問の取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	if c.Query("mark_sent") == "true" {
+		for i := range followUps {
+			if followUps[i].Status != "pending" {
+				continue
+			}
+			updated, err := ah.followUpScheduler.UpdateStatus(ctx, followUps[i].ID, "sent", "")
+			if err != nil {
+				log.Printf("⚠️ フォローアップ質問を送信済みにできませんでした (ID: %s): %v", followUps[i].ID, err)
+				continue
+			}
+			followUps[i] = *updated
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"as_of":      asOf.Format("2006-01-02"),
+		"follow_ups": followUps,
+		"count":      len(followUps),
+	})
+}
+
+// ListFollowUps フォローアップ質問の一覧を取得（?status= / ?session_id= で絞り込み）
+func (ah *AIHandler) ListFollowUps(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	followUps, err := ah.vectorStoreService.ListFollowUpQuestions(c.Request.Context(), c.Query("status"), c.Query("session_id"))
+	if err != nil {
+		log.Printf("フォローアップ質問の取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"follow_ups": followUps,
+		"count":      len(followUps),
+	})
+}
+
+// UpdateFollowUpStatus フォローアップ質問の状態を更新（sent/answered/skipped）
+func (ah *AIHandler) UpdateFollowUpStatus(c *gin.Context) {
+	if ah.vectorStoreService == nil || ah.followUpScheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	var req models.FollowUpStatusUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	followUp, err := ah.followUpScheduler.UpdateStatus(c.Request.Context(), c.Param("id"), req.Status, req.Answer)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrFollowUpNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrInvalidFollowUpStatus):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	// 回答の品質を分析して質問効果の集計に使う
+	if followUp.Status == "answered" && ah.answerQuality != nil {
+		followUp.Analysis = ah.answerQuality.Score(followUp.Question, followUp.Answer)
+		if err := ah.vectorStoreService.SaveFollowUpQuestion(c.Request.Context(), *followUp); err != nil {
+			log.Printf("⚠️ フォローアップ回答の品質分析の保存に失敗: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"follow_up": followUp,
+	})
+}
+
+// ScheduleSessionFollowUps 完了済みの異常回答セッションのフォローアップ質問を計画（計画済みの質問はそのまま）
+func (ah *AIHandler) ScheduleSessionFollowUps(c *gin.Context) {
+	if ah.vectorStoreService == nil || ah.followUpScheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	sessionID := c.Param("session_id")
+	session, err := ah.vectorStoreService.GetAnomalyResponseSession(ctx, sessionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "セッションが見つかりません: " + sessionID})
+		return
+	}
+
+	created, err := ah.followUpScheduler.ScheduleForSession(ctx, session)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"session_id": sessionID,
+		"follow_ups": created,
+		"count":      len(created),
+	})
+}
+
+// scheduleFollowUps 完了したセッションのフォローアップ質問を計画（失敗しても回答の保存は成功として扱う）
+func (ah *AIHandler) scheduleFollowUps(ctx context.Context, session *models.AnomalyResponseSession) []models.FollowUpQuestion {
+	if ah.followUpScheduler == nil {
+		return nil
+	}
+	followUps, err := ah.followUpScheduler.ScheduleForSession(ctx, session)
+	if err != nil {
+		log.Printf("⚠️ フォローアップ質問の計画に失敗 (セッション: %s): %v", session.SessionID, err)
+	}
+	return followUps
+}
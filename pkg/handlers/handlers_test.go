@@ -3,8 +3,11 @@ package handlers
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"hunt-chat-api/pkg/services"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 )
@@ -81,7 +84,7 @@ func TestHelloAPI(t *testing.T) {
 }
 
 func TestWeatherHandlerCreation(t *testing.T) {
-	handler := NewWeatherHandler()
+	handler := NewWeatherHandler(nil)
 
 	assert.NotNil(t, handler, "WeatherHandler should not be nil")
 	assert.NotNil(t, handler.GetWeatherService(), "WeatherService should not be nil")
@@ -95,7 +98,7 @@ func TestWeatherRegionCodesEndpoint(t *testing.T) {
 	router := gin.New()
 
 	// WeatherHandlerを作成
-	weatherHandler := NewWeatherHandler()
+	weatherHandler := NewWeatherHandler(nil)
 
 	// エンドポイントを追加
 	router.GET("/api/v1/weather/regions", weatherHandler.GetRegionCodes)
@@ -119,3 +122,102 @@ func TestWeatherRegionCodesEndpoint(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "success")
 	assert.Contains(t, w.Body.String(), "data")
 }
+
+func TestWeatherCacheAdminEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	weatherHandler := NewWeatherHandler(nil)
+	router.GET("/api/v1/admin/weather-cache", weatherHandler.GetCacheStats)
+	router.DELETE("/api/v1/admin/weather-cache", weatherHandler.PurgeCache)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/admin/weather-cache", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "max_entries")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/admin/weather-cache?kind=hourly", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/admin/weather-cache?region_code=240000", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"removed":0`)
+}
+
+func TestSiteEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	registry, err := services.NewLocationRegistry("", "../../data/amedas_stations.json")
+	assert.NoError(t, err)
+	siteHandler := NewSiteHandler(registry)
+	weatherService := services.NewWeatherServiceWithConfig(services.WeatherServiceConfig{Sites: registry})
+	weatherHandler := NewWeatherHandler(weatherService)
+	aiHandler := NewAIHandler(nil, weatherService, nil, services.NewDemandForecastService(weatherService), nil, nil, nil, nil, nil)
+	router.GET("/api/v1/sites/:siteId", siteHandler.GetSite)
+	router.POST("/api/v1/sites", siteHandler.CreateSite)
+	router.DELETE("/api/v1/sites/:siteId", siteHandler.DeleteSite)
+	router.GET("/api/v1/sites/nearest-station", siteHandler.GetNearestStation)
+	router.GET("/api/v1/weather/historical/:regionCode", weatherHandler.GetHistoricalWeatherData)
+	router.GET("/api/v1/ai/generate-question", aiHandler.GenerateAnomalyQuestion)
+
+	body := `{"id":"tsu","name":"津店","lat":34.7186,"lon":136.5056,"municipality_code":"24201"}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/sites", strings.NewReader(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"station_code":"53133"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/sites", strings.NewReader(body)))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/sites", strings.NewReader(`{"id":"x","name":"座標なし"}`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/sites/nearest-station?lat=34.69&lon=135.52", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"62078"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/weather/historical/240000?site_id=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/ai/generate-question?site_id=unknown", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("DELETE", "/api/v1/sites/tsu", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/sites/tsu", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestParseSalesTimestamp(t *testing.T) {
+	cases := []struct {
+		row      []string
+		timeCol  int
+		expected string
+		hasTime  bool
+	}{
+		{[]string{"2024-06-10 15:42:10", "P001"}, -1, "2024-06-10 15:42", true},
+		{[]string{"2024/6/10 9:05", "P001"}, -1, "2024-06-10 09:05", true},
+		{[]string{"2024-06-10T15:42:10+09:00", "P001"}, -1, "2024-06-10 15:42", true},
+		{[]string{"2024/06/10", "P001", "14時"}, 2, "2024-06-10 14:00", true},
+		{[]string{"2024-06-10", "P001", "8:30"}, 2, "2024-06-10 08:30", true},
+		{[]string{"2024-06-10", "P001", ""}, 2, "2024-06-10 00:00", false},
+		{[]string{"2024-06-10", "P001"}, -1, "2024-06-10 00:00", false},
+	}
+	for _, tc := range cases {
+		parsed, hasTime := parseSalesTimestamp(tc.row, 0, tc.timeCol)
+		assert.Equal(t, tc.expected, parsed.Format("2006-01-02 15:04"), tc.row)
+		assert.Equal(t, tc.hasTime, hasTime, tc.row)
+	}
+
+	parsed, _ := parseSalesTimestamp([]string{"不明"}, 0, -1)
+	assert.True(t, parsed.IsZero())
+}
@@ -23,6 +23,49 @@ func findIndex(slice []string, candidates ...string) int {
 	return -1
 }
 
+// salesDateLayouts 販売データの日付の形式
+var salesDateLayouts = []string{"2006-01-02", "2006/1/2", "2006/01/02"}
+
+// salesDateTimeLayouts 販売データの日時（時刻を含む）の形式
+// タイムゾーン付きの場合も、その表記のままの時刻を使う
+var salesDateTimeLayouts = []string{
+	"2006-01-02 15:04:05", "2006-01-02 15:04", "2006/1/2 15:04:05", "2006/1/2 15:04",
+	"2006-01-02T15:04:05", "2006-01-02T15:04", time.RFC3339,
+}
+
+// salesTimeLayouts 日付と分かれた時刻列の形式（例: 14:05、14時）
+var salesTimeLayouts = []string{"15:04:05", "15:04", "15時", "15"}
+
+// parseSalesTimestamp 行の日付（または日時）と時刻列から販売日時を解析する
+// timeColIdx が -1 の場合は日付列の値だけを使う。時刻が分かった場合は hasTime が true
+func parseSalesTimestamp(row []string, dateColIdx, timeColIdx int) (t time.Time, hasTime bool) {
+	if dateColIdx < 0 || dateColIdx >= len(row) {
+		return time.Time{}, false
+	}
+	value := strings.TrimSpace(row[dateColIdx])
+	for _, layout := range salesDateTimeLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return time.Date(parsed.Year(), parsed.Month(), parsed.Day(), parsed.Hour(), parsed.Minute(), parsed.Second(), 0, time.UTC), true
+		}
+	}
+	for _, layout := range salesDateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			t = parsed
+			break
+		}
+	}
+	if t.IsZero() || timeColIdx < 0 || timeColIdx >= len(row) {
+		return t, false
+	}
+	timeValue := strings.TrimSpace(row[timeColIdx])
+	for _, layout := range salesTimeLayouts {
+		if clock, err := time.Parse(layout, timeValue); err == nil {
+			return t.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute + time.Duration(clock.Second())*time.Second), true
+		}
+	}
+	return t, false
+}
+
 // AnalysisProgress 分析の進捗情報
 type AnalysisProgress struct {
 	Step       string `json:"step"`        // 処理ステップ名
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetQuestionAnalytics 質問テンプレート・仮説カテゴリごとの回答率・具体性・実行可能性を集計し、使い続けるべき質問を判定
+func (ah *AIHandler) GetQuestionAnalytics(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	report, err := ah.answerQuality.Report(c.Request.Context())
+	if err != nil {
+		log.Printf("質問効果の集計に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// BackfillAnswerQuality 品質分析がない過去の回答を分析して保存（?limit= で対象セッション数を指定、デフォルト20）
+func (ah *AIHandler) BackfillAnswerQuality(c *gin.Context) {
+	if ah.vectorStoreService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error":   "データベースサービスが利用できません。設定を確認してください。",
+		})
+		return
+	}
+
+	limit := 20
+	if s := c.Query("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "limitは正の整数で指定してください"})
+			return
+		}
+		limit = v
+	}
+
+	sessions, answers, err := ah.answerQuality.Backfill(c.Request.Context(), limit)
+	if err != nil {
+		log.Printf("回答品質のバックフィルに失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success":          false,
+			"error":            err.Error(),
+			"updated_sessions": sessions,
+			"scored_answers":   answers,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":          true,
+		"updated_sessions": sessions,
+		"scored_answers":   answers,
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SiteHandler 店舗・拠点（気象データを取得する地点）を管理するハンドラー
+type SiteHandler struct {
+	registry *services.LocationRegistry
+}
+
+// NewSiteHandler 新しい店舗・拠点ハンドラーを作成
+func NewSiteHandler(registry *services.LocationRegistry) *SiteHandler {
+	return &SiteHandler{registry: registry}
+}
+
+// ListSites 店舗・拠点の一覧を取得
+func (sh *SiteHandler) ListSites(c *gin.Context) {
+	sites := sh.registry.List()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"sites":   sites,
+		"count":   len(sites),
+	})
+}
+
+// GetSite 店舗・拠点を取得
+func (sh *SiteHandler) GetSite(c *gin.Context) {
+	site, ok := sh.registry.Get(c.Param("siteId"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": services.ErrSiteNotFound.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "site": site})
+}
+
+// CreateSite 店舗・拠点を登録（予報区・最寄りの観測所は省略時に自動で求める）
+func (sh *SiteHandler) CreateSite(c *gin.Context) {
+	var req models.SiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	site, err := sh.registry.Create(req)
+	if err != nil {
+		c.JSON(siteErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "site": site})
+}
+
+// UpdateSite 店舗・拠点を更新
+func (sh *SiteHandler) UpdateSite(c *gin.Context) {
+	var req models.SiteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	site, err := sh.registry.Update(c.Param("siteId"), req)
+	if err != nil {
+		c.JSON(siteErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "site": site})
+}
+
+// DeleteSite 店舗・拠点を削除
+func (sh *SiteHandler) DeleteSite(c *gin.Context) {
+	if err := sh.registry.Delete(c.Param("siteId")); err != nil {
+		c.JSON(siteErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "店舗・拠点を削除しました"})
+}
+
+// GetNearestStation 座標から最寄りのアメダス観測所を求める
+func (sh *SiteHandler) GetNearestStation(c *gin.Context) {
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(c.Query("lon"), 64)
+	if latErr != nil || lonErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "lat と lon を数値で指定してください"})
+		return
+	}
+
+	station, distance, ok := sh.registry.NearestStation(lat, lon)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "観測所一覧が読み込まれていません"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"station":     station,
+		"distance_km": distance,
+	})
+}
+
+// siteErrorStatus 店舗・拠点の操作のエラーをHTTPステータスに変換
+func siteErrorStatus(err error) int {
+	if errors.Is(err, services.ErrSiteNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrSiteAlreadyExists) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, services.ErrInvalidSite) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"hunt-chat-api/pkg/models"
 	"hunt-chat-api/pkg/services"
 
 	"github.com/gin-gonic/gin"
@@ -15,10 +18,13 @@ type WeatherHandler struct {
 	weatherService *services.WeatherService
 }
 
-// NewWeatherHandler 新しい気象データハンドラーを作成
-func NewWeatherHandler() *WeatherHandler {
+// NewWeatherHandler 新しい気象データハンドラーを作成（nilの場合は既定の取得元を使う）
+func NewWeatherHandler(weatherService *services.WeatherService) *WeatherHandler {
+	if weatherService == nil {
+		weatherService = services.NewWeatherService()
+	}
 	return &WeatherHandler{
-		weatherService: services.NewWeatherService(),
+		weatherService: weatherService,
 	}
 }
 
@@ -29,9 +35,9 @@ func (wh *WeatherHandler) GetWeatherService() *services.WeatherService {
 
 // GetForecastData 予報データを取得するハンドラー
 func (wh *WeatherHandler) GetForecastData(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "130000" // デフォルト：東京都
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "130000") // デフォルト：東京都
+	if !ok {
+		return
 	}
 
 	forecastData, err := wh.weatherService.GetForecastData(regionCode)
@@ -66,6 +72,40 @@ func (wh *WeatherHandler) GetTokyoWeatherData(c *gin.Context) {
 	})
 }
 
+// GetDailyForecasts 日別・予報区別の予報（気温・降水確率・天気・信頼度）を取得するハンドラー
+func (wh *WeatherHandler) GetDailyForecasts(c *gin.Context) {
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "130000") // デフォルト：東京都
+	if !ok {
+		return
+	}
+
+	forecasts, err := wh.weatherService.GetDailyForecasts(regionCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// 予報区での絞り込み（オプション）
+	if areaCode := c.Query("area_code"); areaCode != "" {
+		filtered := make([]services.DailyForecast, 0, len(forecasts))
+		for _, forecast := range forecasts {
+			if forecast.AreaCode == areaCode {
+				filtered = append(filtered, forecast)
+			}
+		}
+		forecasts = filtered
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"region_code": regionCode,
+		"data":        forecasts,
+		"count":       len(forecasts),
+	})
+}
+
 // GetRegionCodes 地域コード一覧を取得するハンドラー
 func (wh *WeatherHandler) GetRegionCodes(c *gin.Context) {
 	regionCodes := wh.weatherService.GetRegionCodes()
@@ -89,7 +129,10 @@ func (wh *WeatherHandler) TestWeatherAPI(c *gin.Context) {
 
 // GetWeatherByRegion 指定地域の気象データを取得
 func (wh *WeatherHandler) GetWeatherByRegion(c *gin.Context) {
-	regionCode := c.Param("regionCode")
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "")
+	if !ok {
+		return
+	}
 	if regionCode == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": "地域コードが指定されていません",
@@ -129,9 +172,9 @@ func (wh *WeatherHandler) GetWeatherByRegion(c *gin.Context) {
 
 // GetHistoricalWeatherData 過去の気象データを取得するハンドラー
 func (wh *WeatherHandler) GetHistoricalWeatherData(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "130000" // デフォルト：東京都
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "130000") // デフォルト：東京都
+	if !ok {
+		return
 	}
 
 	// 日数パラメータの取得
@@ -162,9 +205,9 @@ func (wh *WeatherHandler) GetHistoricalWeatherData(c *gin.Context) {
 
 // GetHistoricalWeatherDataByDate 指定日の過去気象データを取得
 func (wh *WeatherHandler) GetHistoricalWeatherDataByDate(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "130000" // デフォルト：東京都
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "130000") // デフォルト：東京都
+	if !ok {
+		return
 	}
 
 	dateStr := c.Query("date")
@@ -203,9 +246,9 @@ func (wh *WeatherHandler) GetHistoricalWeatherDataByDate(c *gin.Context) {
 
 // GetHistoricalWeatherDataRange 期間指定での過去気象データを取得
 func (wh *WeatherHandler) GetHistoricalWeatherDataRange(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "130000" // デフォルト：東京都
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "130000") // デフォルト：東京都
+	if !ok {
+		return
 	}
 
 	startDateStr := c.Query("start_date")
@@ -263,10 +306,23 @@ func (wh *WeatherHandler) GetAvailableHistoricalDataRange(c *gin.Context) {
 	})
 }
 
-// GetSuzukaMonthlyWeatherSummary 三重県鈴鹿市の過去一か月分の気象データをまとめて取得
-func (wh *WeatherHandler) GetSuzukaMonthlyWeatherSummary(c *gin.Context) {
-	// 三重県のコード: 240000
-	regionCode := "240000"
+// GetSiteMonthlyWeatherSummary 店舗・拠点の過去一か月分の気象データをまとめて取得
+// /weather/suzuka/monthly は既定の拠点（鈴鹿市）を対象とする互換用のルート
+func (wh *WeatherHandler) GetSiteMonthlyWeatherSummary(c *gin.Context) {
+	key := "240000" // 既定の拠点が未登録の場合は三重県
+	if siteID := c.Param("siteId"); siteID != "" {
+		resolved, err := wh.weatherService.ResolveLocationKey(siteID, "")
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   err.Error(),
+			})
+			return
+		}
+		key = resolved
+	} else if _, ok := wh.weatherService.Sites().Get(services.DefaultSiteID); ok {
+		key = services.DefaultSiteID
+	}
 
 	// 過去30日分のデータを取得
 	days := 30
@@ -283,7 +339,7 @@ func (wh *WeatherHandler) GetSuzukaMonthlyWeatherSummary(c *gin.Context) {
 	}
 
 	// データ取得
-	weatherSummary, err := wh.weatherService.GetSuzukaWeatherSummary(days, summaryType)
+	weatherSummary, err := wh.weatherService.GetSiteWeatherSummary(key, days, summaryType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
@@ -293,8 +349,9 @@ func (wh *WeatherHandler) GetSuzukaMonthlyWeatherSummary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success":      true,
-		"region_code":  regionCode,
-		"region_name":  "三重県（鈴鹿市含む）",
+		"site_id":      weatherSummary.SiteID,
+		"region_code":  weatherSummary.RegionCode,
+		"region_name":  weatherSummary.RegionName,
 		"days":         days,
 		"summary_type": summaryType,
 		"data":         weatherSummary,
@@ -303,9 +360,9 @@ func (wh *WeatherHandler) GetSuzukaMonthlyWeatherSummary(c *gin.Context) {
 
 // GetWeatherDataAnalysis 気象データの分析結果を取得
 func (wh *WeatherHandler) GetWeatherDataAnalysis(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "240000" // デフォルト：三重県
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
 	}
 
 	// 分析期間の指定
@@ -340,11 +397,275 @@ func (wh *WeatherHandler) GetWeatherDataAnalysis(c *gin.Context) {
 	})
 }
 
+// GetWeatherFeatures 派生特徴量（不快指数・冷暖房度日・真夏日の連続日数など）を取得
+// with_forecast=true の場合は予報の期間まで延長する
+func (wh *WeatherHandler) GetWeatherFeatures(c *gin.Context) {
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
+	}
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 365 {
+			days = d
+		}
+	}
+	withForecast := c.Query("with_forecast") == "true"
+
+	endDate := time.Now().AddDate(0, 0, -1)
+	startDate := endDate.AddDate(0, 0, -days+1)
+	features, err := wh.weatherService.GetWeatherFeatures(regionCode, startDate, endDate, withForecast)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"region_code":   regionCode,
+		"days":          days,
+		"with_forecast": withForecast,
+		"features":      services.WeatherFeatureCatalog(),
+		"data":          features,
+	})
+}
+
+// GetWeatherQuality 過去データの検証結果（欠測日・ありえない値・急な変化と補完内容）を取得
+func (wh *WeatherHandler) GetWeatherQuality(c *gin.Context) {
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
+	}
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 365 {
+			days = d
+		}
+	}
+
+	endDate := time.Now().AddDate(0, 0, -1)
+	startDate := endDate.AddDate(0, 0, -days+1)
+	data, report, err := wh.weatherService.GetValidatedHistoricalWeatherData(regionCode, startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"region_code": regionCode,
+		"days":        days,
+		"report":      report,
+		"data":        data,
+	})
+}
+
+// climatologyPeriod 平年値・異常度の対象期間と比較年数を解析する
+// start_date・end_date（YYYY-MM-DD）を指定しない場合は昨日までの days 日（既定30日）、years は既定5年
+func climatologyPeriod(c *gin.Context) (startDate, endDate time.Time, years int, ok bool) {
+	years = services.ClimatologyBaselineYears
+	if yearsStr := c.Query("years"); yearsStr != "" {
+		y, err := strconv.Atoi(yearsStr)
+		if err != nil || y < 1 || y > services.ClimatologyMaxBaselineYears {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("years は1〜%dで指定してください", services.ClimatologyMaxBaselineYears),
+			})
+			return startDate, endDate, 0, false
+		}
+		years = y
+	}
+
+	if startDateStr, endDateStr := c.Query("start_date"), c.Query("end_date"); startDateStr != "" || endDateStr != "" {
+		var errStart, errEnd error
+		startDate, errStart = time.Parse("2006-01-02", startDateStr)
+		endDate, errEnd = time.Parse("2006-01-02", endDateStr)
+		if errStart != nil || errEnd != nil || startDate.After(endDate) || endDate.Sub(startDate) > 365*24*time.Hour {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "start_date と end_date を YYYY-MM-DD 形式で、366日以内の期間で指定してください",
+			})
+			return startDate, endDate, 0, false
+		}
+		return startDate, endDate, years, true
+	}
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 365 {
+			days = d
+		}
+	}
+	endDate = time.Now().AddDate(0, 0, -1)
+	startDate = endDate.AddDate(0, 0, -days+1)
+	return startDate, endDate, years, true
+}
+
+// GetClimatology 日ごとの平年値（過去の同じ時期の平均・パーセンタイル）を取得
+func (wh *WeatherHandler) GetClimatology(c *gin.Context) {
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
+	}
+	startDate, endDate, years, ok := climatologyPeriod(c)
+	if !ok {
+		return
+	}
+
+	climatology, err := wh.weatherService.GetClimatology(regionCode, startDate, endDate, years)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"region_code": regionCode,
+		"start_date":  startDate.Format("2006-01-02"),
+		"end_date":    endDate.Format("2006-01-02"),
+		"years":       years,
+		"data":        climatology,
+	})
+}
+
+// GetWeatherAnomalies 日ごとの天候が平年と比べてどれだけ珍しかったか（平年差・パーセンタイル・記録）を取得
+// unusual_only=true の場合は珍しい天候の日だけを返す
+func (wh *WeatherHandler) GetWeatherAnomalies(c *gin.Context) {
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
+	}
+	startDate, endDate, years, ok := climatologyPeriod(c)
+	if !ok {
+		return
+	}
+
+	anomalies, err := wh.weatherService.GetWeatherAnomalies(regionCode, startDate, endDate, years)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+	if c.Query("unusual_only") == "true" {
+		unusual := []models.WeatherDayAnomaly{}
+		for _, anomaly := range anomalies {
+			if anomaly.Unusual {
+				unusual = append(unusual, anomaly)
+			}
+		}
+		anomalies = unusual
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"region_code": regionCode,
+		"start_date":  startDate.Format("2006-01-02"),
+		"end_date":    endDate.Format("2006-01-02"),
+		"years":       years,
+		"data":        anomalies,
+		"count":       len(anomalies),
+	})
+}
+
+// forecastArchivePeriod 予報アーカイブの対象日の期間を start_date・end_date（YYYY-MM-DD）、または days（直近の日数、既定90日）から決める
+// days の場合は終了日を指定せず、まだ対象日が来ていない予報も含める
+func forecastArchivePeriod(c *gin.Context) (from, to string, ok bool) {
+	if from, to = c.Query("start_date"), c.Query("end_date"); from != "" || to != "" {
+		startDate, errStart := time.Parse("2006-01-02", from)
+		endDate, errEnd := time.Parse("2006-01-02", to)
+		if errStart != nil || errEnd != nil || startDate.After(endDate) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "start_date と end_date を YYYY-MM-DD 形式で指定してください",
+			})
+			return "", "", false
+		}
+		return from, to, true
+	}
+
+	days := 90
+	if daysStr := c.Query("days"); daysStr != "" {
+		if d, err := strconv.Atoi(daysStr); err == nil && d > 0 && d <= 400 {
+			days = d
+		}
+	}
+	return time.Now().AddDate(0, 0, -days).Format("2006-01-02"), "", true
+}
+
+// GetForecastArchive 保存した予報（発表時刻・対象日・値と、検証済みなら観測値との比較）を取得
+// lead_days を指定した場合はそのリードタイムの予報だけを返す
+func (wh *WeatherHandler) GetForecastArchive(c *gin.Context) {
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
+	}
+	from, to, ok := forecastArchivePeriod(c)
+	if !ok {
+		return
+	}
+	filter := services.ForecastArchiveFilter{LocationKey: regionCode, From: from, To: to}
+	if leadStr := c.Query("lead_days"); leadStr != "" {
+		lead, err := strconv.Atoi(leadStr)
+		if err != nil || lead < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "lead_days は0以上の整数で指定してください",
+			})
+			return
+		}
+		filter.LeadDays = &lead
+	}
+
+	records := wh.weatherService.ForecastArchive().Records(filter)
+	if records == nil {
+		records = []services.ArchivedForecast{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"region_code": regionCode,
+		"from":        from,
+		"to":          to,
+		"data":        records,
+		"count":       len(records),
+	})
+}
+
+// GetForecastSkill 保存した予報と後日の観測値を比べた予報精度（最高・最低気温の誤差、雨の的中率、ブライアスコア）をリードタイムごとに取得
+func (wh *WeatherHandler) GetForecastSkill(c *gin.Context) {
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
+	}
+	from, to, ok := forecastArchivePeriod(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"region_code": regionCode,
+		"data":        wh.weatherService.ForecastSkill(regionCode, from, to),
+	})
+}
+
 // GetWeatherTrendAnalysis 気象データのトレンド分析を取得
 func (wh *WeatherHandler) GetWeatherTrendAnalysis(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "240000" // デフォルト：三重県
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
 	}
 
 	// 分析期間の指定
@@ -374,9 +695,9 @@ func (wh *WeatherHandler) GetWeatherTrendAnalysis(c *gin.Context) {
 
 // GetWeatherDataByCategory カテゴリ別の気象データを取得
 func (wh *WeatherHandler) GetWeatherDataByCategory(c *gin.Context) {
-	regionCode := c.Param("regionCode")
-	if regionCode == "" {
-		regionCode = "240000" // デフォルト：三重県
+	regionCode, ok := locationKey(c, wh.weatherService, c.Param("regionCode"), "240000") // デフォルト：三重県
+	if !ok {
+		return
 	}
 
 	// カテゴリの指定
@@ -410,3 +731,81 @@ func (wh *WeatherHandler) GetWeatherDataByCategory(c *gin.Context) {
 		"data":        categoryData,
 	})
 }
+
+// locationKey site_id クエリで店舗・拠点が指定されていればその拠点ID、なければ地域コード（空の場合は既定値）を返す
+// 未登録の拠点が指定された場合は404を返して false
+func locationKey(c *gin.Context, weatherService *services.WeatherService, regionCode, defaultRegionCode string) (string, bool) {
+	if regionCode == "" {
+		regionCode = defaultRegionCode
+	}
+	key, err := weatherService.ResolveLocationKey(c.Query("site_id"), regionCode)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrSiteNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return "", false
+	}
+	return key, true
+}
+
+// GetCacheStats 気象データキャッシュの利用状況を取得（管理者向け）
+func (wh *WeatherHandler) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    wh.weatherService.Cache().Stats(),
+	})
+}
+
+// GetForecastArchiveStats 予報アーカイブの件数を取得（管理者向け）
+func (wh *WeatherHandler) GetForecastArchiveStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    wh.weatherService.ForecastArchive().Stats(),
+	})
+}
+
+// VerifyForecasts 対象日を過ぎた未検証の予報を観測値と比較する検証ジョブを今すぐ実行（管理者向け）
+func (wh *WeatherHandler) VerifyForecasts(c *gin.Context) {
+	run := wh.weatherService.VerifyForecasts(time.Now())
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+		"archive": wh.weatherService.ForecastArchive().Stats(),
+	})
+}
+
+// PurgeCache 気象データキャッシュを削除（管理者向け）
+// region_code・kind（forecast / historical）を指定した場合は該当するものだけを削除
+func (wh *WeatherHandler) PurgeCache(c *gin.Context) {
+	regionCode := c.Query("region_code")
+	kind := c.Query("kind")
+	if kind != "" && kind != "forecast" && kind != "historical" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "kind は forecast または historical を指定してください",
+		})
+		return
+	}
+
+	removed := wh.weatherService.Cache().Purge(regionCode, kind)
+	if err := wh.weatherService.Cache().Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"removed":     removed,
+		"region_code": regionCode,
+		"kind":        kind,
+		"data":        wh.weatherService.Cache().Stats(),
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"hunt-chat-api/pkg/models"
+	"hunt-chat-api/pkg/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler 異常通知のWebhook購読・デッドレターを管理するハンドラー
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhookHandler 新しいWebhookハンドラーを作成
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: webhookService}
+}
+
+// ListSubscriptions Webhook購読の一覧を取得（署名鍵は返さない）
+func (wh *WebhookHandler) ListSubscriptions(c *gin.Context) {
+	if !wh.available(c) {
+		return
+	}
+
+	subscriptions, err := wh.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		log.Printf("Webhook購読の取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	for i := range subscriptions {
+		subscriptions[i] = maskWebhookSecret(subscriptions[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":       true,
+		"subscriptions": subscriptions,
+		"count":         len(subscriptions),
+	})
+}
+
+// CreateSubscription Webhook購読を作成
+func (wh *WebhookHandler) CreateSubscription(c *gin.Context) {
+	if !wh.available(c) {
+		return
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	subscription, err := wh.webhookService.CreateSubscription(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "subscription": maskWebhookSecret(*subscription)})
+}
+
+// UpdateSubscription Webhook購読を更新
+func (wh *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	if !wh.available(c) {
+		return
+	}
+
+	var req models.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": "リクエスト形式が正しくありません: " + err.Error()})
+		return
+	}
+
+	subscription, err := wh.webhookService.UpdateSubscription(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "subscription": maskWebhookSecret(*subscription)})
+}
+
+// DeleteSubscription Webhook購読を削除
+func (wh *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	if !wh.available(c) {
+		return
+	}
+
+	if err := wh.webhookService.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Webhook購読を削除しました"})
+}
+
+// TestSubscription サンプルの異常を送って購読先の設定を確認
+func (wh *WebhookHandler) TestSubscription(c *gin.Context) {
+	if !wh.available(c) {
+		return
+	}
+
+	result, err := wh.webhookService.SendTest(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": result.Delivered, "result": result})
+}
+
+// ListDeadLetters 配信に失敗したWebhookを取得（?status=dead|replayed / ?subscription_id=）
+func (wh *WebhookHandler) ListDeadLetters(c *gin.Context) {
+	if !wh.available(c) {
+		return
+	}
+
+	deliveries, err := wh.webhookService.ListDeadLetters(c.Request.Context(), c.Query("status"), c.Query("subscription_id"))
+	if err != nil {
+		log.Printf("デッドレターの取得に失敗: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"dead_letters": deliveries,
+		"count":        len(deliveries),
+	})
+}
+
+// ReplayDeadLetter 配信に失敗したWebhookを再送
+func (wh *WebhookHandler) ReplayDeadLetter(c *gin.Context) {
+	if !wh.available(c) {
+		return
+	}
+
+	delivery, err := wh.webhookService.Replay(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := webhookErrorStatus(err)
+		if delivery != nil {
+			// 再送を試みて失敗した場合は、受信側のエラーとして最新の状態を返す
+			status = http.StatusBadGateway
+		}
+		c.JSON(status, gin.H{"success": false, "error": err.Error(), "dead_letter": delivery})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "dead_letter": delivery})
+}
+
+// available データベースが使えない場合は503を返してfalseを返す
+func (wh *WebhookHandler) available(c *gin.Context) bool {
+	if wh.webhookService.Available() {
+		return true
+	}
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"success": false,
+		"error":   "データベースサービスが利用できません。設定を確認してください。",
+	})
+	return false
+}
+
+// maskWebhookSecret 署名鍵をレスポンスから取り除く
+func maskWebhookSecret(subscription models.WebhookSubscription) models.WebhookSubscription {
+	subscription.HasSecret = subscription.Secret != ""
+	subscription.Secret = ""
+	return subscription
+}
+
+// webhookErrorStatus Webhook操作のエラーをHTTPステータスに変換
+func webhookErrorStatus(err error) int {
+	if errors.Is(err, services.ErrWebhookSubscriptionNotFound) || errors.Is(err, services.ErrWebhookDeliveryNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrWebhookAlreadyReplayed) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, services.ErrInvalidWebhookRequest) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
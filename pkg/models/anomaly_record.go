@@ -0,0 +1,71 @@
+package models
+
+// AnomalyStatuses 異常のライフサイクル上の状態
+var AnomalyStatuses = []string{"open", "acknowledged", "investigating", "explained", "dismissed"}
+
+// AnomalyRecord 分析レポートから登録された異常（製品・期間・粒度から決まるIDで重複を防ぐ）
+type AnomalyRecord struct {
+	AnomalyID       string              `json:"anomaly_id"`
+	ProductID       string              `json:"product_id"`
+	ProductName     string              `json:"product_name,omitempty"`
+	Period          string              `json:"period"`      // 異常の日付（週次・月次は期間の開始日）
+	Granularity     string              `json:"granularity"` // daily / weekly / monthly
+	Status          string              `json:"status"`      // open / acknowledged / investigating / explained / dismissed
+	Assignee        string              `json:"assignee,omitempty"`
+	Resolution      string              `json:"resolution,omitempty"` // explained・dismissed にした理由（false_positive など）
+	Detection       AnomalyDetection    `json:"detection"`            // 直近の検出結果
+	ReportIDs       []string            `json:"report_ids"`           // この異常を検出した分析レポート
+	DetectionCount  int                 `json:"detection_count"`      // 再分析を含めて検出された回数
+	Comments        []AnomalyComment    `json:"comments"`
+	History         []AnomalyTransition `json:"history"`
+	FirstDetectedAt string              `json:"first_detected_at"`
+	LastDetectedAt  string              `json:"last_detected_at"`
+	ResolvedAt      string              `json:"resolved_at,omitempty"`
+	UpdatedAt       string              `json:"updated_at"`
+}
+
+// AnomalyComment 異常へのコメント
+type AnomalyComment struct {
+	Author    string `json:"author,omitempty"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"created_at"`
+}
+
+// AnomalyTransition 状態・担当者の変更履歴
+type AnomalyTransition struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Actor string `json:"actor,omitempty"`
+	Note  string `json:"note,omitempty"`
+	At    string `json:"at"`
+}
+
+// AnomalyFilter 異常一覧の絞り込み条件（空の項目は絞り込まない）
+type AnomalyFilter struct {
+	Status      string `json:"status,omitempty"` // カンマ区切りで複数指定可
+	ProductID   string `json:"product_id,omitempty"`
+	Granularity string `json:"granularity,omitempty"`
+	Assignee    string `json:"assignee,omitempty"`
+	From        string `json:"from,omitempty"` // この日以降の異常
+	To          string `json:"to,omitempty"`   // この日以前の異常
+}
+
+// AnomalyTransitionRequest 状態遷移リクエスト
+type AnomalyTransitionRequest struct {
+	Status     string `json:"status" binding:"required"`
+	Actor      string `json:"actor"`
+	Note       string `json:"note"`
+	Resolution string `json:"resolution"`
+}
+
+// AnomalyAssignRequest 担当者の割り当てリクエスト（空文字で割り当て解除）
+type AnomalyAssignRequest struct {
+	Assignee string `json:"assignee"`
+	Actor    string `json:"actor"`
+}
+
+// AnomalyCommentRequest コメント追加リクエスト
+type AnomalyCommentRequest struct {
+	Author string `json:"author"`
+	Text   string `json:"text" binding:"required"`
+}
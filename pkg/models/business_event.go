@@ -0,0 +1,102 @@
+package models
+
+// BusinessEventTypes イベント知識ベースで扱うイベントの種類
+var BusinessEventTypes = []string{"campaign", "weather", "competitor", "supply", "holiday", "local_event"}
+
+// BusinessEvent 異常への回答などから構造化した、売上に影響する出来事
+type BusinessEvent struct {
+	EventID       string   `json:"event_id"`
+	EventType     string   `json:"event_type"` // campaign/weather/competitor/supply/holiday/local_event
+	Title         string   `json:"title"`
+	Description   string   `json:"description,omitempty"`
+	StartDate     string   `json:"start_date"` // YYYY-MM-DD
+	EndDate       string   `json:"end_date"`   // YYYY-MM-DD（単日の場合は StartDate と同じ）
+	ProductIDs    []string `json:"product_ids"`
+	RegionCode    string   `json:"region_code,omitempty"`
+	UpliftPercent *float64 `json:"uplift_percent,omitempty"` // 予測値に対する売上の変化率（%）。不明な場合はnil
+	UpliftSource  string   `json:"uplift_source,omitempty"`  // measured（実績と予測の差）/ reported（回答の影響値）/ manual
+	Source        string   `json:"source"`                   // anomaly_response / anomaly_session / manual
+	SourceID      string   `json:"source_id,omitempty"`      // 元の回答ID・セッションID
+	Tags          []string `json:"tags,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
+// BusinessEventRequest イベントの作成・更新リクエスト
+type BusinessEventRequest struct {
+	EventType     string   `json:"event_type" binding:"required"`
+	Title         string   `json:"title" binding:"required"`
+	Description   string   `json:"description"`
+	StartDate     string   `json:"start_date" binding:"required"`
+	EndDate       string   `json:"end_date"`
+	ProductIDs    []string `json:"product_ids"`
+	RegionCode    string   `json:"region_code"`
+	UpliftPercent *float64 `json:"uplift_percent"`
+	Tags          []string `json:"tags"`
+}
+
+// BusinessEventFilter イベント一覧の絞り込み条件（空の項目は絞り込まない）
+type BusinessEventFilter struct {
+	EventType string `json:"event_type,omitempty"`
+	ProductID string `json:"product_id,omitempty"`
+	From      string `json:"from,omitempty"` // この日以降に終了したイベント
+	To        string `json:"to,omitempty"`   // この日以前に開始したイベント
+}
+
+// EventEffectEstimate イベントの種類（・製品）ごとの効果量の推定
+type EventEffectEstimate struct {
+	EventType     string   `json:"event_type"`
+	ProductID     string   `json:"product_id,omitempty"`
+	Count         int      `json:"count"`          // 効果が計測されたイベント数
+	MeanUplift    float64  `json:"mean_uplift"`    // 平均変化率（%）
+	StdDev        float64  `json:"std_dev"`        // 変化率の標準偏差
+	StandardError float64  `json:"standard_error"` // 平均の標準誤差
+	CILower       float64  `json:"ci_lower"`       // 平均の95%信頼区間（下限）
+	CIUpper       float64  `json:"ci_upper"`       // 平均の95%信頼区間（上限）
+	CohensD       float64  `json:"cohens_d"`       // 効果量（平均 / 標準偏差）
+	HedgesG       float64  `json:"hedges_g"`       // 小標本補正した効果量
+	PValue        float64  `json:"p_value"`        // 平均が0と異なるかの両側t検定
+	Examples      []string `json:"examples"`       // 直近のイベント例
+	LastUpdated   string   `json:"last_updated"`
+}
+
+// EventRegressor 予測に回帰要因として組み込むイベント（期待される変化率つき）
+type EventRegressor struct {
+	EventID       string   `json:"event_id,omitempty"`
+	EventType     string   `json:"event_type"`
+	Title         string   `json:"title"`
+	StartDate     string   `json:"start_date"` // YYYY-MM-DD
+	EndDate       string   `json:"end_date"`   // YYYY-MM-DD（省略時は StartDate と同じ）
+	ProductIDs    []string `json:"product_ids,omitempty"`
+	UpliftPercent float64  `json:"uplift_percent"`       // 期待される売上の変化率（%）
+	UpliftSource  string   `json:"uplift_source"`        // manual / measured / reported / learned（過去の類似イベントから推定）
+	Confidence    float64  `json:"confidence,omitempty"` // learned の場合の推定の信頼度（0-1）
+}
+
+// EventContribution 1日の予測値に対するイベントの寄与
+type EventContribution struct {
+	EventID       string  `json:"event_id,omitempty"`
+	EventType     string  `json:"event_type"`
+	Title         string  `json:"title"`
+	UpliftPercent float64 `json:"uplift_percent"`
+	Contribution  float64 `json:"contribution"` // 予測値への寄与（基準値 × 変化率）
+}
+
+// ForecastAccuracy 予測精度の指標
+type ForecastAccuracy struct {
+	MAE  float64 `json:"mae"`
+	RMSE float64 `json:"rmse"`
+	MAPE float64 `json:"mape"` // %
+}
+
+// EventBacktestResult イベントを回帰要因に使った場合と使わない場合の予測精度の比較
+type EventBacktestResult struct {
+	HoldoutStart    string           `json:"holdout_start"`
+	HoldoutEnd      string           `json:"holdout_end"`
+	HoldoutDays     int              `json:"holdout_days"`
+	EventDays       int              `json:"event_days"` // 検証期間のうちイベントが重なった日数
+	WithEvents      ForecastAccuracy `json:"with_events"`
+	WithoutEvents   ForecastAccuracy `json:"without_events"`
+	MAPEImprovement float64          `json:"mape_improvement"` // イベントありでのMAPEの改善幅（ポイント、正なら改善）
+	Summary         string           `json:"summary"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// CrossProductAnomaly カテゴリ全体の動きと比べて検出した製品の異常
+type CrossProductAnomaly struct {
+	Date          string  `json:"date"` // 期間キー（異常検知と同じ形式）
+	ProductID     string  `json:"product_id"`
+	ProductName   string  `json:"product_name,omitempty"`
+	ActualValue   float64 `json:"actual_value"`   // 期間の1日あたり平均
+	ExpectedValue float64 `json:"expected_value"` // isolated: 製品の通常水準 × カテゴリ指数 / systemic: 製品の通常水準
+	CategoryIndex float64 `json:"category_index"` // 製品ごとの通常水準に対する比率の中央値（1.0 = 通常）
+	Residual      float64 `json:"residual"`       // 製品の比率 - カテゴリ指数
+	RobustZ       float64 `json:"robust_z"`       // 残差のロバストZスコア（中央値・MADで標準化）
+	CategoryZ     float64 `json:"category_z"`     // カテゴリ指数のロバストZスコア
+	AnomalyType   string  `json:"anomaly_type"`   // "急増" or "急減"
+	Scope         string  `json:"scope"`          // "isolated"（製品単独）or "systemic"（カテゴリ全体）
+	Severity      string  `json:"severity"`
+	IncidentID    string  `json:"incident_id"`
+}
+
+// AnomalyIncident 同じ原因と考えられる異常のまとまり（インシデントごとに1つの質問をする）
+type AnomalyIncident struct {
+	IncidentID            string   `json:"incident_id"`
+	Scope                 string   `json:"scope"` // "isolated" or "systemic"
+	AnomalyType           string   `json:"anomaly_type"`
+	StartPeriod           string   `json:"start_period"`
+	EndPeriod             string   `json:"end_period"`
+	ProductIDs            []string `json:"product_ids"`
+	ProductNames          []string `json:"product_names,omitempty"`
+	AnomalyCount          int      `json:"anomaly_count"`
+	Severity              string   `json:"severity"`                          // 含まれる異常のうち最も深刻なもの
+	ChangePercent         float64  `json:"change_percent"`                    // 通常水準からの平均変化率（%）
+	CategoryChangePercent float64  `json:"category_change_percent,omitempty"` // カテゴリ指数の変化率（%、systemicのみ）
+	Summary               string   `json:"summary"`
+	Question              string   `json:"question"`
+	QuestionChoices       []string `json:"question_choices"`
+}
+
+// CrossProductAnalysis 複数製品をまとめて見た異常検知の結果
+type CrossProductAnalysis struct {
+	Granularity string                `json:"granularity"`
+	Products    int                   `json:"products"` // 分析に使った製品数
+	Periods     int                   `json:"periods"`
+	Anomalies   []CrossProductAnomaly `json:"anomalies"`
+	Incidents   []AnomalyIncident     `json:"incidents"`
+}
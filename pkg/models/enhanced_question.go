@@ -55,29 +55,62 @@ type VerificationQuestion struct {
 
 // FollowUpQuestion フォローアップ質問
 type FollowUpQuestion struct {
-	ID              string   `json:"id"`
-	AnomalyID       string   `json:"anomaly_id"`
-	SessionID       string   `json:"session_id"`
-	QuestionType    string   `json:"question_type"` // short_term_effect/medium_term_effect/long_term_pattern/yearly_review
-	ScheduledDate   string   `json:"scheduled_date"`
-	Status          string   `json:"status"` // pending/sent/answered/skipped
-	Question        string   `json:"question"`
-	Choices         []string `json:"choices"`
-	Context         string   `json:"context"`          // 元の異常の情報
-	PreviousAnswers []string `json:"previous_answers"` // 過去の回答
-	Priority        int      `json:"priority"`         // 優先度
+	ID              string          `json:"id"`
+	AnomalyID       string          `json:"anomaly_id"`
+	SessionID       string          `json:"session_id"`
+	QuestionType    string          `json:"question_type"` // short_term_effect/medium_term_effect/long_term_pattern/yearly_review
+	ScheduledDate   string          `json:"scheduled_date"`
+	Status          string          `json:"status"` // pending/sent/answered/skipped
+	Question        string          `json:"question"`
+	Choices         []string        `json:"choices"`
+	Context         string          `json:"context"`          // 元の異常の情報
+	PreviousAnswers []string        `json:"previous_answers"` // 過去の回答
+	Priority        int             `json:"priority"`         // 優先度
+	ProductID       string          `json:"product_id"`
+	AnomalyDate     string          `json:"anomaly_date"`
+	OffsetDays      int             `json:"offset_days"`      // 異常発生日から何日後に質問するか
+	Answer          string          `json:"answer,omitempty"` // フォローアップへの回答
+	CreatedAt       string          `json:"created_at"`
+	SentAt          string          `json:"sent_at,omitempty"`     // 提示した日時
+	AnsweredAt      string          `json:"answered_at,omitempty"` // 回答またはスキップした日時
+	Analysis        *AnswerAnalysis `json:"analysis,omitempty"`    // 回答の品質分析
+}
+
+// FollowUpStatusUpdateRequest フォローアップ質問の状態更新リクエスト
+type FollowUpStatusUpdateRequest struct {
+	Status string `json:"status" binding:"required"` // sent/answered/skipped
+	Answer string `json:"answer"`                    // status が answered の場合は必須
 }
 
 // QuestionQualityMetrics 質問の品質指標
+// 回答を取り込む前後の予測誤差（MAPEの改善度）は、回答と予測結果を結び付ける記録がないため集計しない（予測への貢献は PredictiveValue で見る）
 type QuestionQualityMetrics struct {
-	QuestionID            string  `json:"question_id"`
-	ResponseRate          float64 `json:"response_rate"`          // 回答率
-	SpecificityScore      float64 `json:"specificity_score"`      // 具体性スコア (0-100)
-	InformationDensity    float64 `json:"information_density"`    // 情報密度（有用情報数/文字数）
-	FollowUpSuccessRate   float64 `json:"follow_up_success_rate"` // 深掘り成功率
-	PredictionImprovement float64 `json:"prediction_improvement"` // 予測精度改善度 (MAPE減少率)
-	AverageAnswerLength   int     `json:"average_answer_length"`  // 平均回答文字数
-	UserSatisfaction      float64 `json:"user_satisfaction"`      // ユーザー満足度 (1-5)
+	QuestionID          string  `json:"question_id"`
+	ResponseRate        float64 `json:"response_rate"`          // 回答率
+	SpecificityScore    float64 `json:"specificity_score"`      // 具体性スコア (0-100)
+	InformationDensity  float64 `json:"information_density"`    // 情報密度（有用情報数/文字数）
+	FollowUpSuccessRate float64 `json:"follow_up_success_rate"` // 深掘り成功率
+	AverageAnswerLength int     `json:"average_answer_length"`  // 平均回答文字数
+	UserSatisfaction    float64 `json:"user_satisfaction"`      // ユーザー満足度 (1-5)
+
+	// 集計単位と件数（/question-analytics で使用）
+	Dimension       string  `json:"dimension,omitempty"`      // template / hypothesis_category
+	Key             string  `json:"key,omitempty"`            // 質問テンプレート名または仮説カテゴリ
+	AskedCount      int     `json:"asked_count"`              // 質問した数
+	AnsweredCount   int     `json:"answered_count"`           // 回答された数
+	ScoredCount     int     `json:"scored_count"`             // 品質分析済みの回答数
+	ActionableRate  float64 `json:"actionable_rate"`          // 実行可能な情報を含む回答の割合
+	PredictiveValue float64 `json:"predictive_value"`         // 予測への貢献度の平均 (0-100)
+	Recommendation  string  `json:"recommendation,omitempty"` // keep/improve/retire/insufficient_data
+}
+
+// QuestionEffectivenessReport 質問テンプレート・仮説カテゴリごとの質問効果の集計
+type QuestionEffectivenessReport struct {
+	GeneratedAt          string                   `json:"generated_at"`
+	TotalQuestions       int                      `json:"total_questions"`
+	ScoredAnswers        int                      `json:"scored_answers"`
+	ByTemplate           []QuestionQualityMetrics `json:"by_template"`
+	ByHypothesisCategory []QuestionQualityMetrics `json:"by_hypothesis_category"`
 }
 
 // AnswerAnalysis 回答の分析結果
@@ -88,6 +121,8 @@ type AnswerAnalysis struct {
 	Sentiment         string   `json:"sentiment"`          // positive/neutral/negative
 	Actionable        bool     `json:"actionable"`         // 実行可能な情報か
 	PredictiveValue   int      `json:"predictive_value"`   // 予測への貢献度 (0-100)
+	Method            string   `json:"method,omitempty"`   // llm / heuristic
+	AnalyzedAt        string   `json:"analyzed_at,omitempty"`
 }
 
 // Entity 抽出されたエンティティ
@@ -96,3 +131,70 @@ type Entity struct {
 	Value   string `json:"value"`   // 実際の値
 	Context string `json:"context"` // 文脈
 }
+
+// HypothesisState インタビュー中の仮説と検証状況
+type HypothesisState struct {
+	Hypothesis
+	InitialConfidence float64 `json:"initial_confidence"`    // 生成時の信頼度
+	Status            string  `json:"status"`                // pending/asked/supported/refuted/inconclusive
+	Answer            string  `json:"answer,omitempty"`      // 検証質問への回答
+	Reasoning         string  `json:"reasoning,omitempty"`   // 信頼度更新の根拠
+	AskedOrder        int     `json:"asked_order,omitempty"` // 何番目に質問したか（1始まり、0は未質問）
+}
+
+// HypothesisAssessment 回答が各仮説をどれだけ支持するかの評価
+type HypothesisAssessment struct {
+	HypothesisID string  `json:"hypothesis_id"`
+	Support      float64 `json:"support"`   // -1.0（否定）〜 1.0（支持）
+	Reasoning    string  `json:"reasoning"` // 判断理由
+}
+
+// RankedCause 根本原因の候補（信頼度順）
+type RankedCause struct {
+	Rank         int     `json:"rank"`
+	HypothesisID string  `json:"hypothesis_id"`
+	Category     string  `json:"category"`
+	Title        string  `json:"title"`
+	Confidence   float64 `json:"confidence"`
+	Status       string  `json:"status"`
+	Evidence     string  `json:"evidence,omitempty"` // 回答・データ上の根拠
+}
+
+// RootCauseConclusion インタビューの結論（順位付けされた根本原因）
+type RootCauseConclusion struct {
+	PrimaryCause string        `json:"primary_cause"` // 最有力の原因
+	Summary      string        `json:"summary"`
+	RankedCauses []RankedCause `json:"ranked_causes"`
+	Questions    int           `json:"questions"` // 実施した検証質問の数
+	ConcludedAt  string        `json:"concluded_at"`
+}
+
+// AnomalyInterviewStartRequest 異常インタビューの開始リクエスト
+type AnomalyInterviewStartRequest struct {
+	AnomalyDate string `json:"anomaly_date" binding:"required"`
+	ProductID   string `json:"product_id" binding:"required"`
+	RegionCode  string `json:"region_code,omitempty"` // 気象コンテキストの地域（デフォルト: 240000）
+	UserID      string `json:"user_id,omitempty"`
+}
+
+// AnomalyInterviewAnswerRequest 異常インタビューの回答リクエスト
+type AnomalyInterviewAnswerRequest struct {
+	SessionID  string `json:"session_id" binding:"required"`
+	Answer     string `json:"answer" binding:"required"`
+	AnswerType string `json:"answer_type"` // "choice", "free_text"
+}
+
+// AnomalyInterviewResponse 異常インタビューのレスポンス（次の質問または結論）
+type AnomalyInterviewResponse struct {
+	Success            bool                 `json:"success"`
+	SessionID          string               `json:"session_id"`
+	IsComplete         bool                 `json:"is_complete"`
+	PrimaryQuestion    string               `json:"primary_question,omitempty"` // 開始時の状況説明
+	ContextSummary     []string             `json:"context_summary,omitempty"`
+	NextQuestion       string               `json:"next_question,omitempty"`
+	NextChoices        []string             `json:"next_choices,omitempty"`
+	HypothesisID       string               `json:"hypothesis_id,omitempty"` // 次の質問が検証する仮説
+	Hypotheses         []HypothesisState    `json:"hypotheses"`
+	Conclusion         *RootCauseConclusion `json:"conclusion,omitempty"`
+	ScheduledFollowUps []FollowUpQuestion   `json:"scheduled_follow_ups,omitempty"` // 完了時に計画したフォローアップ質問
+}
@@ -0,0 +1,34 @@
+package models
+
+// RegimeShift 売上水準が持続的に変化した変化点（新店舗の開店・価格改定など）
+type RegimeShift struct {
+	Date             string  `json:"date"`       // 新しい水準が始まった期間（異常検知と同じ期間キー）
+	StartDate        string  `json:"start_date"` // 新しい水準が始まった日（YYYY-MM-DD）
+	ProductID        string  `json:"product_id,omitempty"`
+	ProductName      string  `json:"product_name,omitempty"`
+	Granularity      string  `json:"granularity"` // daily / weekly / monthly
+	BeforeMean       float64 `json:"before_mean"` // 変化点直前の区間の1日あたり平均
+	AfterMean        float64 `json:"after_mean"`  // 変化点以降の区間の1日あたり平均
+	Magnitude        float64 `json:"magnitude"`   // AfterMean - BeforeMean
+	MagnitudePercent float64 `json:"magnitude_percent,omitempty"`
+	Direction        string  `json:"direction"`      // "上昇" or "下降"
+	BeforePeriods    int     `json:"before_periods"` // 直前の区間の期間数
+	AfterPeriods     int     `json:"after_periods"`  // 変化点以降の区間の期間数
+}
+
+// RegimeShiftRequest 変化点検出リクエスト
+type RegimeShiftRequest struct {
+	Sales            []float64 `json:"sales" binding:"required"`
+	Dates            []string  `json:"dates" binding:"required"`
+	ProductID        string    `json:"product_id,omitempty"`
+	ProductName      string    `json:"product_name,omitempty"`
+	Granularity      string    `json:"granularity"`        // "daily", "weekly", "monthly" (default: "weekly")
+	MinSegmentLength int       `json:"min_segment_length"` // 区間の最小期間数（省略時は粒度ごとの既定値）
+}
+
+// RegimeShiftResponse 変化点検出レスポンス
+type RegimeShiftResponse struct {
+	Success      bool          `json:"success"`
+	RegimeShifts []RegimeShift `json:"regime_shifts"`
+	Message      string        `json:"message,omitempty"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// Site 店舗・拠点（気象データを取得する地点）
+type Site struct {
+	ID                 string  `json:"id"` // 店舗・拠点ID（英小文字・数字・-・_）
+	Name               string  `json:"name"`
+	Lat                float64 `json:"lat"`
+	Lon                float64 `json:"lon"`
+	MunicipalityCode   string  `json:"municipality_code,omitempty"`   // 全国地方公共団体コード（5桁。例: 鈴鹿市 24207）
+	ForecastOfficeCode string  `json:"forecast_office_code"`          // 気象庁の予報を発表する府県予報区（例: 240000）
+	AreaCode           string  `json:"area_code,omitempty"`           // 一次細分区域（例: 240010 北中部）。空の場合は予報区の最初の区域
+	StationCode        string  `json:"station_code,omitempty"`        // 最寄りのアメダス観測所
+	StationName        string  `json:"station_name,omitempty"`        // 最寄りのアメダス観測所名
+	StationDistanceKm  float64 `json:"station_distance_km,omitempty"` // 観測所までの距離
+	StationPinned      bool    `json:"station_pinned,omitempty"`      // 観測所を手動で指定した（座標の変更で再計算しない）
+	CreatedAt          string  `json:"created_at,omitempty"`
+	UpdatedAt          string  `json:"updated_at,omitempty"`
+}
+
+// SiteRequest 店舗・拠点の登録・更新リクエスト
+// 予報区は省略時に全国地方公共団体コードから、観測所は省略時に座標から求める
+type SiteRequest struct {
+	ID                 string   `json:"id"` // 登録時のみ使用
+	Name               string   `json:"name" binding:"required"`
+	Lat                *float64 `json:"lat" binding:"required"`
+	Lon                *float64 `json:"lon" binding:"required"`
+	MunicipalityCode   string   `json:"municipality_code"`
+	ForecastOfficeCode string   `json:"forecast_office_code"`
+	AreaCode           string   `json:"area_code"`
+	StationCode        string   `json:"station_code"`
+}
@@ -12,21 +12,66 @@ type ChatRequest struct {
 
 // ContextSource コンテキストソースの情報（スコア付き）
 type ContextSource struct {
-	Type     string  `json:"type"`           // "chat_history", "document", "analysis_report", "file_analysis"
-	FileName string  `json:"file_name"`      // ファイル名やドキュメント名
-	Score    float32 `json:"score"`          // 類似度スコア (0.0-1.0)
-	Date     string  `json:"date,omitempty"` // 日付（チャット履歴や分析レポートの場合）
+	Type         string  `json:"type"`                    // "chat_history", "document", "analysis_report", "file_analysis"
+	FileName     string  `json:"file_name"`               // ファイル名やドキュメント名
+	Score        float32 `json:"score"`                   // 類似度スコア (0.0-1.0)
+	Date         string  `json:"date,omitempty"`          // 日付（チャット履歴や分析レポートの場合）
+	VectorScore  float32 `json:"vector_score,omitempty"`  // ベクトル検索のコサイン類似度
+	VectorRank   int     `json:"vector_rank,omitempty"`   // ベクトル検索での順位（1始まり、0は該当なし）
+	LexicalScore float64 `json:"lexical_score,omitempty"` // BM25スコア
+	LexicalRank  int     `json:"lexical_rank,omitempty"`  // BM25検索での順位（1始まり、0は該当なし）
+	FusedScore   float64 `json:"fused_score,omitempty"`   // RRFによる融合スコア（0.0-1.0に正規化）
+	RerankScore  float64 `json:"rerank_score,omitempty"`  // リランキング後のスコア
+	Tokens       int     `json:"tokens,omitempty"`        // 推定トークン数
+	Included     bool    `json:"included"`                // プロンプトに採用されたか
+	Truncated    bool    `json:"truncated,omitempty"`     // トークン予算に合わせて切り詰めたか
+	DropReason   string  `json:"drop_reason,omitempty"`   // 不採用の理由（"duplicate", "token_budget"）
+}
+
+// RetrievalPlan 意図ごとの検索プラン（各ソースの候補件数）
+type RetrievalPlan struct {
+	ChatHistory      int    `json:"chat_history"`             // チャット履歴の候補数
+	Documents        int    `json:"documents"`                // ドキュメントの候補数
+	Reports          int    `json:"reports"`                  // 分析レポートの候補数
+	AnomalyResponses int    `json:"anomaly_responses"`        // 過去の異常回答の候補数
+	WeatherDays      int    `json:"weather_days"`             // 参照する直近の気象データ日数
+	WeatherRegion    string `json:"weather_region,omitempty"` // 気象データの地域コード
+}
+
+// ChatRoute チャットメッセージの意図ルーティング結果
+type ChatRoute struct {
+	Intent          string        `json:"intent"`                     // 意図名（"docs", "reports", "forecast" など）
+	Label           string        `json:"label"`                      // 意図の表示名
+	Action          string        `json:"action"`                     // 実行するアクション
+	Tool            string        `json:"tool,omitempty"`             // 最初の応答で必ず呼び出すチャットツール
+	Method          string        `json:"method"`                     // 分類方法（"llm", "keyword", "default"）
+	MatchedKeywords []string      `json:"matched_keywords,omitempty"` // キーワード分類で一致した語
+	Plan            RetrievalPlan `json:"plan"`                       // 適用した検索プラン
+}
+
+// ToolInvocation チャットのエージェントループで実行したツール呼び出しの記録
+type ToolInvocation struct {
+	ID            string `json:"id"`                       // モデルが発行したツール呼び出しID
+	Name          string `json:"name"`                     // ツール名
+	Arguments     string `json:"arguments"`                // 引数（JSON文字列）
+	Status        string `json:"status"`                   // "success" or "error"
+	Error         string `json:"error,omitempty"`          // エラー内容
+	ResultPreview string `json:"result_preview,omitempty"` // 実行結果の先頭部分
+	DurationMs    int64  `json:"duration_ms"`              // 実行時間（ミリ秒）
+	Iteration     int    `json:"iteration"`                // 何回目のループで呼び出されたか（1始まり）
 }
 
 // ChatResponse represents the response from the chat API
 type ChatResponse struct {
-	Response          string          `json:"response"`
-	Timestamp         string          `json:"timestamp"`
-	Model             string          `json:"model"`
-	SessionID         string          `json:"session_id,omitempty"`
-	RelevantHistory   []string        `json:"relevant_history,omitempty"`   // 関連する過去の会話
-	ContextSources    []ContextSource `json:"context_sources,omitempty"`    // コンテキストのソース情報（スコア付き）
-	ConversationCount int             `json:"conversation_count,omitempty"` // 使用した過去の会話数
+	Response          string           `json:"response"`
+	Timestamp         string           `json:"timestamp"`
+	Model             string           `json:"model"`
+	SessionID         string           `json:"session_id,omitempty"`
+	RelevantHistory   []string         `json:"relevant_history,omitempty"`   // 関連する過去の会話
+	ContextSources    []ContextSource  `json:"context_sources,omitempty"`    // コンテキストのソース情報（スコア付き）
+	ConversationCount int              `json:"conversation_count,omitempty"` // 使用した過去の会話数
+	Route             *ChatRoute       `json:"route,omitempty"`              // 意図ルーティングの結果
+	ToolInvocations   []ToolInvocation `json:"tool_invocations,omitempty"`   // エージェントループで実行したツール
 }
 
 // ChatHistoryEntry チャット履歴の1エントリー
@@ -83,6 +128,26 @@ type ChatHistorySearchResponse struct {
 	Message string             `json:"message,omitempty"`
 }
 
+// ChatSessionInfo チャットセッションの一覧表示用の概要
+type ChatSessionInfo struct {
+	SessionID      string `json:"session_id"`
+	UserID         string `json:"user_id,omitempty"`
+	Title          string `json:"title"`            // 最初のユーザーメッセージ（先頭部分）
+	TurnCount      int    `json:"turn_count"`       // 保存されている発話数（user + assistant）
+	FirstMessageAt string `json:"first_message_at"` // 最初の発話の日時
+	LastMessageAt  string `json:"last_message_at"`  // 最後の発話の日時
+	HasSummary     bool   `json:"has_summary"`      // 古い発話の要約があるか
+}
+
+// ChatSessionSummary 長いセッションの古い発話をまとめた要約
+type ChatSessionSummary struct {
+	SessionID       string `json:"session_id"`
+	Summary         string `json:"summary"`
+	SummarizedTurns int    `json:"summarized_turns"` // 要約に含めた発話数（トランスクリプトの先頭から）
+	LastTurnAt      string `json:"last_turn_at"`     // 要約に含めた最後の発話の日時
+	UpdatedAt       string `json:"updated_at"`
+}
+
 // DemandForecastRequest represents a demand forecast request
 type DemandForecastRequest struct {
 	ProductID       string                 `json:"product_id" binding:"required"`
@@ -150,18 +215,44 @@ type RegressionResult struct {
 
 // AnalysisReport represents a comprehensive analysis report
 type AnalysisReport struct {
-	ReportID        string              `json:"report_id"`
-	FileName        string              `json:"file_name"`
-	AnalysisDate    string              `json:"analysis_date"`
-	DataPoints      int                 `json:"data_points"`
-	DateRange       string              `json:"date_range"`
-	WeatherMatches  int                 `json:"weather_matches"`
-	Summary         string              `json:"summary"`
-	Correlations    []CorrelationResult `json:"correlations"`
-	Regression      *RegressionResult   `json:"regression,omitempty"`
-	AIInsights      string              `json:"ai_insights"`
-	Recommendations []string            `json:"recommendations"`
-	Anomalies       []AnomalyDetection  `json:"anomalies"`
+	ReportID           string                     `json:"report_id"`
+	FileName           string                     `json:"file_name"`
+	AnalysisDate       string                     `json:"analysis_date"`
+	DataPoints         int                        `json:"data_points"`
+	DateRange          string                     `json:"date_range"`
+	WeatherMatches     int                        `json:"weather_matches"`
+	Summary            string                     `json:"summary"`
+	Correlations       []CorrelationResult        `json:"correlations"`
+	Regression         *RegressionResult          `json:"regression,omitempty"`
+	AIInsights         string                     `json:"ai_insights"`
+	Recommendations    []string                   `json:"recommendations"`
+	Anomalies          []AnomalyDetection         `json:"anomalies"`
+	RegimeShifts       []RegimeShift              `json:"regime_shifts,omitempty"`        // Persistent level shifts
+	CrossProduct       *CrossProductAnalysis      `json:"cross_product,omitempty"`        // Isolated vs systemic anomalies across products
+	WeatherAdjustments []WeatherAdjustment        `json:"weather_adjustments,omitempty"`  // Per-product weather regressions used as anomaly expectations
+	WeatherFeatures    []WeatherFeatureDefinition `json:"weather_features,omitempty"`     // Derived weather features included in the correlations
+	Granularity        string                     `json:"granularity,omitempty"`          // Aggregation used for anomaly detection (hourly/daily/weekly/monthly)
+	HourOfDayProfiles  []HourOfDayProfile         `json:"hour_of_day_profiles,omitempty"` // Per-product sales by hour of day (hourly granularity only)
+	ImputedWeatherDays int                        `json:"imputed_weather_days,omitempty"` // Days whose weather was gap-filled (excluded from correlations and regression)
+}
+
+// HourOfDayProfile represents how a product's sales are distributed over the day
+type HourOfDayProfile struct {
+	ProductID   string          `json:"product_id"`
+	ProductName string          `json:"product_name,omitempty"`
+	Days        int             `json:"days"`      // Days with sales of the product
+	PeakHour    int             `json:"peak_hour"` // Hour with the highest average sales
+	Hours       []HourOfDayStat `json:"hours"`
+}
+
+// HourOfDayStat represents sales statistics for one hour of the day
+type HourOfDayStat struct {
+	Hour              int      `json:"hour"`
+	AverageSales      float64  `json:"average_sales"`                 // Per day, counting days without sales in that hour as zero
+	SharePercent      float64  `json:"share_percent"`                 // Share of the product's total sales
+	RainyAverage      *float64 `json:"rainy_average,omitempty"`       // Average over rainy hours with sales (requires hourly weather)
+	DryAverage        *float64 `json:"dry_average,omitempty"`         // Average over dry hours with sales (requires hourly weather)
+	RainEffectPercent *float64 `json:"rain_effect_percent,omitempty"` // Rainy vs dry difference in percent
 }
 
 // AnalysisReportHeader represents the header information of an analysis report
@@ -174,13 +265,16 @@ type AnalysisReportHeader struct {
 
 // WeatherSalesData represents a single data point combining weather and sales
 type WeatherSalesData struct {
-	Date        string  `json:"date"`
-	ProductID   string  `json:"product_id"`
-	ProductName string  `json:"product_name,omitempty"` // 製品名（表示用）
-	Sales       float64 `json:"sales"`
-	Temperature float64 `json:"temperature"`
-	Humidity    float64 `json:"humidity"`
-	Weather     string  `json:"weather"`
+	Date           string  `json:"date"`
+	ProductID      string  `json:"product_id"`
+	ProductName    string  `json:"product_name,omitempty"` // 製品名（表示用）
+	Sales          float64 `json:"sales"`
+	Temperature    float64 `json:"temperature"`
+	Humidity       float64 `json:"humidity"`
+	Weather        string  `json:"weather"`
+	Precipitation  float64 `json:"precipitation,omitempty"`   // 降水量（mm）
+	DateTime       string  `json:"datetime,omitempty"`        // 時間帯（YYYY-MM-DD HH:00、hourly 粒度の場合のみ）
+	WeatherImputed bool    `json:"weather_imputed,omitempty"` // 結合した気象データに補完した値が含まれるか（気象回帰には使わない）
 }
 
 // SalesPrediction represents a future sales prediction with confidence interval
@@ -201,17 +295,24 @@ type ConfidenceInterval struct {
 
 // AnomalyDetection represents a detected anomaly in the data
 type AnomalyDetection struct {
-	Date            string   `json:"date"`
-	ProductID       string   `json:"product_id,omitempty"`   // 製品ID（内部識別用）
-	ProductName     string   `json:"product_name,omitempty"` // 製品名（表示用）
-	ActualValue     float64  `json:"actual_value"`
-	ExpectedValue   float64  `json:"expected_value"`
-	Deviation       float64  `json:"deviation"`             // Absolute deviation from expected
-	ZScore          float64  `json:"z_score"`               // Standard deviations from mean
-	AnomalyType     string   `json:"anomaly_type"`          // "急増" or "急減"
-	Severity        string   `json:"severity"`              // "low", "medium", "high", "critical"
-	AIQuestion      string   `json:"ai_question,omitempty"` // AI-generated question
-	QuestionChoices []string `json:"question_choices,omitempty"`
+	AnomalyID           string             `json:"anomaly_id,omitempty"` // 登録済みの異常のID
+	Date                string             `json:"date"`
+	ProductID           string             `json:"product_id,omitempty"`   // 製品ID（内部識別用）
+	ProductName         string             `json:"product_name,omitempty"` // 製品名（表示用）
+	ActualValue         float64            `json:"actual_value"`
+	ExpectedValue       float64            `json:"expected_value"`
+	Deviation           float64            `json:"deviation"`             // Absolute deviation from expected
+	ZScore              float64            `json:"z_score"`               // Standard deviations from mean
+	AnomalyType         string             `json:"anomaly_type"`          // "急増" or "急減"
+	Severity            string             `json:"severity"`              // "low", "medium", "high", "critical"
+	AIQuestion          string             `json:"ai_question,omitempty"` // AI-generated question
+	QuestionChoices     []string           `json:"question_choices,omitempty"`
+	Granularity         string             `json:"granularity,omitempty"`          // "daily", "weekly", "monthly"
+	ExpectationModel    string             `json:"expectation_model,omitempty"`    // "moving_average" or "weather_regression"
+	WeatherContribution float64            `json:"weather_contribution,omitempty"` // Part of the expected value explained by weather (vs. average weather)
+	Residual            float64            `json:"residual,omitempty"`             // Actual - expected, i.e. what weather does not explain
+	WeatherSummary      string             `json:"weather_summary,omitempty"`      // Weather during the period, for questions
+	WeatherAnomaly      *WeatherDayAnomaly `json:"weather_anomaly,omitempty"`      // How unusual the day's weather was against climatology
 }
 
 // PredictionRequest represents a request for sales prediction
@@ -238,36 +339,50 @@ type AnomalyDetectionResponse struct {
 
 // ProductForecastRequest represents a request for product-specific forecast
 type ProductForecastRequest struct {
-	ProductID   string `json:"product_id" binding:"required"`
-	ProductName string `json:"product_name,omitempty"`
-	Period      string `json:"period" binding:"required"` // "week", "2weeks", "month"
-	RegionCode  string `json:"region_code"`
-	StartDate   string `json:"start_date"` // Historical data start date
-	EndDate     string `json:"end_date"`   // Historical data end date
+	ProductID        string   `json:"product_id" binding:"required"`
+	ProductName      string   `json:"product_name,omitempty"`
+	Period           string   `json:"period" binding:"required"` // "week", "2weeks", "month"
+	RegionCode       string   `json:"region_code"`
+	StartDate        string   `json:"start_date"`                   // Historical data start date
+	EndDate          string   `json:"end_date"`                     // Historical data end date
+	UseEvents        *bool    `json:"use_events,omitempty"`         // Apply registered events as regressors (default: true)
+	Backtest         bool     `json:"backtest,omitempty"`           // Compare accuracy with and without events (and observed weather vs archived forecasts) on the latest period
+	LatestRegimeOnly bool     `json:"latest_regime_only,omitempty"` // Train only on data after the latest regime shift
+	SiteID           string   `json:"site_id,omitempty"`            // Registered site used instead of region_code for weather data
+	WeatherFeatures  []string `json:"weather_features,omitempty"`   // Derived weather features to use as regressors (see GET /weather/features)
 }
 
 // ProductForecast represents a forecast for a specific product
 type ProductForecast struct {
-	ProductID          string             `json:"product_id"`
-	ProductName        string             `json:"product_name"`
-	ForecastPeriod     string             `json:"forecast_period"` // "2025-01-15 〜 2025-01-21"
-	PredictedTotal     float64            `json:"predicted_total"` // Total demand for the period
-	DailyAverage       float64            `json:"daily_average"`   // Average per day
-	ConfidenceInterval ConfidenceInterval `json:"confidence_interval"`
-	Confidence         float64            `json:"confidence"`            // Model confidence (R²)
-	DailyBreakdown     []DailyForecast    `json:"daily_breakdown"`       // Day-by-day forecast
-	Factors            []string           `json:"factors"`               // Factors considered
-	Seasonality        string             `json:"seasonality,omitempty"` // e.g., "夏季需要増加傾向"
-	Recommendations    []string           `json:"recommendations"`
+	ProductID          string                         `json:"product_id"`
+	ProductName        string                         `json:"product_name"`
+	ForecastPeriod     string                         `json:"forecast_period"` // "2025-01-15 〜 2025-01-21"
+	PredictedTotal     float64                        `json:"predicted_total"` // Total demand for the period
+	DailyAverage       float64                        `json:"daily_average"`   // Average per day
+	ConfidenceInterval ConfidenceInterval             `json:"confidence_interval"`
+	Confidence         float64                        `json:"confidence"`            // Model confidence (R²)
+	DailyBreakdown     []DailyForecast                `json:"daily_breakdown"`       // Day-by-day forecast
+	Factors            []string                       `json:"factors"`               // Factors considered
+	Seasonality        string                         `json:"seasonality,omitempty"` // e.g., "夏季需要増加傾向"
+	Recommendations    []string                       `json:"recommendations"`
+	Events             []EventRegressor               `json:"events,omitempty"`             // Events applied as regressors
+	Backtest           *EventBacktestResult           `json:"backtest,omitempty"`           // Accuracy with vs without events
+	RegimeShift        *RegimeShift                   `json:"regime_shift,omitempty"`       // Latest regime shift used to trim training data
+	TrainingStart      string                         `json:"training_start,omitempty"`     // First date of the training data
+	WeatherRegressors  []WeatherFeatureRegressor      `json:"weather_regressors,omitempty"` // Derived weather features applied as regressors
+	WeatherBacktest    *WeatherForecastBacktestResult `json:"weather_backtest,omitempty"`   // Accuracy with observed weather vs forecasts archived at the time
 }
 
 // DailyForecast represents a single day's forecast
 type DailyForecast struct {
-	Date           string  `json:"date"`
-	DayOfWeek      string  `json:"day_of_week"` // "月", "火", etc.
-	PredictedValue float64 `json:"predicted_value"`
-	Temperature    float64 `json:"temperature,omitempty"`
-	Weather        string  `json:"weather,omitempty"`
+	Date                       string              `json:"date"`
+	DayOfWeek                  string              `json:"day_of_week"` // "月", "火", etc.
+	PredictedValue             float64             `json:"predicted_value"`
+	BaseValue                  float64             `json:"base_value,omitempty"` // Forecast before event effects
+	EventContributions         []EventContribution `json:"event_contributions,omitempty"`
+	WeatherFeatureContribution float64             `json:"weather_feature_contribution,omitempty"` // Forecast change from derived weather features
+	Temperature                float64             `json:"temperature,omitempty"`
+	Weather                    string              `json:"weather,omitempty"`
 }
 
 // ProductForecastResponse represents the response for product forecast
@@ -365,17 +480,18 @@ type WeeklyTrends struct {
 
 // AnomalyResponse represents a user's response to an AI question about an anomaly
 type AnomalyResponse struct {
-	ResponseID  string   `json:"response_id"`  // Unique ID for this response
-	AnomalyDate string   `json:"anomaly_date"` // Date of the anomaly
-	ProductID   string   `json:"product_id"`
-	Question    string   `json:"question"`     // The AI's question
-	Answer      string   `json:"answer"`       // User's answer
-	AnswerType  string   `json:"answer_type"`  // "text", "select", "yes_no"
-	Tags        []string `json:"tags"`         // Categories: "campaign", "weather", "event", etc.
-	Impact      string   `json:"impact"`       // "positive", "negative", "neutral"
-	ImpactValue float64  `json:"impact_value"` // Estimated % impact
-	Timestamp   string   `json:"timestamp"`
-	UserID      string   `json:"user_id,omitempty"`
+	ResponseID  string          `json:"response_id"`  // Unique ID for this response
+	AnomalyDate string          `json:"anomaly_date"` // Date of the anomaly
+	ProductID   string          `json:"product_id"`
+	Question    string          `json:"question"`     // The AI's question
+	Answer      string          `json:"answer"`       // User's answer
+	AnswerType  string          `json:"answer_type"`  // "text", "select", "yes_no"
+	Tags        []string        `json:"tags"`         // Categories: "campaign", "weather", "event", etc.
+	Impact      string          `json:"impact"`       // "positive", "negative", "neutral"
+	ImpactValue float64         `json:"impact_value"` // Estimated % impact
+	Timestamp   string          `json:"timestamp"`
+	UserID      string          `json:"user_id,omitempty"`
+	Analysis    *AnswerAnalysis `json:"analysis,omitempty"` // 回答の品質分析
 }
 
 // AnomalyResponseRequest represents a request to save a user's response
@@ -400,14 +516,16 @@ type AnomalyResponseHistory struct {
 
 // LearningInsight represents AI-learned insights from past responses
 type LearningInsight struct {
-	InsightID     string   `json:"insight_id"`
-	Category      string   `json:"category"`       // "campaign", "weather", "event", etc.
-	Pattern       string   `json:"pattern"`        // Description of the pattern
-	Examples      []string `json:"examples"`       // Example dates/events
-	AverageImpact float64  `json:"average_impact"` // Average % impact
-	Confidence    float64  `json:"confidence"`     // 0-1
-	LearnedFrom   int      `json:"learned_from"`   // Number of responses
-	LastUpdated   string   `json:"last_updated"`
+	InsightID     string               `json:"insight_id"`
+	Category      string               `json:"category"`       // "campaign", "weather", "event", etc.
+	Pattern       string               `json:"pattern"`        // Description of the pattern
+	Examples      []string             `json:"examples"`       // Example dates/events
+	AverageImpact float64              `json:"average_impact"` // Average % impact
+	Confidence    float64              `json:"confidence"`     // 0-1
+	LearnedFrom   int                  `json:"learned_from"`   // Number of responses
+	LastUpdated   string               `json:"last_updated"`
+	ProductID     string               `json:"product_id,omitempty"`
+	Effect        *EventEffectEstimate `json:"effect,omitempty"` // イベント知識ベースから推定した効果量
 }
 
 // LearningInsightsResponse represents AI's learned insights
@@ -429,6 +547,11 @@ type Conversation struct {
 	Answer          string   `json:"answer"`
 	Timestamp       string   `json:"timestamp"`
 	AnswerType      string   `json:"answer_type"` // "choice" or "free_text"
+
+	// 質問効果の分析用
+	QuestionTemplate   string          `json:"question_template,omitempty"`   // anomaly_question/follow_up_probe/hypothesis_verification
+	HypothesisCategory string          `json:"hypothesis_category,omitempty"` // 検証した仮説のカテゴリ（仮説検証型のみ）
+	Analysis           *AnswerAnalysis `json:"analysis,omitempty"`            // 回答の品質分析
 }
 
 // AnomalyResponseSession 異常に対する対話セッション全体
@@ -445,6 +568,13 @@ type AnomalyResponseSession struct {
 	CreatedAt        string         `json:"created_at"`
 	CompletedAt      string         `json:"completed_at,omitempty"`
 	UserID           string         `json:"user_id,omitempty"`
+
+	// 仮説検証型インタビュー（/anomaly-interview）で使用
+	Mode              string               `json:"mode,omitempty"`               // "interview"（仮説検証型）、空は従来の深掘り対話
+	AnomalyContext    string               `json:"anomaly_context,omitempty"`    // レポート・気象・類似回答から構築した状況
+	Hypotheses        []HypothesisState    `json:"hypotheses,omitempty"`         // 仮説と検証状況
+	CurrentHypothesis string               `json:"current_hypothesis,omitempty"` // 回答待ちの検証質問の仮説ID
+	RootCause         *RootCauseConclusion `json:"root_cause,omitempty"`         // 順位付けされた根本原因の結論
 }
 
 // AnswerEvaluation AIによる回答の評価結果
@@ -472,11 +602,12 @@ type SaveAnomalyResponseRequest struct {
 
 // SaveAnomalyResponseResponse 異常回答保存レスポンス（深掘り対応版）
 type SaveAnomalyResponseResponse struct {
-	Success          bool              `json:"success"`
-	SessionID        string            `json:"session_id"`
-	Message          string            `json:"message"`
-	NeedsFollowUp    bool              `json:"needs_follow_up"`              // 深掘り質問が必要か
-	Evaluation       *AnswerEvaluation `json:"evaluation,omitempty"`         // 評価結果
-	FollowUpQuestion string            `json:"follow_up_question,omitempty"` // 次の質問
-	FollowUpChoices  []string          `json:"follow_up_choices,omitempty"`  // 次の質問の選択肢
+	Success            bool               `json:"success"`
+	SessionID          string             `json:"session_id"`
+	Message            string             `json:"message"`
+	NeedsFollowUp      bool               `json:"needs_follow_up"`                // 深掘り質問が必要か
+	Evaluation         *AnswerEvaluation  `json:"evaluation,omitempty"`           // 評価結果
+	FollowUpQuestion   string             `json:"follow_up_question,omitempty"`   // 次の質問
+	FollowUpChoices    []string           `json:"follow_up_choices,omitempty"`    // 次の質問の選択肢
+	ScheduledFollowUps []FollowUpQuestion `json:"scheduled_follow_ups,omitempty"` // セッション完了時に計画したフォローアップ質問
 }
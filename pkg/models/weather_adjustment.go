@@ -0,0 +1,14 @@
+package models
+
+// WeatherAdjustment 売上を同日の気象条件で回帰した、気象調整済み異常検知のモデル要約
+type WeatherAdjustment struct {
+	ProductID    string             `json:"product_id"`
+	ProductName  string             `json:"product_name,omitempty"`
+	Applied      bool               `json:"applied"`                // false の場合は移動平均の期待値にフォールバック
+	Reason       string             `json:"reason,omitempty"`       // フォールバックした理由
+	SampleSize   int                `json:"sample_size"`            // 回帰に使った日数（気象データがある日のみ）
+	RSquared     float64            `json:"r_squared"`              // 気象条件で説明できる日次売上の変動の割合
+	MeanSales    float64            `json:"mean_sales"`             // 平均的な気象条件での1日あたり売上
+	Coefficients map[string]float64 `json:"coefficients,omitempty"` // 特徴量ごとの係数（平均的な気象条件からの差1単位あたり）
+	MeanWeather  map[string]float64 `json:"mean_weather,omitempty"` // 基準とした平均的な気象条件
+}
@@ -0,0 +1,48 @@
+package models
+
+// WeatherClimatologyStats ある時期の項目の平年の分布（過去の各年の前後7日の観測値から求める）
+type WeatherClimatologyStats struct {
+	Mean       float64 `json:"mean"`
+	StdDev     float64 `json:"std_dev"`
+	P10        float64 `json:"p10"`
+	P25        float64 `json:"p25"`
+	P50        float64 `json:"p50"`
+	P75        float64 `json:"p75"`
+	P90        float64 `json:"p90"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	SampleSize int     `json:"sample_size"`
+}
+
+// WeatherClimatologyDay 1日分の平年値
+type WeatherClimatologyDay struct {
+	Date     string                             `json:"date"`
+	MonthDay string                             `json:"month_day"` // MM-DD
+	Years    []int                              `json:"years"`     // 平年値に使った年（データがあった年のみ）
+	Fields   map[string]WeatherClimatologyStats `json:"fields"`    // 項目名（temperature など）→ 分布
+}
+
+// WeatherFieldAnomaly 項目の値が平年と比べてどれだけ珍しかったか
+type WeatherFieldAnomaly struct {
+	Field       string  `json:"field"`
+	Label       string  `json:"label"`
+	Unit        string  `json:"unit"`
+	Value       float64 `json:"value"`
+	Normal      float64 `json:"normal"`     // 平年値（平均）
+	Deviation   float64 `json:"deviation"`  // 平年値との差
+	Percentile  float64 `json:"percentile"` // 平年の分布の中での位置（0〜100）
+	ZScore      float64 `json:"z_score"`
+	Description string  `json:"description"`      // 例: +4.2℃（97パーセンタイル）
+	Record      string  `json:"record,omitempty"` // 例: 2019年以降の6月上旬で最も暑い日
+}
+
+// WeatherDayAnomaly 1日の天候が平年と比べてどれだけ珍しかったか
+type WeatherDayAnomaly struct {
+	Date          string                `json:"date"`
+	Score         float64               `json:"score"`             // 項目の Z スコアの絶対値の最大
+	Unusual       bool                  `json:"unusual"`           // 10パーセンタイル以下・90パーセンタイル以上、または記録的な項目がある
+	Summary       string                `json:"summary,omitempty"` // 例: 日平均気温は平年比+4.2℃（97パーセンタイル）
+	Record        string                `json:"record,omitempty"`  // 記録的な項目があればその説明
+	BaselineYears []int                 `json:"baseline_years"`
+	Fields        []WeatherFieldAnomaly `json:"fields"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// WeatherFeatureDefinition 気象データから派生させた特徴量の説明（APIレスポンスで各特徴量の意味を示す）
+type WeatherFeatureDefinition struct {
+	Name        string `json:"name"`           // 特徴量名（相関分析の factor・予測の weather_features で指定する名前）
+	Label       string `json:"label"`          // 表示名（例: 不快指数）
+	Unit        string `json:"unit,omitempty"` // 単位（0/1 のフラグは "flag"）
+	Description string `json:"description"`    // 計算方法
+}
+
+// WeatherFeatureDay 1日分の派生特徴量
+type WeatherFeatureDay struct {
+	Date   string             `json:"date"`
+	Values map[string]float64 `json:"values"` // 特徴量名 → 値
+}
+
+// WeatherFeatureRegressor 需要予測に回帰要因として使った気象特徴量
+type WeatherFeatureRegressor struct {
+	WeatherFeatureDefinition
+	Coefficient float64 `json:"coefficient"` // 特徴量が1単位増えたときの1日の売上の増減
+	Mean        float64 `json:"mean"`        // 学習期間の平均（予測日は平均との差に係数を掛けた分を加算）
+}
+
+// WeatherForecastBacktestResult 検証期間の需要予測を、実際の天候で計算した場合と当時入手できた予報で計算した場合の精度の比較
+// 両者の差が、需要予測の誤差のうち天気予報の誤差による部分
+type WeatherForecastBacktestResult struct {
+	HoldoutStart          string           `json:"holdout_start"`
+	HoldoutEnd            string           `json:"holdout_end"`
+	HoldoutDays           int              `json:"holdout_days"`
+	ForecastDays          int              `json:"forecast_days"` // 検証期間のうち当時の予報があった日数
+	IssuedBefore          string           `json:"issued_before"` // この時刻までに発表された予報を使用
+	WithObservedWeather   ForecastAccuracy `json:"with_observed_weather"`
+	WithArchivedForecasts ForecastAccuracy `json:"with_archived_forecasts"`
+	WeatherErrorMAPE      float64          `json:"weather_error_mape"` // 天気予報の誤差によるMAPEの悪化幅（ポイント）
+	Summary               string           `json:"summary"`
+}
@@ -0,0 +1,62 @@
+package models
+
+// WebhookFormats Webhookのペイロード形式
+var WebhookFormats = []string{"generic", "slack"}
+
+// WebhookSubscription 異常通知のWebhook購読
+type WebhookSubscription struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	Secret       string   `json:"secret,omitempty"` // HMAC署名の鍵（一覧・取得では返さない）
+	HasSecret    bool     `json:"has_secret"`
+	Format       string   `json:"format"`                  // generic / slack
+	Severities   []string `json:"severities"`              // 通知する深刻度（low/medium/high/critical）
+	ProductIDs   []string `json:"product_ids,omitempty"`   // 空の場合は全製品
+	AnomalyTypes []string `json:"anomaly_types,omitempty"` // 空の場合は全種類（急増/急減 など）
+	Enabled      bool     `json:"enabled"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+// WebhookSubscriptionRequest Webhook購読の作成・更新リクエスト
+type WebhookSubscriptionRequest struct {
+	Name         string   `json:"name"`
+	URL          string   `json:"url" binding:"required"`
+	Secret       string   `json:"secret"` // 更新時に空の場合は既存の鍵を保持
+	Format       string   `json:"format"`
+	Severities   []string `json:"severities"` // 省略時は critical のみ
+	ProductIDs   []string `json:"product_ids"`
+	AnomalyTypes []string `json:"anomaly_types"`
+	Enabled      *bool    `json:"enabled"` // 省略時は有効
+}
+
+// AnomalyNotification Webhookで通知する異常
+type AnomalyNotification struct {
+	AnomalyID     string  `json:"anomaly_id,omitempty"`
+	Date          string  `json:"date"`
+	ProductID     string  `json:"product_id"`
+	ProductName   string  `json:"product_name,omitempty"`
+	AnomalyType   string  `json:"anomaly_type"`
+	Severity      string  `json:"severity"`
+	ActualValue   float64 `json:"actual_value,omitempty"`
+	ExpectedValue float64 `json:"expected_value,omitempty"`
+	Deviation     float64 `json:"deviation,omitempty"`
+	Description   string  `json:"description,omitempty"`
+}
+
+// WebhookDelivery 配信に失敗したWebhook（デッドレター）
+type WebhookDelivery struct {
+	ID             string `json:"id"`
+	SubscriptionID string `json:"subscription_id"`
+	URL            string `json:"url"`
+	Event          string `json:"event"`
+	Source         string `json:"source"`
+	Payload        string `json:"payload"`
+	Status         string `json:"status"` // dead / replayed
+	Attempts       int    `json:"attempts"`
+	LastStatusCode int    `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	ReplayedAt     string `json:"replayed_at,omitempty"`
+}
@@ -0,0 +1,515 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	interviewMode             = "interview"
+	interviewMaxQuestions     = 4    // 検証質問の最大数
+	interviewConfirmThreshold = 0.85 // 検証済みの仮説がこの信頼度に達したら結論を出す
+	interviewDismissThreshold = 0.10 // 未質問の仮説がこの信頼度を下回ったら質問せずに棄却する
+	interviewSupportWeight    = 2.0  // 回答の支持度を対数オッズに反映する重み
+	interviewStatusThreshold  = 0.3  // 支持・否定と判定する支持度の絶対値
+)
+
+// ErrAnomalyNotFound 指定した日付・製品の異常が分析レポートに存在しない
+var ErrAnomalyNotFound = errors.New("指定された異常が分析レポートに見つかりません")
+
+// AnomalyInterviewService 仮説を立て、検証質問で信頼度を更新しながら異常の根本原因を特定するインタビュー
+type AnomalyInterviewService struct {
+	azureOpenAIService *AzureOpenAIService
+	vectorStoreService *VectorStoreService
+	weatherService     *WeatherService
+	answerQuality      *AnswerQualityService
+}
+
+// NewAnomalyInterviewService 新しい異常インタビューサービスを作成
+func NewAnomalyInterviewService(azureOpenAIService *AzureOpenAIService, vectorStoreService *VectorStoreService, weatherService *WeatherService, answerQuality *AnswerQualityService) *AnomalyInterviewService {
+	return &AnomalyInterviewService{
+		azureOpenAIService: azureOpenAIService,
+		vectorStoreService: vectorStoreService,
+		weatherService:     weatherService,
+		answerQuality:      answerQuality,
+	}
+}
+
+// Start 異常のコンテキスト（レポート・気象・過去の類似回答）から仮説を生成し、最初の検証質問を決めてセッションを保存する
+func (s *AnomalyInterviewService) Start(ctx context.Context, req models.AnomalyInterviewStartRequest) (*models.AnomalyResponseSession, *models.EnhancedQuestion, error) {
+	report, anomaly, err := s.findAnomaly(ctx, req.AnomalyDate, req.ProductID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	regionCode := req.RegionCode
+	if regionCode == "" {
+		regionCode = "240000"
+	}
+	weatherContext := s.buildWeatherContext(regionCode, anomaly.Date)
+	pastSimilar := s.findSimilarResponses(ctx, anomaly)
+	reportContext := formatInterviewReportContext(report)
+
+	enhanced, err := s.azureOpenAIService.GenerateEnhancedQuestion(anomaly, pastSimilar, nil, weatherContext, reportContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(enhanced.Hypotheses) == 0 {
+		return nil, nil, fmt.Errorf("AIが仮説を生成できませんでした")
+	}
+
+	session := &models.AnomalyResponseSession{
+		SessionID:      uuid.New().String(),
+		AnomalyDate:    anomaly.Date,
+		ProductID:      anomaly.ProductID,
+		Conversations:  []models.Conversation{},
+		CreatedAt:      time.Now().Format(time.RFC3339),
+		UserID:         req.UserID,
+		Mode:           interviewMode,
+		AnomalyContext: formatInterviewAnomalyContext(anomaly, weatherContext, reportContext),
+		Hypotheses:     NewHypothesisStates(enhanced.Hypotheses),
+	}
+	// 影響の向きと大きさはデータから判明しているため、開始時に設定する
+	session.FinalImpact, session.FinalImpactValue = anomalyImpact(anomaly)
+
+	next := NextHypothesis(session.Hypotheses)
+	if next < 0 {
+		return nil, nil, fmt.Errorf("検証可能な仮説がありません")
+	}
+	session.Hypotheses[next].Status = "asked"
+	session.Hypotheses[next].AskedOrder = 1
+	session.CurrentHypothesis = session.Hypotheses[next].ID
+
+	if err := s.vectorStoreService.SaveAnomalyResponseSession(ctx, session); err != nil {
+		return nil, nil, err
+	}
+
+	log.Printf("🕵️ 異常インタビュー開始: %s (製品: %s, 日付: %s, 仮説: %d件)",
+		session.SessionID, session.ProductID, session.AnomalyDate, len(session.Hypotheses))
+	return session, enhanced, nil
+}
+
+// Answer 検証質問への回答で仮説の信頼度を更新し、次の質問を決めるか、根本原因の結論を出してセッションを保存する
+func (s *AnomalyInterviewService) Answer(ctx context.Context, req models.AnomalyInterviewAnswerRequest) (*models.AnomalyResponseSession, error) {
+	session, err := s.vectorStoreService.GetAnomalyResponseSession(ctx, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Mode != interviewMode {
+		return nil, fmt.Errorf("セッション %s は仮説検証型インタビューではありません", req.SessionID)
+	}
+	if session.IsComplete {
+		return nil, fmt.Errorf("セッション %s は既に完了しています", req.SessionID)
+	}
+
+	current := findHypothesis(session.Hypotheses, session.CurrentHypothesis)
+	if current < 0 {
+		return nil, fmt.Errorf("回答待ちの仮説が見つかりません: %s", session.CurrentHypothesis)
+	}
+	hypothesis := session.Hypotheses[current]
+
+	conversation := models.Conversation{
+		Question:           hypothesis.VerificationQuestion,
+		QuestionChoices:    hypothesis.Choices,
+		Answer:             req.Answer,
+		Timestamp:          time.Now().Format(time.RFC3339),
+		AnswerType:         req.AnswerType,
+		QuestionTemplate:   TemplateHypothesisVerification,
+		HypothesisCategory: hypothesis.Category,
+	}
+	if s.answerQuality != nil {
+		conversation.Analysis = s.answerQuality.Score(hypothesis.VerificationQuestion, req.Answer)
+	}
+	session.Conversations = append(session.Conversations, conversation)
+
+	assessments, err := s.azureOpenAIService.AssessHypothesisAnswer(session.AnomalyContext, session.Hypotheses, hypothesis.VerificationQuestion, req.Answer)
+	if err != nil {
+		log.Printf("⚠️ 仮説評価に失敗したため、期待パターンとの一致で評価します: %v", err)
+		assessments = []models.HypothesisAssessment{heuristicHypothesisAssessment(hypothesis, req.Answer)}
+	}
+	ApplyHypothesisAssessments(session.Hypotheses, assessments, hypothesis.ID, req.Answer)
+
+	asked := askedHypothesisCount(session.Hypotheses)
+	next := NextHypothesis(session.Hypotheses)
+	if ShouldConcludeInterview(session.Hypotheses, asked) || next < 0 {
+		conclusion := BuildRootCauseConclusion(session.Hypotheses, asked)
+		session.RootCause = &conclusion
+		session.CurrentHypothesis = ""
+		session.IsComplete = true
+		session.CompletedAt = conclusion.ConcludedAt
+		session.FinalTags = conclusionTags(session.Hypotheses)
+		log.Printf("✅ 異常インタビュー完了: %s (最有力: %s)", session.SessionID, conclusion.PrimaryCause)
+	} else {
+		session.Hypotheses[next].Status = "asked"
+		session.Hypotheses[next].AskedOrder = asked + 1
+		session.CurrentHypothesis = session.Hypotheses[next].ID
+		session.FollowUpCount++
+	}
+
+	if err := s.vectorStoreService.SaveAnomalyResponseSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// findAnomaly 分析レポートから日付・製品IDが一致する異常を探す（新しいレポートを優先）
+func (s *AnomalyInterviewService) findAnomaly(ctx context.Context, date, productID string) (*models.AnalysisReport, models.AnomalyDetection, error) {
+	reports, err := s.vectorStoreService.GetAllAnalysisReports(ctx)
+	if err != nil {
+		return nil, models.AnomalyDetection{}, err
+	}
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].AnalysisDate > reports[j].AnalysisDate })
+	for i := range reports {
+		for _, anomaly := range reports[i].Anomalies {
+			if anomaly.Date == date && anomaly.ProductID == productID {
+				return &reports[i], anomaly, nil
+			}
+		}
+	}
+	return nil, models.AnomalyDetection{}, ErrAnomalyNotFound
+}
+
+// buildWeatherContext 異常日までの4日間の気象データを整形
+// 時間帯の異常（YYYY-MM-DD HH:00）の場合は、その前後3時間の1時間ごとの気象も加える
+func (s *AnomalyInterviewService) buildWeatherContext(regionCode, date string) string {
+	if s.weatherService == nil {
+		return "気象データなし"
+	}
+	day, err := parseAnomalyDay(date)
+	if err != nil {
+		return "気象データなし"
+	}
+	data, err := s.weatherService.GetHistoricalWeatherData(regionCode, day.AddDate(0, 0, -3), day)
+	if err != nil || len(data) == 0 {
+		return "気象データなし"
+	}
+	var b strings.Builder
+	for _, w := range data {
+		fmt.Fprintf(&b, "- %s: %s 平均%.1f℃ (最高%.1f℃/最低%.1f℃) 降水量%.1fmm\n",
+			w.Date, w.Weather, w.Temperature, w.MaxTemp, w.MinTemp, w.Precipitation)
+	}
+	if anomalies, err := s.weatherService.GetWeatherAnomalies(regionCode, day, day, ClimatologyBaselineYears); err == nil {
+		if sentence := WeatherAnomalySentence(&anomalies[0]); sentence != "" {
+			fmt.Fprintf(&b, "- 平年との比較: %s\n", sentence)
+		}
+	}
+
+	if hour, err := ParseHourKey(date); err == nil {
+		if hourly, err := s.weatherService.GetHourlyWeatherData(regionCode, day, day); err == nil {
+			for _, w := range hourly {
+				t, _ := ParseHourKey(w.DateTime)
+				if diff := t.Sub(hour); diff < -3*time.Hour || diff > 3*time.Hour {
+					continue
+				}
+				fmt.Fprintf(&b, "  - %s: %s %.1f℃ 降水量%.1fmm\n", w.DateTime, w.Weather, w.Temperature, w.Precipitation)
+			}
+		}
+	}
+	return b.String()
+}
+
+// findSimilarResponses 同じ製品・異常タイプの過去の回答を検索（対象の異常自体への回答は除く）
+func (s *AnomalyInterviewService) findSimilarResponses(ctx context.Context, anomaly models.AnomalyDetection) []models.AnomalyResponse {
+	query := fmt.Sprintf("製品ID: %s %s %s", anomaly.ProductID, anomaly.ProductName, anomaly.AnomalyType)
+	points, err := s.vectorStoreService.SearchWithFilter(ctx, "anomaly_responses", query, 5, nil)
+	if err != nil {
+		log.Printf("⚠️ 類似回答の検索に失敗: %v", err)
+		return nil
+	}
+
+	var responses []models.AnomalyResponse
+	for _, point := range points {
+		response := models.AnomalyResponse{
+			ResponseID:  getStringFromPayload(point.Payload, "response_id"),
+			AnomalyDate: getStringFromPayload(point.Payload, "anomaly_date"),
+			ProductID:   getStringFromPayload(point.Payload, "product_id"),
+			Question:    getStringFromPayload(point.Payload, "question"),
+			Answer:      getStringFromPayload(point.Payload, "answer"),
+			Impact:      getStringFromPayload(point.Payload, "impact"),
+		}
+		if response.Answer == "" || (response.AnomalyDate == anomaly.Date && response.ProductID == anomaly.ProductID) {
+			continue
+		}
+		if tags := getStringFromPayload(point.Payload, "tags"); tags != "" {
+			response.Tags = strings.Split(tags, ",")
+		}
+		if v, ok := point.Payload["impact_value"]; ok {
+			response.ImpactValue = v.GetDoubleValue()
+		}
+		responses = append(responses, response)
+	}
+	return responses
+}
+
+// NewHypothesisStates 生成された仮説をインタビュー用の状態に変換（信頼度は0.05〜0.95に丸める）
+func NewHypothesisStates(hypotheses []models.Hypothesis) []models.HypothesisState {
+	states := make([]models.HypothesisState, 0, len(hypotheses))
+	for i, h := range hypotheses {
+		if h.ID == "" {
+			h.ID = fmt.Sprintf("H%d", i+1)
+		}
+		h.Confidence = math.Max(0.05, math.Min(0.95, h.Confidence))
+		states = append(states, models.HypothesisState{
+			Hypothesis:        h,
+			InitialConfidence: h.Confidence,
+			Status:            "pending",
+		})
+	}
+	return states
+}
+
+// NextHypothesis 未質問の仮説のうち、現在の信頼度が最も高いもののインデックスを返す（なければ-1）
+// 信頼度が高い仮説から検証することで、少ない質問で根本原因を確定させる
+func NextHypothesis(states []models.HypothesisState) int {
+	best := -1
+	for i, state := range states {
+		if state.Status != "pending" || state.VerificationQuestion == "" {
+			continue
+		}
+		if best < 0 || state.Confidence > states[best].Confidence {
+			best = i
+		}
+	}
+	return best
+}
+
+// UpdateHypothesisConfidence 支持度（-1〜1）を対数オッズに加算して信頼度を更新する
+func UpdateHypothesisConfidence(prior, support float64) float64 {
+	support = math.Max(-1, math.Min(1, support))
+	p := math.Max(0.01, math.Min(0.99, prior))
+	logOdds := math.Log(p/(1-p)) + interviewSupportWeight*support
+	posterior := 1 / (1 + math.Exp(-logOdds))
+	return math.Max(0.01, math.Min(0.99, posterior))
+}
+
+// ApplyHypothesisAssessments 回答の評価を各仮説に反映し、質問した仮説の検証結果を記録する
+// 質問していない仮説も回答から否定された場合は、信頼度が下がり棄却されることがある
+func ApplyHypothesisAssessments(states []models.HypothesisState, assessments []models.HypothesisAssessment, askedID string, answer string) {
+	for _, assessment := range assessments {
+		i := findHypothesis(states, assessment.HypothesisID)
+		if i < 0 {
+			continue
+		}
+		states[i].Confidence = UpdateHypothesisConfidence(states[i].Confidence, assessment.Support)
+
+		if states[i].ID == askedID {
+			states[i].Answer = answer
+			states[i].Reasoning = assessment.Reasoning
+			switch {
+			case assessment.Support >= interviewStatusThreshold:
+				states[i].Status = "supported"
+			case assessment.Support <= -interviewStatusThreshold:
+				states[i].Status = "refuted"
+			default:
+				states[i].Status = "inconclusive"
+			}
+		} else if states[i].Status == "pending" && states[i].Confidence < interviewDismissThreshold {
+			states[i].Status = "refuted"
+			states[i].Reasoning = assessment.Reasoning
+		}
+	}
+
+	// 評価が返らなかった場合でも、質問した仮説は回答済みとして扱う
+	if i := findHypothesis(states, askedID); i >= 0 && states[i].Status == "asked" {
+		states[i].Answer = answer
+		states[i].Status = "inconclusive"
+	}
+}
+
+// ShouldConcludeInterview 結論を出すべきか判定（質問数の上限、または検証済み仮説が十分な信頼度に達した場合）
+func ShouldConcludeInterview(states []models.HypothesisState, asked int) bool {
+	if asked >= interviewMaxQuestions {
+		return true
+	}
+	for _, state := range states {
+		if state.Status == "supported" && state.Confidence >= interviewConfirmThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildRootCauseConclusion 仮説を信頼度順に並べ、根本原因の結論を作成
+func BuildRootCauseConclusion(states []models.HypothesisState, questions int) models.RootCauseConclusion {
+	ranked := make([]models.HypothesisState, len(states))
+	copy(ranked, states)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Confidence > ranked[j].Confidence })
+
+	conclusion := models.RootCauseConclusion{
+		Questions:   questions,
+		ConcludedAt: time.Now().Format(time.RFC3339),
+	}
+	var refuted []string
+	for i, state := range ranked {
+		evidence := state.DataEvidence
+		if state.Answer != "" {
+			evidence = "回答: " + state.Answer
+		}
+		conclusion.RankedCauses = append(conclusion.RankedCauses, models.RankedCause{
+			Rank:         i + 1,
+			HypothesisID: state.ID,
+			Category:     state.Category,
+			Title:        state.Title,
+			Confidence:   math.Round(state.Confidence*1000) / 1000,
+			Status:       state.Status,
+			Evidence:     evidence,
+		})
+		if state.Status == "refuted" {
+			refuted = append(refuted, state.Title)
+		}
+	}
+
+	if len(ranked) > 0 {
+		top := ranked[0]
+		conclusion.PrimaryCause = top.Title
+		conclusion.Summary = fmt.Sprintf("最有力の原因は「%s」（信頼度%.0f%%）です。", top.Title, top.Confidence*100)
+		if len(ranked) > 1 && ranked[1].Confidence >= 0.5 {
+			conclusion.Summary += fmt.Sprintf("「%s」（信頼度%.0f%%）も併せて影響した可能性があります。", ranked[1].Title, ranked[1].Confidence*100)
+		}
+		if len(refuted) > 0 {
+			conclusion.Summary += fmt.Sprintf("否定された仮説: %s。", strings.Join(refuted, "、"))
+		}
+	}
+	return conclusion
+}
+
+// heuristicHypothesisAssessment AI評価が使えない場合に、期待される回答パターンとの一致と回答の最初の語で支持度を決める
+// 回答が期待される回答パターン全体を含む場合だけ支持とする（短い回答がパターンの一部と一致しても支持にしない）
+func heuristicHypothesisAssessment(hypothesis models.HypothesisState, answer string) models.HypothesisAssessment {
+	assessment := models.HypothesisAssessment{HypothesisID: hypothesis.ID, Reasoning: "期待される回答パターンとの一致で評価"}
+	expected := strings.TrimSpace(hypothesis.ExpectedPattern)
+	answer = strings.TrimSpace(answer)
+	switch {
+	case expected != "" && strings.Contains(answer, expected):
+		assessment.Support = 0.6
+	default:
+		switch answerPolarity(answer) {
+		case 1:
+			assessment.Support = 0.6
+			assessment.Reasoning = "回答の「はい」で評価"
+		case -1:
+			assessment.Support = -0.6
+			assessment.Reasoning = "回答の「いいえ」で評価"
+		}
+	}
+	return assessment
+}
+
+// affirmativeLeadingWords・negativeLeadingWords 回答の最初の語がこれらであれば、はい・いいえの回答とみなす
+var (
+	affirmativeLeadingWords = []string{"はい", "ええ", "うん", "そうです", "その通り", "その通りです", "yes"}
+	negativeLeadingWords    = []string{"いいえ", "いえ", "いや", "ううん", "違います", "違う", "ちがいます", "no"}
+)
+
+// answerPolarity 回答の最初の語（句読点・空白まで）がはい・いいえのどちらか（はい: 1、いいえ: -1、どちらでもない: 0）
+// 「在庫が足りなかった」「間違いない」のように文中の否定表現は、仮説を否定する回答として数えない
+func answerPolarity(answer string) int {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	leading := answer
+	if i := strings.IndexAny(answer, "、。,.!！?？ 　"); i >= 0 {
+		leading = answer[:i]
+	}
+	for _, word := range negativeLeadingWords {
+		if leading == word || (word == "いいえ" && strings.HasPrefix(leading, word)) {
+			return -1
+		}
+	}
+	for _, word := range affirmativeLeadingWords {
+		if leading == word || (word == "はい" && strings.HasPrefix(leading, word)) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// findHypothesis IDで仮説のインデックスを探す（見つからない場合は-1）
+func findHypothesis(states []models.HypothesisState, id string) int {
+	for i, state := range states {
+		if state.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// askedHypothesisCount 質問済みの仮説数
+func askedHypothesisCount(states []models.HypothesisState) int {
+	count := 0
+	for _, state := range states {
+		if state.AskedOrder > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// conclusionTags 信頼度が0.5以上の仮説のカテゴリをタグとして返す（なければ最有力の仮説のカテゴリ）
+func conclusionTags(states []models.HypothesisState) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	best := -1
+	for i, state := range states {
+		if best < 0 || state.Confidence > states[best].Confidence {
+			best = i
+		}
+		if state.Confidence >= 0.5 && state.Category != "" && !seen[state.Category] {
+			seen[state.Category] = true
+			tags = append(tags, state.Category)
+		}
+	}
+	if len(tags) == 0 && best >= 0 && states[best].Category != "" {
+		tags = append(tags, states[best].Category)
+	}
+	return tags
+}
+
+// anomalyImpact 異常の向きと予測値に対する変化率（%）を返す
+func anomalyImpact(anomaly models.AnomalyDetection) (string, float64) {
+	impact := "neutral"
+	switch anomaly.AnomalyType {
+	case "急増":
+		impact = "positive"
+	case "急減":
+		impact = "negative"
+	}
+	if anomaly.ExpectedValue == 0 {
+		return impact, 0
+	}
+	return impact, math.Round((anomaly.ActualValue-anomaly.ExpectedValue)/anomaly.ExpectedValue*1000) / 10
+}
+
+// formatInterviewAnomalyContext 仮説評価に渡す異常の状況を整形
+func formatInterviewAnomalyContext(anomaly models.AnomalyDetection, weatherContext, reportContext string) string {
+	return fmt.Sprintf("日付: %s\n製品: %s (%s)\n実績値: %.2f / 予測値: %.2f（Zスコア %.2f）\n異常タイプ: %s / 深刻度: %s\n\n気象:\n%s\n%s",
+		anomaly.Date, anomaly.ProductName, anomaly.ProductID, anomaly.ActualValue, anomaly.ExpectedValue, anomaly.ZScore,
+		anomaly.AnomalyType, anomaly.Severity, weatherContext, reportContext)
+}
+
+// formatInterviewReportContext 異常を検出した分析レポートの要点を整形
+func formatInterviewReportContext(report *models.AnalysisReport) string {
+	if report == nil {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "分析レポート: %s（期間: %s）\n", report.FileName, report.DateRange)
+	if report.Summary != "" {
+		fmt.Fprintf(&b, "概要: %s\n", truncateRunes(report.Summary, 300))
+	}
+	for i, corr := range report.Correlations {
+		if i >= 3 {
+			break
+		}
+		fmt.Fprintf(&b, "- %sとの相関: %.2f\n", corr.Factor, corr.CorrelationCoef)
+	}
+	return b.String()
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func interviewTestStates() []models.HypothesisState {
+	return NewHypothesisStates([]models.Hypothesis{
+		{ID: "H1", Category: "external", Title: "気温上昇", Confidence: 0.6, VerificationQuestion: "気温が高かったですか？"},
+		{ID: "H2", Category: "internal", Title: "販促キャンペーン", Confidence: 0.8, VerificationQuestion: "キャンペーンを実施しましたか？"},
+		{ID: "H3", Category: "customer", Title: "大口注文", Confidence: 1.2, VerificationQuestion: ""},
+	})
+}
+
+func TestUpdateHypothesisConfidence(t *testing.T) {
+	prior := 0.5
+	if got := UpdateHypothesisConfidence(prior, 1); got <= prior {
+		t.Errorf("Supporting answer should raise confidence, got %.3f", got)
+	}
+	if got := UpdateHypothesisConfidence(prior, -1); got >= prior {
+		t.Errorf("Refuting answer should lower confidence, got %.3f", got)
+	}
+	if got := UpdateHypothesisConfidence(prior, 0); got < 0.499 || got > 0.501 {
+		t.Errorf("Neutral answer should keep confidence, got %.3f", got)
+	}
+	// 支持度は-1〜1に丸められ、信頼度は0や1にならない
+	if got := UpdateHypothesisConfidence(0.99, 10); got >= 1 {
+		t.Errorf("Confidence should stay below 1, got %.3f", got)
+	}
+	if got := UpdateHypothesisConfidence(0.01, -10); got <= 0 {
+		t.Errorf("Confidence should stay above 0, got %.3f", got)
+	}
+}
+
+func TestNextHypothesisPrefersHighestConfidence(t *testing.T) {
+	states := interviewTestStates()
+	if states[2].Confidence != 0.95 {
+		t.Errorf("Confidence should be clamped to 0.95, got %.2f", states[2].Confidence)
+	}
+
+	// 検証質問のない仮説はスキップする
+	if next := NextHypothesis(states); states[next].ID != "H2" {
+		t.Fatalf("NextHypothesis = %s, expected H2", states[next].ID)
+	}
+	states[1].Status = "asked"
+	if next := NextHypothesis(states); states[next].ID != "H1" {
+		t.Fatalf("NextHypothesis = %s, expected H1", states[next].ID)
+	}
+	states[0].Status = "refuted"
+	if next := NextHypothesis(states); next != -1 {
+		t.Errorf("Expected no remaining hypothesis, got %d", next)
+	}
+}
+
+func TestApplyAssessmentsAndConclusion(t *testing.T) {
+	states := interviewTestStates()
+	states[1].Status = "asked"
+	states[1].AskedOrder = 1
+
+	ApplyHypothesisAssessments(states, []models.HypothesisAssessment{
+		{HypothesisID: "H2", Support: -0.9, Reasoning: "キャンペーンは未実施"},
+		{HypothesisID: "H1", Support: 0.8, Reasoning: "猛暑日だった"},
+	}, "H2", "いいえ、実施していません")
+
+	if states[1].Status != "refuted" || states[1].Answer == "" {
+		t.Errorf("H2 should be refuted with answer recorded, got %+v", states[1])
+	}
+	if states[0].Status != "pending" || states[0].Confidence <= 0.6 {
+		t.Errorf("H1 should stay pending with raised confidence, got %+v", states[0])
+	}
+	if ShouldConcludeInterview(states, 1) {
+		t.Error("Should not conclude before a hypothesis is verified")
+	}
+
+	states[0].Status = "asked"
+	states[0].AskedOrder = 2
+	ApplyHypothesisAssessments(states, []models.HypothesisAssessment{
+		{HypothesisID: "H1", Support: 1, Reasoning: "35℃を超えた"},
+	}, "H1", "はい")
+	if !ShouldConcludeInterview(states, 2) {
+		t.Fatalf("Should conclude once a supported hypothesis is confident, got %.3f", states[0].Confidence)
+	}
+
+	conclusion := BuildRootCauseConclusion(states, 2)
+	if conclusion.PrimaryCause != "気温上昇" {
+		t.Errorf("PrimaryCause = %q, expected 気温上昇", conclusion.PrimaryCause)
+	}
+	for i := 1; i < len(conclusion.RankedCauses); i++ {
+		if conclusion.RankedCauses[i-1].Confidence < conclusion.RankedCauses[i].Confidence {
+			t.Errorf("Ranked causes are not sorted by confidence: %+v", conclusion.RankedCauses)
+		}
+		if conclusion.RankedCauses[i].Rank != i+1 {
+			t.Errorf("Rank = %d, expected %d", conclusion.RankedCauses[i].Rank, i+1)
+		}
+	}
+	if conclusion.Questions != 2 {
+		t.Errorf("Questions = %d, expected 2", conclusion.Questions)
+	}
+}
+
+func TestHeuristicHypothesisAssessment(t *testing.T) {
+	hypothesis := models.HypothesisState{Hypothesis: models.Hypothesis{ID: "H2", ExpectedPattern: "実施した"}}
+	cases := []struct {
+		answer  string
+		support float64
+	}{
+		{"実施した", 0.6},
+		{"はい", 0.6},
+		{"はい、間違いないです", 0.6},
+		{"Yes", 0.6},
+		{"いいえ、実施していません", -0.6},
+		{"いいえ実施していません", -0.6},
+		{"違います。別の理由です", -0.6},
+		// 否定表現を含むが、仮説を否定していない回答
+		{"キャンペーンはなかった", 0},
+		{"在庫が足りなかった", 0},
+		{"客が少ない", 0},
+		{"キャンペーンの効果で間違いない", 0},
+		{"セールのせいに違いありません", 0},
+		// 期待される回答パターンの一部だけの回答
+		{"実", 0},
+		{"で", 0},
+	}
+	for _, c := range cases {
+		if got := heuristicHypothesisAssessment(hypothesis, c.answer).Support; got != c.support {
+			t.Errorf("Support for %q = %v, expected %v", c.answer, got, c.support)
+		}
+	}
+}
+
+func TestAnomalyImpact(t *testing.T) {
+	impact, value := anomalyImpact(models.AnomalyDetection{AnomalyType: "急減", ActualValue: 70, ExpectedValue: 100})
+	if impact != "negative" || value != -30 {
+		t.Errorf("anomalyImpact = %s, %.1f", impact, value)
+	}
+}
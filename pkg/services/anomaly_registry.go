@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidAnomalyTransition 許可されていない状態遷移
+var ErrInvalidAnomalyTransition = errors.New("この状態遷移は許可されていません")
+
+// ErrAnomalyRecordNotFound 指定したIDの異常が登録されていない
+var ErrAnomalyRecordNotFound = errors.New("異常が見つかりません")
+
+// ErrInvalidAnomalyRequest 異常の操作の指定が不正
+var ErrInvalidAnomalyRequest = errors.New("異常の操作の指定が不正です")
+
+// AnomalyActiveStatuses まだ説明・却下されていない（回答を求める）状態
+var AnomalyActiveStatuses = []string{"open", "acknowledged", "investigating"}
+
+// anomalyTransitions 状態ごとの遷移先（explained・dismissed は open に戻して再調査できる）
+var anomalyTransitions = map[string][]string{
+	"open":          {"acknowledged", "investigating", "explained", "dismissed"},
+	"acknowledged":  {"open", "investigating", "explained", "dismissed"},
+	"investigating": {"acknowledged", "explained", "dismissed"},
+	"explained":     {"open", "investigating"},
+	"dismissed":     {"open"},
+}
+
+// AnomalyRegistryService 分析レポートで検出した異常を、IDとライフサイクルを持つエンティティとして管理する
+type AnomalyRegistryService struct {
+	vectorStoreService *VectorStoreService
+
+	mu sync.Mutex // 同じ異常への読み込み→更新→保存を直列化する
+}
+
+// NewAnomalyRegistryService 新しい異常レジストリサービスを作成
+func NewAnomalyRegistryService(vectorStoreService *VectorStoreService) *AnomalyRegistryService {
+	return &AnomalyRegistryService{vectorStoreService: vectorStoreService}
+}
+
+// Register 分析レポートの異常を登録し、IDを付けた検出結果を返す（同じ製品・期間・粒度の異常は既存のものに統合）
+// fresh は初めて検出された異常と、別のレポートで前回より深刻度が上がった異常（通知の対象）
+func (s *AnomalyRegistryService) Register(ctx context.Context, reportID string, detections []models.AnomalyDetection) (registered, fresh []models.AnomalyDetection, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().Format(time.RFC3339)
+	registered = make([]models.AnomalyDetection, 0, len(detections))
+	for _, detection := range detections {
+		if detection.ProductID == "" || detection.Date == "" {
+			registered = append(registered, detection)
+			continue
+		}
+		id := AnomalyIDFor(detection.ProductID, detection.Date, detection.Granularity)
+		existing, err := s.vectorStoreService.GetAnomalyRecord(ctx, id)
+		if errors.Is(err, ErrAnomalyRecordNotFound) {
+			existing = nil
+		} else if err != nil {
+			// 既存の異常を読めないまま保存すると状態・履歴・コメントを上書きしてしまうため中断する
+			return registered, fresh, fmt.Errorf("異常の取得に失敗しました（%s）: %w", id, err)
+		}
+		record := ApplyAnomalyDetection(existing, detection, reportID, now)
+		if err := s.vectorStoreService.SaveAnomalyRecord(ctx, record); err != nil {
+			return registered, fresh, err
+		}
+		detection.AnomalyID = record.AnomalyID
+		registered = append(registered, detection)
+		if IsFreshDetection(existing, detection, reportID) {
+			fresh = append(fresh, detection)
+		}
+	}
+	return registered, fresh, nil
+}
+
+// IsFreshDetection 検出が初めてのものか、別のレポートで前回より深刻度が上がったものか
+// 同じファイルの再分析などで既存の異常に統合されただけの検出は通知しない
+func IsFreshDetection(existing *models.AnomalyRecord, detection models.AnomalyDetection, reportID string) bool {
+	if existing == nil {
+		return true
+	}
+	if reportID != "" && containsString(existing.ReportIDs, reportID) {
+		return false
+	}
+	return severityRank[detection.Severity] > severityRank[existing.Detection.Severity]
+}
+
+// Sync 保存済みの分析レポート・回答から異常を登録し直す（既存データの移行用。IDが決まっているため再実行しても重複しない）
+func (s *AnomalyRegistryService) Sync(ctx context.Context) (int, error) {
+	reports, err := s.vectorStoreService.GetAllAnalysisReports(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("分析レポートの取得に失敗しました: %w", err)
+	}
+	registered := 0
+	for _, report := range reports {
+		detections, _, err := s.Register(ctx, report.ReportID, report.Anomalies)
+		if err != nil {
+			return registered, err
+		}
+		for _, detection := range detections {
+			if detection.AnomalyID != "" {
+				registered++
+			}
+		}
+	}
+
+	responses, err := s.vectorStoreService.GetAllAnomalyResponses(ctx)
+	if err != nil {
+		return registered, fmt.Errorf("回答済み異常の取得に失敗しました: %w", err)
+	}
+	for _, response := range responses {
+		if err := s.MarkByAnswer(ctx, response.AnomalyDate, response.ProductID, "explained", "system", "回答が保存されています"); err != nil {
+			return registered, err
+		}
+	}
+
+	log.Printf("📋 %d件の異常をレジストリに同期しました", registered)
+	return registered, nil
+}
+
+// List 条件に一致する異常を取得
+func (s *AnomalyRegistryService) List(ctx context.Context, filter models.AnomalyFilter) ([]models.AnomalyRecord, error) {
+	return s.vectorStoreService.ListAnomalyRecords(ctx, filter)
+}
+
+// Get 異常を取得
+func (s *AnomalyRegistryService) Get(ctx context.Context, anomalyID string) (*models.AnomalyRecord, error) {
+	return s.vectorStoreService.GetAnomalyRecord(ctx, anomalyID)
+}
+
+// Transition 異常の状態を遷移させる
+func (s *AnomalyRegistryService) Transition(ctx context.Context, anomalyID string, req models.AnomalyTransitionRequest) (*models.AnomalyRecord, error) {
+	return s.update(ctx, anomalyID, func(record *models.AnomalyRecord, now string) error {
+		return ApplyAnomalyTransition(record, req, now)
+	})
+}
+
+// Assign 異常に担当者を割り当てる（履歴には状態を変えずに記録）
+func (s *AnomalyRegistryService) Assign(ctx context.Context, anomalyID string, req models.AnomalyAssignRequest) (*models.AnomalyRecord, error) {
+	return s.update(ctx, anomalyID, func(record *models.AnomalyRecord, now string) error {
+		note := "担当者を解除"
+		if req.Assignee != "" {
+			note = "担当者を " + req.Assignee + " に設定"
+		}
+		record.Assignee = req.Assignee
+		record.History = append(record.History, models.AnomalyTransition{From: record.Status, To: record.Status, Actor: req.Actor, Note: note, At: now})
+		return nil
+	})
+}
+
+// Comment 異常にコメントを追加
+func (s *AnomalyRegistryService) Comment(ctx context.Context, anomalyID string, req models.AnomalyCommentRequest) (*models.AnomalyRecord, error) {
+	return s.update(ctx, anomalyID, func(record *models.AnomalyRecord, now string) error {
+		text := strings.TrimSpace(req.Text)
+		if text == "" {
+			return fmt.Errorf("%w: コメントを入力してください", ErrInvalidAnomalyRequest)
+		}
+		record.Comments = append(record.Comments, models.AnomalyComment{Author: req.Author, Text: text, CreatedAt: now})
+		return nil
+	})
+}
+
+// MarkByAnswer 回答の保存・セッションの開始に合わせて、同じ日付・製品の未解決の異常を指定の状態に進める
+func (s *AnomalyRegistryService) MarkByAnswer(ctx context.Context, date, productID, status, actor, note string) error {
+	if s == nil || s.vectorStoreService == nil || date == "" || productID == "" {
+		return nil
+	}
+	records, err := s.vectorStoreService.ListAnomalyRecords(ctx, models.AnomalyFilter{ProductID: productID, From: date, To: date})
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if !IsActiveAnomalyStatus(record.Status) || record.Status == status || !CanTransitionAnomaly(record.Status, status) {
+			continue
+		}
+		if _, err := s.Transition(ctx, record.AnomalyID, models.AnomalyTransitionRequest{Status: status, Actor: actor, Note: note}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update 異常を読み込んで変更を加え、保存する
+func (s *AnomalyRegistryService) update(ctx context.Context, anomalyID string, apply func(record *models.AnomalyRecord, now string) error) (*models.AnomalyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, err := s.vectorStoreService.GetAnomalyRecord(ctx, anomalyID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format(time.RFC3339)
+	if err := apply(record, now); err != nil {
+		return nil, err
+	}
+	record.UpdatedAt = now
+	if err := s.vectorStoreService.SaveAnomalyRecord(ctx, *record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// AnomalyIDFor 製品・期間・粒度から決まる異常のID（粒度が空の場合は既定の weekly とみなす）
+func AnomalyIDFor(productID, period, granularity string) string {
+	if granularity == "" {
+		granularity = "weekly"
+	}
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("anomaly:"+productID+":"+granularity+":"+period)).String()
+}
+
+// IsActiveAnomalyStatus まだ説明・却下されていない状態か
+func IsActiveAnomalyStatus(status string) bool {
+	return containsString(AnomalyActiveStatuses, status)
+}
+
+// CanTransitionAnomaly from から to への状態遷移が許可されているか
+func CanTransitionAnomaly(from, to string) bool {
+	return containsString(anomalyTransitions[from], to)
+}
+
+// ApplyAnomalyDetection 検出結果を異常に反映する（existing が nil なら新規作成）。状態・担当者・コメントは引き継ぐ
+func ApplyAnomalyDetection(existing *models.AnomalyRecord, detection models.AnomalyDetection, reportID, now string) models.AnomalyRecord {
+	granularity := detection.Granularity
+	if granularity == "" {
+		granularity = "weekly"
+	}
+	detection.AnomalyID = AnomalyIDFor(detection.ProductID, detection.Date, granularity)
+	detection.Granularity = granularity
+
+	var record models.AnomalyRecord
+	if existing != nil {
+		record = *existing
+	} else {
+		record = models.AnomalyRecord{
+			AnomalyID:       detection.AnomalyID,
+			ProductID:       detection.ProductID,
+			Period:          detection.Date,
+			Granularity:     granularity,
+			Status:          "open",
+			Comments:        []models.AnomalyComment{},
+			History:         []models.AnomalyTransition{{To: "open", Actor: "system", Note: "分析レポートで検出", At: now}},
+			FirstDetectedAt: now,
+		}
+	}
+
+	// AI質問は非同期で生成されるため、再検出時に空なら以前の質問を残す
+	if detection.AIQuestion == "" {
+		detection.AIQuestion = record.Detection.AIQuestion
+		detection.QuestionChoices = record.Detection.QuestionChoices
+	}
+	record.Detection = detection
+	if detection.ProductName != "" {
+		record.ProductName = detection.ProductName
+	}
+	if reportID != "" && !containsString(record.ReportIDs, reportID) {
+		record.ReportIDs = append(record.ReportIDs, reportID)
+		record.DetectionCount++
+	}
+	record.LastDetectedAt = now
+	record.UpdatedAt = now
+	return record
+}
+
+// ApplyAnomalyTransition 状態遷移を検証して異常に反映する
+func ApplyAnomalyTransition(record *models.AnomalyRecord, req models.AnomalyTransitionRequest, now string) error {
+	if !containsString(models.AnomalyStatuses, req.Status) {
+		return fmt.Errorf("%w: statusは %s のいずれかを指定してください", ErrInvalidAnomalyRequest, strings.Join(models.AnomalyStatuses, "/"))
+	}
+	if !CanTransitionAnomaly(record.Status, req.Status) {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidAnomalyTransition, record.Status, req.Status)
+	}
+
+	record.History = append(record.History, models.AnomalyTransition{From: record.Status, To: req.Status, Actor: req.Actor, Note: req.Note, At: now})
+	record.Status = req.Status
+	switch req.Status {
+	case "explained", "dismissed":
+		record.ResolvedAt = now
+		record.Resolution = req.Resolution
+		if record.Resolution == "" {
+			record.Resolution = req.Note
+		}
+	default:
+		record.ResolvedAt = ""
+		record.Resolution = ""
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestAnomalyIDFor(t *testing.T) {
+	id := AnomalyIDFor("P001", "2024-06-10", "weekly")
+	if id != AnomalyIDFor("P001", "2024-06-10", "") {
+		t.Error("Empty granularity should default to weekly")
+	}
+	if id == AnomalyIDFor("P001", "2024-06-10", "daily") || id == AnomalyIDFor("P002", "2024-06-10", "weekly") {
+		t.Error("Different product or granularity should produce a different ID")
+	}
+}
+
+func TestApplyAnomalyDetection(t *testing.T) {
+	detection := models.AnomalyDetection{Date: "2024-06-10", ProductID: "P001", ProductName: "水", ActualValue: 300, AIQuestion: "何がありましたか？"}
+	record := ApplyAnomalyDetection(nil, detection, "report-1", "2024-06-11T00:00:00Z")
+	if record.Status != "open" || record.DetectionCount != 1 || record.Granularity != "weekly" || record.Detection.AnomalyID != record.AnomalyID {
+		t.Fatalf("New record = %+v", record)
+	}
+
+	// 同じファイルを再分析しても状態・担当者は引き継ぎ、AI質問が未生成なら以前の質問を残す
+	record.Status = "investigating"
+	record.Assignee = "tanaka"
+	reanalysed := models.AnomalyDetection{Date: "2024-06-10", ProductID: "P001", ActualValue: 310}
+	merged := ApplyAnomalyDetection(&record, reanalysed, "report-2", "2024-06-12T00:00:00Z")
+	if merged.Status != "investigating" || merged.Assignee != "tanaka" || merged.DetectionCount != 2 || len(merged.ReportIDs) != 2 {
+		t.Errorf("Merged record = %+v", merged)
+	}
+	if merged.Detection.ActualValue != 310 || merged.Detection.AIQuestion != "何がありましたか？" || merged.ProductName != "水" {
+		t.Errorf("Merged detection = %+v", merged.Detection)
+	}
+	if again := ApplyAnomalyDetection(&merged, reanalysed, "report-2", "2024-06-12T00:00:00Z"); again.DetectionCount != 2 {
+		t.Errorf("Same report should not be counted twice, got %d", again.DetectionCount)
+	}
+}
+
+func TestIsFreshDetection(t *testing.T) {
+	detection := models.AnomalyDetection{Date: "2024-06-10", ProductID: "P001", Severity: "critical"}
+	if !IsFreshDetection(nil, detection, "report-1") {
+		t.Error("A new anomaly should be notified")
+	}
+	record := ApplyAnomalyDetection(nil, detection, "report-1", "2024-06-11T00:00:00Z")
+
+	// 同じファイルの再分析で同じ深刻度のまま検出されても、再通知しない
+	if IsFreshDetection(&record, detection, "report-2") {
+		t.Error("A re-detected anomaly with the same severity should not be notified again")
+	}
+	// 同じレポートの登録し直し（AI質問の保存など）も通知しない
+	high := models.AnomalyDetection{Date: "2024-06-10", ProductID: "P001", Severity: "high"}
+	escalated := ApplyAnomalyDetection(nil, high, "report-1", "2024-06-11T00:00:00Z")
+	if IsFreshDetection(&escalated, detection, "report-1") {
+		t.Error("Re-registering the same report should not be notified")
+	}
+	if !IsFreshDetection(&escalated, detection, "report-2") {
+		t.Error("An anomaly escalated from high to critical should be notified")
+	}
+}
+
+func TestApplyAnomalyTransition(t *testing.T) {
+	record := ApplyAnomalyDetection(nil, models.AnomalyDetection{Date: "2024-06-10", ProductID: "P001"}, "report-1", "t0")
+
+	if err := ApplyAnomalyTransition(&record, models.AnomalyTransitionRequest{Status: "dismissed", Resolution: "false_positive", Actor: "sato"}, "t1"); err != nil {
+		t.Fatalf("open → dismissed failed: %v", err)
+	}
+	if record.Status != "dismissed" || record.Resolution != "false_positive" || record.ResolvedAt != "t1" || len(record.History) != 2 {
+		t.Errorf("Dismissed record = %+v", record)
+	}
+
+	err := ApplyAnomalyTransition(&record, models.AnomalyTransitionRequest{Status: "explained"}, "t2")
+	if !errors.Is(err, ErrInvalidAnomalyTransition) || record.Status != "dismissed" {
+		t.Errorf("dismissed → explained should be rejected, got %v", err)
+	}
+	if err := ApplyAnomalyTransition(&record, models.AnomalyTransitionRequest{Status: "resolved"}, "t2"); !errors.Is(err, ErrInvalidAnomalyRequest) || errors.Is(err, ErrInvalidAnomalyTransition) {
+		t.Errorf("Unknown status should be a validation error, got %v", err)
+	}
+
+	// 再オープンすると解決情報はクリアされる
+	if err := ApplyAnomalyTransition(&record, models.AnomalyTransitionRequest{Status: "open", Note: "再発"}, "t3"); err != nil {
+		t.Fatalf("dismissed → open failed: %v", err)
+	}
+	if record.ResolvedAt != "" || record.Resolution != "" || record.History[len(record.History)-1].From != "dismissed" {
+		t.Errorf("Reopened record = %+v", record)
+	}
+}
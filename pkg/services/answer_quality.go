@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hunt-chat-api/pkg/models"
+)
+
+const (
+	questionMetricsMinSamples = 3 // 推奨判定に必要な最小回答数
+)
+
+// 質問テンプレート（どの仕組みが生成した質問か）
+const (
+	TemplateAnomalyQuestion        = "anomaly_question"        // 異常検知時の最初の質問
+	TemplateFollowUpProbe          = "follow_up_probe"         // 回答が不十分な場合の深掘り質問
+	TemplateHypothesisVerification = "hypothesis_verification" // 仮説検証型インタビューの検証質問
+	TemplateSingleAnswer           = "single_answer"           // 対話なしで保存された単発の回答
+)
+
+var numberPattern = regexp.MustCompile(`[0-9０-９]+`)
+
+// AnswerQualityService 異常への回答の品質を分析し、質問の効果を集計する
+type AnswerQualityService struct {
+	azureOpenAIService *AzureOpenAIService
+	vectorStoreService *VectorStoreService
+}
+
+// NewAnswerQualityService 新しい回答品質サービスを作成
+func NewAnswerQualityService(azureOpenAIService *AzureOpenAIService, vectorStoreService *VectorStoreService) *AnswerQualityService {
+	return &AnswerQualityService{
+		azureOpenAIService: azureOpenAIService,
+		vectorStoreService: vectorStoreService,
+	}
+}
+
+// Score 回答の品質を分析する（AIが使えない場合やサービス未設定の場合は簡易的なルールで評価）
+func (s *AnswerQualityService) Score(question, answer string) *models.AnswerAnalysis {
+	var analysis *models.AnswerAnalysis
+	if s != nil && s.azureOpenAIService != nil {
+		result, err := s.azureOpenAIService.AnalyzeAnswerQuality(question, answer)
+		if err != nil {
+			log.Printf("⚠️ 回答品質の分析に失敗したため簡易評価を使用します: %v", err)
+		} else {
+			analysis = result
+			analysis.Method = "llm"
+		}
+	}
+	if analysis == nil {
+		analysis = HeuristicAnswerAnalysis(answer)
+	}
+	analysis.AnalyzedAt = time.Now().Format(time.RFC3339)
+	return analysis
+}
+
+// ScoreSession 品質分析がまだない会話を分析する（分析した件数を返す）
+func (s *AnswerQualityService) ScoreSession(session *models.AnomalyResponseSession) int {
+	scored := 0
+	for i := range session.Conversations {
+		conv := &session.Conversations[i]
+		if conv.Analysis != nil || strings.TrimSpace(conv.Answer) == "" {
+			continue
+		}
+		conv.Analysis = s.Score(conv.Question, conv.Answer)
+		scored++
+	}
+	return scored
+}
+
+// Backfill 品質分析がない会話を含むセッションを最大limit件分析して保存する
+func (s *AnswerQualityService) Backfill(ctx context.Context, limit int) (int, int, error) {
+	sessions, err := s.vectorStoreService.ListAnomalyResponseSessions(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	updatedSessions, scoredAnswers := 0, 0
+	for i := range sessions {
+		if updatedSessions >= limit {
+			break
+		}
+		scored := s.ScoreSession(&sessions[i])
+		if scored == 0 {
+			continue
+		}
+		if err := s.vectorStoreService.SaveAnomalyResponseSession(ctx, &sessions[i]); err != nil {
+			return updatedSessions, scoredAnswers, err
+		}
+		updatedSessions++
+		scoredAnswers += scored
+	}
+	return updatedSessions, scoredAnswers, nil
+}
+
+// Report 保存済みのセッション・単発の回答・フォローアップ質問から質問効果を集計する
+func (s *AnswerQualityService) Report(ctx context.Context) (*models.QuestionEffectivenessReport, error) {
+	sessions, err := s.vectorStoreService.ListAnomalyResponseSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := s.vectorStoreService.GetAllAnomalyResponses(ctx)
+	if err != nil {
+		log.Printf("⚠️ 単発の回答の取得に失敗: %v", err)
+	}
+	followUps, err := s.vectorStoreService.ListFollowUpQuestions(ctx, "", "")
+	if err != nil {
+		log.Printf("⚠️ フォローアップ質問の取得に失敗: %v", err)
+	}
+
+	report := AggregateQuestionMetrics(sessions, responses, followUps)
+	return &report, nil
+}
+
+// HeuristicAnswerAnalysis 数値・文字数・固有表現らしき語から簡易的に回答品質を評価する
+func HeuristicAnswerAnalysis(answer string) *models.AnswerAnalysis {
+	answer = strings.TrimSpace(answer)
+	length := utf8.RuneCountInString(answer)
+	numbers := numberPattern.FindAllString(answer, -1)
+
+	score := math.Min(float64(length), 60) + float64(len(numbers))*10
+	if strings.ContainsAny(answer, "社店円%％") {
+		score += 10
+	}
+	score = math.Min(score, 100)
+
+	analysis := &models.AnswerAnalysis{
+		SpecificityScore: int(score),
+		Sentiment:        "neutral",
+		Actionable:       length >= 15 && len(numbers) > 0,
+		PredictiveValue:  int(score * 0.8),
+		Method:           "heuristic",
+	}
+	for _, n := range numbers {
+		analysis.ExtractedEntities = append(analysis.ExtractedEntities, models.Entity{Type: "number", Value: n})
+	}
+	return analysis
+}
+
+// questionMetricsAccumulator 集計単位ごとの途中結果
+type questionMetricsAccumulator struct {
+	asked, answered, scored     int
+	answerLength                int
+	specificity, predictive     float64
+	density                     float64
+	actionable                  int
+	successTrials, successCount int
+}
+
+func (a *questionMetricsAccumulator) addAsked() { a.asked++ }
+
+func (a *questionMetricsAccumulator) addAnswer(answer string, analysis *models.AnswerAnalysis) {
+	a.asked++
+	a.answered++
+	length := utf8.RuneCountInString(answer)
+	a.answerLength += length
+	if analysis == nil {
+		return
+	}
+	a.scored++
+	a.specificity += float64(analysis.SpecificityScore)
+	a.predictive += float64(analysis.PredictiveValue)
+	if length > 0 {
+		a.density += float64(len(analysis.ExtractedEntities)+len(analysis.KeyPhrases)) / float64(length)
+	}
+	if analysis.Actionable {
+		a.actionable++
+	}
+}
+
+func (a *questionMetricsAccumulator) addSuccess(success bool) {
+	a.successTrials++
+	if success {
+		a.successCount++
+	}
+}
+
+func (a *questionMetricsAccumulator) metrics(dimension, key string) models.QuestionQualityMetrics {
+	m := models.QuestionQualityMetrics{
+		QuestionID:    dimension + ":" + key,
+		Dimension:     dimension,
+		Key:           key,
+		AskedCount:    a.asked,
+		AnsweredCount: a.answered,
+		ScoredCount:   a.scored,
+	}
+	if a.asked > 0 {
+		m.ResponseRate = roundTo(float64(a.answered)/float64(a.asked), 3)
+	}
+	if a.answered > 0 {
+		m.AverageAnswerLength = a.answerLength / a.answered
+	}
+	if a.scored > 0 {
+		m.SpecificityScore = roundTo(a.specificity/float64(a.scored), 1)
+		m.PredictiveValue = roundTo(a.predictive/float64(a.scored), 1)
+		m.InformationDensity = roundTo(a.density/float64(a.scored), 4)
+		m.ActionableRate = roundTo(float64(a.actionable)/float64(a.scored), 3)
+	}
+	if a.successTrials > 0 {
+		m.FollowUpSuccessRate = roundTo(float64(a.successCount)/float64(a.successTrials), 3)
+	}
+	m.Recommendation = questionRecommendation(m)
+	return m
+}
+
+// AggregateQuestionMetrics 質問テンプレート・仮説カテゴリごとに質問効果を集計する
+// 深掘り成功率は、深掘り質問では直前の回答より具体的になった割合、検証質問では仮説の支持・否定が確定した割合
+func AggregateQuestionMetrics(sessions []models.AnomalyResponseSession, responses []models.AnomalyResponse, followUps []models.FollowUpQuestion) models.QuestionEffectivenessReport {
+	byTemplate := make(map[string]*questionMetricsAccumulator)
+	byCategory := make(map[string]*questionMetricsAccumulator)
+	get := func(m map[string]*questionMetricsAccumulator, key string) *questionMetricsAccumulator {
+		if m[key] == nil {
+			m[key] = &questionMetricsAccumulator{}
+		}
+		return m[key]
+	}
+
+	report := models.QuestionEffectivenessReport{GeneratedAt: time.Now().Format(time.RFC3339)}
+	count := func(analysis *models.AnswerAnalysis) {
+		report.TotalQuestions++
+		if analysis != nil {
+			report.ScoredAnswers++
+		}
+	}
+
+	for _, session := range sessions {
+		hypothesisStatus := make(map[string]string)
+		hypothesisCategory := make(map[string]string)
+		for _, h := range session.Hypotheses {
+			hypothesisStatus[h.VerificationQuestion] = h.Status
+			hypothesisCategory[h.VerificationQuestion] = h.Category
+		}
+
+		for i, conv := range session.Conversations {
+			template := conversationTemplate(session, i)
+			acc := get(byTemplate, template)
+			acc.addAnswer(conv.Answer, conv.Analysis)
+			count(conv.Analysis)
+
+			switch template {
+			case TemplateFollowUpProbe:
+				if i > 0 && conv.Analysis != nil && session.Conversations[i-1].Analysis != nil {
+					acc.addSuccess(conv.Analysis.SpecificityScore > session.Conversations[i-1].Analysis.SpecificityScore)
+				}
+			case TemplateHypothesisVerification:
+				category := conv.HypothesisCategory
+				if category == "" {
+					category = hypothesisCategory[conv.Question]
+				}
+				if category == "" {
+					category = "unknown"
+				}
+				status := hypothesisStatus[conv.Question]
+				decisive := status == "supported" || status == "refuted"
+				acc.addSuccess(decisive)
+				catAcc := get(byCategory, category)
+				catAcc.addAnswer(conv.Answer, conv.Analysis)
+				catAcc.addSuccess(decisive)
+			}
+		}
+
+		// 完了前に離脱したインタビューの検証質問は未回答として数える
+		if session.Mode == "interview" && !session.IsComplete {
+			for _, h := range session.Hypotheses {
+				if h.ID == session.CurrentHypothesis && h.Answer == "" {
+					get(byTemplate, TemplateHypothesisVerification).addAsked()
+					get(byCategory, h.Category).addAsked()
+					report.TotalQuestions++
+				}
+			}
+		}
+	}
+
+	for _, response := range responses {
+		get(byTemplate, TemplateSingleAnswer).addAnswer(response.Answer, response.Analysis)
+		count(response.Analysis)
+	}
+
+	for _, followUp := range followUps {
+		switch followUp.Status {
+		case "answered":
+			get(byTemplate, followUp.QuestionType).addAnswer(followUp.Answer, followUp.Analysis)
+			count(followUp.Analysis)
+		case "sent", "skipped":
+			get(byTemplate, followUp.QuestionType).addAsked()
+			report.TotalQuestions++
+		}
+	}
+
+	report.ByTemplate = sortedQuestionMetrics(byTemplate, "template")
+	report.ByHypothesisCategory = sortedQuestionMetrics(byCategory, "hypothesis_category")
+	return report
+}
+
+// conversationTemplate 会話の質問テンプレート（記録がない古いセッションは会話の位置から推定）
+func conversationTemplate(session models.AnomalyResponseSession, index int) string {
+	if t := session.Conversations[index].QuestionTemplate; t != "" {
+		return t
+	}
+	if session.Mode == "interview" {
+		return TemplateHypothesisVerification
+	}
+	if index == 0 {
+		return TemplateAnomalyQuestion
+	}
+	return TemplateFollowUpProbe
+}
+
+// questionRecommendation 具体性・実行可能性・回答率から質問を使い続けるべきか判定
+func questionRecommendation(m models.QuestionQualityMetrics) string {
+	if m.ScoredCount < questionMetricsMinSamples {
+		return "insufficient_data"
+	}
+	if m.SpecificityScore < 40 && m.ActionableRate < 0.3 {
+		return "retire"
+	}
+	if m.SpecificityScore < 60 || m.ActionableRate < 0.5 || m.ResponseRate < 0.6 {
+		return "improve"
+	}
+	return "keep"
+}
+
+// sortedQuestionMetrics 集計結果を質問数の多い順に並べる
+func sortedQuestionMetrics(accs map[string]*questionMetricsAccumulator, dimension string) []models.QuestionQualityMetrics {
+	metrics := make([]models.QuestionQualityMetrics, 0, len(accs))
+	for key, acc := range accs {
+		metrics = append(metrics, acc.metrics(dimension, key))
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].AskedCount != metrics[j].AskedCount {
+			return metrics[i].AskedCount > metrics[j].AskedCount
+		}
+		return metrics[i].Key < metrics[j].Key
+	})
+	return metrics
+}
+
+// roundTo 小数点以下digits桁に丸める
+func roundTo(v float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"testing"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestHeuristicAnswerAnalysis(t *testing.T) {
+	vague := HeuristicAnswerAnalysis("特になし")
+	specific := HeuristicAnswerAnalysis("A社が6月10日から30%割引のキャンペーンを3店舗で実施した")
+
+	if vague.SpecificityScore >= specific.SpecificityScore {
+		t.Errorf("Specific answer should score higher: vague=%d specific=%d", vague.SpecificityScore, specific.SpecificityScore)
+	}
+	if vague.Actionable || !specific.Actionable {
+		t.Errorf("Actionable: vague=%v specific=%v", vague.Actionable, specific.Actionable)
+	}
+	if specific.Method != "heuristic" || len(specific.ExtractedEntities) == 0 {
+		t.Errorf("Expected heuristic analysis with number entities, got %+v", specific)
+	}
+
+	// AIサービスがない場合は簡易評価になる
+	if got := NewAnswerQualityService(nil, nil).Score("質問", "回答"); got.Method != "heuristic" || got.AnalyzedAt == "" {
+		t.Errorf("Score without AI = %+v", got)
+	}
+}
+
+func TestAggregateQuestionMetrics(t *testing.T) {
+	analysis := func(specificity int, actionable bool) *models.AnswerAnalysis {
+		return &models.AnswerAnalysis{SpecificityScore: specificity, Actionable: actionable, PredictiveValue: specificity, KeyPhrases: []string{"a"}}
+	}
+
+	sessions := []models.AnomalyResponseSession{
+		{
+			// 記録がない古いセッションは会話の位置からテンプレートを推定する
+			SessionID: "legacy",
+			Conversations: []models.Conversation{
+				{Question: "Q1", Answer: "わからない", Analysis: analysis(20, false)},
+				{Question: "Q2", Answer: "キャンペーンで30%割引", Analysis: analysis(80, true)},
+			},
+		},
+		{
+			SessionID:         "interview",
+			Mode:              "interview",
+			CurrentHypothesis: "H2",
+			Hypotheses: []models.HypothesisState{
+				{Hypothesis: models.Hypothesis{ID: "H1", Category: "internal", VerificationQuestion: "販促は？"}, Status: "supported", Answer: "はい"},
+				{Hypothesis: models.Hypothesis{ID: "H2", Category: "external", VerificationQuestion: "気温は？"}, Status: "asked"},
+			},
+			Conversations: []models.Conversation{
+				{Question: "販促は？", Answer: "はい", QuestionTemplate: TemplateHypothesisVerification, HypothesisCategory: "internal", Analysis: analysis(50, true)},
+			},
+		},
+	}
+	responses := []models.AnomalyResponse{{Answer: "雨", Analysis: analysis(10, false)}}
+	followUps := []models.FollowUpQuestion{
+		{QuestionType: "short_term_effect", Status: "answered", Answer: "続いている", Analysis: analysis(60, true)},
+		{QuestionType: "short_term_effect", Status: "skipped"},
+		{QuestionType: "yearly_review", Status: "pending"},
+	}
+
+	report := AggregateQuestionMetrics(sessions, responses, followUps)
+	byTemplate := make(map[string]models.QuestionQualityMetrics)
+	for _, m := range report.ByTemplate {
+		byTemplate[m.Key] = m
+	}
+	byCategory := make(map[string]models.QuestionQualityMetrics)
+	for _, m := range report.ByHypothesisCategory {
+		byCategory[m.Key] = m
+	}
+
+	if m := byTemplate[TemplateFollowUpProbe]; m.AnsweredCount != 1 || m.FollowUpSuccessRate != 1 {
+		t.Errorf("follow_up_probe = %+v, expected 1 answer that became more specific", m)
+	}
+	if m := byTemplate[TemplateAnomalyQuestion]; m.SpecificityScore != 20 || m.ActionableRate != 0 {
+		t.Errorf("anomaly_question = %+v", m)
+	}
+	// 回答待ちのまま離脱した検証質問は未回答として数える
+	if m := byTemplate[TemplateHypothesisVerification]; m.AskedCount != 2 || m.AnsweredCount != 1 || m.ResponseRate != 0.5 {
+		t.Errorf("hypothesis_verification = %+v", m)
+	}
+	if m := byCategory["internal"]; m.FollowUpSuccessRate != 1 || m.AnsweredCount != 1 {
+		t.Errorf("internal category = %+v", m)
+	}
+	if m := byCategory["external"]; m.AskedCount != 1 || m.AnsweredCount != 0 {
+		t.Errorf("external category = %+v", m)
+	}
+	// pending のフォローアップはまだ質問していないため数えない
+	if m := byTemplate["short_term_effect"]; m.AskedCount != 2 || m.ResponseRate != 0.5 {
+		t.Errorf("short_term_effect = %+v", m)
+	}
+	if _, ok := byTemplate["yearly_review"]; ok {
+		t.Error("Pending follow-ups should not be counted")
+	}
+	if report.ScoredAnswers != 5 || report.TotalQuestions != 7 {
+		t.Errorf("Totals = %d scored / %d questions", report.ScoredAnswers, report.TotalQuestions)
+	}
+}
+
+func TestQuestionRecommendation(t *testing.T) {
+	cases := []struct {
+		metrics  models.QuestionQualityMetrics
+		expected string
+	}{
+		{models.QuestionQualityMetrics{ScoredCount: 2, SpecificityScore: 90}, "insufficient_data"},
+		{models.QuestionQualityMetrics{ScoredCount: 5, SpecificityScore: 30, ActionableRate: 0.1, ResponseRate: 1}, "retire"},
+		{models.QuestionQualityMetrics{ScoredCount: 5, SpecificityScore: 70, ActionableRate: 0.8, ResponseRate: 0.4}, "improve"},
+		{models.QuestionQualityMetrics{ScoredCount: 5, SpecificityScore: 70, ActionableRate: 0.8, ResponseRate: 0.9}, "keep"},
+	}
+	for _, tc := range cases {
+		if got := questionRecommendation(tc.metrics); got != tc.expected {
+			t.Errorf("questionRecommendation(%+v) = %s, expected %s", tc.metrics, got, tc.expected)
+		}
+	}
+}
@@ -240,6 +240,28 @@ func (aos *AzureOpenAIService) CreateEmbedding(ctx context.Context, text string)
 
 // ProcessChatWithHistory は、過去のチャット履歴を活用してより良い回答を生成します。
 func (aos *AzureOpenAIService) ProcessChatWithHistory(chatMessage string, context string, relevantHistory []string) (string, error) {
+	messages, specialResponse, isSpecial := buildChatHistoryMessages(chatMessage, context, relevantHistory, nil)
+	if isSpecial {
+		return specialResponse, nil
+	}
+
+	// Azure OpenAI にリクエストを送信
+	resp, err := aos.CreateChatCompletion(messages, 2000, 0.7)
+	if err != nil {
+		return "", fmt.Errorf("AI処理中にエラーが発生しました: %w", err)
+	}
+
+	if len(resp.Choices) > 0 {
+		return resp.Choices[0].Message.Content, nil
+	}
+
+	return "", fmt.Errorf("AIから有効な回答が得られませんでした")
+}
+
+// buildChatHistoryMessages はシステムプロンプト・RAGコンテキスト・過去の会話からチャットのメッセージを組み立てます。
+// recentTurns（同じセッションの直近の発話）はシステムプロンプトと今回の質問の間に時系列順で挿入します。
+// 特殊コマンド（help, docsなど）に該当する場合は、その応答とtrueを返します。
+func buildChatHistoryMessages(chatMessage string, context string, relevantHistory []string, recentTurns []ChatMessage) ([]ChatMessage, string, bool) {
 	// システムプロンプトをYAMLファイルから読み込み
 	promptConfig, err := config.LoadSystemPrompt()
 	if err != nil {
@@ -259,35 +281,14 @@ func (aos *AzureOpenAIService) ProcessChatWithHistory(chatMessage string, contex
 			userPrompt += "- 一般的な知識を補足する場合: `> 💡 **一般的な知識:** [内容]`\n\n"
 		}
 
-		// 過去の関連する会話履歴を追加
-		if len(relevantHistory) > 0 {
-			userPrompt += "\n## 📚 関連する過去の会話\n"
-			for i, history := range relevantHistory {
-				userPrompt += fmt.Sprintf("%d. %s\n", i+1, history)
-			}
-		}
-
-		messages := []ChatMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		}
+		userPrompt += formatRelevantHistory(relevantHistory)
 
-		// Azure OpenAI にリクエストを送信
-		resp, err := aos.CreateChatCompletion(messages, 2000, 0.7)
-		if err != nil {
-			return "", fmt.Errorf("AI処理中にエラーが発生しました: %w", err)
-		}
-
-		if len(resp.Choices) > 0 {
-			return resp.Choices[0].Message.Content, nil
-		}
-
-		return "", fmt.Errorf("AIから有効な回答が得られませんでした")
+		return withRecentTurns(systemPrompt, recentTurns, userPrompt), "", false
 	}
 
 	// 特殊コマンドのチェック（help, docsなど）
 	if isSpecial, specialResponse := promptConfig.CheckSpecialCommand(chatMessage); isSpecial {
-		return specialResponse, nil
+		return nil, specialResponse, true
 	}
 
 	// システムプロンプトを構築
@@ -307,40 +308,159 @@ func (aos *AzureOpenAIService) ProcessChatWithHistory(chatMessage string, contex
 		userPrompt += "- 過去の対話から: `> 🗣️ **過去の対話より:** [内容]`\n\n"
 	}
 
-	// 過去の関連する会話履歴を追加
-	if len(relevantHistory) > 0 {
-		userPrompt += "\n## 📚 関連する過去の会話\n"
-		for i, history := range relevantHistory {
-			userPrompt += fmt.Sprintf("%d. %s\n", i+1, history)
+	userPrompt += formatRelevantHistory(relevantHistory)
+
+	return withRecentTurns(systemPrompt, recentTurns, userPrompt), "", false
+}
+
+// withRecentTurns システムプロンプト・直近の発話・今回のユーザープロンプトの順にメッセージを並べる
+func withRecentTurns(systemPrompt string, recentTurns []ChatMessage, userPrompt string) []ChatMessage {
+	messages := make([]ChatMessage, 0, len(recentTurns)+2)
+	messages = append(messages, ChatMessage{Role: "system", Content: systemPrompt})
+	messages = append(messages, recentTurns...)
+	messages = append(messages, ChatMessage{Role: "user", Content: userPrompt})
+	return messages
+}
+
+// formatRelevantHistory 過去の関連する会話履歴をプロンプト用に整形
+func formatRelevantHistory(relevantHistory []string) string {
+	if len(relevantHistory) == 0 {
+		return ""
+	}
+	section := "\n## 📚 関連する過去の会話\n"
+	for i, history := range relevantHistory {
+		section += fmt.Sprintf("%d. %s\n", i+1, history)
+	}
+	return section
+}
+
+// ProcessChatWithTools は ProcessChatWithHistory と同じプロンプトに、セッションの直近の発話とツール呼び出し（function calling）を加えて回答を生成します。
+// モデルがツールを要求した場合は実行結果を返して再度問い合わせ、最大 maxIterations 回まで繰り返します。
+// 上限に達した場合はツールなしで最終回答を求めます。実行したツールはすべて ToolInvocation として返します。
+// forcedTool を指定した場合は、最初の問い合わせでそのツールを必ず呼び出させます（意図ルートの tool）。
+func (aos *AzureOpenAIService) ProcessChatWithTools(ctx context.Context, chatMessage string, ragContext string, relevantHistory []string, recentTurns []ChatMessage, registry *ChatToolRegistry, forcedTool string, maxIterations int) (string, []models.ToolInvocation, error) {
+	invocations := make([]models.ToolInvocation, 0)
+
+	baseMessages, specialResponse, isSpecial := buildChatHistoryMessages(chatMessage, ragContext, relevantHistory, recentTurns)
+	if isSpecial {
+		return specialResponse, invocations, nil
+	}
+
+	var tools []azure.Tool
+	if registry != nil {
+		tools = registry.Definitions()
+	}
+	if len(tools) == 0 || maxIterations <= 0 {
+		resp, err := aos.CreateChatCompletion(baseMessages, 2000, 0.7)
+		if err != nil {
+			return "", invocations, fmt.Errorf("AI処理中にエラーが発生しました: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return "", invocations, fmt.Errorf("AIから有効な回答が得られませんでした")
 		}
+		return resp.Choices[0].Message.Content, invocations, nil
 	}
 
-	messages := []ChatMessage{
-		{Role: "system", Content: systemPrompt},
-		{Role: "user", Content: userPrompt},
+	messages := make([]azure.ChatMessage, 0, len(baseMessages))
+	for _, msg := range baseMessages {
+		messages = append(messages, azure.ChatMessage{Role: msg.Role, Content: msg.Content})
+	}
+	if forcedTool != "" && !registry.Has(forcedTool) {
+		log.Printf("⚠️ 意図ルートのツール %s が登録されていないため、ツールの選択はモデルに任せます", forcedTool)
+		forcedTool = ""
 	}
 
-	// Azure OpenAI にリクエストを送信
-	resp, err := aos.CreateChatCompletion(messages, 2000, 0.7)
-	if err != nil {
-		return "", fmt.Errorf("AI処理中にエラーが発生しました: %w", err)
+	for iteration := 1; iteration <= maxIterations; iteration++ {
+		toolChoice := "auto"
+		if iteration == 1 && forcedTool != "" {
+			toolChoice = forcedTool
+		}
+		callCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		resp, err := aos.client.ChatCompletionWithTools(callCtx, messages, tools, toolChoice, 2000, 0.7)
+		cancel()
+		if err != nil {
+			return "", invocations, fmt.Errorf("AI処理中にエラーが発生しました: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return "", invocations, fmt.Errorf("AIから有効な回答が得られませんでした")
+		}
+
+		choice := resp.Choices[0]
+		if len(choice.Message.ToolCalls) == 0 {
+			return choice.Message.Content, invocations, nil
+		}
+
+		// assistantのツール呼び出しメッセージを履歴に追加し、各ツールの結果をtoolメッセージとして返す
+		messages = append(messages, azure.ChatMessage{
+			Role:      "assistant",
+			Content:   choice.Message.Content,
+			ToolCalls: choice.Message.ToolCalls,
+		})
+		for _, call := range choice.Message.ToolCalls {
+			invocation := models.ToolInvocation{
+				ID:        call.ID,
+				Name:      call.Function.Name,
+				Arguments: call.Function.Arguments,
+				Iteration: iteration,
+			}
+			startTime := time.Now()
+			result, err := registry.Execute(ctx, call.Function.Name, call.Function.Arguments)
+			invocation.DurationMs = time.Since(startTime).Milliseconds()
+			if err != nil {
+				invocation.Status = "error"
+				invocation.Error = err.Error()
+				errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+				result = string(errorJSON)
+				log.Printf("⚠️ ツール実行エラー [%s]: %v", call.Function.Name, err)
+			} else {
+				invocation.Status = "success"
+				invocation.ResultPreview = truncateRunes(result, 500)
+				log.Printf("🛠️ ツール実行 [%s] (%dms)", call.Function.Name, invocation.DurationMs)
+			}
+			invocations = append(invocations, invocation)
+
+			messages = append(messages, azure.ChatMessage{
+				Role:       "tool",
+				Content:    result,
+				ToolCallID: call.ID,
+				Name:       call.Function.Name,
+			})
+		}
 	}
 
+	// ループ上限に達した場合は、ツール呼び出しを禁止して最終回答を生成
+	log.Printf("⚠️ ツール呼び出しが上限（%d回）に達したため、最終回答を生成します", maxIterations)
+	callCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	resp, err := aos.client.ChatCompletionWithTools(callCtx, messages, tools, "none", 2000, 0.7)
+	if err != nil {
+		return "", invocations, fmt.Errorf("AI処理中にエラーが発生しました: %w", err)
+	}
 	if len(resp.Choices) > 0 {
-		return resp.Choices[0].Message.Content, nil
+		return resp.Choices[0].Message.Content, invocations, nil
 	}
-
-	return "", fmt.Errorf("AIから有効な回答が得られませんでした")
+	return "", invocations, fmt.Errorf("AIから有効な回答が得られませんでした")
 }
 
 // ExtractMetadataFromMessage メッセージから意図やキーワードを抽出
-func (aos *AzureOpenAIService) ExtractMetadataFromMessage(message string) (intent string, keywords []string, err error) {
-	systemPrompt := `あなたはメッセージ分析の専門家です。与えられたメッセージから以下の情報を抽出してください：
-1. 意図（intent）: "需要予測", "異常分析", "データ分析", "質問", "その他" のいずれか
+// intentsが指定された場合は、その意図名のいずれかに分類する
+func (aos *AzureOpenAIService) ExtractMetadataFromMessage(message string, intents []config.IntentRoute) (intent string, keywords []string, err error) {
+	intentOptions := `"需要予測", "異常分析", "データ分析", "質問", "その他" のいずれか`
+	if len(intents) > 0 {
+		var sb strings.Builder
+		sb.WriteString("以下のいずれかの意図名（英字）\n")
+		for _, route := range intents {
+			sb.WriteString(fmt.Sprintf("   - %s: %s（%s）\n", route.Name, route.Label, route.Description))
+		}
+		intentOptions = sb.String()
+	}
+
+	systemPrompt := fmt.Sprintf(`あなたはメッセージ分析の専門家です。与えられたメッセージから以下の情報を抽出してください：
+1. 意図（intent）: %s
 2. キーワード: メッセージから重要なキーワードを3-5個抽出
 
 レスポンスは以下のJSON形式で返してください：
-{"intent": "意図", "keywords": ["キーワード1", "キーワード2", ...]}`
+{"intent": "意図", "keywords": ["キーワード1", "キーワード2", ...]}`, intentOptions)
 
 	userPrompt := fmt.Sprintf("以下のメッセージを分析してください：\n\n%s", message)
 
@@ -355,10 +475,21 @@ func (aos *AzureOpenAIService) ExtractMetadataFromMessage(message string) (inten
 	}
 
 	if len(resp.Choices) > 0 {
-		_ = resp.Choices[0].Message.Content
-		// 簡易的なパース（本番環境では正規表現やJSON解析を使用）
-		// ここでは基本的な実装として返す
-		return "質問", []string{"需要予測", "分析"}, nil
+		jsonString := resp.Choices[0].Message.Content
+		if start := strings.Index(jsonString, "{"); start >= 0 {
+			if end := strings.LastIndex(jsonString, "}"); end > start {
+				jsonString = jsonString[start : end+1]
+			}
+		}
+
+		var result struct {
+			Intent   string   `json:"intent"`
+			Keywords []string `json:"keywords"`
+		}
+		if err := json.Unmarshal([]byte(jsonString), &result); err != nil {
+			return "", nil, fmt.Errorf("メタデータの解析に失敗しました: %w. Response: %s", err, jsonString)
+		}
+		return strings.TrimSpace(result.Intent), result.Keywords, nil
 	}
 
 	return "", nil, fmt.Errorf("メタデータの抽出に失敗しました")
@@ -754,6 +885,85 @@ func (aos *AzureOpenAIService) GenerateScenarioQuestions(
 	return result.Scenarios, nil
 }
 
+// AssessHypothesisAnswer は検証質問への回答が、各仮説をどれだけ支持・否定するかを評価
+func (aos *AzureOpenAIService) AssessHypothesisAnswer(
+	anomalyContext string,
+	hypotheses []models.HypothesisState,
+	question string,
+	answer string,
+) ([]models.HypothesisAssessment, error) {
+
+	systemPrompt := `あなたは需要予測の異常分析アシスタントです。
+異常の原因についての複数の仮説と、検証質問への担当者の回答が与えられます。
+回答が各仮説をどれだけ支持するか、または否定するかを評価してください。
+
+【評価基準】
+- support は -1.0（明確に否定）〜 1.0（明確に支持）の数値
+- 回答が仮説と無関係な場合は 0.0
+- 質問の対象ではない仮説でも、回答から判断できる場合は評価する（例: 「キャンペーンはなかった」→ キャンペーン仮説を否定）
+- 推測ではなく、回答の内容に基づいて評価する
+
+【出力形式】必ずこのJSON形式で返してください：
+{
+  "assessments": [
+    {"hypothesis_id": "H1", "support": 0.8, "reasoning": "判断理由"}
+  ]
+}`
+
+	var hypothesesText strings.Builder
+	for _, h := range hypotheses {
+		fmt.Fprintf(&hypothesesText, "- %s [%s] %s: %s（現在の信頼度: %.2f）\n", h.ID, h.Category, h.Title, h.Description, h.Confidence)
+	}
+
+	userPrompt := fmt.Sprintf(`【異常の状況】
+%s
+
+【仮説】
+%s
+【検証質問】
+%s
+
+【担当者の回答】
+%s
+
+上記の回答が各仮説をどれだけ支持するか、JSON形式で評価してください。`,
+		anomalyContext,
+		hypothesesText.String(),
+		question,
+		answer,
+	)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	}
+
+	resp, err := aos.CreateChatCompletion(messages, 1000, 0.2)
+	if err != nil {
+		return nil, fmt.Errorf("仮説の評価に失敗しました: %w", err)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("AIから回答が得られませんでした")
+	}
+
+	content := resp.Choices[0].Message.Content
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("仮説評価のJSONが見つかりません\nContent: %s", content)
+	}
+
+	var result struct {
+		Assessments []models.HypothesisAssessment `json:"assessments"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &result); err != nil {
+		return nil, fmt.Errorf("仮説評価のJSON解析に失敗しました: %w\nContent: %s", err, content)
+	}
+
+	return result.Assessments, nil
+}
+
 // AnalyzeAnswerQuality は回答の品質を分析
 func (aos *AzureOpenAIService) AnalyzeAnswerQuality(
 	question string,
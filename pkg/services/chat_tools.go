@@ -0,0 +1,411 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"hunt-chat-api/pkg/azure"
+	"hunt-chat-api/pkg/models"
+)
+
+// maxToolResultChars ツール実行結果をモデルに返す際の最大文字数
+const maxToolResultChars = 6000
+
+// ChatToolHandler ツールの実行関数（引数はモデルが生成したJSON）
+type ChatToolHandler func(ctx context.Context, args json.RawMessage) (interface{}, error)
+
+// ChatTool チャットから呼び出せるツールの定義
+type ChatTool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage // JSONスキーマ
+	Handler     ChatToolHandler
+}
+
+// ChatToolRegistry チャットのエージェントループで使用するツールの登録簿
+type ChatToolRegistry struct {
+	tools map[string]ChatTool
+	order []string
+}
+
+// NewChatToolRegistry 空のツール登録簿を作成
+func NewChatToolRegistry() *ChatToolRegistry {
+	return &ChatToolRegistry{tools: make(map[string]ChatTool)}
+}
+
+// NewDefaultChatToolRegistry 気象・需要予測・統計・異常検知サービスをツールとして公開する登録簿を作成
+// 依存サービスがnilのツールは登録しない
+func NewDefaultChatToolRegistry(weatherService *WeatherService, statisticsService *StatisticsService, vectorStoreService *VectorStoreService) *ChatToolRegistry {
+	registry := NewChatToolRegistry()
+
+	if weatherService != nil {
+		registry.Register(ChatTool{
+			Name:        "get_weather_forecast",
+			Description: "日別の天気予報（気温・降水確率・天気・信頼度、取得元付き）を取得します。地域コードは6桁（例: 三重県 240000、東京都 130000）。",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"region_code": {"type": "string", "description": "気象庁の地域コード（例: 240000）"}
+				},
+				"required": ["region_code"]
+			}`),
+			Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+				var params struct {
+					RegionCode string `json:"region_code"`
+				}
+				if err := json.Unmarshal(args, &params); err != nil {
+					return nil, fmt.Errorf("引数の解析に失敗: %w", err)
+				}
+				if params.RegionCode == "" {
+					params.RegionCode = "240000"
+				}
+				return weatherService.GetDailyForecasts(params.RegionCode)
+			},
+		})
+	}
+
+	if statisticsService != nil && vectorStoreService != nil {
+		registry.Register(ChatTool{
+			Name:        "forecast_product_demand",
+			Description: "保存済みの売上実績（直近90日）を基に、製品の需要を曜日効果・気温相関を考慮して予測します。",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"product_id": {"type": "string", "description": "製品ID（例: P001）"},
+					"product_name": {"type": "string", "description": "製品名（省略時は製品ID）"},
+					"period": {"type": "string", "enum": ["week", "2weeks", "month"], "description": "予測期間"},
+					"region_code": {"type": "string", "description": "気象庁の地域コード（例: 240000）"}
+				},
+				"required": ["product_id"]
+			}`),
+			Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+				var params struct {
+					ProductID   string `json:"product_id"`
+					ProductName string `json:"product_name"`
+					Period      string `json:"period"`
+					RegionCode  string `json:"region_code"`
+				}
+				if err := json.Unmarshal(args, &params); err != nil {
+					return nil, fmt.Errorf("引数の解析に失敗: %w", err)
+				}
+				if params.ProductID == "" {
+					return nil, fmt.Errorf("product_id は必須です")
+				}
+				if params.ProductName == "" {
+					params.ProductName = params.ProductID
+				}
+				if params.Period == "" {
+					params.Period = "week"
+				}
+				if params.RegionCode == "" {
+					params.RegionCode = "240000"
+				}
+
+				end := time.Now()
+				series, err := vectorStoreService.GetSalesSeries(ctx, params.ProductID, end.AddDate(0, 0, -90), end)
+				if err != nil {
+					return nil, fmt.Errorf("売上実績の取得に失敗: %w", err)
+				}
+				history := make([]models.SalesDataPoint, 0, len(series))
+				for _, point := range series {
+					date, err := time.Parse("2006-01-02", point.Date)
+					if err != nil {
+						continue
+					}
+					history = append(history, models.SalesDataPoint{
+						Date:      point.Date,
+						Sales:     point.Sales,
+						DayOfWeek: statisticsService.getDayOfWeekJP(date.Weekday()),
+					})
+				}
+				if len(history) < 14 {
+					return nil, fmt.Errorf("製品 %s の売上実績が%d日分しかありません（予測には最低14日分が必要です）", params.ProductID, len(history))
+				}
+				return statisticsService.ForecastProductDemand(params.ProductID, params.ProductName, history, params.Period, params.RegionCode)
+			},
+		})
+
+		registry.Register(ChatTool{
+			Name:        "analyze_economic_lag",
+			Description: "経済指標（日経平均、為替、原油など）と製品売上のラグ付き相関を計算し、相関の強い上位5件のラグを返します。",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"product_id": {"type": "string", "description": "製品ID（例: P001）"},
+					"symbol": {"type": "string", "description": "経済指標のシンボル（例: NIKKEI, USDJPY, WTI）"},
+					"start": {"type": "string", "description": "開始日（YYYY-MM-DD、省略時は終了日の180日前）"},
+					"end": {"type": "string", "description": "終了日（YYYY-MM-DD、省略時は今日）"},
+					"max_lag": {"type": "integer", "description": "最大ラグ日数（デフォルト: 21）"}
+				},
+				"required": ["product_id", "symbol"]
+			}`),
+			Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+				var params struct {
+					ProductID string `json:"product_id"`
+					Symbol    string `json:"symbol"`
+					Start     string `json:"start"`
+					End       string `json:"end"`
+					MaxLag    int    `json:"max_lag"`
+				}
+				if err := json.Unmarshal(args, &params); err != nil {
+					return nil, fmt.Errorf("引数の解析に失敗: %w", err)
+				}
+				if params.ProductID == "" || params.Symbol == "" {
+					return nil, fmt.Errorf("product_id と symbol は必須です")
+				}
+				if params.MaxLag <= 0 {
+					params.MaxLag = 21
+				}
+				end := time.Now()
+				if params.End != "" {
+					parsed, err := time.Parse("2006-01-02", params.End)
+					if err != nil {
+						return nil, fmt.Errorf("end の形式が不正です（YYYY-MM-DD）: %w", err)
+					}
+					end = parsed
+				}
+				start := end.AddDate(0, 0, -180)
+				if params.Start != "" {
+					parsed, err := time.Parse("2006-01-02", params.Start)
+					if err != nil {
+						return nil, fmt.Errorf("start の形式が不正です（YYYY-MM-DD）: %w", err)
+					}
+					start = parsed
+				}
+
+				econ, err := vectorStoreService.GetEconomicSeries(ctx, strings.ToUpper(params.Symbol), start, end)
+				if err != nil {
+					return nil, fmt.Errorf("経済指標の取得に失敗: %w", err)
+				}
+				sales, err := vectorStoreService.GetSalesSeries(ctx, params.ProductID, start, end)
+				if err != nil {
+					return nil, fmt.Errorf("売上実績の取得に失敗: %w", err)
+				}
+				if len(econ) < 5 || len(sales) < 5 {
+					return nil, fmt.Errorf("データが不足しています（経済指標 %d件、売上 %d件）", len(econ), len(sales))
+				}
+
+				xDates := make([]string, 0, len(econ))
+				xVals := make([]float64, 0, len(econ))
+				for _, p := range econ {
+					xDates = append(xDates, p.Date)
+					xVals = append(xVals, p.Value)
+				}
+				yDates := make([]string, 0, len(sales))
+				yVals := make([]float64, 0, len(sales))
+				for _, p := range sales {
+					yDates = append(yDates, p.Date)
+					yVals = append(yVals, p.Sales)
+				}
+				results, err := statisticsService.CalculateLaggedCorrelations(xDates, xVals, yDates, yVals, params.MaxLag)
+				if err != nil {
+					return nil, err
+				}
+				if len(results) > 5 {
+					results = results[:5]
+				}
+				return map[string]interface{}{
+					"product_id": params.ProductID,
+					"symbol":     strings.ToUpper(params.Symbol),
+					"start":      start.Format("2006-01-02"),
+					"end":        end.Format("2006-01-02"),
+					"max_lag":    params.MaxLag,
+					"top":        results,
+				}, nil
+			},
+		})
+	}
+
+	if vectorStoreService != nil {
+		registry.Register(ChatTool{
+			Name:        "get_unanswered_anomalies",
+			Description: "分析レポートで検出された異常のうち、まだ担当者の回答がないものを新しい順に返します。",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"limit": {"type": "integer", "description": "最大件数（デフォルト: 10）"}
+				}
+			}`),
+			Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+				var params struct {
+					Limit int `json:"limit"`
+				}
+				if len(args) > 0 {
+					if err := json.Unmarshal(args, &params); err != nil {
+						return nil, fmt.Errorf("引数の解析に失敗: %w", err)
+					}
+				}
+				if params.Limit <= 0 {
+					params.Limit = 10
+				}
+				anomalies, err := vectorStoreService.FindUnansweredAnomalies(ctx)
+				if err != nil {
+					return nil, err
+				}
+				sort.Slice(anomalies, func(i, j int) bool { return anomalies[i].Date > anomalies[j].Date })
+				total := len(anomalies)
+				if len(anomalies) > params.Limit {
+					anomalies = anomalies[:params.Limit]
+				}
+				return map[string]interface{}{
+					"total":     total,
+					"anomalies": anomalies,
+				}, nil
+			},
+		})
+	}
+
+	return registry
+}
+
+// Register ツールを登録（同名のツールは上書き）
+func (r *ChatToolRegistry) Register(tool ChatTool) {
+	if _, exists := r.tools[tool.Name]; !exists {
+		r.order = append(r.order, tool.Name)
+	}
+	r.tools[tool.Name] = tool
+}
+
+// Names 登録済みのツール名を登録順に返す
+func (r *ChatToolRegistry) Names() []string {
+	return append([]string(nil), r.order...)
+}
+
+// Has 指定した名前のツールが登録されているか
+func (r *ChatToolRegistry) Has(name string) bool {
+	_, ok := r.tools[name]
+	return ok
+}
+
+// Definitions Azure OpenAI の tools パラメータ用の定義を返す
+func (r *ChatToolRegistry) Definitions() []azure.Tool {
+	definitions := make([]azure.Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tool := r.tools[name]
+		definitions = append(definitions, azure.Tool{
+			Type: "function",
+			Function: azure.ToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return definitions
+}
+
+// Execute ツールを実行し、結果をモデルに返すJSON文字列（最大 maxToolResultChars 文字）で返す
+// 長すぎる結果は配列の項目などを省き、JSONとして有効なまま truncated を付けて返す
+func (r *ChatToolRegistry) Execute(ctx context.Context, name string, arguments string) (string, error) {
+	tool, ok := r.tools[name]
+	if !ok {
+		return "", fmt.Errorf("未登録のツールです: %s", name)
+	}
+
+	args := json.RawMessage(arguments)
+	if strings.TrimSpace(arguments) == "" {
+		args = json.RawMessage("{}")
+	}
+	if !json.Valid(args) {
+		return "", fmt.Errorf("ツール %s の引数が不正なJSONです", name)
+	}
+
+	result, err := tool.Handler(ctx, args)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("ツール %s の結果のJSON化に失敗: %w", name, err)
+	}
+	if utf8.RuneCount(data) <= maxToolResultChars {
+		return string(data), nil
+	}
+	shortened, err := shortenToolResult(data, maxToolResultChars)
+	if err != nil {
+		return "", fmt.Errorf("ツール %s の結果の短縮に失敗: %w", name, err)
+	}
+	return shortened, nil
+}
+
+// shortenToolResult JSONとして有効なまま、最も長い配列の後半の項目を省いて（配列がなければ最も長い文字列を切り詰めて）
+// maxRunes 文字以内にし、省略したことを truncated で示す
+func shortenToolResult(data []byte, maxRunes int) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return "", err
+	}
+	// オブジェクトはそのまま truncated を加え、配列・値は result に入れる
+	object, ok := root.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{"result": root}
+	}
+	object["truncated"] = true
+
+	for {
+		shortened, err := json.Marshal(object)
+		if err != nil {
+			return "", err
+		}
+		if utf8.RuneCount(shortened) <= maxRunes {
+			return string(shortened), nil
+		}
+		if !shortenLargestNode(object) {
+			return `{"truncated":true,"error":"結果が長すぎるため省略しました"}`, nil
+		}
+	}
+}
+
+// shortenLargestNode 最も項目の多い配列を半分に（配列がなければ最も長い文字列を半分に）する。短くできなければ false
+func shortenLargestNode(object map[string]interface{}) bool {
+	var list []interface{}
+	var setList func(interface{})
+	var text string
+	var setText func(interface{})
+
+	var walk func(value interface{}, set func(interface{}))
+	walk = func(value interface{}, set func(interface{})) {
+		switch v := value.(type) {
+		case []interface{}:
+			if len(v) > len(list) {
+				list, setList = v, set
+			}
+			for i := range v {
+				i := i
+				walk(v[i], func(n interface{}) { v[i] = n })
+			}
+		case map[string]interface{}:
+			for key, child := range v {
+				key := key
+				walk(child, func(n interface{}) { v[key] = n })
+			}
+		case string:
+			if utf8.RuneCountInString(v) > utf8.RuneCountInString(text) {
+				text, setText = v, set
+			}
+		}
+	}
+	for key, child := range object {
+		key := key
+		walk(child, func(n interface{}) { object[key] = n })
+	}
+
+	if len(list) > 0 {
+		setList(list[:len(list)/2])
+		return true
+	}
+	if runes := []rune(text); len(runes) > 1 {
+		setText(string(runes[:len(runes)/2]) + "…")
+		return true
+	}
+	return false
+}
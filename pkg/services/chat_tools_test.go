@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDefaultChatToolRegistryDefinitions(t *testing.T) {
+	registry := NewDefaultChatToolRegistry(NewWeatherService(), NewStatisticsService(nil, nil, nil), &VectorStoreService{})
+
+	expected := []string{"get_weather_forecast", "forecast_product_demand", "analyze_economic_lag", "get_unanswered_anomalies"}
+	names := registry.Names()
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Names() = %v, expected %v", names, expected)
+	}
+
+	for _, definition := range registry.Definitions() {
+		if definition.Type != "function" {
+			t.Errorf("Tool %s has type %q, expected function", definition.Function.Name, definition.Type)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(definition.Function.Parameters, &schema); err != nil {
+			t.Errorf("Tool %s has invalid JSON schema: %v", definition.Function.Name, err)
+			continue
+		}
+		if schema["type"] != "object" {
+			t.Errorf("Tool %s schema type = %v, expected object", definition.Function.Name, schema["type"])
+		}
+	}
+
+	// 依存サービスがないツールは登録されない
+	if got := NewDefaultChatToolRegistry(nil, nil, nil).Names(); len(got) != 0 {
+		t.Errorf("Expected no tools without services, got %v", got)
+	}
+}
+
+func TestChatToolRegistryExecute(t *testing.T) {
+	registry := NewChatToolRegistry()
+	registry.Register(ChatTool{
+		Name:       "echo",
+		Parameters: json.RawMessage(`{"type": "object"}`),
+		Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+			var params map[string]interface{}
+			if err := json.Unmarshal(args, &params); err != nil {
+				return nil, err
+			}
+			if params["fail"] == true {
+				return nil, fmt.Errorf("failed")
+			}
+			return params, nil
+		},
+	})
+
+	result, err := registry.Execute(context.Background(), "echo", `{"value": 1}`)
+	if err != nil || result != `{"value":1}` {
+		t.Errorf("Execute(echo) = %q, %v", result, err)
+	}
+
+	// 空の引数は {} として扱う
+	if result, err := registry.Execute(context.Background(), "echo", ""); err != nil || result != `{}` {
+		t.Errorf("Execute(echo, empty) = %q, %v", result, err)
+	}
+
+	if _, err := registry.Execute(context.Background(), "unknown", "{}"); err == nil {
+		t.Error("Expected error for unknown tool")
+	}
+	if _, err := registry.Execute(context.Background(), "echo", "{invalid"); err == nil {
+		t.Error("Expected error for invalid JSON arguments")
+	}
+	if _, err := registry.Execute(context.Background(), "echo", `{"fail": true}`); err == nil {
+		t.Error("Expected handler error to be returned")
+	}
+}
+
+func TestChatToolRegistryExecuteShortensLongResults(t *testing.T) {
+	type day struct {
+		Date  string  `json:"date"`
+		Sales float64 `json:"sales"`
+	}
+	var days []day
+	for i := 0; i < 2000; i++ {
+		days = append(days, day{Date: fmt.Sprintf("2024-%04d", i), Sales: float64(i)})
+	}
+	registry := NewChatToolRegistry()
+	registry.Register(ChatTool{Name: "days", Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+		return map[string]interface{}{"product_id": "P001", "days": days}, nil
+	}})
+	registry.Register(ChatTool{Name: "list", Handler: func(ctx context.Context, args json.RawMessage) (interface{}, error) {
+		return days, nil
+	}})
+
+	// 長い結果も項目を省いて有効なJSONのまま返し、省略したことを示す
+	for _, name := range []string{"days", "list"} {
+		result, err := registry.Execute(context.Background(), name, "{}")
+		if err != nil {
+			t.Fatalf("Execute(%s) failed: %v", name, err)
+		}
+		var parsed map[string]interface{}
+		if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+			t.Fatalf("Execute(%s) returned invalid JSON: %v", name, err)
+		}
+		if len([]rune(result)) > maxToolResultChars || parsed["truncated"] != true {
+			t.Errorf("Execute(%s) = %d chars, truncated %v", name, len([]rune(result)), parsed["truncated"])
+		}
+		items := parsed["days"]
+		if name == "list" {
+			items = parsed["result"]
+		} else if parsed["product_id"] != "P001" {
+			t.Errorf("Expected other fields to be kept, got %v", parsed["product_id"])
+		}
+		if list, ok := items.([]interface{}); !ok || len(list) == 0 || len(list) >= len(days) {
+			t.Errorf("Execute(%s) kept %v items", name, len(list))
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// minTruncatedChunkTokens 切り詰めてでも含める価値があるとみなす最小トークン数
+const minTruncatedChunkTokens = 80
+
+// ContextCandidate RAGコンテキストに含める候補チャンク
+type ContextCandidate struct {
+	Source models.ContextSource // スコア情報付きのソース
+	Text   string               // プロンプトに挿入するテキスト
+	Pinned bool                 // リクエストで明示的に渡されたコンテキスト（常に最優先）
+}
+
+// PackedContext リランキング・重複除去・トークン予算詰め込みの結果
+type PackedContext struct {
+	Included   []ContextCandidate     // 採用されたチャンク（リランク順）
+	Sources    []models.ContextSource // 採用・不採用を含む全候補のソース情報
+	UsedTokens int                    // 採用チャンクの推定トークン数
+	Budget     int                    // トークン予算
+}
+
+// EstimateTokens テキストのトークン数を推定する
+// 日本語（非ASCII）は1文字≒1トークン、ASCIIは4文字≒1トークンとして概算する
+func EstimateTokens(text string) int {
+	var ascii, nonASCII int
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			nonASCII++
+		}
+	}
+	return nonASCII + int(math.Ceil(float64(ascii)/4.0))
+}
+
+// truncateToTokens 推定トークン数が上限に収まるようにテキストを切り詰める
+func truncateToTokens(text string, maxTokens int) string {
+	const suffix = "…（以下省略）"
+	limit := maxTokens - EstimateTokens(suffix)
+	var ascii, nonASCII int
+	for i, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			nonASCII++
+		}
+		if nonASCII+int(math.Ceil(float64(ascii)/4.0)) > limit {
+			return text[:i] + suffix
+		}
+	}
+	return text
+}
+
+// tokenSet 重複判定用のトークン集合
+func tokenSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range TokenizeJapanese(text) {
+		set[t] = true
+	}
+	return set
+}
+
+// jaccardSimilarity 2つのトークン集合のJaccard係数
+func jaccardSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1.0
+	}
+	var intersection int
+	for t := range a {
+		if b[t] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// heuristicRerankScore 検索スコアとクエリ語の被覆率を組み合わせた軽量なクロススコア
+func heuristicRerankScore(query string, candidate ContextCandidate) float64 {
+	queryTokens := tokenSet(query)
+	textTokens := tokenSet(candidate.Text + " " + candidate.Source.FileName)
+
+	var matched, exactMatched int
+	for t := range queryTokens {
+		if textTokens[t] {
+			matched++
+			// 製品コードや日付などの英数字トークンの一致を重視
+			if utf8.RuneCountInString(t) > 2 && t[0] < utf8.RuneSelf {
+				exactMatched++
+			}
+		}
+	}
+	coverage := 0.0
+	if len(queryTokens) > 0 {
+		coverage = float64(matched) / float64(len(queryTokens))
+	}
+
+	retrieval := float64(candidate.Source.Score)
+	if candidate.Source.FusedScore > retrieval {
+		retrieval = candidate.Source.FusedScore
+	}
+
+	score := 0.5*retrieval + 0.4*coverage + 0.1*math.Min(1.0, float64(exactMatched))
+
+	// 非常に長いチャンクはわずかに減点（他の候補を押し出しやすいため）
+	if tokens := EstimateTokens(candidate.Text); tokens > 1500 {
+		score *= 0.9
+	}
+	return score
+}
+
+// llmRerankScores LLMに各候補の関連度（0-10）を採点させる
+func (h *HybridSearchService) llmRerankScores(query string, candidates []ContextCandidate) ([]float64, error) {
+	if h.vectorStoreService == nil || h.vectorStoreService.azureOpenAIService == nil {
+		return nil, fmt.Errorf("AzureOpenAIServiceが初期化されていません")
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("## 質問\n%s\n\n## 候補\n", query))
+	for i, c := range candidates {
+		snippet := truncateToTokens(c.Text, 200)
+		prompt.WriteString(fmt.Sprintf("[%d] (%s: %s)\n%s\n\n", i, c.Source.Type, c.Source.FileName, snippet))
+	}
+	prompt.WriteString(fmt.Sprintf(`各候補が質問への回答にどれだけ役立つかを0〜10で採点してください。
+レスポンスは必ず以下のJSON形式で、候補と同じ順序・同じ件数（%d件）で返してください。
+{"scores": [7, 2, ...]}`, len(candidates)))
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "あなたは検索結果の関連度を採点する評価者です。JSON形式のみで応答してください。"},
+		{Role: "user", Content: prompt.String()},
+	}
+
+	resp, err := h.vectorStoreService.azureOpenAIService.CreateChatCompletion(messages, 200, 0.0)
+	if err != nil {
+		return nil, fmt.Errorf("LLMリランキングに失敗: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("LLMリランキングの応答が空です")
+	}
+
+	content := resp.Choices[0].Message.Content
+	if start := strings.Index(content, "{"); start >= 0 {
+		if end := strings.LastIndex(content, "}"); end > start {
+			content = content[start : end+1]
+		}
+	}
+
+	var result struct {
+		Scores []float64 `json:"scores"`
+	}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, fmt.Errorf("LLMリランキング応答の解析に失敗: %w", err)
+	}
+	if len(result.Scores) != len(candidates) {
+		return nil, fmt.Errorf("LLMリランキングの採点件数が一致しません: %d != %d", len(result.Scores), len(candidates))
+	}
+
+	scores := make([]float64, len(result.Scores))
+	for i, s := range result.Scores {
+		scores[i] = math.Max(0, math.Min(10, s)) / 10.0
+	}
+	return scores, nil
+}
+
+// PackContext 候補をリランキングし、重複を除去したうえでトークン予算内に詰め込む
+func (h *HybridSearchService) PackContext(query string, candidates []ContextCandidate) PackedContext {
+	budget := h.config.TokenBudget
+	packed := PackedContext{Budget: budget}
+	if len(candidates) == 0 {
+		return packed
+	}
+
+	// 1. リランキング
+	ranked := make([]ContextCandidate, len(candidates))
+	copy(ranked, candidates)
+
+	var llmScores []float64
+	if h.config.RerankMode == "llm" {
+		var unpinned []ContextCandidate
+		for _, c := range ranked {
+			if !c.Pinned {
+				unpinned = append(unpinned, c)
+			}
+		}
+		if len(unpinned) > 0 {
+			scores, err := h.llmRerankScores(query, unpinned)
+			if err != nil {
+				log.Printf("⚠️ LLMリランキングに失敗したためヒューリスティックにフォールバック: %v", err)
+			} else {
+				llmScores = scores
+			}
+		}
+	}
+
+	llmIndex := 0
+	for i := range ranked {
+		switch {
+		case ranked[i].Pinned:
+			ranked[i].Source.RerankScore = 1.0
+		case llmScores != nil:
+			ranked[i].Source.RerankScore = llmScores[llmIndex]
+			llmIndex++
+		default:
+			ranked[i].Source.RerankScore = heuristicRerankScore(query, ranked[i])
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Pinned != ranked[j].Pinned {
+			return ranked[i].Pinned
+		}
+		return ranked[i].Source.RerankScore > ranked[j].Source.RerankScore
+	})
+
+	// 2. 重複除去とトークン予算への詰め込み
+	var keptTokenSets []map[string]bool
+	for _, c := range ranked {
+		c.Source.Tokens = EstimateTokens(c.Text)
+
+		tokens := tokenSet(c.Text)
+		duplicate := false
+		for _, kept := range keptTokenSets {
+			if jaccardSimilarity(tokens, kept) >= h.config.DedupThreshold {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			c.Source.DropReason = "duplicate"
+			packed.Sources = append(packed.Sources, c.Source)
+			continue
+		}
+
+		remaining := budget - packed.UsedTokens
+		if c.Source.Tokens > remaining {
+			if remaining < minTruncatedChunkTokens {
+				c.Source.DropReason = "token_budget"
+				packed.Sources = append(packed.Sources, c.Source)
+				continue
+			}
+			c.Text = truncateToTokens(c.Text, remaining)
+			c.Source.Tokens = EstimateTokens(c.Text)
+			c.Source.Truncated = true
+		}
+
+		c.Source.Included = true
+		packed.UsedTokens += c.Source.Tokens
+		keptTokenSets = append(keptTokenSets, tokens)
+		packed.Included = append(packed.Included, c)
+		packed.Sources = append(packed.Sources, c.Source)
+	}
+
+	log.Printf("📦 コンテキスト詰め込み: 候補%d件 → 採用%d件 (%d/%dトークン, リランク: %s)",
+		len(candidates), len(packed.Included), packed.UsedTokens, budget, h.rerankModeName(llmScores != nil))
+	return packed
+}
+
+// rerankModeName ログ用のリランキング方式名
+func (h *HybridSearchService) rerankModeName(usedLLM bool) string {
+	if usedLLM {
+		return "llm"
+	}
+	return "heuristic"
+}
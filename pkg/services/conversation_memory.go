@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// ConversationMemoryConfig 会話メモリの設定
+type ConversationMemoryConfig struct {
+	RecentTurns     int // プロンプトに常に含める直近の発話数
+	SummaryInterval int // 未要約の古い発話がこの数に達したら要約を更新
+}
+
+// SessionMemory 1セッション分の会話メモリ（古い発話の要約 + 要約に含まれていない発話）
+type SessionMemory struct {
+	Summary     *models.ChatSessionSummary
+	RecentTurns []models.ChatHistoryEntry // 要約に含まれていない発話（直近の発話を含む。時系列順）
+	TotalTurns  int
+}
+
+// ConversationMemoryService セッションのトランスクリプトを時系列で管理し、長いセッションを要約する
+type ConversationMemoryService struct {
+	vectorStoreService *VectorStoreService
+	azureOpenAIService *AzureOpenAIService
+	config             ConversationMemoryConfig
+
+	mu          sync.Mutex
+	summarizing map[string]bool
+}
+
+// NewConversationMemoryService 新しい会話メモリサービスを作成
+func NewConversationMemoryService(vectorStoreService *VectorStoreService, azureOpenAIService *AzureOpenAIService, cfg ConversationMemoryConfig) *ConversationMemoryService {
+	if cfg.RecentTurns <= 0 {
+		cfg.RecentTurns = 6
+	}
+	if cfg.SummaryInterval <= 0 {
+		cfg.SummaryInterval = 10
+	}
+	return &ConversationMemoryService{
+		vectorStoreService: vectorStoreService,
+		azureOpenAIService: azureOpenAIService,
+		config:             cfg,
+		summarizing:        make(map[string]bool),
+	}
+}
+
+// Config 会話メモリの設定を返す
+func (m *ConversationMemoryService) Config() ConversationMemoryConfig {
+	return m.config
+}
+
+// Load セッションの要約と、要約に含まれていない発話を読み込む（excludeIDの発話は除外）
+// 要約は古い発話が SummaryInterval 件たまるまで更新されないため、それまでの発話も直近の発話と合わせて含める
+func (m *ConversationMemoryService) Load(ctx context.Context, sessionID string, excludeID string) (*SessionMemory, error) {
+	transcript, err := m.vectorStoreService.GetChatTranscript(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	totalTurns := 0
+	for _, entry := range transcript {
+		if entry.ID != excludeID {
+			totalTurns++
+		}
+	}
+	memory := &SessionMemory{TotalTurns: totalTurns}
+
+	summarizedTurns := 0
+	if totalTurns > m.config.RecentTurns {
+		summary, err := m.vectorStoreService.GetChatSessionSummary(ctx, sessionID)
+		if err != nil {
+			log.Printf("⚠️ セッション要約の取得に失敗: %v", err)
+		} else if summary != nil && summary.Summary != "" && summary.SummarizedTurns <= len(transcript) {
+			memory.Summary = summary
+			summarizedTurns = summary.SummarizedTurns
+		}
+	}
+	memory.RecentTurns = unsummarizedTurns(transcript, excludeID, summarizedTurns, m.config.RecentTurns+m.config.SummaryInterval)
+
+	return memory, nil
+}
+
+// MaybeSummarize 直近の発話より古い未要約の発話が SummaryInterval 以上たまっていれば、既存の要約に畳み込んで更新する
+// 同じセッションの要約が実行中の場合は何もしない
+func (m *ConversationMemoryService) MaybeSummarize(ctx context.Context, sessionID string) (*models.ChatSessionSummary, error) {
+	m.mu.Lock()
+	if m.summarizing[sessionID] {
+		m.mu.Unlock()
+		return nil, nil
+	}
+	m.summarizing[sessionID] = true
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.summarizing, sessionID)
+		m.mu.Unlock()
+	}()
+
+	transcript, err := m.vectorStoreService.GetChatTranscript(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	olderCount := len(transcript) - m.config.RecentTurns
+	if olderCount <= 0 {
+		return nil, nil
+	}
+
+	summary, err := m.vectorStoreService.GetChatSessionSummary(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	summarizedTurns := 0
+	previousSummary := ""
+	if summary != nil {
+		summarizedTurns = summary.SummarizedTurns
+		previousSummary = summary.Summary
+	}
+	if summarizedTurns > olderCount {
+		// 発話が削除された場合などは最初から要約し直す
+		summarizedTurns = 0
+		previousSummary = ""
+	}
+	if olderCount-summarizedTurns < m.config.SummaryInterval {
+		return summary, nil
+	}
+
+	newTurns := transcript[summarizedTurns:olderCount]
+	text, err := m.summarize(previousSummary, newTurns)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := models.ChatSessionSummary{
+		SessionID:       sessionID,
+		Summary:         text,
+		SummarizedTurns: olderCount,
+		LastTurnAt:      newTurns[len(newTurns)-1].Timestamp,
+		UpdatedAt:       time.Now().Format(time.RFC3339),
+	}
+	if err := m.vectorStoreService.SaveChatSessionSummary(ctx, updated); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📝 セッション %s の要約を更新しました（%d件の発話を要約）", sessionID, olderCount)
+	return &updated, nil
+}
+
+// summarize 既存の要約に新しい発話を畳み込んだ要約をLLMで生成
+func (m *ConversationMemoryService) summarize(previousSummary string, turns []models.ChatHistoryEntry) (string, error) {
+	if m.azureOpenAIService == nil {
+		return "", fmt.Errorf("AIサービスが利用できません")
+	}
+
+	var prompt strings.Builder
+	if previousSummary != "" {
+		prompt.WriteString("## これまでの要約\n")
+		prompt.WriteString(previousSummary)
+		prompt.WriteString("\n\n")
+	}
+	prompt.WriteString("## 追加の会話\n")
+	prompt.WriteString(formatTranscript(turns))
+	prompt.WriteString("\nこれまでの要約と追加の会話を統合し、会話全体の要約を日本語で400字以内にまとめてください。")
+	prompt.WriteString("話題になった製品ID・期間・数値・決定事項・未解決の質問は必ず残してください。要約本文のみを出力してください。")
+
+	messages := []ChatMessage{
+		{Role: "system", Content: "あなたは需要予測システム「HUNT」の会話を記録するアシスタントです。後続の会話で参照できるよう、事実を正確に要約します。"},
+		{Role: "user", Content: prompt.String()},
+	}
+	resp, err := m.azureOpenAIService.CreateChatCompletion(messages, 800, 0.2)
+	if err != nil {
+		return "", fmt.Errorf("会話の要約に失敗: %w", err)
+	}
+	if len(resp.Choices) == 0 || strings.TrimSpace(resp.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("AIから要約が得られませんでした")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// unsummarizedTurns 時系列順の発話のうち、要約済みの先頭 summarizedTurns 件と excludeID の発話を除いたものを返す
+// 要約の更新に失敗し続けた場合もプロンプトが長くなりすぎないよう、末尾 limit 件までにする
+func unsummarizedTurns(transcript []models.ChatHistoryEntry, excludeID string, summarizedTurns, limit int) []models.ChatHistoryEntry {
+	turns := make([]models.ChatHistoryEntry, 0, len(transcript)-summarizedTurns)
+	for _, entry := range transcript[summarizedTurns:] {
+		if entry.ID != excludeID {
+			turns = append(turns, entry)
+		}
+	}
+	return recentTurns(turns, limit)
+}
+
+// recentTurns 時系列順の発話から末尾n件を返す
+func recentTurns(turns []models.ChatHistoryEntry, n int) []models.ChatHistoryEntry {
+	if len(turns) <= n {
+		return turns
+	}
+	return turns[len(turns)-n:]
+}
+
+// formatTranscript 発話を「[日時] 役割: 内容」の形式で整形
+func formatTranscript(turns []models.ChatHistoryEntry) string {
+	var b strings.Builder
+	for _, turn := range turns {
+		role := "ユーザー"
+		if turn.Role == "assistant" {
+			role = "アシスタント"
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n", turn.Timestamp, role, turn.Message)
+	}
+	return b.String()
+}
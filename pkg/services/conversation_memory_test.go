@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+func TestSortChatHistoryByTime(t *testing.T) {
+	base := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	entries := []models.ChatHistoryEntry{
+		{ID: "3", Role: "user", CreatedAt: base.Add(2 * time.Minute)},
+		{ID: "2", Role: "assistant", CreatedAt: base},
+		{ID: "1", Role: "user", CreatedAt: base},
+	}
+
+	sortChatHistoryByTime(entries)
+
+	for i, expected := range []string{"1", "2", "3"} {
+		if entries[i].ID != expected {
+			t.Errorf("entries[%d].ID = %s, expected %s", i, entries[i].ID, expected)
+		}
+	}
+
+	if got := recentTurns(entries, 2); len(got) != 2 || got[0].ID != "2" {
+		t.Errorf("recentTurns() = %v, expected last 2 entries", got)
+	}
+}
+
+func TestUnsummarizedTurns(t *testing.T) {
+	var transcript []models.ChatHistoryEntry
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"} {
+		transcript = append(transcript, models.ChatHistoryEntry{ID: id})
+	}
+
+	// 要約済みの2件を除き、直近の発話より古い未要約の発話も含める
+	got := unsummarizedTurns(transcript, "9", 2, 16)
+	if len(got) != 6 || got[0].ID != "3" || got[len(got)-1].ID != "8" {
+		t.Errorf("unsummarizedTurns() = %v, expected turns 3 to 8", got)
+	}
+	if got := unsummarizedTurns(transcript, "", 0, 4); len(got) != 4 || got[0].ID != "6" {
+		t.Errorf("unsummarizedTurns() = %v, expected the last 4 turns", got)
+	}
+}
+
+func TestChatHistoryEntryFromPayload(t *testing.T) {
+	createdAt := time.Date(2025, 7, 1, 10, 0, 0, 123, time.UTC)
+	payload := map[string]*qdrant.Value{
+		"session_id": {Kind: &qdrant.Value_StringValue{StringValue: "s1"}},
+		"role":       {Kind: &qdrant.Value_StringValue{StringValue: "user"}},
+		"message":    {Kind: &qdrant.Value_StringValue{StringValue: "P001の売上は？"}},
+		"text":       {Kind: &qdrant.Value_StringValue{StringValue: "Role: user\nMessage: P001の売上は？"}},
+		"created_at": {Kind: &qdrant.Value_IntegerValue{IntegerValue: createdAt.UnixNano()}},
+	}
+
+	entry := chatHistoryEntryFromPayload("id1", payload)
+	if entry.Message != "P001の売上は？" {
+		t.Errorf("Message = %q, expected raw message", entry.Message)
+	}
+	if !entry.CreatedAt.Equal(createdAt) {
+		t.Errorf("CreatedAt = %v, expected %v", entry.CreatedAt, createdAt)
+	}
+
+	// 生のメッセージがない古い履歴はテキストとタイムスタンプから復元する
+	legacy := chatHistoryEntryFromPayload("id2", map[string]*qdrant.Value{
+		"text":      {Kind: &qdrant.Value_StringValue{StringValue: "Role: user\nMessage: こんにちは"}},
+		"timestamp": {Kind: &qdrant.Value_StringValue{StringValue: "2025-07-01T10:00:00Z"}},
+	})
+	if legacy.Message == "" || legacy.CreatedAt.IsZero() {
+		t.Errorf("Expected legacy entry to be restored, got %+v", legacy)
+	}
+}
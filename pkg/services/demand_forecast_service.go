@@ -1,8 +1,10 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"hunt-chat-api/pkg/models"
+	"log"
 	"math"
 	"time"
 )
@@ -10,6 +12,7 @@ import (
 // DemandForecastService 需要予測サービス
 type DemandForecastService struct {
 	weatherService *WeatherService
+	eventKnowledge *EventKnowledgeService
 }
 
 // NewDemandForecastService 新しい需要予測サービスを作成
@@ -19,6 +22,11 @@ func NewDemandForecastService(weatherService *WeatherService) *DemandForecastSer
 	}
 }
 
+// SetEventKnowledge 予測に回帰要因として組み込むイベント知識ベースを設定
+func (dfs *DemandForecastService) SetEventKnowledge(eventKnowledge *EventKnowledgeService) {
+	dfs.eventKnowledge = eventKnowledge
+}
+
 // loadSalesData 模擬的な販売実績データを読み込む
 func (dfs *DemandForecastService) loadSalesData(days int) []models.SalesRecord {
 	// 本来はデータベースやCSVから読み込むが、ここでは模擬データを生成
@@ -112,13 +120,16 @@ func (dfs *DemandForecastService) DetectAnomalies(regionCode string, days int) (
 
 // DemandForecastRequest 需要予測リクエスト構造体
 type DemandForecastRequest struct {
-	RegionCode      string               `json:"region_code"`
-	ProductCategory string               `json:"product_category"`
-	ForecastDays    int                  `json:"forecast_days"`
-	HistoricalDays  int                  `json:"historical_days"`
-	TacitKnowledge  []TacitKnowledgeItem `json:"tacit_knowledge"`
-	SeasonalFactors SeasonalFactors      `json:"seasonal_factors"`
-	ExternalFactors ExternalFactors      `json:"external_factors"`
+	RegionCode      string                  `json:"region_code"`
+	SiteID          string                  `json:"site_id"` // 指定した場合は地域コードの代わりに店舗・拠点の気象データを使う
+	ProductCategory string                  `json:"product_category"`
+	ForecastDays    int                     `json:"forecast_days"`
+	HistoricalDays  int                     `json:"historical_days"`
+	TacitKnowledge  []TacitKnowledgeItem    `json:"tacit_knowledge"`
+	SeasonalFactors SeasonalFactors         `json:"seasonal_factors"`
+	ExternalFactors ExternalFactors         `json:"external_factors"`
+	ProductID       string                  `json:"product_id"` // 指定した場合はその製品のイベントも反映
+	Events          []models.EventRegressor `json:"events"`     // 登録済みイベントに加えて反映する予定イベント
 }
 
 // TacitKnowledgeItem 暗黙知項目
@@ -149,6 +160,7 @@ type ExternalFactors struct {
 type DemandForecastResponse struct {
 	RegionCode      string               `json:"region_code"`
 	RegionName      string               `json:"region_name"`
+	SiteID          string               `json:"site_id,omitempty"`
 	ProductCategory string               `json:"product_category"`
 	ForecastPeriod  string               `json:"forecast_period"`
 	Forecasts       []DemandForecastItem `json:"forecasts"`
@@ -160,15 +172,17 @@ type DemandForecastResponse struct {
 
 // DemandForecastItem 需要予測項目
 type DemandForecastItem struct {
-	Date            string              `json:"date"`
-	PredictedDemand float64             `json:"predicted_demand"`
-	ConfidenceLevel float64             `json:"confidence_level"`
-	WeatherImpact   float64             `json:"weather_impact"`
-	SeasonalImpact  float64             `json:"seasonal_impact"`
-	TacitImpact     float64             `json:"tacit_impact"`
-	ExternalImpact  float64             `json:"external_impact"`
-	WeatherData     DailyWeatherSummary `json:"weather_data"`
-	Factors         []InfluencingFactor `json:"factors"`
+	Date               string                     `json:"date"`
+	PredictedDemand    float64                    `json:"predicted_demand"`
+	ConfidenceLevel    float64                    `json:"confidence_level"`
+	WeatherImpact      float64                    `json:"weather_impact"`
+	SeasonalImpact     float64                    `json:"seasonal_impact"`
+	TacitImpact        float64                    `json:"tacit_impact"`
+	ExternalImpact     float64                    `json:"external_impact"`
+	EventImpact        float64                    `json:"event_impact"`
+	EventContributions []models.EventContribution `json:"event_contributions,omitempty"`
+	WeatherData        DailyWeatherSummary        `json:"weather_data"`
+	Factors            []InfluencingFactor        `json:"factors"`
 }
 
 // DemandStatistics 需要統計
@@ -193,10 +207,12 @@ type ConfidenceMetrics struct {
 
 // ExplanationItem 説明項目
 type ExplanationItem struct {
-	Factor      string  `json:"factor"`
-	Impact      float64 `json:"impact"`
-	Description string  `json:"description"`
-	Confidence  float64 `json:"confidence"`
+	Factor       string  `json:"factor"`
+	Impact       float64 `json:"impact"`
+	Description  string  `json:"description"`
+	Confidence   float64 `json:"confidence"`
+	EventID      string  `json:"event_id,omitempty"`     // イベントの説明の場合のイベントID
+	Contribution float64 `json:"contribution,omitempty"` // イベントによる期間中の需要の増減
 }
 
 // InfluencingFactor 影響要因
@@ -208,32 +224,43 @@ type InfluencingFactor struct {
 
 // PredictDemand 需要予測を実行
 func (dfs *DemandForecastService) PredictDemand(request DemandForecastRequest) (*DemandForecastResponse, error) {
+	locationKey, err := dfs.weatherService.ResolveLocationKey(request.SiteID, request.RegionCode)
+	if err != nil {
+		return nil, err
+	}
+	location := dfs.weatherService.LocationFor(locationKey)
+
 	// 1. 過去の気象データを取得
-	historicalData, err := dfs.weatherService.GetHistoricalWeatherDataByRange(request.RegionCode, request.HistoricalDays)
+	historicalData, err := dfs.weatherService.GetHistoricalWeatherDataByRange(locationKey, request.HistoricalDays)
 	if err != nil {
 		return nil, fmt.Errorf("過去データ取得エラー: %w", err)
 	}
 
 	// 2. 予報データを取得
-	forecastData, err := dfs.weatherService.GetForecastData(request.RegionCode)
+	dailyForecasts, err := dfs.weatherService.GetDailyForecasts(locationKey)
 	if err != nil {
 		return nil, fmt.Errorf("予報データ取得エラー: %w", err)
 	}
 
-	// 3. 需要予測を計算
-	forecasts, err := dfs.calculateDemandForecasts(request, historicalData, forecastData)
+	// 3. 予測期間のイベントを取得
+	events := dfs.collectEventRegressors(request)
+
+	// 4. 需要予測を計算
+	forecasts, err := dfs.calculateDemandForecasts(request, historicalData, dailyForecasts, events)
 	if err != nil {
 		return nil, fmt.Errorf("需要予測計算エラー: %w", err)
 	}
 
-	// 4. 統計とメトリクスを計算
+	// 5. 統計とメトリクスを計算
 	statistics := dfs.calculateStatistics(forecasts)
 	confidence := dfs.calculateConfidence(request, historicalData, forecasts)
 	explanations := dfs.generateExplanations(request, forecasts)
+	explanations = append(explanations, dfs.generateEventExplanations(events, forecasts)...)
 
 	response := &DemandForecastResponse{
-		RegionCode:      request.RegionCode,
-		RegionName:      dfs.weatherService.getRegionName(request.RegionCode),
+		RegionCode:      location.RegionCode,
+		RegionName:      location.RegionName,
+		SiteID:          location.SiteID,
 		ProductCategory: request.ProductCategory,
 		ForecastPeriod:  fmt.Sprintf("%d日間", request.ForecastDays),
 		Forecasts:       forecasts,
@@ -246,23 +273,55 @@ func (dfs *DemandForecastService) PredictDemand(request DemandForecastRequest) (
 	return response, nil
 }
 
+// collectEventRegressors リクエストの予定イベントと、予測期間に重なる登録済みイベントをまとめる
+func (dfs *DemandForecastService) collectEventRegressors(request DemandForecastRequest) []models.EventRegressor {
+	events := request.Events
+	if dfs.eventKnowledge != nil && request.ForecastDays > 0 {
+		from := time.Now().AddDate(0, 0, 1).Format("2006-01-02")
+		to := time.Now().AddDate(0, 0, request.ForecastDays).Format("2006-01-02")
+		registered, err := dfs.eventKnowledge.Regressors(context.Background(), request.ProductID, from, to)
+		if err != nil {
+			log.Printf("⚠️ 予測に使うイベントの取得に失敗: %v", err)
+		}
+		events = append(events, registered...)
+	}
+	return EventRegressorsForProduct(events, request.ProductID)
+}
+
 // calculateDemandForecasts 需要予測を計算
 func (dfs *DemandForecastService) calculateDemandForecasts(
 	request DemandForecastRequest,
 	historicalData []HistoricalWeatherData,
-	forecastData []JMAForecastData,
+	dailyForecasts []DailyForecast,
+	events []models.EventRegressor,
 ) ([]DemandForecastItem, error) {
 	var forecasts []DemandForecastItem
 
 	// 基準需要を計算（過去データから推定）
 	baseDemand := dfs.calculateBaseDemand(request.ProductCategory, historicalData)
 
+	// 予報を日別に変換（地域の最初の予報区を代表とする）
+	forecastByDate := make(map[string]DailyForecast)
+	for _, daily := range dailyForecasts {
+		if daily.AreaCode != dailyForecasts[0].AreaCode {
+			continue
+		}
+		forecastByDate[daily.Date] = daily
+	}
+	if len(forecastByDate) == 0 {
+		log.Printf("⚠️ 予報データがないため、気象影響は推定値で計算します")
+	}
+
 	// 予測日数分のデータを生成
 	for i := 0; i < request.ForecastDays; i++ {
 		forecastDate := time.Now().AddDate(0, 0, i+1)
+		var dailyForecast *DailyForecast
+		if daily, ok := forecastByDate[forecastDate.Format("2006-01-02")]; ok {
+			dailyForecast = &daily
+		}
 
 		// 気象影響を計算
-		weatherImpact := dfs.calculateWeatherImpact(request.ProductCategory, forecastDate, forecastData)
+		weatherImpact := dfs.calculateWeatherImpact(request.ProductCategory, forecastDate, dailyForecast)
 
 		// 季節影響を計算
 		seasonalImpact := dfs.calculateSeasonalImpact(request.ProductCategory, forecastDate, request.SeasonalFactors)
@@ -273,18 +332,35 @@ func (dfs *DemandForecastService) calculateDemandForecasts(
 		// 外部要因影響を計算
 		externalImpact := dfs.calculateExternalFactorImpact(request.ExternalFactors, forecastDate)
 
+		// イベント影響を計算（イベントごとの寄与は基準需要 × 変化率）
+		eventContributions, eventTotal := EventContributionsOn(forecastDate.Format("2006-01-02"), baseDemand, events)
+		eventImpact := eventTotal / baseDemand
+
 		// 総合需要を計算
-		totalDemand := baseDemand * (1 + weatherImpact + seasonalImpact + tacitImpact + externalImpact)
+		totalDemand := baseDemand * (1 + weatherImpact + seasonalImpact + tacitImpact + externalImpact + eventImpact)
 
 		// 信頼度を計算
 		confidence := dfs.calculateItemConfidence(weatherImpact, seasonalImpact, tacitImpact, externalImpact)
 
-		// 気象データを取得（簡略化）
+		// 気象データを取得（予報の範囲外の日は簡略化した値）
 		weatherData := DailyWeatherSummary{
 			Date:    forecastDate.Format("2006-01-02"),
 			AvgTemp: 25.0 + float64(i)*0.5, // 簡略化
 			Weather: "晴れ",
 		}
+		if dailyForecast != nil {
+			if avgTemp, ok := dailyForecast.AverageTemp(); ok {
+				weatherData.AvgTemp = avgTemp
+			}
+			if dailyForecast.MaxTemp != nil {
+				weatherData.MaxTemp = *dailyForecast.MaxTemp
+			}
+			if dailyForecast.MinTemp != nil {
+				weatherData.MinTemp = *dailyForecast.MinTemp
+			}
+			weatherData.Weather = dailyForecast.Weather
+			weatherData.WeatherCode = dailyForecast.WeatherCode
+		}
 
 		// 影響要因を構築
 		factors := []InfluencingFactor{
@@ -293,17 +369,22 @@ func (dfs *DemandForecastService) calculateDemandForecasts(
 			{Name: "暗黙知", Impact: tacitImpact, Weight: 0.25},
 			{Name: "外部要因", Impact: externalImpact, Weight: 0.2},
 		}
+		if len(eventContributions) > 0 {
+			factors = append(factors, InfluencingFactor{Name: "イベント", Impact: eventImpact, Weight: 0.2})
+		}
 
 		forecast := DemandForecastItem{
-			Date:            forecastDate.Format("2006-01-02"),
-			PredictedDemand: totalDemand,
-			ConfidenceLevel: confidence,
-			WeatherImpact:   weatherImpact,
-			SeasonalImpact:  seasonalImpact,
-			TacitImpact:     tacitImpact,
-			ExternalImpact:  externalImpact,
-			WeatherData:     weatherData,
-			Factors:         factors,
+			Date:               forecastDate.Format("2006-01-02"),
+			PredictedDemand:    totalDemand,
+			ConfidenceLevel:    confidence,
+			WeatherImpact:      weatherImpact,
+			SeasonalImpact:     seasonalImpact,
+			TacitImpact:        tacitImpact,
+			ExternalImpact:     externalImpact,
+			EventImpact:        eventImpact,
+			EventContributions: eventContributions,
+			WeatherData:        weatherData,
+			Factors:            factors,
 		}
 
 		forecasts = append(forecasts, forecast)
@@ -333,8 +414,8 @@ func (dfs *DemandForecastService) calculateBaseDemand(productCategory string, hi
 	return baseDemand
 }
 
-// calculateWeatherImpact 気象影響を計算
-func (dfs *DemandForecastService) calculateWeatherImpact(productCategory string, date time.Time, forecastData []JMAForecastData) float64 {
+// calculateWeatherImpact 気象影響を計算（予報がない日は気温・天気を推定値で代用）
+func (dfs *DemandForecastService) calculateWeatherImpact(productCategory string, date time.Time, forecast *DailyForecast) float64 {
 	// 製品カテゴリごとの気象影響係数（簡略化）
 	weatherImpacts := map[string]map[string]float64{
 		"飲料": {
@@ -363,8 +444,13 @@ func (dfs *DemandForecastService) calculateWeatherImpact(productCategory string,
 	// 簡略化した気象影響計算
 	impact := 0.0
 
-	// 気温影響（仮想的な気温データ）
+	// 気温影響（予報がなければ仮想的な気温データ）
 	temperature := 25.0 + float64(date.Day()%10) // 簡略化
+	if forecast != nil {
+		if avgTemp, ok := forecast.AverageTemp(); ok {
+			temperature = avgTemp
+		}
+	}
 	if temperature > 30.0 {
 		if tempImpact, exists := categoryImpacts["temperature_high"]; exists {
 			impact += tempImpact
@@ -375,9 +461,23 @@ func (dfs *DemandForecastService) calculateWeatherImpact(productCategory string,
 		}
 	}
 
-	// 天気影響（簡略化）
-	if sunnyImpact, exists := categoryImpacts["sunny"]; exists {
-		impact += sunnyImpact * 0.7 // 晴天確率を70%と仮定
+	// 天気影響（予報がなければ晴天確率を70%と仮定）
+	if forecast == nil {
+		if sunnyImpact, exists := categoryImpacts["sunny"]; exists {
+			impact += sunnyImpact * 0.7
+		}
+		return impact
+	}
+	switch forecast.Category {
+	case WeatherCategorySunny:
+		impact += categoryImpacts["sunny"]
+	case WeatherCategoryRainy, WeatherCategorySnowy:
+		// 降水確率で重み付け（発表がなければ降るものとする）
+		probability := 1.0
+		if forecast.PrecipitationProbability != nil {
+			probability = float64(*forecast.PrecipitationProbability) / 100
+		}
+		impact += categoryImpacts["rainy"] * probability
 	}
 
 	return impact
@@ -618,3 +718,41 @@ func (dfs *DemandForecastService) generateExplanations(request DemandForecastReq
 
 	return explanations
 }
+
+// generateEventExplanations イベントごとに、期間中の需要への寄与を説明する
+func (dfs *DemandForecastService) generateEventExplanations(events []models.EventRegressor, forecasts []DemandForecastItem) []ExplanationItem {
+	var explanations []ExplanationItem
+	for _, event := range events {
+		days := 0
+		contribution := 0.0
+		for _, forecast := range forecasts {
+			for _, ec := range forecast.EventContributions {
+				if ec.EventID == event.EventID && ec.Title == event.Title {
+					days++
+					contribution += ec.Contribution
+				}
+			}
+		}
+		if days == 0 {
+			continue
+		}
+
+		confidence := event.Confidence
+		if event.UpliftSource != "learned" {
+			confidence = 0.8
+		}
+		description := fmt.Sprintf("「%s」（%s）により、%d日間で需要が%+.1f%%（計%+.0f）変化する見込みです", event.Title, EventTypeLabel(event.EventType), days, event.UpliftPercent, contribution)
+		if event.UpliftSource == "learned" {
+			description += "（過去の同種イベントから推定）"
+		}
+		explanations = append(explanations, ExplanationItem{
+			Factor:       "イベント: " + event.Title,
+			Impact:       event.UpliftPercent / 100,
+			Description:  description,
+			Confidence:   confidence,
+			EventID:      event.EventID,
+			Contribution: roundTo(contribution, 2),
+		})
+	}
+	return explanations
+}
@@ -0,0 +1,570 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// maxDemandScenarios 1回のシミュレーションで比較できるシナリオ数の上限
+const maxDemandScenarios = 10
+
+// ErrInvalidScenario シナリオの指定が不正
+var ErrInvalidScenario = errors.New("シナリオの指定が不正です")
+
+// WeatherScenario 予測期間の天候の仮定（予報に対する変更）
+// analog を指定した場合は過去の観測値で予報を置き換え、そのうえで気温・降水確率の変更を加える
+type WeatherScenario struct {
+	Name               string  `json:"name"`
+	TemperatureDelta   float64 `json:"temperature_delta"`               // 最高・最低気温に加える値（℃）
+	PrecipitationDelta int     `json:"precipitation_probability_delta"` // 降水確率に加える値（ポイント）
+	Analog             string  `json:"analog,omitempty"`                // 過去の天候: "2023-08"（同じ日の観測値）または "2023-08-01:2023-08-14"（先頭から順に）
+}
+
+// DemandScenarioRequest 基準の需要予測の設定と、比較するシナリオ
+type DemandScenarioRequest struct {
+	DemandForecastRequest
+	Scenarios []WeatherScenario `json:"scenarios"`
+	// 気温回帰（PredictFutureSales と同じモデル）でも比較する場合の過去の売上と気温（同じ長さ、10件以上）
+	HistoricalSales        []float64 `json:"historical_sales,omitempty"`
+	HistoricalTemperatures []float64 `json:"historical_temperatures,omitempty"`
+}
+
+// ScenarioWeatherDay シナリオでの1日の天候
+type ScenarioWeatherDay struct {
+	AvgTemp                  float64 `json:"avg_temp"`
+	MaxTemp                  float64 `json:"max_temp"`
+	MinTemp                  float64 `json:"min_temp"`
+	PrecipitationProbability *int    `json:"precipitation_probability,omitempty"`
+	Weather                  string  `json:"weather"`
+	Source                   string  `json:"source"` // forecast（予報） / climatology（予報のない日の平年値） / analog:YYYY-MM-DD（過去の観測値） / estimate（推定値）
+}
+
+// ScenarioDay シナリオでの1日の需要と、基準との差
+type ScenarioDay struct {
+	Date                  string             `json:"date"`
+	Weather               ScenarioWeatherDay `json:"weather"`
+	Demand                float64            `json:"demand"`
+	DemandDelta           float64            `json:"demand_delta"`         // 基準との差
+	DemandDeltaPercent    float64            `json:"demand_delta_percent"` // 基準との差（%）
+	WeatherImpact         float64            `json:"weather_impact"`
+	TemperatureModel      *float64           `json:"temperature_model,omitempty"`       // 気温回帰での予測
+	TemperatureModelDelta *float64           `json:"temperature_model_delta,omitempty"` // 気温回帰での基準との差
+}
+
+// ScenarioResult シナリオ1件の予測結果
+type ScenarioResult struct {
+	Name                  string        `json:"name"`
+	Description           string        `json:"description"`
+	TotalDemand           float64       `json:"total_demand"`
+	TotalDelta            float64       `json:"total_delta"`
+	TotalDeltaPercent     float64       `json:"total_delta_percent"`
+	PeakDay               string        `json:"peak_day,omitempty"`
+	TemperatureModelTotal *float64      `json:"temperature_model_total,omitempty"`
+	TemperatureModelDelta *float64      `json:"temperature_model_delta,omitempty"`
+	Days                  []ScenarioDay `json:"days"`
+}
+
+// DemandScenarioResponse 基準とシナリオの比較
+type DemandScenarioResponse struct {
+	RegionCode       string           `json:"region_code"`
+	RegionName       string           `json:"region_name"`
+	SiteID           string           `json:"site_id,omitempty"`
+	ProductCategory  string           `json:"product_category"`
+	ForecastPeriod   string           `json:"forecast_period"`
+	Forecasters      []string         `json:"forecasters"`                 // 比較に使った予測モデル
+	TemperatureModel string           `json:"temperature_model,omitempty"` // 気温回帰の式
+	Base             ScenarioResult   `json:"base"`
+	Scenarios        []ScenarioResult `json:"scenarios"`
+	Notes            []string         `json:"notes,omitempty"`
+	GeneratedAt      string           `json:"generated_at"`
+}
+
+// SimulateScenarios 基準（予報どおり）の需要予測と、天候のシナリオごとの需要予測を同じ条件で計算して比較する
+// 予報のない日は平年値を基準の天候とし、気温回帰の過去データがあれば日平均気温による回帰予測も並べる
+func (dfs *DemandForecastService) SimulateScenarios(request DemandScenarioRequest) (*DemandScenarioResponse, error) {
+	if len(request.Scenarios) == 0 {
+		return nil, fmt.Errorf("%w: シナリオを1件以上指定してください", ErrInvalidScenario)
+	}
+	if len(request.Scenarios) > maxDemandScenarios {
+		return nil, fmt.Errorf("%w: シナリオは%d件までです", ErrInvalidScenario, maxDemandScenarios)
+	}
+	for i, scenario := range request.Scenarios {
+		if strings.TrimSpace(scenario.Name) == "" {
+			return nil, fmt.Errorf("%w: %d件目のシナリオに name を指定してください", ErrInvalidScenario, i+1)
+		}
+		if scenario.PrecipitationDelta < -100 || scenario.PrecipitationDelta > 100 {
+			return nil, fmt.Errorf("%w: シナリオ「%s」の precipitation_probability_delta は-100〜100で指定してください", ErrInvalidScenario, scenario.Name)
+		}
+	}
+
+	locationKey, err := dfs.weatherService.ResolveLocationKey(request.SiteID, request.RegionCode)
+	if err != nil {
+		return nil, err
+	}
+	location := dfs.weatherService.LocationFor(locationKey)
+
+	historicalData, err := dfs.weatherService.GetHistoricalWeatherDataByRange(locationKey, request.HistoricalDays)
+	if err != nil {
+		return nil, fmt.Errorf("過去データ取得エラー: %w", err)
+	}
+
+	var notes []string
+	var temperatureModel *temperatureRegressionModel
+	if len(request.HistoricalSales) > 0 || len(request.HistoricalTemperatures) > 0 {
+		temperatureModel, err = fitTemperatureRegressionModel(request.HistoricalSales, request.HistoricalTemperatures)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 気温回帰の学習エラー: %v", ErrInvalidScenario, err)
+		}
+	}
+
+	dates := make([]string, request.ForecastDays)
+	for i := range dates {
+		dates[i] = time.Now().AddDate(0, 0, i+1).Format("2006-01-02")
+	}
+	base, baseNotes := dfs.baseScenarioWeather(locationKey, dates)
+	notes = append(notes, baseNotes...)
+	events := dfs.collectEventRegressors(request.DemandForecastRequest)
+
+	run := func(name, description string, weather []DailyForecast) (ScenarioResult, error) {
+		forecasts, err := dfs.calculateDemandForecasts(request.DemandForecastRequest, historicalData, weather, events)
+		if err != nil {
+			return ScenarioResult{}, err
+		}
+		return buildScenarioResult(name, description, forecasts, weather, temperatureModel), nil
+	}
+
+	baseResult, err := run("基準", "予報どおりの天候（予報のない日は平年値）", base)
+	if err != nil {
+		return nil, fmt.Errorf("需要予測計算エラー: %w", err)
+	}
+	response := &DemandScenarioResponse{
+		RegionCode:      location.RegionCode,
+		RegionName:      location.RegionName,
+		SiteID:          location.SiteID,
+		ProductCategory: request.ProductCategory,
+		ForecastPeriod:  fmt.Sprintf("%d日間", request.ForecastDays),
+		Forecasters:     []string{"demand"},
+		Base:            baseResult,
+		GeneratedAt:     time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if temperatureModel != nil {
+		response.Forecasters = append(response.Forecasters, "temperature_regression")
+		response.TemperatureModel = temperatureModel.equation
+	}
+
+	for _, scenario := range request.Scenarios {
+		weather, scenarioNotes, err := dfs.applyWeatherScenario(locationKey, dates, base, scenario)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, scenarioNotes...)
+		result, err := run(scenario.Name, describeWeatherScenario(scenario), weather)
+		if err != nil {
+			return nil, fmt.Errorf("需要予測計算エラー（%s）: %w", scenario.Name, err)
+		}
+		compareScenario(&result, baseResult)
+		response.Scenarios = append(response.Scenarios, result)
+	}
+	response.Notes = notes
+	return response, nil
+}
+
+// baseScenarioWeather 予測期間の基準の天候（予報の最初の予報区、予報のない日は平年値）
+// 平年値も求められない日は含めず、需要予測は推定値で計算する
+func (dfs *DemandForecastService) baseScenarioWeather(locationKey string, dates []string) ([]DailyForecast, []string) {
+	var notes []string
+	byDate := make(map[string]DailyForecast)
+	areaCode := ""
+	forecasts, err := dfs.weatherService.GetDailyForecasts(locationKey)
+	if err != nil {
+		notes = append(notes, "予報を取得できないため、基準の天候は平年値を使いました: "+err.Error())
+	}
+	for _, forecast := range forecasts {
+		if areaCode == "" {
+			areaCode = forecast.AreaCode
+		}
+		if forecast.AreaCode == areaCode {
+			byDate[forecast.Date] = forecast
+		}
+	}
+
+	var missing []string
+	for _, date := range dates {
+		if _, ok := byDate[date]; !ok {
+			missing = append(missing, date)
+		}
+	}
+	if len(missing) > 0 {
+		first, _ := time.Parse("2006-01-02", missing[0])
+		last, _ := time.Parse("2006-01-02", missing[len(missing)-1])
+		climatology, err := dfs.weatherService.GetClimatology(locationKey, first, last, ClimatologyBaselineYears)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("予報のない%d日は平年値も求められないため、推定値で計算しました: %v", len(missing), err))
+		} else {
+			filled := 0
+			for _, day := range climatology {
+				if _, ok := byDate[day.Date]; ok {
+					continue
+				}
+				if forecast, ok := climatologyForecast(day.Date, areaCode, day.Fields); ok {
+					byDate[day.Date] = forecast
+					filled++
+				}
+			}
+			notes = append(notes, fmt.Sprintf("予報のない%d日のうち%d日は平年値を基準の天候にしました", len(missing), filled))
+		}
+	}
+
+	var result []DailyForecast
+	for _, date := range dates {
+		if forecast, ok := byDate[date]; ok {
+			result = append(result, copyDailyForecast(forecast))
+		}
+	}
+	return result, notes
+}
+
+// climatologyForecast 平年値（中央値）を予報と同じ形にする（降水確率は発表がないものとする）
+func climatologyForecast(date, areaCode string, fields map[string]models.WeatherClimatologyStats) (DailyForecast, bool) {
+	temperature, ok := fields["temperature"]
+	if !ok {
+		return DailyForecast{}, false
+	}
+	maxTemp, minTemp := temperature.P50, temperature.P50
+	if stats, ok := fields["max_temp"]; ok {
+		maxTemp = stats.P50
+	}
+	if stats, ok := fields["min_temp"]; ok {
+		minTemp = stats.P50
+	}
+	return DailyForecast{
+		Date:     date,
+		AreaCode: areaCode,
+		Weather:  "平年並み",
+		Category: WeatherCategoryCloudy,
+		MaxTemp:  &maxTemp,
+		MinTemp:  &minTemp,
+		Source:   "climatology",
+	}, true
+}
+
+// applyWeatherScenario 基準の天候にシナリオを適用する（基準は変更しない）
+// 過去の天候を使う場合は、基準の天候がない日（予報も平年値もない日）にも観測値を当てはめる
+func (dfs *DemandForecastService) applyWeatherScenario(locationKey string, dates []string, base []DailyForecast, scenario WeatherScenario) ([]DailyForecast, []string, error) {
+	var notes []string
+	byDate := make(map[string]DailyForecast, len(base))
+	areaCode := ""
+	for _, forecast := range base {
+		byDate[forecast.Date] = copyDailyForecast(forecast)
+		areaCode = forecast.AreaCode
+	}
+
+	if scenario.Analog != "" {
+		analogDates, err := analogDatesFor(scenario.Analog, dates)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: シナリオ「%s」: %v", ErrInvalidScenario, scenario.Name, err)
+		}
+		first, last := analogDates[0], analogDates[0]
+		for _, date := range analogDates {
+			if date.Before(first) {
+				first = date
+			}
+			if date.After(last) {
+				last = date
+			}
+		}
+		observed, err := dfs.weatherService.GetHistoricalWeatherData(locationKey, first, last)
+		if err != nil {
+			return nil, nil, fmt.Errorf("シナリオ「%s」の過去の天候を取得できません: %w", scenario.Name, err)
+		}
+		observedByDate := make(map[string]HistoricalWeatherData, len(observed))
+		for _, d := range observed {
+			observedByDate[d.Date] = d
+		}
+		unmatched := 0
+		for i, date := range dates {
+			d, ok := observedByDate[analogDates[i].Format("2006-01-02")]
+			if !ok {
+				unmatched++
+				continue
+			}
+			forecast, exists := byDate[date]
+			if !exists {
+				forecast = DailyForecast{Date: date, AreaCode: areaCode}
+			}
+			byDate[date] = analogForecast(forecast, d)
+		}
+		if unmatched > 0 {
+			notes = append(notes, fmt.Sprintf("シナリオ「%s」: %s の観測値がない%d日は基準の天候のままです", scenario.Name, scenario.Analog, unmatched))
+		}
+	}
+
+	var weather []DailyForecast
+	for _, date := range dates {
+		forecast, ok := byDate[date]
+		if !ok {
+			continue
+		}
+		applyWeatherDelta(&forecast, scenario.TemperatureDelta, scenario.PrecipitationDelta)
+		weather = append(weather, forecast)
+	}
+	return weather, notes, nil
+}
+
+// analogDatesFor 予測期間の各日に対応する過去の日付
+// "YYYY-MM" はその月の同じ日（月末を超える日は月末）、"YYYY-MM-DD:YYYY-MM-DD" は期間の先頭から順に（足りなければ繰り返す）
+func analogDatesFor(analog string, targets []string) ([]time.Time, error) {
+	today := time.Now().In(jst).Format("2006-01-02")
+	dates := make([]time.Time, len(targets))
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("予測期間がありません")
+	}
+
+	if month, err := time.Parse("2006-01", analog); err == nil {
+		lastDay := month.AddDate(0, 1, -1)
+		if lastDay.Format("2006-01-02") >= today {
+			return nil, fmt.Errorf("analog には過去の月を指定してください: %s", analog)
+		}
+		for i, date := range targets {
+			target, err := time.Parse("2006-01-02", date)
+			if err != nil {
+				return nil, fmt.Errorf("予測日の日付が不正です: %s", date)
+			}
+			day := target.Day()
+			if day > lastDay.Day() {
+				day = lastDay.Day()
+			}
+			dates[i] = time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC)
+		}
+		return dates, nil
+	}
+
+	parts := strings.Split(analog, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("analog は YYYY-MM または YYYY-MM-DD:YYYY-MM-DD で指定してください: %s", analog)
+	}
+	start, errStart := time.Parse("2006-01-02", parts[0])
+	end, errEnd := time.Parse("2006-01-02", parts[1])
+	if errStart != nil || errEnd != nil || start.After(end) || end.Sub(start) > 365*24*time.Hour {
+		return nil, fmt.Errorf("analog は YYYY-MM または YYYY-MM-DD:YYYY-MM-DD（366日以内）で指定してください: %s", analog)
+	}
+	if parts[1] >= today {
+		return nil, fmt.Errorf("analog には過去の期間を指定してください: %s", analog)
+	}
+	length := int(end.Sub(start).Hours()/24) + 1
+	for i := range dates {
+		dates[i] = start.AddDate(0, 0, i%length)
+	}
+	return dates, nil
+}
+
+// analogForecast 過去の観測値で1日の予報を置き換える（雨の日は降水確率100%、それ以外は0%とする）
+func analogForecast(forecast DailyForecast, observed HistoricalWeatherData) DailyForecast {
+	maxTemp, minTemp := observed.Temperature, observed.Temperature
+	if reported(observed, "max_temp") {
+		maxTemp, minTemp = observed.MaxTemp, observed.MinTemp
+	}
+	probability := 0
+	category := WeatherCategoryCloudy
+	switch {
+	case strings.Contains(observed.Weather, "雪"):
+		category = WeatherCategorySnowy
+		probability = 100
+	case rainyConditions(observed.Precipitation, observed.Weather):
+		category = WeatherCategoryRainy
+		probability = 100
+	case strings.Contains(observed.Weather, "晴"):
+		category = WeatherCategorySunny
+	}
+	forecast.MaxTemp, forecast.MinTemp = &maxTemp, &minTemp
+	forecast.PrecipitationProbability = &probability
+	forecast.Category = category
+	forecast.Weather = observed.Weather
+	forecast.WeatherCode = ""
+	forecast.Source = "analog:" + observed.Date
+	return forecast
+}
+
+// applyWeatherDelta 気温と降水確率を変更し、降水確率が雨の日とみなす値をまたいだ場合は天気の分類も変える
+// 降水確率の発表がない日は0%に加える
+func applyWeatherDelta(forecast *DailyForecast, temperatureDelta float64, precipitationDelta int) {
+	if temperatureDelta != 0 {
+		if forecast.MaxTemp != nil {
+			maxTemp := roundTo(*forecast.MaxTemp+temperatureDelta, 1)
+			forecast.MaxTemp = &maxTemp
+		}
+		if forecast.MinTemp != nil {
+			minTemp := roundTo(*forecast.MinTemp+temperatureDelta, 1)
+			forecast.MinTemp = &minTemp
+		}
+	}
+	if precipitationDelta == 0 {
+		return
+	}
+	probability := precipitationDelta
+	if forecast.PrecipitationProbability != nil {
+		probability += *forecast.PrecipitationProbability
+	}
+	probability = int(math.Max(0, math.Min(100, float64(probability))))
+	forecast.PrecipitationProbability = &probability
+
+	switch {
+	case probability >= rainyForecastPercent && forecast.Category != WeatherCategoryRainy && forecast.Category != WeatherCategorySnowy:
+		forecast.Category = WeatherCategoryRainy
+		forecast.Weather = WeatherCategoryRainy.Label()
+	case probability < rainyForecastPercent && forecast.Category == WeatherCategoryRainy:
+		forecast.Category = WeatherCategoryCloudy
+		forecast.Weather = WeatherCategoryCloudy.Label()
+	}
+}
+
+// copyDailyForecast ポインタの値も複製した予報（シナリオの変更が基準に影響しないように）
+func copyDailyForecast(forecast DailyForecast) DailyForecast {
+	if forecast.MaxTemp != nil {
+		v := *forecast.MaxTemp
+		forecast.MaxTemp = &v
+	}
+	if forecast.MinTemp != nil {
+		v := *forecast.MinTemp
+		forecast.MinTemp = &v
+	}
+	if forecast.PrecipitationProbability != nil {
+		v := *forecast.PrecipitationProbability
+		forecast.PrecipitationProbability = &v
+	}
+	return forecast
+}
+
+// describeWeatherScenario シナリオの内容（例: 2023年8月の天候、気温+3.0℃）
+func describeWeatherScenario(scenario WeatherScenario) string {
+	var parts []string
+	if scenario.Analog != "" {
+		if month, err := time.Parse("2006-01", scenario.Analog); err == nil {
+			parts = append(parts, fmt.Sprintf("%d年%d月の天候", month.Year(), int(month.Month())))
+		} else {
+			parts = append(parts, strings.Replace(scenario.Analog, ":", "〜", 1)+"の天候")
+		}
+	}
+	if scenario.TemperatureDelta != 0 {
+		parts = append(parts, fmt.Sprintf("気温%+.1f℃", scenario.TemperatureDelta))
+	}
+	if scenario.PrecipitationDelta != 0 {
+		parts = append(parts, fmt.Sprintf("降水確率%+dポイント", scenario.PrecipitationDelta))
+	}
+	if len(parts) == 0 {
+		return "予報どおりの天候"
+	}
+	return strings.Join(parts, "、")
+}
+
+// temperatureRegressionModel 日平均気温による売上の単回帰（PredictFutureSales と同じモデル）
+type temperatureRegressionModel struct {
+	slope     float64
+	intercept float64
+	equation  string
+}
+
+func fitTemperatureRegressionModel(sales, temperatures []float64) (*temperatureRegressionModel, error) {
+	if len(sales) != len(temperatures) {
+		return nil, fmt.Errorf("データ系列の長さが一致しません")
+	}
+	if len(sales) < 10 {
+		return nil, fmt.Errorf("予測には最低10件のデータが必要です")
+	}
+	regression, err := (&StatisticsService{}).PerformLinearRegression(temperatures, sales)
+	if err != nil {
+		return nil, err
+	}
+	return &temperatureRegressionModel{
+		slope:     regression.Slope,
+		intercept: regression.Intercept,
+		equation:  fmt.Sprintf("y = %.2fx + %.2f", regression.Slope, regression.Intercept),
+	}, nil
+}
+
+// buildScenarioResult 需要予測の結果と天候をシナリオの結果にまとめる
+func buildScenarioResult(name, description string, forecasts []DemandForecastItem, weather []DailyForecast, model *temperatureRegressionModel) ScenarioResult {
+	byDate := make(map[string]DailyForecast, len(weather))
+	for _, forecast := range weather {
+		byDate[forecast.Date] = forecast
+	}
+
+	result := ScenarioResult{Name: name, Description: description}
+	var modelTotal float64
+	peak := -1.0
+	for _, forecast := range forecasts {
+		day := ScenarioDay{
+			Date:          forecast.Date,
+			Demand:        roundTo(forecast.PredictedDemand, 1),
+			WeatherImpact: roundTo(forecast.WeatherImpact, 3),
+			Weather: ScenarioWeatherDay{
+				AvgTemp: forecast.WeatherData.AvgTemp,
+				MaxTemp: forecast.WeatherData.MaxTemp,
+				MinTemp: forecast.WeatherData.MinTemp,
+				Weather: forecast.WeatherData.Weather,
+				Source:  "estimate",
+			},
+		}
+		if daily, ok := byDate[forecast.Date]; ok {
+			day.Weather.PrecipitationProbability = daily.PrecipitationProbability
+			day.Weather.Source = daily.Source
+			if daily.Source == "detailed" || daily.Source == "weekly" {
+				day.Weather.Source = "forecast"
+			}
+		}
+		if model != nil {
+			value := roundTo(model.slope*day.Weather.AvgTemp+model.intercept, 1)
+			day.TemperatureModel = &value
+			modelTotal += value
+		}
+		result.TotalDemand += forecast.PredictedDemand
+		if forecast.PredictedDemand > peak {
+			peak = forecast.PredictedDemand
+			result.PeakDay = forecast.Date
+		}
+		result.Days = append(result.Days, day)
+	}
+	result.TotalDemand = roundTo(result.TotalDemand, 1)
+	if model != nil {
+		modelTotal = roundTo(modelTotal, 1)
+		result.TemperatureModelTotal = &modelTotal
+	}
+	return result
+}
+
+// compareScenario 基準との差（合計・日ごと）を記録する
+func compareScenario(result *ScenarioResult, base ScenarioResult) {
+	result.TotalDelta = roundTo(result.TotalDemand-base.TotalDemand, 1)
+	if base.TotalDemand != 0 {
+		result.TotalDeltaPercent = roundTo(result.TotalDelta/base.TotalDemand*100, 1)
+	}
+	if result.TemperatureModelTotal != nil && base.TemperatureModelTotal != nil {
+		delta := roundTo(*result.TemperatureModelTotal-*base.TemperatureModelTotal, 1)
+		result.TemperatureModelDelta = &delta
+	}
+
+	baseDays := make(map[string]ScenarioDay, len(base.Days))
+	for _, day := range base.Days {
+		baseDays[day.Date] = day
+	}
+	for i := range result.Days {
+		day := &result.Days[i]
+		baseDay, ok := baseDays[day.Date]
+		if !ok {
+			continue
+		}
+		day.DemandDelta = roundTo(day.Demand-baseDay.Demand, 1)
+		if baseDay.Demand != 0 {
+			day.DemandDeltaPercent = roundTo(day.DemandDelta/baseDay.Demand*100, 1)
+		}
+		if day.TemperatureModel != nil && baseDay.TemperatureModel != nil {
+			delta := roundTo(*day.TemperatureModel-*baseDay.TemperatureModel, 1)
+			day.TemperatureModelDelta = &delta
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// scenarioFixture 今後3日間は最高29℃・最低25℃の晴れ（降水確率10%）、直近と13か月前の月は観測値がある取得元
+func scenarioFixture() (*fakeWeatherProvider, string) {
+	provider := newFakeWeatherProvider()
+	now := time.Now()
+	for i := 1; i <= 3; i++ {
+		provider.forecasts = append(provider.forecasts, archivedForecast(now.Format(time.RFC3339), now.AddDate(0, 0, i).Format("2006-01-02"), 29, 25, 10, WeatherCategorySunny))
+	}
+	for i := 1; i <= 40; i++ {
+		date := now.AddDate(0, 0, -i).Format("2006-01-02")
+		provider.days[date] = HistoricalWeatherData{Date: date, Temperature: 24, Humidity: 60, Weather: "晴れ"}
+	}
+	analog := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -13, 0)
+	for d := analog; d.Month() == analog.Month(); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		provider.days[date] = HistoricalWeatherData{Date: date, Temperature: 33, MaxTemp: 36, MinTemp: 30, Humidity: 80, Precipitation: 20, Weather: "雨"}
+	}
+	return provider, analog.Format("2006-01")
+}
+
+func TestSimulateScenariosComparesAgainstBase(t *testing.T) {
+	provider, analog := scenarioFixture()
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+	service := NewDemandForecastService(ws)
+
+	var sales, temperatures []float64
+	for i := 0; i < 10; i++ {
+		temperatures = append(temperatures, 20+float64(i))
+		sales = append(sales, 10*(20+float64(i)))
+	}
+	request := DemandScenarioRequest{
+		DemandForecastRequest: DemandForecastRequest{RegionCode: "240000", ProductCategory: "飲料", ForecastDays: 3, HistoricalDays: 30},
+		Scenarios: []WeatherScenario{
+			{Name: "猛暑", TemperatureDelta: 5},
+			{Name: "雨がち", PrecipitationDelta: 60},
+			{Name: "過去の月", Analog: analog},
+		},
+		HistoricalSales:        sales,
+		HistoricalTemperatures: temperatures,
+	}
+	simulation, err := service.SimulateScenarios(request)
+	if err != nil {
+		t.Fatalf("SimulateScenarios failed: %v", err)
+	}
+	if len(simulation.Forecasters) != 2 || len(simulation.Scenarios) != 3 || len(simulation.Base.Days) != 3 {
+		t.Fatalf("Unexpected simulation: %+v", simulation)
+	}
+	// 基準: 晴れで気温の影響なし → 1000 ×（1 + 0.2）
+	if simulation.Base.TotalDemand != 3600 || simulation.Base.Days[0].Weather.Source != "forecast" || *simulation.Base.TemperatureModelTotal != 810 {
+		t.Errorf("Unexpected base: %+v", simulation.Base)
+	}
+
+	// 猛暑: 日平均32℃で高温の影響（+0.8）が加わり、気温回帰は1日50増える
+	hot := simulation.Scenarios[0]
+	if hot.TotalDelta != 2400 || hot.Days[0].DemandDelta != 800 || hot.Days[0].DemandDeltaPercent != 66.7 || *hot.TemperatureModelDelta != 150 {
+		t.Errorf("Unexpected hot scenario: %+v", hot)
+	}
+	if hot.Description != "気温+5.0℃" || hot.Days[0].Weather.MaxTemp != 34 {
+		t.Errorf("Unexpected hot scenario weather: %+v", hot.Days[0].Weather)
+	}
+
+	// 雨がち: 降水確率70%で雨に分類され、晴天の影響が雨の影響（-0.1 × 0.7）に変わる
+	rainy := simulation.Scenarios[1]
+	if rainy.Days[0].Demand != 930 || *rainy.Days[0].Weather.PrecipitationProbability != 70 || rainy.Days[0].Weather.Weather != "雨" {
+		t.Errorf("Unexpected rainy scenario: %+v", rainy.Days[0])
+	}
+	if *rainy.TemperatureModelDelta != 0 {
+		t.Errorf("Expected the temperature model to be unchanged by rain, got %v", *rainy.TemperatureModelDelta)
+	}
+
+	// 過去の月: 予測日と同じ日の観測値（日平均33℃の雨）を使う
+	analogDay := simulation.Scenarios[2].Days[0]
+	target, _ := time.Parse("2006-01-02", analogDay.Date)
+	month, _ := time.Parse("2006-01", analog)
+	day := target.Day()
+	if last := month.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	if expected := "analog:" + month.AddDate(0, 0, day-1).Format("2006-01-02"); analogDay.Weather.Source != expected {
+		t.Errorf("Analog source = %s, expected %s", analogDay.Weather.Source, expected)
+	}
+	if analogDay.Weather.AvgTemp != 33 || analogDay.Demand != 1700 {
+		t.Errorf("Unexpected analog day: %+v", analogDay)
+	}
+}
+
+func TestSimulateScenariosRejectsInvalidScenarios(t *testing.T) {
+	provider, _ := scenarioFixture()
+	service := NewDemandForecastService(NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}}))
+	base := DemandForecastRequest{RegionCode: "240000", ProductCategory: "飲料", ForecastDays: 3, HistoricalDays: 30}
+	nextMonth := time.Now().AddDate(0, 1, 0).Format("2006-01")
+
+	for _, scenarios := range [][]WeatherScenario{
+		nil,
+		{{TemperatureDelta: 3}},
+		{{Name: "未来", Analog: nextMonth}},
+		{{Name: "形式", Analog: "2023-08-01~2023-08-14"}},
+		{{Name: "降水", PrecipitationDelta: 150}},
+	} {
+		_, err := service.SimulateScenarios(DemandScenarioRequest{DemandForecastRequest: base, Scenarios: scenarios})
+		if !errors.Is(err, ErrInvalidScenario) {
+			t.Errorf("Expected ErrInvalidScenario for %+v, got %v", scenarios, err)
+		}
+	}
+}
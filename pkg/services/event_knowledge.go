@@ -0,0 +1,672 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+const eventEffectMaxExamples = 3
+
+// ErrBusinessEventNotFound 指定したIDのイベントが登録されていない
+var ErrBusinessEventNotFound = errors.New("イベントが見つかりません")
+
+// ErrInvalidBusinessEvent イベントの指定が不正
+var ErrInvalidBusinessEvent = errors.New("イベントの指定が不正です")
+
+// eventRegressorMinSamples 過去の効果から予定イベントの変化率を推定するのに必要なイベント数
+const eventRegressorMinSamples = 2
+
+// eventTypeKeywords タグ・回答文からイベントの種類を判定するキーワード（判定は定義順）
+var eventTypeKeywords = []struct {
+	eventType string
+	keywords  []string
+}{
+	{"campaign", []string{"campaign", "promotion", "キャンペーン", "販促", "セール", "割引", "値引", "特売", "チラシ", "広告", "ポイント"}},
+	{"competitor", []string{"competitor", "競合", "他社", "ライバル", "近隣店"}},
+	{"supply", []string{"supply", "stock", "欠品", "在庫", "供給", "入荷", "物流", "配送", "品切"}},
+	{"holiday", []string{"holiday", "祝日", "連休", "休日", "お盆", "年末", "正月", "ゴールデンウィーク", "GW", "三連休"}},
+	{"weather", []string{"weather", "天候", "天気", "気温", "猛暑", "暑さ", "寒波", "寒さ", "雨", "台風", "雪", "梅雨"}},
+	{"local_event", []string{"local_event", "event", "イベント", "祭", "花火", "コンサート", "試合", "行事", "学校"}},
+}
+
+// eventTypeLabels イベントの種類の表示名
+var eventTypeLabels = map[string]string{
+	"campaign":    "キャンペーン",
+	"weather":     "天候",
+	"competitor":  "競合の動き",
+	"supply":      "供給・在庫",
+	"holiday":     "祝日・連休",
+	"local_event": "地域イベント",
+}
+
+// EventTypeLabel イベントの種類の表示名（未知の種類はそのまま返す）
+func EventTypeLabel(eventType string) string {
+	if label, ok := eventTypeLabels[eventType]; ok {
+		return label
+	}
+	return eventType
+}
+
+// EventKnowledgeService 異常への回答を構造化したイベント知識ベースを管理し、イベントの種類ごとの効果を推定する
+// 効果の統計はストアから一度だけ読み込み、以降はイベントの追加・更新・削除に合わせて差分更新する
+type EventKnowledgeService struct {
+	vectorStoreService *VectorStoreService
+
+	mu     sync.Mutex
+	loaded bool
+	stats  map[string]*EventEffectStats
+}
+
+// NewEventKnowledgeService 新しいイベント知識ベースサービスを作成
+func NewEventKnowledgeService(vectorStoreService *VectorStoreService) *EventKnowledgeService {
+	return &EventKnowledgeService{
+		vectorStoreService: vectorStoreService,
+		stats:              make(map[string]*EventEffectStats),
+	}
+}
+
+// Create イベントを作成
+func (s *EventKnowledgeService) Create(ctx context.Context, req models.BusinessEventRequest) (*models.BusinessEvent, error) {
+	now := time.Now().Format(time.RFC3339)
+	event := models.BusinessEvent{
+		EventID:   uuid.New().String(),
+		Source:    "manual",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyBusinessEventRequest(&event, req); err != nil {
+		return nil, err
+	}
+	if err := s.save(ctx, nil, event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Update イベントを更新（元の出典・作成日時は保持）
+func (s *EventKnowledgeService) Update(ctx context.Context, eventID string, req models.BusinessEventRequest) (*models.BusinessEvent, error) {
+	previous, err := s.vectorStoreService.GetBusinessEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	event := *previous
+	if err := applyBusinessEventRequest(&event, req); err != nil {
+		return nil, err
+	}
+	event.UpdatedAt = time.Now().Format(time.RFC3339)
+	if err := s.save(ctx, previous, event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Delete イベントを削除
+func (s *EventKnowledgeService) Delete(ctx context.Context, eventID string) error {
+	previous, err := s.vectorStoreService.GetBusinessEvent(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	if err := s.vectorStoreService.DeleteBusinessEvent(ctx, eventID); err != nil {
+		return err
+	}
+	s.applyStats(previous, nil)
+	return nil
+}
+
+// IngestResponse 単発の異常回答からイベントを作成（種類を判定できない回答は nil）
+func (s *EventKnowledgeService) IngestResponse(ctx context.Context, response models.AnomalyResponse, anomaly *models.AnomalyDetection) (*models.BusinessEvent, error) {
+	event := EventFromAnomalyResponse(response, anomaly)
+	if event == nil {
+		return nil, nil
+	}
+	return event, s.upsert(ctx, *event)
+}
+
+// IngestSession 完了した異常回答セッションからイベントを作成（種類を判定できないセッションは nil）
+func (s *EventKnowledgeService) IngestSession(ctx context.Context, session *models.AnomalyResponseSession, anomaly *models.AnomalyDetection) (*models.BusinessEvent, error) {
+	event := EventFromSession(session, anomaly)
+	if event == nil {
+		return nil, nil
+	}
+	return event, s.upsert(ctx, *event)
+}
+
+// IngestAll 保存済みの回答・完了済みセッションをすべてイベントに変換（IDは出典から決まるため再実行しても重複しない）
+func (s *EventKnowledgeService) IngestAll(ctx context.Context) (int, error) {
+	reports, err := s.vectorStoreService.GetAllAnalysisReports(ctx)
+	if err != nil {
+		log.Printf("⚠️ 分析レポートの取得に失敗したため、回答の影響値を効果として使用します: %v", err)
+	}
+	findAnomaly := func(date, productID string) *models.AnomalyDetection {
+		return FindReportAnomaly(reports, date, productID)
+	}
+
+	ingested := 0
+	responses, err := s.vectorStoreService.GetAllAnomalyResponses(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, response := range responses {
+		event, err := s.IngestResponse(ctx, response, findAnomaly(response.AnomalyDate, response.ProductID))
+		if err != nil {
+			return ingested, err
+		}
+		if event != nil {
+			ingested++
+		}
+	}
+
+	sessions, err := s.vectorStoreService.ListAnomalyResponseSessions(ctx)
+	if err != nil {
+		return ingested, err
+	}
+	for i := range sessions {
+		if !sessions[i].IsComplete {
+			continue
+		}
+		event, err := s.IngestSession(ctx, &sessions[i], findAnomaly(sessions[i].AnomalyDate, sessions[i].ProductID))
+		if err != nil {
+			return ingested, err
+		}
+		if event != nil {
+			ingested++
+		}
+	}
+
+	log.Printf("📚 %d件の回答をイベント知識ベースに取り込みました", ingested)
+	return ingested, nil
+}
+
+// Effects イベントの種類ごと（productIDを指定した場合はその製品）の効果推定を、件数の多い順に返す
+func (s *EventKnowledgeService) Effects(ctx context.Context, eventType, productID string, refresh bool) ([]models.EventEffectEstimate, error) {
+	if err := s.ensureStats(ctx, refresh); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	estimates := make([]models.EventEffectEstimate, 0)
+	for _, stats := range s.stats {
+		if stats.ProductID != productID || (eventType != "" && stats.EventType != eventType) || stats.Count == 0 {
+			continue
+		}
+		estimates = append(estimates, stats.Estimate())
+	}
+	sort.Slice(estimates, func(i, j int) bool {
+		if estimates[i].Count != estimates[j].Count {
+			return estimates[i].Count > estimates[j].Count
+		}
+		return estimates[i].EventType < estimates[j].EventType
+	})
+	return estimates, nil
+}
+
+// Regressors 期間に重なる登録済みイベントを、製品の予測に使う回帰要因に変換する
+// 変化率が記録されていないイベント（予定しているキャンペーンなど）は、過去の同種イベントの効果推定を使う
+func (s *EventKnowledgeService) Regressors(ctx context.Context, productID, from, to string) ([]models.EventRegressor, error) {
+	if s == nil || s.vectorStoreService == nil {
+		return nil, nil
+	}
+	events, err := s.vectorStoreService.ListBusinessEvents(ctx, models.BusinessEventFilter{From: from, To: to})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureStats(ctx, false); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var regressors []models.EventRegressor
+	for _, event := range events {
+		if len(event.ProductIDs) > 0 && !containsString(event.ProductIDs, productID) {
+			continue
+		}
+		if regressor, ok := EventRegressorFor(event, productID, s.stats); ok {
+			regressors = append(regressors, regressor)
+		}
+	}
+	return regressors, nil
+}
+
+// upsert 出典から決まるIDでイベントを保存（既存のイベントがあれば作成日時を引き継ぐ）
+func (s *EventKnowledgeService) upsert(ctx context.Context, event models.BusinessEvent) error {
+	previous, err := s.vectorStoreService.GetBusinessEvent(ctx, event.EventID)
+	if err == nil && previous != nil {
+		event.CreatedAt = previous.CreatedAt
+	} else {
+		previous = nil
+	}
+	return s.save(ctx, previous, event)
+}
+
+// save イベントを保存し、効果の統計を差分更新する
+func (s *EventKnowledgeService) save(ctx context.Context, previous *models.BusinessEvent, event models.BusinessEvent) error {
+	if err := s.vectorStoreService.SaveBusinessEvent(ctx, event); err != nil {
+		return err
+	}
+	s.applyStats(previous, &event)
+	return nil
+}
+
+// applyStats 読み込み済みの統計から古いイベントを取り除き、新しいイベントを加える（未読み込みなら次回の読み込みで反映される）
+func (s *EventKnowledgeService) applyStats(previous, current *models.BusinessEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded {
+		return
+	}
+	if previous != nil {
+		removeEventFromStats(s.stats, *previous)
+	}
+	if current != nil {
+		addEventToStats(s.stats, *current)
+	}
+}
+
+// ensureStats 効果の統計をストアから読み込む（refresh の場合は再計算）
+func (s *EventKnowledgeService) ensureStats(ctx context.Context, refresh bool) error {
+	s.mu.Lock()
+	loaded := s.loaded
+	s.mu.Unlock()
+	if loaded && !refresh {
+		return nil
+	}
+
+	events, err := s.vectorStoreService.ListBusinessEvents(ctx, models.BusinessEventFilter{})
+	if err != nil {
+		return err
+	}
+	stats := BuildEventEffectStats(events)
+
+	s.mu.Lock()
+	s.stats = stats
+	s.loaded = true
+	s.mu.Unlock()
+	return nil
+}
+
+// EventEffectStats イベントの種類（・製品）ごとの変化率の統計（Welford法で差分更新する）
+type EventEffectStats struct {
+	EventType   string
+	ProductID   string
+	Count       int
+	Mean        float64
+	M2          float64 // 偏差平方和
+	Examples    []eventExample
+	LastUpdated string
+}
+
+type eventExample struct {
+	EventID string
+	Label   string
+}
+
+// Add 変化率を1件加える
+func (st *EventEffectStats) Add(eventID, label string, x float64) {
+	st.Count++
+	delta := x - st.Mean
+	st.Mean += delta / float64(st.Count)
+	st.M2 += delta * (x - st.Mean)
+
+	st.Examples = append([]eventExample{{EventID: eventID, Label: label}}, st.Examples...)
+	if len(st.Examples) > eventEffectMaxExamples {
+		st.Examples = st.Examples[:eventEffectMaxExamples]
+	}
+	st.LastUpdated = time.Now().Format(time.RFC3339)
+}
+
+// Remove Add で加えた変化率を1件取り除く
+func (st *EventEffectStats) Remove(eventID string, x float64) {
+	if st.Count <= 1 {
+		*st = EventEffectStats{EventType: st.EventType, ProductID: st.ProductID, LastUpdated: time.Now().Format(time.RFC3339)}
+		return
+	}
+	meanWithout := (st.Mean*float64(st.Count) - x) / float64(st.Count-1)
+	st.M2 -= (x - st.Mean) * (x - meanWithout)
+	if st.M2 < 0 {
+		st.M2 = 0
+	}
+	st.Mean = meanWithout
+	st.Count--
+
+	examples := st.Examples[:0]
+	for _, example := range st.Examples {
+		if example.EventID != eventID {
+			examples = append(examples, example)
+		}
+	}
+	st.Examples = examples
+	st.LastUpdated = time.Now().Format(time.RFC3339)
+}
+
+// Estimate 平均変化率の信頼区間・効果量・p値を計算する
+func (st *EventEffectStats) Estimate() models.EventEffectEstimate {
+	estimate := models.EventEffectEstimate{
+		EventType:   st.EventType,
+		ProductID:   st.ProductID,
+		Count:       st.Count,
+		MeanUplift:  roundTo(st.Mean, 2),
+		CILower:     roundTo(st.Mean, 2),
+		CIUpper:     roundTo(st.Mean, 2),
+		PValue:      1,
+		LastUpdated: st.LastUpdated,
+	}
+	for _, example := range st.Examples {
+		estimate.Examples = append(estimate.Examples, example.Label)
+	}
+	if st.Count < 2 {
+		return estimate
+	}
+
+	n := float64(st.Count)
+	df := n - 1
+	sd := math.Sqrt(st.M2 / df)
+	estimate.StdDev = roundTo(sd, 2)
+	if sd == 0 {
+		estimate.PValue = 0
+		return estimate
+	}
+
+	se := sd / math.Sqrt(n)
+	tCrit := tCritical(0.975, df)
+	d := st.Mean / sd
+	estimate.StandardError = roundTo(se, 2)
+	estimate.CILower = roundTo(st.Mean-tCrit*se, 2)
+	estimate.CIUpper = roundTo(st.Mean+tCrit*se, 2)
+	estimate.CohensD = roundTo(d, 3)
+	if df > 1 {
+		estimate.HedgesG = roundTo(d*(1-3/(4*df-1)), 3)
+	}
+	estimate.PValue = roundTo(2*(1-studentTCDF(math.Abs(st.Mean/se), df)), 4)
+	return estimate
+}
+
+// EffectConfidence 推定の信頼度（0-1）。平均が0と異なる確からしさを、件数が少ないほど割り引く
+func EffectConfidence(estimate models.EventEffectEstimate) float64 {
+	if estimate.Count == 0 {
+		return 0
+	}
+	n := float64(estimate.Count)
+	return roundTo((1-math.Min(estimate.PValue, 1))*n/(n+2), 3)
+}
+
+// BuildEventEffectStats イベント一覧から効果の統計を作る（種類ごと、および種類×製品ごと）
+func BuildEventEffectStats(events []models.BusinessEvent) map[string]*EventEffectStats {
+	sorted := make([]models.BusinessEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].StartDate < sorted[j].StartDate })
+
+	stats := make(map[string]*EventEffectStats)
+	for _, event := range sorted {
+		addEventToStats(stats, event)
+	}
+	return stats
+}
+
+func addEventToStats(stats map[string]*EventEffectStats, event models.BusinessEvent) {
+	if event.UpliftPercent == nil {
+		return
+	}
+	label := fmt.Sprintf("%s %s", event.StartDate, event.Title)
+	for _, key := range eventStatsKeys(event) {
+		st, ok := stats[key.id]
+		if !ok {
+			st = &EventEffectStats{EventType: event.EventType, ProductID: key.productID}
+			stats[key.id] = st
+		}
+		st.Add(event.EventID, label, *event.UpliftPercent)
+	}
+}
+
+func removeEventFromStats(stats map[string]*EventEffectStats, event models.BusinessEvent) {
+	if event.UpliftPercent == nil {
+		return
+	}
+	for _, key := range eventStatsKeys(event) {
+		if st, ok := stats[key.id]; ok {
+			st.Remove(event.EventID, *event.UpliftPercent)
+		}
+	}
+}
+
+type eventStatsKey struct {
+	id        string
+	productID string
+}
+
+// eventStatsKeys イベントが寄与する統計のキー（種類全体と、対象製品ごと）
+func eventStatsKeys(event models.BusinessEvent) []eventStatsKey {
+	keys := []eventStatsKey{{id: event.EventType}}
+	for _, productID := range event.ProductIDs {
+		keys = append(keys, eventStatsKey{id: event.EventType + "|" + productID, productID: productID})
+	}
+	return keys
+}
+
+// EventRegressorFor イベントを回帰要因に変換する
+// 変化率が記録されていれば（実測・回答・手入力）それを使い、なければ同種イベントの平均効果を信頼度で0に向けて縮小して使う。
+// 製品別の推定が eventRegressorMinSamples 件に満たない場合は種類全体の推定を使い、どちらもなければ false を返す
+func EventRegressorFor(event models.BusinessEvent, productID string, stats map[string]*EventEffectStats) (models.EventRegressor, bool) {
+	regressor := models.EventRegressor{
+		EventID:    event.EventID,
+		EventType:  event.EventType,
+		Title:      event.Title,
+		StartDate:  event.StartDate,
+		EndDate:    event.EndDate,
+		ProductIDs: event.ProductIDs,
+	}
+	if event.UpliftPercent != nil {
+		regressor.UpliftPercent = *event.UpliftPercent
+		regressor.UpliftSource = event.UpliftSource
+		if regressor.UpliftSource == "" {
+			regressor.UpliftSource = "manual"
+		}
+		return regressor, true
+	}
+
+	keys := []string{event.EventType}
+	if productID != "" {
+		keys = []string{event.EventType + "|" + productID, event.EventType}
+	}
+	for _, key := range keys {
+		st, ok := stats[key]
+		if !ok || st.Count < eventRegressorMinSamples {
+			continue
+		}
+		estimate := st.Estimate()
+		confidence := EffectConfidence(estimate)
+		regressor.UpliftPercent = roundTo(estimate.MeanUplift*confidence, 2)
+		regressor.UpliftSource = "learned"
+		regressor.Confidence = confidence
+		return regressor, regressor.UpliftPercent != 0
+	}
+	return regressor, false
+}
+
+// tCritical t分布の上側分位点（二分法で studentTCDF(x) = p となるxを求める）
+func tCritical(p, df float64) float64 {
+	lo, hi := 0.0, 100.0
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		if studentTCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// ClassifyEventType タグ・回答文からイベントの種類を判定（判定できない場合は空文字）
+func ClassifyEventType(tags []string, text string) string {
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		for _, entry := range eventTypeKeywords {
+			for _, keyword := range entry.keywords {
+				if tag != "" && strings.Contains(tag, strings.ToLower(keyword)) {
+					return entry.eventType
+				}
+			}
+		}
+	}
+	lower := strings.ToLower(text)
+	for _, entry := range eventTypeKeywords {
+		for _, keyword := range entry.keywords {
+			if len(keyword) > 0 && !isASCII(keyword) && strings.Contains(lower, keyword) {
+				return entry.eventType
+			}
+		}
+	}
+	return ""
+}
+
+// EventFromAnomalyResponse 単発の回答からイベントを作る（効果は実績と予測の差、なければ回答の影響値）
+func EventFromAnomalyResponse(response models.AnomalyResponse, anomaly *models.AnomalyDetection) *models.BusinessEvent {
+	eventType := ClassifyEventType(response.Tags, response.Answer)
+	if eventType == "" || response.AnomalyDate == "" {
+		return nil
+	}
+	event := newDerivedEvent(eventType, "anomaly_response", response.ResponseID, response.AnomalyDate, response.ProductID, response.Answer, response.Tags)
+	setEventUplift(event, anomaly, response.ImpactValue)
+	return event
+}
+
+// EventFromSession 完了したセッションからイベントを作る（仮説検証型は結論の最有力原因を優先）
+func EventFromSession(session *models.AnomalyResponseSession, anomaly *models.AnomalyDetection) *models.BusinessEvent {
+	var answers []string
+	for _, conv := range session.Conversations {
+		if conv.Answer != "" {
+			answers = append(answers, conv.Answer)
+		}
+	}
+	description := strings.Join(answers, " / ")
+
+	eventType := ""
+	title := ""
+	if session.RootCause != nil && session.RootCause.PrimaryCause != "" {
+		title = session.RootCause.PrimaryCause
+		eventType = ClassifyEventType(nil, title)
+	}
+	if eventType == "" {
+		eventType = ClassifyEventType(session.FinalTags, description)
+	}
+	if eventType == "" || session.AnomalyDate == "" {
+		return nil
+	}
+
+	event := newDerivedEvent(eventType, "anomaly_session", session.SessionID, session.AnomalyDate, session.ProductID, description, session.FinalTags)
+	if title != "" {
+		event.Title = title
+	}
+	setEventUplift(event, anomaly, session.FinalImpactValue)
+	return event
+}
+
+// FindReportAnomaly 分析レポートから日付・製品IDが一致する異常を探す
+func FindReportAnomaly(reports []models.AnalysisReport, date, productID string) *models.AnomalyDetection {
+	for i := range reports {
+		for j := range reports[i].Anomalies {
+			anomaly := reports[i].Anomalies[j]
+			if anomaly.Date == date && anomaly.ProductID == productID {
+				return &anomaly
+			}
+		}
+	}
+	return nil
+}
+
+// BusinessEventIDFor 出典から決まるイベントID
+func BusinessEventIDFor(source, sourceID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("business_event:"+source+":"+sourceID)).String()
+}
+
+func newDerivedEvent(eventType, source, sourceID, date, productID, description string, tags []string) *models.BusinessEvent {
+	now := time.Now().Format(time.RFC3339)
+	event := &models.BusinessEvent{
+		EventID:     BusinessEventIDFor(source, sourceID),
+		EventType:   eventType,
+		Title:       truncateRunes(description, 40),
+		Description: description,
+		StartDate:   date,
+		EndDate:     date,
+		Source:      source,
+		SourceID:    sourceID,
+		Tags:        tags,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if productID != "" {
+		event.ProductIDs = []string{productID}
+	}
+	return event
+}
+
+func setEventUplift(event *models.BusinessEvent, anomaly *models.AnomalyDetection, reported float64) {
+	if anomaly != nil && anomaly.ExpectedValue != 0 {
+		uplift := roundTo((anomaly.ActualValue-anomaly.ExpectedValue)/anomaly.ExpectedValue*100, 2)
+		event.UpliftPercent = &uplift
+		event.UpliftSource = "measured"
+		return
+	}
+	if reported != 0 {
+		uplift := reported
+		event.UpliftPercent = &uplift
+		event.UpliftSource = "reported"
+	}
+}
+
+// applyBusinessEventRequest リクエストの内容を検証してイベントに反映
+func applyBusinessEventRequest(event *models.BusinessEvent, req models.BusinessEventRequest) error {
+	if !containsString(models.BusinessEventTypes, req.EventType) {
+		return fmt.Errorf("%w: event_typeは %s のいずれかを指定してください", ErrInvalidBusinessEvent, strings.Join(models.BusinessEventTypes, "/"))
+	}
+	if _, err := time.Parse("2006-01-02", req.StartDate); err != nil {
+		return fmt.Errorf("%w: start_dateはYYYY-MM-DD形式で指定してください", ErrInvalidBusinessEvent)
+	}
+	endDate := req.EndDate
+	if endDate == "" {
+		endDate = req.StartDate
+	}
+	if _, err := time.Parse("2006-01-02", endDate); err != nil {
+		return fmt.Errorf("%w: end_dateはYYYY-MM-DD形式で指定してください", ErrInvalidBusinessEvent)
+	}
+	if endDate < req.StartDate {
+		return fmt.Errorf("%w: end_dateはstart_date以降の日付を指定してください", ErrInvalidBusinessEvent)
+	}
+
+	event.EventType = req.EventType
+	event.Title = req.Title
+	event.Description = req.Description
+	event.StartDate = req.StartDate
+	event.EndDate = endDate
+	event.ProductIDs = req.ProductIDs
+	event.RegionCode = req.RegionCode
+	event.Tags = req.Tags
+	event.UpliftPercent = req.UpliftPercent
+	event.UpliftSource = ""
+	if req.UpliftPercent != nil {
+		event.UpliftSource = "manual"
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
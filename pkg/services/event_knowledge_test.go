@@ -0,0 +1,175 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestClassifyEventType(t *testing.T) {
+	cases := []struct {
+		tags     []string
+		text     string
+		expected string
+	}{
+		{[]string{"Promotion"}, "", "campaign"},
+		{nil, "近くで花火大会があった", "local_event"},
+		{nil, "台風で客足が落ちた", "weather"},
+		{nil, "他社が値下げした", "competitor"},
+		{[]string{"other"}, "特に思い当たらない", ""},
+		// 英字キーワードは回答文の部分一致に使わない（"event" が "prevent" などに誤反応しないように）
+		{nil, "prevent", ""},
+	}
+	for _, tc := range cases {
+		if got := ClassifyEventType(tc.tags, tc.text); got != tc.expected {
+			t.Errorf("ClassifyEventType(%v, %q) = %q, expected %q", tc.tags, tc.text, got, tc.expected)
+		}
+	}
+}
+
+func TestEventEffectStatsIncremental(t *testing.T) {
+	uplift := func(v float64) *float64 { return &v }
+	events := []models.BusinessEvent{
+		{EventID: "e1", EventType: "campaign", StartDate: "2024-01-01", ProductIDs: []string{"P001"}, UpliftPercent: uplift(20)},
+		{EventID: "e2", EventType: "campaign", StartDate: "2024-02-01", ProductIDs: []string{"P001"}, UpliftPercent: uplift(30)},
+		{EventID: "e3", EventType: "campaign", StartDate: "2024-03-01", ProductIDs: []string{"P002"}, UpliftPercent: uplift(10)},
+		{EventID: "e4", EventType: "campaign", StartDate: "2024-04-01"},
+	}
+
+	stats := BuildEventEffectStats(events)
+	all := stats["campaign"]
+	if all.Count != 3 || math.Abs(all.Mean-20) > 1e-9 || math.Abs(all.M2-200) > 1e-9 {
+		t.Fatalf("campaign stats = count %d, mean %f, M2 %f", all.Count, all.Mean, all.M2)
+	}
+	if p1 := stats["campaign|P001"]; p1 == nil || p1.Count != 2 || p1.ProductID != "P001" {
+		t.Errorf("campaign|P001 stats = %+v", p1)
+	}
+
+	// 差分で取り除いた結果は、最初から作り直した結果と一致する
+	removeEventFromStats(stats, events[1])
+	rebuilt := BuildEventEffectStats([]models.BusinessEvent{events[0], events[2]})["campaign"]
+	if all.Count != rebuilt.Count || math.Abs(all.Mean-rebuilt.Mean) > 1e-9 || math.Abs(all.M2-rebuilt.M2) > 1e-9 {
+		t.Errorf("Incremental removal = %+v, rebuilt = %+v", all, rebuilt)
+	}
+	for _, example := range all.Examples {
+		if example.EventID == "e2" {
+			t.Error("Removed event should not remain in examples")
+		}
+	}
+}
+
+func TestEventEffectEstimate(t *testing.T) {
+	st := &EventEffectStats{EventType: "campaign"}
+	for i, x := range []float64{12, 18, 15, 20, 10} {
+		st.Add(string(rune('a'+i)), "example", x)
+	}
+	estimate := st.Estimate()
+
+	if estimate.MeanUplift != 15 {
+		t.Errorf("MeanUplift = %f, expected 15", estimate.MeanUplift)
+	}
+	if !(estimate.CILower < 15 && estimate.CIUpper > 15 && estimate.CILower > 0) {
+		t.Errorf("CI = [%f, %f], expected a positive interval around 15", estimate.CILower, estimate.CIUpper)
+	}
+	if estimate.PValue >= 0.01 {
+		t.Errorf("PValue = %f, expected a clearly positive effect", estimate.PValue)
+	}
+	if !(estimate.HedgesG > 0 && estimate.HedgesG < estimate.CohensD) {
+		t.Errorf("Hedges g = %f should shrink Cohen's d = %f", estimate.HedgesG, estimate.CohensD)
+	}
+	if len(estimate.Examples) != eventEffectMaxExamples {
+		t.Errorf("Examples = %v, expected the latest %d", estimate.Examples, eventEffectMaxExamples)
+	}
+
+	// ばらつきが大きく平均が0付近なら有意にならない
+	noisy := &EventEffectStats{EventType: "weather"}
+	for i, x := range []float64{-30, 25, -10, 20} {
+		noisy.Add(string(rune('a'+i)), "example", x)
+	}
+	if got := noisy.Estimate(); got.PValue < 0.5 || EffectConfidence(got) > 0.5 {
+		t.Errorf("Noisy estimate = %+v", got)
+	}
+}
+
+func TestEventFromAnomalyResponse(t *testing.T) {
+	response := models.AnomalyResponse{
+		ResponseID:  "r1",
+		AnomalyDate: "2024-06-10",
+		ProductID:   "P001",
+		Answer:      "駅前でキャンペーンを実施した",
+		ImpactValue: 25,
+	}
+
+	// 分析レポートの異常があれば実績と予測の差を効果とする
+	measured := EventFromAnomalyResponse(response, &models.AnomalyDetection{ActualValue: 150, ExpectedValue: 100})
+	if measured == nil || measured.EventType != "campaign" || measured.UpliftSource != "measured" || *measured.UpliftPercent != 50 {
+		t.Fatalf("Measured event = %+v", measured)
+	}
+	if measured.EventID != BusinessEventIDFor("anomaly_response", "r1") || len(measured.ProductIDs) != 1 {
+		t.Errorf("Event ID/products = %s %v", measured.EventID, measured.ProductIDs)
+	}
+
+	reported := EventFromAnomalyResponse(response, nil)
+	if reported == nil || reported.UpliftSource != "reported" || *reported.UpliftPercent != 25 {
+		t.Errorf("Reported event = %+v", reported)
+	}
+
+	response.Answer = "わからない"
+	if got := EventFromAnomalyResponse(response, nil); got != nil {
+		t.Errorf("Unclassified answer should not create an event, got %+v", got)
+	}
+}
+
+func TestApplyBusinessEventRequest(t *testing.T) {
+	var event models.BusinessEvent
+	valid := models.BusinessEventRequest{EventType: "holiday", Title: "GW", StartDate: "2024-04-27", EndDate: "2024-05-06"}
+	if err := applyBusinessEventRequest(&event, valid); err != nil {
+		t.Fatalf("Valid request failed: %v", err)
+	}
+	if event.EndDate != "2024-05-06" || event.UpliftSource != "" {
+		t.Errorf("Applied event = %+v", event)
+	}
+
+	invalid := []models.BusinessEventRequest{
+		{EventType: "unknown", Title: "x", StartDate: "2024-01-01"},
+		{EventType: "holiday", Title: "x", StartDate: "2024/01/01"},
+		{EventType: "holiday", Title: "x", StartDate: "2024-01-10", EndDate: "2024-01-01"},
+	}
+	for _, req := range invalid {
+		if err := applyBusinessEventRequest(&event, req); !errors.Is(err, ErrInvalidBusinessEvent) {
+			t.Errorf("Expected validation error for %+v", req)
+		}
+	}
+}
+
+func TestEventRegressorFor(t *testing.T) {
+	uplift := func(v float64) *float64 { return &v }
+	stats := BuildEventEffectStats([]models.BusinessEvent{
+		{EventID: "e1", EventType: "campaign", StartDate: "2024-01-01", ProductIDs: []string{"P001"}, UpliftPercent: uplift(28)},
+		{EventID: "e2", EventType: "campaign", StartDate: "2024-02-01", ProductIDs: []string{"P001"}, UpliftPercent: uplift(32)},
+		{EventID: "e3", EventType: "campaign", StartDate: "2024-03-01", ProductIDs: []string{"P002"}, UpliftPercent: uplift(10)},
+	})
+
+	// 変化率が記録されていればそのまま使う
+	manual, ok := EventRegressorFor(models.BusinessEvent{EventType: "campaign", UpliftPercent: uplift(15)}, "P001", stats)
+	if !ok || manual.UpliftPercent != 15 || manual.UpliftSource != "manual" {
+		t.Errorf("Manual regressor = %+v", manual)
+	}
+
+	// 予定イベントは製品別の過去の効果を信頼度で縮小して使う
+	planned := models.BusinessEvent{EventID: "next", EventType: "campaign", Title: "夏のセール", StartDate: "2024-07-01", EndDate: "2024-07-03"}
+	learned, ok := EventRegressorFor(planned, "P001", stats)
+	if !ok || learned.UpliftSource != "learned" || learned.UpliftPercent <= 0 || learned.UpliftPercent > 30 || learned.Confidence <= 0 {
+		t.Errorf("Learned regressor = %+v", learned)
+	}
+
+	// 製品別の件数が足りなければ種類全体の推定を使い、推定がなければ使わない
+	if fallback, ok := EventRegressorFor(planned, "P002", stats); !ok || fallback.UpliftPercent == learned.UpliftPercent {
+		t.Errorf("Fallback regressor = %+v", fallback)
+	}
+	if _, ok := EventRegressorFor(models.BusinessEvent{EventType: "supply", StartDate: "2024-07-01"}, "P001", stats); ok {
+		t.Error("Event type without history should not become a regressor")
+	}
+}
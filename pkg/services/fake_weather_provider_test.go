@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// fakeWeatherProvider テスト用の取得元
+// 決めた予報と日ごとの観測値を返し、呼び出し回数・要求された期間と地点を記録する
+type fakeWeatherProvider struct {
+	name      string                           // 省略時は "fake"
+	forecasts []DailyForecast                  // Forecast が返す予報
+	days      map[string]HistoricalWeatherData // 日付 → 観測値（Historical は期間内の日を返す）
+	// 指定した場合は days の代わりに使う
+	historical func(location WeatherLocation, startDate, endDate time.Time) []HistoricalWeatherData
+	point      bool  // 座標・観測所ごとの過去データに対応する（PointHistorical）
+	err        error // 指定した場合はすべての取得で返す
+
+	forecastCalls   int
+	historicalCalls []string          // 要求された期間（"開始~終了"）
+	locations       []WeatherLocation // 要求された地点
+}
+
+// newFakeWeatherProvider 観測値のない取得元を作成
+func newFakeWeatherProvider() *fakeWeatherProvider {
+	return &fakeWeatherProvider{days: make(map[string]HistoricalWeatherData)}
+}
+
+func (p *fakeWeatherProvider) Name() string {
+	if p.name == "" {
+		return "fake"
+	}
+	return p.name
+}
+
+func (p *fakeWeatherProvider) Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error) {
+	p.forecastCalls++
+	p.locations = append(p.locations, location)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.forecasts == nil {
+		return nil, ErrWeatherNotSupported
+	}
+	return p.forecasts, nil
+}
+
+func (p *fakeWeatherProvider) Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	p.historicalCalls = append(p.historicalCalls, startDate.Format("2006-01-02")+"~"+endDate.Format("2006-01-02"))
+	p.locations = append(p.locations, location)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.historical != nil {
+		return p.historical(location, startDate, endDate), nil
+	}
+	var result []HistoricalWeatherData
+	for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+		if day, ok := p.days[d.Format("2006-01-02")]; ok {
+			result = append(result, day)
+		}
+	}
+	return result, nil
+}
+
+func (p *fakeWeatherProvider) PointHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	if !p.point {
+		return nil, ErrWeatherNotSupported
+	}
+	return p.Historical(ctx, location, startDate, endDate)
+}
+
+// todayForecasts 今日は2つの区域、明日は1つ目の区域の予報
+func todayForecasts(regionCode string) []DailyForecast {
+	today := time.Now().In(jst)
+	return []DailyForecast{
+		{Date: today.Format("2006-01-02"), AreaCode: regionCode + "a"},
+		{Date: today.Format("2006-01-02"), AreaCode: regionCode + "b"},
+		{Date: today.AddDate(0, 0, 1).Format("2006-01-02"), AreaCode: regionCode + "a"},
+	}
+}
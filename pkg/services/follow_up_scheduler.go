@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrFollowUpNotFound 指定したIDのフォローアップ質問が登録されていない
+var ErrFollowUpNotFound = errors.New("フォローアップ質問が見つかりません")
+
+// ErrInvalidFollowUpStatus フォローアップ質問の状態の変更が不正（許可されていない遷移・回答なしの回答済み）
+var ErrInvalidFollowUpStatus = errors.New("フォローアップ質問の状態を変更できません")
+
+// DefaultFollowUpOffsets FOLLOW_UP_OFFSETS が未設定・不正な場合のフォローアップ計画
+const DefaultFollowUpOffsets = "short_term_effect=14,medium_term_effect=60,yearly_review=365"
+
+// followUpPriorities フォローアップ質問の種類ごとの優先度（1が最優先）
+var followUpPriorities = map[string]int{
+	"short_term_effect":  1,
+	"yearly_review":      2,
+	"medium_term_effect": 3,
+	"long_term_pattern":  4,
+}
+
+// followUpTransitions 状態ごとに遷移可能な状態
+var followUpTransitions = map[string][]string{
+	"pending": {"sent", "answered", "skipped"},
+	"sent":    {"answered", "skipped"},
+}
+
+// FollowUpOffset フォローアップ質問の種類と、異常発生日から質問するまでの日数
+type FollowUpOffset struct {
+	QuestionType string `json:"question_type"`
+	Days         int    `json:"days"`
+}
+
+// ParseFollowUpOffsets "種類=日数" のカンマ区切り文字列を解析（不正な項目は無視し、日数の昇順に並べる）
+func ParseFollowUpOffsets(spec string) []FollowUpOffset {
+	var offsets []FollowUpOffset
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		questionType := strings.TrimSpace(parts[0])
+		days, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if _, ok := followUpPriorities[questionType]; !ok || err != nil || days <= 0 || seen[questionType] {
+			log.Printf("⚠️ フォローアップ設定の不正な項目を無視します: %q", item)
+			continue
+		}
+		seen[questionType] = true
+		offsets = append(offsets, FollowUpOffset{QuestionType: questionType, Days: days})
+	}
+	sort.SliceStable(offsets, func(i, j int) bool { return offsets[i].Days < offsets[j].Days })
+	return offsets
+}
+
+// FollowUpScheduler 完了した異常回答セッションに対して、効果の持続や再発を確認するフォローアップ質問を計画・管理する
+type FollowUpScheduler struct {
+	vectorStoreService *VectorStoreService
+	offsets            []FollowUpOffset
+}
+
+// NewFollowUpScheduler 新しいフォローアップスケジューラーを作成（offsetsが空の場合はデフォルト計画を使用）
+func NewFollowUpScheduler(vectorStoreService *VectorStoreService, offsets []FollowUpOffset) *FollowUpScheduler {
+	if len(offsets) == 0 {
+		offsets = ParseFollowUpOffsets(DefaultFollowUpOffsets)
+	}
+	return &FollowUpScheduler{
+		vectorStoreService: vectorStoreService,
+		offsets:            offsets,
+	}
+}
+
+// Offsets フォローアップ計画を返す
+func (s *FollowUpScheduler) Offsets() []FollowUpOffset {
+	return s.offsets
+}
+
+// PlanFollowUps 完了したセッションのフォローアップ質問を計画する
+// 予定日は異常発生日＋日数とし、既に過ぎている場合はセッション完了日に前倒しする
+func (s *FollowUpScheduler) PlanFollowUps(session *models.AnomalyResponseSession) []models.FollowUpQuestion {
+	anomalyDay, err := parseAnomalyDay(session.AnomalyDate)
+	if err != nil {
+		log.Printf("⚠️ 異常発生日を解析できないためフォローアップを計画しません: %s", session.AnomalyDate)
+		return nil
+	}
+	completedDay := time.Now()
+	if t, err := time.Parse(time.RFC3339, session.CompletedAt); err == nil {
+		completedDay = t
+	}
+	completedDay = time.Date(completedDay.Year(), completedDay.Month(), completedDay.Day(), 0, 0, 0, 0, time.UTC)
+
+	var previousAnswers []string
+	for _, conv := range session.Conversations {
+		if conv.Answer != "" {
+			previousAnswers = append(previousAnswers, conv.Answer)
+		}
+	}
+
+	cause := followUpCause(session)
+	anomalyContext := fmt.Sprintf("日付: %s / 製品ID: %s / 影響: %s (%.1f%%) / 原因: %s",
+		session.AnomalyDate, session.ProductID, session.FinalImpact, session.FinalImpactValue, cause)
+	createdAt := time.Now().Format(time.RFC3339)
+
+	followUps := make([]models.FollowUpQuestion, 0, len(s.offsets))
+	for _, offset := range s.offsets {
+		scheduled := anomalyDay.AddDate(0, 0, offset.Days)
+		if scheduled.Before(completedDay) {
+			scheduled = completedDay
+		}
+		question, choices := followUpQuestionText(offset, session, cause)
+		followUps = append(followUps, models.FollowUpQuestion{
+			ID:              followUpID(session.SessionID, offset.QuestionType),
+			AnomalyID:       session.AnomalyDate + "_" + session.ProductID,
+			SessionID:       session.SessionID,
+			QuestionType:    offset.QuestionType,
+			ScheduledDate:   scheduled.Format("2006-01-02"),
+			Status:          "pending",
+			Question:        question,
+			Choices:         choices,
+			Context:         anomalyContext,
+			PreviousAnswers: previousAnswers,
+			Priority:        followUpPriorities[offset.QuestionType],
+			ProductID:       session.ProductID,
+			AnomalyDate:     session.AnomalyDate,
+			OffsetDays:      offset.Days,
+			CreatedAt:       createdAt,
+		})
+	}
+	return followUps
+}
+
+// ScheduleForSession 完了したセッションのフォローアップ質問を保存する
+// IDはセッションと種類から決まるため、既に計画済みの質問は状態を保ったまま保存し直さない
+func (s *FollowUpScheduler) ScheduleForSession(ctx context.Context, session *models.AnomalyResponseSession) ([]models.FollowUpQuestion, error) {
+	if !session.IsComplete {
+		return nil, fmt.Errorf("セッション %s はまだ完了していません", session.SessionID)
+	}
+
+	existing, err := s.vectorStoreService.ListFollowUpQuestions(ctx, "", session.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	scheduled := make(map[string]bool, len(existing))
+	for _, followUp := range existing {
+		scheduled[followUp.ID] = true
+	}
+
+	var created []models.FollowUpQuestion
+	for _, followUp := range s.PlanFollowUps(session) {
+		if scheduled[followUp.ID] {
+			continue
+		}
+		if err := s.vectorStoreService.SaveFollowUpQuestion(ctx, followUp); err != nil {
+			return created, err
+		}
+		created = append(created, followUp)
+	}
+
+	if len(created) > 0 {
+		log.Printf("📅 フォローアップ質問を%d件計画しました (セッション: %s)", len(created), session.SessionID)
+	}
+	return created, nil
+}
+
+// Pending 予定日が asOf 以前で、回答待ち（pending/sent）のフォローアップ質問を予定日・優先度順に返す
+func (s *FollowUpScheduler) Pending(ctx context.Context, asOf time.Time) ([]models.FollowUpQuestion, error) {
+	all, err := s.vectorStoreService.ListFollowUpQuestions(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return DueFollowUps(all, asOf), nil
+}
+
+// UpdateStatus フォローアップ質問の状態を更新する（answered の場合は回答を記録）
+func (s *FollowUpScheduler) UpdateStatus(ctx context.Context, id, status, answer string) (*models.FollowUpQuestion, error) {
+	followUp, err := s.vectorStoreService.GetFollowUpQuestion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyFollowUpStatus(followUp, status, answer, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.vectorStoreService.SaveFollowUpQuestion(ctx, *followUp); err != nil {
+		return nil, err
+	}
+	return followUp, nil
+}
+
+// DueFollowUps 予定日が asOf 以前で、回答待ち（pending/sent）のものを予定日・優先度順に抽出
+func DueFollowUps(followUps []models.FollowUpQuestion, asOf time.Time) []models.FollowUpQuestion {
+	day := asOf.Format("2006-01-02")
+	due := make([]models.FollowUpQuestion, 0)
+	for _, followUp := range followUps {
+		if (followUp.Status == "pending" || followUp.Status == "sent") && followUp.ScheduledDate <= day {
+			due = append(due, followUp)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		if due[i].ScheduledDate != due[j].ScheduledDate {
+			return due[i].ScheduledDate < due[j].ScheduledDate
+		}
+		return due[i].Priority < due[j].Priority
+	})
+	return due
+}
+
+// ApplyFollowUpStatus 状態遷移を検証して適用する
+// pending → sent/answered/skipped、sent → answered/skipped のみ許可し、answered/skipped は終端
+func ApplyFollowUpStatus(followUp *models.FollowUpQuestion, status, answer string, now time.Time) error {
+	allowed := false
+	for _, next := range followUpTransitions[followUp.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s から %s には変更できません", ErrInvalidFollowUpStatus, followUp.Status, status)
+	}
+	if status == "answered" && strings.TrimSpace(answer) == "" {
+		return fmt.Errorf("%w: 回答済みにするには回答が必要です", ErrInvalidFollowUpStatus)
+	}
+
+	timestamp := now.Format(time.RFC3339)
+	switch status {
+	case "sent":
+		followUp.SentAt = timestamp
+	case "answered":
+		followUp.Answer = answer
+		followUp.AnsweredAt = timestamp
+	case "skipped":
+		followUp.AnsweredAt = timestamp
+	}
+	followUp.Status = status
+	return nil
+}
+
+// followUpID セッションと質問の種類からフォローアップ質問の安定したIDを生成
+func followUpID(sessionID, questionType string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("follow_up:"+sessionID+":"+questionType)).String()
+}
+
+// followUpCause セッションで特定された原因（インタビューの結論 > タグ > 最初の回答）
+func followUpCause(session *models.AnomalyResponseSession) string {
+	if session.RootCause != nil && session.RootCause.PrimaryCause != "" {
+		return session.RootCause.PrimaryCause
+	}
+	if len(session.FinalTags) > 0 {
+		return strings.Join(session.FinalTags, "・")
+	}
+	if len(session.Conversations) > 0 && session.Conversations[0].Answer != "" {
+		return truncateRunes(session.Conversations[0].Answer, 40)
+	}
+	return "この変動の要因"
+}
+
+// followUpQuestionText フォローアップ質問の種類に応じた質問文と選択肢
+func followUpQuestionText(offset FollowUpOffset, session *models.AnomalyResponseSession, cause string) (string, []string) {
+	movement := "売上の変動"
+	switch session.FinalImpact {
+	case "positive":
+		movement = "売上の増加"
+	case "negative":
+		movement = "売上の減少"
+	}
+
+	switch offset.QuestionType {
+	case "short_term_effect":
+		return fmt.Sprintf("%sに製品%sで発生した%s（原因: %s）から%d日が経ちました。その影響はその後も続いていますか？",
+				session.AnomalyDate, session.ProductID, movement, cause, offset.Days),
+			[]string{"影響が続いている", "徐々に元の水準に戻った", "すでに元の水準に戻った", "反動で逆の動きが出ている"}
+	case "medium_term_effect":
+		return fmt.Sprintf("%sに製品%sで発生した%s（原因: %s）から%d日が経ちました。売上の水準は変化前と比べてどうなっていますか？",
+				session.AnomalyDate, session.ProductID, movement, cause, offset.Days),
+			[]string{"新しい水準で定着した", "一時的な影響で終わった", "再び同じような変動があった", "判断できない"}
+	case "long_term_pattern":
+		return fmt.Sprintf("%sに製品%sで発生した%s（原因: %s）から%d日が経ちました。同じ要因による変動は、その後も繰り返し起きていますか？",
+				session.AnomalyDate, session.ProductID, movement, cause, offset.Days),
+			[]string{"定期的に繰り返している", "条件が揃ったときに起きている", "一度だけだった", "判断できない"}
+	default: // yearly_review
+		return fmt.Sprintf("%sに製品%sで%s（原因: %s）が発生してから%d日が経ちました。今年の同じ時期にも同様のことが起きましたか？",
+				session.AnomalyDate, session.ProductID, movement, cause, offset.Days),
+			[]string{"同じように起きた", "規模は小さいが起きた", "起きなかった", "逆の動きになった"}
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestParseFollowUpOffsets(t *testing.T) {
+	offsets := ParseFollowUpOffsets("yearly_review=365, short_term_effect=14,unknown=3,medium_term_effect=abc,short_term_effect=7")
+	if len(offsets) != 2 {
+		t.Fatalf("Expected 2 valid offsets, got %+v", offsets)
+	}
+	// 日数の昇順に並び、重複した種類は最初の指定を採用する
+	if offsets[0].QuestionType != "short_term_effect" || offsets[0].Days != 14 {
+		t.Errorf("offsets[0] = %+v, expected short_term_effect=14", offsets[0])
+	}
+	if offsets[1].QuestionType != "yearly_review" || offsets[1].Days != 365 {
+		t.Errorf("offsets[1] = %+v, expected yearly_review=365", offsets[1])
+	}
+
+	// 有効な項目がない場合はデフォルト計画を使用
+	if got := NewFollowUpScheduler(nil, ParseFollowUpOffsets("invalid")).Offsets(); len(got) != 3 {
+		t.Errorf("Expected default offsets, got %+v", got)
+	}
+}
+
+func TestPlanFollowUps(t *testing.T) {
+	scheduler := NewFollowUpScheduler(nil, ParseFollowUpOffsets("short_term_effect=14,yearly_review=365"))
+	session := &models.AnomalyResponseSession{
+		SessionID:     "session-1",
+		AnomalyDate:   "2025-07-01",
+		ProductID:     "P001",
+		IsComplete:    true,
+		CompletedAt:   "2025-07-20T10:00:00+09:00",
+		FinalTags:     []string{"キャンペーン"},
+		FinalImpact:   "positive",
+		Conversations: []models.Conversation{{Question: "理由は？", Answer: "販促を実施した"}},
+	}
+
+	followUps := scheduler.PlanFollowUps(session)
+	if len(followUps) != 2 {
+		t.Fatalf("Expected 2 follow-ups, got %d", len(followUps))
+	}
+
+	// 14日後（7/15）は既に過ぎているため、完了日に前倒しされる
+	if followUps[0].ScheduledDate != "2025-07-20" {
+		t.Errorf("short_term_effect scheduled at %s, expected 2025-07-20", followUps[0].ScheduledDate)
+	}
+	if followUps[1].ScheduledDate != "2026-07-01" {
+		t.Errorf("yearly_review scheduled at %s, expected 2026-07-01", followUps[1].ScheduledDate)
+	}
+	for _, followUp := range followUps {
+		if followUp.Status != "pending" || followUp.Question == "" || len(followUp.Choices) == 0 {
+			t.Errorf("Unexpected follow-up: %+v", followUp)
+		}
+		if len(followUp.PreviousAnswers) != 1 {
+			t.Errorf("Expected previous answers to be carried over, got %v", followUp.PreviousAnswers)
+		}
+	}
+
+	// IDはセッションと種類から決まる
+	if again := scheduler.PlanFollowUps(session); again[0].ID != followUps[0].ID {
+		t.Errorf("Follow-up IDs should be stable, got %s and %s", followUps[0].ID, again[0].ID)
+	}
+}
+
+func TestDueFollowUps(t *testing.T) {
+	followUps := []models.FollowUpQuestion{
+		{ID: "future", Status: "pending", ScheduledDate: "2025-09-01", Priority: 1},
+		{ID: "yearly", Status: "pending", ScheduledDate: "2025-08-01", Priority: 2},
+		{ID: "short", Status: "sent", ScheduledDate: "2025-08-01", Priority: 1},
+		{ID: "done", Status: "answered", ScheduledDate: "2025-07-01", Priority: 1},
+		{ID: "early", Status: "pending", ScheduledDate: "2025-07-15", Priority: 3},
+	}
+
+	due := DueFollowUps(followUps, time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC))
+	expected := []string{"early", "short", "yearly"}
+	if len(due) != len(expected) {
+		t.Fatalf("Expected %d due follow-ups, got %+v", len(expected), due)
+	}
+	for i, id := range expected {
+		if due[i].ID != id {
+			t.Errorf("due[%d] = %s, expected %s", i, due[i].ID, id)
+		}
+	}
+}
+
+func TestApplyFollowUpStatus(t *testing.T) {
+	now := time.Date(2025, 8, 1, 9, 0, 0, 0, time.UTC)
+	followUp := &models.FollowUpQuestion{Status: "pending"}
+
+	if err := ApplyFollowUpStatus(followUp, "answered", "", now); !errors.Is(err, ErrInvalidFollowUpStatus) {
+		t.Error("Expected error when answering without an answer")
+	}
+	if err := ApplyFollowUpStatus(followUp, "sent", "", now); err != nil || followUp.SentAt == "" {
+		t.Fatalf("pending → sent failed: %v", err)
+	}
+	if err := ApplyFollowUpStatus(followUp, "pending", "", now); !errors.Is(err, ErrInvalidFollowUpStatus) {
+		t.Error("Expected error for sent → pending")
+	}
+	if err := ApplyFollowUpStatus(followUp, "answered", "効果は続いている", now); err != nil {
+		t.Fatalf("sent → answered failed: %v", err)
+	}
+	if followUp.Answer != "効果は続いている" || followUp.AnsweredAt == "" {
+		t.Errorf("Answer was not recorded: %+v", followUp)
+	}
+	// answered は終端
+	if err := ApplyFollowUpStatus(followUp, "skipped", "", now); err == nil {
+		t.Error("Expected error for answered → skipped")
+	}
+}
@@ -0,0 +1,10 @@
+package services
+
+// truncateRunes 文字列を指定した文字数で切り詰める
+func truncateRunes(s string, maxRunes int) string {
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	return string(runes[:maxRunes]) + "…"
+}
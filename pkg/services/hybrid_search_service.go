@@ -0,0 +1,503 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+// lexicalIndexTTL BM25インデックスを再構築するまでの有効期間
+const lexicalIndexTTL = 5 * time.Minute
+
+// HybridSearchConfig ハイブリッド検索（ベクトル + BM25）の設定
+type HybridSearchConfig struct {
+	VectorWeight  float64 // ベクトル検索ランキングの重み
+	LexicalWeight float64 // BM25ランキングの重み
+	FusionK       int     // RRFの定数k（大きいほど下位の順位も重視）
+
+	TokenBudget    int     // RAGコンテキストのトークン予算
+	RerankMode     string  // リランキング方式（"heuristic" または "llm"）
+	DedupThreshold float64 // 重複とみなすJaccard係数の閾値
+}
+
+// HybridSearchResult 融合後の検索結果（ソースごとのスコア付き）
+type HybridSearchResult struct {
+	ID           string
+	Collection   string
+	Type         string
+	FileName     string
+	Text         string
+	Date         string
+	Payload      map[string]*qdrant.Value
+	VectorScore  float32
+	VectorRank   int // 0は該当なし
+	LexicalScore float64
+	LexicalRank  int     // 0は該当なし
+	FusedScore   float64 // RRFスコア（0.0-1.0に正規化）
+}
+
+// lexicalDocument BM25インデックス内のドキュメント
+type lexicalDocument struct {
+	id         string
+	collection string
+	docType    string
+	fileName   string
+	text       string
+	date       string
+	payload    map[string]*qdrant.Value
+	termFreq   map[string]int
+	length     int
+}
+
+// BM25Index 文字n-gramによるBM25転置インデックス
+type BM25Index struct {
+	k1        float64
+	b         float64
+	docs      []lexicalDocument
+	postings  map[string][]int // term -> docs のインデックス
+	totalLen  int
+	avgDocLen float64
+}
+
+// LexicalHit BM25検索のヒット
+type LexicalHit struct {
+	DocIndex int
+	Score    float64
+}
+
+// NewBM25Index 空のBM25インデックスを作成
+func NewBM25Index() *BM25Index {
+	return &BM25Index{
+		k1:       1.2,
+		b:        0.75,
+		postings: make(map[string][]int),
+	}
+}
+
+// add ドキュメントをインデックスに追加
+func (idx *BM25Index) add(doc lexicalDocument) {
+	tokens := TokenizeJapanese(doc.text + " " + doc.fileName)
+	doc.termFreq = make(map[string]int)
+	for _, t := range tokens {
+		doc.termFreq[t]++
+	}
+	doc.length = len(tokens)
+
+	docIndex := len(idx.docs)
+	idx.docs = append(idx.docs, doc)
+	for term := range doc.termFreq {
+		idx.postings[term] = append(idx.postings[term], docIndex)
+	}
+
+	idx.totalLen += doc.length
+	idx.avgDocLen = float64(idx.totalLen) / float64(len(idx.docs))
+}
+
+// Search クエリに対するBM25スコア上位topK件を返す（filterがnilでなければ一致するもののみ）
+func (idx *BM25Index) Search(query string, topK int, filter func(doc lexicalDocument) bool) []LexicalHit {
+	if len(idx.docs) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.docs))
+	scores := make(map[int]float64)
+	seen := make(map[string]bool)
+	for _, term := range TokenizeJapanese(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		postings := idx.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for _, docIndex := range postings {
+			doc := idx.docs[docIndex]
+			if filter != nil && !filter(doc) {
+				continue
+			}
+			tf := float64(doc.termFreq[term])
+			norm := idx.k1 * (1 - idx.b + idx.b*float64(doc.length)/idx.avgDocLen)
+			scores[docIndex] += idf * tf * (idx.k1 + 1) / (tf + norm)
+		}
+	}
+
+	hits := make([]LexicalHit, 0, len(scores))
+	for docIndex, score := range scores {
+		hits = append(hits, LexicalHit{DocIndex: docIndex, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score == hits[j].Score {
+			return hits[i].DocIndex < hits[j].DocIndex
+		}
+		return hits[i].Score > hits[j].Score
+	})
+	if topK > 0 && len(hits) > topK {
+		hits = hits[:topK]
+	}
+	return hits
+}
+
+// TokenizeJapanese 日本語向けのトークン化を行う
+// 英数字の連続（製品コードや日付など）は1トークン、それ以外の文字列は文字bigramに分割する
+func TokenizeJapanese(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, strings.Trim(string(word), "-/.:"))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		// 全角英数字・記号を半角に正規化
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		r = unicode.ToLower(r)
+
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushCJK()
+			word = append(word, r)
+		case len(word) > 0 && (r == '-' || r == '/' || r == '.' || r == ':'):
+			// 日付（2024-07-01）や小数、コードの区切りはトークン内に保持
+			word = append(word, r)
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || r == 'ー':
+			flushWord()
+			cjk = append(cjk, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	// 空トークンを除去
+	result := tokens[:0]
+	for _, t := range tokens {
+		if t != "" {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// ReciprocalRankFusion 複数のランキングを重み付きRRFで融合する
+// score(d) = Σ weight_i / (k + rank_i(d))
+func ReciprocalRankFusion(rankings [][]string, weights []float64, k int) map[string]float64 {
+	if k <= 0 {
+		k = 60
+	}
+	fused := make(map[string]float64)
+	for i, ranking := range rankings {
+		weight := 1.0
+		if i < len(weights) {
+			weight = weights[i]
+		}
+		for rank, key := range ranking {
+			fused[key] += weight / float64(k+rank+1)
+		}
+	}
+	return fused
+}
+
+// HybridSearchService ベクトル検索とBM25検索を融合するサービス
+type HybridSearchService struct {
+	vectorStoreService *VectorStoreService
+	config             HybridSearchConfig
+
+	mu        sync.RWMutex
+	index     *BM25Index
+	builtAt   time.Time
+	buildLock sync.Mutex
+}
+
+// NewHybridSearchService 新しいハイブリッド検索サービスを作成
+func NewHybridSearchService(vectorStoreService *VectorStoreService, config HybridSearchConfig) *HybridSearchService {
+	if config.FusionK <= 0 {
+		config.FusionK = 60
+	}
+	if config.VectorWeight < 0 {
+		config.VectorWeight = 0
+	}
+	if config.LexicalWeight < 0 {
+		config.LexicalWeight = 0
+	}
+	if config.TokenBudget <= 0 {
+		config.TokenBudget = 3000
+	}
+	if config.RerankMode != "llm" {
+		config.RerankMode = "heuristic"
+	}
+	if config.DedupThreshold <= 0 || config.DedupThreshold > 1 {
+		config.DedupThreshold = 0.85
+	}
+	return &HybridSearchService{
+		vectorStoreService: vectorStoreService,
+		config:             config,
+	}
+}
+
+// Config 現在のハイブリッド検索設定を返す
+func (h *HybridSearchService) Config() HybridSearchConfig {
+	return h.config
+}
+
+// Invalidate BM25インデックスを破棄し、次回検索時に再構築させる
+func (h *HybridSearchService) Invalidate() {
+	h.mu.Lock()
+	h.builtAt = time.Time{}
+	h.mu.Unlock()
+}
+
+// lexicalIndex 有効期限内のBM25インデックスを返す（期限切れなら再構築）
+func (h *HybridSearchService) lexicalIndex(ctx context.Context) (*BM25Index, error) {
+	h.mu.RLock()
+	if h.index != nil && time.Since(h.builtAt) < lexicalIndexTTL {
+		idx := h.index
+		h.mu.RUnlock()
+		return idx, nil
+	}
+	h.mu.RUnlock()
+
+	h.buildLock.Lock()
+	defer h.buildLock.Unlock()
+
+	// 他のゴルーチンが再構築済みの場合
+	h.mu.RLock()
+	if h.index != nil && time.Since(h.builtAt) < lexicalIndexTTL {
+		idx := h.index
+		h.mu.RUnlock()
+		return idx, nil
+	}
+	h.mu.RUnlock()
+
+	idx, err := h.buildIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	h.index = idx
+	h.builtAt = time.Now()
+	h.mu.Unlock()
+	return idx, nil
+}
+
+// buildIndex ドキュメント・分析レポートのコレクションからBM25インデックスを構築
+func (h *HybridSearchService) buildIndex(ctx context.Context) (*BM25Index, error) {
+	if h.vectorStoreService == nil {
+		return nil, fmt.Errorf("VectorStoreServiceが初期化されていません")
+	}
+
+	idx := NewBM25Index()
+	for _, collectionName := range []string{"hunt_documents", "hunt_chat_documents"} {
+		points, err := h.vectorStoreService.ScrollAllPoints(ctx, collectionName, 10000)
+		if err != nil {
+			log.Printf("⚠️ BM25インデックス構築: コレクション '%s' の取得に失敗: %v", collectionName, err)
+			continue
+		}
+		for _, point := range points {
+			text := getStringFromPayload(point.Payload, "text")
+			if text == "" {
+				continue
+			}
+			date := getStringFromPayload(point.Payload, "analysis_date")
+			if date == "" {
+				date = getStringFromPayload(point.Payload, "timestamp")
+			}
+			idx.add(lexicalDocument{
+				id:         point.Id.GetUuid(),
+				collection: collectionName,
+				docType:    getStringFromPayload(point.Payload, "type"),
+				fileName:   getStringFromPayload(point.Payload, "file_name"),
+				text:       text,
+				date:       date,
+				payload:    point.Payload,
+			})
+		}
+	}
+
+	log.Printf("🔤 BM25インデックスを構築しました: %d件のドキュメント", len(idx.docs))
+	return idx, nil
+}
+
+// analysisReportCollections 分析レポートを保存するコレクション（統計分析のレポートは hunt_documents、ファイル分析のレポートは hunt_chat_documents）
+var analysisReportCollections = []string{"hunt_documents", "hunt_chat_documents"}
+
+// vectorHit ベクトル検索の結果1件と、その取得元のコレクション
+type vectorHit struct {
+	collection string
+	point      *qdrant.ScoredPoint
+}
+
+// SearchDocuments 分析レポート以外のドキュメントをハイブリッド検索
+// ベクトル検索（Qdrantのフィルタ）・BM25とも分析レポートを除いた同じ対象から候補を集める
+func (h *HybridSearchService) SearchDocuments(ctx context.Context, query string, topK int) ([]HybridSearchResult, error) {
+	candidates := topK * 3
+	vectorResults, vectorErr := h.vectorStoreService.SearchDocuments(ctx, query, uint64(candidates))
+	if vectorErr != nil {
+		log.Printf("ベクトル検索に失敗: %v", vectorErr)
+	}
+	hits := make([]vectorHit, 0, len(vectorResults))
+	for _, point := range vectorResults {
+		hits = append(hits, vectorHit{collection: "hunt_documents", point: point})
+	}
+	filter := func(doc lexicalDocument) bool {
+		return doc.collection == "hunt_documents" && doc.docType != "analysis_report"
+	}
+	return h.fuse(ctx, query, topK, hits, vectorErr, filter)
+}
+
+// SearchReports 分析レポートをハイブリッド検索
+// ベクトル検索・BM25とも同じコレクションの分析レポートを対象にし、同じレポートは1件に融合する
+func (h *HybridSearchService) SearchReports(ctx context.Context, query string, topK int) ([]HybridSearchResult, error) {
+	candidates := topK * 3
+	var hits []vectorHit
+	var vectorErr error
+	failed := 0
+	for _, collection := range analysisReportCollections {
+		results, err := h.vectorStoreService.SearchAnalysisReports(ctx, collection, query, uint64(candidates))
+		if err != nil {
+			log.Printf("分析レポートのベクトル検索に失敗（%s）: %v", collection, err)
+			vectorErr = err
+			failed++
+			continue
+		}
+		for _, point := range results {
+			hits = append(hits, vectorHit{collection: collection, point: point})
+		}
+	}
+	if failed < len(analysisReportCollections) {
+		vectorErr = nil
+	}
+	// 同じ埋め込みモデルの類似度なので、コレクションをまたいでスコア順に並べる
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].point.Score > hits[j].point.Score })
+	if len(hits) > candidates {
+		hits = hits[:candidates]
+	}
+	return h.fuse(ctx, query, topK, hits, vectorErr, isAnalysisReportDocument)
+}
+
+// isAnalysisReportDocument 分析レポートのコレクションにある分析レポートか
+func isAnalysisReportDocument(doc lexicalDocument) bool {
+	return doc.docType == "analysis_report" && containsString(analysisReportCollections, doc.collection)
+}
+
+// fuse ベクトル検索結果とBM25結果をRRFで融合する
+func (h *HybridSearchService) fuse(
+	ctx context.Context,
+	query string,
+	topK int,
+	vectorHits []vectorHit,
+	vectorErr error,
+	filter func(doc lexicalDocument) bool,
+) ([]HybridSearchResult, error) {
+	results := make(map[string]*HybridSearchResult)
+	var vectorRanking, lexicalRanking []string
+
+	for i, hit := range vectorHits {
+		point := hit.point
+		key := hit.collection + "/" + point.Id.GetUuid()
+		results[key] = &HybridSearchResult{
+			ID:          point.Id.GetUuid(),
+			Collection:  hit.collection,
+			Type:        getStringFromPayload(point.Payload, "type"),
+			FileName:    getStringFromPayload(point.Payload, "file_name"),
+			Text:        getStringFromPayload(point.Payload, "text"),
+			Date:        getStringFromPayload(point.Payload, "analysis_date"),
+			Payload:     point.Payload,
+			VectorScore: point.Score,
+			VectorRank:  i + 1,
+		}
+		vectorRanking = append(vectorRanking, key)
+	}
+
+	idx, lexicalErr := h.lexicalIndex(ctx)
+	if lexicalErr != nil {
+		log.Printf("⚠️ BM25インデックスが利用できません: %v", lexicalErr)
+	} else {
+		for i, hit := range idx.Search(query, topK*3, filter) {
+			doc := idx.docs[hit.DocIndex]
+			key := doc.collection + "/" + doc.id
+			r, ok := results[key]
+			if !ok {
+				r = &HybridSearchResult{
+					ID:         doc.id,
+					Collection: doc.collection,
+					Type:       doc.docType,
+					FileName:   doc.fileName,
+					Text:       doc.text,
+					Date:       doc.date,
+					Payload:    doc.payload,
+				}
+				results[key] = r
+			}
+			r.LexicalScore = hit.Score
+			r.LexicalRank = i + 1
+			lexicalRanking = append(lexicalRanking, key)
+		}
+	}
+
+	if vectorErr != nil && lexicalErr != nil {
+		return nil, fmt.Errorf("ベクトル検索・BM25検索の両方に失敗: %w", vectorErr)
+	}
+
+	fused := ReciprocalRankFusion(
+		[][]string{vectorRanking, lexicalRanking},
+		[]float64{h.config.VectorWeight, h.config.LexicalWeight},
+		h.config.FusionK,
+	)
+
+	// 両ランキングで1位の場合を1.0とする正規化
+	maxFused := (h.config.VectorWeight + h.config.LexicalWeight) / float64(h.config.FusionK+1)
+
+	merged := make([]HybridSearchResult, 0, len(results))
+	for key, r := range results {
+		r.FusedScore = fused[key]
+		if maxFused > 0 {
+			r.FusedScore /= maxFused
+		}
+		merged = append(merged, *r)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].FusedScore == merged[j].FusedScore {
+			return merged[i].ID < merged[j].ID
+		}
+		return merged[i].FusedScore > merged[j].FusedScore
+	})
+	if len(merged) > topK {
+		merged = merged[:topK]
+	}
+
+	log.Printf("🔀 ハイブリッド検索: ベクトル%d件 + BM25 %d件 → %d件", len(vectorRanking), len(lexicalRanking), len(merged))
+	return merged, nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/qdrant/go-client/qdrant"
+)
+
+func TestTokenizeJapanese(t *testing.T) {
+	tokens := TokenizeJapanese("製品P001の2024-07-01売上")
+
+	expected := map[string]bool{
+		"製品":         true,
+		"p001":       true,
+		"2024-07-01": true,
+		"売上":         true,
+	}
+	found := make(map[string]bool)
+	for _, token := range tokens {
+		found[token] = true
+	}
+	for token := range expected {
+		if !found[token] {
+			t.Errorf("TokenizeJapanese() missing token %q, got %v", token, tokens)
+		}
+	}
+
+	// 全角英数字は半角に正規化される
+	fullWidth := TokenizeJapanese("Ｐ００１")
+	if len(fullWidth) != 1 || fullWidth[0] != "p001" {
+		t.Errorf("TokenizeJapanese(full-width) = %v, expected [p001]", fullWidth)
+	}
+}
+
+func TestBM25IndexSearch(t *testing.T) {
+	idx := NewBM25Index()
+	idx.add(lexicalDocument{id: "a", text: "鈴鹿市のミネラルウォーター売上レポート"})
+	idx.add(lexicalDocument{id: "b", text: "製品P001の夏季需要が増加"})
+	idx.add(lexicalDocument{id: "c", text: "東京都の気温と湿度の推移"})
+
+	hits := idx.Search("P001 の需要", 3, nil)
+	if len(hits) == 0 {
+		t.Fatal("BM25Index.Search() returned no hits")
+	}
+	if idx.docs[hits[0].DocIndex].id != "b" {
+		t.Errorf("Expected top hit to be 'b', got '%s'", idx.docs[hits[0].DocIndex].id)
+	}
+
+	// フィルタで除外されたドキュメントはヒットしない
+	filtered := idx.Search("P001", 3, func(doc lexicalDocument) bool { return doc.id != "b" })
+	if len(filtered) != 0 {
+		t.Errorf("Expected no hits with filter, got %d", len(filtered))
+	}
+}
+
+func TestReciprocalRankFusion(t *testing.T) {
+	vector := []string{"a", "b", "c"}
+	lexical := []string{"c", "a"}
+
+	fused := ReciprocalRankFusion([][]string{vector, lexical}, []float64{1.0, 1.0}, 60)
+	if fused["a"] <= fused["c"] || fused["c"] <= fused["b"] {
+		t.Errorf("Unexpected fusion order: %v", fused)
+	}
+
+	// 重み0のランキングは融合スコアに寄与しない
+	vectorOnly := ReciprocalRankFusion([][]string{vector, lexical}, []float64{1.0, 0}, 60)
+	if vectorOnly["a"] <= vectorOnly["b"] || vectorOnly["b"] <= vectorOnly["c"] {
+		t.Errorf("Unexpected vector-only fusion order: %v", vectorOnly)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens("売上"); got != 2 {
+		t.Errorf("EstimateTokens(売上) = %d, expected 2", got)
+	}
+	if got := EstimateTokens("abcdefgh"); got != 2 {
+		t.Errorf("EstimateTokens(abcdefgh) = %d, expected 2", got)
+	}
+}
+
+func TestPackContext(t *testing.T) {
+	service := NewHybridSearchService(nil, HybridSearchConfig{TokenBudget: 60})
+
+	longText := ""
+	for i := 0; i < 300; i++ {
+		longText += "気"
+	}
+
+	candidates := []ContextCandidate{
+		{Source: models.ContextSource{Type: "document", FileName: "a", Score: 0.9}, Text: "製品P001の夏季需要が増加しました"},
+		{Source: models.ContextSource{Type: "document", FileName: "b", Score: 0.8}, Text: "製品P001の夏季需要が増加しました"},
+		{Source: models.ContextSource{Type: "document", FileName: "c", Score: 0.1}, Text: longText},
+	}
+
+	packed := service.PackContext("P001の需要", candidates)
+
+	if len(packed.Sources) != len(candidates) {
+		t.Fatalf("Expected %d sources, got %d", len(candidates), len(packed.Sources))
+	}
+	if packed.UsedTokens > packed.Budget {
+		t.Errorf("UsedTokens %d exceeds budget %d", packed.UsedTokens, packed.Budget)
+	}
+
+	reasons := make(map[string]string)
+	for _, source := range packed.Sources {
+		reasons[source.FileName] = source.DropReason
+	}
+	if reasons["a"] != "" {
+		t.Errorf("Expected 'a' to be included, got drop reason %q", reasons["a"])
+	}
+	if reasons["b"] != "duplicate" {
+		t.Errorf("Expected 'b' to be dropped as duplicate, got %q", reasons["b"])
+	}
+	if reasons["c"] != "token_budget" {
+		t.Errorf("Expected 'c' to be dropped by token budget, got %q", reasons["c"])
+	}
+}
+
+func TestFuseMergesReportFoundByBothRetrievers(t *testing.T) {
+	// ファイル分析のレポートは hunt_chat_documents にあり、ベクトル検索・BM25の両方でヒットする
+	idx := NewBM25Index()
+	idx.add(lexicalDocument{id: "report-1", collection: "hunt_chat_documents", docType: "analysis_report", text: "製品P001の売上が急増した分析レポート"})
+	idx.add(lexicalDocument{id: "report-2", collection: "hunt_documents", docType: "analysis_report", text: "製品P001の在庫の分析レポート"})
+	idx.add(lexicalDocument{id: "doc-1", collection: "hunt_documents", docType: "document", text: "製品P001の売上"})
+	service := NewHybridSearchService(nil, HybridSearchConfig{VectorWeight: 1, LexicalWeight: 1})
+	service.index, service.builtAt = idx, time.Now()
+
+	hits := []vectorHit{{collection: "hunt_chat_documents", point: &qdrant.ScoredPoint{
+		Id:    &qdrant.PointId{PointIdOptions: &qdrant.PointId_Uuid{Uuid: "report-1"}},
+		Score: 0.9,
+	}}}
+	results, err := service.fuse(context.Background(), "P001 売上 急増", 5, hits, nil, isAnalysisReportDocument)
+	if err != nil {
+		t.Fatalf("fuse failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected the two reports only, got %+v", results)
+	}
+	top := results[0]
+	if top.ID != "report-1" || top.Collection != "hunt_chat_documents" || top.VectorRank != 1 || top.LexicalRank != 1 || top.FusedScore != 1 {
+		t.Errorf("Expected report-1 to be fused from both rankings, got %+v", top)
+	}
+}
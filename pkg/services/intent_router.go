@@ -0,0 +1,85 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	config "hunt-chat-api/configs"
+	"hunt-chat-api/pkg/models"
+)
+
+// IntentRouter チャットメッセージの意図を分類し、検索プランを決定する
+type IntentRouter struct {
+	routes *config.IntentRoutesConfig
+}
+
+// NewIntentRouter 新しい意図ルーターを作成（設定ファイルが読めない場合はデフォルト設定を使用）
+func NewIntentRouter() *IntentRouter {
+	routes, err := config.LoadIntentRoutes()
+	if err != nil {
+		log.Printf("Warning: Failed to load intent routes from YAML, using fallback: %v", err)
+		routes = config.DefaultIntentRoutes()
+	}
+	return NewIntentRouterWithRoutes(routes)
+}
+
+// NewIntentRouterWithRoutes 指定した設定で意図ルーターを作成
+func NewIntentRouterWithRoutes(routes *config.IntentRoutesConfig) *IntentRouter {
+	return &IntentRouter{routes: routes}
+}
+
+// Routes ルーティング設定を返す（分類プロンプトの構築に使用）
+func (r *IntentRouter) Routes() *config.IntentRoutesConfig {
+	return r.routes
+}
+
+// Route 分類された意図（LLM）とメッセージのキーワードからルートを決定する
+// LLMの意図が設定に存在すればそれを採用し、なければキーワード一致数が最大の意図、どれにも一致しなければデフォルトを使う
+func (r *IntentRouter) Route(classifiedIntent string, message string) models.ChatRoute {
+	lowerMsg := strings.ToLower(message)
+
+	var bestRoute *config.IntentRoute
+	var bestMatched []string
+	for i := range r.routes.Intents {
+		route := &r.routes.Intents[i]
+		var matched []string
+		for _, keyword := range route.Keywords {
+			if strings.Contains(lowerMsg, strings.ToLower(keyword)) {
+				matched = append(matched, keyword)
+			}
+		}
+		if len(matched) > len(bestMatched) {
+			bestRoute = route
+			bestMatched = matched
+		}
+	}
+
+	if route := r.routes.Find(classifiedIntent); route != nil {
+		return newChatRoute(route, "llm", nil)
+	}
+	if bestRoute != nil {
+		return newChatRoute(bestRoute, "keyword", bestMatched)
+	}
+	return newChatRoute(r.routes.Find(r.routes.DefaultIntent), "default", nil)
+}
+
+// newChatRoute 設定の意図定義からレスポンス用のルート情報を作成
+func newChatRoute(route *config.IntentRoute, method string, matchedKeywords []string) models.ChatRoute {
+	plan := route.Retrieval
+	return models.ChatRoute{
+		Intent:          route.Name,
+		Label:           route.Label,
+		Action:          route.Action,
+		Tool:            route.Tool,
+		Method:          method,
+		MatchedKeywords: matchedKeywords,
+		Plan: models.RetrievalPlan{
+			ChatHistory:      plan.ChatHistory,
+			Documents:        plan.Documents,
+			Reports:          plan.Reports,
+			AnomalyResponses: plan.AnomalyResponses,
+			WeatherDays:      plan.WeatherDays,
+			WeatherRegion:    plan.WeatherRegion,
+		},
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	config "hunt-chat-api/configs"
+)
+
+func TestIntentRouterRoute(t *testing.T) {
+	router := NewIntentRouterWithRoutes(&config.IntentRoutesConfig{
+		DefaultIntent: "docs",
+		Intents: []config.IntentRoute{
+			{Name: "docs", Action: "answer_from_docs", Retrieval: config.RetrievalPlan{Documents: 8}},
+			{Name: "reports", Action: "query_reports", Keywords: []string{"分析", "レポート"}, Retrieval: config.RetrievalPlan{Reports: 4}},
+			{Name: "weather", Action: "lookup_weather", Keywords: []string{"天気"}, Retrieval: config.RetrievalPlan{WeatherDays: 7}},
+			{Name: "forecast", Action: "run_forecast", Tool: "forecast_product_demand", Keywords: []string{"予測"}},
+		},
+	})
+
+	testCases := []struct {
+		classified string
+		message    string
+		intent     string
+		method     string
+	}{
+		{"weather", "先月のレポートを見せて", "weather", "llm"},
+		{"", "先月の分析レポートを見せて", "reports", "keyword"},
+		{"unknown", "明日の天気は？", "weather", "keyword"},
+		{"", "こんにちは", "docs", "default"},
+	}
+
+	for _, tc := range testCases {
+		route := router.Route(tc.classified, tc.message)
+		if route.Intent != tc.intent || route.Method != tc.method {
+			t.Errorf("Route(%q, %q) = (%s, %s), expected (%s, %s)",
+				tc.classified, tc.message, route.Intent, route.Method, tc.intent, tc.method)
+		}
+	}
+
+	if route := router.Route("", "来週の需要を予測して"); route.Tool != "forecast_product_demand" {
+		t.Errorf("Expected the forecast route to force its tool, got %q", route.Tool)
+	}
+
+	if plan := router.Route("reports", "").Plan; plan.Reports != 4 {
+		t.Errorf("Expected reports plan to have 4 reports, got %d", plan.Reports)
+	}
+}
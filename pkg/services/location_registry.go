@@ -0,0 +1,403 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// DefaultSiteID 地点を指定しない既存API（鈴鹿市向けの月次サマリーなど）で使う地点
+const DefaultSiteID = "suzuka"
+
+// ErrSiteNotFound 指定された店舗・拠点が登録されていない
+var ErrSiteNotFound = errors.New("指定された店舗・拠点が見つかりません")
+
+// ErrSiteAlreadyExists 同じIDの店舗・拠点が既に登録されている
+var ErrSiteAlreadyExists = errors.New("店舗・拠点は既に登録されています")
+
+// ErrInvalidSite 店舗・拠点の指定が不正
+var ErrInvalidSite = errors.New("店舗・拠点の指定が不正です")
+
+var (
+	// siteIDPattern 店舗・拠点IDの形式（数字の地域コードと区別するため英字で始める）
+	siteIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,63}$`)
+	// municipalityCodePattern 全国地方公共団体コード（検査数字付きの6桁も可）
+	municipalityCodePattern = regexp.MustCompile(`^\d{5,6}$`)
+)
+
+// AMeDASStation アメダス観測所
+type AMeDASStation struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	EnName   string  `json:"en_name,omitempty"`
+	Type     string  `json:"type,omitempty"` // 観測所の種別（気象庁の amedastable.json の type）
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+	Altitude float64 `json:"altitude"`
+}
+
+// jmaAMeDASTableEntry 気象庁の amedastable.json の1観測所（緯度・経度は度と分の組）
+type jmaAMeDASTableEntry struct {
+	Type   string     `json:"type"`
+	Lat    [2]float64 `json:"lat"`
+	Lon    [2]float64 `json:"lon"`
+	Alt    float64    `json:"alt"`
+	KjName string     `json:"kjName"`
+	EnName string     `json:"enName"`
+}
+
+// LoadAMeDASStations 気象庁の amedastable.json 形式の観測所一覧を読み込む
+func LoadAMeDASStations(path string) ([]AMeDASStation, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("観測所一覧を読み込めません: %w", err)
+	}
+	var table map[string]jmaAMeDASTableEntry
+	if err := json.Unmarshal(raw, &table); err != nil {
+		return nil, fmt.Errorf("観測所一覧の形式が不正です: %w", err)
+	}
+
+	stations := make([]AMeDASStation, 0, len(table))
+	for code, entry := range table {
+		stations = append(stations, AMeDASStation{
+			Code:     code,
+			Name:     entry.KjName,
+			EnName:   entry.EnName,
+			Type:     entry.Type,
+			Lat:      entry.Lat[0] + entry.Lat[1]/60,
+			Lon:      entry.Lon[0] + entry.Lon[1]/60,
+			Altitude: entry.Alt,
+		})
+	}
+	sort.Slice(stations, func(i, j int) bool { return stations[i].Code < stations[j].Code })
+	return stations, nil
+}
+
+// LocationRegistry 店舗・拠点の登録簿（データファイルから読み込み、変更はファイルに書き戻す）
+type LocationRegistry struct {
+	mu        sync.RWMutex
+	path      string
+	sites     map[string]models.Site
+	stations  []AMeDASStation
+	now       func() time.Time
+	listeners []func(siteID string)
+}
+
+// NewLocationRegistry 店舗・拠点の登録簿を作成
+// sitesPath が存在しない場合は空の登録簿から始め、最初の登録時に作成する
+func NewLocationRegistry(sitesPath, stationsPath string) (*LocationRegistry, error) {
+	registry := &LocationRegistry{
+		path:  sitesPath,
+		sites: make(map[string]models.Site),
+		now:   time.Now,
+	}
+
+	if stationsPath != "" {
+		stations, err := LoadAMeDASStations(stationsPath)
+		if err != nil {
+			return nil, err
+		}
+		registry.stations = stations
+	}
+
+	if sitesPath == "" {
+		return registry, nil
+	}
+	raw, err := os.ReadFile(sitesPath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("⚠️ 店舗・拠点のデータファイルがないため、空の登録簿から始めます: %s", sitesPath)
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("店舗・拠点のデータファイルを読み込めません: %w", err)
+	}
+	var sites []models.Site
+	if err := json.Unmarshal(raw, &sites); err != nil {
+		return nil, fmt.Errorf("店舗・拠点のデータファイルの形式が不正です: %w", err)
+	}
+	for _, site := range sites {
+		if err := registry.resolve(&site); err != nil {
+			return nil, fmt.Errorf("店舗・拠点 %s: %w", site.ID, err)
+		}
+		registry.sites[site.ID] = site
+	}
+	log.Printf("📍 店舗・拠点を読み込みました: %d件（観測所 %d件）", len(registry.sites), len(registry.stations))
+	return registry, nil
+}
+
+// List 登録されている店舗・拠点（ID順）
+func (r *LocationRegistry) List() []models.Site {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sites := make([]models.Site, 0, len(r.sites))
+	for _, site := range r.sites {
+		sites = append(sites, site)
+	}
+	sort.Slice(sites, func(i, j int) bool { return sites[i].ID < sites[j].ID })
+	return sites
+}
+
+// Get 店舗・拠点を取得
+func (r *LocationRegistry) Get(id string) (models.Site, bool) {
+	if r == nil {
+		return models.Site{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	site, ok := r.sites[id]
+	return site, ok
+}
+
+// Create 店舗・拠点を登録
+func (r *LocationRegistry) Create(req models.SiteRequest) (*models.Site, error) {
+	if !siteIDPattern.MatchString(req.ID) {
+		return nil, fmt.Errorf("%w: id は英小文字で始まる64文字以内の英小文字・数字・-・_ で指定してください", ErrInvalidSite)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.sites[req.ID]; exists {
+		return nil, fmt.Errorf("%w: %s", ErrSiteAlreadyExists, req.ID)
+	}
+
+	now := r.now().Format(time.RFC3339)
+	site := models.Site{ID: req.ID, CreatedAt: now, UpdatedAt: now}
+	if err := r.apply(&site, req); err != nil {
+		return nil, err
+	}
+	r.sites[site.ID] = site
+	if err := r.save(); err != nil {
+		delete(r.sites, site.ID)
+		return nil, err
+	}
+	return &site, nil
+}
+
+// Update 店舗・拠点を更新（座標を変えた場合、手動指定でない観測所は再計算する）
+func (r *LocationRegistry) Update(id string, req models.SiteRequest) (*models.Site, error) {
+	r.mu.Lock()
+	existing, ok := r.sites[id]
+	if !ok {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrSiteNotFound, id)
+	}
+
+	site := existing
+	site.UpdatedAt = r.now().Format(time.RFC3339)
+	if err := r.apply(&site, req); err != nil {
+		r.mu.Unlock()
+		return nil, err
+	}
+	r.sites[id] = site
+	if err := r.save(); err != nil {
+		r.sites[id] = existing
+		r.mu.Unlock()
+		return nil, err
+	}
+	listeners := r.listeners
+	r.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(id)
+	}
+	return &site, nil
+}
+
+// Delete 店舗・拠点を削除
+func (r *LocationRegistry) Delete(id string) error {
+	r.mu.Lock()
+	existing, ok := r.sites[id]
+	if !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrSiteNotFound, id)
+	}
+	delete(r.sites, id)
+	if err := r.save(); err != nil {
+		r.sites[id] = existing
+		r.mu.Unlock()
+		return err
+	}
+	listeners := r.listeners
+	r.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(id)
+	}
+	return nil
+}
+
+// OnChange 店舗・拠点の更新・削除時に呼ばれる処理を登録（キャッシュの破棄など）
+func (r *LocationRegistry) OnChange(listener func(siteID string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// Stations 観測所一覧
+func (r *LocationRegistry) Stations() []AMeDASStation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]AMeDASStation(nil), r.stations...)
+}
+
+// NearestStation 座標から最も近い観測所と距離（km）を求める
+func (r *LocationRegistry) NearestStation(lat, lon float64) (AMeDASStation, float64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.nearestStation(lat, lon)
+}
+
+// StationsNear 座標から maxKm 以内の観測所を近い順に返す
+func (r *LocationRegistry) StationsNear(lat, lon, maxKm float64) []AMeDASStation {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	type candidate struct {
+		station  AMeDASStation
+		distance float64
+	}
+	var candidates []candidate
+	for _, station := range r.stations {
+		if distance := haversineKm(lat, lon, station.Lat, station.Lon); distance <= maxKm {
+			candidates = append(candidates, candidate{station, distance})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	stations := make([]AMeDASStation, 0, len(candidates))
+	for _, c := range candidates {
+		stations = append(stations, c.station)
+	}
+	return stations
+}
+
+func (r *LocationRegistry) nearestStation(lat, lon float64) (AMeDASStation, float64, bool) {
+	var nearest AMeDASStation
+	best := math.Inf(1)
+	for _, station := range r.stations {
+		if distance := haversineKm(lat, lon, station.Lat, station.Lon); distance < best {
+			nearest, best = station, distance
+		}
+	}
+	if math.IsInf(best, 1) {
+		return AMeDASStation{}, 0, false
+	}
+	return nearest, roundTo(best, 1), true
+}
+
+// apply リクエストの内容を反映して予報区・観測所を求める（呼び出し元でロックを取得済み）
+func (r *LocationRegistry) apply(site *models.Site, req models.SiteRequest) error {
+	if req.Lat == nil || req.Lon == nil {
+		return fmt.Errorf("%w: lat と lon を指定してください", ErrInvalidSite)
+	}
+	moved := site.Lat != *req.Lat || site.Lon != *req.Lon
+
+	site.Name = req.Name
+	site.Lat, site.Lon = *req.Lat, *req.Lon
+	site.MunicipalityCode = req.MunicipalityCode
+	site.ForecastOfficeCode = req.ForecastOfficeCode
+	site.AreaCode = req.AreaCode
+	switch {
+	case req.StationCode != "":
+		site.StationCode, site.StationPinned = req.StationCode, true
+	case site.StationPinned || moved:
+		site.StationCode, site.StationName, site.StationDistanceKm, site.StationPinned = "", "", 0, false
+	}
+	return r.resolve(site)
+}
+
+// resolve 座標・コードを検証し、省略された予報区と観測所を補う（呼び出し元でロックを取得済み）
+func (r *LocationRegistry) resolve(site *models.Site) error {
+	if site.Name == "" {
+		return fmt.Errorf("%w: name を指定してください", ErrInvalidSite)
+	}
+	if site.Lat < -90 || site.Lat > 90 || site.Lon < -180 || site.Lon > 180 || (site.Lat == 0 && site.Lon == 0) {
+		return fmt.Errorf("%w: lat・lon の値が範囲外です（%.4f, %.4f）", ErrInvalidSite, site.Lat, site.Lon)
+	}
+	if site.MunicipalityCode != "" && !municipalityCodePattern.MatchString(site.MunicipalityCode) {
+		return fmt.Errorf("%w: municipality_code は5桁（検査数字付きの場合は6桁）の数字で指定してください", ErrInvalidSite)
+	}
+	if site.ForecastOfficeCode == "" {
+		site.ForecastOfficeCode = forecastOfficeForMunicipality(site.MunicipalityCode)
+	}
+	if site.ForecastOfficeCode == "" {
+		return fmt.Errorf("%w: forecast_office_code または municipality_code を指定してください", ErrInvalidSite)
+	}
+
+	if site.StationCode != "" {
+		for _, station := range r.stations {
+			if station.Code == site.StationCode {
+				site.StationName = station.Name
+				site.StationDistanceKm = roundTo(haversineKm(site.Lat, site.Lon, station.Lat, station.Lon), 1)
+				return nil
+			}
+		}
+		if len(r.stations) > 0 && site.StationPinned {
+			return fmt.Errorf("%w: 観測所 %s は観測所一覧にありません", ErrInvalidSite, site.StationCode)
+		}
+		return nil
+	}
+	if station, distance, ok := r.nearestStation(site.Lat, site.Lon); ok {
+		site.StationCode, site.StationName, site.StationDistanceKm = station.Code, station.Name, distance
+	}
+	return nil
+}
+
+// save 登録簿をデータファイルに書き戻す（呼び出し元でロックを取得済み）
+func (r *LocationRegistry) save() error {
+	if r.path == "" {
+		return nil
+	}
+	sites := make([]models.Site, 0, len(r.sites))
+	for _, site := range r.sites {
+		sites = append(sites, site)
+	}
+	sort.Slice(sites, func(i, j int) bool { return sites[i].ID < sites[j].ID })
+
+	raw, err := json.MarshalIndent(sites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("店舗・拠点のJSON変換に失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("店舗・拠点のデータファイルを作成できません: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, append(raw, '\n'), 0o644); err != nil {
+		return fmt.Errorf("店舗・拠点のデータファイルを書き込めません: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("店舗・拠点のデータファイルを書き込めません: %w", err)
+	}
+	return nil
+}
+
+// multiOfficePrefectures 府県予報区が複数ある道県（全国地方公共団体コードだけでは予報区が決まらない）
+var multiOfficePrefectures = map[string]bool{"01": true, "46": true, "47": true}
+
+// forecastOfficeForMunicipality 全国地方公共団体コードの都道府県部分から府県予報区を求める
+// 北海道・鹿児島県・沖縄県は予報区が複数あるため空を返す
+func forecastOfficeForMunicipality(code string) string {
+	if len(code) < 2 || multiOfficePrefectures[code[:2]] {
+		return ""
+	}
+	return code[:2] + "0000"
+}
+
+// haversineKm 2点間の大円距離（km）
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
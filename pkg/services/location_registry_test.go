@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestLocationRegistryLoadsSitesAndNearestStation(t *testing.T) {
+	registry, err := NewLocationRegistry("../../data/sites.json", "../../data/amedas_stations.json")
+	if err != nil {
+		t.Fatalf("NewLocationRegistry failed: %v", err)
+	}
+
+	site, ok := registry.Get(DefaultSiteID)
+	if !ok {
+		t.Fatalf("Expected %s to be registered", DefaultSiteID)
+	}
+	if site.ForecastOfficeCode != "240000" || site.StationCode != "53041" || site.StationDistanceKm <= 0 {
+		t.Errorf("Unexpected resolution for %s: %+v", DefaultSiteID, site)
+	}
+
+	station, distance, ok := registry.NearestStation(35.6895, 139.6917)
+	if !ok || station.Code != "44132" || distance > 10 {
+		t.Errorf("Nearest station for Shinjuku = %s (%.1f km)", station.Code, distance)
+	}
+}
+
+func TestLocationRegistryCRUDPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sites.json")
+	registry, err := NewLocationRegistry(path, "../../data/amedas_stations.json")
+	if err != nil {
+		t.Fatalf("NewLocationRegistry failed: %v", err)
+	}
+	var changed []string
+	registry.OnChange(func(siteID string) { changed = append(changed, siteID) })
+
+	lat, lon := 35.1709, 136.8815
+	site, err := registry.Create(models.SiteRequest{ID: "nagoya-station", Name: "名古屋駅前店", Lat: &lat, Lon: &lon, MunicipalityCode: "23100"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if site.ForecastOfficeCode != "230000" || site.StationCode != "51106" {
+		t.Errorf("Unexpected resolution: %+v", site)
+	}
+	if _, err := registry.Create(models.SiteRequest{ID: "nagoya-station", Name: "重複", Lat: &lat, Lon: &lon, MunicipalityCode: "23100"}); !errors.Is(err, ErrSiteAlreadyExists) {
+		t.Error("Expected duplicate ID to be rejected")
+	}
+	if _, err := registry.Create(models.SiteRequest{ID: "240000", Name: "数字", Lat: &lat, Lon: &lon, MunicipalityCode: "23100"}); !errors.Is(err, ErrInvalidSite) {
+		t.Error("Expected numeric ID to be rejected")
+	}
+	if _, err := registry.Create(models.SiteRequest{ID: "naha", Name: "那覇店", Lat: &lat, Lon: &lon, MunicipalityCode: "47201"}); !errors.Is(err, ErrInvalidSite) {
+		t.Error("Expected forecast office to be required for Okinawa")
+	}
+
+	// 観測所を手動で指定すると座標を変えても維持される
+	site, err = registry.Update("nagoya-station", models.SiteRequest{Name: "名古屋駅前店", Lat: &lat, Lon: &lon, MunicipalityCode: "23100", StationCode: "52586"})
+	if err != nil || site.StationCode != "52586" || !site.StationPinned {
+		t.Fatalf("Pinned update = %+v, err %v", site, err)
+	}
+	moved := 35.18
+	site, err = registry.Update("nagoya-station", models.SiteRequest{Name: "名古屋駅前店", Lat: &moved, Lon: &lon, MunicipalityCode: "23100", StationCode: "52586"})
+	if err != nil || site.StationCode != "52586" {
+		t.Fatalf("Moved pinned update = %+v, err %v", site, err)
+	}
+	site, err = registry.Update("nagoya-station", models.SiteRequest{Name: "名古屋駅前店", Lat: &moved, Lon: &lon, MunicipalityCode: "23100"})
+	if err != nil || site.StationCode != "51106" || site.StationPinned {
+		t.Fatalf("Unpinned update = %+v, err %v", site, err)
+	}
+	if _, err := registry.Update("missing", models.SiteRequest{Name: "x", Lat: &lat, Lon: &lon}); !errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("Expected ErrSiteNotFound, got %v", err)
+	}
+
+	restored, err := NewLocationRegistry(path, "")
+	if err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if site, ok := restored.Get("nagoya-station"); !ok || site.Lat != moved || site.StationCode != "51106" {
+		t.Errorf("Site was not persisted: %+v", site)
+	}
+
+	if err := registry.Delete("nagoya-station"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if len(registry.List()) != 0 {
+		t.Error("Expected registry to be empty after delete")
+	}
+	if len(changed) != 4 {
+		t.Errorf("Expected 4 change notifications, got %v", changed)
+	}
+	if data, err := os.ReadFile(path); err != nil || len(data) == 0 {
+		t.Errorf("Expected sites file to be written, err %v", err)
+	}
+}
+
+func TestWeatherServiceResolvesSiteID(t *testing.T) {
+	registry, err := NewLocationRegistry("", "../../data/amedas_stations.json")
+	if err != nil {
+		t.Fatalf("NewLocationRegistry failed: %v", err)
+	}
+	lat, lon := 34.882, 136.5856
+	if _, err := registry.Create(models.SiteRequest{ID: "suzuka", Name: "鈴鹿市", Lat: &lat, Lon: &lon, MunicipalityCode: "24207", AreaCode: "240000a"}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	provider := &fakeWeatherProvider{forecasts: todayForecasts("240000"), historical: generateMockHistoricalData}
+	service := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}, Sites: registry})
+
+	if _, err := service.GetHistoricalWeatherData("suzuka", cacheTestDay(2024, 5, 1), cacheTestDay(2024, 5, 3)); err != nil {
+		t.Fatalf("GetHistoricalWeatherData failed: %v", err)
+	}
+	location := provider.locations[0]
+	if location.SiteID != "suzuka" || location.RegionCode != "240000" || location.Lat != lat || location.StationCode != "53041" {
+		t.Errorf("Unexpected location: %+v", location)
+	}
+
+	// 店舗・拠点の区域の予報のみを返す
+	forecasts, err := service.GetDailyForecasts("suzuka")
+	if err != nil || len(forecasts) != 2 {
+		t.Fatalf("GetDailyForecasts = %d forecasts, err %v", len(forecasts), err)
+	}
+	for _, forecast := range forecasts {
+		if forecast.AreaCode != "240000a" {
+			t.Errorf("Unexpected area %s", forecast.AreaCode)
+		}
+	}
+
+	if _, err := service.ResolveLocationKey("unknown", "240000"); !errors.Is(err, ErrSiteNotFound) {
+		t.Errorf("Expected ErrSiteNotFound, got %v", err)
+	}
+	if key, err := service.ResolveLocationKey("", "130000"); err != nil || key != "130000" {
+		t.Errorf("ResolveLocationKey = %s, err %v", key, err)
+	}
+
+	// 拠点を更新するとキャッシュが破棄され、次の要求で取得し直す
+	if _, err := registry.Update("suzuka", models.SiteRequest{Name: "鈴鹿市", Lat: &lat, Lon: &lon, MunicipalityCode: "24207"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if _, err := service.GetHistoricalWeatherData("suzuka", cacheTestDay(2024, 5, 1), cacheTestDay(2024, 5, 3)); err != nil {
+		t.Fatalf("GetHistoricalWeatherData failed: %v", err)
+	}
+	if len(provider.historicalCalls) != 2 {
+		t.Errorf("Expected cache to be purged on update, fetches %v", provider.historicalCalls)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"strings"
 	"time"
 
@@ -27,36 +28,31 @@ func (s *StatisticsService) DetectAnomaliesWithGranularity(sales []float64, date
 		granularity = "weekly"
 	}
 
+	// 時間帯別は移動平均ではなく、同じ時刻の過去の平均と比べる
+	if granularity == "hourly" {
+		data := make([]models.WeatherSalesData, 0, len(sales))
+		for i := range sales {
+			if i < len(dates) {
+				data = append(data, models.WeatherSalesData{DateTime: dates[i], ProductID: productID, ProductName: productName, Sales: sales[i]})
+			}
+		}
+		return s.DetectHourlyAnomalies(data, productID, productName)
+	}
+
 	log.Printf("[異常検知@%s] 粒度: %s でデータを集約してから異常検知を実行します", displayName, granularity)
 
 	// 日次データの場合のみ集約が必要（週次・月次の場合は既に集約済みと仮定）
 	aggregatedSales := sales
 	aggregatedDates := dates
 
-	if granularity != "daily" && len(sales) > 0 {
-		// データを週次または月次に集約
+	if len(sales) > 0 {
+		// データを週次または月次に集約（日次でも同じ日付の行は合計する）
 		aggregatedSales, aggregatedDates = s.aggregateDataForAnomalyDetection(sales, dates, granularity)
 		log.Printf("[異常検知@%s] データを集約: %d件 → %d件", displayName, len(sales), len(aggregatedSales))
 	}
 
 	// 移動平均のウィンドウサイズを粒度に応じて調整
-	var windowSize int
-	var percentageThreshold float64
-
-	switch granularity {
-	case "daily":
-		windowSize = 30           // 30日間の移動平均
-		percentageThreshold = 0.5 // 50%の乖離
-	case "weekly":
-		windowSize = 4            // 4週間の移動平均
-		percentageThreshold = 0.4 // 40%の乖離（週次は変動が大きいため緩和）
-	case "monthly":
-		windowSize = 3            // 3ヶ月の移動平均
-		percentageThreshold = 0.3 // 30%の乖離（月次はさらに緩和）
-	default:
-		windowSize = 4
-		percentageThreshold = 0.4
-	}
+	windowSize, percentageThreshold := anomalyWindowFor(granularity)
 
 	if len(aggregatedSales) < windowSize {
 		log.Printf("[異常検知@%s] データが少なく、移動平均を計算できません（%d件 < %d件）", displayName, len(aggregatedSales), windowSize)
@@ -95,15 +91,17 @@ func (s *StatisticsService) DetectAnomaliesWithGranularity(sales []float64, date
 			}
 
 			anomalies = append(anomalies, models.AnomalyDetection{
-				Date:          aggregatedDates[i],
-				ProductID:     productID,
-				ProductName:   productName,
-				ActualValue:   currentValue,
-				ExpectedValue: mean, // 期待値として移動平均を使用
-				Deviation:     math.Abs(deviation),
-				ZScore:        zScore,
-				AnomalyType:   anomalyType,
-				Severity:      s.calculateSeverity(math.Abs(zScore)),
+				Date:             aggregatedDates[i],
+				ProductID:        productID,
+				ProductName:      productName,
+				ActualValue:      currentValue,
+				ExpectedValue:    mean, // 期待値として移動平均を使用
+				Deviation:        math.Abs(deviation),
+				ZScore:           zScore,
+				AnomalyType:      anomalyType,
+				Severity:         s.calculateSeverity(math.Abs(zScore)),
+				Granularity:      granularity,
+				ExpectationModel: "moving_average",
 			})
 		}
 	}
@@ -113,6 +111,20 @@ func (s *StatisticsService) DetectAnomaliesWithGranularity(sales []float64, date
 	return anomalies
 }
 
+// anomalyWindowFor 粒度ごとの移動平均のウィンドウサイズと、異常とみなす乖離率
+func anomalyWindowFor(granularity string) (int, float64) {
+	switch granularity {
+	case "daily":
+		return 30, 0.5 // 30日間の移動平均 / 50%の乖離
+	case "weekly":
+		return 4, 0.4 // 4週間の移動平均 / 40%の乖離（週次は変動が大きいため緩和）
+	case "monthly":
+		return 3, 0.3 // 3ヶ月の移動平均 / 30%の乖離（月次はさらに緩和）
+	default:
+		return 4, 0.4
+	}
+}
+
 // aggregateDataForAnomalyDetection 異常検知用にデータを集約
 func (s *StatisticsService) aggregateDataForAnomalyDetection(sales []float64, dates []string, granularity string) ([]float64, []string) {
 	if len(sales) != len(dates) {
@@ -131,18 +143,7 @@ func (s *StatisticsService) aggregateDataForAnomalyDetection(sales []float64, da
 			continue
 		}
 
-		var periodKey string
-		switch granularity {
-		case "weekly":
-			// 月曜始まりの週番号
-			year, week := t.ISOWeek()
-			periodKey = fmt.Sprintf("%d-W%02d", year, week)
-		case "monthly":
-			periodKey = t.Format("2006-01")
-		default:
-			periodKey = dateStr // 日次の場合はそのまま
-		}
-
+		periodKey := periodKeyFor(t, granularity)
 		if _, exists := periodMap[periodKey]; !exists {
 			periodOrder = append(periodOrder, periodKey)
 		}
@@ -169,6 +170,22 @@ func (s *StatisticsService) aggregateDataForAnomalyDetection(sales []float64, da
 	return aggregatedSales, aggregatedDates
 }
 
+// periodKeyFor 集約の期間キーを返す（週次: 2006-W01 / 月次: 2006-01 / 日次: 2006-01-02）
+func periodKeyFor(t time.Time, granularity string) string {
+	switch granularity {
+	case "weekly":
+		// 月曜始まりの週番号
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case "monthly":
+		return t.Format("2006-01")
+	case "hourly":
+		return HourKey(t)
+	default:
+		return t.Format("2006-01-02") // 日次の場合はそのまま
+	}
+}
+
 // calculateSeverity 異常の深刻度を計算
 func (s *StatisticsService) calculateSeverity(absZScore float64) string {
 	if absZScore > 4.0 {
@@ -207,10 +224,80 @@ func (s *StatisticsService) formatDateForDisplay(date string) string {
 		}
 	}
 
+	// 時間帯形式: YYYY-MM-DD HH:00
+	if t, err := ParseHourKey(date); err == nil {
+		return t.Format("2006年1月2日 15時")
+	}
+
 	// パースできない場合はそのまま返す
 	return date
 }
 
+// AttachWeatherAnomalies 日次・時間帯の異常に、その日の天候が平年と比べてどれだけ珍しかったかを付ける
+// 週次・月次の異常と、平年値と比べられない日はそのまま返す
+func (s *StatisticsService) AttachWeatherAnomalies(anomalies []models.AnomalyDetection, key string) []models.AnomalyDetection {
+	if s.weatherService == nil || len(anomalies) == 0 {
+		return anomalies
+	}
+
+	// 異常の日を旬ごとにまとめ、旬ごとに平年値と比べる
+	// （全期間をまとめて取得すると、離れた日の異常だけでも期間全体の過去の気象データを取得することになるため）
+	days := make([]string, len(anomalies))
+	type dekadRange struct{ first, last time.Time }
+	dekads := make(map[string]*dekadRange)
+	var dekadKeys []string
+	for i, anomaly := range anomalies {
+		if anomaly.Granularity != "daily" && anomaly.Granularity != "hourly" {
+			continue
+		}
+		day, err := parseAnomalyDay(anomaly.Date)
+		if err != nil {
+			continue
+		}
+		days[i] = day.Format("2006-01-02")
+		dekadStart, _ := dekadOf(day)
+		dekadKey := dekadStart.Format("2006-01-02")
+		r, ok := dekads[dekadKey]
+		if !ok {
+			dekads[dekadKey] = &dekadRange{first: day, last: day}
+			dekadKeys = append(dekadKeys, dekadKey)
+			continue
+		}
+		if day.Before(r.first) {
+			r.first = day
+		}
+		if day.After(r.last) {
+			r.last = day
+		}
+	}
+	if len(dekadKeys) == 0 {
+		return anomalies
+	}
+	sort.Strings(dekadKeys)
+
+	byDate := make(map[string]models.WeatherDayAnomaly)
+	for _, dekadKey := range dekadKeys {
+		r := dekads[dekadKey]
+		weatherAnomalies, err := s.weatherService.GetWeatherAnomalies(key, r.first, r.last, ClimatologyBaselineYears)
+		if err != nil {
+			log.Printf("⚠️ 異常の日（%s〜%s）の天候を平年値と比べられません: %v", r.first.Format("2006-01-02"), r.last.Format("2006-01-02"), err)
+			continue
+		}
+		for _, w := range weatherAnomalies {
+			byDate[w.Date] = w
+		}
+	}
+	attached := 0
+	for i := range anomalies {
+		if w, ok := byDate[days[i]]; ok && days[i] != "" {
+			anomalies[i].WeatherAnomaly = &w
+			attached++
+		}
+	}
+	log.Printf("🌡️ %d件の異常に平年と比べた天候の珍しさを付けました", attached)
+	return anomalies
+}
+
 // GenerateAIQuestion 異常値に基づいてAIが質問を生成
 func (s *StatisticsService) GenerateAIQuestion(anomaly models.AnomalyDetection) (string, []string) {
 	// 製品の表示名を決定
@@ -222,6 +309,15 @@ func (s *StatisticsService) GenerateAIQuestion(anomaly models.AnomalyDetection)
 	// 日付を読みやすい形式にフォーマット
 	formattedDate := s.formatDateForDisplay(anomaly.Date)
 
+	// 気象回帰を期待値にした異常は、気象で説明できる分を質問の前提として伝える
+	weatherAdjusted := anomaly.ExpectationModel == "weather_regression"
+	weatherConditions := "気象条件"
+	if anomaly.WeatherSummary != "" {
+		weatherConditions = fmt.Sprintf("気象条件（%s）", anomaly.WeatherSummary)
+	}
+	// 平年と比べて珍しい天候だった日は、そのことをデータに基づいて伝える
+	unusualWeather := WeatherAnomalySentence(anomaly.WeatherAnomaly)
+
 	// AIサービスが利用可能な場合は、AIに質問と選択肢を生成させる
 	if s.azureOpenAIService != nil {
 		// AnomalyDetectionをAnomalyに変換
@@ -230,6 +326,17 @@ func (s *StatisticsService) GenerateAIQuestion(anomaly models.AnomalyDetection)
 			ProductID:   displayName,
 			Description: fmt.Sprintf("売上%s (実績: %.0f, 期待値: %.0f)", anomaly.AnomalyType, anomaly.ActualValue, anomaly.ExpectedValue),
 		}
+		if weatherAdjusted {
+			anomalyForAI.Description += fmt.Sprintf(
+				"。期待値には%sによる増減 %+.0f を織り込み済みで、天候では説明できない残差は %+.0f。天候以外の要因を尋ねてください",
+				weatherConditions,
+				anomaly.WeatherContribution,
+				anomaly.Residual,
+			)
+		}
+		if unusualWeather != "" {
+			anomalyForAI.Description += "。" + strings.TrimSuffix(unusualWeather, "。")
+		}
 
 		result, err := s.azureOpenAIService.GenerateQuestionAndChoicesFromAnomaly(anomalyForAI)
 		if err == nil && result != nil && result.Question != "" {
@@ -260,6 +367,8 @@ func (s *StatisticsService) GenerateAIQuestion(anomaly models.AnomalyDetection)
 		)
 	}
 
+	question += unusualWeather
+
 	defaultChoices := []string{
 		"キャンペーン・販促活動",
 		"天候の影響",
@@ -268,5 +377,21 @@ func (s *StatisticsService) GenerateAIQuestion(anomaly models.AnomalyDetection)
 		"その他（自由記述）",
 	}
 
+	if weatherAdjusted {
+		question += fmt.Sprintf(
+			"なお、%sで説明できる %+.0f は期待値に織り込み済みで、それを除いても %+.0f の差が残っています。",
+			weatherConditions,
+			anomaly.WeatherContribution,
+			anomaly.Residual,
+		)
+		// 天候の影響はすでに差し引いているため選択肢から外す
+		defaultChoices = []string{
+			"キャンペーン・販促活動",
+			"競合他社の動き",
+			"特に思い当たる節はない",
+			"その他（自由記述）",
+		}
+	}
+
 	return question, defaultChoices
 }
@@ -0,0 +1,271 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// regimeShiftMinRelativeChange 変化点として報告する最小の水準変化（直前の区間の平均に対する割合）
+const regimeShiftMinRelativeChange = 0.1
+
+// regimeMinTrainingDays 最新のレジームだけで学習する場合に必要な最小日数（予測の最低データ数と同じ）
+const regimeMinTrainingDays = 14
+
+// defaultRegimeMinSegment 粒度ごとの区間の最小期間数（短すぎる区間を一時的な異常と区別するため）
+func defaultRegimeMinSegment(granularity string) int {
+	if granularity == "daily" {
+		return 14
+	}
+	return 3
+}
+
+// DetectChangePoints PELT法で平均の変化点を検出し、新しい区間が始まるインデックスを昇順で返す
+// コストは区間内の二乗誤差をノイズの分散で正規化したもの、ペナルティはBIC（変化点1つにつき位置と平均の2パラメータ × ln n）
+// 単発の外れ値を短い区間として切り出さないよう、幅3の移動中央値で平滑化してから検出する（段差の位置は保たれる）
+func DetectChangePoints(values []float64, minSegment int) []int {
+	n := len(values)
+	if minSegment < 1 {
+		minSegment = 1
+	}
+	if n < 2*minSegment {
+		return nil
+	}
+	values = runningMedian3(values)
+
+	variance := changePointNoiseVariance(values)
+	if variance <= 0 {
+		return nil
+	}
+	penalty := 2 * math.Log(float64(n))
+
+	// 累積和で任意区間のコストをO(1)で計算
+	sum := make([]float64, n+1)
+	sumSq := make([]float64, n+1)
+	for i, v := range values {
+		sum[i+1] = sum[i] + v
+		sumSq[i+1] = sumSq[i] + v*v
+	}
+	cost := func(s, t int) float64 {
+		length := float64(t - s)
+		segmentSum := sum[t] - sum[s]
+		return (sumSq[t] - sumSq[s] - segmentSum*segmentSum/length) / variance
+	}
+
+	best := make([]float64, n+1) // best[t]: values[0:t] を分割したときの最小コスト
+	last := make([]int, n+1)     // last[t]: 最小コストを与える最後の区間の開始位置
+	best[0] = -penalty
+	candidates := []int{0}
+	for t := minSegment; t <= n; t++ {
+		if s := t - minSegment; s >= minSegment {
+			candidates = append(candidates, s)
+		}
+
+		best[t] = math.Inf(1)
+		for _, s := range candidates {
+			if c := best[s] + cost(s, t) + penalty; c < best[t] {
+				best[t] = c
+				last[t] = s
+			}
+		}
+
+		// 以降の時点でも最適になり得ない候補を枝刈り
+		kept := candidates[:0]
+		for _, s := range candidates {
+			if best[s]+cost(s, t) <= best[t] {
+				kept = append(kept, s)
+			}
+		}
+		candidates = kept
+	}
+
+	var points []int
+	for t := n; t > 0; t = last[t] {
+		if last[t] > 0 {
+			points = append(points, last[t])
+		}
+	}
+	sort.Ints(points)
+	return points
+}
+
+// runningMedian3 幅3の移動中央値（両端はそのまま）
+func runningMedian3(values []float64) []float64 {
+	smoothed := append([]float64(nil), values...)
+	for i := 1; i+1 < len(values); i++ {
+		smoothed[i] = medianOf(values[i-1 : i+2])
+	}
+	return smoothed
+}
+
+// changePointNoiseVariance 隣接差分のMADからノイズの分散を推定（水準の変化そのものに影響されにくい）
+func changePointNoiseVariance(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	diffs := make([]float64, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		diffs[i-1] = values[i] - values[i-1]
+	}
+
+	deviations := make([]float64, len(diffs))
+	median := medianOf(diffs)
+	for i, d := range diffs {
+		deviations[i] = math.Abs(d - median)
+	}
+	sigma := 1.4826 * medianOf(deviations) / math.Sqrt2
+	if sigma <= 0 {
+		// ノイズがほとんどない系列は差分の標準偏差で代用
+		sigma = calculateStandardDeviation(diffs) / math.Sqrt2
+	}
+	return sigma * sigma
+}
+
+// medianOf 中央値（元のスライスは変更しない）
+func medianOf(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// DetectRegimeShifts 売上の水準が持続的に変化した時点を検出する（minSegmentが0以下の場合は粒度ごとの既定値）
+// 部分的な週・月の影響を受けないよう、各期間の1日あたり平均で比較する
+func (s *StatisticsService) DetectRegimeShifts(sales []float64, dates []string, productID, productName, granularity string, minSegment int) []models.RegimeShift {
+	if granularity == "" {
+		granularity = "weekly"
+	}
+	if minSegment <= 0 {
+		minSegment = defaultRegimeMinSegment(granularity)
+	}
+
+	values, keys, starts := aggregateDailyAverages(sales, dates, granularity)
+	points := DetectChangePoints(values, minSegment)
+
+	shifts := make([]models.RegimeShift, 0, len(points))
+	for i, point := range points {
+		previous := 0
+		if i > 0 {
+			previous = points[i-1]
+		}
+		next := len(values)
+		if i+1 < len(points) {
+			next = points[i+1]
+		}
+
+		before := calculateMean(values[previous:point])
+		after := calculateMean(values[point:next])
+		magnitude := after - before
+		if before > 0 && math.Abs(magnitude)/before < regimeShiftMinRelativeChange {
+			continue
+		}
+
+		shift := models.RegimeShift{
+			Date:          keys[point],
+			StartDate:     starts[point],
+			ProductID:     productID,
+			ProductName:   productName,
+			Granularity:   granularity,
+			BeforeMean:    roundTo(before, 2),
+			AfterMean:     roundTo(after, 2),
+			Magnitude:     roundTo(magnitude, 2),
+			Direction:     "上昇",
+			BeforePeriods: point - previous,
+			AfterPeriods:  next - point,
+		}
+		if magnitude < 0 {
+			shift.Direction = "下降"
+		}
+		if before > 0 {
+			shift.MagnitudePercent = roundTo(magnitude/before*100, 1)
+		}
+		shifts = append(shifts, shift)
+	}
+
+	displayName := productName
+	if displayName == "" {
+		displayName = productID
+	}
+	log.Printf("[変化点検出@%s] 粒度: %s / %d期間から %d 件の水準変化を検出しました", displayName, granularity, len(values), len(shifts))
+	return shifts
+}
+
+// TrimToLatestRegime 最新の水準変化以降のデータだけを返す（変化がない・残りが短すぎる場合は元のデータと nil を返す）
+func (s *StatisticsService) TrimToLatestRegime(productID, productName string, history []models.SalesDataPoint) ([]models.SalesDataPoint, *models.RegimeShift) {
+	sales := make([]float64, len(history))
+	dates := make([]string, len(history))
+	for i, point := range history {
+		sales[i] = point.Sales
+		dates[i] = point.Date
+	}
+
+	shifts := s.DetectRegimeShifts(sales, dates, productID, productName, "weekly", 0)
+	if len(shifts) == 0 {
+		return history, nil
+	}
+	latest := shifts[len(shifts)-1]
+
+	trimmed := make([]models.SalesDataPoint, 0, len(history))
+	for _, point := range history {
+		if point.Date >= latest.StartDate {
+			trimmed = append(trimmed, point)
+		}
+	}
+	if len(trimmed) < regimeMinTrainingDays {
+		log.Printf("⚠️ 最新のレジーム（%s以降）は%d日分しかないため、全期間で学習します", latest.StartDate, len(trimmed))
+		return history, nil
+	}
+	return trimmed, &latest
+}
+
+// aggregateDailyAverages 日付順に並べて期間ごとの1日あたり平均・期間キー・期間の初日を返す
+func aggregateDailyAverages(sales []float64, dates []string, granularity string) ([]float64, []string, []string) {
+	type dailyPoint struct {
+		date  time.Time
+		value float64
+	}
+	points := make([]dailyPoint, 0, len(dates))
+	for i, dateStr := range dates {
+		if i >= len(sales) {
+			break
+		}
+		t, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			log.Printf("[警告] 日付のパースに失敗: %s", dateStr)
+			continue
+		}
+		points = append(points, dailyPoint{date: t, value: sales[i]})
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].date.Before(points[j].date) })
+
+	var values []float64
+	var keys, starts []string
+	var total float64
+	var count int
+	for _, point := range points {
+		key := periodKeyFor(point.date, granularity)
+		if len(keys) == 0 || keys[len(keys)-1] != key {
+			if count > 0 {
+				values = append(values, total/float64(count))
+			}
+			keys = append(keys, key)
+			starts = append(starts, point.date.Format("2006-01-02"))
+			total, count = 0, 0
+		}
+		total += point.value
+		count++
+	}
+	if count > 0 {
+		values = append(values, total/float64(count))
+	}
+	return values, keys, starts
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// stepSeries level1 から level2 へ change 番目で切り替わる、決まったノイズを含む系列
+func stepSeries(n, change int, level1, level2 float64) []float64 {
+	noise := []float64{3, -2, 4, -5, 1, -1, 2, -3, 5, -4}
+	values := make([]float64, n)
+	for i := range values {
+		level := level1
+		if i >= change {
+			level = level2
+		}
+		values[i] = level + noise[i%len(noise)]
+	}
+	return values
+}
+
+func TestDetectChangePoints(t *testing.T) {
+	points := DetectChangePoints(stepSeries(40, 25, 100, 150), 3)
+	if len(points) != 1 || points[0] != 25 {
+		t.Fatalf("Change points = %v, expected [25]", points)
+	}
+
+	// 2回の水準変化
+	values := append(stepSeries(20, 20, 100, 100), stepSeries(30, 15, 60, 120)...)
+	if points := DetectChangePoints(values, 3); len(points) != 2 || points[0] != 20 || points[1] != 35 {
+		t.Errorf("Change points = %v, expected [20 35]", points)
+	}
+
+	// 水準が変わらなければ変化点はない
+	if points := DetectChangePoints(stepSeries(40, 40, 100, 100), 3); len(points) != 0 {
+		t.Errorf("Flat series change points = %v", points)
+	}
+
+	// 単発の外れ値は最小区間長より短いため変化点にならない
+	spike := stepSeries(30, 30, 100, 100)
+	spike[15] = 400
+	if points := DetectChangePoints(spike, 3); len(points) != 0 {
+		t.Errorf("Spike change points = %v", points)
+	}
+}
+
+func TestDetectRegimeShifts(t *testing.T) {
+	s := &StatisticsService{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // 月曜日
+	var sales []float64
+	var dates []string
+	// 10週間は1日100個、新店舗の開店後の8週間は1日130個
+	for i, v := range stepSeries(18*7, 70, 100, 130) {
+		sales = append(sales, v)
+		dates = append(dates, start.AddDate(0, 0, i).Format("2006-01-02"))
+	}
+
+	shifts := s.DetectRegimeShifts(sales, dates, "P001", "飲料A", "weekly", 0)
+	if len(shifts) != 1 {
+		t.Fatalf("Shifts = %+v, expected one shift", shifts)
+	}
+	shift := shifts[0]
+	if shift.StartDate != "2024-03-11" || shift.Date != "2024-W11" || shift.Direction != "上昇" {
+		t.Errorf("Shift = %+v", shift)
+	}
+	if math.Abs(shift.BeforeMean-100) > 1 || math.Abs(shift.AfterMean-130) > 1 || math.Abs(shift.MagnitudePercent-30) > 1.5 {
+		t.Errorf("Shift means = %+v", shift)
+	}
+	if shift.BeforePeriods != 10 || shift.AfterPeriods != 8 {
+		t.Errorf("Shift periods = %d / %d", shift.BeforePeriods, shift.AfterPeriods)
+	}
+
+	// 小さな変化は報告しない
+	var flatSales []float64
+	for i := range dates {
+		flatSales = append(flatSales, 100+float64(i%3))
+	}
+	flatSales[100] = 104
+	if shifts := s.DetectRegimeShifts(flatSales, dates, "P001", "", "weekly", 0); len(shifts) != 0 {
+		t.Errorf("Flat shifts = %+v", shifts)
+	}
+}
+
+func TestTrimToLatestRegime(t *testing.T) {
+	s := &StatisticsService{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var history []models.SalesDataPoint
+	for i, v := range stepSeries(90, 56, 100, 70) {
+		history = append(history, models.SalesDataPoint{Date: start.AddDate(0, 0, i).Format("2006-01-02"), Sales: v})
+	}
+
+	trimmed, shift := s.TrimToLatestRegime("P001", "", history)
+	if shift == nil || shift.Direction != "下降" {
+		t.Fatalf("Shift = %+v", shift)
+	}
+	if trimmed[0].Date != shift.StartDate || len(trimmed) != 34 {
+		t.Errorf("Trimmed from %s (%d days), expected %s (34 days)", trimmed[0].Date, len(trimmed), shift.StartDate)
+	}
+
+	// 水準変化がなければ全期間を使う
+	flat := make([]models.SalesDataPoint, 0, 30)
+	for i := 0; i < 30; i++ {
+		flat = append(flat, models.SalesDataPoint{Date: start.AddDate(0, 0, i).Format("2006-01-02"), Sales: 100 + float64(i%2)})
+	}
+	if trimmed, shift := s.TrimToLatestRegime("P001", "", flat); shift != nil || len(trimmed) != 30 {
+		t.Errorf("Flat history trimmed to %d days with shift %v", len(trimmed), shift)
+	}
+}
+
+func TestAggregateDailyAverages(t *testing.T) {
+	// 日付順でない入力と部分的な週
+	values, keys, starts := aggregateDailyAverages(
+		[]float64{30, 10, 20, 40},
+		[]string{"2024-01-08", "2024-01-06", "2024-01-07", "2024-01-09"},
+		"weekly",
+	)
+	if fmt.Sprint(keys) != "[2024-W01 2024-W02]" || fmt.Sprint(starts) != "[2024-01-06 2024-01-08]" {
+		t.Fatalf("Keys = %v, starts = %v", keys, starts)
+	}
+	if values[0] != 15 || values[1] != 35 {
+		t.Errorf("Daily averages = %v, expected [15 35]", values)
+	}
+}
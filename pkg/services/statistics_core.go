@@ -517,6 +517,7 @@ func (s *StatisticsService) CreateAnalysisReport(
 	// 回帰分析（気温と売上）
 	var regression *models.RegressionResult
 	var weatherMatches int
+	var imputedDays int
 	var dateRange string
 
 	if len(salesData) > 0 {
@@ -552,8 +553,15 @@ func (s *StatisticsService) CreateAnalysisReport(
 			log.Printf("✅ 気象データ取得成功: %d件 (期間: %s 〜 %s)", len(weatherData), startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
 		}
 
+		// 気温を補完した日は回帰に使わない
 		weatherMap := make(map[string]float64)
 		for _, w := range weatherData {
+			if w.IsImputed() {
+				imputedDays++
+			}
+			if w.IsFieldImputed("temperature") {
+				continue
+			}
 			weatherMap[w.Date] = w.Temperature
 		}
 
@@ -586,22 +594,38 @@ func (s *StatisticsService) CreateAnalysisReport(
 	recommendations := s.generateRecommendations(correlations, regression)
 
 	report := &models.AnalysisReport{
-		ReportID:        uuid.New().String(),
-		FileName:        fileName,
-		AnalysisDate:    time.Now().Format(time.RFC3339),
-		DataPoints:      len(salesData),
-		DateRange:       dateRange,
-		WeatherMatches:  weatherMatches,
-		Summary:         summary,
-		Correlations:    correlations,
-		Regression:      regression,
-		AIInsights:      aiInsights,
-		Recommendations: recommendations,
+		ReportID:           uuid.New().String(),
+		FileName:           fileName,
+		AnalysisDate:       time.Now().Format(time.RFC3339),
+		DataPoints:         len(salesData),
+		DateRange:          dateRange,
+		WeatherMatches:     weatherMatches,
+		Summary:            summary,
+		Correlations:       correlations,
+		Regression:         regression,
+		AIInsights:         aiInsights,
+		Recommendations:    recommendations,
+		WeatherFeatures:    weatherFeaturesIn(correlations),
+		ImputedWeatherDays: imputedDays,
 	}
 
 	return report, nil
 }
 
+// weatherFeaturesIn 相関分析の結果に含まれる派生特徴量の定義（レポートで各特徴量の意味を示す）
+func weatherFeaturesIn(correlations []models.CorrelationResult) []models.WeatherFeatureDefinition {
+	var definitions []models.WeatherFeatureDefinition
+	for _, definition := range weatherFeatureCatalog {
+		for _, correlation := range correlations {
+			if strings.HasPrefix(correlation.Factor, definition.Name+"_") {
+				definitions = append(definitions, definition)
+				break
+			}
+		}
+	}
+	return definitions
+}
+
 // generateRecommendations 分析結果に基づいてレコメンデーションを生成
 func (s *StatisticsService) generateRecommendations(
 	correlations []models.CorrelationResult,
@@ -10,7 +10,16 @@ import (
 	"hunt-chat-api/pkg/models"
 )
 
+// weatherCorrelationSeries 売上との相関を調べる気象の系列
+type weatherCorrelationSeries struct {
+	name   string // Factor名の接頭辞
+	label  string // ログ用の表示名
+	dates  []string
+	values []float64
+}
+
 // AnalyzeSalesWeatherCorrelation 販売データと気象データの相関を分析（遅れ相関を含む）
+// 気温・湿度に加え、不快指数・冷暖房度日・真夏日の連続日数などの派生特徴量（WeatherFeatureCatalog）も対象にする
 func (s *StatisticsService) AnalyzeSalesWeatherCorrelation(
 	salesData []models.WeatherSalesData,
 	regionCode string,
@@ -60,14 +69,39 @@ func (s *StatisticsService) AnalyzeSalesWeatherCorrelation(
 		salesValues = append(salesValues, sale.Sales)
 	}
 
-	// 気象データの日付と値を抽出
-	var weatherDates []string
-	var tempValues []float64
-	var humValues []float64
+	// 気象データの日付と値を抽出（気温・湿度に加え、派生特徴量も系列として扱う）
+	// 補完した値は相関を作り出してしまうため使わない（派生特徴量は補完した項目を含む日を除く）
+	temperature := weatherCorrelationSeries{name: "temperature", label: "気温"}
+	humidity := weatherCorrelationSeries{name: "humidity", label: "湿度"}
+	imputed := make(map[string]bool)
 	for _, w := range weatherData {
-		weatherDates = append(weatherDates, w.Date)
-		tempValues = append(tempValues, w.Temperature)
-		humValues = append(humValues, w.Humidity)
+		if w.IsImputed() {
+			imputed[w.Date] = true
+		}
+		if !w.IsFieldImputed("temperature") {
+			temperature.dates = append(temperature.dates, w.Date)
+			temperature.values = append(temperature.values, w.Temperature)
+		}
+		if !w.IsFieldImputed("humidity") {
+			humidity.dates = append(humidity.dates, w.Date)
+			humidity.values = append(humidity.values, w.Humidity)
+		}
+	}
+	if len(imputed) > 0 {
+		log.Printf("📊 補完した気象データを含む%d日は相関分析から除きます", len(imputed))
+	}
+	series := []weatherCorrelationSeries{temperature, humidity}
+	features := ComputeWeatherFeatures(weatherData)
+	for _, definition := range weatherFeatureCatalog {
+		feature := weatherCorrelationSeries{name: definition.Name, label: definition.Label}
+		for _, day := range features {
+			if imputed[day.Date] {
+				continue
+			}
+			feature.dates = append(feature.dates, day.Date)
+			feature.values = append(feature.values, day.Values[definition.Name])
+		}
+		series = append(series, feature)
 	}
 
 	if len(salesValues) < 5 {
@@ -79,40 +113,23 @@ func (s *StatisticsService) AnalyzeSalesWeatherCorrelation(
 
 	var allResults []models.CorrelationResult
 
-	// 気温との遅れ相関を計算
-	tempLaggedCorrs, err := s.CalculateLaggedCorrelations(salesDates, salesValues, weatherDates, tempValues, maxLagDays)
-	if err != nil {
-		log.Printf("⚠️ 気温の遅れ相関計算エラー: %v", err)
-	} else {
-		// Factor名に "temperature_" を追加
-		for i := range tempLaggedCorrs {
-			tempLaggedCorrs[i].Factor = fmt.Sprintf("temperature_%s", tempLaggedCorrs[i].Factor)
-		}
-		// 統計的に有意な結果のみを追加
-		for _, corr := range tempLaggedCorrs {
-			if corr.PValue < 0.05 || math.Abs(corr.CorrelationCoef) >= 0.3 {
-				allResults = append(allResults, corr)
-			}
+	for _, ws := range series {
+		laggedCorrs, err := s.CalculateLaggedCorrelations(salesDates, salesValues, ws.dates, ws.values, maxLagDays)
+		if err != nil {
+			log.Printf("⚠️ %sの遅れ相関計算エラー: %v", ws.label, err)
+			continue
 		}
-		log.Printf("✅ 気温の遅れ相関分析完了: %d件の有意な相関を検出", len(tempLaggedCorrs))
-	}
-
-	// 湿度との遅れ相関を計算
-	humLaggedCorrs, err := s.CalculateLaggedCorrelations(salesDates, salesValues, weatherDates, humValues, maxLagDays)
-	if err != nil {
-		log.Printf("⚠️ 湿度の遅れ相関計算エラー: %v", err)
-	} else {
-		// Factor名に "humidity_" を追加
-		for i := range humLaggedCorrs {
-			humLaggedCorrs[i].Factor = fmt.Sprintf("humidity_%s", humLaggedCorrs[i].Factor)
+		// Factor名に系列名（例: "temperature_"、"discomfort_index_"）を追加
+		for i := range laggedCorrs {
+			laggedCorrs[i].Factor = fmt.Sprintf("%s_%s", ws.name, laggedCorrs[i].Factor)
 		}
 		// 統計的に有意な結果のみを追加
-		for _, corr := range humLaggedCorrs {
+		for _, corr := range laggedCorrs {
 			if corr.PValue < 0.05 || math.Abs(corr.CorrelationCoef) >= 0.3 {
 				allResults = append(allResults, corr)
 			}
 		}
-		log.Printf("✅ 湿度の遅れ相関分析完了: %d件の有意な相関を検出", len(humLaggedCorrs))
+		log.Printf("✅ %sの遅れ相関分析完了: %d件の有意な相関を検出", ws.label, len(laggedCorrs))
 	}
 
 	// 相関係数の絶対値でソート（降順）
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	crossProductMinProducts = 3   // カテゴリ指数（比率の中央値）を意味のあるものにするための最小製品数
+	crossProductMinPeriods  = 4   // 製品の通常水準を推定するための最小期間数
+	crossProductZThreshold  = 3.5 // ロバストZスコアの閾値（Iglewicz & Hoaglin の修正Zスコアの推奨値）
+	crossProductMinChange   = 0.1 // 通常水準からの最小変化（ばらつきの小さい系列で数%の動きを異常としないため）
+)
+
+// severityRank 深刻度の順位（大きいほど深刻）
+var severityRank = map[string]int{"low": 0, "medium": 1, "high": 2, "critical": 3}
+
+// DetectCrossProductAnomalies 複数製品の売上をまとめて見て、製品単独の異常（isolated）とカテゴリ全体の異常（systemic）を区別する
+// カテゴリの動きは「製品ごとの通常水準に対する比率」の中央値（カテゴリ指数）でモデル化し、その残差をロバストZスコアで評価する
+func (s *StatisticsService) DetectCrossProductAnomalies(salesData []models.WeatherSalesData, granularity string) *models.CrossProductAnalysis {
+	if granularity == "" {
+		granularity = "weekly"
+	}
+
+	// 製品ごとに期間別の1日あたり平均を求める
+	type productSeries struct {
+		id       string
+		name     string
+		values   map[string]float64
+		baseline float64
+	}
+	type productSales struct {
+		name  string
+		sales []float64
+		dates []string
+	}
+	grouped := make(map[string]*productSales)
+	for _, sd := range salesData {
+		if sd.ProductID == "" {
+			continue
+		}
+		g, ok := grouped[sd.ProductID]
+		if !ok {
+			g = &productSales{}
+			grouped[sd.ProductID] = g
+		}
+		if g.name == "" {
+			g.name = sd.ProductName
+		}
+		g.sales = append(g.sales, sd.Sales)
+		g.dates = append(g.dates, sd.Date)
+	}
+
+	periodSet := make(map[string]bool)
+	var products []productSeries
+	for productID, g := range grouped {
+		values, keys, _ := aggregateDailyAverages(g.sales, g.dates, granularity)
+		if len(values) < crossProductMinPeriods {
+			continue
+		}
+		baseline := medianOf(values)
+		if baseline <= 0 {
+			continue
+		}
+		series := productSeries{id: productID, name: g.name, values: make(map[string]float64, len(keys)), baseline: baseline}
+		for i, key := range keys {
+			series.values[key] = values[i]
+			periodSet[key] = true
+		}
+		products = append(products, series)
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].id < products[j].id })
+
+	analysis := &models.CrossProductAnalysis{
+		Granularity: granularity,
+		Products:    len(products),
+		Anomalies:   []models.CrossProductAnomaly{},
+		Incidents:   []models.AnomalyIncident{},
+	}
+	if len(products) < crossProductMinProducts {
+		log.Printf("[製品横断の異常検知] 比較できる製品が%d件のため実行しません（最低%d件）", len(products), crossProductMinProducts)
+		return analysis
+	}
+
+	// カテゴリ指数: 各期間の「通常水準に対する比率」の製品間の中央値
+	periods := make([]string, 0, len(periodSet))
+	for key := range periodSet {
+		periods = append(periods, key)
+	}
+	sort.Strings(periods)
+
+	categoryIndex := make(map[string]float64)
+	var indexPeriods []string
+	for _, period := range periods {
+		var ratios []float64
+		for _, p := range products {
+			if v, ok := p.values[period]; ok {
+				ratios = append(ratios, v/p.baseline)
+			}
+		}
+		if len(ratios) < crossProductMinProducts {
+			continue
+		}
+		categoryIndex[period] = medianOf(ratios)
+		indexPeriods = append(indexPeriods, period)
+	}
+	analysis.Periods = len(indexPeriods)
+
+	indexValues := make([]float64, len(indexPeriods))
+	for i, period := range indexPeriods {
+		indexValues[i] = categoryIndex[period]
+	}
+	categoryZ := make(map[string]float64)
+	for i, z := range RobustZScores(indexValues) {
+		categoryZ[indexPeriods[i]] = z
+	}
+
+	for _, p := range products {
+		var usedPeriods []string
+		var ratios, residuals []float64
+		for _, period := range indexPeriods {
+			v, ok := p.values[period]
+			if !ok {
+				continue
+			}
+			ratio := v / p.baseline
+			usedPeriods = append(usedPeriods, period)
+			ratios = append(ratios, ratio)
+			residuals = append(residuals, ratio-categoryIndex[period])
+		}
+		ownZ := RobustZScores(ratios)
+		residualZ := RobustZScores(residuals)
+
+		for i, period := range usedPeriods {
+			index := categoryIndex[period]
+			cz := categoryZ[period]
+
+			scope := ""
+			z := residualZ[i]
+			expected := p.baseline * index
+			switch {
+			case math.Abs(cz) > crossProductZThreshold && math.Abs(index-1) >= crossProductMinChange &&
+				math.Abs(ownZ[i]) > crossProductZThreshold && cz*ownZ[i] > 0:
+				// カテゴリ全体と同じ向きに動いている
+				scope = "systemic"
+				z = ownZ[i]
+				expected = p.baseline
+			case math.Abs(residualZ[i]) > crossProductZThreshold && math.Abs(residuals[i]) >= crossProductMinChange:
+				scope = "isolated"
+			default:
+				continue
+			}
+
+			anomalyType := "急増"
+			if z < 0 {
+				anomalyType = "急減"
+			}
+			analysis.Anomalies = append(analysis.Anomalies, models.CrossProductAnomaly{
+				Date:          period,
+				ProductID:     p.id,
+				ProductName:   p.name,
+				ActualValue:   roundTo(p.values[period], 2),
+				ExpectedValue: roundTo(expected, 2),
+				CategoryIndex: roundTo(index, 3),
+				Residual:      roundTo(residuals[i], 3),
+				RobustZ:       roundTo(z, 2),
+				CategoryZ:     roundTo(cz, 2),
+				AnomalyType:   anomalyType,
+				Scope:         scope,
+				Severity:      s.calculateSeverity(math.Abs(z)),
+			})
+		}
+	}
+
+	analysis.Incidents = s.groupAnomalyIncidents(analysis.Anomalies, indexPeriods, categoryIndex)
+	log.Printf("[製品横断の異常検知] %d製品・%d期間から %d 件の異常（%d インシデント）を検出しました",
+		len(products), len(indexPeriods), len(analysis.Anomalies), len(analysis.Incidents))
+	return analysis
+}
+
+// groupAnomalyIncidents 同じ原因と考えられる異常をインシデントにまとめる
+// systemic: 連続する期間で同じ向きに動いた製品をまとめる / isolated: 製品ごとに連続する期間の同じ向きの異常をまとめる
+func (s *StatisticsService) groupAnomalyIncidents(anomalies []models.CrossProductAnomaly, periods []string, categoryIndex map[string]float64) []models.AnomalyIncident {
+	position := make(map[string]int, len(periods))
+	for i, period := range periods {
+		position[period] = i
+	}
+
+	groupKey := func(a models.CrossProductAnomaly) string {
+		if a.Scope == "systemic" {
+			return "systemic|" + a.AnomalyType
+		}
+		return "isolated|" + a.AnomalyType + "|" + a.ProductID
+	}
+
+	// グループごとに期間順に並べ、期間が途切れたところで分ける
+	byGroup := make(map[string][]int)
+	for i, a := range anomalies {
+		key := groupKey(a)
+		byGroup[key] = append(byGroup[key], i)
+	}
+
+	incidents := make([]models.AnomalyIncident, 0)
+	for _, indexes := range byGroup {
+		sort.SliceStable(indexes, func(i, j int) bool {
+			return position[anomalies[indexes[i]].Date] < position[anomalies[indexes[j]].Date]
+		})
+
+		var current []int
+		flush := func() {
+			if len(current) > 0 {
+				incidents = append(incidents, s.buildAnomalyIncident(anomalies, current, categoryIndex))
+			}
+			current = nil
+		}
+		for _, idx := range indexes {
+			if len(current) > 0 {
+				gap := position[anomalies[idx].Date] - position[anomalies[current[len(current)-1]].Date]
+				if gap > 1 {
+					flush()
+				}
+			}
+			current = append(current, idx)
+		}
+		flush()
+	}
+
+	sort.SliceStable(incidents, func(i, j int) bool {
+		if severityRank[incidents[i].Severity] != severityRank[incidents[j].Severity] {
+			return severityRank[incidents[i].Severity] > severityRank[incidents[j].Severity]
+		}
+		return incidents[i].StartPeriod < incidents[j].StartPeriod
+	})
+	return incidents
+}
+
+// buildAnomalyIncident 異常のまとまりからインシデントと質問を作り、各異常にインシデントIDを付ける
+func (s *StatisticsService) buildAnomalyIncident(anomalies []models.CrossProductAnomaly, indexes []int, categoryIndex map[string]float64) models.AnomalyIncident {
+	first := anomalies[indexes[0]]
+	incident := models.AnomalyIncident{
+		Scope:       first.Scope,
+		AnomalyType: first.AnomalyType,
+		StartPeriod: first.Date,
+		EndPeriod:   anomalies[indexes[len(indexes)-1]].Date,
+		Severity:    "low",
+	}
+
+	seen := make(map[string]bool)
+	periods := make(map[string]bool)
+	var changeTotal float64
+	for _, idx := range indexes {
+		a := anomalies[idx]
+		if !seen[a.ProductID] {
+			seen[a.ProductID] = true
+			incident.ProductIDs = append(incident.ProductIDs, a.ProductID)
+			name := a.ProductName
+			if name == "" {
+				name = a.ProductID
+			}
+			incident.ProductNames = append(incident.ProductNames, name)
+		}
+		if severityRank[a.Severity] > severityRank[incident.Severity] {
+			incident.Severity = a.Severity
+		}
+		periods[a.Date] = true
+		changeTotal += (a.CategoryIndex + a.Residual - 1) * 100
+	}
+	incident.AnomalyCount = len(indexes)
+	incident.ChangePercent = roundTo(changeTotal/float64(len(indexes)), 1)
+	if incident.Scope == "systemic" {
+		var indexTotal float64
+		for period := range periods {
+			indexTotal += categoryIndex[period]
+		}
+		incident.CategoryChangePercent = roundTo((indexTotal/float64(len(periods))-1)*100, 1)
+	}
+
+	incident.IncidentID = uuid.NewSHA1(uuid.NameSpaceURL, []byte(fmt.Sprintf("incident:%s:%s:%s:%s",
+		incident.Scope, incident.AnomalyType, strings.Join(incident.ProductIDs, ","), incident.StartPeriod))).String()
+	incident.Summary, incident.Question, incident.QuestionChoices = s.incidentQuestion(incident)
+	for _, idx := range indexes {
+		anomalies[idx].IncidentID = incident.IncidentID
+	}
+	return incident
+}
+
+// incidentQuestion インシデントの要約と、インシデントごとに1つだけ尋ねる質問・選択肢
+func (s *StatisticsService) incidentQuestion(incident models.AnomalyIncident) (string, string, []string) {
+	period := s.formatDateForDisplay(incident.StartPeriod)
+	if incident.EndPeriod != incident.StartPeriod {
+		period += "〜" + s.formatDateForDisplay(incident.EndPeriod)
+	}
+	direction, icon := "増加", "📈"
+	if incident.AnomalyType == "急減" {
+		direction, icon = "減少", "📉"
+	}
+	change := math.Abs(incident.ChangePercent)
+
+	if incident.Scope == "systemic" {
+		summary := fmt.Sprintf("%s にカテゴリ全体（%d製品）の売上がそろって約%.0f%%%sしました", period, len(incident.ProductIDs), change, direction)
+		question := fmt.Sprintf("%s %s。天候・祝日・地域の行事など、店舗全体に影響した要因はありましたか？", icon, summary)
+		choices := []string{"天候の影響", "祝日・連休", "地域のイベント・行事", "店舗全体の営業時間の変更・休業", "特に思い当たる節はない", "その他（自由記述）"}
+		if incident.AnomalyType == "急増" {
+			choices[3] = "店舗全体のセール・販促"
+		}
+		return summary, question, choices
+	}
+
+	name := strings.Join(incident.ProductNames, "、")
+	summary := fmt.Sprintf("%s に「%s」だけがカテゴリ全体と異なる動きで約%.0f%%%sしました", period, name, change, direction)
+	question := fmt.Sprintf("%s %s。この製品に固有の要因はありましたか？", icon, summary)
+	choices := []string{"欠品・在庫切れ", "価格変更", "陳列・棚割りの変更", "競合製品の動き", "特に思い当たる節はない", "その他（自由記述）"}
+	if incident.AnomalyType == "急増" {
+		choices = []string{"製品単独の販促・値引き", "メディア・SNSでの露出", "陳列・棚割りの変更", "競合製品の欠品", "特に思い当たる節はない", "その他（自由記述）"}
+	}
+	return summary, question, choices
+}
+
+// RobustZScores 中央値とMADで標準化したZスコア（MADが0の場合は平均絶対偏差で代用し、それも0なら全て0）
+func RobustZScores(values []float64) []float64 {
+	scores := make([]float64, len(values))
+	if len(values) == 0 {
+		return scores
+	}
+	median := medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+
+	scale := 1.4826 * medianOf(deviations)
+	if scale == 0 {
+		scale = 1.2533 * calculateMean(deviations)
+	}
+	if scale == 0 {
+		return scores
+	}
+	for i, v := range values {
+		scores[i] = (v - median) / scale
+	}
+	return scores
+}
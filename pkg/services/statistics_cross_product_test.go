@@ -0,0 +1,118 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// crossProductFixture 4製品×12週の日次データ。adjust で週ごとの倍率を変える
+func crossProductFixture(adjust func(productID string, week int) float64) []models.WeatherSalesData {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // 月曜日
+	levels := map[string]float64{"P001": 100, "P002": 50, "P003": 80, "P004": 120}
+	noise := []float64{1.02, 0.97, 1.01, 0.99, 1.03, 0.98, 1.0, 1.01, 0.99, 1.02, 0.98, 1.0}
+	var data []models.WeatherSalesData
+	for _, productID := range []string{"P001", "P002", "P003", "P004"} {
+		for day := 0; day < 12*7; day++ {
+			week := day / 7
+			sales := levels[productID] * noise[(week+len(productID)+int(levels[productID]))%len(noise)] * adjust(productID, week)
+			data = append(data, models.WeatherSalesData{
+				Date:        start.AddDate(0, 0, day).Format("2006-01-02"),
+				ProductID:   productID,
+				ProductName: "製品" + productID[3:],
+				Sales:       sales,
+			})
+		}
+	}
+	return data
+}
+
+func TestDetectCrossProductAnomaliesIsolated(t *testing.T) {
+	s := &StatisticsService{}
+	// P002だけ6週目に欠品で半減
+	data := crossProductFixture(func(productID string, week int) float64 {
+		if productID == "P002" && week == 5 {
+			return 0.5
+		}
+		return 1
+	})
+
+	analysis := s.DetectCrossProductAnomalies(data, "weekly")
+	if analysis.Products != 4 || analysis.Periods != 12 {
+		t.Fatalf("Analysis covers %d products / %d periods", analysis.Products, analysis.Periods)
+	}
+	if len(analysis.Anomalies) != 1 {
+		t.Fatalf("Anomalies = %+v, expected one", analysis.Anomalies)
+	}
+	anomaly := analysis.Anomalies[0]
+	if anomaly.ProductID != "P002" || anomaly.Scope != "isolated" || anomaly.AnomalyType != "急減" || anomaly.Date != "2024-W06" {
+		t.Errorf("Anomaly = %+v", anomaly)
+	}
+	if len(analysis.Incidents) != 1 || analysis.Incidents[0].IncidentID != anomaly.IncidentID || analysis.Incidents[0].Question == "" {
+		t.Errorf("Incidents = %+v", analysis.Incidents)
+	}
+}
+
+func TestDetectCrossProductAnomaliesSystemic(t *testing.T) {
+	s := &StatisticsService{}
+	// 8〜9週目に全製品が3割減（台風・休業など店舗全体の要因）
+	data := crossProductFixture(func(productID string, week int) float64 {
+		if week == 7 || week == 8 {
+			return 0.7
+		}
+		return 1
+	})
+
+	analysis := s.DetectCrossProductAnomalies(data, "weekly")
+	if len(analysis.Anomalies) != 8 {
+		t.Fatalf("Anomalies = %d, expected 4 products x 2 weeks", len(analysis.Anomalies))
+	}
+	for _, anomaly := range analysis.Anomalies {
+		if anomaly.Scope != "systemic" || anomaly.AnomalyType != "急減" {
+			t.Errorf("Anomaly = %+v", anomaly)
+		}
+	}
+
+	// 連続する2週・4製品の異常は1つのインシデントにまとめ、質問も1つにする
+	if len(analysis.Incidents) != 1 {
+		t.Fatalf("Incidents = %+v, expected one", analysis.Incidents)
+	}
+	incident := analysis.Incidents[0]
+	if incident.Scope != "systemic" || incident.AnomalyCount != 8 || len(incident.ProductIDs) != 4 {
+		t.Errorf("Incident = %+v", incident)
+	}
+	if incident.StartPeriod != "2024-W08" || incident.EndPeriod != "2024-W09" || math.Abs(incident.CategoryChangePercent+30) > 3 {
+		t.Errorf("Incident period/change = %s〜%s %.1f%%", incident.StartPeriod, incident.EndPeriod, incident.CategoryChangePercent)
+	}
+	if len(incident.QuestionChoices) == 0 || incident.QuestionChoices[0] != "天候の影響" {
+		t.Errorf("Choices = %v", incident.QuestionChoices)
+	}
+}
+
+func TestDetectCrossProductAnomaliesTooFewProducts(t *testing.T) {
+	s := &StatisticsService{}
+	var data []models.WeatherSalesData
+	for _, d := range crossProductFixture(func(string, int) float64 { return 1 }) {
+		if d.ProductID == "P001" || d.ProductID == "P002" {
+			data = append(data, d)
+		}
+	}
+	if analysis := s.DetectCrossProductAnomalies(data, "weekly"); analysis.Products != 2 || len(analysis.Anomalies) != 0 {
+		t.Errorf("Analysis = %+v", analysis)
+	}
+}
+
+func TestRobustZScores(t *testing.T) {
+	scores := RobustZScores([]float64{10, 11, 9, 10, 30})
+	if math.Abs(scores[4]) < 10 || math.Abs(scores[0]) > 1e-9 {
+		t.Errorf("Scores = %v", scores)
+	}
+	// ばらつきがなければ全て0
+	for _, z := range RobustZScores([]float64{5, 5, 5}) {
+		if z != 0 {
+			t.Errorf("Constant series score = %f", z)
+		}
+	}
+}
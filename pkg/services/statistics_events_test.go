@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestEventContributionsOn(t *testing.T) {
+	events := EventRegressorsForProduct([]models.EventRegressor{
+		{EventID: "c1", EventType: "campaign", Title: "セール", StartDate: "2024-06-01", EndDate: "2024-06-03", UpliftPercent: 30},
+		{EventID: "w1", EventType: "weather", Title: "台風", StartDate: "2024-06-02", UpliftPercent: -50, ProductIDs: []string{"P001"}},
+		{EventID: "o1", EventType: "campaign", Title: "他製品", StartDate: "2024-06-02", UpliftPercent: 100, ProductIDs: []string{"P002"}},
+		{EventID: "z1", EventType: "holiday", Title: "効果なし", StartDate: "2024-06-02"},
+	}, "P001")
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 applicable events, got %+v", events)
+	}
+
+	contributions, total := EventContributionsOn("2024-06-02", 200, events)
+	if len(contributions) != 2 || total != -40 {
+		t.Errorf("Contributions = %+v, total = %f (expected 60 - 100 = -40)", contributions, total)
+	}
+	if contributions, total := EventContributionsOn("2024-06-05", 200, events); len(contributions) != 0 || total != 0 {
+		t.Errorf("No event should apply after the end date, got %+v", contributions)
+	}
+
+	// 過去データはイベントの倍率で割り戻す
+	adjusted := RemoveEventEffects([]models.SalesDataPoint{{Date: "2024-06-01", Sales: 130}, {Date: "2024-06-04", Sales: 100}}, events)
+	if adjusted[0].Sales != 100 || adjusted[1].Sales != 100 {
+		t.Errorf("Adjusted sales = %+v", adjusted)
+	}
+}
+
+func TestBacktestEventFeatures(t *testing.T) {
+	// 5日に1度のキャンペーンで売上が50%増える系列
+	start := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	var history []models.SalesDataPoint
+	var events []models.EventRegressor
+	s := &StatisticsService{}
+	for i := 0; i < 56; i++ {
+		date := start.AddDate(0, 0, i)
+		sales := 100.0
+		if i%5 == 3 {
+			sales = 150
+			events = append(events, models.EventRegressor{EventType: "campaign", Title: "週末セール", StartDate: date.Format("2006-01-02"), UpliftPercent: 50})
+		}
+		history = append(history, models.SalesDataPoint{Date: date.Format("2006-01-02"), Sales: sales})
+	}
+
+	result, err := s.BacktestEventFeatures("P001", "テスト", history, "week", events)
+	if err != nil {
+		t.Fatalf("BacktestEventFeatures failed: %v", err)
+	}
+	if result.HoldoutDays != 7 || result.EventDays != 1 {
+		t.Errorf("Holdout = %d days, %d event days", result.HoldoutDays, result.EventDays)
+	}
+	if result.WithEvents.MAPE >= result.WithoutEvents.MAPE || result.MAPEImprovement <= 0 {
+		t.Errorf("Events should improve accuracy: with=%+v without=%+v", result.WithEvents, result.WithoutEvents)
+	}
+
+	// 予測期間のイベントは日別予測に寄与として表示される
+	planned := append(events, models.EventRegressor{EventID: "next", EventType: "campaign", Title: "次回セール", StartDate: "2024-05-28", UpliftPercent: 50})
+	forecast, err := s.ForecastProductDemandWithEvents("P001", "テスト", history, "week", "", planned)
+	if err != nil {
+		t.Fatalf("ForecastProductDemandWithEvents failed: %v", err)
+	}
+	for _, day := range forecast.DailyBreakdown {
+		if day.Date != "2024-05-28" {
+			if len(day.EventContributions) != 0 {
+				t.Errorf("%s should have no event contribution: %+v", day.Date, day.EventContributions)
+			}
+			continue
+		}
+		if len(day.EventContributions) != 1 || day.EventContributions[0].EventID != "next" || day.PredictedValue <= day.BaseValue {
+			t.Errorf("Event day forecast = %+v", day)
+		}
+	}
+}
@@ -103,23 +103,45 @@ func (s *StatisticsService) ForecastProductDemand(
 	historicalData []models.SalesDataPoint,
 	period string,
 	regionCode string,
+) (models.ProductForecast, error) {
+	return s.ForecastProductDemandWithEvents(productID, productName, historicalData, period, regionCode, nil)
+}
+
+// ForecastProductDemandWithEvents イベントを回帰要因として組み込んだ製品別の需要予測を実行
+// 過去データのイベント日は効果を取り除いてから学習し、予測期間のイベントは基準値に変化率を掛けた分を加算する
+func (s *StatisticsService) ForecastProductDemandWithEvents(
+	productID string,
+	productName string,
+	historicalData []models.SalesDataPoint,
+	period string,
+	regionCode string,
+	events []models.EventRegressor,
+) (models.ProductForecast, error) {
+	return s.ForecastProductDemandWithRegressors(productID, productName, historicalData, period, regionCode, events, nil)
+}
+
+// ForecastProductDemandWithRegressors イベントと気象特徴量を回帰要因として組み込んだ製品別の需要予測を実行
+// 気象特徴量は、学習期間で基本モデル（平均・曜日効果・気温）が説明できなかった残差に当てはめ、
+// 予測期間の特徴量がある日は学習期間の平均との差に係数を掛けた分を加算する
+func (s *StatisticsService) ForecastProductDemandWithRegressors(
+	productID string,
+	productName string,
+	historicalData []models.SalesDataPoint,
+	period string,
+	regionCode string,
+	events []models.EventRegressor,
+	weather *WeatherFeatureRegressors,
 ) (models.ProductForecast, error) {
 	if len(historicalData) < 14 {
 		return models.ProductForecast{}, fmt.Errorf("予測には最低14日分のデータが必要です")
 	}
 
 	// 期間の日数を決定
-	var forecastDays int
-	switch period {
-	case "week":
-		forecastDays = 7
-	case "2weeks":
-		forecastDays = 14
-	case "month":
-		forecastDays = 30
-	default:
-		forecastDays = 7
-	}
+	forecastDays := forecastDaysFor(period)
+
+	// 対象製品のイベントだけを使い、過去データからイベントの効果を取り除く
+	events = EventRegressorsForProduct(events, productID)
+	historicalData = RemoveEventEffects(historicalData, events)
 
 	// 統計情報を計算
 	stats := s.calculateProductStatistics(historicalData)
@@ -145,6 +167,31 @@ func (s *StatisticsService) ForecastProductDemand(
 		}
 	}
 
+	// 気象特徴量の回帰（基本モデルの残差に当てはめる）
+	var featureFit *weatherFeatureFit
+	var featureFitErr error
+	if weather != nil && len(weather.Names) > 0 {
+		residuals := make(map[string]float64, len(historicalData))
+		for _, point := range historicalData {
+			date, err := time.Parse("2006-01-02", point.Date)
+			if err != nil {
+				continue
+			}
+			expected := stats.Mean
+			if effect, ok := weekdayEffect[s.getDayOfWeekJP(date.Weekday())]; ok {
+				expected *= effect
+			}
+			if regression != nil && regression.RSquared > 0.1 && point.Temperature > 0 {
+				expected += regression.Slope * (point.Temperature - calculateMean(temperatures))
+			}
+			residuals[point.Date] = point.Sales - expected
+		}
+		featureFit, featureFitErr = fitWeatherFeatureRegressors(residuals, weather)
+		if featureFitErr != nil {
+			log.Printf("⚠️ 気象特徴量の回帰をスキップ: %v", featureFitErr)
+		}
+	}
+
 	// 将来の予測日を生成
 	lastDate, _ := time.Parse("2006-01-02", historicalData[len(historicalData)-1].Date)
 	var dailyForecasts []models.DailyForecast
@@ -174,14 +221,29 @@ func (s *StatisticsService) ForecastProductDemand(
 		trendAdjustment := s.calculateTrend(historicalData) * float64(i)
 		baseValue += trendAdjustment
 
-		dailyForecasts = append(dailyForecasts, models.DailyForecast{
-			Date:           forecastDate.Format("2006-01-02"),
-			DayOfWeek:      dayOfWeek,
-			PredictedValue: math.Max(0, baseValue), // 負の値を避ける
-			Temperature:    s.getSeasonalTemperature(forecastDate.Month()),
-		})
+		// 気象特徴量の効果（予測期間の特徴量がある日のみ）
+		var featureContribution float64
+		if featureFit != nil {
+			featureContribution = featureFit.contribution(forecastDate.Format("2006-01-02"))
+			baseValue += featureContribution
+		}
+
+		// イベント効果（基準値に対する変化率）
+		contributions, eventTotal := EventContributionsOn(forecastDate.Format("2006-01-02"), baseValue, events)
+		daily := models.DailyForecast{
+			Date:                       forecastDate.Format("2006-01-02"),
+			DayOfWeek:                  dayOfWeek,
+			PredictedValue:             math.Max(0, baseValue+eventTotal), // 負の値を避ける
+			EventContributions:         contributions,
+			WeatherFeatureContribution: roundTo(featureContribution, 2),
+			Temperature:                s.getSeasonalTemperature(forecastDate.Month()),
+		}
+		if len(contributions) > 0 {
+			daily.BaseValue = math.Max(0, baseValue)
+		}
+		dailyForecasts = append(dailyForecasts, daily)
 
-		totalForecast += baseValue
+		totalForecast += baseValue + eventTotal
 	}
 
 	// 信頼区間を計算
@@ -205,6 +267,19 @@ func (s *StatisticsService) ForecastProductDemand(
 	// 季節性の判定
 	seasonality := s.detectSeasonality(historicalData)
 
+	factors := s.buildFactorsList(regression, weekdayEffect, stats)
+	if len(events) > 0 {
+		factors = append(factors, fmt.Sprintf("登録イベント %d 件を回帰要因として反映", len(events)))
+	}
+	var weatherRegressors []models.WeatherFeatureRegressor
+	switch {
+	case featureFit != nil:
+		weatherRegressors = featureFit.output()
+		factors = append(factors, fmt.Sprintf("気象特徴量（%s）を回帰要因として反映（残差の決定係数 R² = %.3f、%d 日で学習）", featureFit.labels(), featureFit.rSquared, featureFit.sampleSize))
+	case featureFitErr != nil:
+		factors = append(factors, "気象特徴量は使用しませんでした: "+featureFitErr.Error())
+	}
+
 	return models.ProductForecast{
 		ProductID:      productID,
 		ProductName:    productName,
@@ -216,11 +291,13 @@ func (s *StatisticsService) ForecastProductDemand(
 			Upper:      totalForecast + marginTotal,
 			Confidence: 0.95,
 		},
-		Confidence:      confidence,
-		DailyBreakdown:  dailyForecasts,
-		Factors:         s.buildFactorsList(regression, weekdayEffect, stats),
-		Seasonality:     seasonality,
-		Recommendations: recommendations,
+		Confidence:        confidence,
+		DailyBreakdown:    dailyForecasts,
+		Factors:           factors,
+		Seasonality:       seasonality,
+		Recommendations:   recommendations,
+		Events:            events,
+		WeatherRegressors: weatherRegressors,
 	}, nil
 }
 
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+const (
+	hourlyBaselineDays      = 14  // 同じ時間帯と比べる過去の日数
+	hourlyMinBaselineDays   = 7   // 期待値を出すのに必要な、過去の販売日の最小日数
+	hourlyMinExpectedSales  = 5.0 // 期待値がこれ未満の時間帯は変動が大きいため判定しない
+	hourlyAnomalyPercentage = 0.5 // 異常とみなす乖離率（50%）
+	hourlyAnomalyMinZScore  = 2.0 // 乖離率に加えて必要なZスコア（ばらつきの大きい時間帯の誤検知を抑える）
+)
+
+// hourlySales 製品の時間帯ごとの売上（同じ時間帯の行は合計）と、その時間帯の気象
+type hourlySales struct {
+	sales   map[string]float64                 // 時間帯 → 売上
+	weather map[string]models.WeatherSalesData // 時間帯 → 気象が結合された行
+	days    []string                           // 売上のある日（日付順）
+	hours   []int                              // 売上のある時刻（昇順）
+}
+
+// groupHourlySales DateTime のある行を時間帯ごとにまとめる（DateTime のない行は使わない）
+func groupHourlySales(data []models.WeatherSalesData) *hourlySales {
+	grouped := &hourlySales{
+		sales:   make(map[string]float64),
+		weather: make(map[string]models.WeatherSalesData),
+	}
+	daySeen := make(map[string]bool)
+	hourSeen := make(map[int]bool)
+	for _, d := range data {
+		t, err := ParseHourKey(d.DateTime)
+		if err != nil {
+			continue
+		}
+		key := HourKey(t)
+		grouped.sales[key] += d.Sales
+		if d.Weather != "" {
+			grouped.weather[key] = d
+		}
+		if day := t.Format("2006-01-02"); !daySeen[day] {
+			daySeen[day] = true
+			grouped.days = append(grouped.days, day)
+		}
+		if !hourSeen[t.Hour()] {
+			hourSeen[t.Hour()] = true
+			grouped.hours = append(grouped.hours, t.Hour())
+		}
+	}
+	sort.Strings(grouped.days)
+	sort.Ints(grouped.hours)
+	return grouped
+}
+
+// at その日・時刻の売上（売上のある日でその時間帯の行がない場合は0）
+func (h *hourlySales) at(day string, hour int) float64 {
+	return h.sales[fmt.Sprintf("%s %02d:00", day, hour)]
+}
+
+// DetectHourlyAnomalies 時間帯ごとの売上を、過去14日間の同じ時刻の平均と比べて異常を検知する
+// 午後の雷雨・朝の雨のように一日の中で影響が異なる変化を捉えるため、日次に集約せずに判定する
+// 売上のある日でその時間帯の行がない場合は売上0として扱う
+func (s *StatisticsService) DetectHourlyAnomalies(data []models.WeatherSalesData, productID, productName string) []models.AnomalyDetection {
+	displayName := productName
+	if displayName == "" {
+		displayName = productID
+	}
+
+	grouped := groupHourlySales(data)
+	if len(grouped.days) <= hourlyMinBaselineDays {
+		log.Printf("[異常検知@%s] 販売日が少なく、時間帯別の期待値を計算できません（%d日 <= %d日）", displayName, len(grouped.days), hourlyMinBaselineDays)
+		return []models.AnomalyDetection{}
+	}
+
+	anomalies := []models.AnomalyDetection{}
+	for i, day := range grouped.days {
+		// 過去14日間のうち、売上のある日を期待値に使う
+		var baselineDays []string
+		earliest := shiftDate(day, -hourlyBaselineDays)
+		for j := i - 1; j >= 0 && grouped.days[j] >= earliest; j-- {
+			baselineDays = append(baselineDays, grouped.days[j])
+		}
+		if len(baselineDays) < hourlyMinBaselineDays {
+			continue
+		}
+
+		for _, hour := range grouped.hours {
+			window := make([]float64, len(baselineDays))
+			for k, baselineDay := range baselineDays {
+				window[k] = grouped.at(baselineDay, hour)
+			}
+			mean := calculateMean(window)
+			if mean < hourlyMinExpectedSales {
+				continue
+			}
+
+			currentValue := grouped.at(day, hour)
+			deviation := currentValue - mean
+			stdDev := calculateStandardDeviation(window)
+			var zScore float64
+			if stdDev > 0 {
+				zScore = deviation / stdDev
+			}
+			if math.Abs(deviation) <= mean*hourlyAnomalyPercentage || (stdDev > 0 && math.Abs(zScore) < hourlyAnomalyMinZScore) {
+				continue
+			}
+
+			anomalyType := "急増"
+			if deviation < 0 {
+				anomalyType = "急減"
+			}
+			period := fmt.Sprintf("%s %02d:00", day, hour)
+			anomalies = append(anomalies, models.AnomalyDetection{
+				Date:             period,
+				ProductID:        productID,
+				ProductName:      productName,
+				ActualValue:      currentValue,
+				ExpectedValue:    roundTo(mean, 2),
+				Deviation:        math.Abs(deviation),
+				ZScore:           zScore,
+				AnomalyType:      anomalyType,
+				Severity:         s.calculateSeverity(math.Abs(zScore)),
+				Granularity:      "hourly",
+				ExpectationModel: "hour_of_day_average",
+				WeatherSummary:   hourlyWeatherSummary(grouped.weather[period]),
+			})
+		}
+	}
+
+	log.Printf("[異常検知@%s] 時間帯別の平均との比較により %d 件の異常を検出しました", displayName, len(anomalies))
+	return anomalies
+}
+
+// parseAnomalyDay 異常の発生日（YYYY-MM-DD、または時間帯 YYYY-MM-DD HH:00 のその日）
+func parseAnomalyDay(date string) (time.Time, error) {
+	if t, err := ParseHourKey(date); err == nil {
+		return t.Truncate(24 * time.Hour), nil
+	}
+	return time.Parse("2006-01-02", date)
+}
+
+// hourlyWeatherSummary 時間帯の気象条件の説明（例: 気温24.0℃・雷雨（降水量12.5mm））
+func hourlyWeatherSummary(d models.WeatherSalesData) string {
+	if d.Weather == "" {
+		return ""
+	}
+	summary := fmt.Sprintf("気温%.1f℃・%s", d.Temperature, d.Weather)
+	if d.Precipitation > 0 {
+		summary += fmt.Sprintf("（降水量%.1fmm）", d.Precipitation)
+	}
+	return summary
+}
+
+// JoinHourlyWeather 販売データの各時間帯に、その時間帯の気象データを結合する（DateTime のない行はそのまま）
+func (s *StatisticsService) JoinHourlyWeather(salesData []models.WeatherSalesData, regionCode string) ([]models.WeatherSalesData, error) {
+	if s.weatherService == nil {
+		return nil, fmt.Errorf("気象サービスが利用できません")
+	}
+
+	var startDate, endDate time.Time
+	for _, d := range salesData {
+		t, err := ParseHourKey(d.DateTime)
+		if err != nil {
+			continue
+		}
+		if startDate.IsZero() || t.Before(startDate) {
+			startDate = t
+		}
+		if endDate.IsZero() || t.After(endDate) {
+			endDate = t
+		}
+	}
+	if startDate.IsZero() {
+		return nil, fmt.Errorf("販売データに有効な日時がありません")
+	}
+
+	weatherData, err := s.weatherService.GetHourlyWeatherData(regionCode, startDate.Truncate(24*time.Hour), endDate.Truncate(24*time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("1時間ごとの気象データの取得に失敗: %w", err)
+	}
+	weatherByHour := make(map[string]HourlyWeatherData, len(weatherData))
+	for _, w := range weatherData {
+		weatherByHour[w.DateTime] = w
+	}
+
+	joined := make([]models.WeatherSalesData, len(salesData))
+	matches := 0
+	for i, d := range salesData {
+		if w, ok := weatherByHour[d.DateTime]; ok {
+			d.Temperature = w.Temperature
+			d.Humidity = w.Humidity
+			d.Precipitation = w.Precipitation
+			d.Weather = w.Weather
+			matches++
+		}
+		joined[i] = d
+	}
+	log.Printf("🌤️ 販売データ %d件のうち %d件に1時間ごとの気象データを結合しました（地域コード: %s）", len(salesData), matches, regionCode)
+	return joined, nil
+}
+
+// BuildHourOfDayProfiles 製品ごとに、時刻別の1日あたり平均売上と売上の割合をまとめる
+// 気象データが結合されている場合は、雨の時間帯と雨でない時間帯の平均も比べる
+func (s *StatisticsService) BuildHourOfDayProfiles(data []models.WeatherSalesData) []models.HourOfDayProfile {
+	type productRows struct {
+		name string
+		rows []models.WeatherSalesData
+	}
+	byProduct := make(map[string]*productRows)
+	var productIDs []string
+	for _, d := range data {
+		if d.ProductID == "" || d.DateTime == "" {
+			continue
+		}
+		p, ok := byProduct[d.ProductID]
+		if !ok {
+			p = &productRows{}
+			byProduct[d.ProductID] = p
+			productIDs = append(productIDs, d.ProductID)
+		}
+		if p.name == "" {
+			p.name = d.ProductName
+		}
+		p.rows = append(p.rows, d)
+	}
+	sort.Strings(productIDs)
+
+	profiles := make([]models.HourOfDayProfile, 0, len(productIDs))
+	for _, productID := range productIDs {
+		p := byProduct[productID]
+		grouped := groupHourlySales(p.rows)
+		if len(grouped.days) == 0 {
+			continue
+		}
+
+		var total float64
+		for _, sales := range grouped.sales {
+			total += sales
+		}
+
+		profile := models.HourOfDayProfile{ProductID: productID, ProductName: p.name, Days: len(grouped.days)}
+		var peak float64
+		for _, hour := range grouped.hours {
+			var hourTotal float64
+			var rainy, dry []float64
+			for _, day := range grouped.days {
+				period := fmt.Sprintf("%s %02d:00", day, hour)
+				sales, ok := grouped.sales[period]
+				if !ok {
+					continue
+				}
+				hourTotal += sales
+				if w, ok := grouped.weather[period]; ok {
+					if rainyConditions(w.Precipitation, w.Weather) {
+						rainy = append(rainy, sales)
+					} else {
+						dry = append(dry, sales)
+					}
+				}
+			}
+
+			stat := models.HourOfDayStat{
+				Hour:         hour,
+				AverageSales: roundTo(hourTotal/float64(len(grouped.days)), 2),
+			}
+			if total > 0 {
+				stat.SharePercent = roundTo(hourTotal/total*100, 1)
+			}
+			if len(rainy) > 0 && len(dry) > 0 {
+				rainyAverage := roundTo(calculateMean(rainy), 2)
+				dryAverage := roundTo(calculateMean(dry), 2)
+				stat.RainyAverage, stat.DryAverage = &rainyAverage, &dryAverage
+				if dryAverage > 0 {
+					effect := roundTo((rainyAverage-dryAverage)/dryAverage*100, 1)
+					stat.RainEffectPercent = &effect
+				}
+			}
+			if stat.AverageSales > peak {
+				peak = stat.AverageSales
+				profile.PeakHour = hour
+			}
+			profile.Hours = append(profile.Hours, stat)
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles
+}
+
+// DailyTotals 製品・日ごとに売上を合計する（相関分析などの日次の分析用、日付・製品ID順）
+// 気象データは結合し直すため引き継がない
+func DailyTotals(data []models.WeatherSalesData) []models.WeatherSalesData {
+	type key struct{ date, productID string }
+	totals := make(map[key]*models.WeatherSalesData)
+	for _, d := range data {
+		k := key{d.Date, d.ProductID}
+		total, ok := totals[k]
+		if !ok {
+			total = &models.WeatherSalesData{Date: d.Date, ProductID: d.ProductID}
+			totals[k] = total
+		}
+		if total.ProductName == "" {
+			total.ProductName = d.ProductName
+		}
+		total.Sales += d.Sales
+	}
+
+	result := make([]models.WeatherSalesData, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Date != result[j].Date {
+			return result[i].Date < result[j].Date
+		}
+		return result[i].ProductID < result[j].ProductID
+	})
+	return result
+}
+
+// HourlyAggregatedPoints 時間帯ごとの売上を保存用の集計値にする（時間帯順、開始日・終了日はその日）
+func HourlyAggregatedPoints(data []models.WeatherSalesData) []AggregatedPoint {
+	grouped := groupHourlySales(data)
+	periods := make([]string, 0, len(grouped.sales))
+	for period := range grouped.sales {
+		periods = append(periods, period)
+	}
+	sort.Strings(periods)
+
+	points := make([]AggregatedPoint, 0, len(periods))
+	for _, period := range periods {
+		date := period[:len("2006-01-02")]
+		points = append(points, AggregatedPoint{Period: period, StartDate: date, EndDate: date, Value: grouped.sales[period]})
+	}
+	return points
+}
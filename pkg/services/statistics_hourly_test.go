@@ -0,0 +1,124 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// hourlySalesFixture 10時〜18時に毎時20個売れる日が days 日続く販売データ
+func hourlySalesFixture(days int) []models.WeatherSalesData {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	var data []models.WeatherSalesData
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		for hour := 10; hour <= 18; hour++ {
+			// 1時間の売上は2行（レシート単位）に分かれている
+			for _, sales := range []float64{12, 8} {
+				data = append(data, models.WeatherSalesData{
+					Date: date, DateTime: fmt.Sprintf("%s %02d:00", date, hour), ProductID: "P001", ProductName: "製品A", Sales: sales,
+					Temperature: 30, Weather: "晴れ",
+				})
+			}
+		}
+	}
+	return data
+}
+
+func TestDetectHourlyAnomalies(t *testing.T) {
+	data := hourlySalesFixture(21)
+	// 7月18日の15時は雷雨で売上が落ちた
+	for i := range data {
+		if data[i].DateTime == "2024-07-18 15:00" {
+			data[i].Sales = 1
+			data[i].Weather, data[i].Precipitation = "雷雨", 12.5
+		}
+	}
+
+	service := NewStatisticsService(nil, nil, nil)
+	anomalies := service.DetectHourlyAnomalies(data, "P001", "製品A")
+	if len(anomalies) != 1 {
+		t.Fatalf("Expected 1 anomaly, got %+v", anomalies)
+	}
+	anomaly := anomalies[0]
+	if anomaly.Date != "2024-07-18 15:00" || anomaly.AnomalyType != "急減" || anomaly.ActualValue != 2 || anomaly.ExpectedValue != 20 {
+		t.Errorf("Unexpected anomaly: %+v", anomaly)
+	}
+	if anomaly.Granularity != "hourly" || anomaly.ExpectationModel != "hour_of_day_average" {
+		t.Errorf("Unexpected model: %s / %s", anomaly.Granularity, anomaly.ExpectationModel)
+	}
+	if !strings.Contains(anomaly.WeatherSummary, "雷雨") || !strings.Contains(anomaly.WeatherSummary, "12.5mm") {
+		t.Errorf("Expected the hour's weather in the summary, got %q", anomaly.WeatherSummary)
+	}
+	if got := service.formatDateForDisplay(anomaly.Date); got != "2024年7月18日 15時" {
+		t.Errorf("formatDateForDisplay = %q", got)
+	}
+
+	// 行が丸ごとない時間帯（売上のある日）は売上0として扱う
+	var missing []models.WeatherSalesData
+	for _, d := range hourlySalesFixture(21) {
+		if d.DateTime != "2024-07-20 11:00" {
+			missing = append(missing, d)
+		}
+	}
+	var sales []float64
+	var dates []string
+	for _, d := range missing {
+		sales = append(sales, d.Sales)
+		dates = append(dates, d.DateTime)
+	}
+	anomalies = service.DetectAnomaliesWithGranularity(sales, dates, "P001", "製品A", "hourly")
+	if len(anomalies) != 1 || anomalies[0].Date != "2024-07-20 11:00" || anomalies[0].ActualValue != 0 {
+		t.Errorf("Expected a drop to zero at 11:00, got %+v", anomalies)
+	}
+
+	if anomalies := service.DetectHourlyAnomalies(hourlySalesFixture(5), "P001", "製品A"); len(anomalies) != 0 {
+		t.Errorf("Expected no anomalies with too few days, got %d", len(anomalies))
+	}
+}
+
+func TestBuildHourOfDayProfiles(t *testing.T) {
+	data := []models.WeatherSalesData{
+		{Date: "2024-07-01", DateTime: "2024-07-01 10:00", ProductID: "P001", ProductName: "製品A", Sales: 10, Weather: "晴れ"},
+		{Date: "2024-07-01", DateTime: "2024-07-01 15:00", ProductID: "P001", Sales: 30, Weather: "晴れ"},
+		{Date: "2024-07-02", DateTime: "2024-07-02 10:00", ProductID: "P001", Sales: 20, Weather: "雨", Precipitation: 3},
+		{Date: "2024-07-02", DateTime: "2024-07-02 15:00", ProductID: "P001", Sales: 15, Weather: "雨", Precipitation: 5},
+		{Date: "2024-07-02", DateTime: "2024-07-02 15:00", ProductID: "P001", Sales: 5, Weather: "雨", Precipitation: 5},
+		// 時刻のない行は使わない
+		{Date: "2024-07-03", ProductID: "P001", Sales: 100},
+	}
+
+	profiles := NewStatisticsService(nil, nil, nil).BuildHourOfDayProfiles(data)
+	if len(profiles) != 1 {
+		t.Fatalf("Expected 1 profile, got %+v", profiles)
+	}
+	profile := profiles[0]
+	if profile.ProductName != "製品A" || profile.Days != 2 || profile.PeakHour != 15 || len(profile.Hours) != 2 {
+		t.Fatalf("Unexpected profile: %+v", profile)
+	}
+	morning, afternoon := profile.Hours[0], profile.Hours[1]
+	if morning.Hour != 10 || morning.AverageSales != 15 || morning.SharePercent != 37.5 {
+		t.Errorf("Unexpected morning stats: %+v", morning)
+	}
+	if afternoon.AverageSales != 25 || afternoon.SharePercent != 62.5 {
+		t.Errorf("Unexpected afternoon stats: %+v", afternoon)
+	}
+	// 午前は雨で増え、午後は雨で減る
+	if morning.RainEffectPercent == nil || *morning.RainEffectPercent != 100 {
+		t.Errorf("Expected +100%% in the rainy morning, got %+v", morning.RainEffectPercent)
+	}
+	if afternoon.RainyAverage == nil || *afternoon.RainyAverage != 20 || *afternoon.DryAverage != 30 || *afternoon.RainEffectPercent != -33.3 {
+		t.Errorf("Unexpected rainy afternoon stats: %+v", afternoon)
+	}
+
+	points := HourlyAggregatedPoints(data)
+	if len(points) != 4 || points[3].Period != "2024-07-02 15:00" || points[3].Value != 20 || points[3].StartDate != "2024-07-02" {
+		t.Errorf("Unexpected aggregated points: %+v", points)
+	}
+	if totals := DailyTotals(data); len(totals) != 3 || totals[1].Sales != 40 {
+		t.Errorf("Unexpected daily totals: %+v", totals)
+	}
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"sort"
 )
@@ -212,6 +214,65 @@ func solveSymmetric(A [][]float64, b []float64) ([]float64, error) {
 	return x, nil
 }
 
+// errNoVariance is returned by solveCenteredOLS when the target has no variance.
+var errNoVariance = errors.New("target has no variance")
+
+// solveCenteredOLS fits y ~ X by least squares and returns the coefficients and R².
+// Rows of X and y must already be centred (column means of zero), so no intercept is fitted.
+// Features without variance are fixed at a coefficient of 0, and a tiny ridge keeps the
+// normal equations XᵀX β = Xᵀy numerically stable.
+func solveCenteredOLS(X [][]float64, y []float64) ([]float64, float64, error) {
+	if len(X) == 0 || len(X) != len(y) {
+		return nil, 0, fmt.Errorf("mismatched rows: %d features, %d targets", len(X), len(y))
+	}
+	k := len(X[0])
+	xtx := make([][]float64, k)
+	for j := range xtx {
+		xtx[j] = make([]float64, k)
+	}
+	xty := make([]float64, k)
+	var tss float64
+	for t, row := range X {
+		tss += y[t] * y[t]
+		for a := 0; a < k; a++ {
+			xty[a] += row[a] * y[t]
+			for b := 0; b < k; b++ {
+				xtx[a][b] += row[a] * row[b]
+			}
+		}
+	}
+	if tss <= 0 {
+		return nil, 0, errNoVariance
+	}
+	for j := 0; j < k; j++ {
+		if xtx[j][j] < 1e-9 {
+			for b := 0; b < k; b++ {
+				xtx[j][b], xtx[b][j] = 0, 0
+			}
+			xtx[j][j], xty[j] = 1, 0
+			continue
+		}
+		xtx[j][j] *= 1 + 1e-9
+	}
+
+	beta, err := solveSymmetric(xtx, xty)
+	if err != nil {
+		return nil, 0, err
+	}
+	if beta == nil {
+		return nil, 0, errors.New("normal equations are not positive definite")
+	}
+	var rss float64
+	for t, row := range X {
+		residual := y[t]
+		for j, x := range row {
+			residual -= beta[j] * x
+		}
+		rss += residual * residual
+	}
+	return beta, 1 - rss/tss, nil
+}
+
 // fDistSurvival computes P(F > f) for F ~ F(d1, d2)
 func fDistSurvival(f, d1, d2 float64) float64 {
 	if f <= 0 {
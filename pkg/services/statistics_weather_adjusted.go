@@ -0,0 +1,322 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// weatherAdjustmentMinDays 気象回帰を当てはめるのに必要な、気象データがある日の最小日数
+const weatherAdjustmentMinDays = 28
+
+// weatherAdjustmentMinRSquared 気象条件の説明力がこれ未満なら移動平均の期待値にフォールバック
+const weatherAdjustmentMinRSquared = 0.1
+
+// weatherFeatureNames 回帰に使う特徴量（いずれも平均的な気象条件からの差）
+var weatherFeatureNames = []string{"temperature", "temperature_squared", "humidity", "rain"}
+
+// weatherSalesFit 日次売上を同日の気象条件で回帰した結果
+type weatherSalesFit struct {
+	coefficients []float64 // weatherFeatureNames の順
+	meanSales    float64
+	meanTemp     float64
+	tempVariance float64 // 気温の二乗項を中心化するための (気温 - 平均)² の平均
+	meanHumidity float64
+	rainRate     float64 // 雨の日の割合
+	rSquared     float64
+	sampleSize   int
+}
+
+// hasWeatherObservation 気象データが結合されている日か
+func hasWeatherObservation(d models.WeatherSalesData) bool {
+	return d.Weather != "" || d.Temperature != 0 || d.Humidity != 0
+}
+
+// isRainyDay 降水量1mm以上、または天気に「雨」を含む日
+func isRainyDay(d models.WeatherSalesData) bool {
+	return rainyConditions(d.Precipitation, d.Weather)
+}
+
+// features 平均的な気象条件を0とした特徴量ベクトル
+func (f *weatherSalesFit) features(d models.WeatherSalesData) []float64 {
+	dt := d.Temperature - f.meanTemp
+	rain := 0.0
+	if isRainyDay(d) {
+		rain = 1
+	}
+	return []float64{dt, dt*dt - f.tempVariance, d.Humidity - f.meanHumidity, rain - f.rainRate}
+}
+
+// contribution 平均的な気象条件の日と比べて、その日の気象条件で増減する売上（気象データがない日は0）
+func (f *weatherSalesFit) contribution(d models.WeatherSalesData) float64 {
+	if !hasWeatherObservation(d) {
+		return 0
+	}
+	var total float64
+	for j, x := range f.features(d) {
+		total += f.coefficients[j] * x
+	}
+	return total
+}
+
+// fitWeatherSalesModel 日次売上を気温・気温²・湿度・雨で最小二乗回帰する
+// 補完した気象データの日は回帰に使わない
+func fitWeatherSalesModel(data []models.WeatherSalesData) (*weatherSalesFit, error) {
+	var observed []models.WeatherSalesData
+	for _, d := range data {
+		if hasWeatherObservation(d) && !d.WeatherImputed {
+			observed = append(observed, d)
+		}
+	}
+	n := len(observed)
+	if n < weatherAdjustmentMinDays {
+		return nil, fmt.Errorf("気象データがある日が%d日しかありません（最低%d日必要）", n, weatherAdjustmentMinDays)
+	}
+
+	fit := &weatherSalesFit{sampleSize: n}
+	for _, d := range observed {
+		fit.meanSales += d.Sales
+		fit.meanTemp += d.Temperature
+		fit.meanHumidity += d.Humidity
+		if isRainyDay(d) {
+			fit.rainRate++
+		}
+	}
+	fit.meanSales /= float64(n)
+	fit.meanTemp /= float64(n)
+	fit.meanHumidity /= float64(n)
+	fit.rainRate /= float64(n)
+	for _, d := range observed {
+		dt := d.Temperature - fit.meanTemp
+		fit.tempVariance += dt * dt
+	}
+	fit.tempVariance /= float64(n)
+
+	// 特徴量・売上とも中心化する（変動のない特徴量（雨の日がない等）は係数0）
+	x := make([][]float64, n)
+	y := make([]float64, n)
+	for t, d := range observed {
+		x[t] = fit.features(d)
+		y[t] = d.Sales - fit.meanSales
+	}
+	beta, rSquared, err := solveCenteredOLS(x, y)
+	if errors.Is(err, errNoVariance) {
+		return nil, fmt.Errorf("売上に変動がありません")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("気象回帰の係数を求められませんでした")
+	}
+	fit.coefficients, fit.rSquared = beta, rSquared
+	return fit, nil
+}
+
+// JoinWeather 販売データに同日の気象データ（気温・湿度・降水量・天気）を結合したコピーを返す
+func (s *StatisticsService) JoinWeather(salesData []models.WeatherSalesData, regionCode string) ([]models.WeatherSalesData, error) {
+	if s.weatherService == nil {
+		return nil, fmt.Errorf("気象サービスが利用できません")
+	}
+
+	var startDate, endDate time.Time
+	for _, d := range salesData {
+		t, err := time.Parse("2006-01-02", d.Date)
+		if err != nil {
+			continue
+		}
+		if startDate.IsZero() || t.Before(startDate) {
+			startDate = t
+		}
+		if endDate.IsZero() || t.After(endDate) {
+			endDate = t
+		}
+	}
+	if startDate.IsZero() {
+		return nil, fmt.Errorf("販売データに有効な日付がありません")
+	}
+
+	weatherData, err := s.weatherService.GetHistoricalWeatherData(regionCode, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("気象データの取得に失敗: %w", err)
+	}
+	weatherByDate := make(map[string]HistoricalWeatherData, len(weatherData))
+	for _, w := range weatherData {
+		weatherByDate[w.Date] = w
+	}
+
+	joined := make([]models.WeatherSalesData, len(salesData))
+	matches := 0
+	for i, d := range salesData {
+		if w, ok := weatherByDate[d.Date]; ok {
+			d.Temperature = w.Temperature
+			d.Humidity = w.Humidity
+			d.Precipitation = w.Precipitation
+			d.Weather = w.Weather
+			d.WeatherImputed = w.IsImputed()
+			matches++
+		}
+		joined[i] = d
+	}
+	log.Printf("🌤️ 販売データ %d件のうち %d件に気象データを結合しました（地域コード: %s）", len(salesData), matches, regionCode)
+	return joined, nil
+}
+
+// sumSalesByDate 同じ日付の行（店舗別・明細別など）の売上を合計し、日付順に並べる
+// 気象データは日付で結合しているため、同じ日の行はどれも同じ値を持つ
+func sumSalesByDate(data []models.WeatherSalesData) []models.WeatherSalesData {
+	byDate := make(map[string]int, len(data))
+	var result []models.WeatherSalesData
+	for _, d := range data {
+		if i, ok := byDate[d.Date]; ok {
+			result[i].Sales += d.Sales
+			continue
+		}
+		byDate[d.Date] = len(result)
+		result = append(result, d)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result
+}
+
+// DetectWeatherAdjustedAnomalies 期待値を「気象調整後の移動平均 + その日の気象条件による増減」とし、
+// 気象条件でも説明できない残差だけで異常を判定する
+// 気象データが少ない・説明力が低い製品は移動平均による通常の異常検知にフォールバックする
+func (s *StatisticsService) DetectWeatherAdjustedAnomalies(data []models.WeatherSalesData, productID, productName, granularity string) ([]models.AnomalyDetection, models.WeatherAdjustment) {
+	displayName := productName
+	if displayName == "" {
+		displayName = productID
+	}
+	if granularity == "" {
+		granularity = "weekly"
+	}
+
+	sorted := sumSalesByDate(data)
+	sales := make([]float64, len(sorted))
+	dates := make([]string, len(sorted))
+	for i, d := range sorted {
+		sales[i] = d.Sales
+		dates[i] = d.Date
+	}
+
+	adjustment := models.WeatherAdjustment{ProductID: productID, ProductName: productName}
+	fit, err := fitWeatherSalesModel(sorted)
+	if err == nil && fit.rSquared < weatherAdjustmentMinRSquared {
+		err = fmt.Errorf("気象条件による説明力が低いため（R²=%.2f）", fit.rSquared)
+	}
+	if fit != nil {
+		adjustment.SampleSize = fit.sampleSize
+		adjustment.RSquared = roundTo(fit.rSquared, 3)
+		adjustment.MeanSales = roundTo(fit.meanSales, 2)
+	}
+	if err != nil {
+		adjustment.Reason = err.Error() + "、移動平均を期待値にしました"
+		log.Printf("[気象調整@%s] %s", displayName, adjustment.Reason)
+		return s.DetectAnomaliesWithGranularity(sales, dates, productID, productName, granularity), adjustment
+	}
+
+	adjustment.Applied = true
+	adjustment.Coefficients = make(map[string]float64, len(weatherFeatureNames))
+	for j, name := range weatherFeatureNames {
+		adjustment.Coefficients[name] = roundTo(fit.coefficients[j], 4)
+	}
+	adjustment.MeanWeather = map[string]float64{
+		"temperature": roundTo(fit.meanTemp, 2),
+		"humidity":    roundTo(fit.meanHumidity, 2),
+		"rain_rate":   roundTo(fit.rainRate, 3),
+	}
+
+	// 日ごとの気象による増減と、期間の気象の要約に使う値
+	contributions := make([]float64, len(sorted))
+	temperatures := make([]float64, len(sorted))
+	observedDays := make([]float64, len(sorted))
+	rainyDays := make([]float64, len(sorted))
+	for i, d := range sorted {
+		contributions[i] = fit.contribution(d)
+		if hasWeatherObservation(d) {
+			temperatures[i] = d.Temperature
+			observedDays[i] = 1
+		}
+		if isRainyDay(d) {
+			rainyDays[i] = 1
+		}
+	}
+
+	keys := dates
+	if granularity != "daily" {
+		// 同じ日付列で集約するので、期間キーの並びはすべて一致する
+		sales, keys = s.aggregateDataForAnomalyDetection(sales, dates, granularity)
+		contributions, _ = s.aggregateDataForAnomalyDetection(contributions, dates, granularity)
+		temperatures, _ = s.aggregateDataForAnomalyDetection(temperatures, dates, granularity)
+		observedDays, _ = s.aggregateDataForAnomalyDetection(observedDays, dates, granularity)
+		rainyDays, _ = s.aggregateDataForAnomalyDetection(rainyDays, dates, granularity)
+	}
+
+	windowSize, percentageThreshold := anomalyWindowFor(granularity)
+	if len(sales) < windowSize {
+		log.Printf("[気象調整@%s] データが少なく、移動平均を計算できません（%d件 < %d件）", displayName, len(sales), windowSize)
+		return []models.AnomalyDetection{}, adjustment
+	}
+
+	// 気象による増減を除いた系列の移動平均をベースラインにする
+	adjusted := make([]float64, len(sales))
+	for i := range sales {
+		adjusted[i] = sales[i] - contributions[i]
+	}
+
+	var anomalies []models.AnomalyDetection
+	for i := windowSize; i < len(sales); i++ {
+		window := adjusted[i-windowSize : i]
+		baseline := calculateMean(window)
+		expected := baseline + contributions[i]
+		residual := sales[i] - expected
+
+		if baseline <= 0 || math.Abs(residual) <= baseline*percentageThreshold {
+			continue
+		}
+
+		anomalyType := "急増"
+		if residual < 0 {
+			anomalyType = "急減"
+		}
+		var zScore float64
+		if stdDev := calculateStandardDeviation(window); stdDev > 0 {
+			zScore = residual / stdDev
+		}
+
+		anomalies = append(anomalies, models.AnomalyDetection{
+			Date:                keys[i],
+			ProductID:           productID,
+			ProductName:         productName,
+			ActualValue:         sales[i],
+			ExpectedValue:       roundTo(expected, 2),
+			Deviation:           roundTo(math.Abs(residual), 2),
+			ZScore:              zScore,
+			AnomalyType:         anomalyType,
+			Severity:            s.calculateSeverity(math.Abs(zScore)),
+			Granularity:         granularity,
+			ExpectationModel:    "weather_regression",
+			WeatherContribution: roundTo(contributions[i], 2),
+			Residual:            roundTo(residual, 2),
+			WeatherSummary:      weatherSummaryFor(temperatures[i], observedDays[i], rainyDays[i], fit.meanTemp),
+		})
+	}
+
+	log.Printf("[気象調整@%s] 粒度: %s / R²=%.2f の気象回帰を期待値に使い %d 件の異常を検出しました", displayName, granularity, fit.rSquared, len(anomalies))
+	return anomalies, adjustment
+}
+
+// weatherSummaryFor 期間の気象条件の要約（例: 平均気温31.2℃・通常より+4.1℃、雨の日2日）
+func weatherSummaryFor(temperatureTotal, observedDays, rainyDays, meanTemp float64) string {
+	if observedDays == 0 {
+		return ""
+	}
+	averageTemp := temperatureTotal / observedDays
+	summary := fmt.Sprintf("平均気温%.1f℃・通常より%+.1f℃", averageTemp, averageTemp-meanTemp)
+	if rainyDays > 0 {
+		summary += fmt.Sprintf("、雨の日%.0f日", rainyDays)
+	}
+	return summary
+}
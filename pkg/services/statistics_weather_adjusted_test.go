@@ -0,0 +1,141 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// heatwaveFixture 12週の日次データ。売上は気温に比例し、8週目は猛暑（+10℃）、11週目は欠品で半減
+func heatwaveFixture() []models.WeatherSalesData {
+	start := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC) // 月曜日
+	noise := []float64{2, -1, 3, -2, 0, 1, -3}
+	var data []models.WeatherSalesData
+	for day := 0; day < 12*7; day++ {
+		week := day / 7
+		temperature := 25 + float64(day%5) - 2
+		if week == 7 {
+			temperature += 10
+		}
+		sales := 100 + 5*(temperature-25) + noise[day%len(noise)]
+		if week == 10 {
+			sales /= 2
+		}
+		data = append(data, models.WeatherSalesData{
+			Date:        start.AddDate(0, 0, day).Format("2006-01-02"),
+			ProductID:   "P001",
+			ProductName: "冷たい飲料",
+			Sales:       sales,
+			Temperature: temperature,
+			Humidity:    60 + float64(day%3),
+			Weather:     "晴れ",
+		})
+	}
+	return data
+}
+
+func TestDetectWeatherAdjustedAnomalies(t *testing.T) {
+	s := &StatisticsService{}
+	data := heatwaveFixture()
+
+	// 移動平均では猛暑の週も急増として検出される
+	var sales []float64
+	var dates []string
+	for _, d := range data {
+		sales = append(sales, d.Sales)
+		dates = append(dates, d.Date)
+	}
+	baseline := s.DetectAnomaliesWithGranularity(sales, dates, "P001", "冷たい飲料", "weekly")
+	if len(baseline) == 0 || baseline[0].Date != "2024-W30" || baseline[0].ExpectationModel != "moving_average" {
+		t.Fatalf("Moving average anomalies = %+v, expected the heatwave week", baseline)
+	}
+
+	anomalies, adjustment := s.DetectWeatherAdjustedAnomalies(data, "P001", "冷たい飲料", "weekly")
+	if !adjustment.Applied || adjustment.RSquared < 0.5 || adjustment.SampleSize != 84 {
+		t.Fatalf("Adjustment = %+v", adjustment)
+	}
+	if math.Abs(adjustment.Coefficients["temperature"]-5) > 1 {
+		t.Errorf("Temperature coefficient = %f, expected about 5", adjustment.Coefficients["temperature"])
+	}
+
+	// 気温で説明できる猛暑の週は異常にならず、欠品の週だけが残る
+	if len(anomalies) != 1 {
+		t.Fatalf("Anomalies = %+v, expected only the stockout week", anomalies)
+	}
+	anomaly := anomalies[0]
+	if anomaly.Date != "2024-W33" || anomaly.AnomalyType != "急減" || anomaly.ExpectationModel != "weather_regression" {
+		t.Errorf("Anomaly = %+v", anomaly)
+	}
+	if math.Abs(anomaly.ExpectedValue+anomaly.Residual-anomaly.ActualValue) > 0.05 || anomaly.Residual >= 0 {
+		t.Errorf("Expected %.2f + residual %.2f != actual %.2f", anomaly.ExpectedValue, anomaly.Residual, anomaly.ActualValue)
+	}
+	if !strings.HasPrefix(anomaly.WeatherSummary, "平均気温") {
+		t.Errorf("Weather summary = %q", anomaly.WeatherSummary)
+	}
+}
+
+func TestDetectWeatherAdjustedAnomaliesSumsRowsPerDay(t *testing.T) {
+	s := &StatisticsService{}
+	// 1日の売上を2店舗の行に分けても、日ごとに合計してから検知する
+	var split []models.WeatherSalesData
+	for _, d := range heatwaveFixture() {
+		d.Sales /= 2
+		split = append(split, d, d)
+	}
+	whole, _ := s.DetectWeatherAdjustedAnomalies(heatwaveFixture(), "P001", "冷たい飲料", "daily")
+	anomalies, adjustment := s.DetectWeatherAdjustedAnomalies(split, "P001", "冷たい飲料", "daily")
+	if !adjustment.Applied || adjustment.SampleSize != 84 || len(anomalies) == 0 || len(anomalies) != len(whole) {
+		t.Fatalf("Anomalies = %d (adjustment %+v), expected %d as with one row per day", len(anomalies), adjustment, len(whole))
+	}
+	for i := range anomalies {
+		if anomalies[i].Date != whole[i].Date || math.Abs(anomalies[i].ExpectedValue-whole[i].ExpectedValue) > 0.01 {
+			t.Errorf("Anomaly %d = %+v, expected %+v", i, anomalies[i], whole[i])
+		}
+	}
+}
+
+func TestDetectWeatherAdjustedAnomaliesFallback(t *testing.T) {
+	s := &StatisticsService{}
+	// 気象データが結合されていなければ移動平均にフォールバックする
+	var data []models.WeatherSalesData
+	for _, d := range heatwaveFixture() {
+		d.Temperature, d.Humidity, d.Weather = 0, 0, ""
+		data = append(data, d)
+	}
+	anomalies, adjustment := s.DetectWeatherAdjustedAnomalies(data, "P001", "", "weekly")
+	if adjustment.Applied || adjustment.Reason == "" {
+		t.Errorf("Adjustment = %+v", adjustment)
+	}
+	for _, anomaly := range anomalies {
+		if anomaly.ExpectationModel != "moving_average" {
+			t.Errorf("Fallback anomaly = %+v", anomaly)
+		}
+	}
+}
+
+func TestGenerateAIQuestionWeatherAdjusted(t *testing.T) {
+	s := &StatisticsService{}
+	question, choices := s.GenerateAIQuestion(models.AnomalyDetection{
+		Date:                "2024-08-05",
+		ProductName:         "冷たい飲料",
+		ActualValue:         180,
+		ExpectedValue:       140,
+		Deviation:           40,
+		AnomalyType:         "急増",
+		ExpectationModel:    "weather_regression",
+		WeatherContribution: 40,
+		Residual:            40,
+		WeatherSummary:      "平均気温33.0℃・通常より+8.0℃",
+	})
+	if !strings.Contains(question, "+40 は期待値に織り込み済み") || !strings.Contains(question, "平均気温33.0℃") {
+		t.Errorf("Question = %q", question)
+	}
+	for _, choice := range choices {
+		if choice == "天候の影響" {
+			t.Errorf("Choices should not offer weather again: %v", choices)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// weatherRegressorMinDays 気象特徴量の回帰に必要な、特徴量がある学習日の最小日数
+const weatherRegressorMinDays = 21
+
+// WeatherFeatureRegressors 需要予測に回帰要因として使う気象特徴量
+type WeatherFeatureRegressors struct {
+	Names []string                   // 使う特徴量（WeatherFeatureCatalog の名前）
+	Days  []models.WeatherFeatureDay // 学習期間と予測期間の日ごとの特徴量（予測期間の日がなければその日は補正しない）
+}
+
+// weatherFeatureFit 基本モデルの残差を気象特徴量で回帰した結果
+type weatherFeatureFit struct {
+	regressors []models.WeatherFeatureRegressor
+	values     map[string]map[string]float64 // 日付 → 特徴量名 → 値
+	rSquared   float64
+	sampleSize int
+}
+
+// contribution 学習期間の平均的な気象条件と比べて、その日の特徴量で増減する売上（特徴量がない日は0）
+func (f *weatherFeatureFit) contribution(date string) float64 {
+	values, ok := f.values[date]
+	if !ok {
+		return 0
+	}
+	var total float64
+	for _, regressor := range f.regressors {
+		total += regressor.Coefficient * (values[regressor.Name] - regressor.Mean)
+	}
+	return total
+}
+
+// labels 使った特徴量の表示名（例: 不快指数、真夏日の連続日数）
+func (f *weatherFeatureFit) labels() string {
+	labels := make([]string, 0, len(f.regressors))
+	for _, regressor := range f.regressors {
+		labels = append(labels, regressor.Label)
+	}
+	return strings.Join(labels, "、")
+}
+
+// fitWeatherFeatureRegressors 日ごとの残差（実績 - 基本モデルの期待値）を指定した気象特徴量で最小二乗回帰する
+func fitWeatherFeatureRegressors(residuals map[string]float64, weather *WeatherFeatureRegressors) (*weatherFeatureFit, error) {
+	fit := &weatherFeatureFit{values: make(map[string]map[string]float64, len(weather.Days))}
+	for _, name := range weather.Names {
+		definition, ok := WeatherFeatureDefinitionFor(name)
+		if !ok {
+			return nil, fmt.Errorf("不明な気象特徴量です: %s", name)
+		}
+		fit.regressors = append(fit.regressors, models.WeatherFeatureRegressor{WeatherFeatureDefinition: definition})
+	}
+	for _, day := range weather.Days {
+		fit.values[day.Date] = day.Values
+	}
+
+	var dates []string
+	var meanResidual float64
+	for date, residual := range residuals {
+		if _, ok := fit.values[date]; ok {
+			dates = append(dates, date)
+			meanResidual += residual
+		}
+	}
+	n := len(dates)
+	if n < weatherRegressorMinDays {
+		return nil, fmt.Errorf("気象特徴量がある学習日が%d日しかありません（最低%d日必要）", n, weatherRegressorMinDays)
+	}
+	fit.sampleSize = n
+	meanResidual /= float64(n)
+
+	for j := range fit.regressors {
+		var mean float64
+		for _, date := range dates {
+			mean += fit.values[date][fit.regressors[j].Name]
+		}
+		fit.regressors[j].Mean = mean / float64(n)
+	}
+
+	// 特徴量・残差とも中心化する（学習期間に変動のない特徴量（真夏日がない等）は係数0）
+	x := make([][]float64, n)
+	y := make([]float64, n)
+	for t, date := range dates {
+		x[t] = make([]float64, len(fit.regressors))
+		for j, regressor := range fit.regressors {
+			x[t][j] = fit.values[date][regressor.Name] - regressor.Mean
+		}
+		y[t] = residuals[date] - meanResidual
+	}
+	beta, rSquared, err := solveCenteredOLS(x, y)
+	if errors.Is(err, errNoVariance) {
+		return nil, fmt.Errorf("基本モデルの残差に変動がありません")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("気象特徴量の係数を求められませんでした")
+	}
+	for j := range fit.regressors {
+		fit.regressors[j].Coefficient = beta[j]
+	}
+	fit.rSquared = rSquared
+	return fit, nil
+}
+
+// output 出力用に係数・平均を丸めた回帰要因（予測の計算には丸める前の値を使う）
+func (f *weatherFeatureFit) output() []models.WeatherFeatureRegressor {
+	regressors := make([]models.WeatherFeatureRegressor, len(f.regressors))
+	for j, regressor := range f.regressors {
+		regressor.Coefficient = roundTo(regressor.Coefficient, 4)
+		regressor.Mean = roundTo(regressor.Mean, 4)
+		regressors[j] = regressor
+	}
+	return regressors
+}
+
+// BacktestWeatherForecasts 直近の予測期間分を検証期間として、気象特徴量に実際の天候を使った場合と、
+// 検証期間の開始時点で発表済みだった予報（予報アーカイブ）を使った場合の予測精度を比較する
+func (s *StatisticsService) BacktestWeatherForecasts(
+	productID string,
+	productName string,
+	historicalData []models.SalesDataPoint,
+	period string,
+	locationKey string,
+	events []models.EventRegressor,
+	names []string,
+) (*models.WeatherForecastBacktestResult, error) {
+	if s.weatherService == nil || len(names) == 0 {
+		return nil, fmt.Errorf("気象特徴量が指定されていません")
+	}
+	holdoutDays := forecastDaysFor(period)
+	if len(historicalData) < 14+holdoutDays {
+		return nil, fmt.Errorf("バックテストには最低%d日分のデータが必要です", 14+holdoutDays)
+	}
+
+	train := historicalData[:len(historicalData)-holdoutDays]
+	holdout := historicalData[len(historicalData)-holdoutDays:]
+	actual := make(map[string]float64, len(holdout))
+	for _, point := range holdout {
+		actual[point.Date] = point.Sales
+	}
+	trainStart, err := time.ParseInLocation("2006-01-02", train[0].Date, jst)
+	if err != nil {
+		return nil, fmt.Errorf("学習期間の開始日が不正です: %w", err)
+	}
+	holdoutStart, err := time.ParseInLocation("2006-01-02", holdout[0].Date, jst)
+	if err != nil {
+		return nil, fmt.Errorf("検証期間の開始日が不正です: %w", err)
+	}
+	holdoutEnd, err := time.ParseInLocation("2006-01-02", holdout[len(holdout)-1].Date, jst)
+	if err != nil {
+		return nil, fmt.Errorf("検証期間の終了日が不正です: %w", err)
+	}
+
+	observed, err := s.weatherService.GetWeatherFeatures(locationKey, trainStart, holdoutEnd, false)
+	if err != nil {
+		return nil, err
+	}
+	replayed, forecastDays, err := s.weatherService.GetWeatherFeaturesAsOf(locationKey, trainStart, holdoutStart)
+	if err != nil {
+		return nil, err
+	}
+
+	withObserved, err := s.ForecastProductDemandWithRegressors(productID, productName, train, period, locationKey, events, &WeatherFeatureRegressors{Names: names, Days: observed})
+	if err != nil {
+		return nil, err
+	}
+	withForecasts, err := s.ForecastProductDemandWithRegressors(productID, productName, train, period, locationKey, events, &WeatherFeatureRegressors{Names: names, Days: replayed})
+	if err != nil {
+		return nil, err
+	}
+
+	observedAccuracy, matched := forecastAccuracy(withObserved.DailyBreakdown, actual)
+	forecastAccuracyResult, _ := forecastAccuracy(withForecasts.DailyBreakdown, actual)
+	if matched == 0 {
+		return nil, fmt.Errorf("検証期間の実績と予測の日付が一致しません")
+	}
+
+	result := &models.WeatherForecastBacktestResult{
+		HoldoutStart:          holdout[0].Date,
+		HoldoutEnd:            holdout[len(holdout)-1].Date,
+		HoldoutDays:           matched,
+		ForecastDays:          forecastDays,
+		IssuedBefore:          holdoutStart.Format(time.RFC3339),
+		WithObservedWeather:   observedAccuracy,
+		WithArchivedForecasts: forecastAccuracyResult,
+		WeatherErrorMAPE:      roundTo(forecastAccuracyResult.MAPE-observedAccuracy.MAPE, 2),
+	}
+	switch {
+	case len(withObserved.WeatherRegressors) == 0:
+		result.Summary = "気象特徴量の回帰ができなかったため、天気予報の誤差は予測に影響しません"
+	case result.WeatherErrorMAPE > 0:
+		result.Summary = fmt.Sprintf("当時の予報を使うとMAPEが%.2fポイント悪化しました（実際の天候 %.2f%% → 予報 %.2f%%）。この差が天気予報の誤差による分です", result.WeatherErrorMAPE, observedAccuracy.MAPE, forecastAccuracyResult.MAPE)
+	default:
+		result.Summary = fmt.Sprintf("当時の予報を使ってもMAPEは悪化しませんでした（実際の天候 %.2f%% → 予報 %.2f%%）", observedAccuracy.MAPE, forecastAccuracyResult.MAPE)
+	}
+	if forecastDays < matched {
+		result.Summary += fmt.Sprintf("。検証期間%d日のうち当時の予報があったのは%d日です", matched, forecastDays)
+	}
+	return result, nil
+}
@@ -7,9 +7,9 @@ import (
 	"time"
 )
 
-// AggregatedPoint represents a weekly/monthly aggregated value over a period.
+// AggregatedPoint represents an hourly/weekly/monthly aggregated value over a period.
 type AggregatedPoint struct {
-	Period    string  // e.g., 2024-W12 or 2024-03
+	Period    string  // e.g., 2024-W12, 2024-03 or 2024-03-05 14:00
 	StartDate string  // YYYY-MM-DD (inclusive)
 	EndDate   string  // YYYY-MM-DD (inclusive)
 	Value     float64 // aggregated value
@@ -213,6 +213,47 @@ func (s *VectorStoreService) Search(ctx context.Context, queryText string, topK
 	return searchResult.GetResult(), nil
 }
 
+// SearchDocuments hunt_documents から分析レポート（type: analysis_report）以外のドキュメントを類似検索
+// BM25側のドキュメント検索と同じ対象にするため、分析レポートはQdrantのフィルタで除外する
+func (s *VectorStoreService) SearchDocuments(ctx context.Context, queryText string, topK uint64) ([]*qdrant.ScoredPoint, error) {
+	queryVector, err := s.azureOpenAIService.CreateEmbedding(ctx, queryText)
+	if err != nil {
+		return nil, fmt.Errorf("クエリテキストのベクトル化に失敗: %w", err)
+	}
+
+	filter := &qdrant.Filter{
+		MustNot: []*qdrant.Condition{
+			{
+				ConditionOneOf: &qdrant.Condition_Field{
+					Field: &qdrant.FieldCondition{
+						Key: "type",
+						Match: &qdrant.Match{
+							MatchValue: &qdrant.Match_Keyword{
+								Keyword: "analysis_report",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	withPayload := true
+	searchResult, err := s.qdrantClient.Search(ctx, &qdrant.SearchPoints{
+		CollectionName: "hunt_documents",
+		Vector:         queryVector,
+		Limit:          topK,
+		Filter:         filter,
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: withPayload}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Qdrantでのドキュメント検索に失敗: %w", err)
+	}
+
+	log.Printf("ドキュメント検索: '%s' に類似した %d 件を取得", queryText, len(searchResult.GetResult()))
+	return searchResult.GetResult(), nil
+}
+
 // SaveAnalysisReport 分析レポートを構造化してQdrantに保存
 func (s *VectorStoreService) SaveAnalysisReport(ctx context.Context, report interface{}, reportType string) error {
 	// レポートをJSON文字列に変換
@@ -235,8 +276,8 @@ func (s *VectorStoreService) SaveAnalysisReport(ctx context.Context, report inte
 	return s.Save(ctx, reportText, metadata)
 }
 
-// SearchAnalysisReports 分析レポートを検索（typeフィルタ付き）
-func (s *VectorStoreService) SearchAnalysisReports(ctx context.Context, query string, topK uint64) ([]*qdrant.ScoredPoint, error) {
+// SearchAnalysisReports 指定したコレクションの分析レポートを検索（typeフィルタ付き）
+func (s *VectorStoreService) SearchAnalysisReports(ctx context.Context, collectionName string, query string, topK uint64) ([]*qdrant.ScoredPoint, error) {
 	// クエリテキストをベクトル化
 	queryVector, err := s.azureOpenAIService.CreateEmbedding(ctx, query)
 	if err != nil {
@@ -244,7 +285,6 @@ func (s *VectorStoreService) SearchAnalysisReports(ctx context.Context, query st
 	}
 
 	// typeフィルタを追加
-	collectionName := "hunt_documents"
 	withPayload := true
 
 	// Qdrantのフィルタ条件を構築
@@ -366,6 +406,12 @@ func (s *VectorStoreService) GetAllAnomalyResponses(ctx context.Context) ([]mode
 		response.ProductID = getStringFromPayload(point.Payload, "product_id")
 		response.Question = getStringFromPayload(point.Payload, "question")
 		response.Answer = getStringFromPayload(point.Payload, "answer")
+		if analysisJSON := getStringFromPayload(point.Payload, "analysis_json"); analysisJSON != "" {
+			var analysis models.AnswerAnalysis
+			if err := json.Unmarshal([]byte(analysisJSON), &analysis); err == nil {
+				response.Analysis = &analysis
+			}
+		}
 
 		if response.AnomalyDate != "" && response.ProductID != "" {
 			responses = append(responses, response)
@@ -381,6 +427,38 @@ func (s *VectorStoreService) GetAllAnomalyResponses(ctx context.Context) ([]mode
 	return responses, nil
 }
 
+// FindUnansweredAnomalies は分析レポートの異常のうち、まだ回答が保存されていないものを返します
+// 回答済みかどうかは「日付-製品ID」で判定し、製品IDが空の異常は除外します
+func (s *VectorStoreService) FindUnansweredAnomalies(ctx context.Context) ([]models.AnomalyDetection, error) {
+	reports, err := s.GetAllAnalysisReports(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("分析レポートの取得に失敗しました: %w", err)
+	}
+
+	responses, err := s.GetAllAnomalyResponses(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("回答済み異常の取得に失敗しました: %w", err)
+	}
+
+	answeredAnomalies := make(map[string]struct{})
+	for _, res := range responses {
+		key := fmt.Sprintf("%s-%s", res.AnomalyDate, res.ProductID)
+		answeredAnomalies[key] = struct{}{}
+	}
+
+	unansweredAnomalies := make([]models.AnomalyDetection, 0)
+	for _, report := range reports {
+		for _, anomaly := range report.Anomalies {
+			key := fmt.Sprintf("%s-%s", anomaly.Date, anomaly.ProductID)
+			if _, found := answeredAnomalies[key]; !found && anomaly.ProductID != "" {
+				unansweredAnomalies = append(unansweredAnomalies, anomaly)
+			}
+		}
+	}
+
+	return unansweredAnomalies, nil
+}
+
 // GetAnalysisReportByID はIDで単一の分析レポートを取得します
 func (s *VectorStoreService) GetAnalysisReportByID(ctx context.Context, reportID string) (*models.AnalysisReport, error) {
 	collectionName := "hunt_documents"
@@ -1126,7 +1204,13 @@ func (s *VectorStoreService) GetSalesAggregatedSeries(ctx context.Context, produ
 		}
 		out = append(out, AggregatedPoint{Period: getStringFromPayload(p.Payload, "period"), StartDate: sd, EndDate: ed, Value: v})
 	}
-	sort.Slice(out, func(i, j int) bool { return out[i].StartDate < out[j].StartDate })
+	// hourly は同じ日に複数の時間帯があるため、期間でも並べる
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].StartDate != out[j].StartDate {
+			return out[i].StartDate < out[j].StartDate
+		}
+		return out[i].Period < out[j].Period
+	})
 	return out, nil
 }
 
@@ -1420,6 +1504,8 @@ func (s *VectorStoreService) SaveChatHistory(ctx context.Context, entry models.C
 		"intent":     entry.Metadata.Intent,
 		"product_id": entry.Metadata.ProductID,
 		"date_range": entry.Metadata.DateRange,
+		"message":    entry.Message,
+		"created_at": entry.CreatedAt.UnixNano(), // 時系列順の並べ替え用
 	}
 
 	// タグをJSON文字列として追加
@@ -1504,52 +1590,270 @@ func (s *VectorStoreService) SearchChatHistory(ctx context.Context, query string
 	// 結果を ChatHistoryEntry に変換
 	var entries []models.ChatHistoryEntry
 	for _, result := range results {
-		payload := result.GetPayload()
-
-		entry := models.ChatHistoryEntry{
-			ID:        result.Id.GetUuid(),
-			SessionID: getStringFromPayload(payload, "session_id"),
-			UserID:    getStringFromPayload(payload, "user_id"),
-			Role:      getStringFromPayload(payload, "role"),
-			Message:   getStringFromPayload(payload, "text"), // 元のテキストフィールドから取得
-			Timestamp: getStringFromPayload(payload, "timestamp"),
-			Metadata: models.Metadata{
-				Intent:         getStringFromPayload(payload, "intent"),
-				ProductID:      getStringFromPayload(payload, "product_id"),
-				DateRange:      getStringFromPayload(payload, "date_range"),
-				RelevanceScore: float64(result.GetScore()),
-			},
+		entry := chatHistoryEntryFromPayload(result.Id.GetUuid(), result.GetPayload())
+		entry.Metadata.RelevanceScore = float64(result.GetScore())
+		entries = append(entries, entry)
+	}
+
+	log.Printf("チャット履歴検索: %d 件の関連する会話を取得しました", len(entries))
+	return entries, nil
+}
+
+// GetRecentChatHistory 最近のチャット履歴を取得（時系列順）
+// セッションのトランスクリプトから末尾limit件を返す
+func (s *VectorStoreService) GetRecentChatHistory(ctx context.Context, sessionID string, limit int) ([]models.ChatHistoryEntry, error) {
+	transcript, err := s.GetChatTranscript(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(transcript) > limit {
+		transcript = transcript[len(transcript)-limit:]
+	}
+	return transcript, nil
+}
+
+// GetChatTranscript セッションの全発話を時系列順（古い順）に取得
+func (s *VectorStoreService) GetChatTranscript(ctx context.Context, sessionID string) ([]models.ChatHistoryEntry, error) {
+	filter := &qdrant.Filter{
+		Must: []*qdrant.Condition{
+			{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "type", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: "chat_history"}}}}},
+			{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "session_id", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: sessionID}}}}},
+		},
+	}
+	entries, err := s.scrollChatHistory(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("トランスクリプトの取得に失敗: %w", err)
+	}
+	sortChatHistoryByTime(entries)
+	return entries, nil
+}
+
+// ListChatSessions チャットセッションの一覧を最終発話の新しい順に取得（userIDが空の場合は全ユーザー）
+func (s *VectorStoreService) ListChatSessions(ctx context.Context, userID string) ([]models.ChatSessionInfo, error) {
+	conditions := []*qdrant.Condition{
+		{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "type", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: "chat_history"}}}}},
+	}
+	if userID != "" {
+		conditions = append(conditions, &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "user_id", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: userID}}}}})
+	}
+	entries, err := s.scrollChatHistory(ctx, &qdrant.Filter{Must: conditions})
+	if err != nil {
+		return nil, fmt.Errorf("チャットセッション一覧の取得に失敗: %w", err)
+	}
+	sortChatHistoryByTime(entries)
+
+	sessionIndex := make(map[string]int)
+	var sessions []models.ChatSessionInfo
+	for _, entry := range entries {
+		if entry.SessionID == "" {
+			continue
 		}
+		idx, exists := sessionIndex[entry.SessionID]
+		if !exists {
+			idx = len(sessions)
+			sessionIndex[entry.SessionID] = idx
+			sessions = append(sessions, models.ChatSessionInfo{
+				SessionID:      entry.SessionID,
+				UserID:         entry.UserID,
+				FirstMessageAt: entry.Timestamp,
+			})
+		}
+		session := &sessions[idx]
+		session.TurnCount++
+		session.LastMessageAt = entry.Timestamp
+		if session.Title == "" && entry.Role == "user" {
+			session.Title = truncateRunes(entry.Message, 40)
+		}
+	}
 
-		// タグの復元
-		if tagsJSON := getStringFromPayload(payload, "tags"); tagsJSON != "" {
-			var tags []string
-			if err := json.Unmarshal([]byte(tagsJSON), &tags); err == nil {
-				entry.Tags = tags
-			}
+	// 要約の有無を付与（要約コレクションが空・未作成の場合は全てfalse）
+	if summaryPoints, err := s.ScrollAllPoints(ctx, chatSessionSummaryCollection, 10000); err == nil {
+		summarized := make(map[string]bool)
+		for _, point := range summaryPoints {
+			summarized[getStringFromPayload(point.Payload, "session_id")] = true
+		}
+		for i := range sessions {
+			sessions[i].HasSummary = summarized[sessions[i].SessionID]
 		}
+	}
 
-		// キーワードの復元
-		if keywordsJSON := getStringFromPayload(payload, "keywords"); keywordsJSON != "" {
-			var keywords []string
-			if err := json.Unmarshal([]byte(keywordsJSON), &keywords); err == nil {
-				entry.Metadata.TopicKeywords = keywords
-			}
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].LastMessageAt > sessions[j].LastMessageAt })
+	return sessions, nil
+}
+
+// DeleteChatSession セッションの全発話と要約を削除し、削除した発話数を返す
+func (s *VectorStoreService) DeleteChatSession(ctx context.Context, sessionID string) (int, error) {
+	transcript, err := s.GetChatTranscript(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(transcript) > 0 {
+		ids := make([]*qdrant.PointId, 0, len(transcript))
+		for _, entry := range transcript {
+			ids = append(ids, &qdrant.PointId{PointIdOptions: &qdrant.PointId_Uuid{Uuid: entry.ID}})
 		}
+		waitDelete := true
+		_, err = s.qdrantClient.Delete(ctx, &qdrant.DeletePoints{
+			CollectionName: "chat_history",
+			Wait:           &waitDelete,
+			Points: &qdrant.PointsSelector{
+				PointsSelectorOneOf: &qdrant.PointsSelector_Points{
+					Points: &qdrant.PointsIdsList{Ids: ids},
+				},
+			},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("セッションの発話削除に失敗: %w", err)
+		}
+	}
 
-		entries = append(entries, entry)
+	if err := s.DeletePoint(ctx, chatSessionSummaryCollection, chatSessionSummaryID(sessionID)); err != nil {
+		log.Printf("⚠️ セッション要約の削除に失敗: %v", err)
 	}
 
-	log.Printf("チャット履歴検索: %d 件の関連する会話を取得しました", len(entries))
+	log.Printf("🗑️ チャットセッション %s を削除しました（%d件の発話）", sessionID, len(transcript))
+	return len(transcript), nil
+}
+
+// chatSessionSummaryCollection セッション要約を保存するコレクション
+const chatSessionSummaryCollection = "chat_session_summaries"
+
+// chatSessionSummaryID セッションIDから要約ポイントの安定したIDを生成
+func chatSessionSummaryID(sessionID string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte("chat_session_summary:"+sessionID)).String()
+}
+
+// SaveChatSessionSummary セッション要約を保存（既存の要約は上書き）
+func (s *VectorStoreService) SaveChatSessionSummary(ctx context.Context, summary models.ChatSessionSummary) error {
+	metadata := map[string]interface{}{
+		"type":             "chat_session_summary",
+		"session_id":       summary.SessionID,
+		"summarized_turns": summary.SummarizedTurns,
+		"last_turn_at":     summary.LastTurnAt,
+		"updated_at":       summary.UpdatedAt,
+	}
+	return s.StoreDocument(ctx, chatSessionSummaryCollection, chatSessionSummaryID(summary.SessionID), summary.Summary, metadata)
+}
+
+// GetChatSessionSummary セッション要約を取得（要約がない場合はnil）
+func (s *VectorStoreService) GetChatSessionSummary(ctx context.Context, sessionID string) (*models.ChatSessionSummary, error) {
+	if err := s.ensureCollection(ctx, chatSessionSummaryCollection); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+
+	points, err := s.qdrantClient.Get(ctx, &qdrant.GetPoints{
+		CollectionName: chatSessionSummaryCollection,
+		Ids:            []*qdrant.PointId{{PointIdOptions: &qdrant.PointId_Uuid{Uuid: chatSessionSummaryID(sessionID)}}},
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("セッション要約の取得に失敗: %w", err)
+	}
+	if len(points.GetResult()) == 0 {
+		return nil, nil
+	}
+
+	payload := points.GetResult()[0].Payload
+	summary := &models.ChatSessionSummary{
+		SessionID:  sessionID,
+		Summary:    getStringFromPayload(payload, "text"),
+		LastTurnAt: getStringFromPayload(payload, "last_turn_at"),
+		UpdatedAt:  getStringFromPayload(payload, "updated_at"),
+	}
+	if v, ok := payload["summarized_turns"]; ok {
+		summary.SummarizedTurns = int(v.GetIntegerValue())
+	}
+	return summary, nil
+}
+
+// scrollChatHistory フィルタに一致するチャット履歴をページングしながら全件取得
+func (s *VectorStoreService) scrollChatHistory(ctx context.Context, filter *qdrant.Filter) ([]models.ChatHistoryEntry, error) {
+	collectionName := "chat_history"
+	if err := s.ensureCollection(ctx, collectionName); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+
+	var entries []models.ChatHistoryEntry
+	var nextOffset *qdrant.PointId
+	for {
+		limit := uint32(256)
+		res, err := s.qdrantClient.Scroll(ctx, &qdrant.ScrollPoints{
+			CollectionName: collectionName,
+			Filter:         filter,
+			Limit:          &limit,
+			WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+			Offset:         nextOffset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range res.GetResult() {
+			entries = append(entries, chatHistoryEntryFromPayload(point.GetId().GetUuid(), point.GetPayload()))
+		}
+		nextOffset = res.NextPageOffset
+		if nextOffset == nil {
+			break
+		}
+	}
 	return entries, nil
 }
 
-// GetRecentChatHistory 最近のチャット履歴を取得（時系列順）
-func (s *VectorStoreService) GetRecentChatHistory(ctx context.Context, sessionID string, limit int) ([]models.ChatHistoryEntry, error) {
-	// この機能は、Qdrantではスコアベースの検索になるため、
-	// 実際には時系列での取得が難しい。代わりにベクトル検索で直近の会話を取得する
-	// 改善案: timestampを使った専用のフィルタリング機能を追加する
-	return s.SearchChatHistory(ctx, "最近の会話", sessionID, "", uint64(limit))
+// chatHistoryEntryFromPayload Qdrantのペイロードから ChatHistoryEntry を復元
+func chatHistoryEntryFromPayload(id string, payload map[string]*qdrant.Value) models.ChatHistoryEntry {
+	// 生のメッセージがない古い履歴は、ベクトル化用テキストで代用する
+	message := getStringFromPayload(payload, "message")
+	if message == "" {
+		message = getStringFromPayload(payload, "text")
+	}
+
+	entry := models.ChatHistoryEntry{
+		ID:        id,
+		SessionID: getStringFromPayload(payload, "session_id"),
+		UserID:    getStringFromPayload(payload, "user_id"),
+		Role:      getStringFromPayload(payload, "role"),
+		Message:   message,
+		Timestamp: getStringFromPayload(payload, "timestamp"),
+		Metadata: models.Metadata{
+			Intent:    getStringFromPayload(payload, "intent"),
+			ProductID: getStringFromPayload(payload, "product_id"),
+			DateRange: getStringFromPayload(payload, "date_range"),
+		},
+	}
+
+	if v, ok := payload["created_at"]; ok && v.GetIntegerValue() != 0 {
+		entry.CreatedAt = time.Unix(0, v.GetIntegerValue())
+	} else if t, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+		entry.CreatedAt = t
+	}
+
+	// タグの復元
+	if tagsJSON := getStringFromPayload(payload, "tags"); tagsJSON != "" {
+		var tags []string
+		if err := json.Unmarshal([]byte(tagsJSON), &tags); err == nil {
+			entry.Tags = tags
+		}
+	}
+
+	// キーワードの復元
+	if keywordsJSON := getStringFromPayload(payload, "keywords"); keywordsJSON != "" {
+		var keywords []string
+		if err := json.Unmarshal([]byte(keywordsJSON), &keywords); err == nil {
+			entry.Metadata.TopicKeywords = keywords
+		}
+	}
+
+	return entry
+}
+
+// sortChatHistoryByTime チャット履歴を作成日時の古い順に並べ替え（同時刻はuser→assistantの順）
+func sortChatHistoryByTime(entries []models.ChatHistoryEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+		}
+		return entries[i].Role == "user" && entries[j].Role != "user"
+	})
 }
 
 // getStringFromPayload ペイロードから文字列値を取得するヘルパー関数
@@ -1685,7 +1989,30 @@ func (s *VectorStoreService) GetAnomalyResponseSession(ctx context.Context, sess
 	}
 
 	// session_jsonフィールドから完全なセッションデータを復元
-	payload := points.GetResult()[0].Payload
+	return anomalyResponseSessionFromPayload(points.GetResult()[0].Payload)
+}
+
+// ListAnomalyResponseSessions 保存されている異常回答セッションを全件取得
+func (s *VectorStoreService) ListAnomalyResponseSessions(ctx context.Context) ([]models.AnomalyResponseSession, error) {
+	points, err := s.scrollPoints(ctx, "anomaly_response_sessions", nil)
+	if err != nil {
+		return nil, fmt.Errorf("異常回答セッションの取得に失敗: %w", err)
+	}
+
+	sessions := make([]models.AnomalyResponseSession, 0, len(points))
+	for _, point := range points {
+		session, err := anomalyResponseSessionFromPayload(point.GetPayload())
+		if err != nil {
+			log.Printf("⚠️ セッションの復元に失敗 (ID: %s): %v", point.GetId().GetUuid(), err)
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, nil
+}
+
+// anomalyResponseSessionFromPayload session_json フィールドから異常回答セッションを復元
+func anomalyResponseSessionFromPayload(payload map[string]*qdrant.Value) (*models.AnomalyResponseSession, error) {
 	sessionJSONValue, ok := payload["session_json"]
 	if !ok {
 		return nil, fmt.Errorf("session_jsonフィールドが見つかりません")
@@ -1707,6 +2034,512 @@ func (s *VectorStoreService) GetAnomalyResponseSession(ctx context.Context, sess
 	return &session, nil
 }
 
+const followUpCollection = "anomaly_follow_ups"
+
+// SaveFollowUpQuestion フォローアップ質問を保存（同じIDの質問は上書き）
+func (s *VectorStoreService) SaveFollowUpQuestion(ctx context.Context, followUp models.FollowUpQuestion) error {
+	followUpJSON, err := json.Marshal(followUp)
+	if err != nil {
+		return fmt.Errorf("フォローアップ質問のJSON化に失敗: %w", err)
+	}
+
+	searchText := fmt.Sprintf("日付: %s\n製品ID: %s\n質問: %s\n回答: %s", followUp.AnomalyDate, followUp.ProductID, followUp.Question, followUp.Answer)
+	metadata := map[string]interface{}{
+		"type":           "follow_up_question",
+		"follow_up_id":   followUp.ID,
+		"session_id":     followUp.SessionID,
+		"anomaly_id":     followUp.AnomalyID,
+		"question_type":  followUp.QuestionType,
+		"scheduled_date": followUp.ScheduledDate,
+		"status":         followUp.Status,
+		"follow_up_json": string(followUpJSON),
+	}
+	if err := s.StoreDocument(ctx, followUpCollection, followUp.ID, searchText, metadata); err != nil {
+		return fmt.Errorf("フォローアップ質問の保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// GetFollowUpQuestion IDからフォローアップ質問を取得
+func (s *VectorStoreService) GetFollowUpQuestion(ctx context.Context, id string) (*models.FollowUpQuestion, error) {
+	if err := s.ensureCollection(ctx, followUpCollection); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+
+	points, err := s.qdrantClient.Get(ctx, &qdrant.GetPoints{
+		CollectionName: followUpCollection,
+		Ids:            []*qdrant.PointId{{PointIdOptions: &qdrant.PointId_Uuid{Uuid: id}}},
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("フォローアップ質問の取得に失敗: %w", err)
+	}
+	if len(points.GetResult()) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrFollowUpNotFound, id)
+	}
+	return followUpFromPayload(points.GetResult()[0].Payload)
+}
+
+// ListFollowUpQuestions フォローアップ質問を取得（status・sessionIDが空の場合は絞り込まない）
+func (s *VectorStoreService) ListFollowUpQuestions(ctx context.Context, status string, sessionID string) ([]models.FollowUpQuestion, error) {
+	filter := &qdrant.Filter{}
+	if status != "" {
+		filter.Must = append(filter.Must, &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "status", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: status}}}}})
+	}
+	if sessionID != "" {
+		filter.Must = append(filter.Must, &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "session_id", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: sessionID}}}}})
+	}
+
+	points, err := s.scrollPoints(ctx, followUpCollection, filter)
+	if err != nil {
+		return nil, fmt.Errorf("フォローアップ質問の取得に失敗: %w", err)
+	}
+
+	followUps := make([]models.FollowUpQuestion, 0, len(points))
+	for _, point := range points {
+		followUp, err := followUpFromPayload(point.GetPayload())
+		if err != nil {
+			log.Printf("⚠️ フォローアップ質問の復元に失敗 (ID: %s): %v", point.GetId().GetUuid(), err)
+			continue
+		}
+		followUps = append(followUps, *followUp)
+	}
+	return followUps, nil
+}
+
+// followUpFromPayload follow_up_json フィールドからフォローアップ質問を復元
+func followUpFromPayload(payload map[string]*qdrant.Value) (*models.FollowUpQuestion, error) {
+	raw := getStringFromPayload(payload, "follow_up_json")
+	if raw == "" {
+		return nil, fmt.Errorf("follow_up_jsonフィールドが見つかりません")
+	}
+	var followUp models.FollowUpQuestion
+	if err := json.Unmarshal([]byte(raw), &followUp); err != nil {
+		return nil, fmt.Errorf("フォローアップ質問のJSON解析に失敗: %w", err)
+	}
+	return &followUp, nil
+}
+
+const businessEventCollection = "business_events"
+
+// SaveBusinessEvent イベントを保存（同じIDのイベントは上書き）
+func (s *VectorStoreService) SaveBusinessEvent(ctx context.Context, event models.BusinessEvent) error {
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("イベントのJSON化に失敗: %w", err)
+	}
+
+	searchText := fmt.Sprintf("イベント種類: %s\nタイトル: %s\n期間: %s〜%s\n製品ID: %s\n詳細: %s",
+		event.EventType, event.Title, event.StartDate, event.EndDate, strings.Join(event.ProductIDs, ", "), event.Description)
+	metadata := map[string]interface{}{
+		"type":        "business_event",
+		"event_id":    event.EventID,
+		"event_type":  event.EventType,
+		"start_date":  event.StartDate,
+		"end_date":    event.EndDate,
+		"product_ids": strings.Join(event.ProductIDs, ","),
+		"source":      event.Source,
+		"event_json":  string(eventJSON),
+	}
+	if err := s.StoreDocument(ctx, businessEventCollection, event.EventID, searchText, metadata); err != nil {
+		return fmt.Errorf("イベントの保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// GetBusinessEvent IDからイベントを取得
+func (s *VectorStoreService) GetBusinessEvent(ctx context.Context, eventID string) (*models.BusinessEvent, error) {
+	if err := s.ensureCollection(ctx, businessEventCollection); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+
+	points, err := s.qdrantClient.Get(ctx, &qdrant.GetPoints{
+		CollectionName: businessEventCollection,
+		Ids:            []*qdrant.PointId{{PointIdOptions: &qdrant.PointId_Uuid{Uuid: eventID}}},
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("イベントの取得に失敗: %w", err)
+	}
+	if len(points.GetResult()) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrBusinessEventNotFound, eventID)
+	}
+	return businessEventFromPayload(points.GetResult()[0].Payload)
+}
+
+// ListBusinessEvents 条件に一致するイベントを開始日の新しい順に取得
+func (s *VectorStoreService) ListBusinessEvents(ctx context.Context, filter models.BusinessEventFilter) ([]models.BusinessEvent, error) {
+	qdrantFilter := &qdrant.Filter{}
+	if filter.EventType != "" {
+		qdrantFilter.Must = append(qdrantFilter.Must, &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "event_type", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: filter.EventType}}}}})
+	}
+
+	points, err := s.scrollPoints(ctx, businessEventCollection, qdrantFilter)
+	if err != nil {
+		return nil, fmt.Errorf("イベントの取得に失敗: %w", err)
+	}
+
+	events := make([]models.BusinessEvent, 0, len(points))
+	for _, point := range points {
+		event, err := businessEventFromPayload(point.GetPayload())
+		if err != nil {
+			log.Printf("⚠️ イベントの復元に失敗 (ID: %s): %v", point.GetId().GetUuid(), err)
+			continue
+		}
+		if filter.ProductID != "" && !containsString(event.ProductIDs, filter.ProductID) {
+			continue
+		}
+		if filter.From != "" && event.EndDate < filter.From {
+			continue
+		}
+		if filter.To != "" && event.StartDate > filter.To {
+			continue
+		}
+		events = append(events, *event)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartDate > events[j].StartDate })
+	return events, nil
+}
+
+// DeleteBusinessEvent イベントを削除
+func (s *VectorStoreService) DeleteBusinessEvent(ctx context.Context, eventID string) error {
+	return s.DeletePoint(ctx, businessEventCollection, eventID)
+}
+
+// businessEventFromPayload event_json フィールドからイベントを復元
+func businessEventFromPayload(payload map[string]*qdrant.Value) (*models.BusinessEvent, error) {
+	raw := getStringFromPayload(payload, "event_json")
+	if raw == "" {
+		return nil, fmt.Errorf("event_jsonフィールドが見つかりません")
+	}
+	var event models.BusinessEvent
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return nil, fmt.Errorf("イベントのJSON解析に失敗: %w", err)
+	}
+	return &event, nil
+}
+
+const anomalyRecordCollection = "anomalies"
+
+// SaveAnomalyRecord 登録済みの異常を保存（同じIDの異常は上書き）
+func (s *VectorStoreService) SaveAnomalyRecord(ctx context.Context, record models.AnomalyRecord) error {
+	recordJSON, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("異常のJSON化に失敗: %w", err)
+	}
+
+	searchText := fmt.Sprintf("日付: %s\n製品: %s (%s)\n粒度: %s\n種類: %s\n状態: %s",
+		record.Period, record.ProductName, record.ProductID, record.Granularity, record.Detection.AnomalyType, record.Status)
+	metadata := map[string]interface{}{
+		"type":         "anomaly_record",
+		"anomaly_id":   record.AnomalyID,
+		"product_id":   record.ProductID,
+		"period":       record.Period,
+		"granularity":  record.Granularity,
+		"status":       record.Status,
+		"assignee":     record.Assignee,
+		"anomaly_json": string(recordJSON),
+	}
+	if err := s.StoreDocument(ctx, anomalyRecordCollection, record.AnomalyID, searchText, metadata); err != nil {
+		return fmt.Errorf("異常の保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// GetAnomalyRecord IDから登録済みの異常を取得
+func (s *VectorStoreService) GetAnomalyRecord(ctx context.Context, anomalyID string) (*models.AnomalyRecord, error) {
+	if err := s.ensureCollection(ctx, anomalyRecordCollection); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+
+	points, err := s.qdrantClient.Get(ctx, &qdrant.GetPoints{
+		CollectionName: anomalyRecordCollection,
+		Ids:            []*qdrant.PointId{{PointIdOptions: &qdrant.PointId_Uuid{Uuid: anomalyID}}},
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("異常の取得に失敗: %w", err)
+	}
+	if len(points.GetResult()) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAnomalyRecordNotFound, anomalyID)
+	}
+	return anomalyRecordFromPayload(points.GetResult()[0].Payload)
+}
+
+// ListAnomalyRecords 条件に一致する登録済みの異常を日付の新しい順に取得
+func (s *VectorStoreService) ListAnomalyRecords(ctx context.Context, filter models.AnomalyFilter) ([]models.AnomalyRecord, error) {
+	qdrantFilter := &qdrant.Filter{}
+	keyword := func(key, value string) *qdrant.Condition {
+		return &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: key, Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: value}}}}}
+	}
+	if filter.Status != "" {
+		statuses := strings.Split(filter.Status, ",")
+		if len(statuses) == 1 {
+			qdrantFilter.Must = append(qdrantFilter.Must, keyword("status", strings.TrimSpace(statuses[0])))
+		} else {
+			statusFilter := &qdrant.Filter{}
+			for _, status := range statuses {
+				statusFilter.Should = append(statusFilter.Should, keyword("status", strings.TrimSpace(status)))
+			}
+			qdrantFilter.Must = append(qdrantFilter.Must, &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Filter{Filter: statusFilter}})
+		}
+	}
+	if filter.ProductID != "" {
+		qdrantFilter.Must = append(qdrantFilter.Must, keyword("product_id", filter.ProductID))
+	}
+	if filter.Granularity != "" {
+		qdrantFilter.Must = append(qdrantFilter.Must, keyword("granularity", filter.Granularity))
+	}
+	if filter.Assignee != "" {
+		qdrantFilter.Must = append(qdrantFilter.Must, keyword("assignee", filter.Assignee))
+	}
+
+	points, err := s.scrollPoints(ctx, anomalyRecordCollection, qdrantFilter)
+	if err != nil {
+		return nil, fmt.Errorf("異常の取得に失敗: %w", err)
+	}
+
+	records := make([]models.AnomalyRecord, 0, len(points))
+	for _, point := range points {
+		record, err := anomalyRecordFromPayload(point.GetPayload())
+		if err != nil {
+			log.Printf("⚠️ 異常の復元に失敗 (ID: %s): %v", point.GetId().GetUuid(), err)
+			continue
+		}
+		if (filter.From != "" && record.Period < filter.From) || (filter.To != "" && record.Period > filter.To) {
+			continue
+		}
+		records = append(records, *record)
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Period != records[j].Period {
+			return records[i].Period > records[j].Period
+		}
+		return records[i].ProductID < records[j].ProductID
+	})
+	return records, nil
+}
+
+// anomalyRecordFromPayload anomaly_json フィールドから登録済みの異常を復元
+func anomalyRecordFromPayload(payload map[string]*qdrant.Value) (*models.AnomalyRecord, error) {
+	raw := getStringFromPayload(payload, "anomaly_json")
+	if raw == "" {
+		return nil, fmt.Errorf("anomaly_jsonフィールドが見つかりません")
+	}
+	var record models.AnomalyRecord
+	if err := json.Unmarshal([]byte(raw), &record); err != nil {
+		return nil, fmt.Errorf("異常のJSON解析に失敗: %w", err)
+	}
+	return &record, nil
+}
+
+const (
+	webhookSubscriptionCollection = "webhook_subscriptions"
+	webhookDeliveryCollection     = "webhook_dead_letters"
+)
+
+// SaveWebhookSubscription Webhook購読を保存（同じIDの購読は上書き）
+func (s *VectorStoreService) SaveWebhookSubscription(ctx context.Context, subscription models.WebhookSubscription) error {
+	subscriptionJSON, err := json.Marshal(subscription)
+	if err != nil {
+		return fmt.Errorf("Webhook購読のJSON化に失敗: %w", err)
+	}
+
+	searchText := fmt.Sprintf("Webhook: %s\nURL: %s\n形式: %s", subscription.Name, subscription.URL, subscription.Format)
+	metadata := map[string]interface{}{
+		"type":              "webhook_subscription",
+		"subscription_id":   subscription.ID,
+		"enabled":           subscription.Enabled,
+		"subscription_json": string(subscriptionJSON),
+	}
+	if err := s.StoreDocument(ctx, webhookSubscriptionCollection, subscription.ID, searchText, metadata); err != nil {
+		return fmt.Errorf("Webhook購読の保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookSubscription IDからWebhook購読を取得
+func (s *VectorStoreService) GetWebhookSubscription(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	if err := s.ensureCollection(ctx, webhookSubscriptionCollection); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+
+	points, err := s.qdrantClient.Get(ctx, &qdrant.GetPoints{
+		CollectionName: webhookSubscriptionCollection,
+		Ids:            []*qdrant.PointId{{PointIdOptions: &qdrant.PointId_Uuid{Uuid: id}}},
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Webhook購読の取得に失敗: %w", err)
+	}
+	if len(points.GetResult()) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookSubscriptionNotFound, id)
+	}
+	return webhookSubscriptionFromPayload(points.GetResult()[0].Payload)
+}
+
+// ListWebhookSubscriptions Webhook購読を作成日時の古い順に取得
+func (s *VectorStoreService) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	points, err := s.scrollPoints(ctx, webhookSubscriptionCollection, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Webhook購読の取得に失敗: %w", err)
+	}
+
+	subscriptions := make([]models.WebhookSubscription, 0, len(points))
+	for _, point := range points {
+		subscription, err := webhookSubscriptionFromPayload(point.GetPayload())
+		if err != nil {
+			log.Printf("⚠️ Webhook購読の復元に失敗 (ID: %s): %v", point.GetId().GetUuid(), err)
+			continue
+		}
+		subscriptions = append(subscriptions, *subscription)
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool { return subscriptions[i].CreatedAt < subscriptions[j].CreatedAt })
+	return subscriptions, nil
+}
+
+// DeleteWebhookSubscription Webhook購読を削除
+func (s *VectorStoreService) DeleteWebhookSubscription(ctx context.Context, id string) error {
+	return s.DeletePoint(ctx, webhookSubscriptionCollection, id)
+}
+
+// webhookSubscriptionFromPayload subscription_json フィールドからWebhook購読を復元
+func webhookSubscriptionFromPayload(payload map[string]*qdrant.Value) (*models.WebhookSubscription, error) {
+	raw := getStringFromPayload(payload, "subscription_json")
+	if raw == "" {
+		return nil, fmt.Errorf("subscription_jsonフィールドが見つかりません")
+	}
+	var subscription models.WebhookSubscription
+	if err := json.Unmarshal([]byte(raw), &subscription); err != nil {
+		return nil, fmt.Errorf("Webhook購読のJSON解析に失敗: %w", err)
+	}
+	return &subscription, nil
+}
+
+// SaveWebhookDelivery 配信に失敗したWebhookをデッドレターとして保存（同じIDは上書き）
+func (s *VectorStoreService) SaveWebhookDelivery(ctx context.Context, delivery models.WebhookDelivery) error {
+	deliveryJSON, err := json.Marshal(delivery)
+	if err != nil {
+		return fmt.Errorf("Webhook配信のJSON化に失敗: %w", err)
+	}
+
+	searchText := fmt.Sprintf("Webhook配信失敗: %s\nURL: %s\nエラー: %s", delivery.Event, delivery.URL, delivery.LastError)
+	metadata := map[string]interface{}{
+		"type":            "webhook_delivery",
+		"delivery_id":     delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+		"status":          delivery.Status,
+		"delivery_json":   string(deliveryJSON),
+	}
+	if err := s.StoreDocument(ctx, webhookDeliveryCollection, delivery.ID, searchText, metadata); err != nil {
+		return fmt.Errorf("Webhook配信の保存に失敗: %w", err)
+	}
+	return nil
+}
+
+// GetWebhookDelivery IDからデッドレターを取得
+func (s *VectorStoreService) GetWebhookDelivery(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	if err := s.ensureCollection(ctx, webhookDeliveryCollection); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+
+	points, err := s.qdrantClient.Get(ctx, &qdrant.GetPoints{
+		CollectionName: webhookDeliveryCollection,
+		Ids:            []*qdrant.PointId{{PointIdOptions: &qdrant.PointId_Uuid{Uuid: id}}},
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Webhook配信の取得に失敗: %w", err)
+	}
+	if len(points.GetResult()) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookDeliveryNotFound, id)
+	}
+	return webhookDeliveryFromPayload(points.GetResult()[0].Payload)
+}
+
+// ListWebhookDeliveries デッドレターを新しい順に取得（status・subscriptionIDが空の場合は絞り込まない）
+func (s *VectorStoreService) ListWebhookDeliveries(ctx context.Context, status, subscriptionID string) ([]models.WebhookDelivery, error) {
+	filter := &qdrant.Filter{}
+	if status != "" {
+		filter.Must = append(filter.Must, &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "status", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: status}}}}})
+	}
+	if subscriptionID != "" {
+		filter.Must = append(filter.Must, &qdrant.Condition{ConditionOneOf: &qdrant.Condition_Field{Field: &qdrant.FieldCondition{Key: "subscription_id", Match: &qdrant.Match{MatchValue: &qdrant.Match_Keyword{Keyword: subscriptionID}}}}})
+	}
+
+	points, err := s.scrollPoints(ctx, webhookDeliveryCollection, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Webhook配信の取得に失敗: %w", err)
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(points))
+	for _, point := range points {
+		delivery, err := webhookDeliveryFromPayload(point.GetPayload())
+		if err != nil {
+			log.Printf("⚠️ Webhook配信の復元に失敗 (ID: %s): %v", point.GetId().GetUuid(), err)
+			continue
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	sort.SliceStable(deliveries, func(i, j int) bool { return deliveries[i].CreatedAt > deliveries[j].CreatedAt })
+	return deliveries, nil
+}
+
+// webhookDeliveryFromPayload delivery_json フィールドからデッドレターを復元
+func webhookDeliveryFromPayload(payload map[string]*qdrant.Value) (*models.WebhookDelivery, error) {
+	raw := getStringFromPayload(payload, "delivery_json")
+	if raw == "" {
+		return nil, fmt.Errorf("delivery_jsonフィールドが見つかりません")
+	}
+	var delivery models.WebhookDelivery
+	if err := json.Unmarshal([]byte(raw), &delivery); err != nil {
+		return nil, fmt.Errorf("Webhook配信のJSON解析に失敗: %w", err)
+	}
+	return &delivery, nil
+}
+
+// containsString スライスに文字列が含まれるか
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// scrollPoints フィルタに一致するポイントをページングしながら全件取得
+func (s *VectorStoreService) scrollPoints(ctx context.Context, collectionName string, filter *qdrant.Filter) ([]*qdrant.RetrievedPoint, error) {
+	if err := s.ensureCollection(ctx, collectionName); err != nil {
+		return nil, fmt.Errorf("コレクションの確認に失敗: %w", err)
+	}
+	if filter != nil && len(filter.Must) == 0 && len(filter.Should) == 0 && len(filter.MustNot) == 0 {
+		filter = nil
+	}
+
+	var points []*qdrant.RetrievedPoint
+	var nextOffset *qdrant.PointId
+	for {
+		limit := uint32(256)
+		res, err := s.qdrantClient.Scroll(ctx, &qdrant.ScrollPoints{
+			CollectionName: collectionName,
+			Filter:         filter,
+			Limit:          &limit,
+			WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+			Offset:         nextOffset,
+		})
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, res.GetResult()...)
+		nextOffset = res.NextPageOffset
+		if nextOffset == nil {
+			break
+		}
+	}
+	return points, nil
+}
+
 // ListCollections は、Qdrantのすべてのコレクション名を取得します
 func (v *VectorStoreService) ListCollections(ctx context.Context) ([]string, error) {
 	// コレクション一覧を取得
@@ -0,0 +1,438 @@
+package services
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	weatherCacheKindForecast   = "forecast"
+	weatherCacheKindHistorical = "historical"
+
+	defaultWeatherCacheMaxEntries = 20000
+	defaultWeatherForecastTTL     = 3 * time.Hour
+	defaultWeatherHistoricalTTL   = 7 * 24 * time.Hour
+	defaultWeatherCacheFlushDelay = 30 * time.Second
+)
+
+// WeatherCacheConfig 気象データキャッシュの設定
+type WeatherCacheConfig struct {
+	MaxEntries    int           // 保持する日数（地域×日×種類）の上限。超えたら最も使われていないものから破棄
+	ForecastTTL   time.Duration // 予報の有効期間
+	HistoricalTTL time.Duration // 観測済みの過去データの有効期間（今日以降の日は予報と同じ期間）
+	PersistPath   string        // 設定されていれば再起動後も使えるようにファイルへ保存
+	FlushDelay    time.Duration // 変更があってからファイルへ書き出すまでの待ち時間（その間の変更はまとめて書き出す）
+}
+
+// WeatherCacheStats キャッシュの利用状況
+type WeatherCacheStats struct {
+	Entries           int     `json:"entries"`
+	ForecastEntries   int     `json:"forecast_entries"`
+	HistoricalEntries int     `json:"historical_entries"`
+	Regions           int     `json:"regions"`
+	MaxEntries        int     `json:"max_entries"`
+	Hits              int64   `json:"hits"`
+	Misses            int64   `json:"misses"`
+	HitRate           float64 `json:"hit_rate"`
+	Evictions         int64   `json:"evictions"`
+	Expirations       int64   `json:"expirations"`
+	ForecastTTL       string  `json:"forecast_ttl"`
+	HistoricalTTL     string  `json:"historical_ttl"`
+	PersistPath       string  `json:"persist_path,omitempty"`
+}
+
+// weatherCacheEntry 地域・日・種類ごとのキャッシュ
+// 予報は同じ日に複数の予報区があるため、その日の予報をまとめて持つ
+// 予報の取得単位（どの日までを一度に取得したか）は日付を持たない索引エントリで管理する
+type weatherCacheEntry struct {
+	Kind       string                 `json:"kind"`
+	RegionCode string                 `json:"region_code"`
+	Date       string                 `json:"date,omitempty"`
+	Forecasts  []DailyForecast        `json:"forecasts,omitempty"`
+	Historical *HistoricalWeatherData `json:"historical,omitempty"`
+	Dates      []string               `json:"dates,omitempty"` // 予報の索引: 取得した予報の日付
+	StoredAt   time.Time              `json:"stored_at"`
+	ExpiresAt  time.Time              `json:"expires_at"`
+}
+
+func (e *weatherCacheEntry) key() string {
+	return e.Kind + ":" + e.RegionCode + ":" + e.Date
+}
+
+// WeatherCache 地域・日単位の気象データキャッシュ（LRUで件数を制限し、種類ごとの有効期間で失効）
+// 期間の一部が重なる要求は、キャッシュ済みの日を使い、不足している日だけを取得元から取得する
+type WeatherCache struct {
+	mu      sync.Mutex
+	config  WeatherCacheConfig
+	order   *list.List // 先頭が最近使われたもの
+	entries map[string]*list.Element
+	now     func() time.Time
+
+	// 取得のたびにファイル全体を書き直さないよう、変更は印を付けて FlushDelay 後にまとめて書き出す
+	dirty      bool
+	flushTimer *time.Timer
+	saveMu     sync.Mutex // ファイルへの書き込みを直列化
+
+	hits        int64
+	misses      int64
+	evictions   int64
+	expirations int64
+}
+
+// NewWeatherCache 新しい気象データキャッシュを作成（保存先があれば読み込む）
+func NewWeatherCache(config WeatherCacheConfig) *WeatherCache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultWeatherCacheMaxEntries
+	}
+	if config.ForecastTTL <= 0 {
+		config.ForecastTTL = defaultWeatherForecastTTL
+	}
+	if config.HistoricalTTL <= 0 {
+		config.HistoricalTTL = defaultWeatherHistoricalTTL
+	}
+	if config.FlushDelay <= 0 {
+		config.FlushDelay = defaultWeatherCacheFlushDelay
+	}
+	cache := &WeatherCache{
+		config:  config,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+	if config.PersistPath != "" {
+		if err := cache.load(); err != nil {
+			log.Printf("⚠️ 気象データキャッシュの読み込みに失敗: %v", err)
+		}
+	}
+	return cache
+}
+
+// Historical 期間内のキャッシュ済みの日と、キャッシュにない日を返す
+func (c *WeatherCache) Historical(regionCode string, startDate, endDate time.Time) ([]HistoricalWeatherData, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var cached []HistoricalWeatherData
+	var missing []string
+	end := calendarDay(endDate)
+	for date := calendarDay(startDate); !date.After(end); date = date.AddDate(0, 0, 1) {
+		day := date.Format("2006-01-02")
+		entry := c.get(weatherCacheKindHistorical, regionCode, day)
+		if entry == nil || entry.Historical == nil {
+			missing = append(missing, day)
+			continue
+		}
+		cached = append(cached, *entry.Historical)
+	}
+	return cached, missing
+}
+
+// calendarDay 時刻を除いた日付（期間の日数を時刻に関係なく数えるため）
+func calendarDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// PutHistorical 過去データを日ごとに保存
+func (c *WeatherCache) PutHistorical(regionCode string, data []HistoricalWeatherData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	today := now.In(jst).Format("2006-01-02")
+	for i := range data {
+		d := data[i]
+		ttl := c.config.HistoricalTTL
+		if d.Date >= today {
+			ttl = c.config.ForecastTTL // 当日以降の値はまだ確定していない
+		}
+		c.put(&weatherCacheEntry{
+			Kind:       weatherCacheKindHistorical,
+			RegionCode: regionCode,
+			Date:       d.Date,
+			Historical: &d,
+			StoredAt:   now,
+			ExpiresAt:  now.Add(ttl),
+		})
+	}
+	c.markDirty()
+}
+
+// Forecast 最後に取得した予報のうち今日以降の日を返す（失効・破棄された日があれば取得し直す）
+func (c *WeatherCache) Forecast(regionCode string) ([]DailyForecast, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index := c.get(weatherCacheKindForecast, regionCode, "")
+	if index == nil {
+		return nil, false
+	}
+	today := c.now().In(jst).Format("2006-01-02")
+	var forecasts []DailyForecast
+	for _, date := range index.Dates {
+		if date < today {
+			continue
+		}
+		entry := c.get(weatherCacheKindForecast, regionCode, date)
+		if entry == nil {
+			c.remove(c.entries[index.key()])
+			return nil, false
+		}
+		forecasts = append(forecasts, entry.Forecasts...)
+	}
+	if len(forecasts) == 0 {
+		return nil, false
+	}
+	return forecasts, true
+}
+
+// PutForecast 予報を日ごとに保存し、取得した日付を索引として記録する
+func (c *WeatherCache) PutForecast(regionCode string, forecasts []DailyForecast) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	expiresAt := now.Add(c.config.ForecastTTL)
+	byDate := make(map[string][]DailyForecast)
+	var dates []string
+	for _, forecast := range forecasts {
+		if _, ok := byDate[forecast.Date]; !ok {
+			dates = append(dates, forecast.Date)
+		}
+		byDate[forecast.Date] = append(byDate[forecast.Date], forecast)
+	}
+	sort.Strings(dates)
+
+	for _, date := range dates {
+		c.put(&weatherCacheEntry{
+			Kind:       weatherCacheKindForecast,
+			RegionCode: regionCode,
+			Date:       date,
+			Forecasts:  byDate[date],
+			StoredAt:   now,
+			ExpiresAt:  expiresAt,
+		})
+	}
+	c.put(&weatherCacheEntry{
+		Kind:       weatherCacheKindForecast,
+		RegionCode: regionCode,
+		Dates:      dates,
+		StoredAt:   now,
+		ExpiresAt:  expiresAt,
+	})
+	c.markDirty()
+}
+
+// Purge キャッシュを削除（地域・種類が空の場合はすべて）し、削除した件数を返す
+func (c *WeatherCache) Purge(regionCode, kind string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*weatherCacheEntry)
+		if (regionCode == "" || entry.RegionCode == regionCode) && (kind == "" || entry.Kind == kind) {
+			c.remove(element)
+			removed++
+		}
+		element = next
+	}
+	return removed
+}
+
+// Stats キャッシュの利用状況
+func (c *WeatherCache) Stats() WeatherCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := WeatherCacheStats{
+		Entries:       c.order.Len(),
+		MaxEntries:    c.config.MaxEntries,
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Evictions:     c.evictions,
+		Expirations:   c.expirations,
+		ForecastTTL:   c.config.ForecastTTL.String(),
+		HistoricalTTL: c.config.HistoricalTTL.String(),
+		PersistPath:   c.config.PersistPath,
+	}
+	regions := make(map[string]bool)
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*weatherCacheEntry)
+		regions[entry.RegionCode] = true
+		switch {
+		case entry.Kind == weatherCacheKindHistorical:
+			stats.HistoricalEntries++
+		case entry.Date != "":
+			stats.ForecastEntries++
+		}
+	}
+	stats.Regions = len(regions)
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = roundTo(float64(c.hits)/float64(total), 3)
+	}
+	return stats
+}
+
+// markDirty 未保存の変更があることを記録し、まだ予定がなければ FlushDelay 後の書き出しを予定する（呼び出し元でロックを取得済み）
+func (c *WeatherCache) markDirty() {
+	if c.config.PersistPath == "" {
+		return
+	}
+	c.dirty = true
+	if c.flushTimer == nil {
+		c.flushTimer = time.AfterFunc(c.config.FlushDelay, func() {
+			if err := c.Flush(); err != nil {
+				log.Printf("⚠️ 気象データキャッシュの保存に失敗: %v", err)
+			}
+		})
+	}
+}
+
+// Flush 未保存の変更があればファイルに書き出す（サーバー終了時にも呼び出す）
+func (c *WeatherCache) Flush() error {
+	c.mu.Lock()
+	if c.flushTimer != nil {
+		c.flushTimer.Stop()
+		c.flushTimer = nil
+	}
+	dirty := c.dirty
+	c.mu.Unlock()
+	if !dirty {
+		return nil
+	}
+	return c.Save()
+}
+
+// Save 保存先が設定されていれば、有効なキャッシュをファイルに書き出す
+func (c *WeatherCache) Save() error {
+	if c.config.PersistPath == "" {
+		return nil
+	}
+
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	c.mu.Lock()
+	c.dirty = false
+	now := c.now()
+	entries := make([]*weatherCacheEntry, 0, c.order.Len())
+	for element := c.order.Back(); element != nil; element = element.Prev() {
+		if entry := element.Value.(*weatherCacheEntry); now.Before(entry.ExpiresAt) {
+			entries = append(entries, entry)
+		}
+	}
+	raw, err := json.Marshal(entries)
+	c.mu.Unlock()
+	if err != nil {
+		c.redirty()
+		return fmt.Errorf("気象データキャッシュのJSON変換に失敗: %w", err)
+	}
+	if err := c.write(raw); err != nil {
+		c.redirty()
+		return err
+	}
+	return nil
+}
+
+// redirty 書き出しに失敗した変更を未保存に戻す（次の変更か終了時に再び書き出す）
+func (c *WeatherCache) redirty() {
+	c.mu.Lock()
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+// write キャッシュの内容をファイルに書き込む
+func (c *WeatherCache) write(raw []byte) error {
+	if dir := filepath.Dir(c.config.PersistPath); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("気象データキャッシュの保存先を作成できません: %w", err)
+		}
+	}
+	// 書き込み途中で終了しても壊れたファイルが残らないよう、一時ファイルから置き換える
+	tmp := c.config.PersistPath + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("気象データキャッシュを書き込めません: %w", err)
+	}
+	if err := os.Rename(tmp, c.config.PersistPath); err != nil {
+		return fmt.Errorf("気象データキャッシュを書き込めません: %w", err)
+	}
+	return nil
+}
+
+// load 保存されたキャッシュのうち有効なものを読み込む（古いものから順に並んでいる）
+func (c *WeatherCache) load() error {
+	raw, err := os.ReadFile(c.config.PersistPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries []*weatherCacheEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return fmt.Errorf("気象データキャッシュの形式が不正です: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for _, entry := range entries {
+		if now.Before(entry.ExpiresAt) {
+			c.put(entry)
+		}
+	}
+	log.Printf("💾 気象データキャッシュを読み込みました: %d件", c.order.Len())
+	return nil
+}
+
+// get 有効なエントリを取得して最近使われたものにする（呼び出し元でロックを取得済み）
+func (c *WeatherCache) get(kind, regionCode, date string) *weatherCacheEntry {
+	element, ok := c.entries[kind+":"+regionCode+":"+date]
+	if !ok {
+		c.misses++
+		return nil
+	}
+	entry := element.Value.(*weatherCacheEntry)
+	if !c.now().Before(entry.ExpiresAt) {
+		c.remove(element)
+		c.expirations++
+		c.misses++
+		return nil
+	}
+	c.order.MoveToFront(element)
+	c.hits++
+	return entry
+}
+
+// put エントリを保存し、上限を超えた分を最も使われていないものから破棄（呼び出し元でロックを取得済み）
+func (c *WeatherCache) put(entry *weatherCacheEntry) {
+	key := entry.key()
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.config.MaxEntries {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// remove エントリを削除（呼び出し元でロックを取得済み）
+func (c *WeatherCache) remove(element *list.Element) {
+	if element == nil {
+		return
+	}
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*weatherCacheEntry).key())
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWeatherServiceServesOverlappingRangesFromCache(t *testing.T) {
+	provider := &fakeWeatherProvider{forecasts: todayForecasts("240000"), historical: generateMockHistoricalData}
+	service := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+
+	first, err := service.GetHistoricalWeatherData("240000", cacheTestDay(2024, 5, 1), cacheTestDay(2024, 5, 10))
+	if err != nil || len(first) != 10 {
+		t.Fatalf("First request = %d days, err %v", len(first), err)
+	}
+	overlapping, err := service.GetHistoricalWeatherData("240000", cacheTestDay(2024, 5, 6), cacheTestDay(2024, 5, 15))
+	if err != nil || len(overlapping) != 10 {
+		t.Fatalf("Overlapping request = %d days, err %v", len(overlapping), err)
+	}
+	if overlapping[0].Date != "2024-05-06" || overlapping[9].Date != "2024-05-15" {
+		t.Errorf("Unexpected range: %s to %s", overlapping[0].Date, overlapping[9].Date)
+	}
+	if _, err := service.GetHistoricalWeatherData("240000", cacheTestDay(2024, 5, 3), cacheTestDay(2024, 5, 12)); err != nil {
+		t.Fatalf("Cached request failed: %v", err)
+	}
+
+	expected := []string{"2024-05-01~2024-05-10", "2024-05-11~2024-05-15"}
+	if len(provider.historicalCalls) != len(expected) {
+		t.Fatalf("Expected only missing days to be fetched, got %v", provider.historicalCalls)
+	}
+	for i, call := range expected {
+		if provider.historicalCalls[i] != call {
+			t.Errorf("Fetch %d = %s, expected %s", i, provider.historicalCalls[i], call)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		forecasts, err := service.GetDailyForecasts("240000")
+		if err != nil || len(forecasts) != 3 {
+			t.Fatalf("GetDailyForecasts = %d forecasts, err %v", len(forecasts), err)
+		}
+	}
+	if provider.forecastCalls != 1 {
+		t.Errorf("Expected forecasts to be fetched once, got %d", provider.forecastCalls)
+	}
+}
+
+func TestWeatherCacheExpiryAndEviction(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, jst)
+	cache := NewWeatherCache(WeatherCacheConfig{MaxEntries: 3, ForecastTTL: time.Hour, HistoricalTTL: 24 * time.Hour})
+	cache.now = func() time.Time { return now }
+
+	cache.PutHistorical("240000", []HistoricalWeatherData{
+		{Date: "2024-06-08"}, {Date: "2024-06-09"}, {Date: "2024-06-10"},
+	})
+	// 最も使われていない日から破棄される
+	if _, missing := cache.Historical("240000", cacheTestDay(2024, 6, 8), cacheTestDay(2024, 6, 8)); len(missing) != 0 {
+		t.Fatal("Expected 2024-06-08 to be cached")
+	}
+	cache.PutHistorical("130000", []HistoricalWeatherData{{Date: "2024-06-08"}})
+	if _, missing := cache.Historical("240000", cacheTestDay(2024, 6, 9), cacheTestDay(2024, 6, 9)); len(missing) != 1 {
+		t.Error("Expected the least recently used day to be evicted")
+	}
+
+	// 当日の値は予報と同じ有効期間で失効する
+	now = now.Add(2 * time.Hour)
+	cached, missing := cache.Historical("240000", cacheTestDay(2024, 6, 8), cacheTestDay(2024, 6, 10))
+	if len(cached) != 1 || len(missing) != 2 || missing[1] != "2024-06-10" {
+		t.Errorf("Unexpected cache state: cached %d, missing %v", len(cached), missing)
+	}
+
+	stats := cache.Stats()
+	if stats.Evictions != 1 || stats.Expirations != 1 || stats.Entries != 2 || stats.Regions != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if removed := cache.Purge("130000", ""); removed != 1 {
+		t.Errorf("Expected 1 entry purged, got %d", removed)
+	}
+	if removed := cache.Purge("", "forecast"); removed != 0 {
+		t.Errorf("Expected no forecast entries, got %d", removed)
+	}
+}
+
+func TestWeatherCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "weather.json")
+	cache := NewWeatherCache(WeatherCacheConfig{PersistPath: path})
+	cache.PutHistorical("240000", []HistoricalWeatherData{{Date: "2024-06-01", Temperature: 21.5, Provider: "jma"}})
+	cache.PutForecast("240000", []DailyForecast{{Date: "2999-01-01", AreaCode: "240010"}})
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	restored := NewWeatherCache(WeatherCacheConfig{PersistPath: path})
+	cached, missing := restored.Historical("240000", cacheTestDay(2024, 6, 1), cacheTestDay(2024, 6, 1))
+	if len(missing) != 0 || cached[0].Temperature != 21.5 || cached[0].Provider != "jma" {
+		t.Errorf("Historical data was not restored: %+v, missing %v", cached, missing)
+	}
+	if forecasts, ok := restored.Forecast("240000"); !ok || len(forecasts) != 1 {
+		t.Errorf("Forecast was not restored: %+v", forecasts)
+	}
+}
+
+func TestWeatherCacheFlushesChangesLater(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather.json")
+	cache := NewWeatherCache(WeatherCacheConfig{PersistPath: path, FlushDelay: time.Hour})
+	cache.PutHistorical("240000", []HistoricalWeatherData{{Date: "2024-06-01", Temperature: 21.5}})
+
+	// 取得のたびにはファイルを書き直さない
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Cache should not be written on every change, stat err = %v", err)
+	}
+
+	if err := cache.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	restored := NewWeatherCache(WeatherCacheConfig{PersistPath: path})
+	if _, missing := restored.Historical("240000", cacheTestDay(2024, 6, 1), cacheTestDay(2024, 6, 1)); len(missing) != 0 {
+		t.Errorf("Flushed data was not restored, missing %v", missing)
+	}
+
+	// 変更がなければ書き出さない
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Flush without changes should not write, stat err = %v", err)
+	}
+}
+
+func cacheTestDay(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
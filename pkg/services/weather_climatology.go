@@ -0,0 +1,360 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+const (
+	ClimatologyBaselineYears    = 5  // 平年値の既定の比較年数
+	ClimatologyMaxBaselineYears = 30 // 平年値に使える最大の年数
+	climatologyMinSamples       = 10 // 平年値の分布を求めるのに必要な観測値の数
+	weatherAnomalyPercentile    = 90 // この値以上（または 100 からこの値を引いた値以下）を珍しい天候とする
+)
+
+// climatologyFields 平年値・異常度の対象にする項目
+var climatologyFields = []string{"temperature", "max_temp", "min_temp", "humidity", "precipitation"}
+
+// climatologyFieldLabels 項目の表示名・単位と、記録的な値の表現（空の場合は記録として扱わない）
+var climatologyFieldLabels = map[string]struct {
+	label, unit, high, low string
+}{
+	"temperature":   {"日平均気温", "℃", "最も暑い日", "最も寒い日"},
+	"max_temp":      {"最高気温", "℃", "最高気温が最も高い日", "最高気温が最も低い日"},
+	"min_temp":      {"最低気温", "℃", "最低気温が最も高い日", "最低気温が最も低い日"},
+	"humidity":      {"湿度", "%", "最も湿度の高い日", "最も乾燥した日"},
+	"precipitation": {"降水量", "mm", "最も雨の多い日", ""},
+}
+
+// baselineWeather 期間の各日について、過去 years 年の同じ時期（前後 windowDays 日）の観測値を集める
+// 単位の誤りは変換し、模擬データの日と、取得元が返さなかった値・ありえない値・補完した値の項目は含めない（日付 → 項目 → 値）
+func (ws *WeatherService) baselineWeather(key string, first, last time.Time, years, windowDays int) map[string]map[string]float64 {
+	history := make(map[string]map[string]float64)
+	for year := 1; year <= years; year++ {
+		start := first.AddDate(-year, 0, -windowDays)
+		end := last.AddDate(-year, 0, windowDays)
+		data, err := ws.fetchHistoricalWeatherData(key, start, end)
+		if err != nil {
+			log.Printf("⚠️ 平年値の計算に使う%d年前の気象データを取得できません: %v", year, err)
+			continue
+		}
+		fahrenheit := fahrenheitSources(data)
+		for _, d := range data {
+			if d.Provider == mockWeatherProviderName {
+				continue
+			}
+			history[d.Date] = observedWeatherValues(d, fahrenheit[d.Provider])
+		}
+	}
+	return history
+}
+
+// observedWeatherValues 単位の誤りを変換したうえで、観測値として使える項目の値を返す（fahrenheit は fahrenheitSources の判断）
+func observedWeatherValues(d HistoricalWeatherData, fahrenheit bool) map[string]float64 {
+	correctWeatherUnits(&d, fahrenheit)
+	bad := make(map[string]bool)
+	for _, field := range implausibleFields(d) {
+		bad[field] = true
+	}
+	values := make(map[string]float64, len(weatherFields))
+	for _, field := range weatherFields {
+		if reported(d, field) && !bad[field] && !d.IsFieldImputed(field) {
+			values[field] = *weatherFieldValue(&d, field)
+		}
+	}
+	return values
+}
+
+// baselineSamples 日付の過去 years 年の同じ時期（前後 windowDays 日）の観測値を項目ごとに集め、データがあった年を返す
+func baselineSamples(history map[string]map[string]float64, day time.Time, years, windowDays int) (map[string][]float64, []int) {
+	samples := make(map[string][]float64)
+	var observedYears []int
+	for year := years; year >= 1; year-- {
+		center := day.AddDate(-year, 0, 0)
+		found := false
+		for offset := -windowDays; offset <= windowDays; offset++ {
+			values, ok := history[center.AddDate(0, 0, offset).Format("2006-01-02")]
+			if !ok {
+				continue
+			}
+			found = true
+			for field, v := range values {
+				samples[field] = append(samples[field], v)
+			}
+		}
+		if found {
+			observedYears = append(observedYears, center.Year())
+		}
+	}
+	return samples, observedYears
+}
+
+// climatologyStats 観測値の分布
+func climatologyStats(values []float64) models.WeatherClimatologyStats {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return models.WeatherClimatologyStats{
+		Mean:       roundTo(calculateMean(sorted), 1),
+		StdDev:     roundTo(calculateStandardDeviation(sorted), 2),
+		P10:        roundTo(percentileOf(sorted, 10), 1),
+		P25:        roundTo(percentileOf(sorted, 25), 1),
+		P50:        roundTo(percentileOf(sorted, 50), 1),
+		P75:        roundTo(percentileOf(sorted, 75), 1),
+		P90:        roundTo(percentileOf(sorted, 90), 1),
+		Min:        sorted[0],
+		Max:        sorted[len(sorted)-1],
+		SampleSize: len(sorted),
+	}
+}
+
+// percentileOf 昇順に並んだ値のパーセンタイル（線形補間）
+func percentileOf(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	position := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(position-float64(lower))
+}
+
+// percentileRank 値が観測値の分布の中で何パーセンタイルにあたるか（同じ値は半分を下に数える）
+func percentileRank(values []float64, value float64) float64 {
+	var below, equal float64
+	for _, v := range values {
+		if v < value {
+			below++
+		} else if v == value {
+			equal++
+		}
+	}
+	return (below + equal/2) / float64(len(values)) * 100
+}
+
+// dekadOf 日付を含む旬（上旬: 1〜10日、中旬: 11〜20日、下旬: 21日〜月末）の初日と表示名
+func dekadOf(t time.Time) (time.Time, string) {
+	day, name := 21, "下旬"
+	switch {
+	case t.Day() <= 10:
+		day, name = 1, "上旬"
+	case t.Day() <= 20:
+		day, name = 11, "中旬"
+	}
+	return time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, t.Location()), fmt.Sprintf("%d月%s", int(t.Month()), name)
+}
+
+// GetClimatology 期間の各日の平年値（過去 years 年の同じ時期の平均・パーセンタイル）を取得
+func (ws *WeatherService) GetClimatology(key string, startDate, endDate time.Time, years int) ([]models.WeatherClimatologyDay, error) {
+	if startDate.After(endDate) {
+		return nil, fmt.Errorf("開始日は終了日より前である必要があります")
+	}
+	if years < 1 || years > ClimatologyMaxBaselineYears {
+		return nil, fmt.Errorf("比較年数は1〜%d年で指定してください", ClimatologyMaxBaselineYears)
+	}
+
+	history := ws.baselineWeather(key, startDate, endDate, years, climatologyWindowDays)
+	var result []models.WeatherClimatologyDay
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		samples, observedYears := baselineSamples(history, day, years, climatologyWindowDays)
+		climatology := models.WeatherClimatologyDay{
+			Date:     day.Format("2006-01-02"),
+			MonthDay: day.Format("01-02"),
+			Years:    observedYears,
+			Fields:   make(map[string]models.WeatherClimatologyStats),
+		}
+		for _, field := range climatologyFields {
+			if len(samples[field]) >= climatologyMinSamples {
+				climatology.Fields[field] = climatologyStats(samples[field])
+			}
+		}
+		if len(climatology.Fields) > 0 {
+			result = append(result, climatology)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("平年値を求められる過去の気象データがありません（地域: %s, 比較年数: %d年）", key, years)
+	}
+	return result, nil
+}
+
+// GetWeatherAnomalies 期間の各日の天候が、過去 years 年の同じ時期と比べてどれだけ珍しかったかを取得
+// 模擬データの日・補完した値の項目は評価せず、平年値にも使わない
+func (ws *WeatherService) GetWeatherAnomalies(key string, startDate, endDate time.Time, years int) ([]models.WeatherDayAnomaly, error) {
+	if years < 1 || years > ClimatologyMaxBaselineYears {
+		return nil, fmt.Errorf("比較年数は1〜%d年で指定してください", ClimatologyMaxBaselineYears)
+	}
+	// 記録的かどうかは同じ旬のそれ以前の日とも比べるため、旬の初日から取得する
+	contextStart, _ := dekadOf(startDate)
+	data, err := ws.GetHistoricalWeatherData(key, contextStart, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return ws.scoreWeatherAnomalies(key, data, startDate, endDate, years)
+}
+
+// scoreWeatherAnomalies 気象データの startDate〜endDate の日を平年値と比べる（それより前の日は記録の比較にだけ使う）
+// 模擬データの日と、平年値に使える過去の観測値が足りない日は比べずに除き、その日数をログとエラーに示す
+func (ws *WeatherService) scoreWeatherAnomalies(key string, data []HistoricalWeatherData, startDate, endDate time.Time, years int) ([]models.WeatherDayAnomaly, error) {
+	if startDate.After(endDate) {
+		return nil, fmt.Errorf("開始日は終了日より前である必要があります")
+	}
+	observed := make(map[string]map[string]float64, len(data))
+	simulated := 0
+	fahrenheit := fahrenheitSources(data)
+	for _, d := range data {
+		// 模擬データは日付から決まる値のため、珍しさを求めても実際の天候の説明にならない
+		if d.Provider == mockWeatherProviderName {
+			if t, err := time.Parse("2006-01-02", d.Date); err == nil && !t.Before(startDate) && !t.After(endDate) {
+				simulated++
+			}
+			continue
+		}
+		observed[d.Date] = observedWeatherValues(d, fahrenheit[d.Provider])
+	}
+
+	// 旬全体を記録の比較に使うため、前後7日に加えて旬の初日から末日までを取得する
+	first, _ := dekadOf(startDate)
+	lastDekad, _ := dekadOf(endDate)
+	last := lastDekad.AddDate(0, 0, 9)
+	if lastDekad.Day() == 21 {
+		last = time.Date(lastDekad.Year(), lastDekad.Month()+1, 0, 0, 0, 0, 0, lastDekad.Location())
+	}
+	history := ws.baselineWeather(key, first, last, years, climatologyWindowDays)
+	baselineByDekad := make(map[string]map[string][]float64) // 旬 → 項目 → 過去の年の観測値
+	for date, values := range history {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			continue
+		}
+		_, label := dekadOf(t)
+		if baselineByDekad[label] == nil {
+			baselineByDekad[label] = make(map[string][]float64)
+		}
+		for field, v := range values {
+			baselineByDekad[label][field] = append(baselineByDekad[label][field], v)
+		}
+	}
+
+	var result []models.WeatherDayAnomaly
+	shortBaseline := 0
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		values, ok := observed[date]
+		if !ok {
+			continue
+		}
+		samples, observedYears := baselineSamples(history, day, years, climatologyWindowDays)
+		if len(observedYears) == 0 {
+			shortBaseline++
+			continue
+		}
+
+		// 同じ旬の過去の年と、同じ年のそれ以前の日の観測値（記録の判定に使う）
+		dekadStart, dekadLabel := dekadOf(day)
+		dekadValues := make(map[string][]float64)
+		for field, v := range baselineByDekad[dekadLabel] {
+			dekadValues[field] = append(dekadValues[field], v...)
+		}
+		for other := dekadStart; other.Before(day); other = other.AddDate(0, 0, 1) {
+			for field, v := range observed[other.Format("2006-01-02")] {
+				dekadValues[field] = append(dekadValues[field], v)
+			}
+		}
+
+		anomaly := models.WeatherDayAnomaly{Date: date, BaselineYears: observedYears, Fields: []models.WeatherFieldAnomaly{}}
+		var highlights []models.WeatherFieldAnomaly
+		for _, field := range climatologyFields {
+			value, ok := values[field]
+			if !ok || len(samples[field]) < climatologyMinSamples {
+				continue
+			}
+			labels := climatologyFieldLabels[field]
+			mean := calculateMean(samples[field])
+			fieldAnomaly := models.WeatherFieldAnomaly{
+				Field:      field,
+				Label:      labels.label,
+				Unit:       labels.unit,
+				Value:      value,
+				Normal:     roundTo(mean, 1),
+				Deviation:  roundTo(value-mean, 1),
+				Percentile: math.Round(percentileRank(samples[field], value)),
+			}
+			if std := calculateStandardDeviation(samples[field]); std > 0 {
+				fieldAnomaly.ZScore = roundTo((value-mean)/std, 2)
+			}
+			fieldAnomaly.Description = fmt.Sprintf("%+.1f%s（%.0fパーセンタイル）", fieldAnomaly.Deviation, labels.unit, fieldAnomaly.Percentile)
+
+			// 比較できる年が2年以上あり、同じ旬のどの日よりも高い（低い）値を記録とする
+			if others := dekadValues[field]; len(observedYears) >= 2 && len(others) > 0 {
+				sorted := append([]float64(nil), others...)
+				sort.Float64s(sorted)
+				since := fmt.Sprintf("%d年以降の%s", observedYears[0], dekadLabel)
+				switch {
+				case labels.high != "" && value > sorted[len(sorted)-1] && (field != "precipitation" || value >= rainyDayPrecipitation):
+					fieldAnomaly.Record = since + "で" + labels.high
+				case labels.low != "" && value < sorted[0]:
+					fieldAnomaly.Record = since + "で" + labels.low
+				}
+			}
+
+			anomaly.Score = math.Max(anomaly.Score, math.Abs(fieldAnomaly.ZScore))
+			if unusualWeatherField(fieldAnomaly) {
+				highlights = append(highlights, fieldAnomaly)
+				if anomaly.Record == "" {
+					anomaly.Record = fieldAnomaly.Record
+				}
+			}
+			anomaly.Fields = append(anomaly.Fields, fieldAnomaly)
+		}
+		if len(anomaly.Fields) == 0 {
+			shortBaseline++
+			continue
+		}
+
+		// 平年から外れている項目ほど先に示す
+		sort.SliceStable(highlights, func(i, j int) bool {
+			return math.Abs(highlights[i].Percentile-50) > math.Abs(highlights[j].Percentile-50)
+		})
+		var parts []string
+		for _, h := range highlights {
+			parts = append(parts, fmt.Sprintf("%sは平年比%s", h.Label, h.Description))
+		}
+		anomaly.Unusual = len(highlights) > 0
+		anomaly.Summary = strings.Join(parts, "、")
+		anomaly.Score = roundTo(anomaly.Score, 2)
+		result = append(result, anomaly)
+	}
+	if simulated > 0 || shortBaseline > 0 {
+		log.Printf("⚠️ 模擬データの%d日と、平年値に使える過去の観測値が足りない%d日は平年値と比べませんでした（地域: %s）", simulated, shortBaseline, key)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("平年値と比べられる気象データがありません（地域: %s, 比較年数: %d年, 模擬データの日: %d日, 過去の観測値が足りない日: %d日）", key, years, simulated, shortBaseline)
+	}
+	return result, nil
+}
+
+// unusualWeatherField 平年の分布の上下10%に入る（降水量は多い側のみ）か、記録的な値か
+func unusualWeatherField(field models.WeatherFieldAnomaly) bool {
+	if field.Record != "" || field.Percentile >= weatherAnomalyPercentile {
+		return true
+	}
+	return field.Percentile <= 100-weatherAnomalyPercentile && field.Field != "precipitation"
+}
+
+// WeatherAnomalySentence 平年と比べて珍しい天候だった日の説明文（例: 当日は2019年以降の6月上旬で最も暑い日で、日平均気温は平年比+4.2℃（97パーセンタイル）でした。）
+// 珍しい天候でなければ空文字列を返す
+func WeatherAnomalySentence(anomaly *models.WeatherDayAnomaly) string {
+	if anomaly == nil || !anomaly.Unusual {
+		return ""
+	}
+	if anomaly.Record != "" {
+		return fmt.Sprintf("当日は%sで、%sでした。", anomaly.Record, anomaly.Summary)
+	}
+	return fmt.Sprintf("当日の%sでした。", anomaly.Summary)
+}
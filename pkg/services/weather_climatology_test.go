@@ -0,0 +1,150 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// climatologyFixture 2019〜2023年の5〜7月は日付によって20〜24℃、2024年6月5日だけが28.2℃の取得元
+func climatologyFixture() *fakeWeatherProvider {
+	provider := newFakeWeatherProvider()
+	for year := 2019; year <= 2023; year++ {
+		for d := time.Date(year, 5, 1, 0, 0, 0, 0, time.UTC); d.Month() <= 7; d = d.AddDate(0, 0, 1) {
+			date := d.Format("2006-01-02")
+			provider.days[date] = HistoricalWeatherData{Date: date, Temperature: 20 + float64(d.Day()%5), Humidity: 70, Weather: "晴れ"}
+		}
+	}
+	for day := 1; day <= 5; day++ {
+		date := time.Date(2024, 6, day, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+		provider.days[date] = HistoricalWeatherData{Date: date, Temperature: 22, Humidity: 70, Weather: "晴れ"}
+	}
+	provider.days["2024-06-05"] = HistoricalWeatherData{Date: "2024-06-05", Temperature: 28.2, Humidity: 70, Weather: "晴れ"}
+	return provider
+}
+
+func TestGetClimatology(t *testing.T) {
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{climatologyFixture()}})
+	day := time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)
+	climatology, err := ws.GetClimatology("240000", day, day, ClimatologyBaselineYears)
+	if err != nil {
+		t.Fatalf("GetClimatology failed: %v", err)
+	}
+	if len(climatology) != 1 || climatology[0].MonthDay != "06-05" || len(climatology[0].Years) != 5 || climatology[0].Years[0] != 2019 {
+		t.Fatalf("Unexpected climatology: %+v", climatology)
+	}
+	temperature, ok := climatology[0].Fields["temperature"]
+	if !ok || temperature.SampleSize != 75 || temperature.P50 != 22 || temperature.Min != 20 || temperature.Max != 24 {
+		t.Errorf("Unexpected temperature climatology: %+v", temperature)
+	}
+	// 取得元が返さない最高・最低気温は平年値を求めない
+	if _, ok := climatology[0].Fields["max_temp"]; ok {
+		t.Error("Expected no climatology for unreported max temperature")
+	}
+
+	if _, err := ws.GetClimatology("240000", day, day, ClimatologyMaxBaselineYears+1); err == nil {
+		t.Error("Expected too many baseline years to be rejected")
+	}
+}
+
+func TestGetWeatherAnomalies(t *testing.T) {
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{climatologyFixture()}})
+	anomalies, err := ws.GetWeatherAnomalies("240000", time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC), ClimatologyBaselineYears)
+	if err != nil {
+		t.Fatalf("GetWeatherAnomalies failed: %v", err)
+	}
+	if len(anomalies) != 2 || anomalies[0].Date != "2024-06-04" {
+		t.Fatalf("Unexpected anomalies: %+v", anomalies)
+	}
+	if anomalies[0].Unusual || anomalies[0].Summary != "" {
+		t.Errorf("Expected an ordinary day, got %+v", anomalies[0])
+	}
+
+	hot := anomalies[1]
+	if !hot.Unusual || hot.Score < 3 || hot.Record != "2019年以降の6月上旬で最も暑い日" {
+		t.Fatalf("Unexpected hot day: %+v", hot)
+	}
+	temperature := hot.Fields[0]
+	if temperature.Field != "temperature" || temperature.Percentile != 100 || temperature.Deviation != 6.3 || temperature.Description != "+6.3℃（100パーセンタイル）" {
+		t.Errorf("Unexpected temperature anomaly: %+v", temperature)
+	}
+	sentence := WeatherAnomalySentence(&hot)
+	if sentence != "当日は2019年以降の6月上旬で最も暑い日で、日平均気温は平年比+6.3℃（100パーセンタイル）でした。" {
+		t.Errorf("Unexpected sentence: %s", sentence)
+	}
+}
+
+func TestGetWeatherAnomaliesSkipsSimulatedData(t *testing.T) {
+	day := time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC)
+
+	// 当日が模擬データの場合は平年値と比べない
+	mockDay := climatologyFixture()
+	delete(mockDay.days, "2024-06-05")
+	mock := newFakeWeatherProvider()
+	mock.name = mockWeatherProviderName
+	mock.days["2024-06-05"] = HistoricalWeatherData{Date: "2024-06-05", Temperature: 28.2, Humidity: 70, Weather: "晴れ"}
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{mockDay, mock}})
+	if anomalies, err := ws.GetWeatherAnomalies("240000", day, day, ClimatologyBaselineYears); err == nil || !strings.Contains(err.Error(), "模擬データの日: 1日") {
+		t.Errorf("Expected a simulated day to be left unscored, got %+v, err %v", anomalies, err)
+	}
+
+	// 過去の年が模擬データしかない場合も平年値にしない
+	baseline := climatologyFixture()
+	mockBaseline := newFakeWeatherProvider()
+	mockBaseline.name = mockWeatherProviderName
+	for date, d := range baseline.days {
+		if !strings.HasPrefix(date, "2024") {
+			mockBaseline.days[date] = d
+			delete(baseline.days, date)
+		}
+	}
+	ws = NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{baseline, mockBaseline}})
+	if anomalies, err := ws.GetWeatherAnomalies("240000", day, day, ClimatologyBaselineYears); err == nil || !strings.Contains(err.Error(), "過去の観測値が足りない日: 1日") {
+		t.Errorf("Expected a simulated baseline to be ignored, got %+v, err %v", anomalies, err)
+	}
+}
+
+func TestAttachWeatherAnomaliesToSalesAnomalies(t *testing.T) {
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{climatologyFixture()}})
+	service := NewStatisticsService(ws, nil, nil)
+	anomalies := service.AttachWeatherAnomalies([]models.AnomalyDetection{
+		{Date: "2024-06-05", ProductID: "P001", AnomalyType: "急増", Granularity: "daily", ActualValue: 200, ExpectedValue: 100, Deviation: 100},
+		{Date: "2024-06-03", ProductID: "P001", AnomalyType: "急減", Granularity: "weekly"},
+	}, "240000")
+
+	if anomalies[0].WeatherAnomaly == nil || anomalies[0].WeatherAnomaly.Date != "2024-06-05" {
+		t.Fatalf("Expected the daily anomaly to carry the weather anomaly, got %+v", anomalies[0])
+	}
+	if anomalies[1].WeatherAnomaly != nil {
+		t.Error("Expected weekly anomalies to be left unchanged")
+	}
+
+	question, _ := service.GenerateAIQuestion(anomalies[0])
+	if !strings.Contains(question, "2019年以降の6月上旬で最も暑い日") {
+		t.Errorf("Expected the question to mention the record, got %s", question)
+	}
+}
+
+func TestAttachWeatherAnomaliesFetchesOnlyAnomalyDekads(t *testing.T) {
+	provider := climatologyFixture()
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+	service := NewStatisticsService(ws, nil, nil)
+	anomalies := service.AttachWeatherAnomalies([]models.AnomalyDetection{
+		{Date: "2024-06-05", ProductID: "P001", AnomalyType: "急増", Granularity: "daily"},
+		{Date: "2024-12-24", ProductID: "P001", AnomalyType: "急増", Granularity: "daily"},
+	}, "240000")
+
+	if anomalies[0].WeatherAnomaly == nil {
+		t.Fatalf("Expected the June anomaly to carry the weather anomaly, got %+v", anomalies[0])
+	}
+	// 半年離れた2つの日でも、それぞれの旬の前後だけを取得する
+	for _, call := range provider.historicalCalls {
+		start, _ := time.Parse("2006-01-02", call[:10])
+		end, _ := time.Parse("2006-01-02", call[11:])
+		if end.Sub(start) > 40*24*time.Hour {
+			t.Errorf("Expected only the anomaly dekads to be fetched, got %s", call)
+		}
+	}
+}
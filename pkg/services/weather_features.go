@@ -0,0 +1,313 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+// 派生特徴量の名前
+const (
+	WeatherFeatureDiscomfortIndex   = "discomfort_index"
+	WeatherFeatureHeatIndex         = "heat_index"
+	WeatherFeatureCoolingDegreeDays = "cooling_degree_days"
+	WeatherFeatureHeatingDegreeDays = "heating_degree_days"
+	WeatherFeatureHotDayStreak      = "hot_day_streak"
+	WeatherFeatureFirstSummerDay    = "first_summer_day"
+	WeatherFeatureRainStreak        = "rain_streak"
+	WeatherFeatureRainAfterSunny    = "rain_after_sunny"
+)
+
+const (
+	coolingDegreeBase     = 24.0 // 冷房度日の基準温度（気象庁の冷房度日と同じ）
+	heatingDegreeBase     = 14.0 // 暖房度日の基準温度（気象庁の暖房度日と同じ）
+	summerDayThreshold    = 25.0 // 夏日（最高気温25℃以上）
+	hotDayThreshold       = 30.0 // 真夏日（最高気温30℃以上）
+	rainyDayPrecipitation = 1.0  // 雨の日とみなす降水量（mm）
+	rainyForecastPercent  = 50   // 予報で雨の日とみなす降水確率（%）
+)
+
+// weatherFeatureCatalog 派生特徴量の一覧（この順でレスポンスに並べる）
+var weatherFeatureCatalog = []models.WeatherFeatureDefinition{
+	{
+		Name:        WeatherFeatureDiscomfortIndex,
+		Label:       "不快指数",
+		Description: "日平均気温Tと湿度Hから 0.81T + 0.01H(0.99T - 14.3) + 46.3。75以上でやや暑い、80以上で暑くて汗が出る",
+	},
+	{
+		Name:        WeatherFeatureHeatIndex,
+		Label:       "暑さ指数（ヒートインデックス）",
+		Unit:        "℃",
+		Description: "日平均気温と湿度から米国気象局の式で求める体感温度。体感が27℃未満の日は気温そのもの",
+	},
+	{
+		Name:        WeatherFeatureCoolingDegreeDays,
+		Label:       "冷房度日",
+		Unit:        "℃・日",
+		Description: "日平均気温が24℃を上回った分（下回る日は0）",
+	},
+	{
+		Name:        WeatherFeatureHeatingDegreeDays,
+		Label:       "暖房度日",
+		Unit:        "℃・日",
+		Description: "日平均気温が14℃を下回った分（上回る日は0）",
+	},
+	{
+		Name:        WeatherFeatureHotDayStreak,
+		Label:       "真夏日の連続日数",
+		Unit:        "日",
+		Description: "最高気温30℃以上の日が当日まで何日続いているか（真夏日でない日は0）",
+	},
+	{
+		Name:        WeatherFeatureFirstSummerDay,
+		Label:       "その年最初の夏日",
+		Unit:        "flag",
+		Description: "その年で初めて最高気温25℃以上になった日は1。同じ年のそれより前の日がデータにない場合は判定しない",
+	},
+	{
+		Name:        WeatherFeatureRainStreak,
+		Label:       "雨の連続日数",
+		Unit:        "日",
+		Description: "降水量1mm以上または天気に「雨」を含む日が当日まで何日続いているか（雨でない日は0）",
+	},
+	{
+		Name:        WeatherFeatureRainAfterSunny,
+		Label:       "晴れの翌日の雨",
+		Unit:        "flag",
+		Description: "前日が晴れ（雨ではなく天気に「晴」を含む）で当日が雨の日は1",
+	},
+}
+
+// WeatherFeatureCatalog 派生特徴量の一覧と計算方法
+func WeatherFeatureCatalog() []models.WeatherFeatureDefinition {
+	return append([]models.WeatherFeatureDefinition(nil), weatherFeatureCatalog...)
+}
+
+// WeatherFeatureDefinitionFor 特徴量名から定義を取得
+func WeatherFeatureDefinitionFor(name string) (models.WeatherFeatureDefinition, bool) {
+	for _, definition := range weatherFeatureCatalog {
+		if definition.Name == name {
+			return definition, true
+		}
+	}
+	return models.WeatherFeatureDefinition{}, false
+}
+
+// ComputeWeatherFeatures 気象データの系列から日ごとの派生特徴量を計算する（日付順）
+// 連続日数は日付が途切れたところでリセットする
+func ComputeWeatherFeatures(data []HistoricalWeatherData) []models.WeatherFeatureDay {
+	sorted := make([]HistoricalWeatherData, 0, len(data))
+	seen := make(map[string]bool, len(data))
+	for _, d := range data {
+		if _, err := time.Parse("2006-01-02", d.Date); err != nil || seen[d.Date] {
+			continue
+		}
+		seen[d.Date] = true
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Date < sorted[j].Date })
+
+	features := make([]models.WeatherFeatureDay, 0, len(sorted))
+	var hotStreak, rainStreak float64
+	observedBeforeSummer := make(map[int]bool) // 年 → 夏日より前の日がデータにあるか
+	summerSeen := make(map[int]bool)           // 年 → 夏日が既に出たか
+	for i, d := range sorted {
+		day, _ := time.Parse("2006-01-02", d.Date)
+		consecutive := false
+		if i > 0 {
+			previous, _ := time.Parse("2006-01-02", sorted[i-1].Date)
+			consecutive = day.Sub(previous) == 24*time.Hour
+		}
+		if !consecutive {
+			hotStreak, rainStreak = 0, 0
+		}
+
+		maxTemp := dailyMaxTemp(d)
+		if maxTemp >= hotDayThreshold {
+			hotStreak++
+		} else {
+			hotStreak = 0
+		}
+		rainy := rainyConditions(d.Precipitation, d.Weather)
+		if rainy {
+			rainStreak++
+		} else {
+			rainStreak = 0
+		}
+
+		var firstSummerDay float64
+		year := day.Year()
+		if maxTemp >= summerDayThreshold {
+			if !summerSeen[year] && observedBeforeSummer[year] {
+				firstSummerDay = 1
+			}
+			summerSeen[year] = true
+		} else if !summerSeen[year] {
+			observedBeforeSummer[year] = true
+		}
+
+		var rainAfterSunny float64
+		if rainy && consecutive && sunnyConditions(sorted[i-1]) {
+			rainAfterSunny = 1
+		}
+
+		features = append(features, models.WeatherFeatureDay{
+			Date: d.Date,
+			Values: map[string]float64{
+				WeatherFeatureDiscomfortIndex:   roundTo(discomfortIndex(d.Temperature, d.Humidity), 1),
+				WeatherFeatureHeatIndex:         roundTo(heatIndex(d.Temperature, d.Humidity), 1),
+				WeatherFeatureCoolingDegreeDays: roundTo(math.Max(0, d.Temperature-coolingDegreeBase), 1),
+				WeatherFeatureHeatingDegreeDays: roundTo(math.Max(0, heatingDegreeBase-d.Temperature), 1),
+				WeatherFeatureHotDayStreak:      hotStreak,
+				WeatherFeatureFirstSummerDay:    firstSummerDay,
+				WeatherFeatureRainStreak:        rainStreak,
+				WeatherFeatureRainAfterSunny:    rainAfterSunny,
+			},
+		})
+	}
+	return features
+}
+
+// WeatherFromForecasts 予報を特徴量の計算に使える日次の気象データに変換する
+// 予報には湿度がないため humidity（直近の実績の平均など）で補い、降水量は降水確率から雨の日かどうかだけを表す
+func WeatherFromForecasts(forecasts []DailyForecast, humidity float64) []HistoricalWeatherData {
+	var result []HistoricalWeatherData
+	seen := make(map[string]bool)
+	for _, forecast := range forecasts {
+		temperature, ok := forecast.AverageTemp()
+		if !ok || seen[forecast.Date] {
+			continue
+		}
+		seen[forecast.Date] = true
+
+		d := HistoricalWeatherData{
+			Date:        forecast.Date,
+			Temperature: temperature,
+			Humidity:    humidity,
+			Weather:     forecast.Weather,
+			WeatherCode: forecast.WeatherCode,
+			DataSource:  "予報",
+			Provider:    forecast.Provider,
+		}
+		if forecast.MaxTemp != nil {
+			d.MaxTemp = *forecast.MaxTemp
+		}
+		if forecast.MinTemp != nil {
+			d.MinTemp = *forecast.MinTemp
+		}
+		if forecast.Category == WeatherCategoryRainy ||
+			(forecast.PrecipitationProbability != nil && *forecast.PrecipitationProbability >= rainyForecastPercent) {
+			d.Precipitation = rainyDayPrecipitation
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// discomfortIndex 不快指数
+func discomfortIndex(temperature, humidity float64) float64 {
+	return 0.81*temperature + 0.01*humidity*(0.99*temperature-14.3) + 46.3
+}
+
+// heatIndex 米国気象局（Rothfusz）の式による体感温度（℃）
+func heatIndex(temperature, humidity float64) float64 {
+	t := temperature*9/5 + 32
+	simple := 0.5 * (t + 61 + (t-68)*1.2 + humidity*0.094)
+	if (simple+t)/2 < 80 {
+		return temperature
+	}
+	hi := -42.379 + 2.04901523*t + 10.14333127*humidity -
+		0.22475541*t*humidity - 0.00683783*t*t - 0.05481717*humidity*humidity +
+		0.00122874*t*t*humidity + 0.00085282*t*humidity*humidity - 0.00000199*t*t*humidity*humidity
+	return (hi - 32) * 5 / 9
+}
+
+// dailyMaxTemp 最高気温（取得元が最高・最低気温を返さない場合は日平均気温）
+func dailyMaxTemp(d HistoricalWeatherData) float64 {
+	if d.MaxTemp == 0 && d.MinTemp == 0 {
+		return d.Temperature
+	}
+	return d.MaxTemp
+}
+
+// rainyConditions 降水量1mm以上、または天気に「雨」を含む
+func rainyConditions(precipitation float64, weather string) bool {
+	return precipitation >= rainyDayPrecipitation || strings.Contains(weather, "雨")
+}
+
+// sunnyConditions 雨ではなく、天気に「晴」を含む
+func sunnyConditions(d HistoricalWeatherData) bool {
+	return !rainyConditions(d.Precipitation, d.Weather) && strings.Contains(d.Weather, "晴")
+}
+
+// GetWeatherFeatures 期間の派生特徴量を計算する
+// withForecast の場合は実績の翌日以降を予報で延長する（連続日数などが予報期間にも引き継がれる）
+func (ws *WeatherService) GetWeatherFeatures(key string, startDate, endDate time.Time, withForecast bool) ([]models.WeatherFeatureDay, error) {
+	data, err := ws.GetHistoricalWeatherData(key, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if withForecast {
+		forecasts, err := ws.GetDailyForecasts(key)
+		if err != nil {
+			log.Printf("⚠️ 予報を取得できないため、派生特徴量は実績の期間のみ計算します: %v", err)
+		} else {
+			data = extendWithForecasts(data, forecasts)
+		}
+	}
+	return ComputeWeatherFeatures(data), nil
+}
+
+// GetWeatherFeaturesAsOf asOf の時点で入手できた情報だけで派生特徴量を計算する
+// asOf の前日までの実績を、予報アーカイブにある asOf までに発表された予報で延長する（バックテストで当時の予報を再現する）
+func (ws *WeatherService) GetWeatherFeaturesAsOf(key string, startDate, asOf time.Time) ([]models.WeatherFeatureDay, int, error) {
+	day := asOf.In(jst)
+	data, err := ws.GetHistoricalWeatherData(key, startDate, time.Date(day.Year(), day.Month(), day.Day()-1, 0, 0, 0, 0, jst))
+	if err != nil {
+		return nil, 0, err
+	}
+	forecasts := ws.archive.AvailableAsOf(key, asOf)
+	if len(forecasts) == 0 {
+		return nil, 0, fmt.Errorf("%s 時点で発表済みの予報がアーカイブにありません", asOf.In(jst).Format("2006-01-02 15:04"))
+	}
+	extended := extendWithForecasts(data, forecasts)
+	return ComputeWeatherFeatures(extended), len(extended) - len(data), nil
+}
+
+// extendWithForecasts 実績の最終日の翌日以降を予報で延長する
+// 予報には湿度がないため、直近14日の湿度の平均で補う
+func extendWithForecasts(data []HistoricalWeatherData, forecasts []DailyForecast) []HistoricalWeatherData {
+	var lastDate string
+	var humidities []float64
+	for _, d := range data {
+		if d.Date > lastDate {
+			lastDate = d.Date
+		}
+	}
+	for _, d := range data {
+		if d.Date > shiftDate(lastDate, -14) {
+			humidities = append(humidities, d.Humidity)
+		}
+	}
+	for _, d := range WeatherFromForecasts(forecasts, calculateMean(humidities)) {
+		if d.Date > lastDate {
+			data = append(data, d)
+		}
+	}
+	return data
+}
+
+// shiftDate YYYY-MM-DD の日付を days 日ずらす（解析できない場合はそのまま返す）
+func shiftDate(date string, days int) string {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, days).Format("2006-01-02")
+}
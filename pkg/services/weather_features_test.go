@@ -0,0 +1,142 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestComputeWeatherFeatures(t *testing.T) {
+	data := []HistoricalWeatherData{
+		{Date: "2024-06-03", Temperature: 28, MaxTemp: 31, MinTemp: 24, Humidity: 80, Weather: "晴れ"},
+		{Date: "2024-06-01", Temperature: 20, MaxTemp: 24, MinTemp: 16, Humidity: 60, Weather: "晴れ"},
+		{Date: "2024-06-02", Temperature: 26, MaxTemp: 30, MinTemp: 22, Humidity: 70, Weather: "晴れ"},
+		{Date: "2024-06-04", Temperature: 24, MaxTemp: 27, MinTemp: 21, Humidity: 90, Precipitation: 12, Weather: "雨"},
+		{Date: "2024-06-05", Temperature: 22, MaxTemp: 25, MinTemp: 20, Humidity: 90, Weather: "雨時々くもり"},
+		// 1日欠けると連続日数はリセットされる
+		{Date: "2024-06-07", Temperature: 10, MaxTemp: 13, MinTemp: 7, Humidity: 50, Weather: "雨"},
+	}
+
+	features := ComputeWeatherFeatures(data)
+	if len(features) != 6 || features[0].Date != "2024-06-01" {
+		t.Fatalf("Unexpected features: %+v", features)
+	}
+	value := func(i int, name string) float64 { return features[i].Values[name] }
+
+	if di := value(2, WeatherFeatureDiscomfortIndex); math.Abs(di-79.7) > 0.1 {
+		t.Errorf("Discomfort index = %.1f, expected 79.7", di)
+	}
+	if hi := value(2, WeatherFeatureHeatIndex); hi <= 28 {
+		t.Errorf("Heat index = %.1f, expected above the temperature", hi)
+	}
+	if hi := value(0, WeatherFeatureHeatIndex); hi != 20 {
+		t.Errorf("Heat index on a mild day = %.1f, expected the temperature", hi)
+	}
+	if cdd, hdd := value(2, WeatherFeatureCoolingDegreeDays), value(5, WeatherFeatureHeatingDegreeDays); cdd != 4 || hdd != 4 {
+		t.Errorf("Degree days = %.1f / %.1f, expected 4 / 4", cdd, hdd)
+	}
+	if streak := value(2, WeatherFeatureHotDayStreak); streak != 2 {
+		t.Errorf("Hot day streak = %.0f, expected 2", streak)
+	}
+	if value(1, WeatherFeatureFirstSummerDay) != 1 || value(2, WeatherFeatureFirstSummerDay) != 0 {
+		t.Error("Expected only 2024-06-02 to be the first summer day")
+	}
+	if value(3, WeatherFeatureRainAfterSunny) != 1 || value(4, WeatherFeatureRainAfterSunny) != 0 {
+		t.Error("Expected only 2024-06-04 to be rain after a sunny day")
+	}
+	if value(4, WeatherFeatureRainStreak) != 2 || value(5, WeatherFeatureRainStreak) != 1 {
+		t.Errorf("Rain streak = %.0f / %.0f, expected 2 / 1", value(4, WeatherFeatureRainStreak), value(5, WeatherFeatureRainStreak))
+	}
+
+	// 同じ年のそれより前の日がない場合は最初の夏日と判定しない
+	if features := ComputeWeatherFeatures(data[:1]); features[0].Values[WeatherFeatureFirstSummerDay] != 0 {
+		t.Error("Expected first summer day to be undetermined without earlier days")
+	}
+}
+
+func TestForecastWithWeatherFeatureRegressors(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	var history []models.SalesDataPoint
+	var weather []HistoricalWeatherData
+	for i := 0; i < 63; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		// 3週間ごとに真夏日が続く週があり（予測期間の週も真夏日）、連続日数に比例して売上が増える
+		maxTemp := 28.0
+		if (i/7)%3 == 2 {
+			maxTemp = 32
+		}
+		weather = append(weather, HistoricalWeatherData{Date: date, Temperature: maxTemp - 4, MaxTemp: maxTemp, MinTemp: maxTemp - 8, Humidity: 70, Weather: "晴れ"})
+		if i < 56 {
+			history = append(history, models.SalesDataPoint{Date: date, Sales: 100})
+		}
+	}
+	features := ComputeWeatherFeatures(weather)
+	for i := range history {
+		history[i].Sales += 5 * features[i].Values[WeatherFeatureHotDayStreak]
+	}
+
+	service := NewStatisticsService(nil, nil, nil)
+	forecast, err := service.ForecastProductDemandWithRegressors("P001", "製品A", history, "week", "240000", nil,
+		&WeatherFeatureRegressors{Names: []string{WeatherFeatureHotDayStreak}, Days: features})
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	if len(forecast.WeatherRegressors) != 1 || math.Abs(forecast.WeatherRegressors[0].Coefficient-5) > 0.5 {
+		t.Fatalf("Unexpected regressors: %+v", forecast.WeatherRegressors)
+	}
+	if forecast.WeatherRegressors[0].Label == "" || forecast.WeatherRegressors[0].Description == "" {
+		t.Error("Expected regressor to be documented")
+	}
+	// 予測期間（8月26日〜9月1日）は真夏日が続くため、後の日ほど寄与が大きい
+	first, last := forecast.DailyBreakdown[0], forecast.DailyBreakdown[6]
+	if last.WeatherFeatureContribution <= 0 || last.WeatherFeatureContribution <= first.WeatherFeatureContribution {
+		t.Errorf("Unexpected contributions: %.2f, %.2f", first.WeatherFeatureContribution, last.WeatherFeatureContribution)
+	}
+
+	if _, err := service.ForecastProductDemandWithRegressors("P001", "製品A", history, "week", "240000", nil,
+		&WeatherFeatureRegressors{Names: []string{WeatherFeatureHotDayStreak}}); err != nil {
+		t.Fatalf("Forecast without feature days failed: %v", err)
+	}
+}
+
+func TestSalesWeatherCorrelationIncludesFeatures(t *testing.T) {
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	provider := newFakeWeatherProvider()
+	var sales []models.WeatherSalesData
+	for i := 0; i < 42; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		// 雨の連続日数だけが売上を左右し、気温・湿度は一定
+		weather, precipitation := "晴れ", 0.0
+		if i%6 >= 3 {
+			weather, precipitation = "雨", 10
+		}
+		provider.days[date] = HistoricalWeatherData{Date: date, Temperature: 25, MaxTemp: 29, MinTemp: 21, Humidity: 70, Precipitation: precipitation, Weather: weather}
+		sales = append(sales, models.WeatherSalesData{Date: date, ProductID: "P001", Sales: 100})
+	}
+	features := ComputeWeatherFeatures(func() []HistoricalWeatherData {
+		var data []HistoricalWeatherData
+		for _, d := range provider.days {
+			data = append(data, d)
+		}
+		return data
+	}())
+	for i := range sales {
+		sales[i].Sales -= 10 * features[i].Values[WeatherFeatureRainStreak]
+	}
+
+	weatherService := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+	service := NewStatisticsService(weatherService, nil, nil)
+	correlations, err := service.AnalyzeSalesWeatherCorrelation(sales, "240000")
+	if err != nil {
+		t.Fatalf("AnalyzeSalesWeatherCorrelation failed: %v", err)
+	}
+	if len(correlations) == 0 || !strings.HasPrefix(correlations[0].Factor, WeatherFeatureRainStreak+"_") {
+		t.Fatalf("Expected rain streak to be the strongest factor, got %+v", correlations)
+	}
+	if definitions := weatherFeaturesIn(correlations); len(definitions) == 0 || definitions[0].Name != WeatherFeatureRainStreak {
+		t.Errorf("Expected the report to document rain_streak, got %+v", definitions)
+	}
+}
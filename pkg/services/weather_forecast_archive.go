@@ -0,0 +1,620 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultForecastArchiveRetention = 400 * 24 * time.Hour
+
+// ForecastArchiveConfig 予報アーカイブの設定
+type ForecastArchiveConfig struct {
+	PersistPath string        // 設定されていれば再起動後も使えるようにファイルへ保存
+	Retention   time.Duration // 対象日からこの期間を過ぎた予報は破棄（0の場合は400日）
+}
+
+// ArchivedForecast 取得した予報1件（拠点・予報区・発表時刻・対象日ごと）
+type ArchivedForecast struct {
+	ID           string                `json:"id"`
+	LocationKey  string                `json:"location_key"` // 予報を取得した店舗・拠点ID または地域コード
+	RegionCode   string                `json:"region_code"`  // 予報を発表した府県予報区
+	IssuedAt     time.Time             `json:"issued_at"`    // 発表時刻（取得元が返さない場合は取得した時刻の正時）
+	FetchedAt    time.Time             `json:"fetched_at"`
+	TargetDate   string                `json:"target_date"`
+	LeadDays     int                   `json:"lead_days"` // 発表日（日本時間）から対象日までの日数
+	Forecast     DailyForecast         `json:"forecast"`
+	Verification *ForecastVerification `json:"verification,omitempty"`
+}
+
+// ForecastVerification 予報と、対象日の後日の観測値との比較
+type ForecastVerification struct {
+	VerifiedAt          time.Time `json:"verified_at"`
+	ObservedTemperature float64   `json:"observed_temperature"`
+	ObservedMaxTemp     *float64  `json:"observed_max_temp,omitempty"`
+	ObservedMinTemp     *float64  `json:"observed_min_temp,omitempty"`
+	ObservedWeather     string    `json:"observed_weather"`
+	ObservedRainy       bool      `json:"observed_rainy"`
+	ForecastRainy       bool      `json:"forecast_rainy"`                 // 雨の予報、または降水確率50%以上
+	MaxTempError        *float64  `json:"max_temp_error,omitempty"`       // 予報 - 観測（℃）
+	MinTempError        *float64  `json:"min_temp_error,omitempty"`       // 予報 - 観測（℃）
+	BrierScore          *float64  `json:"brier_score,omitempty"`          // 降水確率と雨の有無の二乗誤差（降水確率がある場合のみ）
+	ObservedImputed     bool      `json:"observed_imputed,omitempty"`     // 観測値に補完した値を含む（精度の集計には使わない）
+	ObservedProvider    string    `json:"observed_provider,omitempty"`    // 観測値を返した取得元
+	ObservedDataSource  string    `json:"observed_data_source,omitempty"` // 観測値のデータソース
+}
+
+// ForecastArchiveFilter アーカイブの絞り込み条件（空の項目は絞り込まない）
+type ForecastArchiveFilter struct {
+	LocationKey string
+	From        string // 対象日の開始（YYYY-MM-DD）
+	To          string // 対象日の終了（YYYY-MM-DD）
+	LeadDays    *int
+}
+
+// ForecastArchiveStats アーカイブの件数
+type ForecastArchiveStats struct {
+	Records     int    `json:"records"`
+	Verified    int    `json:"verified"`
+	Locations   int    `json:"locations"`
+	OldestDate  string `json:"oldest_date,omitempty"`
+	LatestDate  string `json:"latest_date,omitempty"`
+	Retention   string `json:"retention"`
+	PersistPath string `json:"persist_path,omitempty"`
+}
+
+// ForecastArchive 取得した予報を捨てずに保存し、後日の観測値と比較できるようにする
+// 同じ発表の予報を何度取得しても1件として扱う
+type ForecastArchive struct {
+	mu      sync.Mutex
+	config  ForecastArchiveConfig
+	records map[string]*ArchivedForecast
+	now     func() time.Time
+}
+
+// NewForecastArchive 新しい予報アーカイブを作成（保存先があれば読み込む）
+func NewForecastArchive(config ForecastArchiveConfig) *ForecastArchive {
+	if config.Retention <= 0 {
+		config.Retention = defaultForecastArchiveRetention
+	}
+	archive := &ForecastArchive{
+		config:  config,
+		records: make(map[string]*ArchivedForecast),
+		now:     time.Now,
+	}
+	if config.PersistPath != "" {
+		if err := archive.load(); err != nil {
+			log.Printf("⚠️ 予報アーカイブの読み込みに失敗: %v", err)
+		}
+	}
+	return archive
+}
+
+// Add 取得した予報を保存し、新しく保存した件数を返す
+func (a *ForecastArchive) Add(locationKey, regionCode string, forecasts []DailyForecast) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	added := 0
+	for _, forecast := range forecasts {
+		issuedAt, err := time.Parse(time.RFC3339, forecast.ReportDatetime)
+		if err != nil {
+			issuedAt = now.Truncate(time.Hour)
+		}
+		target, err := time.ParseInLocation("2006-01-02", forecast.Date, jst)
+		if err != nil {
+			continue
+		}
+		issuedDay := issuedAt.In(jst)
+		issuedDay = time.Date(issuedDay.Year(), issuedDay.Month(), issuedDay.Day(), 0, 0, 0, 0, jst)
+
+		id := fmt.Sprintf("%s|%s|%s|%s", locationKey, forecast.AreaCode, issuedAt.UTC().Format(time.RFC3339), forecast.Date)
+		if _, exists := a.records[id]; exists {
+			continue
+		}
+		a.records[id] = &ArchivedForecast{
+			ID:          id,
+			LocationKey: locationKey,
+			RegionCode:  regionCode,
+			IssuedAt:    issuedAt,
+			FetchedAt:   now,
+			TargetDate:  forecast.Date,
+			LeadDays:    int(math.Round(target.Sub(issuedDay).Hours() / 24)),
+			Forecast:    forecast,
+		}
+		added++
+	}
+	a.prune(now)
+	return added
+}
+
+// Records 条件に合う予報を対象日・発表時刻の順に返す
+func (a *ForecastArchive) Records(filter ForecastArchiveFilter) []ArchivedForecast {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var result []ArchivedForecast
+	for _, record := range a.records {
+		if filter.LocationKey != "" && record.LocationKey != filter.LocationKey {
+			continue
+		}
+		if (filter.From != "" && record.TargetDate < filter.From) || (filter.To != "" && record.TargetDate > filter.To) {
+			continue
+		}
+		if filter.LeadDays != nil && record.LeadDays != *filter.LeadDays {
+			continue
+		}
+		result = append(result, *record)
+	}
+	sortArchivedForecasts(result)
+	return result
+}
+
+// AvailableAsOf asOf の時点で発表済みだった予報のうち、対象日ごとに最新のもの（asOf の日以降の対象日のみ）
+// バックテストで、その時点で実際に入手できた予報を再現するために使う
+func (a *ForecastArchive) AvailableAsOf(locationKey string, asOf time.Time) []DailyForecast {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	day := asOf.In(jst).Format("2006-01-02")
+	latest := make(map[string]*ArchivedForecast)
+	for _, record := range a.records {
+		if record.LocationKey != locationKey || record.TargetDate < day || record.IssuedAt.After(asOf) {
+			continue
+		}
+		// 同じ発表時刻の予報が複数の予報区にある場合は、予報区コードの小さいものを使う（通常は1つ）
+		if current, ok := latest[record.TargetDate]; !ok || record.IssuedAt.After(current.IssuedAt) ||
+			(record.IssuedAt.Equal(current.IssuedAt) && record.Forecast.AreaCode < current.Forecast.AreaCode) {
+			latest[record.TargetDate] = record
+		}
+	}
+
+	result := make([]DailyForecast, 0, len(latest))
+	for _, record := range latest {
+		result = append(result, record.Forecast)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result
+}
+
+// pending 対象日が asOf の日より前で、まだ観測値と比較していない予報
+func (a *ForecastArchive) pending(asOf time.Time) []ArchivedForecast {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	day := asOf.In(jst).Format("2006-01-02")
+	var result []ArchivedForecast
+	for _, record := range a.records {
+		if record.Verification == nil && record.TargetDate < day {
+			result = append(result, *record)
+		}
+	}
+	sortArchivedForecasts(result)
+	return result
+}
+
+// setVerification 観測値との比較結果を記録
+func (a *ForecastArchive) setVerification(id string, verification ForecastVerification) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if record, ok := a.records[id]; ok {
+		record.Verification = &verification
+	}
+}
+
+// Stats アーカイブの件数
+func (a *ForecastArchive) Stats() ForecastArchiveStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	stats := ForecastArchiveStats{
+		Records:     len(a.records),
+		Retention:   a.config.Retention.String(),
+		PersistPath: a.config.PersistPath,
+	}
+	locations := make(map[string]bool)
+	for _, record := range a.records {
+		locations[record.LocationKey] = true
+		if record.Verification != nil {
+			stats.Verified++
+		}
+		if stats.OldestDate == "" || record.TargetDate < stats.OldestDate {
+			stats.OldestDate = record.TargetDate
+		}
+		if record.TargetDate > stats.LatestDate {
+			stats.LatestDate = record.TargetDate
+		}
+	}
+	stats.Locations = len(locations)
+	return stats
+}
+
+// Save 保存先が設定されていれば、アーカイブをファイルに書き出す
+func (a *ForecastArchive) Save() error {
+	if a.config.PersistPath == "" {
+		return nil
+	}
+
+	a.mu.Lock()
+	records := make([]ArchivedForecast, 0, len(a.records))
+	for _, record := range a.records {
+		records = append(records, *record)
+	}
+	a.mu.Unlock()
+	sortArchivedForecasts(records)
+	raw, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("予報アーカイブのJSON変換に失敗: %w", err)
+	}
+
+	if dir := filepath.Dir(a.config.PersistPath); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("予報アーカイブの保存先を作成できません: %w", err)
+		}
+	}
+	// 書き込み途中で終了しても壊れたファイルが残らないよう、一時ファイルから置き換える
+	tmp := a.config.PersistPath + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("予報アーカイブを書き込めません: %w", err)
+	}
+	if err := os.Rename(tmp, a.config.PersistPath); err != nil {
+		return fmt.Errorf("予報アーカイブを書き込めません: %w", err)
+	}
+	return nil
+}
+
+// load 保存された予報のうち保存期間内のものを読み込む
+func (a *ForecastArchive) load() error {
+	raw, err := os.ReadFile(a.config.PersistPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var records []ArchivedForecast
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return fmt.Errorf("予報アーカイブの形式が不正です: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i := range records {
+		a.records[records[i].ID] = &records[i]
+	}
+	a.prune(a.now())
+	log.Printf("💾 予報アーカイブを読み込みました: %d件", len(a.records))
+	return nil
+}
+
+// prune 保存期間を過ぎた予報を破棄（呼び出し元でロックを取得済み）
+func (a *ForecastArchive) prune(now time.Time) {
+	cutoff := now.Add(-a.config.Retention).In(jst).Format("2006-01-02")
+	for id, record := range a.records {
+		if record.TargetDate < cutoff {
+			delete(a.records, id)
+		}
+	}
+}
+
+func sortArchivedForecasts(records []ArchivedForecast) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].TargetDate != records[j].TargetDate {
+			return records[i].TargetDate < records[j].TargetDate
+		}
+		if !records[i].IssuedAt.Equal(records[j].IssuedAt) {
+			return records[i].IssuedAt.Before(records[j].IssuedAt)
+		}
+		return records[i].ID < records[j].ID
+	})
+}
+
+// ForecastArchive 予報アーカイブ
+func (ws *WeatherService) ForecastArchive() *ForecastArchive {
+	return ws.archive
+}
+
+// archiveForecasts 取得元から取得した予報をアーカイブに保存する
+// 予報区が決まっていない地域コードでは、府県予報区の最初の予報区（県庁所在地を含む区域）の予報を保存する
+func (ws *WeatherService) archiveForecasts(key string, location WeatherLocation, forecasts []DailyForecast) {
+	if len(forecasts) == 0 {
+		return
+	}
+	if location.AreaCode == "" {
+		forecasts = filterForecastArea(forecasts, forecasts[0].AreaCode)
+	}
+	if added := ws.archive.Add(key, location.RegionCode, forecasts); added > 0 {
+		if err := ws.archive.Save(); err != nil {
+			log.Printf("⚠️ 予報アーカイブの保存に失敗: %v", err)
+		}
+	}
+}
+
+// ForecastVerificationRun 検証ジョブ1回分の結果
+type ForecastVerificationRun struct {
+	RanAt     time.Time `json:"ran_at"`
+	Checked   int       `json:"checked"`   // 対象日を過ぎていて未検証だった予報
+	Verified  int       `json:"verified"`  // 観測値と比較できた予報
+	Imputed   int       `json:"imputed"`   // 観測値が補完値のため、精度の集計から除く予報
+	Unmatched int       `json:"unmatched"` // 観測値を取得できず、次回に持ち越す予報
+	Simulated int       `json:"simulated"` // 観測値が模擬データのため検証せず、次回に持ち越す予報
+	Errors    []string  `json:"errors,omitempty"`
+}
+
+// VerifyForecasts 対象日が asOf の前日以前で未検証の予報を、検証済みの観測値と比較する
+func (ws *WeatherService) VerifyForecasts(asOf time.Time) ForecastVerificationRun {
+	run := ForecastVerificationRun{RanAt: asOf}
+	pending := ws.archive.pending(asOf)
+	run.Checked = len(pending)
+	if len(pending) == 0 {
+		return run
+	}
+
+	byLocation := make(map[string][]ArchivedForecast)
+	var keys []string
+	for _, record := range pending {
+		if _, ok := byLocation[record.LocationKey]; !ok {
+			keys = append(keys, record.LocationKey)
+		}
+		byLocation[record.LocationKey] = append(byLocation[record.LocationKey], record)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		records := byLocation[key]
+		// pending は対象日の順に並んでいる
+		start, _ := time.ParseInLocation("2006-01-02", records[0].TargetDate, jst)
+		end, _ := time.ParseInLocation("2006-01-02", records[len(records)-1].TargetDate, jst)
+		observations, err := ws.GetHistoricalWeatherData(key, start, end)
+		if err != nil {
+			run.Unmatched += len(records)
+			run.Errors = append(run.Errors, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		byDate := make(map[string]HistoricalWeatherData, len(observations))
+		for _, d := range observations {
+			byDate[d.Date] = d
+		}
+
+		for _, record := range records {
+			observed, ok := byDate[record.TargetDate]
+			if !ok {
+				run.Unmatched++
+				continue
+			}
+			// 模擬データと比べても予報の精度にはならないため、実際の観測値を取得できるまで未検証のままにする
+			if observed.Provider == mockWeatherProviderName {
+				run.Simulated++
+				continue
+			}
+			verification := verifyForecast(record.Forecast, observed, asOf)
+			ws.archive.setVerification(record.ID, verification)
+			run.Verified++
+			if verification.ObservedImputed {
+				run.Imputed++
+			}
+		}
+	}
+
+	if run.Verified > 0 {
+		if err := ws.archive.Save(); err != nil {
+			log.Printf("⚠️ 予報アーカイブの保存に失敗: %v", err)
+		}
+	}
+	log.Printf("🔎 予報の検証: 対象%d件、検証%d件（補完値%d件）、持ち越し%d件（模擬データ%d件）",
+		run.Checked, run.Verified, run.Imputed, run.Unmatched+run.Simulated, run.Simulated)
+	return run
+}
+
+// verifyForecast 予報1件と観測値を比較する
+func verifyForecast(forecast DailyForecast, observed HistoricalWeatherData, verifiedAt time.Time) ForecastVerification {
+	v := ForecastVerification{
+		VerifiedAt:          verifiedAt,
+		ObservedTemperature: observed.Temperature,
+		ObservedWeather:     observed.Weather,
+		ObservedRainy:       rainyConditions(observed.Precipitation, observed.Weather),
+		ForecastRainy:       forecastRainy(forecast),
+		ObservedImputed:     observed.IsImputed(),
+		ObservedProvider:    observed.Provider,
+		ObservedDataSource:  observed.DataSource,
+	}
+	if reported(observed, "max_temp") {
+		maxTemp, minTemp := observed.MaxTemp, observed.MinTemp
+		v.ObservedMaxTemp, v.ObservedMinTemp = &maxTemp, &minTemp
+		if forecast.MaxTemp != nil {
+			diff := roundTo(*forecast.MaxTemp-maxTemp, 1)
+			v.MaxTempError = &diff
+		}
+		if forecast.MinTemp != nil {
+			diff := roundTo(*forecast.MinTemp-minTemp, 1)
+			v.MinTempError = &diff
+		}
+	}
+	if forecast.PrecipitationProbability != nil {
+		outcome := 0.0
+		if v.ObservedRainy {
+			outcome = 1
+		}
+		p := float64(*forecast.PrecipitationProbability) / 100
+		brier := roundTo((p-outcome)*(p-outcome), 4)
+		v.BrierScore = &brier
+	}
+	return v
+}
+
+// forecastRainy 雨の予報、または降水確率が雨の日とみなす値以上
+func forecastRainy(forecast DailyForecast) bool {
+	return forecast.Category == WeatherCategoryRainy ||
+		(forecast.PrecipitationProbability != nil && *forecast.PrecipitationProbability >= rainyForecastPercent)
+}
+
+// StartForecastVerification interval ごとに予報の検証ジョブを実行する（0以下の場合は定期実行しない）
+func (ws *WeatherService) StartForecastVerification(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	log.Printf("⏱️ 予報の検証ジョブを%s間隔で実行します", interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			ws.VerifyForecasts(now)
+		}
+	}()
+}
+
+// ForecastSkillByLead リードタイム（発表日から対象日までの日数）ごとの予報精度
+type ForecastSkillByLead struct {
+	LeadDays       int     `json:"lead_days"`
+	Verified       int     `json:"verified"`
+	MaxTempSamples int     `json:"max_temp_samples"`
+	MaxTempMAE     float64 `json:"max_temp_mae"`
+	MaxTempBias    float64 `json:"max_temp_bias"` // 予報 - 観測の平均（正なら高めの予報）
+	MaxTempRMSE    float64 `json:"max_temp_rmse"`
+	MinTempSamples int     `json:"min_temp_samples"`
+	MinTempMAE     float64 `json:"min_temp_mae"`
+	MinTempBias    float64 `json:"min_temp_bias"`
+	MinTempRMSE    float64 `json:"min_temp_rmse"`
+	RainAccuracy   float64 `json:"rain_accuracy"` // 雨の有無の的中率（%）
+	RainHitRate    float64 `json:"rain_hit_rate"` // 雨だった日のうち雨の予報だった割合（%）
+	RainFalseAlarm float64 `json:"rain_false_alarm_rate"`
+	BrierSamples   int     `json:"brier_samples"`
+	BrierScore     float64 `json:"brier_score"` // 降水確率の二乗誤差の平均（0が最良）
+}
+
+// ForecastSkillReport 期間の予報精度（観測値が補完値・模擬データの日は集計から除く）
+type ForecastSkillReport struct {
+	LocationKey       string                `json:"location_key"`
+	From              string                `json:"from"`
+	To                string                `json:"to"`
+	Archived          int                   `json:"archived"`
+	Verified          int                   `json:"verified"`
+	Pending           int                   `json:"pending"`            // 未検証（対象日が来ていない・観測値が未取得）
+	ExcludedImputed   int                   `json:"excluded_imputed"`   // 観測値が補完値のため除いた予報
+	ExcludedSimulated int                   `json:"excluded_simulated"` // 観測値が模擬データのため除いた予報
+	ByLead            []ForecastSkillByLead `json:"by_lead"`
+}
+
+// ForecastSkill 対象日が期間内の予報の精度をリードタイムごとに集計する
+func (ws *WeatherService) ForecastSkill(key, from, to string) ForecastSkillReport {
+	records := ws.archive.Records(ForecastArchiveFilter{LocationKey: key, From: from, To: to})
+	report := ComputeForecastSkill(records)
+	report.LocationKey, report.From, report.To = key, from, to
+	return report
+}
+
+// ComputeForecastSkill アーカイブの予報からリードタイムごとの精度を集計する
+func ComputeForecastSkill(records []ArchivedForecast) ForecastSkillReport {
+	type accumulator struct {
+		verified                        int
+		maxTemp, minTemp                []float64
+		rainTotal, rainCorrect          int
+		rainObserved, rainHit           int
+		rainNotObserved, rainFalseAlarm int
+		brier                           []float64
+	}
+
+	report := ForecastSkillReport{Archived: len(records)}
+	byLead := make(map[int]*accumulator)
+	for _, record := range records {
+		v := record.Verification
+		if v == nil {
+			report.Pending++
+			continue
+		}
+		if v.ObservedProvider == mockWeatherProviderName {
+			report.ExcludedSimulated++
+			continue
+		}
+		if v.ObservedImputed {
+			report.ExcludedImputed++
+			continue
+		}
+		report.Verified++
+		acc, ok := byLead[record.LeadDays]
+		if !ok {
+			acc = &accumulator{}
+			byLead[record.LeadDays] = acc
+		}
+		acc.verified++
+		if v.MaxTempError != nil {
+			acc.maxTemp = append(acc.maxTemp, *v.MaxTempError)
+		}
+		if v.MinTempError != nil {
+			acc.minTemp = append(acc.minTemp, *v.MinTempError)
+		}
+		acc.rainTotal++
+		if v.ForecastRainy == v.ObservedRainy {
+			acc.rainCorrect++
+		}
+		if v.ObservedRainy {
+			acc.rainObserved++
+			if v.ForecastRainy {
+				acc.rainHit++
+			}
+		} else {
+			acc.rainNotObserved++
+			if v.ForecastRainy {
+				acc.rainFalseAlarm++
+			}
+		}
+		if v.BrierScore != nil {
+			acc.brier = append(acc.brier, *v.BrierScore)
+		}
+	}
+
+	leads := make([]int, 0, len(byLead))
+	for lead := range byLead {
+		leads = append(leads, lead)
+	}
+	sort.Ints(leads)
+	percent := func(n, total int) float64 {
+		if total == 0 {
+			return 0
+		}
+		return roundTo(float64(n)/float64(total)*100, 1)
+	}
+	for _, lead := range leads {
+		acc := byLead[lead]
+		skill := ForecastSkillByLead{
+			LeadDays:       lead,
+			Verified:       acc.verified,
+			MaxTempSamples: len(acc.maxTemp),
+			MinTempSamples: len(acc.minTemp),
+			RainAccuracy:   percent(acc.rainCorrect, acc.rainTotal),
+			RainHitRate:    percent(acc.rainHit, acc.rainObserved),
+			RainFalseAlarm: percent(acc.rainFalseAlarm, acc.rainNotObserved),
+			BrierSamples:   len(acc.brier),
+		}
+		skill.MaxTempMAE, skill.MaxTempBias, skill.MaxTempRMSE = errorStats(acc.maxTemp)
+		skill.MinTempMAE, skill.MinTempBias, skill.MinTempRMSE = errorStats(acc.minTemp)
+		if len(acc.brier) > 0 {
+			skill.BrierScore = roundTo(calculateMean(acc.brier), 4)
+		}
+		report.ByLead = append(report.ByLead, skill)
+	}
+	return report
+}
+
+// errorStats 誤差（予報 - 観測）の平均絶対誤差・平均（バイアス）・二乗平均平方根誤差
+func errorStats(diffs []float64) (mae, bias, rmse float64) {
+	if len(diffs) == 0 {
+		return 0, 0, 0
+	}
+	var abs, sq float64
+	for _, diff := range diffs {
+		abs += math.Abs(diff)
+		sq += diff * diff
+	}
+	n := float64(len(diffs))
+	return roundTo(abs/n, 2), roundTo(calculateMean(diffs), 2), roundTo(math.Sqrt(sq/n), 2)
+}
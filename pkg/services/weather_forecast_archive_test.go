@@ -0,0 +1,155 @@
+package services
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func archivedForecast(issued, date string, maxTemp, minTemp float64, probability int, category WeatherCategory) DailyForecast {
+	return DailyForecast{
+		Date: date, AreaCode: "240010", AreaName: "北中部", Category: category,
+		MaxTemp: &maxTemp, MinTemp: &minTemp, PrecipitationProbability: &probability,
+		Source: "detailed", ReportDatetime: issued,
+	}
+}
+
+func TestForecastArchiveVerificationAndSkill(t *testing.T) {
+	provider := qualityFixture(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), 5)
+	rainy := provider.days["2024-06-03"]
+	rainy.Precipitation, rainy.Weather = 5, "雨"
+	provider.days["2024-06-03"] = rainy
+
+	archive := NewForecastArchive(ForecastArchiveConfig{PersistPath: filepath.Join(t.TempDir(), "archive.json")})
+	archive.now = func() time.Time { return time.Date(2024, 6, 1, 6, 0, 0, 0, jst) }
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}, Archive: archive})
+
+	// 6/1発表の予報（6/2は晴れ、6/3は雨）と、6/2発表の6/3の予報を順に取得する
+	provider.forecasts = []DailyForecast{
+		archivedForecast("2024-06-01T05:00:00+09:00", "2024-06-02", 26, 17, 30, WeatherCategorySunny),
+		archivedForecast("2024-06-01T05:00:00+09:00", "2024-06-03", 25, 19, 80, WeatherCategoryRainy),
+		{Date: "2024-06-03", AreaCode: "240020", ReportDatetime: "2024-06-01T05:00:00+09:00"},
+	}
+	if _, err := ws.GetDailyForecasts("240000"); err != nil {
+		t.Fatalf("GetDailyForecasts failed: %v", err)
+	}
+	ws.Cache().Purge("240000", "")
+	provider.forecasts = []DailyForecast{archivedForecast("2024-06-02T05:00:00+09:00", "2024-06-03", 27, 18, 60, WeatherCategoryCloudy)}
+	if _, err := ws.GetDailyForecasts("240000"); err != nil {
+		t.Fatalf("GetDailyForecasts failed: %v", err)
+	}
+	// 同じ発表の予報は重複して保存しない
+	if added := archive.Add("240000", "240000", provider.forecasts); added != 0 {
+		t.Errorf("Expected duplicate forecasts to be skipped, added %d", added)
+	}
+
+	records := archive.Records(ForecastArchiveFilter{LocationKey: "240000"})
+	if len(records) != 3 || records[0].LeadDays != 1 || records[1].LeadDays != 2 || records[2].LeadDays != 1 {
+		t.Fatalf("Unexpected archive: %+v", records)
+	}
+
+	// 当時入手できた予報: 6/2の0時には6/1発表、6/2の正午には6/2発表の6/3の予報
+	if asOf := archive.AvailableAsOf("240000", time.Date(2024, 6, 2, 0, 0, 0, 0, jst)); len(asOf) != 2 || *asOf[1].MaxTemp != 25 {
+		t.Errorf("Unexpected forecasts as of midnight: %+v", asOf)
+	}
+	if asOf := archive.AvailableAsOf("240000", time.Date(2024, 6, 2, 12, 0, 0, 0, jst)); len(asOf) != 2 || *asOf[1].MaxTemp != 27 {
+		t.Errorf("Unexpected forecasts as of noon: %+v", asOf)
+	}
+
+	run := ws.VerifyForecasts(time.Date(2024, 6, 5, 9, 0, 0, 0, jst))
+	if run.Checked != 3 || run.Verified != 3 || run.Unmatched != 0 {
+		t.Fatalf("Unexpected verification run: %+v", run)
+	}
+
+	skill := ws.ForecastSkill("240000", "2024-06-01", "2024-06-05")
+	if skill.Verified != 3 || skill.Pending != 0 || len(skill.ByLead) != 2 {
+		t.Fatalf("Unexpected skill report: %+v", skill)
+	}
+	// 観測: 6/2 最高25℃・晴れ、6/3 最高26℃・雨
+	lead1 := skill.ByLead[0]
+	if lead1.LeadDays != 1 || lead1.MaxTempSamples != 2 || lead1.MaxTempMAE != 1 || lead1.MaxTempBias != 1 || lead1.RainAccuracy != 100 || lead1.BrierScore != 0.125 {
+		t.Errorf("Unexpected lead 1 skill: %+v", lead1)
+	}
+	if lead2 := skill.ByLead[1]; lead2.LeadDays != 2 || lead2.MaxTempBias != -1 || lead2.MinTempBias != 1 || lead2.RainHitRate != 100 {
+		t.Errorf("Unexpected lead 2 skill: %+v", lead2)
+	}
+
+	// 保存したアーカイブは検証結果とともに読み込める（2024年の予報が保存期間を過ぎないよう期間を延ばす）
+	reloaded := NewForecastArchive(ForecastArchiveConfig{PersistPath: archive.config.PersistPath, Retention: 20 * 365 * 24 * time.Hour})
+	if stats := reloaded.Stats(); stats.Records != 3 || stats.Verified != 3 {
+		t.Errorf("Unexpected reloaded archive: %+v", stats)
+	}
+}
+
+func TestBacktestWeatherForecastsReplaysArchivedForecasts(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	provider := newFakeWeatherProvider()
+	var history []models.SalesDataPoint
+	for i := 0; i < 60; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		temperature := 22 + 6*float64(i%3) // 冷房度日 0・4・10
+		provider.days[date] = HistoricalWeatherData{Date: date, Temperature: temperature, Humidity: 60, Weather: "晴れ"}
+		history = append(history, models.SalesDataPoint{Date: date, Sales: 100 + 10*math.Max(0, temperature-coolingDegreeBase)})
+	}
+
+	// 検証期間の前日に発表された、すべての日を22℃とする（外れた）予報
+	archive := NewForecastArchive(ForecastArchiveConfig{})
+	archive.now = func() time.Time { return time.Date(2024, 6, 22, 6, 0, 0, 0, jst) }
+	var forecasts []DailyForecast
+	for i := 53; i < 60; i++ {
+		forecasts = append(forecasts, archivedForecast("2024-06-22T05:00:00+09:00", start.AddDate(0, 0, i).Format("2006-01-02"), 22, 22, 10, WeatherCategorySunny))
+	}
+	archive.Add("240000", "240000", forecasts)
+
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}, Archive: archive})
+	service := NewStatisticsService(ws, nil, nil)
+	result, err := service.BacktestWeatherForecasts("P001", "テスト製品", history, "week", "240000", nil, []string{WeatherFeatureCoolingDegreeDays})
+	if err != nil {
+		t.Fatalf("BacktestWeatherForecasts failed: %v", err)
+	}
+	if result.HoldoutStart != "2024-06-23" || result.HoldoutDays != 7 || result.ForecastDays != 7 {
+		t.Fatalf("Unexpected backtest period: %+v", result)
+	}
+	if result.WeatherErrorMAPE <= 0 || result.WithObservedWeather.MAPE >= result.WithArchivedForecasts.MAPE {
+		t.Errorf("Expected the forecast error to worsen accuracy: %+v", result)
+	}
+
+	// 検証期間の開始時点の予報がなければ比較できない
+	empty := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+	if _, err := NewStatisticsService(empty, nil, nil).BacktestWeatherForecasts("P001", "テスト製品", history, "week", "240000", nil, []string{WeatherFeatureCoolingDegreeDays}); err == nil {
+		t.Error("Expected an error without archived forecasts")
+	}
+}
+
+func TestVerifyForecastsSkipsSimulatedObservations(t *testing.T) {
+	// 6/2は実際の観測値、6/3は模擬データしかない
+	provider := qualityFixture(time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC), 1)
+	provider.forecasts = []DailyForecast{
+		archivedForecast("2024-06-01T05:00:00+09:00", "2024-06-02", 26, 17, 30, WeatherCategorySunny),
+		archivedForecast("2024-06-01T05:00:00+09:00", "2024-06-03", 25, 19, 80, WeatherCategoryRainy),
+	}
+	archive := NewForecastArchive(ForecastArchiveConfig{})
+	archive.now = func() time.Time { return time.Date(2024, 6, 1, 6, 0, 0, 0, jst) }
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider, NewMockWeatherProvider()}, Archive: archive})
+	if _, err := ws.GetDailyForecasts("240000"); err != nil {
+		t.Fatalf("GetDailyForecasts failed: %v", err)
+	}
+
+	run := ws.VerifyForecasts(time.Date(2024, 6, 5, 9, 0, 0, 0, jst))
+	if run.Checked != 2 || run.Verified != 1 || run.Simulated != 1 {
+		t.Fatalf("Unexpected verification run: %+v", run)
+	}
+	if skill := ws.ForecastSkill("240000", "2024-06-01", "2024-06-05"); skill.Verified != 1 || skill.Pending != 1 {
+		t.Errorf("Expected the simulated day to stay pending: %+v", skill)
+	}
+
+	// 模擬データで検証済みになっている予報は精度の集計から除く
+	records := archive.Records(ForecastArchiveFilter{LocationKey: "240000"})
+	records[1].Verification = &ForecastVerification{ObservedProvider: mockWeatherProviderName, ObservedRainy: true, ForecastRainy: true}
+	if skill := ComputeForecastSkill(records); skill.Verified != 1 || skill.ExcludedSimulated != 1 || skill.ByLead[0].LeadDays != 1 {
+		t.Errorf("Expected simulated observations to be excluded: %+v", skill)
+	}
+}
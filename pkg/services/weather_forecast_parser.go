@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WeatherCategory 天気コードの大分類
+type WeatherCategory string
+
+const (
+	WeatherCategorySunny   WeatherCategory = "sunny"   // 晴れ（1xx）
+	WeatherCategoryCloudy  WeatherCategory = "cloudy"  // くもり（2xx）
+	WeatherCategoryRainy   WeatherCategory = "rainy"   // 雨（3xx）
+	WeatherCategorySnowy   WeatherCategory = "snowy"   // 雪（4xx）
+	WeatherCategoryUnknown WeatherCategory = "unknown" // 不明なコード
+)
+
+// WeatherCategoryForCode 気象庁の天気コードを大分類に変換（先頭の桁が主な天気を表す）
+func WeatherCategoryForCode(code string) WeatherCategory {
+	if len(code) != 3 {
+		return WeatherCategoryUnknown
+	}
+	switch code[0] {
+	case '1':
+		return WeatherCategorySunny
+	case '2':
+		return WeatherCategoryCloudy
+	case '3':
+		return WeatherCategoryRainy
+	case '4':
+		return WeatherCategorySnowy
+	default:
+		return WeatherCategoryUnknown
+	}
+}
+
+// Label 大分類の日本語表記
+func (c WeatherCategory) Label() string {
+	switch c {
+	case WeatherCategorySunny:
+		return "晴れ"
+	case WeatherCategoryCloudy:
+		return "くもり"
+	case WeatherCategoryRainy:
+		return "雨"
+	case WeatherCategorySnowy:
+		return "雪"
+	default:
+		return "不明"
+	}
+}
+
+// DailyForecast 予報区ごとの1日分の予報（値が発表されていない項目は nil）
+type DailyForecast struct {
+	Date                     string          `json:"date"` // YYYY-MM-DD
+	AreaCode                 string          `json:"area_code"`
+	AreaName                 string          `json:"area_name"`
+	StationCode              string          `json:"station_code,omitempty"` // 気温の観測地点
+	StationName              string          `json:"station_name,omitempty"`
+	WeatherCode              string          `json:"weather_code"`
+	Weather                  string          `json:"weather"` // 詳細予報の文言（週間予報のみの日は大分類の表記）
+	Category                 WeatherCategory `json:"category"`
+	MinTemp                  *float64        `json:"min_temp,omitempty"`
+	MaxTemp                  *float64        `json:"max_temp,omitempty"`
+	PrecipitationProbability *int            `json:"precipitation_probability,omitempty"` // その日の降水確率の最大値（%）
+	Reliability              string          `json:"reliability,omitempty"`               // 週間予報の信頼度（A/B/C）
+	Source                   string          `json:"source"`                              // "detailed"（3日間） or "weekly"（週間）
+	Provider                 string          `json:"provider,omitempty"`                  // この日の予報を返した取得元（jma / openweathermap / csv / fixture）
+	ReportDatetime           string          `json:"report_datetime"`
+}
+
+// AverageTemp 最高・最低気温の平均（片方しかない場合はその値）
+func (f DailyForecast) AverageTemp() (float64, bool) {
+	switch {
+	case f.MinTemp != nil && f.MaxTemp != nil:
+		return (*f.MinTemp + *f.MaxTemp) / 2, true
+	case f.MaxTemp != nil:
+		return *f.MaxTemp, true
+	case f.MinTemp != nil:
+		return *f.MinTemp, true
+	default:
+		return 0, false
+	}
+}
+
+// GetDailyForecasts 指定地域の日別・予報区別の予報を取得元の優先順で取得
+// 各日の予報には、その日を返した取得元が Provider として記録される（有効期間内はキャッシュを使う）
+// 取得元から取得した予報は、後日の観測値と比較できるよう予報アーカイブにも保存する
+func (ws *WeatherService) GetDailyForecasts(regionCode string) ([]DailyForecast, error) {
+	if cached, ok := ws.cache.Forecast(regionCode); ok {
+		return cached, nil
+	}
+	location := ws.LocationFor(regionCode)
+	forecasts, err := ws.providers.Forecast(context.Background(), location)
+	if err != nil {
+		return nil, err
+	}
+	ws.record(location.RegionCode, forecasts, nil)
+	forecasts = filterForecastArea(forecasts, location.AreaCode)
+	ws.archiveForecasts(regionCode, location, forecasts)
+	ws.cache.PutForecast(regionCode, forecasts)
+	return forecasts, nil
+}
+
+// filterForecastArea 店舗・拠点の一次細分区域の予報に絞り込む（該当がなければそのまま返す）
+func filterForecastArea(forecasts []DailyForecast, areaCode string) []DailyForecast {
+	if areaCode == "" {
+		return forecasts
+	}
+	var filtered []DailyForecast
+	for _, forecast := range forecasts {
+		if forecast.AreaCode == areaCode {
+			filtered = append(filtered, forecast)
+		}
+	}
+	if len(filtered) == 0 {
+		return forecasts
+	}
+	return filtered
+}
+
+// ParseJMAForecast 気象庁の予報JSONを日別・予報区別の予報に変換する
+// 3日間の詳細予報を優先し、週間予報は詳細予報にない日の追加と信頼度・不足値の補完に使う
+// 予報区と気温の観測地点は同じ予報の中で同じ順序に並んでいるため、インデックスで対応付ける
+func ParseJMAForecast(forecasts []JMAForecastData) ([]DailyForecast, error) {
+	if len(forecasts) == 0 {
+		return nil, fmt.Errorf("予報データが空です")
+	}
+
+	parsed := &dailyForecastSet{
+		index:       make(map[string]int),
+		stationArea: make(map[string]string),
+		areaOrder:   make(map[string]int),
+		areaNames:   make(map[string]string),
+	}
+	for _, forecast := range forecasts {
+		if isWeeklyForecast(forecast) {
+			if err := parsed.addWeekly(forecast); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := parsed.addDetailed(forecast); err != nil {
+			return nil, err
+		}
+	}
+
+	result := parsed.days
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].AreaCode != result[j].AreaCode {
+			return parsed.areaOrder[result[i].AreaCode] < parsed.areaOrder[result[j].AreaCode]
+		}
+		return result[i].Date < result[j].Date
+	})
+	return result, nil
+}
+
+// dailyForecastSet 予報区×日付で予報を集める
+type dailyForecastSet struct {
+	days        []DailyForecast
+	index       map[string]int    // 予報区コード|日付 → days のインデックス
+	stationArea map[string]string // 詳細予報の気温観測地点 → 予報区コード
+	areaOrder   map[string]int    // 出力順（最初に現れた順）
+	areaNames   map[string]string // 予報区コード → 予報区名
+}
+
+func (set *dailyForecastSet) get(areaCode, date string) (*DailyForecast, bool) {
+	i, ok := set.index[areaCode+"|"+date]
+	if !ok {
+		return nil, false
+	}
+	return &set.days[i], true
+}
+
+func (set *dailyForecastSet) add(forecast DailyForecast) *DailyForecast {
+	if _, ok := set.areaOrder[forecast.AreaCode]; !ok {
+		set.areaOrder[forecast.AreaCode] = len(set.areaOrder)
+		set.areaNames[forecast.AreaCode] = forecast.AreaName
+	}
+	set.index[forecast.AreaCode+"|"+forecast.Date] = len(set.days)
+	set.days = append(set.days, forecast)
+	return &set.days[len(set.days)-1]
+}
+
+// isWeeklyForecast 週間予報（信頼度または最低・最高気温の系列を持つ）か
+func isWeeklyForecast(forecast JMAForecastData) bool {
+	for _, series := range forecast.TimeSeries {
+		for _, area := range series.Areas {
+			if len(area.Reliabilities) > 0 || len(area.TempsMin) > 0 || len(area.TempsMax) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addDetailed 3日間の詳細予報（天気・6時間ごとの降水確率・朝の最低/日中の最高気温）を追加
+func (set *dailyForecastSet) addDetailed(forecast JMAForecastData) error {
+	var weatherSeries, popSeries, tempSeries *JMATimeSeries
+	for i := range forecast.TimeSeries {
+		series := &forecast.TimeSeries[i]
+		if len(series.Areas) == 0 {
+			continue
+		}
+		switch area := series.Areas[0]; {
+		case len(area.WeatherCodes) > 0 && weatherSeries == nil:
+			weatherSeries = series
+		case len(area.Pops) > 0 && popSeries == nil:
+			popSeries = series
+		case len(area.Temps) > 0 && tempSeries == nil:
+			tempSeries = series
+		}
+	}
+	if weatherSeries == nil {
+		return fmt.Errorf("詳細予報に天気の時系列がありません")
+	}
+
+	weatherDates, err := forecastDates(weatherSeries.TimeDefines)
+	if err != nil {
+		return err
+	}
+
+	for i, area := range weatherSeries.Areas {
+		var station *JMAForecastArea
+		if tempSeries != nil && i < len(tempSeries.Areas) {
+			station = &tempSeries.Areas[i]
+			set.stationArea[station.Area.Code] = area.Area.Code
+		}
+
+		for k, date := range weatherDates {
+			if _, exists := set.get(area.Area.Code, date); exists {
+				continue
+			}
+			code := valueAt(area.WeatherCodes, k)
+			day := DailyForecast{
+				Date:           date,
+				AreaCode:       area.Area.Code,
+				AreaName:       area.Area.Name,
+				WeatherCode:    code,
+				Weather:        normalizeWeatherText(valueAt(area.Weathers, k)),
+				Category:       WeatherCategoryForCode(code),
+				Source:         "detailed",
+				ReportDatetime: forecast.ReportDatetime,
+			}
+			if day.Weather == "" {
+				day.Weather = day.Category.Label()
+			}
+			if station != nil {
+				day.StationCode = station.Area.Code
+				day.StationName = station.Area.Name
+			}
+			set.add(day)
+		}
+
+		if popSeries != nil {
+			if err := set.applyDetailedPops(area.Area.Code, popSeries); err != nil {
+				return err
+			}
+		}
+		if station != nil {
+			if err := set.applyDetailedTemps(area.Area.Code, tempSeries.TimeDefines, station.Temps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyDetailedPops 6時間ごとの降水確率から、その日の最大値を設定
+func (set *dailyForecastSet) applyDetailedPops(areaCode string, series *JMATimeSeries) error {
+	var pops []string
+	for _, area := range series.Areas {
+		if area.Area.Code == areaCode {
+			pops = area.Pops
+			break
+		}
+	}
+	times, err := forecastTimes(series.TimeDefines)
+	if err != nil {
+		return err
+	}
+	for k, t := range times {
+		pop, ok := parseForecastInt(valueAt(pops, k))
+		if !ok {
+			continue
+		}
+		day, exists := set.get(areaCode, t.Format("2006-01-02"))
+		if !exists {
+			continue
+		}
+		if day.PrecipitationProbability == nil || pop > *day.PrecipitationProbability {
+			value := pop
+			day.PrecipitationProbability = &value
+		}
+	}
+	return nil
+}
+
+// applyDetailedTemps 詳細予報の気温を設定する（00時が朝の最低気温、09時が日中の最高気温）
+// 朝の最低気温の時刻を過ぎた発表では、当日の00時の欄に最高気温が繰り返し入るため、
+// 同じ日の09時より後に現れる00時の値は最低気温として扱わない
+func (set *dailyForecastSet) applyDetailedTemps(areaCode string, timeDefines, temps []string) error {
+	times, err := forecastTimes(timeDefines)
+	if err != nil {
+		return err
+	}
+	maxSeen := make(map[string]bool)
+	for k, t := range times {
+		date := t.Format("2006-01-02")
+		value, ok := parseForecastFloat(valueAt(temps, k))
+		if !ok {
+			continue
+		}
+		day, exists := set.get(areaCode, date)
+		if !exists {
+			continue
+		}
+		if t.Hour() >= 9 {
+			day.MaxTemp = &value
+			maxSeen[date] = true
+		} else if !maxSeen[date] {
+			day.MinTemp = &value
+		}
+	}
+	return nil
+}
+
+// addWeekly 週間予報を追加する
+// 気温の観測地点が詳細予報と同じなら詳細予報の予報区に統合し、そうでなければ週間予報の予報区として追加する
+func (set *dailyForecastSet) addWeekly(forecast JMAForecastData) error {
+	var weatherSeries, tempSeries *JMATimeSeries
+	for i := range forecast.TimeSeries {
+		series := &forecast.TimeSeries[i]
+		if len(series.Areas) == 0 {
+			continue
+		}
+		if area := series.Areas[0]; len(area.WeatherCodes) > 0 && weatherSeries == nil {
+			weatherSeries = series
+		} else if (len(area.TempsMin) > 0 || len(area.TempsMax) > 0) && tempSeries == nil {
+			tempSeries = series
+		}
+	}
+	if weatherSeries == nil {
+		return fmt.Errorf("週間予報に天気の時系列がありません")
+	}
+
+	weatherDates, err := forecastDates(weatherSeries.TimeDefines)
+	if err != nil {
+		return err
+	}
+	var tempDates []string
+	if tempSeries != nil {
+		if tempDates, err = forecastDates(tempSeries.TimeDefines); err != nil {
+			return err
+		}
+	}
+
+	for i, area := range weatherSeries.Areas {
+		areaCode, areaName := area.Area.Code, area.Area.Name
+		var station *JMAForecastArea
+		if tempSeries != nil && i < len(tempSeries.Areas) {
+			station = &tempSeries.Areas[i]
+			if detailedArea, ok := set.stationArea[station.Area.Code]; ok {
+				areaCode, areaName = detailedArea, set.areaNames[detailedArea]
+			}
+		}
+
+		for k, date := range weatherDates {
+			day, exists := set.get(areaCode, date)
+			if !exists {
+				code := valueAt(area.WeatherCodes, k)
+				if code == "" {
+					continue
+				}
+				category := WeatherCategoryForCode(code)
+				day = set.add(DailyForecast{
+					Date:           date,
+					AreaCode:       areaCode,
+					AreaName:       areaName,
+					WeatherCode:    code,
+					Weather:        category.Label(),
+					Category:       category,
+					Source:         "weekly",
+					ReportDatetime: forecast.ReportDatetime,
+				})
+				if station != nil {
+					day.StationCode = station.Area.Code
+					day.StationName = station.Area.Name
+				}
+			}
+
+			if day.Reliability == "" {
+				day.Reliability = valueAt(area.Reliabilities, k)
+			}
+			if day.PrecipitationProbability == nil {
+				if pop, ok := parseForecastInt(valueAt(area.Pops, k)); ok {
+					day.PrecipitationProbability = &pop
+				}
+			}
+		}
+
+		if station == nil {
+			continue
+		}
+		for k, date := range tempDates {
+			day, exists := set.get(areaCode, date)
+			if !exists {
+				continue
+			}
+			if day.MinTemp == nil {
+				if value, ok := parseForecastFloat(valueAt(station.TempsMin, k)); ok {
+					day.MinTemp = &value
+				}
+			}
+			if day.MaxTemp == nil {
+				if value, ok := parseForecastFloat(valueAt(station.TempsMax, k)); ok {
+					day.MaxTemp = &value
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// forecastTimes timeDefines（RFC3339、日本時間）をパース
+func forecastTimes(timeDefines []string) ([]time.Time, error) {
+	times := make([]time.Time, len(timeDefines))
+	for i, value := range timeDefines {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("予報の時刻をパースできません: %s", value)
+		}
+		times[i] = t
+	}
+	return times, nil
+}
+
+// forecastDates timeDefines を発表地点の現地日付（YYYY-MM-DD）に変換
+func forecastDates(timeDefines []string) ([]string, error) {
+	times, err := forecastTimes(timeDefines)
+	if err != nil {
+		return nil, err
+	}
+	dates := make([]string, len(times))
+	for i, t := range times {
+		dates[i] = t.Format("2006-01-02")
+	}
+	return dates, nil
+}
+
+// valueAt 配列の範囲外は空文字を返す
+func valueAt(values []string, i int) string {
+	if i < len(values) {
+		return strings.TrimSpace(values[i])
+	}
+	return ""
+}
+
+// parseForecastFloat 空欄（未発表）の場合は false
+func parseForecastFloat(value string) (float64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+func parseForecastInt(value string) (int, bool) {
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// normalizeWeatherText 天気の文言に含まれる全角スペースを詰める（例: 「晴れ　時々　くもり」→「晴れ時々くもり」）
+func normalizeWeatherText(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, "　", " ")), "")
+}
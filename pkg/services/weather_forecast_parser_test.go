@@ -0,0 +1,206 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// jmaForecastFixturePath 記録済みの気象庁の予報（オフラインのデモと共用の moc/weather）
+func jmaForecastFixturePath(regionCode string) string {
+	return filepath.Join("..", "..", "moc", "weather", "forecast_"+regionCode+".json")
+}
+
+func loadJMAForecastFixture(t *testing.T, regionCode string) []JMAForecastData {
+	t.Helper()
+	raw, err := os.ReadFile(jmaForecastFixturePath(regionCode))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	var forecasts []JMAForecastData
+	if err := json.Unmarshal(raw, &forecasts); err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	return forecasts
+}
+
+// forecastsByArea 予報区コード → 日付 → 予報
+func forecastsByArea(forecasts []DailyForecast) map[string]map[string]DailyForecast {
+	byArea := make(map[string]map[string]DailyForecast)
+	for _, f := range forecasts {
+		if byArea[f.AreaCode] == nil {
+			byArea[f.AreaCode] = make(map[string]DailyForecast)
+		}
+		byArea[f.AreaCode][f.Date] = f
+	}
+	return byArea
+}
+
+func assertTemp(t *testing.T, label string, got *float64, expected float64) {
+	t.Helper()
+	if got == nil || *got != expected {
+		t.Errorf("%s = %v, expected %.0f", label, got, expected)
+	}
+}
+
+func TestParseJMAForecastTokyo(t *testing.T) {
+	forecasts, err := ParseJMAForecast(loadJMAForecastFixture(t, "130000"))
+	if err != nil {
+		t.Fatalf("ParseJMAForecast failed: %v", err)
+	}
+	byArea := forecastsByArea(forecasts)
+	if len(byArea["130010"]) != 7 || len(byArea["130020"]) != 3 || len(byArea["130030"]) != 7 {
+		t.Fatalf("Days per area = %d / %d / %d", len(byArea["130010"]), len(byArea["130020"]), len(byArea["130030"]))
+	}
+	if forecasts[0].AreaCode != "130010" || forecasts[0].Date != "2024-06-10" {
+		t.Errorf("First forecast = %+v, expected 東京地方 on 2024-06-10", forecasts[0])
+	}
+
+	// 11時発表: 当日の朝の最低気温は発表済みでないため、00時の欄の値（最高気温の繰り返し）は使わない
+	today := byArea["130010"]["2024-06-10"]
+	if today.Weather != "晴れ時々くもり" || today.Category != WeatherCategorySunny || today.Source != "detailed" {
+		t.Errorf("Today = %+v", today)
+	}
+	assertTemp(t, "Today max", today.MaxTemp, 28)
+	if today.MinTemp != nil {
+		t.Errorf("Today min = %v, expected nil", *today.MinTemp)
+	}
+	if today.PrecipitationProbability == nil || *today.PrecipitationProbability != 20 {
+		t.Errorf("Today pop = %v, expected 20", today.PrecipitationProbability)
+	}
+	if today.StationName != "東京" || today.ReportDatetime != "2024-06-10T11:00:00+09:00" {
+		t.Errorf("Today station/report = %+v", today)
+	}
+
+	// 翌日: 6時間ごとの降水確率の最大値
+	tomorrow := byArea["130010"]["2024-06-11"]
+	assertTemp(t, "Tomorrow min", tomorrow.MinTemp, 19)
+	assertTemp(t, "Tomorrow max", tomorrow.MaxTemp, 23)
+	if tomorrow.Category != WeatherCategoryRainy || *tomorrow.PrecipitationProbability != 80 {
+		t.Errorf("Tomorrow = %+v", tomorrow)
+	}
+
+	// 明後日: 詳細予報の天気に週間予報の気温・降水確率を補完
+	dayAfter := byArea["130010"]["2024-06-12"]
+	if dayAfter.Source != "detailed" || dayAfter.Weather != "くもり時々晴れ" || *dayAfter.PrecipitationProbability != 30 {
+		t.Errorf("Day after tomorrow = %+v", dayAfter)
+	}
+	assertTemp(t, "Day after min", dayAfter.MinTemp, 18)
+	assertTemp(t, "Day after max", dayAfter.MaxTemp, 26)
+
+	// 週間予報だけの日
+	last := byArea["130010"]["2024-06-16"]
+	if last.Source != "weekly" || last.Reliability != "C" || last.Category != WeatherCategoryRainy || last.Weather != "雨" {
+		t.Errorf("Weekly day = %+v", last)
+	}
+	assertTemp(t, "Weekly max", last.MaxTemp, 24)
+
+	// 詳細予報にない観測地点（八丈島）は週間予報の予報区として残る
+	islands := byArea["130030"]["2024-06-13"]
+	if islands.AreaName != "伊豆諸島" || islands.StationCode != "44263" || islands.Reliability != "B" {
+		t.Errorf("Islands = %+v", islands)
+	}
+}
+
+func TestParseJMAForecastMie(t *testing.T) {
+	forecasts, err := ParseJMAForecast(loadJMAForecastFixture(t, "240000"))
+	if err != nil {
+		t.Fatalf("ParseJMAForecast failed: %v", err)
+	}
+	byArea := forecastsByArea(forecasts)
+	// 週間予報（三重県・津）は同じ観測地点の北中部に統合される
+	if len(byArea["240010"]) != 7 || len(byArea["240020"]) != 3 || len(byArea["240000"]) != 0 {
+		t.Fatalf("Days per area = %d / %d / %d", len(byArea["240010"]), len(byArea["240020"]), len(byArea["240000"]))
+	}
+
+	// 5時発表: 当日の最低・最高気温がそろう
+	today := byArea["240010"]["2024-06-10"]
+	assertTemp(t, "Today min", today.MinTemp, 18)
+	assertTemp(t, "Today max", today.MaxTemp, 29)
+	if *today.PrecipitationProbability != 10 {
+		t.Errorf("Today pop = %d", *today.PrecipitationProbability)
+	}
+
+	weekly := byArea["240010"]["2024-06-13"]
+	if weekly.AreaName != "北中部" || weekly.Reliability != "B" || weekly.Category != WeatherCategoryRainy || *weekly.PrecipitationProbability != 80 {
+		t.Errorf("Weekly day = %+v", weekly)
+	}
+	if avg, ok := weekly.AverageTemp(); !ok || avg != 22.5 {
+		t.Errorf("Average temp = %v (%v), expected 22.5", avg, ok)
+	}
+
+	south := byArea["240020"]["2024-06-11"]
+	assertTemp(t, "South min", south.MinTemp, 19)
+	assertTemp(t, "South max", south.MaxTemp, 26)
+	if south.StationName != "尾鷲" || south.Reliability != "" {
+		t.Errorf("South = %+v", south)
+	}
+}
+
+func TestParseJMAForecastInvalid(t *testing.T) {
+	if _, err := ParseJMAForecast(nil); err == nil {
+		t.Error("Expected error for empty forecast")
+	}
+	broken := loadJMAForecastFixture(t, "130000")
+	broken[0].TimeSeries[0].TimeDefines[0] = "2024/06/10"
+	if _, err := ParseJMAForecast(broken); err == nil {
+		t.Error("Expected error for invalid timeDefines")
+	}
+}
+
+func TestWeatherCategoryForCode(t *testing.T) {
+	cases := map[string]WeatherCategory{
+		"100": WeatherCategorySunny,
+		"212": WeatherCategoryCloudy,
+		"313": WeatherCategoryRainy,
+		"400": WeatherCategorySnowy,
+		"":    WeatherCategoryUnknown,
+		"999": WeatherCategoryUnknown,
+	}
+	for code, expected := range cases {
+		if got := WeatherCategoryForCode(code); got != expected {
+			t.Errorf("WeatherCategoryForCode(%q) = %s, expected %s", code, got, expected)
+		}
+	}
+}
+
+// fixtureTransport 予報JSONのURLから地域コードを取り出し、保存済みの予報を返す
+type fixtureTransport struct {
+	body      []byte
+	requested []string
+}
+
+func (ft *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ft.requested = append(ft.requested, strings.TrimSuffix(filepath.Base(req.URL.Path), ".json"))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(string(ft.body))),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+func TestGetDailyForecastsForAllRegions(t *testing.T) {
+	body, err := os.ReadFile(jmaForecastFixturePath("240000"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	transport := &fixtureTransport{body: body}
+	service := NewWeatherServiceWithConfig(WeatherServiceConfig{
+		Providers: []WeatherProvider{NewJMAWeatherProvider(&http.Client{Transport: transport}, "")},
+	})
+
+	for regionCode := range service.GetRegionCodes() {
+		forecasts, err := service.GetDailyForecasts(regionCode)
+		if err != nil || len(forecasts) != 10 {
+			t.Errorf("GetDailyForecasts(%s) = %d forecasts, err %v", regionCode, len(forecasts), err)
+		}
+		if last := transport.requested[len(transport.requested)-1]; last != regionCode {
+			t.Errorf("Requested %s, expected %s", last, regionCode)
+		}
+	}
+}
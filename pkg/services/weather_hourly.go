@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HourlyTimeLayout 時間帯の表記（その時間帯の開始時刻、日本時間）
+const HourlyTimeLayout = "2006-01-02 15:00"
+
+// HourlyWeatherData 1時間ごとの気象データ
+type HourlyWeatherData struct {
+	DateTime      string  `json:"datetime"` // YYYY-MM-DD HH:00
+	Date          string  `json:"date"`
+	Hour          int     `json:"hour"`
+	RegionCode    string  `json:"region_code"`
+	RegionName    string  `json:"region_name"`
+	Temperature   float64 `json:"temperature"`
+	Humidity      float64 `json:"humidity"`
+	Precipitation float64 `json:"precipitation"` // その1時間の降水量（mm）
+	WindSpeed     float64 `json:"wind_speed"`
+	Weather       string  `json:"weather"`
+	Provider      string  `json:"provider,omitempty"`
+}
+
+// Rainy 降水量1mm以上、または天気に「雨」を含む時間帯
+func (d HourlyWeatherData) Rainy() bool {
+	return rainyConditions(d.Precipitation, d.Weather)
+}
+
+// HourlyWeatherProvider 1時間ごとの過去データに対応する取得元（WeatherProvider に加えて任意で実装する）
+type HourlyWeatherProvider interface {
+	HourlyHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HourlyWeatherData, error)
+}
+
+// HourKey 時刻をその時間帯の表記にする（時刻は壁時計の値をそのまま使う）
+func HourKey(t time.Time) string {
+	return t.Truncate(time.Hour).Format(HourlyTimeLayout)
+}
+
+// ParseHourKey 時間帯の表記を解析する
+func ParseHourKey(value string) (time.Time, error) {
+	return time.Parse(HourlyTimeLayout, value)
+}
+
+// newHourlyWeatherData 日本時間の時刻から時間帯の項目を埋めた気象データを作成
+func newHourlyWeatherData(t time.Time, location WeatherLocation) HourlyWeatherData {
+	t = t.In(jst)
+	return HourlyWeatherData{
+		DateTime:   HourKey(t),
+		Date:       t.Format("2006-01-02"),
+		Hour:       t.Hour(),
+		RegionCode: location.RegionCode,
+		RegionName: location.RegionName,
+	}
+}
+
+// HourlyHistorical 期間の各時間帯について、優先順で最初にデータを返した取得元の値を使う
+// 1時間ごとのデータに対応しない取得元は飛ばす
+func (c *WeatherProviderChain) HourlyHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HourlyWeatherData, error) {
+	start := startDate.Format("2006-01-02")
+	end := endDate.Format("2006-01-02")
+	hours := (int(endDate.Sub(startDate).Hours()/24) + 1) * 24
+
+	filled := make(map[string]HourlyWeatherData, hours)
+	var failures []string
+	for _, provider := range c.providers {
+		if len(filled) >= hours {
+			break
+		}
+		hourly, ok := provider.(HourlyWeatherProvider)
+		if !ok {
+			continue
+		}
+		data, err := hourly.HourlyHistorical(ctx, location, startDate, endDate)
+		if err != nil {
+			if !errors.Is(err, ErrWeatherNotSupported) {
+				log.Printf("⚠️ 1時間ごとの過去データの取得に失敗（%s / 地域: %s）: %v", provider.Name(), location.RegionCode, err)
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+		for _, d := range data {
+			if d.Date < start || d.Date > end {
+				continue
+			}
+			if _, exists := filled[d.DateTime]; exists {
+				continue
+			}
+			d.Provider = provider.Name()
+			filled[d.DateTime] = d
+		}
+	}
+
+	if len(filled) == 0 {
+		if len(failures) == 0 {
+			return nil, fmt.Errorf("%w: 1時間ごとの過去データに対応する取得元がありません", ErrWeatherNotSupported)
+		}
+		return nil, fmt.Errorf("1時間ごとの過去データを取得できる取得元がありません（%s）", strings.Join(failures, " / "))
+	}
+	result := make([]HourlyWeatherData, 0, len(filled))
+	for _, d := range filled {
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DateTime < result[j].DateTime })
+	return result, nil
+}
+
+// GetHourlyWeatherData 期間（日単位、両端を含む）の1時間ごとの過去データを取得
+// 地域コードの代わりに店舗・拠点IDも指定できる
+func (ws *WeatherService) GetHourlyWeatherData(key string, startDate, endDate time.Time) ([]HourlyWeatherData, error) {
+	if startDate.After(endDate) {
+		return nil, fmt.Errorf("開始日は終了日より前である必要があります")
+	}
+	log.Printf("🔍 1時間ごとの気象データ取得開始: 地域=%s, 期間=%s〜%s",
+		key, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	return ws.providers.HourlyHistorical(context.Background(), ws.LocationFor(key), startDate, endDate)
+}
+
+// HourlyHistorical 模擬データの日平均・最高・最低気温から、14時に最も暑く明け方に最も涼しい1日の変化を作る
+func (p *MockWeatherProvider) HourlyHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HourlyWeatherData, error) {
+	daily := generateMockHistoricalData(location, startDate, endDate)
+	result := make([]HourlyWeatherData, 0, len(daily)*24)
+	for _, day := range daily {
+		date, err := time.ParseInLocation("2006-01-02", day.Date, jst)
+		if err != nil {
+			continue
+		}
+		amplitude := (day.MaxTemp - day.MinTemp) / 2
+		for hour := 0; hour < 24; hour++ {
+			phase := math.Cos(2 * math.Pi * float64(hour-14) / 24)
+			d := newHourlyWeatherData(date.Add(time.Duration(hour)*time.Hour), location)
+			d.Temperature = roundTo(day.Temperature+amplitude*phase, 1)
+			d.Humidity = roundTo(math.Min(100, day.Humidity-10*phase), 1)
+			d.Precipitation = day.Precipitation / 24
+			d.WindSpeed = day.WindSpeed
+			d.Weather = day.Weather
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// HourlyHistorical 日ごとに過去データを取得し、1時間ごとの値を使う（取得できなかった日は含めない）
+func (p *OpenWeatherMapWeatherProvider) HourlyHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HourlyWeatherData, error) {
+	if !location.HasCoordinates() {
+		return nil, fmt.Errorf("%w: 座標が不明な地域です（%s）", ErrWeatherNotSupported, location.RegionCode)
+	}
+
+	var result []HourlyWeatherData
+	var lastErr error
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		data, err := p.service.GetHourlyWeatherFromOpenWeatherMap(location, date)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		result = append(result, data...)
+	}
+	if len(result) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+// GetHourlyWeatherFromOpenWeatherMap OpenWeatherMapの過去データから1時間ごとの値を取得
+func (ows *OpenWeatherMapService) GetHourlyWeatherFromOpenWeatherMap(location WeatherLocation, date time.Time) ([]HourlyWeatherData, error) {
+	url := fmt.Sprintf("%s/onecall/timemachine?lat=%f&lon=%f&dt=%d&appid=%s&units=metric&lang=ja",
+		ows.baseURL, location.Lat, location.Lon, date.Unix(), ows.apiKey)
+
+	resp, err := ows.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("OpenWeatherMap API呼び出しエラー: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenWeatherMap API エラー: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("レスポンス読み取りエラー: %w", err)
+	}
+
+	var owmData OpenWeatherMapHistoricalData
+	if err := json.Unmarshal(body, &owmData); err != nil {
+		return nil, fmt.Errorf("JSONパースエラー: %w", err)
+	}
+
+	result := make([]HourlyWeatherData, 0, len(owmData.Hourly))
+	for _, hour := range owmData.Hourly {
+		d := newHourlyWeatherData(time.Unix(hour.Dt, 0), location)
+		d.Temperature = hour.Temp
+		d.Humidity = float64(hour.Humidity)
+		d.Precipitation = hour.Rain.OneHour
+		d.WindSpeed = hour.WindSpeed
+		if len(hour.Weather) > 0 {
+			d.Weather = hour.Weather[0].Description
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenWeatherMapWeatherProviderHourlyHistorical(t *testing.T) {
+	// 2024-06-10 の 09:00 / 15:00（日本時間）
+	body := `{"hourly": [
+		{"dt": 1717977600, "temp": 22.5, "humidity": 70, "wind_speed": 2.1, "weather": [{"id": 803, "description": "曇りがち"}]},
+		{"dt": 1717999200, "temp": 24.0, "humidity": 88, "wind_speed": 5.4, "rain": {"1h": 12.5}, "weather": [{"id": 211, "description": "雷雨"}]}
+	]}`
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !strings.HasSuffix(r.URL.Path, "/onecall/timemachine") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	provider := NewOpenWeatherMapWeatherProvider("test-key", server.URL)
+	day := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	data, err := provider.HourlyHistorical(context.Background(), WeatherLocation{RegionCode: "240000", RegionName: "三重県", Lat: 34.88, Lon: 136.58}, day, day)
+	if err != nil {
+		t.Fatalf("HourlyHistorical failed: %v", err)
+	}
+	if requests != 1 || len(data) != 2 {
+		t.Fatalf("Expected 2 hours from 1 request, got %d hours from %d requests", len(data), requests)
+	}
+	storm := data[1]
+	if storm.DateTime != "2024-06-10 15:00" || storm.Date != "2024-06-10" || storm.Hour != 15 || storm.RegionCode != "240000" {
+		t.Errorf("Unexpected time fields: %+v", storm)
+	}
+	if storm.Precipitation != 12.5 || storm.Weather != "雷雨" || !storm.Rainy() || data[0].Rainy() {
+		t.Errorf("Unexpected weather: %+v / %+v", data[0], storm)
+	}
+}
+
+func TestGetHourlyWeatherDataUsesHourlyProviders(t *testing.T) {
+	// 1時間ごとのデータに対応しない取得元は飛ばして模擬データを使う
+	service := NewWeatherServiceWithConfig(WeatherServiceConfig{
+		Providers: []WeatherProvider{newFakeWeatherProvider(), NewMockWeatherProvider()},
+	})
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	data, err := service.GetHourlyWeatherData("240000", start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetHourlyWeatherData failed: %v", err)
+	}
+	if len(data) != 48 || data[0].DateTime != "2024-07-01 00:00" || data[47].DateTime != "2024-07-02 23:00" {
+		t.Fatalf("Expected 48 hours over 2 days, got %d (%s〜%s)", len(data), data[0].DateTime, data[len(data)-1].DateTime)
+	}
+	if data[0].Provider != "mock" {
+		t.Errorf("Expected mock provider, got %q", data[0].Provider)
+	}
+	if data[14].Temperature <= data[5].Temperature {
+		t.Errorf("Expected the afternoon to be warmer than the early morning: %.1f / %.1f", data[14].Temperature, data[5].Temperature)
+	}
+
+	onlyDaily := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{newFakeWeatherProvider()}})
+	if _, err := onlyDaily.GetHourlyWeatherData("240000", start, start); err == nil {
+		t.Error("Expected an error without hourly providers")
+	}
+}
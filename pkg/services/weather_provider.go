@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrWeatherNotSupported 取得元がその種類のデータ（予報・過去データ）や地点に対応していない
+var ErrWeatherNotSupported = errors.New("この取得元は対応していません")
+
+// WeatherLocation 気象データを取得する地点（地域コードと代表地点の座標）
+// 店舗・拠点の場合、RegionCode はその拠点の府県予報区
+type WeatherLocation struct {
+	RegionCode  string  `json:"region_code"`
+	RegionName  string  `json:"region_name"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	SiteID      string  `json:"site_id,omitempty"`
+	AreaCode    string  `json:"area_code,omitempty"`    // 予報を絞り込む一次細分区域
+	StationCode string  `json:"station_code,omitempty"` // 最寄りのアメダス観測所
+}
+
+// HasCoordinates 座標が設定されているか
+func (l WeatherLocation) HasCoordinates() bool {
+	return l.Lat != 0 || l.Lon != 0
+}
+
+// WeatherProvider 気象データの取得元
+type WeatherProvider interface {
+	// Name 取得元の名前（各日のデータの Provider に記録される）
+	Name() string
+	// Forecast 地点の日別予報
+	Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error)
+	// Historical 地点の期間内の日別観測値
+	Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error)
+}
+
+// PointWeatherProvider 座標・観測所ごとの過去データに対応する取得元（WeatherProvider に加えて任意で実装する）
+// 地域コードや拠点でしか引けない取得元は実装しない（近くの観測所による補完に使わない）
+type PointWeatherProvider interface {
+	PointHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error)
+}
+
+// regionCoordinates 地域コードごとの代表地点（県庁所在地）
+var regionCoordinates = map[string][2]float64{
+	"130000": {35.6895, 139.6917}, // 東京
+	"140000": {35.4478, 139.6425}, // 横浜
+	"120000": {35.6074, 140.1065}, // 千葉
+	"110000": {35.8617, 139.6455}, // さいたま
+	"270000": {34.6937, 135.5023}, // 大阪
+	"280000": {34.6901, 135.1955}, // 神戸
+	"260000": {35.0116, 135.7681}, // 京都
+	"220000": {34.9756, 138.3828}, // 静岡
+	"210000": {35.4233, 136.7607}, // 岐阜
+	"200000": {36.6513, 138.1810}, // 長野
+	"190000": {35.6622, 138.5683}, // 甲府
+	"080000": {36.3418, 140.4468}, // 水戸
+	"090000": {36.5551, 139.8828}, // 宇都宮
+	"100000": {36.3895, 139.0634}, // 前橋
+	"240000": {34.7303, 136.5086}, // 津
+}
+
+// LocationFor 店舗・拠点IDまたは地域コードから地点を作成（座標が不明な地域は座標なし）
+func (ws *WeatherService) LocationFor(key string) WeatherLocation {
+	if site, ok := ws.sites.Get(key); ok {
+		return WeatherLocation{
+			RegionCode:  site.ForecastOfficeCode,
+			RegionName:  site.Name,
+			Lat:         site.Lat,
+			Lon:         site.Lon,
+			SiteID:      site.ID,
+			AreaCode:    site.AreaCode,
+			StationCode: site.StationCode,
+		}
+	}
+	location := WeatherLocation{RegionCode: key, RegionName: ws.getRegionName(key)}
+	if coordinates, ok := regionCoordinates[key]; ok {
+		location.Lat, location.Lon = coordinates[0], coordinates[1]
+	}
+	return location
+}
+
+// ResolveLocationKey 店舗・拠点IDが指定されていればそれを、なければ地域コードを気象データの取得キーとして返す
+func (ws *WeatherService) ResolveLocationKey(siteID, regionCode string) (string, error) {
+	if siteID == "" {
+		return regionCode, nil
+	}
+	if _, ok := ws.sites.Get(siteID); !ok {
+		return "", fmt.Errorf("%w: %s", ErrSiteNotFound, siteID)
+	}
+	return siteID, nil
+}
+
+// WeatherProviderChain 取得元を優先順に試し、前の取得元で欠けた日を後ろの取得元で補う
+type WeatherProviderChain struct {
+	providers []WeatherProvider
+}
+
+// NewWeatherProviderChain 優先順の取得元からチェーンを作成（nilは除外）
+func NewWeatherProviderChain(providers ...WeatherProvider) *WeatherProviderChain {
+	chain := &WeatherProviderChain{}
+	for _, provider := range providers {
+		if provider != nil {
+			chain.providers = append(chain.providers, provider)
+		}
+	}
+	return chain
+}
+
+// Names 取得元の名前（優先順）
+func (c *WeatherProviderChain) Names() []string {
+	names := make([]string, 0, len(c.providers))
+	for _, provider := range c.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// Forecast 最初に予報を返した取得元の予報を使い、含まれない日付だけを後ろの取得元で補う
+func (c *WeatherProviderChain) Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error) {
+	var result []DailyForecast
+	covered := make(map[string]bool)
+	var failures []string
+
+	for _, provider := range c.providers {
+		forecasts, err := provider.Forecast(ctx, location)
+		if err != nil {
+			if !errors.Is(err, ErrWeatherNotSupported) {
+				log.Printf("⚠️ 予報の取得に失敗（%s / 地域: %s）: %v", provider.Name(), location.RegionCode, err)
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+
+		added := make(map[string]bool)
+		for _, forecast := range forecasts {
+			if covered[forecast.Date] {
+				continue
+			}
+			forecast.Provider = provider.Name()
+			result = append(result, forecast)
+			added[forecast.Date] = true
+		}
+		for date := range added {
+			covered[date] = true
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("予報を取得できる取得元がありません（%s）", strings.Join(failures, " / "))
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// Historical 期間の各日について、優先順で最初にデータを返した取得元の値を使う
+// どの取得元にもない日は含まれない
+func (c *WeatherProviderChain) Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	return c.historical(ctx, location, startDate, endDate, false)
+}
+
+// PointHistorical 座標・観測所ごとの過去データに対応する取得元だけを使う Historical
+// 地域単位でしか返せない取得元は飛ばす
+func (c *WeatherProviderChain) PointHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	return c.historical(ctx, location, startDate, endDate, true)
+}
+
+func (c *WeatherProviderChain) historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time, pointOnly bool) ([]HistoricalWeatherData, error) {
+	start := startDate.Format("2006-01-02")
+	end := endDate.Format("2006-01-02")
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+
+	filled := make(map[string]HistoricalWeatherData, days)
+	var failures []string
+	for _, provider := range c.providers {
+		if len(filled) >= days {
+			break
+		}
+		fetch := provider.Historical
+		if pointOnly {
+			point, ok := provider.(PointWeatherProvider)
+			if !ok {
+				continue
+			}
+			fetch = point.PointHistorical
+		}
+		data, err := fetch(ctx, location, startDate, endDate)
+		if err != nil {
+			if !errors.Is(err, ErrWeatherNotSupported) {
+				log.Printf("⚠️ 過去データの取得に失敗（%s / 地域: %s）: %v", provider.Name(), location.RegionCode, err)
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+		for _, d := range data {
+			if d.Date < start || d.Date > end {
+				continue
+			}
+			if _, exists := filled[d.Date]; exists {
+				continue
+			}
+			d.Provider = provider.Name()
+			filled[d.Date] = d
+		}
+	}
+
+	if len(filled) == 0 {
+		if pointOnly && len(failures) == 0 {
+			return nil, fmt.Errorf("%w: 座標・観測所ごとの過去データに対応する取得元がありません", ErrWeatherNotSupported)
+		}
+		return nil, fmt.Errorf("過去データを取得できる取得元がありません（%s）", strings.Join(failures, " / "))
+	}
+	result := make([]HistoricalWeatherData, 0, len(filled))
+	for _, d := range filled {
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// WeatherProviderConfig 取得元の構成
+type WeatherProviderConfig struct {
+	Order                 []string // 優先順（jma / openweathermap / csv / fixture / mock）
+	JMABaseURL            string
+	OpenWeatherMapAPIKey  string
+	OpenWeatherMapBaseURL string
+	CSVPath               string // csv の読み込み元ファイル
+	FixtureDir            string // fixture の読み込み元ディレクトリ
+}
+
+// ParseWeatherProviderOrder カンマ区切りの取得元の並びを分解（例: "jma,openweathermap,mock"）
+func ParseWeatherProviderOrder(value string) []string {
+	var order []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// BuildWeatherProviders 構成に従って取得元を優先順に作成する
+// 設定が不足している取得元（APIキーのないOpenWeatherMapなど）は警告を出して除外する
+func BuildWeatherProviders(cfg WeatherProviderConfig) ([]WeatherProvider, error) {
+	var providers []WeatherProvider
+	for _, name := range cfg.Order {
+		switch name {
+		case "jma":
+			providers = append(providers, NewJMAWeatherProvider(nil, cfg.JMABaseURL))
+		case "openweathermap":
+			if !isOpenWeatherMapKeyConfigured(cfg.OpenWeatherMapAPIKey) {
+				log.Printf("⚠️ OPENWEATHERMAP_API_KEY が設定されていないため、OpenWeatherMap は使用しません")
+				continue
+			}
+			providers = append(providers, NewOpenWeatherMapWeatherProvider(cfg.OpenWeatherMapAPIKey, cfg.OpenWeatherMapBaseURL))
+		case "csv":
+			if cfg.CSVPath == "" {
+				log.Printf("⚠️ WEATHER_CSV_PATH が設定されていないため、CSVの気象データは使用しません")
+				continue
+			}
+			providers = append(providers, NewCSVWeatherProvider(cfg.CSVPath))
+		case "fixture":
+			if cfg.FixtureDir == "" {
+				log.Printf("⚠️ WEATHER_FIXTURE_DIR が設定されていないため、記録済みの気象データは使用しません")
+				continue
+			}
+			providers = append(providers, NewFixtureWeatherProvider(cfg.FixtureDir))
+		case "mock":
+			providers = append(providers, NewMockWeatherProvider())
+		default:
+			return nil, fmt.Errorf("不明な気象データの取得元です: %s（jma, openweathermap, csv, fixture, mock のいずれかを指定してください）", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("気象データの取得元が1つもありません")
+	}
+	return providers, nil
+}
+
+// isOpenWeatherMapKeyConfigured .env.example・README のプレースホルダーは未設定として扱う
+func isOpenWeatherMapKeyConfigured(apiKey string) bool {
+	switch apiKey {
+	case "", "your_api_key_here", "your-api-key-here", "YOUR_OPENWEATHERMAP_API_KEY":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeatherProviderChainForecastFallback(t *testing.T) {
+	failing := &fakeWeatherProvider{name: "jma", err: errors.New("timeout")}
+	primary := &fakeWeatherProvider{name: "openweathermap", forecasts: []DailyForecast{
+		{Date: "2024-06-11", Weather: "晴れ"},
+		{Date: "2024-06-10", Weather: "くもり"},
+	}}
+	secondary := &fakeWeatherProvider{name: "csv", forecasts: []DailyForecast{
+		{Date: "2024-06-10", Weather: "雨"},
+		{Date: "2024-06-12", Weather: "雨"},
+	}}
+
+	chain := NewWeatherProviderChain(failing, nil, primary, secondary)
+	forecasts, err := chain.Forecast(context.Background(), WeatherLocation{RegionCode: "240000"})
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+
+	expected := []struct{ date, weather, provider string }{
+		{"2024-06-10", "くもり", "openweathermap"},
+		{"2024-06-11", "晴れ", "openweathermap"},
+		{"2024-06-12", "雨", "csv"},
+	}
+	if len(forecasts) != len(expected) {
+		t.Fatalf("Expected %d forecasts, got %d", len(expected), len(forecasts))
+	}
+	for i, want := range expected {
+		got := forecasts[i]
+		if got.Date != want.date || got.Weather != want.weather || got.Provider != want.provider {
+			t.Errorf("forecasts[%d] = %s/%s/%s, expected %s/%s/%s", i, got.Date, got.Weather, got.Provider, want.date, want.weather, want.provider)
+		}
+	}
+	if names := chain.Names(); len(names) != 3 || names[0] != "jma" {
+		t.Errorf("Unexpected provider names: %v", names)
+	}
+}
+
+func TestWeatherProviderChainHistoricalFillsGaps(t *testing.T) {
+	recorded := &fakeWeatherProvider{name: "fixture", historical: func(WeatherLocation, time.Time, time.Time) []HistoricalWeatherData {
+		return []HistoricalWeatherData{
+			{Date: "2024-06-01", Temperature: 21},
+			{Date: "2024-06-03", Temperature: 23},
+			{Date: "2024-05-31", Temperature: 99}, // 期間外
+		}
+	}}
+	chain := NewWeatherProviderChain(recorded, NewMockWeatherProvider())
+
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	data, err := chain.Historical(context.Background(), WeatherLocation{RegionCode: "240000", RegionName: "三重県"}, start, end)
+	if err != nil {
+		t.Fatalf("Historical failed: %v", err)
+	}
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 days, got %d", len(data))
+	}
+	providers := []string{"fixture", "mock", "fixture"}
+	for i, d := range data {
+		if d.Provider != providers[i] {
+			t.Errorf("%s served by %s, expected %s", d.Date, d.Provider, providers[i])
+		}
+	}
+	if data[0].Temperature != 21 || data[2].Temperature != 23 {
+		t.Errorf("Recorded values should take priority: %+v", data)
+	}
+
+	if _, err := NewWeatherProviderChain(NewMockWeatherProvider()).Forecast(context.Background(), WeatherLocation{}); err == nil {
+		t.Error("Expected an error when no provider supports forecasts")
+	}
+}
+
+func TestCSVWeatherProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather.csv")
+	content := "\ufeffdate,region_code,max_temp,min_temp,humidity,precipitation,precipitation_probability,weather,weather_code\n" +
+		"2024-06-09,240000,27,19,70,0,10,晴れ,100\n" +
+		"2024-06-10,240000,24,18,85,12.5,80,雨,\n" +
+		"2024-06-10,130000,30,22,60,0,0,晴れ,100\n" +
+		"2024-06-11,,25,,,,,くもり,\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	provider := NewCSVWeatherProvider(path)
+	provider.now = func() time.Time { return time.Date(2024, 6, 10, 9, 0, 0, 0, jst) }
+	location := WeatherLocation{RegionCode: "240000", RegionName: "三重県"}
+
+	historical, err := provider.Historical(context.Background(), location,
+		time.Date(2024, 6, 9, 0, 0, 0, 0, jst), time.Date(2024, 6, 10, 0, 0, 0, 0, jst))
+	if err != nil {
+		t.Fatalf("Historical failed: %v", err)
+	}
+	if len(historical) != 2 {
+		t.Fatalf("Expected 2 rows for 240000, got %d", len(historical))
+	}
+	if historical[0].Temperature != 23 || historical[1].Precipitation != 12.5 || historical[1].RegionName != "三重県" {
+		t.Errorf("Unexpected historical rows: %+v", historical)
+	}
+
+	forecasts, err := provider.Forecast(context.Background(), location)
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	if len(forecasts) != 2 {
+		t.Fatalf("Expected forecasts from today onwards, got %d", len(forecasts))
+	}
+	if forecasts[0].Category != WeatherCategoryRainy || forecasts[0].PrecipitationProbability == nil || *forecasts[0].PrecipitationProbability != 80 {
+		t.Errorf("Unexpected forecast for 2024-06-10: %+v", forecasts[0])
+	}
+	if forecasts[1].Category != WeatherCategoryCloudy || forecasts[1].MinTemp != nil || forecasts[1].MaxTemp == nil {
+		t.Errorf("Unexpected forecast for 2024-06-11: %+v", forecasts[1])
+	}
+}
+
+func TestFixtureWeatherProviderRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	location := WeatherLocation{RegionCode: "240000", RegionName: "三重県"}
+
+	provider := NewFixtureWeatherProvider(dir)
+	if _, err := provider.Forecast(context.Background(), location); !errors.Is(err, ErrWeatherNotSupported) {
+		t.Fatalf("Expected ErrWeatherNotSupported for a missing fixture, got %v", err)
+	}
+
+	maxTemp := 28.0
+	forecasts := []DailyForecast{{Date: "2024-06-10", AreaCode: "240010", MaxTemp: &maxTemp, Provider: "jma"}}
+	first := []HistoricalWeatherData{{Date: "2024-06-01", Temperature: 20}, {Date: "2024-06-02", Temperature: 21}}
+	second := []HistoricalWeatherData{{Date: "2024-06-02", Temperature: 22}, {Date: "2024-06-03", Temperature: 23}}
+	if err := RecordWeatherFixture(dir, "240000", forecasts, first); err != nil {
+		t.Fatalf("RecordWeatherFixture failed: %v", err)
+	}
+	if err := RecordWeatherFixture(dir, "240000", nil, second); err != nil {
+		t.Fatalf("RecordWeatherFixture failed: %v", err)
+	}
+
+	gotForecasts, err := provider.Forecast(context.Background(), location)
+	if err != nil || len(gotForecasts) != 1 || gotForecasts[0].MaxTemp == nil || *gotForecasts[0].MaxTemp != 28 {
+		t.Fatalf("Unexpected recorded forecasts: %+v, err %v", gotForecasts, err)
+	}
+	historical, err := provider.Historical(context.Background(), location,
+		time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Historical failed: %v", err)
+	}
+	if len(historical) != 3 || historical[1].Temperature != 22 {
+		t.Errorf("Recorded historical data should be merged by date: %+v", historical)
+	}
+
+	// 気象庁の予報JSONをそのまま置いた場合も読み込める
+	raw, err := os.ReadFile(jmaForecastFixturePath("130000"))
+	if err != nil {
+		t.Fatalf("Failed to read fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "forecast_130000.json"), raw, 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	jmaForecasts, err := provider.Forecast(context.Background(), WeatherLocation{RegionCode: "130000"})
+	if err != nil || len(jmaForecasts) == 0 {
+		t.Errorf("Failed to read raw JMA fixture: %d forecasts, err %v", len(jmaForecasts), err)
+	}
+}
+
+func TestBuildWeatherProviders(t *testing.T) {
+	providers, err := BuildWeatherProviders(WeatherProviderConfig{
+		Order:                ParseWeatherProviderOrder(" JMA, openweathermap ,csv,mock,"),
+		OpenWeatherMapAPIKey: "your_api_key_here",
+	})
+	if err != nil {
+		t.Fatalf("BuildWeatherProviders failed: %v", err)
+	}
+	names := NewWeatherProviderChain(providers...).Names()
+	if fmt.Sprint(names) != "[jma mock]" {
+		t.Errorf("Unconfigured providers should be skipped, got %v", names)
+	}
+
+	for _, key := range []string{"your-api-key-here", "YOUR_OPENWEATHERMAP_API_KEY", ""} {
+		if isOpenWeatherMapKeyConfigured(key) {
+			t.Errorf("Placeholder key %q should be treated as unset", key)
+		}
+	}
+
+	if _, err := BuildWeatherProviders(WeatherProviderConfig{Order: []string{"jma", "satellite"}}); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+	if _, err := BuildWeatherProviders(WeatherProviderConfig{Order: []string{"csv"}}); err == nil {
+		t.Error("Expected an error when no provider is available")
+	}
+}
+
+func TestOpenWeatherMapWeatherProviderForecast(t *testing.T) {
+	// 2024-06-10 の 09:00 / 12:00 / 15:00（日本時間）と翌日 12:00
+	body := `{"list": [
+		{"dt": 1717977600, "main": {"temp_min": 21.0, "temp_max": 23.5, "humidity": 70}, "weather": [{"id": 803, "description": "曇りがち"}], "pop": 0.2},
+		{"dt": 1717988400, "main": {"temp_min": 24.0, "temp_max": 27.0, "humidity": 65}, "weather": [{"id": 800, "description": "晴天"}], "pop": 0.1},
+		{"dt": 1717999200, "main": {"temp_min": 23.0, "temp_max": 26.0, "humidity": 75}, "weather": [{"id": 500, "description": "小雨"}], "pop": 0.64},
+		{"dt": 1718074800, "main": {"temp_min": 19.0, "temp_max": 22.0, "humidity": 90}, "weather": [{"id": 501, "description": "適度な雨"}], "pop": 0.9}
+	]}`
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path + "?" + r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	provider := NewOpenWeatherMapWeatherProvider("test-key", server.URL+"/")
+	if _, err := provider.Forecast(context.Background(), WeatherLocation{RegionCode: "999999"}); !errors.Is(err, ErrWeatherNotSupported) {
+		t.Errorf("Expected ErrWeatherNotSupported without coordinates, got %v", err)
+	}
+
+	forecasts, err := provider.Forecast(context.Background(), WeatherLocation{RegionCode: "240000", RegionName: "三重県", Lat: 34.88, Lon: 136.58})
+	if err != nil {
+		t.Fatalf("Forecast failed: %v", err)
+	}
+	if !strings.HasPrefix(requested, "/forecast?") || !strings.Contains(requested, "appid=test-key") {
+		t.Errorf("Expected a request to the forecast endpoint, got %q", requested)
+	}
+	if len(forecasts) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(forecasts))
+	}
+	day := forecasts[0]
+	if day.Date != "2024-06-10" || *day.MinTemp != 21 || *day.MaxTemp != 27 || *day.PrecipitationProbability != 64 {
+		t.Errorf("Unexpected aggregation: %+v", day)
+	}
+	if day.Category != WeatherCategorySunny || day.WeatherCode != "100" || day.Weather != "晴天" {
+		t.Errorf("Weather should come from the entry nearest noon: %+v", day)
+	}
+	if forecasts[1].Category != WeatherCategoryRainy {
+		t.Errorf("Expected rainy for 2024-06-11, got %s", forecasts[1].Category)
+	}
+}
@@ -0,0 +1,648 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultJMABaseURL 気象庁の予報JSONの配信元
+const DefaultJMABaseURL = "https://www.jma.go.jp/bosai/forecast/data/forecast"
+
+// jst 日付の区切りに使う日本時間
+var jst = time.FixedZone("JST", 9*60*60)
+
+// ===== 気象庁 =====
+
+// JMAWeatherProvider 気象庁の予報（過去データは未対応）
+type JMAWeatherProvider struct {
+	client  *http.Client
+	baseURL string
+}
+
+// NewJMAWeatherProvider 気象庁の取得元を作成（client・baseURLが空の場合は既定値）
+func NewJMAWeatherProvider(client *http.Client, baseURL string) *JMAWeatherProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if baseURL == "" {
+		baseURL = DefaultJMABaseURL
+	}
+	return &JMAWeatherProvider{client: client, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Name 取得元の名前
+func (p *JMAWeatherProvider) Name() string { return "jma" }
+
+// FetchForecast 気象庁の予報JSONをそのまま取得
+func (p *JMAWeatherProvider) FetchForecast(ctx context.Context, regionCode string) ([]JMAForecastData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s.json", p.baseURL, regionCode), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch forecast data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var forecastData []JMAForecastData
+	if err := json.Unmarshal(body, &forecastData); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return forecastData, nil
+}
+
+// Forecast 日別予報
+func (p *JMAWeatherProvider) Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error) {
+	forecastData, err := p.FetchForecast(ctx, location.RegionCode)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJMAForecast(forecastData)
+}
+
+// Historical 気象庁の過去データは未対応
+func (p *JMAWeatherProvider) Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	return nil, fmt.Errorf("%w: 気象庁の過去データ", ErrWeatherNotSupported)
+}
+
+// ===== 模擬データ =====
+
+// mockWeatherProviderName 模擬データの取得元の名前（実際の観測値ではないことの判定に使う）
+const mockWeatherProviderName = "mock"
+
+// MockWeatherProvider 季節を考慮した模擬的な過去データ（予報は未対応）
+type MockWeatherProvider struct{}
+
+// NewMockWeatherProvider 模擬データの取得元を作成
+func NewMockWeatherProvider() *MockWeatherProvider {
+	return &MockWeatherProvider{}
+}
+
+// Name 取得元の名前
+func (p *MockWeatherProvider) Name() string { return mockWeatherProviderName }
+
+// Forecast 模擬の予報は提供しない（実在しない予報を需要予測に使わないため）
+func (p *MockWeatherProvider) Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error) {
+	return nil, fmt.Errorf("%w: 模擬データの予報", ErrWeatherNotSupported)
+}
+
+// Historical 指定期間の模擬データを一括生成
+func (p *MockWeatherProvider) Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	return generateMockHistoricalData(location, startDate, endDate), nil
+}
+
+// generateMockHistoricalData 指定期間の模擬データを一括生成（高速版）
+func generateMockHistoricalData(location WeatherLocation, startDate, endDate time.Time) []HistoricalWeatherData {
+	// 日数を計算して事前にメモリ確保
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	if days < 0 {
+		days = 0
+	}
+	result := make([]HistoricalWeatherData, 0, days)
+
+	for i := 0; i < days; i++ {
+		date := startDate.AddDate(0, 0, i)
+		month := date.Month()
+		baseTemp := 20.0
+
+		// 季節による気温調整
+		switch {
+		case month >= 6 && month <= 8: // 夏
+			baseTemp = 28.0
+		case month >= 12 || month <= 2: // 冬
+			baseTemp = 8.0
+		case month >= 3 && month <= 5: // 春
+			baseTemp = 18.0
+		case month >= 9 && month <= 11: // 秋
+			baseTemp = 20.0
+		}
+
+		// 日付に基づく変動を追加
+		dayVariation := float64(date.Day()%10 - 5)
+
+		result = append(result, HistoricalWeatherData{
+			Date:          date.Format("2006-01-02"),
+			RegionCode:    location.RegionCode,
+			RegionName:    location.RegionName,
+			Temperature:   baseTemp + dayVariation,
+			MaxTemp:       baseTemp + dayVariation + 5,
+			MinTemp:       baseTemp + dayVariation - 5,
+			Humidity:      60.0 + float64(date.Day()%20),
+			Precipitation: 0.0,
+			WindSpeed:     2.0 + float64(date.Day()%5),
+			WindDirection: "南",
+			Pressure:      1013.25,
+			Weather:       "晴れ",
+			WeatherCode:   "100",
+			DataSource:    "模擬データ（一括生成）",
+		})
+	}
+	return result
+}
+
+// ===== OpenWeatherMap =====
+
+// OpenWeatherMapWeatherProvider OpenWeatherMap の5日間予報と過去データ（座標が必要）
+type OpenWeatherMapWeatherProvider struct {
+	service *OpenWeatherMapService
+}
+
+// NewOpenWeatherMapWeatherProvider OpenWeatherMap の取得元を作成
+func NewOpenWeatherMapWeatherProvider(apiKey, baseURL string) *OpenWeatherMapWeatherProvider {
+	if baseURL == "" {
+		baseURL = "https://api.openweathermap.org/data/2.5"
+	}
+	return &OpenWeatherMapWeatherProvider{service: NewOpenWeatherMapService(apiKey, strings.TrimRight(baseURL, "/"))}
+}
+
+// Name 取得元の名前
+func (p *OpenWeatherMapWeatherProvider) Name() string { return "openweathermap" }
+
+// openWeatherMapForecastResponse 5日間・3時間ごとの予報
+type openWeatherMapForecastResponse struct {
+	List []struct {
+		Dt   int64 `json:"dt"`
+		Main struct {
+			TempMin  float64 `json:"temp_min"`
+			TempMax  float64 `json:"temp_max"`
+			Humidity float64 `json:"humidity"`
+		} `json:"main"`
+		Weather []struct {
+			ID          int    `json:"id"`
+			Description string `json:"description"`
+		} `json:"weather"`
+		Pop float64 `json:"pop"` // 0〜1
+	} `json:"list"`
+}
+
+// Forecast 3時間ごとの予報を日本時間の日別にまとめる（天気は正午に最も近い時刻のもの）
+func (p *OpenWeatherMapWeatherProvider) Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error) {
+	if !location.HasCoordinates() {
+		return nil, fmt.Errorf("%w: 座標が不明な地域です（%s）", ErrWeatherNotSupported, location.RegionCode)
+	}
+
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(location.Lat, 'f', 4, 64))
+	query.Set("lon", strconv.FormatFloat(location.Lon, 'f', 4, 64))
+	query.Set("appid", p.service.apiKey)
+	query.Set("units", "metric")
+	query.Set("lang", "ja")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.service.baseURL+"/forecast?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("OpenWeatherMap リクエスト作成エラー: %w", err)
+	}
+	resp, err := p.service.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OpenWeatherMap API呼び出しエラー: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OpenWeatherMap API エラー: %d", resp.StatusCode)
+	}
+
+	var body openWeatherMapForecastResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("JSONパースエラー: %w", err)
+	}
+
+	byDate := make(map[string]*DailyForecast)
+	middayDistance := make(map[string]float64)
+	var dates []string
+	for _, item := range body.List {
+		t := time.Unix(item.Dt, 0).In(jst)
+		date := t.Format("2006-01-02")
+		day, ok := byDate[date]
+		if !ok {
+			minTemp, maxTemp := item.Main.TempMin, item.Main.TempMax
+			day = &DailyForecast{
+				Date:           date,
+				AreaCode:       location.RegionCode,
+				AreaName:       location.RegionName,
+				MinTemp:        &minTemp,
+				MaxTemp:        &maxTemp,
+				Source:         "openweathermap",
+				ReportDatetime: time.Now().In(jst).Format(time.RFC3339),
+			}
+			byDate[date] = day
+			middayDistance[date] = 24
+			dates = append(dates, date)
+		}
+		if item.Main.TempMin < *day.MinTemp {
+			*day.MinTemp = item.Main.TempMin
+		}
+		if item.Main.TempMax > *day.MaxTemp {
+			*day.MaxTemp = item.Main.TempMax
+		}
+		pop := int(item.Pop*100 + 0.5)
+		if day.PrecipitationProbability == nil || pop > *day.PrecipitationProbability {
+			day.PrecipitationProbability = &pop
+		}
+		if distance := math.Abs(float64(t.Hour()) - 12); distance < middayDistance[date] && len(item.Weather) > 0 {
+			middayDistance[date] = distance
+			day.Category = openWeatherMapCategory(item.Weather[0].ID)
+			day.WeatherCode = jmaCodeForCategory(day.Category)
+			day.Weather = item.Weather[0].Description
+		}
+	}
+
+	sort.Strings(dates)
+	forecasts := make([]DailyForecast, 0, len(dates))
+	for _, date := range dates {
+		forecasts = append(forecasts, *byDate[date])
+	}
+	return forecasts, nil
+}
+
+// Historical 日ごとに過去データを取得（取得できなかった日は含めない）
+func (p *OpenWeatherMapWeatherProvider) Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	if !location.HasCoordinates() {
+		return nil, fmt.Errorf("%w: 座標が不明な地域です（%s）", ErrWeatherNotSupported, location.RegionCode)
+	}
+
+	var result []HistoricalWeatherData
+	var lastErr error
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		data, err := p.service.GetHistoricalWeatherFromOpenWeatherMap(location.Lat, location.Lon, date)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data.RegionCode = location.RegionCode
+		data.RegionName = location.RegionName
+		result = append(result, *data)
+	}
+	if len(result) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return result, nil
+}
+
+// PointHistorical 座標で取得するため、観測所の座標を指定すればその地点の過去データになる
+func (p *OpenWeatherMapWeatherProvider) PointHistorical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	return p.Historical(ctx, location, startDate, endDate)
+}
+
+// openWeatherMapCategory OpenWeatherMap の天気IDを大分類に変換
+func openWeatherMapCategory(id int) WeatherCategory {
+	switch {
+	case id >= 200 && id < 600: // 雷雨・霧雨・雨
+		return WeatherCategoryRainy
+	case id >= 600 && id < 700:
+		return WeatherCategorySnowy
+	case id == 800:
+		return WeatherCategorySunny
+	case id > 800 || (id >= 700 && id < 800): // 雲・霧など
+		return WeatherCategoryCloudy
+	default:
+		return WeatherCategoryUnknown
+	}
+}
+
+// jmaCodeForCategory 大分類に対応する気象庁の代表的な天気コード
+func jmaCodeForCategory(category WeatherCategory) string {
+	switch category {
+	case WeatherCategorySunny:
+		return "100"
+	case WeatherCategoryCloudy:
+		return "200"
+	case WeatherCategoryRainy:
+		return "300"
+	case WeatherCategorySnowy:
+		return "400"
+	default:
+		return ""
+	}
+}
+
+// ===== CSVファイル =====
+
+// CSVWeatherProvider 日別の気象データを記録したCSVファイル
+// 列（1行目の見出しで指定）: date（必須）, region_code, temperature, max_temp, min_temp, humidity,
+// precipitation, precipitation_probability, weather, weather_code
+// region_code が空の行はすべての地域に使う（店舗・拠点IDも指定できる）。今日以降の行は予報として扱う
+type CSVWeatherProvider struct {
+	path string
+	now  func() time.Time
+}
+
+// NewCSVWeatherProvider CSVファイルの取得元を作成
+func NewCSVWeatherProvider(path string) *CSVWeatherProvider {
+	return &CSVWeatherProvider{path: path, now: time.Now}
+}
+
+// Name 取得元の名前
+func (p *CSVWeatherProvider) Name() string { return "csv" }
+
+// csvWeatherRow CSVの1行
+type csvWeatherRow struct {
+	data        HistoricalWeatherData
+	probability *int
+	hasMin      bool
+	hasMax      bool
+}
+
+// Forecast 今日以降の行を予報として返す
+func (p *CSVWeatherProvider) Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error) {
+	rows, err := p.load(location)
+	if err != nil {
+		return nil, err
+	}
+	today := p.now().In(jst).Format("2006-01-02")
+
+	var forecasts []DailyForecast
+	for _, row := range rows {
+		if row.data.Date < today {
+			continue
+		}
+		forecast := DailyForecast{
+			Date:                     row.data.Date,
+			AreaCode:                 location.RegionCode,
+			AreaName:                 location.RegionName,
+			WeatherCode:              row.data.WeatherCode,
+			Weather:                  row.data.Weather,
+			Category:                 categoryForWeather(row.data.WeatherCode, row.data.Weather),
+			PrecipitationProbability: row.probability,
+			Source:                   "csv",
+		}
+		if row.hasMin {
+			minTemp := row.data.MinTemp
+			forecast.MinTemp = &minTemp
+		}
+		if row.hasMax {
+			maxTemp := row.data.MaxTemp
+			forecast.MaxTemp = &maxTemp
+		}
+		forecasts = append(forecasts, forecast)
+	}
+	if len(forecasts) == 0 {
+		return nil, fmt.Errorf("%w: CSVに今日以降の行がありません", ErrWeatherNotSupported)
+	}
+	return forecasts, nil
+}
+
+// Historical 期間内の行を返す
+func (p *CSVWeatherProvider) Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	rows, err := p.load(location)
+	if err != nil {
+		return nil, err
+	}
+	start, end := startDate.Format("2006-01-02"), endDate.Format("2006-01-02")
+
+	var result []HistoricalWeatherData
+	for _, row := range rows {
+		if row.data.Date >= start && row.data.Date <= end {
+			result = append(result, row.data)
+		}
+	}
+	return result, nil
+}
+
+// load CSVを読み込み、地点の地域に該当する行を日付順に返す
+func (p *CSVWeatherProvider) load(location WeatherLocation) ([]csvWeatherRow, error) {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("気象データのCSVを開けません: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("気象データのCSVを読み込めません: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("気象データのCSVが空です")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["date"]; !ok {
+		return nil, fmt.Errorf("気象データのCSVに date 列がありません")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []csvWeatherRow
+	for line, record := range records[1:] {
+		regionCode := field(record, "region_code")
+		if regionCode != "" && regionCode != location.RegionCode && regionCode != location.SiteID {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", field(record, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("気象データのCSVの%d行目: 日付の形式が不正です（%s）", line+2, field(record, "date"))
+		}
+
+		row := csvWeatherRow{data: HistoricalWeatherData{
+			Date:        date.Format("2006-01-02"),
+			RegionCode:  location.RegionCode,
+			RegionName:  location.RegionName,
+			Weather:     field(record, "weather"),
+			WeatherCode: field(record, "weather_code"),
+			DataSource:  filepath.Base(p.path),
+		}}
+		temperature, hasTemp := parseForecastFloat(field(record, "temperature"))
+		row.data.MaxTemp, row.hasMax = parseForecastFloat(field(record, "max_temp"))
+		row.data.MinTemp, row.hasMin = parseForecastFloat(field(record, "min_temp"))
+		switch {
+		case hasTemp:
+			row.data.Temperature = temperature
+		case row.hasMax && row.hasMin:
+			row.data.Temperature = (row.data.MaxTemp + row.data.MinTemp) / 2
+		}
+		row.data.Humidity, _ = parseForecastFloat(field(record, "humidity"))
+		row.data.Precipitation, _ = parseForecastFloat(field(record, "precipitation"))
+		if probability, ok := parseForecastInt(field(record, "precipitation_probability")); ok {
+			row.probability = &probability
+		}
+		rows = append(rows, row)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].data.Date < rows[j].data.Date })
+	return rows, nil
+}
+
+// categoryForWeather 天気コードがあればコードから、なければ天気の文言から大分類を判定
+func categoryForWeather(code, weather string) WeatherCategory {
+	if category := WeatherCategoryForCode(code); category != WeatherCategoryUnknown {
+		return category
+	}
+	switch {
+	case strings.Contains(weather, "雪"):
+		return WeatherCategorySnowy
+	case strings.Contains(weather, "雨"):
+		return WeatherCategoryRainy
+	case strings.Contains(weather, "くもり"), strings.Contains(weather, "曇"):
+		return WeatherCategoryCloudy
+	case strings.Contains(weather, "晴"):
+		return WeatherCategorySunny
+	default:
+		return WeatherCategoryUnknown
+	}
+}
+
+// ===== 記録済みデータ =====
+
+// FixtureWeatherProvider ディレクトリに記録した気象データ（オフラインのテスト・デモ用）
+// forecast_<地域コード>.json: 気象庁の予報JSONそのもの、または DailyForecast の配列
+// historical_<地域コード>.json: HistoricalWeatherData の配列
+// 地域コードの代わりに店舗・拠点IDのファイルがあれば、その拠点ではそちらを優先する
+type FixtureWeatherProvider struct {
+	dir string
+}
+
+// NewFixtureWeatherProvider 記録済みデータの取得元を作成
+func NewFixtureWeatherProvider(dir string) *FixtureWeatherProvider {
+	return &FixtureWeatherProvider{dir: dir}
+}
+
+// Name 取得元の名前
+func (p *FixtureWeatherProvider) Name() string { return "fixture" }
+
+// Forecast 記録済みの予報
+func (p *FixtureWeatherProvider) Forecast(ctx context.Context, location WeatherLocation) ([]DailyForecast, error) {
+	raw, err := p.readFor("forecast", location)
+	if err != nil {
+		return nil, err
+	}
+
+	var jmaData []JMAForecastData
+	if err := json.Unmarshal(raw, &jmaData); err == nil && len(jmaData) > 0 && len(jmaData[0].TimeSeries) > 0 {
+		return ParseJMAForecast(jmaData)
+	}
+	var forecasts []DailyForecast
+	if err := json.Unmarshal(raw, &forecasts); err != nil {
+		return nil, fmt.Errorf("記録済みの予報を読み込めません: %w", err)
+	}
+	return forecasts, nil
+}
+
+// Historical 記録済みの過去データのうち期間内のもの
+func (p *FixtureWeatherProvider) Historical(ctx context.Context, location WeatherLocation, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	raw, err := p.readFor("historical", location)
+	if err != nil {
+		return nil, err
+	}
+	var recorded []HistoricalWeatherData
+	if err := json.Unmarshal(raw, &recorded); err != nil {
+		return nil, fmt.Errorf("記録済みの過去データを読み込めません: %w", err)
+	}
+
+	start, end := startDate.Format("2006-01-02"), endDate.Format("2006-01-02")
+	var result []HistoricalWeatherData
+	for _, d := range recorded {
+		if d.Date >= start && d.Date <= end {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// readFor 店舗・拠点の記録があればそれを、なければ府県予報区の記録を読む
+func (p *FixtureWeatherProvider) readFor(kind string, location WeatherLocation) ([]byte, error) {
+	if location.SiteID != "" {
+		if raw, err := p.read(kind, location.SiteID); !errors.Is(err, ErrWeatherNotSupported) {
+			return raw, err
+		}
+	}
+	return p.read(kind, location.RegionCode)
+}
+
+func (p *FixtureWeatherProvider) read(kind, regionCode string) ([]byte, error) {
+	path := weatherFixturePath(p.dir, kind, regionCode)
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: 記録済みのデータがありません（%s）", ErrWeatherNotSupported, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("記録済みのデータを読み込めません: %w", err)
+	}
+	return raw, nil
+}
+
+// weatherFixturePath 記録済みデータのファイルパス
+func weatherFixturePath(dir, kind, regionCode string) string {
+	return filepath.Join(dir, fmt.Sprintf("%s_%s.json", kind, regionCode))
+}
+
+// RecordWeatherFixture 取得したデータを記録済みデータとして保存する（FixtureWeatherProvider で再生できる）
+// 過去データは既存の記録と日付単位で統合する
+func RecordWeatherFixture(dir, regionCode string, forecasts []DailyForecast, historical []HistoricalWeatherData) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("記録先のディレクトリを作成できません: %w", err)
+	}
+
+	if len(forecasts) > 0 {
+		if err := writeJSONFile(weatherFixturePath(dir, "forecast", regionCode), forecasts); err != nil {
+			return err
+		}
+	}
+
+	if len(historical) > 0 {
+		path := weatherFixturePath(dir, "historical", regionCode)
+		byDate := make(map[string]HistoricalWeatherData)
+		if raw, err := os.ReadFile(path); err == nil {
+			var existing []HistoricalWeatherData
+			if err := json.Unmarshal(raw, &existing); err == nil {
+				for _, d := range existing {
+					byDate[d.Date] = d
+				}
+			}
+		}
+		for _, d := range historical {
+			byDate[d.Date] = d
+		}
+		merged := make([]HistoricalWeatherData, 0, len(byDate))
+		for _, d := range byDate {
+			merged = append(merged, d)
+		}
+		sort.Slice(merged, func(i, j int) bool { return merged[i].Date < merged[j].Date })
+		if err := writeJSONFile(path, merged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSONFile(path string, value interface{}) error {
+	raw, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("JSONへの変換に失敗: %w", err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return fmt.Errorf("記録済みデータを書き込めません: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// 欠測・異常値の補完方法
+const (
+	WeatherGapFillLinear         = "linear"          // 前後の観測値から直線で補間
+	WeatherGapFillClimatology    = "climatology"     // 過去の同じ時期（前後7日）の平均
+	WeatherGapFillNearestStation = "nearest_station" // 近くの別のアメダス観測所の値
+)
+
+// 検証で見つかった問題の種類
+const (
+	WeatherIssueMissing     = "missing"     // 欠測日
+	WeatherIssueImplausible = "implausible" // 物理的にありえない値
+	WeatherIssueUnit        = "unit"        // 単位の誤り（華氏・ケルビン・湿度の割合表記）
+	WeatherIssueJump        = "jump"        // 前日からの急な変化
+)
+
+const (
+	linearGapFillMaxDays     = 7    // 直線補間に使う前後の観測値を探す日数
+	climatologyYears         = 3    // 平年値に使う過去の年数
+	climatologyWindowDays    = 7    // 平年値に使う前後の日数
+	nearestStationMaxKm      = 50.0 // 補完に使う近くの観測所の最大距離
+	temperatureJumpThreshold = 12.0 // 前日からの日平均気温の変化（℃）がこれを超えたら急変とする
+)
+
+// weatherFields 検証・補完の対象にする項目
+var weatherFields = []string{"temperature", "max_temp", "min_temp", "humidity", "precipitation", "wind_speed", "pressure"}
+
+// plausibleWeatherRanges 項目ごとの物理的にありうる範囲（国内の観測記録に余裕を持たせた値）
+// 湿度・気圧の0は取得元が値を返さなかったものとして検証しない
+var plausibleWeatherRanges = map[string][2]float64{
+	"temperature":   {-40, 45},
+	"max_temp":      {-40, 45},
+	"min_temp":      {-40, 45},
+	"humidity":      {1, 100},
+	"precipitation": {0, 900},
+	"wind_speed":    {0, 90},
+	"pressure":      {870, 1090},
+}
+
+// ParseWeatherGapFill カンマ区切りの補完方法を分解する（例: "linear,climatology"、"none" は補完しない）
+func ParseWeatherGapFill(value string) ([]string, error) {
+	var strategies []string
+	for _, name := range strings.Split(value, ",") {
+		switch name = strings.ToLower(strings.TrimSpace(name)); name {
+		case "", "none":
+		case WeatherGapFillLinear, WeatherGapFillClimatology, WeatherGapFillNearestStation:
+			strategies = append(strategies, name)
+		default:
+			return nil, fmt.Errorf("不明な補完方法です: %s", name)
+		}
+	}
+	return strategies, nil
+}
+
+// WeatherQualityIssue 検証で見つかった問題
+type WeatherQualityIssue struct {
+	Date        string  `json:"date"`
+	Type        string  `json:"type"` // missing / implausible / unit / jump
+	Field       string  `json:"field,omitempty"`
+	Value       float64 `json:"value,omitempty"`
+	Description string  `json:"description"`
+	Resolution  string  `json:"resolution"` // imputed:<方法> / converted / excluded / unfilled / flagged
+}
+
+// WeatherQualityReport 期間の気象データの検証結果
+type WeatherQualityReport struct {
+	RegionCode   string                `json:"region_code"`
+	Period       string                `json:"period"`
+	ExpectedDays int                   `json:"expected_days"`
+	ObservedDays int                   `json:"observed_days"` // 取得元にデータがあった日
+	MissingDates []string              `json:"missing_dates"`
+	ImputedDays  int                   `json:"imputed_days"`  // 補完した値を含む日
+	ExcludedDays int                   `json:"excluded_days"` // 補完できない異常値があり除いた日
+	Strategies   []string              `json:"strategies"`
+	Issues       []WeatherQualityIssue `json:"issues"`
+	QualityRate  float64               `json:"quality_rate"` // 問題のない観測日の割合（%）
+}
+
+// weatherFieldValue 項目名に対応する値への参照
+func weatherFieldValue(d *HistoricalWeatherData, field string) *float64 {
+	switch field {
+	case "temperature":
+		return &d.Temperature
+	case "max_temp":
+		return &d.MaxTemp
+	case "min_temp":
+		return &d.MinTemp
+	case "humidity":
+		return &d.Humidity
+	case "precipitation":
+		return &d.Precipitation
+	case "wind_speed":
+		return &d.WindSpeed
+	case "pressure":
+		return &d.Pressure
+	}
+	return nil
+}
+
+// reported 検証の対象にする値か（値を返さない取得元の0は対象外）
+func reported(d HistoricalWeatherData, field string) bool {
+	switch field {
+	case "humidity", "pressure":
+		return *weatherFieldValue(&d, field) != 0
+	case "max_temp", "min_temp":
+		return d.MaxTemp != 0 || d.MinTemp != 0
+	}
+	return true
+}
+
+// fahrenheitSeriesMedian 日平均気温の中央値がこれを超える取得元は華氏で返していると判断する
+// 日平均気温の国内の記録は35℃台のため摂氏では超えず、華氏では約2℃以上の期間がこれを超える
+// （それより寒い期間の華氏の値は摂氏と区別できないため変換しない）
+const fahrenheitSeriesMedian = 36.0
+
+// fahrenheitSources 取得元ごとに気温の中央値から華氏で返しているかを判断する
+// 冬の華氏の値（30〜44など）は1日ずつ見ると摂氏としてもありえるため、系列全体で単位を決める
+func fahrenheitSources(data []HistoricalWeatherData) map[string]bool {
+	temperatures := make(map[string][]float64)
+	for _, d := range data {
+		if d.Temperature >= 200 {
+			continue // ケルビンの値は日ごとに変換する
+		}
+		temperatures[d.Provider] = append(temperatures[d.Provider], d.Temperature)
+	}
+	fahrenheit := make(map[string]bool)
+	for provider, values := range temperatures {
+		if median := medianOf(values); median > fahrenheitSeriesMedian && (median-32)*5/9 <= plausibleWeatherRanges["temperature"][1] {
+			fahrenheit[provider] = true
+		}
+	}
+	return fahrenheit
+}
+
+// correctWeatherUnits 単位の誤りと判断できる値を変換し、見つかった問題を返す
+// fahrenheit は取得元が華氏で返していると系列全体から判断した場合に true（その日の値によらず変換する）
+func correctWeatherUnits(d *HistoricalWeatherData, fahrenheit bool) []WeatherQualityIssue {
+	var issues []WeatherQualityIssue
+	convert := func(unit string, fn func(float64) float64) {
+		original := d.Temperature
+		d.Temperature = roundTo(fn(d.Temperature), 1)
+		if reported(*d, "max_temp") {
+			d.MaxTemp = roundTo(fn(d.MaxTemp), 1)
+			d.MinTemp = roundTo(fn(d.MinTemp), 1)
+		}
+		d.QualityFlags = append(d.QualityFlags, "unit_corrected_temperature")
+		issues = append(issues, WeatherQualityIssue{
+			Date: d.Date, Type: WeatherIssueUnit, Field: "temperature", Value: original,
+			Description: fmt.Sprintf("気温%.1fは%sと判断して摂氏に変換しました", original, unit),
+			Resolution:  "converted",
+		})
+	}
+	switch t := d.Temperature; {
+	case fahrenheit && t < 200:
+		convert("華氏", func(v float64) float64 { return (v - 32) * 5 / 9 })
+	case t >= 200 && t-273.15 >= plausibleWeatherRanges["temperature"][0] && t-273.15 <= plausibleWeatherRanges["temperature"][1]:
+		convert("ケルビン", func(v float64) float64 { return v - 273.15 })
+	case t > plausibleWeatherRanges["temperature"][1] && (t-32)*5/9 <= plausibleWeatherRanges["temperature"][1]:
+		convert("華氏", func(v float64) float64 { return (v - 32) * 5 / 9 })
+	}
+
+	if d.Humidity > 0 && d.Humidity <= 1 {
+		original := d.Humidity
+		d.Humidity = roundTo(d.Humidity*100, 1)
+		d.QualityFlags = append(d.QualityFlags, "unit_corrected_humidity")
+		issues = append(issues, WeatherQualityIssue{
+			Date: d.Date, Type: WeatherIssueUnit, Field: "humidity", Value: original,
+			Description: fmt.Sprintf("湿度%.2fは割合表記と判断して%%に変換しました", original),
+			Resolution:  "converted",
+		})
+	}
+	return issues
+}
+
+// implausibleFields 物理的にありえない値の項目
+func implausibleFields(d HistoricalWeatherData) []string {
+	var fields []string
+	for _, field := range weatherFields {
+		if !reported(d, field) {
+			continue
+		}
+		bounds := plausibleWeatherRanges[field]
+		if v := *weatherFieldValue(&d, field); math.IsNaN(v) || v < bounds[0] || v > bounds[1] {
+			fields = append(fields, field)
+		}
+	}
+	if reported(d, "max_temp") && d.MaxTemp < d.MinTemp {
+		fields = append(fields, "max_temp", "min_temp")
+	}
+	return fields
+}
+
+// weatherGapFiller 欠測日・異常値を補完する（取得元への問い合わせは必要になった時に一度だけ行う）
+type weatherGapFiller struct {
+	ws       *WeatherService
+	key      string
+	location WeatherLocation
+	valid    map[string]HistoricalWeatherData // 日付 → 問題のない観測値（異常な項目は bad で除く）
+	bad      map[string]map[string]bool       // 日付 → 異常な項目
+	targets  []string                         // 補完が必要な日付（昇順）
+
+	climatology map[string]map[string]float64 // 日付 → 項目 → 平年値
+	neighbor    map[string]HistoricalWeatherData
+	loaded      map[string]bool // 補完方法 → 取得済みか
+}
+
+// value 補完方法で日付・項目の値を求める
+func (f *weatherGapFiller) value(strategy, date, field string) (float64, bool) {
+	switch strategy {
+	case WeatherGapFillLinear:
+		return f.linear(date, field)
+	case WeatherGapFillClimatology:
+		if !f.loaded[strategy] {
+			f.loaded[strategy] = true
+			f.climatology = f.ws.climatologyFor(f.key, f.targets)
+		}
+		v, ok := f.climatology[date][field]
+		return v, ok
+	case WeatherGapFillNearestStation:
+		if !f.loaded[strategy] {
+			f.loaded[strategy] = true
+			f.neighbor = f.ws.nearestStationWeather(f.location, f.targets)
+		}
+		d, ok := f.neighbor[date]
+		if !ok || !reported(d, field) {
+			return 0, false
+		}
+		return *weatherFieldValue(&d, field), true
+	}
+	return 0, false
+}
+
+// observed 補完に使える観測値か（その日が取得でき、項目が異常でない）
+func (f *weatherGapFiller) observed(date, field string) (float64, bool) {
+	d, ok := f.valid[date]
+	if !ok || f.bad[date][field] || !reported(d, field) {
+		return 0, false
+	}
+	return *weatherFieldValue(&d, field), true
+}
+
+// linear 前後7日以内の観測値から直線で補間する
+func (f *weatherGapFiller) linear(date, field string) (float64, bool) {
+	var before, after float64
+	var beforeDays, afterDays int
+	for i := 1; i <= linearGapFillMaxDays && beforeDays == 0; i++ {
+		if v, ok := f.observed(shiftDate(date, -i), field); ok {
+			before, beforeDays = v, i
+		}
+	}
+	for i := 1; i <= linearGapFillMaxDays && afterDays == 0; i++ {
+		if v, ok := f.observed(shiftDate(date, i), field); ok {
+			after, afterDays = v, i
+		}
+	}
+	if beforeDays == 0 || afterDays == 0 {
+		return 0, false
+	}
+	return before + (after-before)*float64(beforeDays)/float64(beforeDays+afterDays), true
+}
+
+// fill 項目を補完方法の優先順に補完する（補完できた方法を返す）
+func (f *weatherGapFiller) fill(d *HistoricalWeatherData, field string, strategies []string) (string, bool) {
+	for _, strategy := range strategies {
+		if v, ok := f.value(strategy, d.Date, field); ok {
+			*weatherFieldValue(d, field) = roundTo(v, 1)
+			if d.Imputed == nil {
+				d.Imputed = make(map[string]string)
+			}
+			d.Imputed[field] = strategy
+			return strategy, true
+		}
+	}
+	return "", false
+}
+
+// validateWeatherData 期間のデータを検証し、設定された方法で欠測日・異常値を補完する
+// 補完できない欠測日は含めず、補完できない異常値のある日は除く
+func (ws *WeatherService) validateWeatherData(key string, data []HistoricalWeatherData, startDate, endDate time.Time) ([]HistoricalWeatherData, WeatherQualityReport) {
+	location := ws.LocationFor(key)
+	start, end := startDate.Format("2006-01-02"), endDate.Format("2006-01-02")
+	report := WeatherQualityReport{
+		RegionCode:   key,
+		Period:       fmt.Sprintf("%s〜%s", start, end),
+		MissingDates: []string{},
+		Strategies:   append([]string{}, ws.gapFill...),
+		Issues:       []WeatherQualityIssue{},
+	}
+
+	filler := &weatherGapFiller{
+		ws:       ws,
+		key:      key,
+		location: location,
+		valid:    make(map[string]HistoricalWeatherData, len(data)),
+		bad:      make(map[string]map[string]bool),
+		loaded:   make(map[string]bool),
+	}
+	fahrenheit := fahrenheitSources(data)
+	for _, d := range data {
+		if d.Date < start || d.Date > end {
+			continue
+		}
+		if _, exists := filler.valid[d.Date]; exists {
+			continue
+		}
+		d.QualityFlags = nil
+		d.Imputed = nil
+		report.Issues = append(report.Issues, correctWeatherUnits(&d, fahrenheit[d.Provider])...)
+		for _, field := range implausibleFields(d) {
+			if filler.bad[d.Date] == nil {
+				filler.bad[d.Date] = make(map[string]bool)
+			}
+			filler.bad[d.Date][field] = true
+		}
+		filler.valid[d.Date] = d
+	}
+	report.ObservedDays = len(filler.valid)
+
+	// 欠測日・異常値のある日を補完の対象にする
+	var dates []string
+	for date := start; date <= end; date = shiftDate(date, 1) {
+		dates = append(dates, date)
+		if _, ok := filler.valid[date]; !ok {
+			report.MissingDates = append(report.MissingDates, date)
+			filler.targets = append(filler.targets, date)
+		} else if len(filler.bad[date]) > 0 {
+			filler.targets = append(filler.targets, date)
+		}
+	}
+	report.ExpectedDays = len(dates)
+
+	result := make([]HistoricalWeatherData, 0, len(dates))
+	var previous *HistoricalWeatherData
+	cleanDays := 0
+	for _, date := range dates {
+		d, observed := filler.valid[date]
+		if !observed {
+			d = HistoricalWeatherData{
+				Date:       date,
+				RegionCode: location.RegionCode,
+				RegionName: location.RegionName,
+				DataSource: "補完",
+			}
+			// 気温を補完できた日だけを含め、他の項目は補完できたものだけ埋める
+			method, filled := filler.fill(&d, "temperature", ws.gapFill)
+			if filled {
+				for _, field := range weatherFields[1:] {
+					filler.fill(&d, field, ws.gapFill)
+				}
+				if d.IsFieldImputed("max_temp") != d.IsFieldImputed("min_temp") {
+					d.MaxTemp, d.MinTemp = 0, 0
+					delete(d.Imputed, "max_temp")
+					delete(d.Imputed, "min_temp")
+				}
+			}
+			issue := WeatherQualityIssue{Date: date, Type: WeatherIssueMissing, Description: "取得元にデータがない日です", Resolution: "unfilled"}
+			if filled {
+				issue.Resolution = "imputed:" + method
+			}
+			report.Issues = append(report.Issues, issue)
+			if !filled {
+				previous = nil
+				continue
+			}
+		}
+
+		excluded := false
+		for _, field := range weatherFields {
+			if !filler.bad[date][field] {
+				continue
+			}
+			original := *weatherFieldValue(&d, field)
+			issue := WeatherQualityIssue{
+				Date: date, Type: WeatherIssueImplausible, Field: field, Value: original,
+				Description: fmt.Sprintf("%sの値%.1fは物理的にありえない値です", field, original),
+				Resolution:  "excluded",
+			}
+			if method, ok := filler.fill(&d, field, ws.gapFill); ok {
+				issue.Resolution = "imputed:" + method
+			} else {
+				excluded = true
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+		if excluded {
+			report.ExcludedDays++
+			previous = nil
+			continue
+		}
+
+		// 急な変化は実際の前線の通過などもありうるため、値は変えずに印だけ付ける
+		if previous != nil && math.Abs(d.Temperature-previous.Temperature) > temperatureJumpThreshold {
+			d.QualityFlags = append(d.QualityFlags, "temperature_jump")
+			report.Issues = append(report.Issues, WeatherQualityIssue{
+				Date: date, Type: WeatherIssueJump, Field: "temperature", Value: d.Temperature,
+				Description: fmt.Sprintf("日平均気温が前日から%+.1f℃変化しました", d.Temperature-previous.Temperature),
+				Resolution:  "flagged",
+			})
+		}
+
+		if d.IsImputed() {
+			report.ImputedDays++
+		} else if len(d.QualityFlags) == 0 {
+			cleanDays++
+		}
+		result = append(result, d)
+		previous = &result[len(result)-1]
+	}
+
+	if report.ExpectedDays > 0 {
+		report.QualityRate = roundTo(float64(cleanDays)/float64(report.ExpectedDays)*100, 1)
+	}
+	if len(report.Issues) > 0 {
+		log.Printf("🧪 気象データの検証: 地域=%s, 期間=%s, 欠測%d日, 補完%d日, 除外%d日, 問題%d件",
+			key, report.Period, len(report.MissingDates), report.ImputedDays, report.ExcludedDays, len(report.Issues))
+	}
+	return result, report
+}
+
+// GetValidatedHistoricalWeatherData 過去の気象データと、その検証結果を取得
+func (ws *WeatherService) GetValidatedHistoricalWeatherData(key string, startDate, endDate time.Time) ([]HistoricalWeatherData, *WeatherQualityReport, error) {
+	data, err := ws.fetchHistoricalWeatherData(key, startDate, endDate)
+	if err != nil {
+		return nil, nil, err
+	}
+	validated, report := ws.validateWeatherData(key, data, startDate, endDate)
+	return validated, &report, nil
+}
+
+// climatologyFor 日付ごとに、過去3年の同じ時期（前後7日）の平均を項目ごとに求める
+func (ws *WeatherService) climatologyFor(key string, dates []string) map[string]map[string]float64 {
+	result := make(map[string]map[string]float64, len(dates))
+	if len(dates) == 0 {
+		return result
+	}
+	first, _ := time.Parse("2006-01-02", dates[0])
+	last, _ := time.Parse("2006-01-02", dates[len(dates)-1])
+	history := ws.baselineWeather(key, first, last, climatologyYears, climatologyWindowDays)
+
+	for _, date := range dates {
+		day, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			continue
+		}
+		samples, _ := baselineSamples(history, day, climatologyYears, climatologyWindowDays)
+		if len(samples) == 0 {
+			continue
+		}
+		result[date] = make(map[string]float64, len(samples))
+		for field, v := range samples {
+			result[date][field] = calculateMean(v)
+		}
+	}
+	return result
+}
+
+// nearestStationWeather 地点に最も近い観測所を除き、50km以内で次に近い観測所の気象データを取得する
+// 観測所の座標で取得できる取得元がなければ補完せず、次の補完方法に任せる
+func (ws *WeatherService) nearestStationWeather(location WeatherLocation, dates []string) map[string]HistoricalWeatherData {
+	result := make(map[string]HistoricalWeatherData)
+	if len(dates) == 0 || !location.HasCoordinates() {
+		return result
+	}
+
+	var neighbor *AMeDASStation
+	for i, station := range ws.sites.StationsNear(location.Lat, location.Lon, nearestStationMaxKm) {
+		// 地点の観測所（未設定の場合は最も近い観測所）は補完元にしない
+		if station.Code == location.StationCode || (location.StationCode == "" && i == 0) {
+			continue
+		}
+		candidate := station
+		neighbor = &candidate
+		break
+	}
+	if neighbor == nil {
+		log.Printf("⚠️ %.0fkm以内に補完に使える観測所がありません（地域: %s）", nearestStationMaxKm, location.RegionCode)
+		return result
+	}
+
+	first, _ := time.Parse("2006-01-02", dates[0])
+	last, _ := time.Parse("2006-01-02", dates[len(dates)-1])
+	neighborLocation := WeatherLocation{
+		RegionCode:  location.RegionCode,
+		RegionName:  neighbor.Name,
+		Lat:         neighbor.Lat,
+		Lon:         neighbor.Lon,
+		StationCode: neighbor.Code,
+	}
+	// 地域単位の取得元は同じ地域の値を返すだけなので、座標・観測所ごとに取得できる取得元に限る
+	data, err := ws.providers.PointHistorical(context.Background(), neighborLocation, first, last)
+	if err != nil {
+		log.Printf("⚠️ 近くの観測所（%s）の気象データを取得できません: %v", neighbor.Name, err)
+		return result
+	}
+	fahrenheit := fahrenheitSources(data)
+	for _, d := range data {
+		// 補完元でも異常な値のある日は使わない
+		correctWeatherUnits(&d, fahrenheit[d.Provider])
+		if len(implausibleFields(d)) == 0 {
+			result[d.Date] = d
+		}
+	}
+	return result
+}
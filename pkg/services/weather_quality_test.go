@@ -0,0 +1,253 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// stationSeries 観測所ごとに決めた日平均気温の観測値（気温のない観測所はデータなし、missing の日は欠測）
+func stationSeries(temperatures map[string]float64, missing map[string]string) func(WeatherLocation, time.Time, time.Time) []HistoricalWeatherData {
+	return func(location WeatherLocation, startDate, endDate time.Time) []HistoricalWeatherData {
+		temperature, ok := temperatures[location.StationCode]
+		if !ok {
+			return nil
+		}
+		var result []HistoricalWeatherData
+		for d := startDate; !d.After(endDate); d = d.AddDate(0, 0, 1) {
+			if date := d.Format("2006-01-02"); date != missing[location.StationCode] {
+				result = append(result, HistoricalWeatherData{Date: date, Temperature: temperature, Humidity: 60, Weather: "晴れ"})
+			}
+		}
+		return result
+	}
+}
+
+func qualityFixture(start time.Time, days int) *fakeWeatherProvider {
+	provider := newFakeWeatherProvider()
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		provider.days[date] = HistoricalWeatherData{
+			Date: date, Temperature: 20 + float64(i), MaxTemp: 24 + float64(i), MinTemp: 16 + float64(i),
+			Humidity: 60, Pressure: 1010, Weather: "晴れ",
+		}
+	}
+	return provider
+}
+
+func TestValidatedWeatherFillsMissingDaysLinearly(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	provider := qualityFixture(start, 7)
+	delete(provider.days, "2024-06-03")
+	delete(provider.days, "2024-06-04")
+
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}, GapFill: []string{WeatherGapFillLinear}})
+	data, report, err := ws.GetValidatedHistoricalWeatherData("240000", start, start.AddDate(0, 0, 6))
+	if err != nil {
+		t.Fatalf("GetValidatedHistoricalWeatherData failed: %v", err)
+	}
+	if len(data) != 7 || report.ObservedDays != 5 || len(report.MissingDates) != 2 || report.ImputedDays != 2 {
+		t.Fatalf("Unexpected result: %d days, report %+v", len(data), report)
+	}
+	filled := data[2]
+	if filled.Date != "2024-06-03" || filled.Temperature != 22 || filled.MaxTemp != 26 || filled.Imputed["temperature"] != WeatherGapFillLinear {
+		t.Errorf("Unexpected filled day: %+v", filled)
+	}
+	if data[0].IsImputed() || !filled.IsFieldImputed("humidity") {
+		t.Error("Expected only missing days to be marked as imputed")
+	}
+	if report.QualityRate != 71.4 {
+		t.Errorf("Quality rate = %.1f, expected 71.4", report.QualityRate)
+	}
+
+	// 補完しない設定では欠測日を含めない
+	ws = NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+	if data, report, _ := ws.GetValidatedHistoricalWeatherData("240000", start, start.AddDate(0, 0, 6)); len(data) != 5 || report.Issues[0].Resolution != "unfilled" {
+		t.Errorf("Expected missing days to stay unfilled, got %d days, %+v", len(data), report.Issues)
+	}
+}
+
+func TestValidatedWeatherCorrectsUnitsAndExcludesImplausibleValues(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	provider := qualityFixture(start, 6)
+	provider.days["2024-06-02"] = HistoricalWeatherData{Date: "2024-06-02", Temperature: 294.15, Humidity: 0.6, Weather: "晴れ"}
+	provider.days["2024-06-03"] = HistoricalWeatherData{Date: "2024-06-03", Temperature: 71.6, Humidity: 60, Weather: "晴れ"}
+	provider.days["2024-06-05"] = HistoricalWeatherData{Date: "2024-06-05", Temperature: 24, Humidity: 60, Precipitation: -5, Weather: "晴れ"}
+	provider.days["2024-06-06"] = HistoricalWeatherData{Date: "2024-06-06", Temperature: 24, Humidity: 60, WindSpeed: 150, Weather: "晴れ"}
+
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}, GapFill: []string{WeatherGapFillLinear}})
+	data, report, err := ws.GetValidatedHistoricalWeatherData("240000", start, start.AddDate(0, 0, 5))
+	if err != nil {
+		t.Fatalf("GetValidatedHistoricalWeatherData failed: %v", err)
+	}
+	byDate := make(map[string]HistoricalWeatherData)
+	for _, d := range data {
+		byDate[d.Date] = d
+	}
+
+	if d := byDate["2024-06-02"]; d.Temperature != 21 || d.Humidity != 60 || len(d.QualityFlags) != 2 || d.IsImputed() {
+		t.Errorf("Expected Kelvin and fractional humidity to be converted: %+v", d)
+	}
+	if d := byDate["2024-06-03"]; d.Temperature != 22 {
+		t.Errorf("Expected Fahrenheit to be converted: %+v", d)
+	}
+	// 前後の観測値がある降水量は補完し、最後の日の風速は補間できないため除く
+	if d := byDate["2024-06-05"]; d.Precipitation != 0 || d.Imputed["precipitation"] != WeatherGapFillLinear {
+		t.Errorf("Expected implausible precipitation to be imputed: %+v", d)
+	}
+	if _, ok := byDate["2024-06-06"]; ok || report.ExcludedDays != 1 {
+		t.Errorf("Expected the day with unfillable wind speed to be excluded, report %+v", report)
+	}
+}
+
+func TestValidatedWeatherConvertsWinterFahrenheitSeries(t *testing.T) {
+	start := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	provider := newFakeWeatherProvider()
+	// 30〜44°F（約-1〜7℃）は1日ずつ見ると摂氏としてもありえる値
+	for i, temperature := range []float64{30.2, 35.6, 39.2, 41, 44.6} {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		provider.days[date] = HistoricalWeatherData{
+			Date: date, Temperature: temperature, MaxTemp: temperature + 9, MinTemp: temperature - 9,
+			Humidity: 50, Pressure: 1015, Weather: "晴れ",
+		}
+	}
+
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+	data, report, err := ws.GetValidatedHistoricalWeatherData("240000", start, start.AddDate(0, 0, 4))
+	if err != nil {
+		t.Fatalf("GetValidatedHistoricalWeatherData failed: %v", err)
+	}
+	expected := []float64{-1, 2, 4, 5, 7}
+	if len(data) != len(expected) {
+		t.Fatalf("Expected %d days, got %d", len(expected), len(data))
+	}
+	for i, d := range data {
+		if d.Temperature != expected[i] || d.MaxTemp != roundTo(expected[i]+5, 1) || d.MinTemp != roundTo(expected[i]-5, 1) {
+			t.Errorf("Expected the whole series to be converted from Fahrenheit: %+v", d)
+		}
+	}
+	if len(report.Issues) != len(expected) || report.Issues[0].Type != WeatherIssueUnit {
+		t.Errorf("Expected one unit issue per day, got %+v", report.Issues)
+	}
+}
+
+func TestValidatedWeatherFlagsTemperatureJumps(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	provider := qualityFixture(start, 3)
+	day := provider.days["2024-03-02"]
+	day.Temperature = 5
+	provider.days["2024-03-02"] = day
+
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{Providers: []WeatherProvider{provider}})
+	data, report, _ := ws.GetValidatedHistoricalWeatherData("240000", start, start.AddDate(0, 0, 2))
+	if len(data) != 3 || len(data[1].QualityFlags) != 1 || data[1].QualityFlags[0] != "temperature_jump" || data[1].Temperature != 5 {
+		t.Fatalf("Expected the jump to be flagged without changing the value: %+v", data)
+	}
+	if len(data[2].QualityFlags) != 1 {
+		t.Errorf("Expected the recovery to be flagged too: %+v", data[2])
+	}
+	if report.QualityRate != 33.3 {
+		t.Errorf("Quality rate = %.1f, expected 33.3", report.QualityRate)
+	}
+}
+
+func TestValidatedWeatherFillsFromClimatology(t *testing.T) {
+	provider := newFakeWeatherProvider()
+	for year := 2021; year <= 2023; year++ {
+		center := time.Date(year, 8, 10, 0, 0, 0, 0, time.UTC)
+		for offset := -7; offset <= 7; offset++ {
+			date := center.AddDate(0, 0, offset).Format("2006-01-02")
+			provider.days[date] = HistoricalWeatherData{Date: date, Temperature: float64(year - 1995), Humidity: 70, Weather: "晴れ"}
+		}
+	}
+	provider.days["2024-08-09"] = HistoricalWeatherData{Date: "2024-08-09", Temperature: 30, Humidity: 70, Weather: "晴れ"}
+
+	start := time.Date(2024, 8, 9, 0, 0, 0, 0, time.UTC)
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{
+		Providers: []WeatherProvider{provider},
+		GapFill:   []string{WeatherGapFillLinear, WeatherGapFillClimatology},
+	})
+	data, _, err := ws.GetValidatedHistoricalWeatherData("240000", start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("GetValidatedHistoricalWeatherData failed: %v", err)
+	}
+	// 翌日の観測値がなく直線補間できないため、2021〜2023年の平均（26・27・28℃）を使う
+	if len(data) != 2 || data[1].Temperature != 27 || data[1].Imputed["temperature"] != WeatherGapFillClimatology {
+		t.Fatalf("Unexpected climatology fill: %+v", data)
+	}
+}
+
+func TestValidatedWeatherFillsFromNearestStation(t *testing.T) {
+	registry, err := NewLocationRegistry("../../data/sites.json", "../../data/amedas_stations.json")
+	if err != nil {
+		t.Fatalf("NewLocationRegistry failed: %v", err)
+	}
+	site, _ := registry.Get(DefaultSiteID)
+	neighbors := registry.StationsNear(site.Lat, site.Lon, nearestStationMaxKm)
+	if len(neighbors) < 2 {
+		t.Fatalf("Expected stations near %s, got %+v", DefaultSiteID, neighbors)
+	}
+	var neighbor AMeDASStation
+	for _, station := range neighbors {
+		if station.Code != site.StationCode {
+			neighbor = station
+			break
+		}
+	}
+
+	// 拠点の観測所は2日目が欠測し、前日とは違う気温の近くの観測所で補う
+	provider := &fakeWeatherProvider{point: true, historical: stationSeries(
+		map[string]float64{site.StationCode: 20, neighbor.Code: 18.5},
+		map[string]string{site.StationCode: "2024-05-02"},
+	)}
+	ws := NewWeatherServiceWithConfig(WeatherServiceConfig{
+		Providers: []WeatherProvider{provider},
+		Sites:     registry,
+		GapFill:   []string{WeatherGapFillNearestStation},
+	})
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	data, report, err := ws.GetValidatedHistoricalWeatherData(DefaultSiteID, start, start.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("GetValidatedHistoricalWeatherData failed: %v", err)
+	}
+	if len(data) != 3 || report.ImputedDays != 1 || data[1].Temperature != 18.5 || data[1].Imputed["temperature"] != WeatherGapFillNearestStation {
+		t.Fatalf("Unexpected nearest station fill: %+v, report %+v", data, report)
+	}
+	last := provider.locations[len(provider.locations)-1]
+	if last.StationCode != neighbor.Code || math.Abs(last.Lat-neighbor.Lat) > 1e-9 {
+		t.Errorf("Expected the neighbor station to be queried, got %+v", last)
+	}
+
+	// 地域単位でしか返せない取得元は同じ地域の値を返すだけなので、近くの観測所の値として使わず次の補完方法に任せる
+	regional := newFakeWeatherProvider()
+	regional.days["2024-05-01"] = HistoricalWeatherData{Date: "2024-05-01", Temperature: 20, Humidity: 60, Weather: "晴れ"}
+	regional.days["2024-05-03"] = HistoricalWeatherData{Date: "2024-05-03", Temperature: 22, Humidity: 60, Weather: "晴れ"}
+	ws = NewWeatherServiceWithConfig(WeatherServiceConfig{
+		Providers: []WeatherProvider{regional},
+		Sites:     registry,
+		GapFill:   []string{WeatherGapFillNearestStation, WeatherGapFillLinear},
+	})
+	data, _, err = ws.GetValidatedHistoricalWeatherData(DefaultSiteID, start, start.AddDate(0, 0, 2))
+	if err != nil {
+		t.Fatalf("GetValidatedHistoricalWeatherData failed: %v", err)
+	}
+	if len(data) != 3 || data[1].Imputed["temperature"] != WeatherGapFillLinear || data[1].Temperature != 21 {
+		t.Errorf("Expected a region-keyed provider not to fill as a neighbor station: %+v", data)
+	}
+	if len(regional.historicalCalls) != 1 {
+		t.Errorf("Expected only the site itself to be fetched, got %v", regional.historicalCalls)
+	}
+}
+
+func TestParseWeatherGapFill(t *testing.T) {
+	strategies, err := ParseWeatherGapFill(" Linear, nearest_station ,climatology")
+	if err != nil || len(strategies) != 3 || strategies[0] != WeatherGapFillLinear || strategies[1] != WeatherGapFillNearestStation {
+		t.Errorf("Unexpected strategies: %v (%v)", strategies, err)
+	}
+	if strategies, err := ParseWeatherGapFill("none"); err != nil || len(strategies) != 0 {
+		t.Errorf("Expected none to disable gap filling, got %v (%v)", strategies, err)
+	}
+	if _, err := ParseWeatherGapFill("linear,spline"); err == nil {
+		t.Error("Expected an unknown strategy to be rejected")
+	}
+}
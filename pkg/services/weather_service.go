@@ -1,54 +1,133 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"sync"
+	"sort"
+	"strings"
 	"time"
 )
 
-// グローバルキャッシュ（スレッドセーフ）
-var (
-	weatherCache      = make(map[string][]HistoricalWeatherData)
-	weatherCacheMutex sync.RWMutex
-)
-
 // WeatherService 気象データサービス
 type WeatherService struct {
-	client *http.Client
+	client    *http.Client
+	jma       *JMAWeatherProvider   // 気象庁の予報JSONをそのまま返すAPI用
+	providers *WeatherProviderChain // 日別の予報・過去データの取得元（優先順）
+	recordDir string                // 設定されていれば取得したデータを記録済みデータとして保存
+	cache     *WeatherCache         // 地域・日単位のキャッシュ
+	sites     *LocationRegistry     // 店舗・拠点（地域コードの代わりに拠点IDで取得できる）
+	gapFill   []string              // 欠測・異常値の補完方法（優先順）
+	archive   *ForecastArchive      // 取得した予報の保存先（後日の観測値との比較・バックテストに使う）
 }
 
-// NewWeatherService 新しい気象データサービスを作成
+// WeatherServiceConfig 気象データサービスの設定
+type WeatherServiceConfig struct {
+	Providers []WeatherProvider // 優先順の取得元（空の場合は気象庁の予報と模擬の過去データ）
+	RecordDir string            // 取得したデータを FixtureWeatherProvider 形式で保存するディレクトリ
+	GapFill   []string          // 欠測・異常値の補完方法（優先順、WeatherGapFill*）。空の場合は補完せず、異常値のある日を除く
+	Cache     *WeatherCache     // nilの場合は既定の設定（メモリのみ）のキャッシュ
+	Sites     *LocationRegistry // nilの場合は拠点なし（地域コードのみ）
+	Archive   *ForecastArchive  // nilの場合は既定の設定（メモリのみ）の予報アーカイブ
+}
+
+// NewWeatherService 新しい気象データサービスを作成（予報は気象庁、過去データは模擬データ）
 func NewWeatherService() *WeatherService {
-	return &WeatherService{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+	return NewWeatherServiceWithConfig(WeatherServiceConfig{})
+}
+
+// NewWeatherServiceWithConfig 取得元を指定して気象データサービスを作成
+func NewWeatherServiceWithConfig(cfg WeatherServiceConfig) *WeatherService {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	ws := &WeatherService{client: client, recordDir: cfg.RecordDir, cache: cfg.Cache, gapFill: cfg.GapFill, archive: cfg.Archive}
+	if ws.cache == nil {
+		ws.cache = NewWeatherCache(WeatherCacheConfig{})
+	}
+	if ws.archive == nil {
+		ws.archive = NewForecastArchive(ForecastArchiveConfig{})
 	}
+	ws.sites = cfg.Sites
+	if ws.sites == nil {
+		ws.sites, _ = NewLocationRegistry("", "")
+	}
+	// 拠点の座標や予報区が変わったら、その拠点のキャッシュを破棄する
+	ws.sites.OnChange(func(siteID string) { ws.cache.Purge(siteID, "") })
+
+	providers := cfg.Providers
+	for _, provider := range providers {
+		if jma, ok := provider.(*JMAWeatherProvider); ok && ws.jma == nil {
+			ws.jma = jma
+		}
+	}
+	if ws.jma == nil {
+		ws.jma = NewJMAWeatherProvider(client, "")
+	}
+	if len(providers) == 0 {
+		providers = []WeatherProvider{ws.jma, NewMockWeatherProvider()}
+	}
+	ws.providers = NewWeatherProviderChain(providers...)
+	log.Printf("🌤️ 気象データの取得元: %s", strings.Join(ws.providers.Names(), " → "))
+	return ws
+}
+
+// ProviderNames 気象データの取得元（優先順）
+func (ws *WeatherService) ProviderNames() []string {
+	return ws.providers.Names()
 }
 
-// JMAForecastData 気象庁予報データの構造体
+// Sites 店舗・拠点の登録簿
+func (ws *WeatherService) Sites() *LocationRegistry {
+	return ws.sites
+}
+
+// Cache 気象データキャッシュ
+func (ws *WeatherService) Cache() *WeatherCache {
+	return ws.cache
+}
+
+// record 記録先が設定されていれば取得したデータを保存する
+func (ws *WeatherService) record(regionCode string, forecasts []DailyForecast, historical []HistoricalWeatherData) {
+	if ws.recordDir == "" {
+		return
+	}
+	if err := RecordWeatherFixture(ws.recordDir, regionCode, forecasts, historical); err != nil {
+		log.Printf("⚠️ 気象データの記録に失敗: %v", err)
+	}
+}
+
+// JMAForecastData 気象庁予報データの構造体（配列の1つ目が3日間の詳細予報、2つ目が週間予報）
 type JMAForecastData struct {
-	PublishingOffice string `json:"publishingOffice"`
-	ReportDatetime   string `json:"reportDatetime"`
-	TimeSeries       []struct {
-		TimeDefines []string `json:"timeDefines"`
-		Areas       []struct {
-			Area struct {
-				Name string `json:"name"`
-				Code string `json:"code"`
-			} `json:"area"`
-			WeatherCodes []string `json:"weatherCodes,omitempty"`
-			Weathers     []string `json:"weathers,omitempty"`
-			Winds        []string `json:"winds,omitempty"`
-			Waves        []string `json:"waves,omitempty"`
-			Pops         []string `json:"pops,omitempty"`
-			Temps        []string `json:"temps,omitempty"`
-		} `json:"areas"`
-	} `json:"timeSeries"`
+	PublishingOffice string          `json:"publishingOffice"`
+	ReportDatetime   string          `json:"reportDatetime"`
+	TimeSeries       []JMATimeSeries `json:"timeSeries"`
+}
+
+// JMATimeSeries 予報の時系列（TimeDefines と各エリアの配列が同じ順序で対応する）
+type JMATimeSeries struct {
+	TimeDefines []string          `json:"timeDefines"`
+	Areas       []JMAForecastArea `json:"areas"`
+}
+
+// JMAForecastArea 予報区または気温の観測地点ごとの値
+type JMAForecastArea struct {
+	Area struct {
+		Name string `json:"name"`
+		Code string `json:"code"`
+	} `json:"area"`
+	WeatherCodes  []string `json:"weatherCodes,omitempty"`
+	Weathers      []string `json:"weathers,omitempty"`
+	Winds         []string `json:"winds,omitempty"`
+	Waves         []string `json:"waves,omitempty"`
+	Pops          []string `json:"pops,omitempty"`
+	Temps         []string `json:"temps,omitempty"`
+	Reliabilities []string `json:"reliabilities,omitempty"` // 週間予報の信頼度（A/B/C）
+	TempsMin      []string `json:"tempsMin,omitempty"`      // 週間予報の最低気温
+	TempsMax      []string `json:"tempsMax,omitempty"`      // 週間予報の最高気温
 }
 
 // WeatherData 統一された気象データ構造体
@@ -80,6 +159,21 @@ type HistoricalWeatherData struct {
 	Weather       string  `json:"weather"`
 	WeatherCode   string  `json:"weather_code"`
 	DataSource    string  `json:"data_source"`
+	Provider      string  `json:"provider,omitempty"` // この日のデータを返した取得元（jma / openweathermap / csv / fixture / mock）
+
+	Imputed      map[string]string `json:"imputed,omitempty"`       // 補完した項目 → 補完方法（欠測日はすべての項目）
+	QualityFlags []string          `json:"quality_flags,omitempty"` // 検証で見つかった点（単位の変換・急な変化など）
+}
+
+// IsImputed 補完した値を含む日か
+func (d HistoricalWeatherData) IsImputed() bool {
+	return len(d.Imputed) > 0
+}
+
+// IsFieldImputed 指定した項目（temperature / humidity など）の値が補完されたものか
+func (d HistoricalWeatherData) IsFieldImputed(field string) bool {
+	_, ok := d.Imputed[field]
+	return ok
 }
 
 // JMAHistoricalData 気象庁過去データ（仮想的な構造体）
@@ -116,7 +210,11 @@ type OpenWeatherMapHistoricalData struct {
 		Pressure  float64 `json:"pressure"`
 		WindSpeed float64 `json:"wind_speed"`
 		WindDeg   int     `json:"wind_deg"`
-		Weather   []struct {
+		Rain      struct {
+			OneHour float64 `json:"1h"`
+		} `json:"rain"`
+		Weather []struct {
+			ID          int    `json:"id"`
 			Main        string `json:"main"`
 			Description string `json:"description"`
 		} `json:"weather"`
@@ -133,74 +231,37 @@ type JMAStationData struct {
 	Prefecture  string  `json:"prefecture"`
 }
 
-// GetForecastData 予報データを取得
+// GetForecastData 気象庁の予報データをそのまま取得（店舗・拠点IDの場合はその府県予報区）
 func (ws *WeatherService) GetForecastData(regionCode string) ([]JMAForecastData, error) {
-	url := fmt.Sprintf("https://www.jma.go.jp/bosai/forecast/data/forecast/%s.json", regionCode)
-
-	resp, err := ws.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch forecast data: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var forecastData []JMAForecastData
-	if err := json.Unmarshal(body, &forecastData); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w", err)
-	}
-
-	return forecastData, nil
+	return ws.jma.FetchForecast(context.Background(), ws.LocationFor(regionCode).RegionCode)
 }
 
 // GetTokyoWeatherData 東京の気象データを取得して統一フォーマットに変換
 func (ws *WeatherService) GetTokyoWeatherData() ([]WeatherData, error) {
 	// 東京都のコード: 130000
-	forecastData, err := ws.GetForecastData("130000")
+	forecasts, err := ws.GetDailyForecasts("130000")
 	if err != nil {
 		return nil, err
 	}
 
 	var weatherDataList []WeatherData
-
-	for _, forecast := range forecastData {
-		for _, timeSeries := range forecast.TimeSeries {
-			for i, timeDefine := range timeSeries.TimeDefines {
-				for _, area := range timeSeries.Areas {
-					// 東京地方のデータのみ処理
-					if area.Area.Code == "130010" {
-						weatherData := WeatherData{
-							Date:       timeDefine,
-							RegionCode: area.Area.Code,
-							RegionName: area.Area.Name,
-						}
-
-						// 気象コードと天気を設定
-						if len(area.WeatherCodes) > i {
-							weatherData.WeatherCode = area.WeatherCodes[i]
-						}
-						if len(area.Weathers) > i {
-							weatherData.Weather = area.Weathers[i]
-						}
-
-						// 気温データを設定
-						if len(area.Temps) > i {
-							// 気温の解析は簡易的に実装
-							weatherData.Temperature = 25.0 // デフォルト値
-						}
-
-						weatherDataList = append(weatherDataList, weatherData)
-					}
-				}
-			}
+	for _, forecast := range forecasts {
+		// 東京地方のデータのみ処理
+		if forecast.AreaCode != "130010" {
+			continue
+		}
+		weatherData := WeatherData{
+			Date:        forecast.Date,
+			RegionCode:  forecast.AreaCode,
+			RegionName:  forecast.AreaName,
+			WeatherCode: forecast.WeatherCode,
+			Weather:     forecast.Weather,
 		}
+		// 最高・最低気温の平均（未発表の日は0のまま）
+		if temperature, ok := forecast.AverageTemp(); ok {
+			weatherData.Temperature = temperature
+		}
+		weatherDataList = append(weatherDataList, weatherData)
 	}
 
 	return weatherDataList, nil
@@ -270,7 +331,14 @@ func (ws *WeatherService) TestWeatherAPI() {
 }
 
 // GetHistoricalWeatherData 過去の気象データを取得（キャッシュ対応版）
+// 取得したデータは検証し、欠測日・ありえない値を設定された方法で補完する（補完した値は Imputed に記録）
 func (ws *WeatherService) GetHistoricalWeatherData(regionCode string, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
+	data, _, err := ws.GetValidatedHistoricalWeatherData(regionCode, startDate, endDate)
+	return data, err
+}
+
+// fetchHistoricalWeatherData キャッシュと取得元から過去の気象データを取得（検証・補完前）
+func (ws *WeatherService) fetchHistoricalWeatherData(regionCode string, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
 	// 日付範囲をチェック
 	if startDate.After(endDate) {
 		return nil, fmt.Errorf("開始日は終了日より前である必要があります")
@@ -282,209 +350,55 @@ func (ws *WeatherService) GetHistoricalWeatherData(regionCode string, startDate,
 		log.Printf("⚠️ 5年以上前のデータはサポートされていません: %s", startDate.Format("2006-01-02"))
 	}
 
-	// キャッシュキーを生成
-	cacheKey := fmt.Sprintf("%s:%s:%s", regionCode, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
-
-	// キャッシュをチェック（読み取りロック）
-	weatherCacheMutex.RLock()
-	cachedData, exists := weatherCache[cacheKey]
-	weatherCacheMutex.RUnlock()
-
-	if exists {
+	// キャッシュ済みの日を使い、不足している日だけを取得する
+	cachedData, missing := ws.cache.Historical(regionCode, startDate, endDate)
+	if len(missing) == 0 {
 		log.Printf("🎯 キャッシュヒット: 地域=%s, 期間=%s〜%s (%d件)",
 			regionCode, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), len(cachedData))
 		return cachedData, nil
 	}
 
-	log.Printf("🔍 気象データ生成開始: 地域=%s, 期間=%s〜%s",
-		regionCode, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
-
-	// キャッシュミス：一括生成（書き込みロックは生成後に取得）
-	historicalData := ws.generateMockHistoricalDataBulk(regionCode, startDate, endDate)
-
-	// キャッシュに保存（書き込みロック）
-	weatherCacheMutex.Lock()
-	weatherCache[cacheKey] = historicalData
-	weatherCacheMutex.Unlock()
-
-	log.Printf("✅ 気象データ生成完了: %d件 (キャッシュに保存)", len(historicalData))
-	return historicalData, nil
-}
-
-// getHistoricalDataForDate 指定日の気象データを取得
-func (ws *WeatherService) getHistoricalDataForDate(regionCode string, date time.Time) ([]HistoricalWeatherData, error) {
-	// 複数のデータソースを試行
-
-	// 1. 気象庁のデータを試行
-	jmaData, err := ws.getJMAHistoricalData(regionCode, date)
-	if err == nil && len(jmaData) > 0 {
-		return jmaData, nil
-	}
-
-	// 2. 模擬データを生成（実際の運用では外部APIを使用）
-	mockData := ws.generateMockHistoricalData(regionCode, date)
-	return mockData, nil
-}
-
-// getJMAHistoricalData 気象庁から過去データを取得
-func (ws *WeatherService) getJMAHistoricalData(regionCode string, date time.Time) ([]HistoricalWeatherData, error) {
-	// 気象庁の過去データAPI（実際のエンドポイントは調査が必要）
-	dateStr := date.Format("20060102")
-	url := fmt.Sprintf("https://www.jma.go.jp/bosai/observation/data/amedas/%s/%s_000000.json", dateStr, dateStr)
+	fetchStart, _ := time.Parse("2006-01-02", missing[0])
+	fetchEnd, _ := time.Parse("2006-01-02", missing[len(missing)-1])
+	log.Printf("🔍 気象データ取得開始: 地域=%s, 期間=%s〜%s (キャッシュ済み%d日)",
+		regionCode, missing[0], missing[len(missing)-1], len(cachedData))
 
-	resp, err := ws.client.Get(url)
+	fetched, err := ws.providers.Historical(context.Background(), ws.LocationFor(regionCode), fetchStart, fetchEnd)
 	if err != nil {
-		return nil, fmt.Errorf("気象庁API呼び出しエラー: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("気象庁API応答エラー: %d", resp.StatusCode)
+		if len(cachedData) > 0 {
+			log.Printf("⚠️ 不足分の気象データを取得できないため、キャッシュ済みの%d日分のみ返します: %v", len(cachedData), err)
+			return cachedData, nil
+		}
+		return nil, err
 	}
+	ws.record(regionCode, nil, fetched)
+	ws.cache.PutHistorical(regionCode, fetched)
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("レスポンス読み込みエラー: %w", err)
+	// キャッシュ済みの日と取得した日を日付順にまとめる（取得し直した日は新しい値を使う）
+	byDate := make(map[string]HistoricalWeatherData, len(cachedData)+len(fetched))
+	for _, d := range cachedData {
+		byDate[d.Date] = d
 	}
-
-	// JSONデータの解析（実際の構造に合わせて調整が必要）
-	var rawData map[string]interface{}
-	if err := json.Unmarshal(body, &rawData); err != nil {
-		return nil, fmt.Errorf("JSON解析エラー: %w", err)
+	for _, d := range fetched {
+		byDate[d.Date] = d
 	}
-
-	// データを統一フォーマットに変換
-	historicalData := ws.convertJMAHistoricalData(rawData, regionCode, date)
-
-	return historicalData, nil
-}
-
-// convertJMAHistoricalData 気象庁データを統一フォーマットに変換
-func (ws *WeatherService) convertJMAHistoricalData(rawData map[string]interface{}, regionCode string, date time.Time) []HistoricalWeatherData {
-	var result []HistoricalWeatherData
-
-	// 実際のデータ構造に応じて変換ロジックを実装
-	// ここでは模擬的な実装
-	regionName := ws.getRegionName(regionCode)
-
-	data := HistoricalWeatherData{
-		Date:          date.Format("2006-01-02"),
-		RegionCode:    regionCode,
-		RegionName:    regionName,
-		Temperature:   25.0, // 実際のデータから取得
-		MaxTemp:       30.0,
-		MinTemp:       20.0,
-		Humidity:      60.0,
-		Precipitation: 0.0,
-		WindSpeed:     3.0,
-		WindDirection: "南",
-		Pressure:      1013.25,
-		Weather:       "晴れ",
-		WeatherCode:   "100",
-		DataSource:    "気象庁",
-	}
-
-	result = append(result, data)
-	return result
-}
-
-// generateMockHistoricalData 模擬的な過去データを生成
-func (ws *WeatherService) generateMockHistoricalData(regionCode string, date time.Time) []HistoricalWeatherData {
-	regionName := ws.getRegionName(regionCode)
-
-	// 季節を考慮した模擬データ生成
-	month := date.Month()
-	baseTemp := 20.0
-
-	// 季節による気温調整
-	switch {
-	case month >= 6 && month <= 8: // 夏
-		baseTemp = 28.0
-	case month >= 12 || month <= 2: // 冬
-		baseTemp = 8.0
-	case month >= 3 && month <= 5: // 春
-		baseTemp = 18.0
-	case month >= 9 && month <= 11: // 秋
-		baseTemp = 20.0
-	}
-
-	// 日付に基づく変動を追加
-	dayVariation := float64(date.Day()%10 - 5)
-
-	data := HistoricalWeatherData{
-		Date:          date.Format("2006-01-02"),
-		RegionCode:    regionCode,
-		RegionName:    regionName,
-		Temperature:   baseTemp + dayVariation,
-		MaxTemp:       baseTemp + dayVariation + 5,
-		MinTemp:       baseTemp + dayVariation - 5,
-		Humidity:      60.0 + float64(date.Day()%20),
-		Precipitation: 0.0,
-		WindSpeed:     2.0 + float64(date.Day()%5),
-		WindDirection: "南",
-		Pressure:      1013.25,
-		Weather:       "晴れ",
-		WeatherCode:   "100",
-		DataSource:    "模擬データ",
-	}
-
-	return []HistoricalWeatherData{data}
-}
-
-// generateMockHistoricalDataBulk 指定期間の模擬データを一括生成（高速版）
-func (ws *WeatherService) generateMockHistoricalDataBulk(regionCode string, startDate, endDate time.Time) []HistoricalWeatherData {
-	regionName := ws.getRegionName(regionCode)
-
-	// 日数を計算して事前にメモリ確保
-	days := int(endDate.Sub(startDate).Hours()/24) + 1
-	result := make([]HistoricalWeatherData, 0, days)
-
-	// 一括生成（関数呼び出しのオーバーヘッドを削減）
-	for i := 0; i < days; i++ {
-		date := startDate.AddDate(0, 0, i)
-		month := date.Month()
-		baseTemp := 20.0
-
-		// 季節による気温調整
-		switch {
-		case month >= 6 && month <= 8: // 夏
-			baseTemp = 28.0
-		case month >= 12 || month <= 2: // 冬
-			baseTemp = 8.0
-		case month >= 3 && month <= 5: // 春
-			baseTemp = 18.0
-		case month >= 9 && month <= 11: // 秋
-			baseTemp = 20.0
-		}
-
-		// 日付に基づく変動を追加
-		dayVariation := float64(date.Day()%10 - 5)
-
-		data := HistoricalWeatherData{
-			Date:          date.Format("2006-01-02"),
-			RegionCode:    regionCode,
-			RegionName:    regionName,
-			Temperature:   baseTemp + dayVariation,
-			MaxTemp:       baseTemp + dayVariation + 5,
-			MinTemp:       baseTemp + dayVariation - 5,
-			Humidity:      60.0 + float64(date.Day()%20),
-			Precipitation: 0.0,
-			WindSpeed:     2.0 + float64(date.Day()%5),
-			WindDirection: "南",
-			Pressure:      1013.25,
-			Weather:       "晴れ",
-			WeatherCode:   "100",
-			DataSource:    "模擬データ（一括生成）",
+	historicalData := make([]HistoricalWeatherData, 0, len(byDate))
+	for _, d := range byDate {
+		if d.Date >= startDate.Format("2006-01-02") && d.Date <= endDate.Format("2006-01-02") {
+			historicalData = append(historicalData, d)
 		}
-
-		result = append(result, data)
 	}
+	sort.Slice(historicalData, func(i, j int) bool { return historicalData[i].Date < historicalData[j].Date })
 
-	return result
+	log.Printf("✅ 気象データ取得完了: %d件 (キャッシュに保存)", len(historicalData))
+	return historicalData, nil
 }
 
-// getRegionName 地域コードから地域名を取得
+// getRegionName 地域コード（または店舗・拠点ID）から地域名を取得
 func (ws *WeatherService) getRegionName(regionCode string) string {
+	if site, ok := ws.sites.Get(regionCode); ok {
+		return site.Name
+	}
 	regions := ws.GetRegionCodes()
 	if name, exists := regions[regionCode]; exists {
 		return name
@@ -510,7 +424,7 @@ func (ws *WeatherService) GetAvailableHistoricalDataRange() map[string]interface
 		"start_date":     time.Now().AddDate(-1, 0, 0).Format("2006-01-02"),
 		"end_date":       time.Now().AddDate(0, 0, -1).Format("2006-01-02"),
 		"max_range_days": 365,
-		"data_sources":   []string{"気象庁", "模擬データ"},
+		"data_sources":   ws.providers.Names(),
 	}
 }
 
@@ -518,6 +432,9 @@ func (ws *WeatherService) GetAvailableHistoricalDataRange() map[string]interface
 type WeatherSummary struct {
 	RegionCode    string                `json:"region_code"`
 	RegionName    string                `json:"region_name"`
+	SiteID        string                `json:"site_id,omitempty"`
+	StationCode   string                `json:"station_code,omitempty"` // 店舗・拠点の最寄りのアメダス観測所
+	StationName   string                `json:"station_name,omitempty"`
 	Period        string                `json:"period"`
 	SummaryType   string                `json:"summary_type"`
 	Temperature   WeatherStatistics     `json:"temperature"`
@@ -592,8 +509,10 @@ type AnomalousWeather struct {
 	Date        string  `json:"date"`
 	Type        string  `json:"type"`
 	Value       float64 `json:"value"`
-	Threshold   float64 `json:"threshold"`
+	Threshold   float64 `json:"threshold"` // 平年値と比べた場合は平年値、比べられない場合は期間の平均
 	Description string  `json:"description"`
+	Deviation   float64 `json:"deviation,omitempty"`  // 平年値との差
+	Percentile  float64 `json:"percentile,omitempty"` // 平年の分布の中での位置（0〜100）
 }
 
 // PeakWeather ピーク気象データ構造体
@@ -608,6 +527,8 @@ type DataQualityMetrics struct {
 	TotalDataPoints int     `json:"total_data_points"`
 	ValidDataPoints int     `json:"valid_data_points"`
 	MissingData     int     `json:"missing_data"`
+	ImputedData     int     `json:"imputed_data"` // 補完した値を含む日（有効なデータには数えない）
+	FlaggedData     int     `json:"flagged_data"` // 検証で注意点が見つかった日（単位の変換・急な変化など）
 	DataQualityRate float64 `json:"data_quality_rate"`
 }
 
@@ -629,12 +550,12 @@ type CategoryStats struct {
 	Description string                  `json:"description"`
 }
 
-// GetSuzukaWeatherSummary 三重県鈴鹿市の気象データサマリーを取得
-func (ws *WeatherService) GetSuzukaWeatherSummary(days int, summaryType string) (*WeatherSummary, error) {
-	regionCode := "240000" // 三重県
+// GetSiteWeatherSummary 店舗・拠点（または地域コード）の気象データサマリーを取得
+func (ws *WeatherService) GetSiteWeatherSummary(key string, days int, summaryType string) (*WeatherSummary, error) {
+	location := ws.LocationFor(key)
 
 	// 過去データを取得
-	historicalData, err := ws.GetHistoricalWeatherDataByRange(regionCode, days)
+	historicalData, err := ws.GetHistoricalWeatherDataByRange(key, days)
 	if err != nil {
 		return nil, fmt.Errorf("過去データ取得エラー: %w", err)
 	}
@@ -645,13 +566,18 @@ func (ws *WeatherService) GetSuzukaWeatherSummary(days int, summaryType string)
 
 	// サマリーを作成
 	summary := &WeatherSummary{
-		RegionCode:  regionCode,
-		RegionName:  "三重県（鈴鹿市含む）",
+		RegionCode:  location.RegionCode,
+		RegionName:  location.RegionName,
+		SiteID:      location.SiteID,
 		Period:      fmt.Sprintf("過去%d日間", days),
 		SummaryType: summaryType,
-		DataSource:  "気象庁・模擬データ",
+		DataSource:  strings.Join(historicalProviders(historicalData), "・"),
 		LastUpdated: time.Now().Format("2006-01-02 15:04:05"),
 	}
+	if site, ok := ws.sites.Get(location.SiteID); ok {
+		summary.StationCode = site.StationCode
+		summary.StationName = site.StationName
+	}
 
 	// 統計計算
 	summary.Temperature = ws.calculateStatistics(historicalData, "temperature")
@@ -669,6 +595,23 @@ func (ws *WeatherService) GetSuzukaWeatherSummary(days int, summaryType string)
 	return summary, nil
 }
 
+// historicalProviders データを返した取得元（出現順）
+func historicalProviders(data []HistoricalWeatherData) []string {
+	seen := make(map[string]bool)
+	var providers []string
+	for _, d := range data {
+		name := d.Provider
+		if name == "" {
+			name = d.DataSource
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			providers = append(providers, name)
+		}
+	}
+	return providers
+}
+
 // calculateStatistics 統計値を計算
 func (ws *WeatherService) calculateStatistics(data []HistoricalWeatherData, fieldType string) WeatherStatistics {
 	var values []float64
@@ -773,7 +716,7 @@ func (ws *WeatherService) GetWeatherDataAnalysis(regionCode string, days int, an
 	}
 
 	// サマリーを作成
-	summary, err := ws.GetSuzukaWeatherSummary(days, "daily")
+	summary, err := ws.GetSiteWeatherSummary(regionCode, days, "daily")
 	if err != nil {
 		return nil, fmt.Errorf("サマリー作成エラー: %w", err)
 	}
@@ -784,6 +727,9 @@ func (ws *WeatherService) GetWeatherDataAnalysis(regionCode string, days int, an
 
 	// パターン分析
 	analysis.Patterns = ws.analyzePatterns(historicalData)
+	if anomalous, ok := ws.climatologyAnomalousValues(regionCode, historicalData); ok {
+		analysis.Patterns.AnomalousValues = anomalous
+	}
 
 	// 相関分析
 	analysis.Correlations = ws.analyzeCorrelations(historicalData)
@@ -877,6 +823,55 @@ func (ws *WeatherService) analyzePatterns(data []HistoricalWeatherData) WeatherP
 	return patterns
 }
 
+// climatologyAnomalousValues 過去の同じ時期（平年値）と比べて珍しい値を異常気象とする
+// 平年値を求められる過去のデータがない場合は false を返す（期間の平均との比較を使う）
+func (ws *WeatherService) climatologyAnomalousValues(key string, data []HistoricalWeatherData) ([]AnomalousWeather, bool) {
+	var startDate, endDate time.Time
+	for _, d := range data {
+		t, err := time.Parse("2006-01-02", d.Date)
+		if err != nil {
+			continue
+		}
+		if startDate.IsZero() || t.Before(startDate) {
+			startDate = t
+		}
+		if endDate.IsZero() || t.After(endDate) {
+			endDate = t
+		}
+	}
+	if startDate.IsZero() {
+		return nil, false
+	}
+	anomalies, err := ws.scoreWeatherAnomalies(key, data, startDate, endDate, ClimatologyBaselineYears)
+	if err != nil {
+		log.Printf("⚠️ 平年値と比べられないため、期間の平均で異常気象を判定します: %v", err)
+		return nil, false
+	}
+
+	anomalous := []AnomalousWeather{}
+	for _, day := range anomalies {
+		for _, field := range day.Fields {
+			if !unusualWeatherField(field) {
+				continue
+			}
+			description := field.Label + " " + field.Description
+			if field.Record != "" {
+				description += "・" + field.Record
+			}
+			anomalous = append(anomalous, AnomalousWeather{
+				Date:        day.Date,
+				Type:        field.Field,
+				Value:       field.Value,
+				Threshold:   field.Normal,
+				Description: description,
+				Deviation:   field.Deviation,
+				Percentile:  field.Percentile,
+			})
+		}
+	}
+	return anomalous, true
+}
+
 // analyzeCorrelations 相関分析を実行
 func (ws *WeatherService) analyzeCorrelations(data []HistoricalWeatherData) map[string]float64 {
 	correlations := make(map[string]float64)
@@ -917,8 +912,17 @@ func (ws *WeatherService) generateInsights(data []HistoricalWeatherData) []strin
 func (ws *WeatherService) evaluateDataQuality(data []HistoricalWeatherData) DataQualityMetrics {
 	totalPoints := len(data)
 	validPoints := 0
+	imputedPoints := 0
+	flaggedPoints := 0
 
 	for _, item := range data {
+		if item.IsImputed() {
+			imputedPoints++
+			continue
+		}
+		if len(item.QualityFlags) > 0 {
+			flaggedPoints++
+		}
 		if item.Temperature > -50 && item.Temperature < 50 &&
 			item.Humidity >= 0 && item.Humidity <= 100 &&
 			item.Pressure > 900 && item.Pressure < 1100 {
@@ -926,11 +930,17 @@ func (ws *WeatherService) evaluateDataQuality(data []HistoricalWeatherData) Data
 		}
 	}
 
+	var rate float64
+	if totalPoints > 0 {
+		rate = float64(validPoints) / float64(totalPoints) * 100
+	}
 	return DataQualityMetrics{
 		TotalDataPoints: totalPoints,
 		ValidDataPoints: validPoints,
 		MissingData:     totalPoints - validPoints,
-		DataQualityRate: float64(validPoints) / float64(totalPoints) * 100,
+		ImputedData:     imputedPoints,
+		FlaggedData:     flaggedPoints,
+		DataQualityRate: rate,
 	}
 }
 
@@ -1219,15 +1229,6 @@ func NewOpenWeatherMapService(apiKey, baseURL string) *OpenWeatherMapService {
 	}
 }
 
-// SuzukaCoordinates 鈴鹿市の座標
-var SuzukaCoordinates = struct {
-	Lat float64
-	Lon float64
-}{
-	Lat: 34.8820,
-	Lon: 136.5856,
-}
-
 // GetHistoricalWeatherFromOpenWeatherMap OpenWeatherMapから実際の過去データを取得
 func (ows *OpenWeatherMapService) GetHistoricalWeatherFromOpenWeatherMap(lat, lon float64, date time.Time) (*HistoricalWeatherData, error) {
 	timestamp := date.Unix()
@@ -1287,33 +1288,8 @@ func getWindDirection(deg int) string {
 	return directions[index]
 }
 
-// GetRealHistoricalWeatherData 実際の過去データを取得（OpenWeatherMapを使用）
+// GetRealHistoricalWeatherData 実際の過去データを取得
+// 取得元は WEATHER_PROVIDERS の優先順に従う（OpenWeatherMap を使う場合はその名前を含める）
 func (ws *WeatherService) GetRealHistoricalWeatherData(regionCode string, startDate, endDate time.Time) ([]HistoricalWeatherData, error) {
-	// OpenWeatherMapサービスのインスタンスを作成
-	// 実際の使用時は環境変数からAPIキーを読み込み
-	apiKey := "YOUR_OPENWEATHERMAP_API_KEY" // 環境変数から取得すべき
-	if apiKey == "YOUR_OPENWEATHERMAP_API_KEY" {
-		// APIキーが設定されていない場合は従来の模擬データを返す
-		return ws.GetHistoricalWeatherData(regionCode, startDate, endDate)
-	}
-
-	owmService := NewOpenWeatherMapService(apiKey, "https://api.openweathermap.org/data/2.5")
-
-	var historicalData []HistoricalWeatherData
-
-	// 日付ごとにデータを取得
-	for date := startDate; date.Before(endDate) || date.Equal(endDate); date = date.AddDate(0, 0, 1) {
-		data, err := owmService.GetHistoricalWeatherFromOpenWeatherMap(SuzukaCoordinates.Lat, SuzukaCoordinates.Lon, date)
-		if err != nil {
-			log.Printf("OpenWeatherMapデータ取得エラー (%s): %v", date.Format("2006-01-02"), err)
-			// エラーの場合は模擬データを使用
-			mockData := ws.generateMockHistoricalData(regionCode, date)
-			historicalData = append(historicalData, mockData...)
-			continue
-		}
-
-		historicalData = append(historicalData, *data)
-	}
-
-	return historicalData, nil
+	return ws.GetHistoricalWeatherData(regionCode, startDate, endDate)
 }
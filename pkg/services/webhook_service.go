@@ -0,0 +1,458 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+
+	"github.com/google/uuid"
+)
+
+// webhookEvent 異常検知の通知イベント名
+const webhookEvent = "anomaly.detected"
+
+var (
+	// ErrWebhookSubscriptionNotFound 指定したIDのWebhook購読が登録されていない
+	ErrWebhookSubscriptionNotFound = errors.New("Webhook購読が見つかりません")
+	// ErrWebhookDeliveryNotFound 指定したIDのWebhook配信（デッドレター）が保存されていない
+	ErrWebhookDeliveryNotFound = errors.New("Webhook配信が見つかりません")
+	// ErrWebhookAlreadyReplayed 再送済みの配信をもう一度再送しようとした
+	ErrWebhookAlreadyReplayed = errors.New("この配信は再送済みです")
+	// ErrInvalidWebhookRequest Webhook購読の指定が不正
+	ErrInvalidWebhookRequest = errors.New("Webhook購読の指定が不正です")
+)
+
+// WebhookConfig Webhook配信の設定
+type WebhookConfig struct {
+	MaxAttempts    int           // 1回の配信で試行する最大回数（初回を含む）
+	InitialBackoff time.Duration // 初回リトライまでの待機時間（以降は2倍ずつ増やす）
+	MaxBackoff     time.Duration // 待機時間の上限
+	Timeout        time.Duration // 1回のHTTPリクエストのタイムアウト
+}
+
+// WebhookService 異常をWebhook購読先へ署名付きで配信し、失敗した配信をデッドレターとして保存する
+type WebhookService struct {
+	vectorStoreService *VectorStoreService
+	config             WebhookConfig
+	client             *http.Client
+	sleep              func(time.Duration) // テストで待機を省略するために差し替え可能
+}
+
+// WebhookDeliveryResult 1件の配信結果
+type WebhookDeliveryResult struct {
+	SubscriptionID string `json:"subscription_id"`
+	Attempts       int    `json:"attempts"`
+	StatusCode     int    `json:"status_code,omitempty"`
+	Error          string `json:"error,omitempty"`
+	Delivered      bool   `json:"delivered"`
+}
+
+// NewWebhookService 新しいWebhookサービスを作成（0以下の設定値は既定値を使用）
+func NewWebhookService(vectorStoreService *VectorStoreService, config WebhookConfig) *WebhookService {
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = 2 * time.Second
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 5 * time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	return &WebhookService{
+		vectorStoreService: vectorStoreService,
+		config:             config,
+		client:             &http.Client{Timeout: config.Timeout},
+		sleep:              time.Sleep,
+	}
+}
+
+// Available 購読・デッドレターを保存するデータベースが利用できるか
+func (s *WebhookService) Available() bool {
+	return s != nil && s.vectorStoreService != nil
+}
+
+// ListSubscriptions Webhook購読の一覧を取得
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.vectorStoreService.ListWebhookSubscriptions(ctx)
+}
+
+// CreateSubscription Webhook購読を作成
+func (s *WebhookService) CreateSubscription(ctx context.Context, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	now := time.Now().Format(time.RFC3339)
+	subscription := models.WebhookSubscription{ID: uuid.New().String(), CreatedAt: now}
+	if err := applyWebhookSubscriptionRequest(&subscription, req); err != nil {
+		return nil, err
+	}
+	subscription.UpdatedAt = now
+	if err := s.vectorStoreService.SaveWebhookSubscription(ctx, subscription); err != nil {
+		return nil, err
+	}
+	log.Printf("🔔 Webhook購読を作成しました: %s (%s)", subscription.Name, subscription.URL)
+	return &subscription, nil
+}
+
+// UpdateSubscription Webhook購読を更新（secretが空の場合は既存の鍵を保持）
+func (s *WebhookService) UpdateSubscription(ctx context.Context, id string, req models.WebhookSubscriptionRequest) (*models.WebhookSubscription, error) {
+	subscription, err := s.vectorStoreService.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyWebhookSubscriptionRequest(subscription, req); err != nil {
+		return nil, err
+	}
+	subscription.UpdatedAt = time.Now().Format(time.RFC3339)
+	if err := s.vectorStoreService.SaveWebhookSubscription(ctx, *subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// DeleteSubscription Webhook購読を削除
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	if _, err := s.vectorStoreService.GetWebhookSubscription(ctx, id); err != nil {
+		return err
+	}
+	return s.vectorStoreService.DeleteWebhookSubscription(ctx, id)
+}
+
+// ListDeadLetters 配信に失敗したWebhookを取得
+func (s *WebhookService) ListDeadLetters(ctx context.Context, status, subscriptionID string) ([]models.WebhookDelivery, error) {
+	return s.vectorStoreService.ListWebhookDeliveries(ctx, status, subscriptionID)
+}
+
+// NotifyAsync 購読条件に一致する異常を、購読ごとにまとめてバックグラウンドで配信する
+func (s *WebhookService) NotifyAsync(source string, notifications []models.AnomalyNotification) {
+	if !s.Available() || len(notifications) == 0 {
+		return
+	}
+	go func() {
+		if _, err := s.Notify(context.Background(), source, notifications); err != nil {
+			log.Printf("⚠️ 異常のWebhook通知に失敗 (%s): %v", source, err)
+		}
+	}()
+}
+
+// Notify 購読条件に一致する異常を購読ごとにまとめて配信する（リトライを使い切った配信はデッドレターに保存）
+func (s *WebhookService) Notify(ctx context.Context, source string, notifications []models.AnomalyNotification) ([]WebhookDeliveryResult, error) {
+	subscriptions, err := s.vectorStoreService.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]WebhookDeliveryResult, 0)
+	for _, subscription := range subscriptions {
+		matched := make([]models.AnomalyNotification, 0)
+		for _, notification := range notifications {
+			if MatchesWebhookSubscription(subscription, notification) {
+				matched = append(matched, notification)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		payload, err := BuildWebhookPayload(subscription.Format, source, matched, now)
+		if err != nil {
+			return results, err
+		}
+		result := s.deliverWithRetry(ctx, subscription, payload)
+		results = append(results, result)
+		if result.Delivered {
+			log.Printf("🔔 %d件の異常を %s に通知しました (%s)", len(matched), subscription.Name, source)
+			continue
+		}
+		s.saveDeadLetter(ctx, subscription, source, payload, result)
+	}
+	return results, nil
+}
+
+// SendTest サンプルの異常を1回だけ配信して購読先の設定を確認する（デッドレターには残さない）
+func (s *WebhookService) SendTest(ctx context.Context, id string) (*WebhookDeliveryResult, error) {
+	subscription, err := s.vectorStoreService.GetWebhookSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sample := []models.AnomalyNotification{{
+		Date:          time.Now().Format("2006-01-02"),
+		ProductID:     "TEST",
+		ProductName:   "テスト製品",
+		AnomalyType:   "急増",
+		Severity:      "critical",
+		ActualValue:   150,
+		ExpectedValue: 100,
+		Deviation:     50,
+		Description:   "Webhook設定の確認用の通知です",
+	}}
+	payload, err := BuildWebhookPayload(subscription.Format, "test", sample, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	statusCode, err := s.post(ctx, subscription.URL, subscription.Secret, uuid.New().String(), payload)
+	result := &WebhookDeliveryResult{SubscriptionID: subscription.ID, Attempts: 1, StatusCode: statusCode, Delivered: err == nil}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, nil
+}
+
+// Replay デッドレターを再配信し、成功したら replayed にする（署名とタイムスタンプは再配信時のもので付け直す）
+func (s *WebhookService) Replay(ctx context.Context, deliveryID string) (*models.WebhookDelivery, error) {
+	delivery, err := s.vectorStoreService.GetWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status == "replayed" {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookAlreadyReplayed, deliveryID)
+	}
+
+	// 購読が削除されていても、保存したURLへ署名なしで再送できるようにする
+	subscription := models.WebhookSubscription{ID: delivery.SubscriptionID, URL: delivery.URL}
+	if stored, err := s.vectorStoreService.GetWebhookSubscription(ctx, delivery.SubscriptionID); err == nil {
+		subscription = *stored
+	}
+
+	// 管理画面からの操作なので待たせないよう1回だけ送る（失敗しても再度replayできる）
+	statusCode, postErr := s.post(ctx, subscription.URL, subscription.Secret, delivery.ID, []byte(delivery.Payload))
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	if postErr != nil {
+		delivery.LastError = postErr.Error()
+	} else {
+		delivery.Status = "replayed"
+		delivery.ReplayedAt = time.Now().Format(time.RFC3339)
+	}
+	if err := s.vectorStoreService.SaveWebhookDelivery(ctx, *delivery); err != nil {
+		return nil, err
+	}
+	if postErr != nil {
+		return delivery, fmt.Errorf("再送に失敗しました: %w", postErr)
+	}
+	log.Printf("🔁 Webhookを再送しました: %s → %s", delivery.ID, delivery.URL)
+	return delivery, nil
+}
+
+// deliverWithRetry 指数バックオフでリトライしながら配信する（4xx（429を除く）は受信側の設定誤りとみなしてリトライしない）
+func (s *WebhookService) deliverWithRetry(ctx context.Context, subscription models.WebhookSubscription, payload []byte) WebhookDeliveryResult {
+	result := WebhookDeliveryResult{SubscriptionID: subscription.ID}
+	deliveryID := uuid.New().String()
+	for attempt := 1; attempt <= s.config.MaxAttempts; attempt++ {
+		result.Attempts = attempt
+		statusCode, err := s.post(ctx, subscription.URL, subscription.Secret, deliveryID, payload)
+		result.StatusCode = statusCode
+		if err == nil {
+			result.Delivered = true
+			result.Error = ""
+			return result
+		}
+		result.Error = err.Error()
+		if !isRetryableWebhookStatus(statusCode) || attempt == s.config.MaxAttempts || ctx.Err() != nil {
+			break
+		}
+		wait := WebhookBackoff(attempt, s.config.InitialBackoff, s.config.MaxBackoff)
+		log.Printf("⚠️ Webhook配信に失敗 (%s, %d/%d回目): %v。%v後に再試行します", subscription.URL, attempt, s.config.MaxAttempts, err, wait)
+		s.sleep(wait)
+	}
+	return result
+}
+
+// post 署名ヘッダーを付けてペイロードを送信し、ステータスコードを返す（2xx以外はエラー）
+func (s *WebhookService) post(ctx context.Context, targetURL, secret, deliveryID string, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("リクエストの作成に失敗: %w", err)
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "HUNT-Chat-API-Webhook/1.0")
+	req.Header.Set("X-Hunt-Event", webhookEvent)
+	req.Header.Set("X-Hunt-Delivery", deliveryID)
+	req.Header.Set("X-Hunt-Timestamp", timestamp)
+	if secret != "" {
+		req.Header.Set("X-Hunt-Signature", SignWebhookPayload(secret, timestamp, payload))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("送信に失敗: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("受信側が %d を返しました", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// saveDeadLetter リトライを使い切った配信をデッドレターとして保存
+func (s *WebhookService) saveDeadLetter(ctx context.Context, subscription models.WebhookSubscription, source string, payload []byte, result WebhookDeliveryResult) {
+	delivery := models.WebhookDelivery{
+		ID:             uuid.New().String(),
+		SubscriptionID: subscription.ID,
+		URL:            subscription.URL,
+		Event:          webhookEvent,
+		Source:         source,
+		Payload:        string(payload),
+		Status:         "dead",
+		Attempts:       result.Attempts,
+		LastStatusCode: result.StatusCode,
+		LastError:      result.Error,
+		CreatedAt:      time.Now().Format(time.RFC3339),
+	}
+	if err := s.vectorStoreService.SaveWebhookDelivery(ctx, delivery); err != nil {
+		log.Printf("❌ デッドレターの保存に失敗 (%s): %v", subscription.URL, err)
+		return
+	}
+	log.Printf("📮 Webhook配信を%d回試行しましたが失敗したため、デッドレターに保存しました: %s (%s)", result.Attempts, subscription.URL, result.Error)
+}
+
+// isRetryableWebhookStatus 再試行すべき結果か（通信エラー・5xx・429）
+func isRetryableWebhookStatus(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// SignWebhookPayload "タイムスタンプ.本文" のHMAC-SHA256署名を "sha256=<hex>" 形式で返す
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookBackoff attempt回目の失敗後に待つ時間（initial × 2^(attempt-1)、上限 maxWait）
+func WebhookBackoff(attempt int, initial, maxWait time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	wait := float64(initial) * math.Pow(2, float64(attempt-1))
+	if wait > float64(maxWait) {
+		return maxWait
+	}
+	return time.Duration(wait)
+}
+
+// MatchesWebhookSubscription 異常が購読の条件（有効・深刻度・製品・異常の種類）に一致するか
+func MatchesWebhookSubscription(subscription models.WebhookSubscription, notification models.AnomalyNotification) bool {
+	if !subscription.Enabled || !containsString(subscription.Severities, notification.Severity) {
+		return false
+	}
+	if len(subscription.ProductIDs) > 0 && !containsString(subscription.ProductIDs, notification.ProductID) {
+		return false
+	}
+	if len(subscription.AnomalyTypes) > 0 && !containsString(subscription.AnomalyTypes, notification.AnomalyType) {
+		return false
+	}
+	return true
+}
+
+// BuildWebhookPayload 購読の形式に合わせて通知本文を作る（slack: Incoming Webhook互換 / generic: 異常の一覧をそのまま含むJSON）
+func BuildWebhookPayload(format, source string, notifications []models.AnomalyNotification, now time.Time) ([]byte, error) {
+	if format != "slack" {
+		return json.Marshal(map[string]interface{}{
+			"event":     webhookEvent,
+			"source":    source,
+			"sent_at":   now.Format(time.RFC3339),
+			"count":     len(notifications),
+			"anomalies": notifications,
+		})
+	}
+
+	summary := fmt.Sprintf(":rotating_light: %d件の異常を検出しました（%s）", len(notifications), source)
+	blocks := []map[string]interface{}{
+		{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": "*" + summary + "*"}},
+	}
+	for _, notification := range notifications {
+		product := notification.ProductName
+		if product == "" {
+			product = notification.ProductID
+		}
+		line := fmt.Sprintf("*%s* %s %s（深刻度: %s）", notification.Date, product, notification.AnomalyType, notification.Severity)
+		if notification.ExpectedValue != 0 {
+			line += fmt.Sprintf("\n実績 %.1f / 予測 %.1f", notification.ActualValue, notification.ExpectedValue)
+		}
+		if notification.Description != "" {
+			line += "\n" + notification.Description
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": line}})
+	}
+	return json.Marshal(map[string]interface{}{"text": summary, "blocks": blocks})
+}
+
+// NotificationsFromDetections 分析レポートの異常を通知に変換
+func NotificationsFromDetections(detections []models.AnomalyDetection) []models.AnomalyNotification {
+	notifications := make([]models.AnomalyNotification, 0, len(detections))
+	for _, detection := range detections {
+		notifications = append(notifications, models.AnomalyNotification{
+			AnomalyID:     detection.AnomalyID,
+			Date:          detection.Date,
+			ProductID:     detection.ProductID,
+			ProductName:   detection.ProductName,
+			AnomalyType:   detection.AnomalyType,
+			Severity:      detection.Severity,
+			ActualValue:   detection.ActualValue,
+			ExpectedValue: detection.ExpectedValue,
+			Deviation:     detection.Deviation,
+		})
+	}
+	return notifications
+}
+
+// applyWebhookSubscriptionRequest リクエストを検証してWebhook購読に反映する
+func applyWebhookSubscriptionRequest(subscription *models.WebhookSubscription, req models.WebhookSubscriptionRequest) error {
+	parsed, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: urlには http(s):// から始まるURLを指定してください", ErrInvalidWebhookRequest)
+	}
+	format := req.Format
+	if format == "" {
+		format = "generic"
+	}
+	if !containsString(models.WebhookFormats, format) {
+		return fmt.Errorf("%w: formatは %s のいずれかを指定してください", ErrInvalidWebhookRequest, strings.Join(models.WebhookFormats, "/"))
+	}
+	severities := req.Severities
+	if len(severities) == 0 {
+		severities = []string{"critical"}
+	}
+	for _, severity := range severities {
+		if !containsString([]string{"low", "medium", "high", "critical"}, severity) {
+			return fmt.Errorf("%w: severitiesには low/medium/high/critical を指定してください", ErrInvalidWebhookRequest)
+		}
+	}
+
+	subscription.Name = strings.TrimSpace(req.Name)
+	if subscription.Name == "" {
+		subscription.Name = parsed.Host
+	}
+	subscription.URL = parsed.String()
+	if req.Secret != "" {
+		subscription.Secret = req.Secret
+	}
+	subscription.HasSecret = subscription.Secret != ""
+	subscription.Format = format
+	subscription.Severities = severities
+	subscription.ProductIDs = req.ProductIDs
+	subscription.AnomalyTypes = req.AnomalyTypes
+	subscription.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hunt-chat-api/pkg/models"
+)
+
+func TestSignWebhookPayloadVerifiedByReceiver(t *testing.T) {
+	var gotSignature, gotTimestamp string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get("X-Hunt-Signature")
+		gotTimestamp = r.Header.Get("X-Hunt-Timestamp")
+		gotBody, _ = io.ReadAll(r.Body)
+		if r.Header.Get("X-Hunt-Event") != webhookEvent {
+			t.Errorf("X-Hunt-Event = %q", r.Header.Get("X-Hunt-Event"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	service := NewWebhookService(nil, WebhookConfig{})
+	subscription := models.WebhookSubscription{ID: "s1", URL: server.URL, Secret: "top-secret"}
+	result := service.deliverWithRetry(context.Background(), subscription, []byte(`{"event":"anomaly.detected"}`))
+	if !result.Delivered || result.Attempts != 1 {
+		t.Fatalf("Result = %+v", result)
+	}
+
+	// 受信側は同じ鍵・タイムスタンプ・本文から署名を再計算して照合できる
+	if expected := SignWebhookPayload("top-secret", gotTimestamp, gotBody); gotSignature != expected {
+		t.Errorf("Signature = %q, expected %q", gotSignature, expected)
+	}
+	if SignWebhookPayload("other", gotTimestamp, gotBody) == gotSignature {
+		t.Error("Signature should depend on the secret")
+	}
+}
+
+func TestWebhookDeliveryRetries(t *testing.T) {
+	statuses := []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusOK}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statuses[calls])
+		calls++
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	service := NewWebhookService(nil, WebhookConfig{MaxAttempts: 5, InitialBackoff: time.Second})
+	service.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	result := service.deliverWithRetry(context.Background(), models.WebhookSubscription{URL: server.URL}, []byte(`{}`))
+	if !result.Delivered || result.Attempts != 3 || result.StatusCode != http.StatusOK {
+		t.Fatalf("Result = %+v", result)
+	}
+	if len(waits) != 2 || waits[0] != time.Second || waits[1] != 2*time.Second {
+		t.Errorf("Waits = %v, expected [1s 2s]", waits)
+	}
+
+	// 429以外の4xxは再試行しない
+	calls = 0
+	statuses = []int{http.StatusBadRequest, http.StatusOK}
+	result = service.deliverWithRetry(context.Background(), models.WebhookSubscription{URL: server.URL}, []byte(`{}`))
+	if result.Delivered || result.Attempts != 1 || result.StatusCode != http.StatusBadRequest {
+		t.Errorf("Client error result = %+v", result)
+	}
+
+	// 試行回数を使い切ったら失敗として返す
+	calls = 0
+	statuses = []int{500, 500, 500, 500, 500}
+	result = service.deliverWithRetry(context.Background(), models.WebhookSubscription{URL: server.URL}, []byte(`{}`))
+	if result.Delivered || result.Attempts != 5 || result.Error == "" {
+		t.Errorf("Exhausted result = %+v", result)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cases := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{4, 16 * time.Second},
+		{10, time.Minute},
+	}
+	for _, tc := range cases {
+		if got := WebhookBackoff(tc.attempt, 2*time.Second, time.Minute); got != tc.expected {
+			t.Errorf("WebhookBackoff(%d) = %v, expected %v", tc.attempt, got, tc.expected)
+		}
+	}
+}
+
+func TestMatchesWebhookSubscription(t *testing.T) {
+	critical := models.AnomalyNotification{ProductID: "P001", AnomalyType: "急増", Severity: "critical"}
+	subscription := models.WebhookSubscription{Enabled: true, Severities: []string{"critical"}}
+
+	if !MatchesWebhookSubscription(subscription, critical) {
+		t.Error("Critical anomaly should match the default subscription")
+	}
+	if MatchesWebhookSubscription(subscription, models.AnomalyNotification{ProductID: "P001", Severity: "high"}) {
+		t.Error("High severity should not match a critical-only subscription")
+	}
+
+	filtered := subscription
+	filtered.ProductIDs = []string{"P002"}
+	if MatchesWebhookSubscription(filtered, critical) {
+		t.Error("Product filter should exclude other products")
+	}
+	filtered = subscription
+	filtered.AnomalyTypes = []string{"急減"}
+	if MatchesWebhookSubscription(filtered, critical) {
+		t.Error("Anomaly type filter should exclude other types")
+	}
+	disabled := subscription
+	disabled.Enabled = false
+	if MatchesWebhookSubscription(disabled, critical) {
+		t.Error("Disabled subscription should not match")
+	}
+}
+
+func TestBuildWebhookPayload(t *testing.T) {
+	notifications := []models.AnomalyNotification{
+		{Date: "2024-06-10", ProductID: "P001", ProductName: "冷たい飲料", AnomalyType: "急増", Severity: "critical", ActualValue: 180, ExpectedValue: 100},
+	}
+	now := time.Date(2024, 6, 11, 9, 0, 0, 0, time.UTC)
+
+	raw, err := BuildWebhookPayload("generic", "file_analysis", notifications, now)
+	if err != nil {
+		t.Fatalf("Generic payload failed: %v", err)
+	}
+	var generic struct {
+		Event     string                       `json:"event"`
+		Source    string                       `json:"source"`
+		SentAt    string                       `json:"sent_at"`
+		Anomalies []models.AnomalyNotification `json:"anomalies"`
+	}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		t.Fatalf("Generic payload is not JSON: %v", err)
+	}
+	if generic.Event != webhookEvent || generic.Source != "file_analysis" || generic.SentAt != "2024-06-11T09:00:00Z" || len(generic.Anomalies) != 1 {
+		t.Errorf("Generic payload = %+v", generic)
+	}
+
+	raw, err = BuildWebhookPayload("slack", "file_analysis", notifications, now)
+	if err != nil {
+		t.Fatalf("Slack payload failed: %v", err)
+	}
+	var slack struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(raw, &slack); err != nil {
+		t.Fatalf("Slack payload is not JSON: %v", err)
+	}
+	if slack.Text == "" || len(slack.Blocks) != 2 || slack.Blocks[1].Text.Type != "mrkdwn" || !strings.Contains(slack.Blocks[1].Text.Text, "冷たい飲料") {
+		t.Errorf("Slack payload = %s", raw)
+	}
+}
+
+func TestApplyWebhookSubscriptionRequest(t *testing.T) {
+	subscription := models.WebhookSubscription{Secret: "kept"}
+	if err := applyWebhookSubscriptionRequest(&subscription, models.WebhookSubscriptionRequest{URL: "https://hooks.example.com/a"}); err != nil {
+		t.Fatalf("Valid request failed: %v", err)
+	}
+	if subscription.Format != "generic" || len(subscription.Severities) != 1 || subscription.Severities[0] != "critical" || !subscription.Enabled {
+		t.Errorf("Defaults = %+v", subscription)
+	}
+	if subscription.Secret != "kept" || !subscription.HasSecret || subscription.Name != "hooks.example.com" {
+		t.Errorf("Secret/name = %+v", subscription)
+	}
+
+	invalid := []models.WebhookSubscriptionRequest{
+		{URL: "ftp://example.com"},
+		{URL: "https://example.com", Format: "teams"},
+		{URL: "https://example.com", Severities: []string{"urgent"}},
+	}
+	for _, req := range invalid {
+		if err := applyWebhookSubscriptionRequest(&subscription, req); !errors.Is(err, ErrInvalidWebhookRequest) {
+			t.Errorf("Expected validation error for %+v", req)
+		}
+	}
+}